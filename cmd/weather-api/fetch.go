@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"weather-api/config"
+	v1 "weather-api/internal/controllers/http/v1"
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+)
+
+const (
+	fetchFormatJSON  = "json"
+	fetchFormatCSV   = "csv"
+	fetchFormatTable = "table"
+)
+
+// fetch prints the forecast of a location from the configured providers, it fails when every provider failed
+func fetch(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("fetch", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", defaultConfigPath, "configuration file")
+	lat := flags.Float64("lat", math.NaN(), "latitude of the location, required")
+	lon := flags.Float64("lon", math.NaN(), "longitude of the location, required")
+	days := flags.Int("days", 5, "days of the forecast")
+	providers := flags.String("providers", "", "comma separated providers, all of them when empty")
+	format := flags.String("format", fetchFormatTable, "output format: json, csv or table")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	var problems []string
+	if math.IsNaN(*lat) || *lat < -90 || *lat > 90 {
+		problems = append(problems, "--lat is required, between -90 and 90")
+	}
+	if math.IsNaN(*lon) || *lon < -180 || *lon > 180 {
+		problems = append(problems, "--lon is required, between -180 and 180")
+	}
+	if *days < 1 {
+		problems = append(problems, "--days must be positive")
+	}
+	switch *format {
+	case fetchFormatJSON, fetchFormatCSV, fetchFormatTable:
+	default:
+		problems = append(problems, "--format must be one of: json, csv, table")
+	}
+	if len(problems) > 0 {
+		fmt.Fprintln(stderr, strings.Join(problems, "\n"))
+		return exitUsage
+	}
+
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(*configPath))
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load configuration: %v\n", err)
+		return exitFailure
+	}
+
+	// The logs go to stderr, stdout only has the forecast
+	l := logger.NewZapLoggerWithOptions(cnf.App.Name, loggerOptions(cnf), stderr)
+	defer func() { _ = l.Stop() }()
+
+	service, err := newWeatherService(cnf, l)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to initialize the weather service: %v\n", err)
+		return exitFailure
+	}
+	if maxDays := service.MaxForecastDays(); maxDays > 0 && *days > maxDays {
+		fmt.Fprintf(stderr, "--days must be at most %d\n", maxDays)
+		return exitUsage
+	}
+
+	var selected []string
+	if *providers != "" {
+		selected = strings.Split(*providers, ",")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), service.RequestBudget())
+	defer cancel()
+
+	forecasts, err := service.FetchProviderForecasts(ctx, *lat, *lon, *days, selected)
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to fetch the forecast: %v\n", err)
+		return exitFailure
+	}
+
+	if err := writeForecasts(stdout, *format, forecasts); err != nil {
+		fmt.Fprintf(stderr, "Failed to write the forecast: %v\n", err)
+		return exitFailure
+	}
+
+	return reportFailures(stderr, forecasts)
+}
+
+// writeForecasts writes the forecasts as the JSON or CSV response of /weather, or as an aligned table
+func writeForecasts(w io.Writer, format string, forecasts map[string]models.Forecast) error {
+	switch format {
+	case fetchFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(forecasts)
+	case fetchFormatCSV:
+		cw := csv.NewWriter(w)
+		return cw.WriteAll(v1.ForecastRows(forecasts))
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range v1.ForecastRows(forecasts) {
+		fmt.Fprintln(tw, strings.Join(row, "\t"))
+	}
+
+	return tw.Flush()
+}
+
+// reportFailures lists the failed providers on w, the fetch fails when none returned a forecast
+func reportFailures(w io.Writer, forecasts map[string]models.Forecast) int {
+	var failed []string
+	for name, forecast := range forecasts {
+		if forecast.Error != "" {
+			failed = append(failed, name)
+		}
+	}
+	sort.Strings(failed)
+
+	for _, name := range failed {
+		fmt.Fprintf(w, "provider %s failed: %s\n", name, forecasts[name].Error)
+	}
+	if len(failed) == len(forecasts) {
+		return exitFailure
+	}
+
+	return exitOK
+}
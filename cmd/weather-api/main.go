@@ -1,21 +1,39 @@
 package main
 
 import (
-	"context"
 	"fmt"
+	"io"
 	"os"
-	"os/signal"
-	"syscall"
-	"time"
-
-	"weather-api/config"
-	v1 "weather-api/internal/controllers/http/v1"
-	"weather-api/internal/repositories"
-	"weather-api/internal/services/weather"
-	"weather-api/pkg/httpserver"
-	"weather-api/pkg/logger"
+	"strings"
 )
 
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitUsage   = 2
+
+	defaultConfigPath = "config/config.yaml"
+)
+
+const usage = `Usage: weather-api [command] [flags]
+
+Commands:
+  serve            run the API servers, the default without a command
+  fetch            print the forecast of a location without starting the servers
+  validate-config  check a configuration file and list its problems
+
+Run weather-api <command> -h for the flags of a command.
+`
+
+// command runs a subcommand with its arguments and returns the exit code of the process
+type command func(args []string, stdout, stderr io.Writer) int
+
+var commands = map[string]command{
+	"serve":           serve,
+	"fetch":           fetch,
+	"validate-config": validateConfig,
+}
+
 // @title Weather API
 // @version 1.0.0
 // @description A high-performance, multi-provider weather forecast API built with Go and Fiber.
@@ -30,70 +48,49 @@ import (
 // @license.url https://opensource.org/licenses/MIT
 
 // @host localhost:8080
-// @BasePath /
+// @BasePath /v1
 // @schemes http https
 
+// @securityDefinitions.apikey APIKey
+// @in header
+// @name X-API-Key
+// @description Required when API keys are enabled in the configuration, also accepted in the api_key query parameter
+
 // @tag.name Weather
 // @tag.description Weather forecast operations
-func main() {
-	ctx, cancel := context.WithCancel(context.Background())
 
-	// Load configuration with proper error handling
-	cnf, err := config.NewConfig()
-	if err != nil {
-		fmt.Printf("Failed to load configuration: %v\n", err)
-		os.Exit(1)
-	}
+// @tag.name Geocoding
+// @tag.description Place name lookups
 
-	l := logger.NewZapLogger(cnf.App.Name, os.Stdout)
+// @tag.name Air Quality
+// @tag.description Air quality forecasts
 
-	app := httpserver.InitFiberServer(cnf.App.Name)
+// @tag.name Version
+// @tag.description Build information of the deployed instance
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
 
-	repos, err := repositories.InitWeatherRepositories(cnf, l)
-	if err != nil {
-		l.Fatal("failed to initialize weather repositories", map[string]any{"err": err})
-		os.Exit(1)
+// run dispatches the arguments to their subcommand, the arguments starting with a flag are served
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		return serve(args, stdout, stderr)
 	}
 
-	service := weather.NewWeatherService(repos, l)
-
-	v1.NewRouter(
-		app,
-		service,
-		l,
-	)
-
-	go func() {
-		if err := app.Listen(":" + cnf.Server.Port); err != nil {
-			l.Fatal("cannot run the server", map[string]any{"err": err})
-		}
-	}()
-
-	l.Info("starting application", map[string]any{
-		"port": cnf.Server.Port,
-		"env":  cnf.App.Env,
-		"name": cnf.App.Name,
-	})
-
-	sigCh := make(chan os.Signal, 2)
-	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
-	defer func() {
-		l.Warning("stopping application services")
-		signal.Stop(sigCh)
-		close(sigCh)
-
-		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
-		defer shutdownCancel()
-
-		_ = app.ShutdownWithContext(shutdownCtx)
-		_ = l.Stop()
-		cancel()
-	}()
-
-	select {
-	case <-sigCh:
-		fmt.Println("received shutdown signal")
-	case <-ctx.Done():
-		fmt.Println("context cancelled")
+	switch args[0] {
+	case "help", "-h", "--help":
+		fmt.Fprint(stdout, usage)
+		return exitOK
 	}
+	if strings.HasPrefix(args[0], "-") {
+		return serve(args, stdout, stderr)
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		fmt.Fprintf(stderr, "unknown command %q\n\n%s", args[0], usage)
+		return exitUsage
+	}
+
+	return cmd(args[1:], stdout, stderr)
 }
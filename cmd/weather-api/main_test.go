@@ -0,0 +1,576 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/config"
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/pkg/httpserver"
+	"weather-api/pkg/logger"
+)
+
+const testConfig = `app:
+  name: weather-api
+  version: 1.0.0
+server:
+  port: "8080"
+  read_timeout: 10
+  write_timeout: 10
+  idle_timeout: 120
+weather:
+  http_mode: replay
+  fixtures_dir: %s
+  apis:
+    - name: open-meteo
+      timeout: 5
+log:
+  level: info
+  format: json
+`
+
+type stubHTTPClient struct {
+	body string
+}
+
+func (c stubHTTPClient) Do(*http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(strings.NewReader(c.body)),
+	}, nil
+}
+
+// writeReplayConfig records an Open-Meteo forecast of 2 days in Berlin and returns a configuration replaying it
+func writeReplayConfig(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	fixtures := filepath.Join(dir, "fixtures")
+	recorder, err := repositories.NewRecordingHTTPClient(repositories.HTTPModeRecord, fixtures, stubHTTPClient{
+		body: `{"daily": {"time": ["2025-07-25", "2025-07-26"], "temperature_2m_max": [25.5, 26.2], "temperature_2m_min": [15.2, 16.1]}}`,
+	})
+	require.NoError(t, err)
+	_, err = repositories.NewOpenMeteoRepository(logger.NopLogger{}, recorder).
+		FetchForecast(context.Background(), 52.52, 13.41, 2)
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(fmt.Sprintf(testConfig, fixtures)), 0o600))
+
+	return path
+}
+
+func runCommand(args ...string) (code int, stdout, stderr string) {
+	var out, errOut bytes.Buffer
+	code = run(args, &out, &errOut)
+
+	return code, out.String(), errOut.String()
+}
+
+func TestFetch_Formats(t *testing.T) {
+	configPath := writeReplayConfig(t)
+	args := []string{"fetch", "--config", configPath, "--lat", "52.52", "--lon", "13.41", "--days", "2"}
+
+	code, stdout, stderr := runCommand(append(args, "--format", "json")...)
+	require.Equal(t, exitOK, code, stderr)
+	var forecasts map[string]models.Forecast
+	require.NoError(t, json.Unmarshal([]byte(stdout), &forecasts))
+	require.Contains(t, forecasts, "open-meteo")
+	assert.Len(t, forecasts["open-meteo"].ForecastData, 2)
+	assert.Equal(t, 25.5, forecasts["open-meteo"].ForecastData[0].TempMax)
+
+	code, stdout, stderr = runCommand(append(args, "--format", "csv")...)
+	require.Equal(t, exitOK, code, stderr)
+	assert.Equal(t, "date,provider,temp_min,temp_max\n2025-07-25,open-meteo,15.2,25.5\n2025-07-26,open-meteo,16.1,26.2\n", stdout)
+
+	code, stdout, stderr = runCommand(args...)
+	require.Equal(t, exitOK, code, stderr)
+	lines := strings.Split(strings.TrimSpace(stdout), "\n")
+	require.Len(t, lines, 3)
+	assert.Equal(t, []string{"date", "provider", "temp_min", "temp_max"}, strings.Fields(lines[0]))
+	assert.Equal(t, []string{"2025-07-26", "open-meteo", "16.1", "26.2"}, strings.Fields(lines[2]))
+}
+
+func TestFetch_Failures(t *testing.T) {
+	configPath := writeReplayConfig(t)
+
+	tests := []struct {
+		name string
+		args []string
+		code int
+		err  string
+	}{
+		{
+			name: "missing location",
+			args: []string{"--config", configPath, "--lon", "13.41"},
+			code: exitUsage,
+			err:  "--lat is required",
+		},
+		{
+			name: "unknown format",
+			args: []string{"--config", configPath, "--lat", "52.52", "--lon", "13.41", "--format", "xml"},
+			code: exitUsage,
+			err:  "--format must be one of",
+		},
+		{
+			name: "unknown provider",
+			args: []string{"--config", configPath, "--lat", "52.52", "--lon", "13.41", "--providers", "unknown"},
+			code: exitFailure,
+			err:  "unknown",
+		},
+		{
+			name: "every provider failed",
+			args: []string{"--config", configPath, "--lat", "48.85", "--lon", "2.35", "--days", "2"},
+			code: exitFailure,
+			err:  "provider open-meteo failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _, stderr := runCommand(append([]string{"fetch"}, tt.args...)...)
+			assert.Equal(t, tt.code, code)
+			assert.Contains(t, stderr, tt.err)
+		})
+	}
+}
+
+func TestValidateConfig(t *testing.T) {
+	valid := writeReplayConfig(t)
+	code, stdout, stderr := runCommand("validate-config", "--file", valid)
+	assert.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "valid")
+
+	invalid := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(invalid, []byte("server:\n  port: \"8080\"\n  grpc_port: \"8080\"\nweather:\n  apis:\n    - timeout: 5\n"), 0o600))
+	code, _, stderr = runCommand("validate-config", "--file", invalid)
+	assert.Equal(t, exitFailure, code)
+	assert.Contains(t, stderr, "server.grpc_port must differ from server.port")
+	assert.Contains(t, stderr, "weather.apis[0].name is required")
+
+	code, _, _ = runCommand("validate-config", "--file", filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.Equal(t, exitFailure, code)
+}
+
+func TestRun_UnknownCommand(t *testing.T) {
+	code, _, stderr := runCommand("deploy")
+	assert.Equal(t, exitUsage, code)
+	assert.Contains(t, stderr, "Usage: weather-api")
+}
+
+func TestNewApplication(t *testing.T) {
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(writeReplayConfig(t)))
+	require.NoError(t, err)
+	cnf.Server.GRPCPort = "9090"
+
+	a, err := newApplication(cnf, logger.NopLogger{})
+	require.NoError(t, err)
+	assert.NotNil(t, a.grpc)
+	t.Cleanup(func() { a.grpc.Stop() })
+
+	resp, err := a.http.Test(httptest.NewRequest(http.MethodGet, "/v1/weather?lat=52.52&lon=13.41&days=2", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = a.http.Test(httptest.NewRequest(http.MethodGet, "/manage/ready", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = a.http.Test(httptest.NewRequest(http.MethodGet, "/providers", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "true", resp.Header.Get("Deprecation"))
+
+	a.readiness.Drain()
+	resp, err = a.http.Test(httptest.NewRequest(http.MethodGet, "/manage/ready", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
+
+func TestNewApplication_NoProviders(t *testing.T) {
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(writeReplayConfig(t)))
+	require.NoError(t, err)
+	disabled := false
+	cnf.Weather.APIs = []config.WeatherAPIConfig{{Name: "open-meteo", Timeout: 5, Enabled: &disabled}}
+
+	_, err = newApplication(cnf, logger.NopLogger{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "no weather provider configured")
+
+	cnf.Weather.APIs = []config.WeatherAPIConfig{{Name: "open-meteoo", Timeout: 5}}
+	_, err = newApplication(cnf, logger.NopLogger{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), `unknown weather provider "open-meteoo"`)
+}
+
+func TestNewApplication_Compression(t *testing.T) {
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(writeReplayConfig(t)))
+	require.NoError(t, err)
+	cnf.Server.CompressionMinBytes = 1
+	// The cached forecast is answered with the same body, and ETag, every time
+	cnf.Cache.Enabled = true
+
+	a, err := newApplication(cnf, logger.NopLogger{})
+	require.NoError(t, err)
+
+	get := func(target string, header map[string]string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		for k, v := range header {
+			req.Header.Set(k, v)
+		}
+		resp, err := a.http.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	get("/v1/weather?lat=52.52&lon=13.41&days=2", nil)
+	plain := get("/v1/weather?lat=52.52&lon=13.41&days=2", nil)
+	require.Equal(t, http.StatusOK, plain.StatusCode)
+	plainBody, err := io.ReadAll(plain.Body)
+	require.NoError(t, err)
+
+	compressed := get("/v1/weather?lat=52.52&lon=13.41&days=2", map[string]string{"Accept-Encoding": "gzip"})
+	require.Equal(t, http.StatusOK, compressed.StatusCode)
+	assert.Equal(t, "gzip", compressed.Header.Get("Content-Encoding"))
+	assert.Contains(t, compressed.Header.Get("Vary"), "Accept-Encoding")
+	assert.Equal(t, "W/"+plain.Header.Get("ETag"), compressed.Header.Get("ETag"))
+	gz, err := gzip.NewReader(compressed.Body)
+	require.NoError(t, err)
+	decoded, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	assert.Equal(t, string(plainBody), string(decoded))
+
+	// Both representations are revalidated with their ETag
+	notModified := get("/v1/weather?lat=52.52&lon=13.41&days=2", map[string]string{
+		"Accept-Encoding": "gzip",
+		"If-None-Match":   compressed.Header.Get("ETag"),
+	})
+	assert.Equal(t, http.StatusNotModified, notModified.StatusCode)
+
+	stream := get("/v1/weather?lat=52.52&lon=13.41&days=2&stream=true", map[string]string{"Accept-Encoding": "gzip"})
+	require.Equal(t, http.StatusOK, stream.StatusCode)
+	assert.Empty(t, stream.Header.Get("Content-Encoding"))
+}
+
+func TestNewApplication_TLS(t *testing.T) {
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(writeReplayConfig(t)))
+	require.NoError(t, err)
+	cnf.Server.Port = "8443"
+	cnf.Server.RedirectPort = "8080"
+	cnf.Server.AutocertHosts = []string{"weather.example.com"}
+	cnf.Server.AutocertCacheDir = t.TempDir()
+
+	a, err := newApplication(cnf, logger.NopLogger{})
+	require.NoError(t, err)
+	require.NotNil(t, a.tls)
+	require.NotNil(t, a.redirect)
+	assert.Equal(t, ":8080", a.redirect.Addr)
+
+	rec := httptest.NewRecorder()
+	a.redirect.Handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://weather.example.com/v1/weather", nil))
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "https://weather.example.com:8443/v1/weather", rec.Header().Get("Location"))
+}
+
+func TestNewApplication_TLSCertificateError(t *testing.T) {
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(writeReplayConfig(t)))
+	require.NoError(t, err)
+	cnf.Server.TLSCertFile = filepath.Join(t.TempDir(), "cert.pem")
+	cnf.Server.TLSKeyFile = filepath.Join(t.TempDir(), "key.pem")
+
+	_, err = newApplication(cnf, logger.NopLogger{})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TLS: cannot load the TLS certificate "+cnf.Server.TLSCertFile)
+}
+
+func TestNewApplication_ServerSettings(t *testing.T) {
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(writeReplayConfig(t)))
+	require.NoError(t, err)
+	cnf.Server.ReadTimeout = 7
+	cnf.Server.WriteTimeout = 9
+	cnf.Server.IdleTimeout = 60
+
+	a, err := newApplication(cnf, logger.NopLogger{})
+	require.NoError(t, err)
+	settings := a.http.Config()
+	assert.Equal(t, 7*time.Second, settings.ReadTimeout)
+	assert.Equal(t, 9*time.Second, settings.WriteTimeout)
+	assert.Equal(t, time.Minute, settings.IdleTimeout)
+	assert.Equal(t, httpserver.DefaultBodyLimit, settings.BodyLimit)
+
+	cnf.Server.BodyLimitKB = 64
+	a, err = newApplication(cnf, logger.NopLogger{})
+	require.NoError(t, err)
+	assert.Equal(t, 64<<10, a.http.Config().BodyLimit)
+}
+
+func TestApplication_Reload(t *testing.T) {
+	path := writeReplayConfig(t)
+	original, err := os.ReadFile(path)
+	require.NoError(t, err)
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(path))
+	require.NoError(t, err)
+	cnf.Admin.Token = "s3cret"
+
+	l := logger.NewTestLogger()
+	a, err := newApplication(cnf, l)
+	require.NoError(t, err)
+	a.reloader.load = func() (*config.Config, error) {
+		return config.NewConfigWithProvider(config.NewFileConfigProvider(path))
+	}
+
+	providers := func() []string {
+		resp, err := a.http.Test(httptest.NewRequest(http.MethodGet, "/v1/providers", nil), -1)
+		require.NoError(t, err)
+		var statuses []models.ProviderStatus
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&statuses))
+		names := make([]string, len(statuses))
+		for i, status := range statuses {
+			names[i] = status.Name
+		}
+		return names
+	}
+	reload := func() (int, string) {
+		req := httptest.NewRequest(http.MethodPost, "/admin/reload", nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		resp, err := a.http.Test(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, string(body)
+	}
+	writeConfig := func(apis string) {
+		content := strings.Replace(string(original), "    - name: open-meteo\n      timeout: 5\n", apis, 1)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	assert.Equal(t, []string{"open-meteo"}, providers())
+
+	// The mock provider is added, and the server settings are left for the restart
+	writeConfig("    - name: open-meteo\n      timeout: 5\n    - name: mock\n      timeout: 5\n")
+	status, body := reload()
+	require.Equal(t, http.StatusOK, status, body)
+	assert.JSONEq(t, `{"added": ["mock"], "removed": null, "changed": null}`, body)
+	assert.Equal(t, []string{"open-meteo", "mock"}, providers())
+
+	// An invalid configuration keeps the current providers
+	writeConfig("    - name: open-meteoo\n      timeout: 5\n")
+	status, body = reload()
+	assert.Equal(t, http.StatusInternalServerError, status)
+	assert.Contains(t, body, `unknown weather provider \"open-meteoo\"`)
+	assert.Equal(t, []string{"open-meteo", "mock"}, providers())
+
+	// SIGHUP reloads the file like POST /admin/reload
+	stop := a.reloader.reloadOnSignal()
+	t.Cleanup(stop)
+	writeConfig("    - name: mock\n      timeout: 10\n")
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	assert.Eventually(t, func() bool {
+		return slices.Equal(providers(), []string{"mock"})
+	}, 5*time.Second, 10*time.Millisecond)
+
+	resp, err := a.http.Test(httptest.NewRequest(http.MethodGet, "/v1/weather?lat=52.52&lon=13.41&days=2", nil), -1)
+	require.NoError(t, err)
+	forecasts, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(forecasts), `"mock"`)
+	assert.NotContains(t, string(forecasts), `"open-meteo"`)
+
+	var reloaded []map[string]any
+	for _, entry := range l.Filter(logger.LevelWarning) {
+		if entry.Message == "weather providers reloaded" {
+			reloaded = append(reloaded, entry.Fields)
+		}
+	}
+	require.Len(t, reloaded, 2)
+	assert.Equal(t, []string{"open-meteo"}, reloaded[1]["removed"])
+	assert.Equal(t, []string{"mock"}, reloaded[1]["changed"])
+}
+
+func TestServe_PrintConfig(t *testing.T) {
+	path := writeReplayConfig(t)
+	t.Setenv("ADMIN_TOKEN", "admin-token-s3cr3t")
+
+	code, stdout, stderr := runCommand("serve", "--config", path, "--print-config")
+	require.Equal(t, exitOK, code, stderr)
+	assert.Contains(t, stdout, "file: "+path)
+	assert.Contains(t, stdout, "- name: open-meteo")
+	assert.Contains(t, stdout, "token: '****cr3t'")
+	assert.NotContains(t, stdout, "admin-token-s3cr3t")
+}
+
+func TestNewApplication_AdminConfig(t *testing.T) {
+	path := writeReplayConfig(t)
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(path))
+	require.NoError(t, err)
+	cnf.Admin.Token = "admin-token-s3cr3t"
+	cnf.Weather.APIs[0].APIKey = "provider-key-0123456789"
+
+	a, err := newApplication(cnf, logger.NopLogger{})
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/config", nil)
+	req.Header.Set("Authorization", "Bearer admin-token-s3cr3t")
+	resp, err := a.http.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/yaml", resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "file: "+path)
+	assert.Contains(t, string(body), "api_key: '****6789'")
+	assert.NotContains(t, string(body), "provider-key-0123456789")
+	assert.NotContains(t, string(body), "admin-token-s3cr3t")
+
+	resp, err = a.http.Test(httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+}
+
+func TestNewApplication_ForecastStore(t *testing.T) {
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(writeReplayConfig(t)))
+	require.NoError(t, err)
+	cnf.Store.Path = filepath.Join(t.TempDir(), "forecasts.db")
+	cnf.Server.Metrics = true
+
+	a, err := newApplication(cnf, logger.NopLogger{})
+	require.NoError(t, err)
+	require.NotNil(t, a.store)
+
+	resp, err := a.http.Test(httptest.NewRequest(http.MethodGet, "/v1/weather?lat=52.52&lon=13.41&days=2", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var forecasts map[string]models.Forecast
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&forecasts))
+	day := forecasts["open-meteo"].ForecastData[0]
+
+	// The forecast is written in the background
+	var stored []models.StoredForecast
+	require.Eventually(t, func() bool {
+		resp, err := a.http.Test(httptest.NewRequest(http.MethodGet, "/v1/weather/stored?lat=52.52&lon=13.41&date="+day.Date.String(), nil))
+		if err != nil || resp.StatusCode != http.StatusOK {
+			return false
+		}
+		return json.NewDecoder(resp.Body).Decode(&stored) == nil && len(stored) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "open-meteo", stored[0].Provider)
+	assert.Equal(t, day.TempMax, stored[0].TempMax)
+
+	resp, err = a.http.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), "forecast_store_written_total 1")
+	assert.Contains(t, string(body), "forecast_store_dropped_total 0")
+
+	// The store keeps the webhook subscriptions
+	req := httptest.NewRequest(http.MethodPost, "/v1/subscriptions", strings.NewReader(`{"lat": 52.52, "lon": 13.41,
+		"condition": {"field": "temp_max", "op": "gte", "value": 35}, "webhook_url": "https://example.com/hook"}`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err = a.http.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+
+	require.NoError(t, a.shutdown(context.Background()))
+}
+
+func TestNewApplication_RuleMetrics(t *testing.T) {
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(writeReplayConfig(t)))
+	require.NoError(t, err)
+	cnf.Server.Metrics = true
+	cnf.Weather.Rules.Rules = []config.RuleConfig{
+		{Name: "suspect-warm", Field: "temp_max", Comparator: "gt", Value: 20, Action: "flag_suspect"},
+		{Name: "drop-cold", Field: "temp_max", Comparator: "lt", Value: 0, Action: "drop"},
+	}
+
+	a, err := newApplication(cnf, logger.NopLogger{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = a.shutdown(context.Background()) })
+
+	resp, err := a.http.Test(httptest.NewRequest(http.MethodGet, "/v1/weather?lat=52.52&lon=13.41&days=2", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = a.http.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `rule_hits_total{action="flag_suspect",rule="suspect-warm"} 2`)
+	assert.Contains(t, string(body), `rule_hits_total{action="drop",rule="drop-cold"} 0`)
+}
+
+func TestNewApplication_CanaryMetrics(t *testing.T) {
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(writeReplayConfig(t)))
+	require.NoError(t, err)
+	cnf.Server.Metrics = true
+	cnf.Weather.APIs[0].Canary = &config.CanaryConfig{Percent: 100}
+
+	a, err := newApplication(cnf, logger.NopLogger{})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = a.shutdown(context.Background()) })
+
+	resp, err := a.http.Test(httptest.NewRequest(http.MethodGet, "/v1/weather?lat=52.52&lon=13.41&days=2", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	var forecasts map[string]models.Forecast
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&forecasts))
+	assert.Equal(t, repositories.CanaryMemberCanary, forecasts["open-meteo"].Variant)
+
+	resp, err = a.http.Test(httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `canary_requests_total{member="canary",provider="open-meteo"} 1`)
+	assert.Contains(t, string(body), `canary_requests_total{member="primary",provider="open-meteo"} 0`)
+	assert.Contains(t, string(body), `canary_errors_total{member="canary",provider="open-meteo"} 0`)
+}
+
+func TestNewApplication_RedisCache(t *testing.T) {
+	server := miniredis.RunT(t)
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(writeReplayConfig(t)))
+	require.NoError(t, err)
+	cnf.Cache = config.CacheConfig{Enabled: true, Backend: "redis", Redis: config.RedisCacheConfig{Address: server.Addr(), KeyPrefix: "test:"}}
+
+	a, err := newApplication(cnf, logger.NopLogger{})
+	require.NoError(t, err)
+	require.NotNil(t, a.cache)
+	t.Cleanup(func() { _ = a.shutdown(context.Background()) })
+
+	resp, err := a.http.Test(httptest.NewRequest(http.MethodGet, "/v1/weather?lat=52.52&lon=13.41&days=2", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotEmpty(t, server.Keys())
+
+	resp, err = a.http.Test(httptest.NewRequest(http.MethodGet, "/manage/ready", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	// The forecasts are still served without Redis, the replica is not ready
+	server.Close()
+	resp, err = a.http.Test(httptest.NewRequest(http.MethodGet, "/v1/weather?lat=52.52&lon=13.41&days=2", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp, err = a.http.Test(httptest.NewRequest(http.MethodGet, "/manage/ready", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
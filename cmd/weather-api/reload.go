@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"weather-api/config"
+	v1 "weather-api/internal/controllers/http/v1"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// reloader replaces the forecast providers of the service with those of weather.apis when the configuration is
+// loaded again, the other settings, e.g. the ports and the timeouts of the server, need a restart
+type reloader struct {
+	mu sync.Mutex
+	// load reads the configuration again, nil when the configuration has no source to reload
+	load    func() (*config.Config, error)
+	service *weather.WeatherService
+	// effective is the configuration in use, the one of the startup with the providers reloaded
+	effective config.Config
+	l         logger.Logger
+}
+
+// reload loads the configuration and swaps the providers, the current ones are kept when it fails
+func (r *reloader) reload() (v1.ReloadResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.load == nil {
+		return v1.ReloadResult{}, errors.New("no configuration file to reload")
+	}
+	cnf, err := r.load()
+	if err != nil {
+		return v1.ReloadResult{}, err
+	}
+	repos, err := newWeatherRepositories(cnf, r.l)
+	if err != nil {
+		return v1.ReloadResult{}, err
+	}
+	r.service.SetProviders(repos, weather.ProviderSettings{
+		Timeouts:          cnf.ProviderTimeouts(),
+		Weights:           cnf.ProviderWeights(),
+		ConcurrencyLimits: cnf.ProviderConcurrencyLimits(),
+		Quotas:            providerQuotas(cnf),
+	})
+
+	// The providers are logged by name, their configurations hold the API keys
+	var result v1.ReloadResult
+	result.Added, result.Removed, result.Changed = config.DiffWeatherAPIs(r.effective.Weather.APIs, cnf.Weather.APIs)
+	r.l.Warning("weather providers reloaded", map[string]any{
+		"added":   result.Added,
+		"removed": result.Removed,
+		"changed": result.Changed,
+	})
+	if !reflect.DeepEqual(r.effective.Server, cnf.Server) {
+		r.l.Warning("server settings changed, they are applied on restart")
+	}
+	r.effective.Weather.APIs = cnf.Weather.APIs
+
+	return result, nil
+}
+
+// dump returns the effective configuration in YAML, its secrets redacted
+func (r *reloader) dump() ([]byte, error) {
+	r.mu.Lock()
+	effective := r.effective
+	r.mu.Unlock()
+
+	return effective.Dump()
+}
+
+// reloadOnSignal reloads the providers on every SIGHUP until stop is called
+func (r *reloader) reloadOnSignal() (stop func()) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+	go func() {
+		for range sigCh {
+			if _, err := r.reload(); err != nil {
+				r.l.Error(fmt.Errorf("cannot reload the configuration: %w", err))
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigCh)
+		close(sigCh)
+	}
+}
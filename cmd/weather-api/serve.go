@@ -0,0 +1,680 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"google.golang.org/grpc"
+
+	"weather-api/config"
+	"weather-api/docs"
+	"weather-api/internal/buildinfo"
+	grpcapi "weather-api/internal/controllers/grpc"
+	v1 "weather-api/internal/controllers/http/v1"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/rules"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/httpserver"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/tracing"
+)
+
+// serve runs the HTTP API, and the gRPC one when its port is configured, until the process is signaled
+func serve(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	configPath := flags.String("config", defaultConfigPath, "configuration file")
+	printConfig := flags.Bool("print-config", false, "print the effective configuration, its secrets redacted, and exit")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+	if *printConfig {
+		return printEffectiveConfig(*configPath, stdout, stderr)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Load configuration with proper error handling
+	cnf, err := config.NewConfigWithProvider(config.NewFileConfigProvider(*configPath))
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load configuration: %v\n", err)
+		cancel()
+		return exitFailure
+	}
+
+	l := logger.NewZapLoggerWithOptions(cnf.App.Name, loggerOptions(cnf), stdout)
+
+	// The spans are exported from the start, the tracers of the application are bound to the global provider
+	shutdownTracing := func(context.Context) error { return nil }
+	if cnf.Tracing.Enabled {
+		shutdownTracing, err = tracing.Setup(ctx, cnf.App.Name, buildinfo.Get(cnf.App.Name).Version)
+		if err != nil {
+			l.Fatal("failed to initialize tracing", map[string]any{"err": err})
+			os.Exit(1)
+		}
+	}
+
+	a, err := newApplication(cnf, l)
+	if err != nil {
+		l.Fatal("failed to initialize the application", map[string]any{"err": err})
+		os.Exit(1)
+	}
+	app, build := a.http, a.build
+	a.reloader.load = func() (*config.Config, error) {
+		return config.NewConfigWithProvider(config.NewFileConfigProvider(*configPath))
+	}
+
+	ln, err := a.listen(":" + cnf.Server.Port)
+	if err != nil {
+		l.Fatal("cannot listen on the port", map[string]any{"err": err, "port": cnf.Server.Port})
+		os.Exit(1)
+	}
+	go func() {
+		if err := app.Listener(ln); err != nil {
+			l.Fatal("cannot run the server", map[string]any{"err": err})
+		}
+	}()
+
+	// Plain HTTP is redirected to HTTPS on its own port when both are configured
+	if a.redirect != nil {
+		go func() {
+			if err := a.redirect.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				l.Fatal("cannot run the HTTP redirection", map[string]any{"err": err, "port": cnf.Server.RedirectPort})
+			}
+		}()
+	}
+
+	// The gRPC API is served next to the HTTP one when its port is configured
+	grpcServer := a.grpc
+	if grpcServer != nil {
+		lis, err := net.Listen("tcp", ":"+cnf.Server.GRPCPort)
+		if err != nil {
+			l.Fatal("cannot listen on the gRPC port", map[string]any{"err": err, "port": cnf.Server.GRPCPort})
+			os.Exit(1)
+		}
+		go func() {
+			if err := grpcServer.Serve(lis); err != nil {
+				l.Fatal("cannot run the gRPC server", map[string]any{"err": err})
+			}
+		}()
+	}
+
+	l.Info("starting application", map[string]any{
+		"port":          cnf.Server.Port,
+		"tls":           a.tls != nil,
+		"redirect_port": cnf.Server.RedirectPort,
+		"grpc_port":     cnf.Server.GRPCPort,
+		"env":           cnf.App.Env,
+		"name":          cnf.App.Name,
+		"version":       build.Version,
+		"commit":        build.Commit,
+	})
+
+	// SIGHUP reloads the providers of weather.apis, e.g. to rotate an API key without a restart
+	stopReload := a.reloader.reloadOnSignal()
+
+	sigCh := make(chan os.Signal, 2)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	defer func() {
+		l.Warning("stopping application services")
+		signal.Stop(sigCh)
+		close(sigCh)
+		stopReload()
+
+		// The load balancers see the server not ready and stop routing requests to it before it stops accepting
+		a.readiness.Drain()
+		time.Sleep(time.Duration(cnf.Server.DrainSeconds) * time.Second)
+
+		shutdownCtx, shutdownCancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer shutdownCancel()
+
+		// The logger is stopped last, once the operations in flight logged their outcome
+		if err := a.shutdown(shutdownCtx); err != nil {
+			l.Error(err)
+		}
+		_ = shutdownTracing(shutdownCtx)
+		_ = l.Stop()
+		cancel()
+	}()
+
+	select {
+	case <-sigCh:
+		fmt.Fprintln(stdout, "received shutdown signal")
+	case <-ctx.Done():
+		fmt.Fprintln(stdout, "context cancelled")
+	}
+
+	return exitOK
+}
+
+// application is the composition of the servers from the configuration, before they listen
+type application struct {
+	http    *fiber.App
+	grpc    *grpc.Server
+	service *weather.WeatherService
+	build   buildinfo.Info
+	// tls serves HTTPS when configured, redirect then serves the redirection of plain HTTP when configured
+	tls      *httpserver.TLS
+	redirect *http.Server
+	// readiness is drained on shutdown
+	readiness *httpserver.Readiness
+	// reloader swaps the forecast providers on SIGHUP and POST /admin/reload
+	reloader *reloader
+	// store records the provider forecasts, nil when not configured, it is closed once the service is stopped
+	store repositories.ForecastStore
+	// cache is the Redis cache of the forecasts, nil unless the redis backend is configured
+	cache *weather.RedisCache
+}
+
+// shutdown stops the servers, waiting for the requests in flight, then cancels the operations of the service
+// still running, e.g. the health checks and the shared fetches, and waits for them until ctx is done
+func (a *application) shutdown(ctx context.Context) error {
+	a.readiness.Drain()
+
+	err := a.http.ShutdownWithContext(ctx)
+	if a.redirect != nil {
+		err = errors.Join(err, a.redirect.Shutdown(ctx))
+	}
+	if a.grpc != nil {
+		grpcapi.Shutdown(ctx, a.grpc)
+	}
+
+	err = errors.Join(err, a.service.Shutdown(ctx))
+	if a.store != nil {
+		err = errors.Join(err, a.store.Close())
+	}
+	if a.cache != nil {
+		err = errors.Join(err, a.cache.Close())
+	}
+
+	return err
+}
+
+// listen listens on addr for the HTTP API, with TLS when configured
+func (a *application) listen(addr string) (net.Listener, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if a.tls != nil {
+		ln = a.tls.Listener(ln)
+	}
+
+	return ln, nil
+}
+
+// newApplication wires the providers, the service and the HTTP routes, and the gRPC server when its port is configured
+func newApplication(cnf *config.Config, l logger.Logger) (*application, error) {
+	build := buildinfo.Get(cnf.App.Name)
+	docs.SwaggerInfo.Version = build.Version
+
+	store, err := repositories.InitForecastStore(cnf, l)
+	if err != nil {
+		return nil, fmt.Errorf("forecast store: %w", err)
+	}
+	serviceOpts := []weather.Option{
+		weather.WithForecastStore(store, cnf.Store.Queue()),
+		weather.WithAccuracyScoring(cnf.Store.Accuracy.Interval(), cnf.Store.Accuracy.Window()),
+		weather.WithWebhooks(weather.WebhookOptions{
+			Timeout:              time.Duration(cnf.Webhooks.TimeoutSeconds) * time.Second,
+			MinInterval:          time.Duration(cnf.Webhooks.MinIntervalMinutes) * time.Minute,
+			MaxFailures:          cnf.Webhooks.MaxFailures,
+			AllowPrivateNetworks: cnf.Webhooks.AllowPrivateNetworks,
+		}),
+		weather.WithPrewarm(weather.PrewarmOptions{Locations: prewarmLocations(cnf.Weather.Prewarm)}),
+	}
+	readinessChecks := []httpserver.ReadinessCheck{}
+	// The replicas share the forecasts cached in Redis, the requests go on without it when it is unreachable
+	// but the replica is not ready
+	var redisCache *weather.RedisCache
+	if cnf.Cache.UseRedis() {
+		redisCache = weather.NewRedisCache(weather.RedisCacheOptions{
+			Address:   cnf.Cache.Redis.Address,
+			Password:  cnf.Cache.Redis.Password,
+			DB:        cnf.Cache.Redis.DB,
+			KeyPrefix: cnf.Cache.Redis.KeyPrefix,
+			TTL:       cnf.Cache.TTL(),
+		}, l)
+		serviceOpts = append(serviceOpts, weather.WithCache(redisCache))
+		readinessChecks = append(readinessChecks, redisCache.Ready)
+	}
+	service, err := newWeatherService(cnf, l, serviceOpts...)
+	if err != nil {
+		if store != nil {
+			_ = store.Close()
+		}
+		if redisCache != nil {
+			_ = redisCache.Close()
+		}
+		return nil, err
+	}
+	readiness := httpserver.NewReadiness(append([]httpserver.ReadinessCheck{service.Ready}, readinessChecks...)...)
+
+	requestsPerMinute, burst := cnf.RateLimit.Limits()
+	opts := httpserver.Options{
+		ReadTimeout:  time.Duration(cnf.Server.ReadTimeout) * time.Second,
+		WriteTimeout: time.Duration(cnf.Server.WriteTimeout) * time.Second,
+		IdleTimeout:  time.Duration(cnf.Server.IdleTimeout) * time.Second,
+		BodyLimit:    cnf.Server.BodyLimitKB << 10,
+		TrustedProxy: cnf.Server.TrustedProxy,
+		AccessLog: httpserver.AccessLogConfig{
+			Enabled:      !cnf.Log.DisableAccess,
+			SampleAfter:  cnf.Log.AccessSampleAfter,
+			SampleEvery:  cnf.Log.AccessSampleEvery,
+			TrustedProxy: cnf.Server.TrustedProxy,
+		},
+		RateLimit: httpserver.RateLimitConfig{
+			RequestsPerMinute: requestsPerMinute,
+			Burst:             burst,
+			TrustedProxy:      cnf.Server.TrustedProxy,
+		},
+		Compression: httpserver.CompressionConfig{
+			Level:   compressionLevels[cnf.Server.CompressionLevel],
+			MinSize: cnf.Server.CompressionMinBytes,
+		},
+		CORS: httpserver.CORSConfig{
+			AllowOrigins:     cnf.CORSOrigins(),
+			AllowMethods:     cnf.Server.CORS.AllowMethods,
+			AllowHeaders:     cnf.Server.CORS.AllowHeaders,
+			MaxAge:           cnf.Server.CORS.MaxAgeSeconds,
+			AllowCredentials: cnf.Server.CORS.AllowCredentials,
+		},
+		Debug:     cnf.DebugEndpoints(),
+		Tracing:   cnf.Tracing.Enabled,
+		Readiness: readiness,
+	}
+	if cnf.Server.Metrics {
+		opts.Metrics = prometheus.NewRegistry()
+		opts.Metrics.MustRegister(
+			collectors.NewGoCollector(),
+			collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		)
+		if store != nil {
+			opts.Metrics.MustRegister(storeMetrics(service)...)
+		}
+		if len(cnf.Weather.Prewarm) > 0 {
+			opts.Metrics.MustRegister(prewarmMetrics(service)...)
+		}
+		// Registered without a quota configured, a reload may add one
+		opts.Metrics.MustRegister(quotaMetrics{service: service})
+		opts.Metrics.MustRegister(ruleMetrics{service: service})
+		opts.Metrics.MustRegister(canaryMetrics{service: service})
+		if redisCache != nil {
+			opts.Metrics.MustRegister(prometheus.NewCounterFunc(prometheus.CounterOpts{
+				Name: "forecast_cache_errors_total",
+				Help: "Failed operations of the Redis forecast cache, the requests went on without it.",
+			}, func() float64 { return float64(redisCache.Errors()) }))
+		}
+	}
+	if opts.Debug {
+		l.Warning("debug endpoints enabled, /debug exposes the internals of the process", map[string]any{
+			"env": cnf.App.Env,
+		})
+	}
+	if cnf.Auth.Enabled {
+		opts.Auth = httpserver.APIKeyAuthConfig{Keys: cnf.APIKeys(), OpenPaths: cnf.OpenPaths()}
+	}
+	app := httpserver.InitFiberServer(cnf.App.Name, opts, l)
+
+	geocoder, err := repositories.InitGeocodingRepository(cnf, l)
+	if err != nil {
+		return nil, fmt.Errorf("geocoding: %w", err)
+	}
+
+	area := cnf.Weather.ServiceArea.Area()
+	routerOpts := []v1.RouterOption{
+		v1.WithTrustedProxy(cnf.Server.TrustedProxy),
+		v1.WithBuildInfo(build),
+		v1.WithServiceArea(area),
+		v1.WithLongitudeNormalization(cnf.Weather.NormalizeLongitude),
+	}
+	locator, err := repositories.InitIPLocator(cnf, l)
+	if err != nil {
+		return nil, fmt.Errorf("IP geolocation: %w", err)
+	}
+	if locator != nil {
+		routerOpts = append(routerOpts, v1.WithIPLocator(locator))
+	}
+
+	v1.NewDocsRouter(app)
+	v1.NewRouter(
+		app.Group("/v1"),
+		service,
+		geocoder,
+		l,
+		routerOpts...,
+	)
+	// The unversioned routes stay as deprecated aliases of /v1 until LegacySunset
+	v1.NewRouter(
+		app,
+		service,
+		geocoder,
+		l,
+		append(routerOpts, v1.WithDeprecation(v1.LegacySunset, "/v1"))...,
+	)
+
+	v1.NewGraphQLRouter(app, service, l, routerOpts...)
+
+	reload := &reloader{service: service, effective: *cnf, l: l}
+	v1.NewAdminRouter(
+		app,
+		cnf.Admin.Token,
+		service,
+		l,
+		v1.WithReloader(reload.reload),
+		v1.WithConfigDump(reload.dump),
+	)
+
+	a := &application{http: app, service: service, build: build, readiness: readiness, reloader: reload, store: store, cache: redisCache}
+	if cnf.Server.GRPCPort != "" {
+		grpcOpts := []grpcapi.Option{grpcapi.WithServiceArea(area)}
+		if cnf.Auth.Enabled {
+			grpcOpts = append(grpcOpts, grpcapi.WithAPIKeys(cnf.APIKeys()))
+		}
+		a.grpc = grpcapi.NewServer(service, l, grpcOpts...)
+	}
+	if cnf.Server.TLS() {
+		// A missing or invalid certificate fails the startup
+		a.tls, err = httpserver.NewTLS(httpserver.TLSOptions{
+			CertFile:         cnf.Server.TLSCertFile,
+			KeyFile:          cnf.Server.TLSKeyFile,
+			AutocertHosts:    cnf.Server.AutocertHosts,
+			AutocertCacheDir: cnf.Server.AutocertCacheDir,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("TLS: %w", err)
+		}
+		if cnf.Server.RedirectPort != "" {
+			a.redirect = &http.Server{
+				Addr:              ":" + cnf.Server.RedirectPort,
+				Handler:           a.tls.RedirectHandler(cnf.Server.Port),
+				ReadHeaderTimeout: opts.ReadTimeout,
+				IdleTimeout:       opts.IdleTimeout,
+			}
+		}
+	}
+
+	return a, nil
+}
+
+// compressionLevels are the levels of server.compression_level, empty selects the default one
+var compressionLevels = map[string]httpserver.CompressionLevel{
+	"":        httpserver.CompressionDefault,
+	"off":     httpserver.CompressionOff,
+	"speed":   httpserver.CompressionSpeed,
+	"default": httpserver.CompressionDefault,
+	"best":    httpserver.CompressionBest,
+}
+
+// printEffectiveConfig prints the configuration the server would run with and the file it is loaded from, it is
+// printed before the validation so an invalid one can be inspected as well
+func printEffectiveConfig(path string, stdout, stderr io.Writer) int {
+	cnf, err := config.NewFileConfigProvider(path).Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to load configuration: %v\n", err)
+		return exitFailure
+	}
+	dump, err := cnf.Dump()
+	if err != nil {
+		fmt.Fprintf(stderr, "Failed to print configuration: %v\n", err)
+		return exitFailure
+	}
+
+	_, _ = stdout.Write(dump)
+	return exitOK
+}
+
+// loggerOptions returns the level, format, environment, redacted fields, sampling and file of the logs from the
+// configuration
+func loggerOptions(cnf *config.Config) logger.Options {
+	return logger.Options{
+		Level:            cnf.Log.Level,
+		Format:           cnf.Log.Format,
+		Env:              cnf.App.Env,
+		RedactKeys:       cnf.Log.RedactKeys,
+		SampleInitial:    cnf.Log.SampleInitial,
+		SampleThereafter: cnf.Log.SampleThereafter,
+		File: logger.FileOptions{
+			Path:       cnf.Log.FilePath,
+			MaxSizeMB:  cnf.Log.FileMaxSizeMB,
+			MaxBackups: cnf.Log.FileMaxBackups,
+			MaxAgeDays: cnf.Log.FileMaxAgeDays,
+			Compress:   cnf.Log.FileCompress,
+		},
+	}
+}
+
+// newWeatherRepositories builds the forecast providers of weather.apis, at least one must be enabled
+func newWeatherRepositories(cnf *config.Config, l logger.Logger) ([]repositories.WeatherRepository, error) {
+	repos, err := repositories.InitWeatherRepositories(cnf, l)
+	if err != nil {
+		return nil, fmt.Errorf("weather repositories: %w", err)
+	}
+	// A server without any enabled provider would fail every forecast
+	if len(repos) == 0 {
+		return nil, errors.New("no weather provider configured, weather.apis must enable at least one of: " +
+			strings.Join(repositories.WeatherProviders(), ", "))
+	}
+
+	return repos, nil
+}
+
+// newWeatherService builds the forecast providers and the service over them from the configuration, opts are
+// applied last. It is shared by the server and the fetch command.
+func newWeatherService(cnf *config.Config, l logger.Logger, opts ...weather.Option) (*weather.WeatherService, error) {
+	repos, err := newWeatherRepositories(cnf, l)
+	if err != nil {
+		return nil, err
+	}
+
+	rulesEngine, err := rules.NewEngine(cnf.Weather.Rules)
+	if err != nil {
+		return nil, fmt.Errorf("rules: %w", err)
+	}
+
+	airQuality, err := repositories.InitAirQualityRepository(cnf, l)
+	if err != nil {
+		return nil, fmt.Errorf("air quality: %w", err)
+	}
+
+	marine, err := repositories.InitMarineRepository(cnf, l)
+	if err != nil {
+		return nil, fmt.Errorf("marine forecasts: %w", err)
+	}
+
+	alertSources, err := repositories.InitAlertSources(cnf, l)
+	if err != nil {
+		return nil, fmt.Errorf("alert sources: %w", err)
+	}
+
+	opts = append([]weather.Option{
+		weather.WithRules(rulesEngine),
+		weather.WithOutlierThreshold(cnf.Weather.Aggregation.OutlierMADs),
+		weather.WithMinProviders(cnf.Weather.Aggregation.MinProviders),
+		weather.WithConfidenceThresholds(
+			cnf.Weather.Aggregation.HighConfidenceSpread,
+			cnf.Weather.Aggregation.MediumConfidenceSpread,
+		),
+		weather.WithCompareThreshold(cnf.Weather.Compare.Threshold),
+		weather.WithQuotas(weather.QuotaOptions{Quotas: providerQuotas(cnf)}),
+		weather.WithWeights(cnf.ProviderWeights()),
+		weather.WithProviderTimeouts(cnf.ProviderTimeouts()),
+		weather.WithRequestTimeout(cnf.Server.RequestBudget()),
+		weather.WithConcurrencyLimits(cnf.Weather.MaxConcurrentRequests, cnf.ProviderConcurrencyLimits()),
+		weather.WithHistoryMaxDays(cnf.Weather.History.MaxDays),
+		weather.WithMaxForecastDays(cnf.Weather.MaxForecastDays),
+		weather.WithCacheTTL(cnf.Cache.TTL()),
+		weather.WithForecastCache(cnf.Cache.Entries()),
+		weather.WithBatchLimits(cnf.Weather.Batch.MaxItems, cnf.Weather.Batch.Concurrency),
+		weather.WithSubscriptionLimits(
+			time.Duration(cnf.Weather.Subscriptions.MinIntervalSeconds)*time.Second,
+			time.Duration(cnf.Weather.Subscriptions.MaxDurationMinutes)*time.Minute,
+		),
+		weather.WithAirQuality(airQuality),
+		weather.WithMarine(marine),
+		weather.WithAlertSources(alertSources...),
+		weather.WithProviderErrorLog(
+			cnf.Log.ProviderErrorsEvery,
+			time.Duration(cnf.Log.ProviderErrorsInterval)*time.Second,
+		),
+	}, opts...)
+
+	return weather.NewWeatherService(repos, l, opts...), nil
+}
+
+// storeMetrics exports the counters of the forecast store of service
+func storeMetrics(service *weather.WeatherService) []prometheus.Collector {
+	counter := func(name, help string, value func(weather.StoreStats) int64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, func() float64 {
+			return float64(value(service.StoreStats()))
+		})
+	}
+
+	return []prometheus.Collector{
+		counter("forecast_store_written_total", "Provider forecasts written to the forecast store.",
+			func(s weather.StoreStats) int64 { return s.Written }),
+		counter("forecast_store_dropped_total", "Provider forecasts dropped, the queue of the forecast store was full.",
+			func(s weather.StoreStats) int64 { return s.Dropped }),
+		counter("forecast_store_failed_total", "Provider forecasts the forecast store failed to write.",
+			func(s weather.StoreStats) int64 { return s.Failed }),
+		prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Name: "forecast_store_queued",
+			Help: "Provider forecasts waiting to be written to the forecast store.",
+		}, func() float64 { return float64(service.StoreStats().Queued) }),
+	}
+}
+
+// prewarmMetrics exports the counters of the cache warming of service
+func prewarmMetrics(service *weather.WeatherService) []prometheus.Collector {
+	counter := func(name, help string, value func(weather.PrewarmStats) int64) prometheus.Collector {
+		return prometheus.NewCounterFunc(prometheus.CounterOpts{Name: name, Help: help}, func() float64 {
+			return float64(value(service.PrewarmStats()))
+		})
+	}
+
+	return []prometheus.Collector{
+		counter("forecast_prewarm_refreshes_total", "Refreshes of the forecasts of the warmed locations.",
+			func(s weather.PrewarmStats) int64 { return s.Refreshes }),
+		counter("forecast_prewarm_failures_total", "Refreshes of the warmed locations a provider failed.",
+			func(s weather.PrewarmStats) int64 { return s.Failures }),
+		counter("forecast_prewarm_hits_total", "Provider forecasts of the warmed locations served from the cache.",
+			func(s weather.PrewarmStats) int64 { return s.Hits }),
+	}
+}
+
+// quotaMetrics exports the usage of the quotas of the providers of service, labeled by provider
+type quotaMetrics struct {
+	service *weather.WeatherService
+}
+
+var (
+	quotaUsedDesc = prometheus.NewDesc("provider_quota_used",
+		"Calls to the provider in the current period of its quota.", []string{"provider"}, nil)
+	quotaLimitDesc = prometheus.NewDesc("provider_quota_limit",
+		"Calls allowed to the provider in a period of its quota.", []string{"provider"}, nil)
+	quotaRemainingDesc = prometheus.NewDesc("provider_quota_remaining",
+		"Calls left to the provider until its quota resets.", []string{"provider"}, nil)
+)
+
+func (m quotaMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- quotaUsedDesc
+	ch <- quotaLimitDesc
+	ch <- quotaRemainingDesc
+}
+
+func (m quotaMetrics) Collect(ch chan<- prometheus.Metric) {
+	for provider, quota := range m.service.QuotaUsage() {
+		ch <- prometheus.MustNewConstMetric(quotaUsedDesc, prometheus.GaugeValue, float64(quota.Used), provider)
+		ch <- prometheus.MustNewConstMetric(quotaLimitDesc, prometheus.GaugeValue, float64(quota.Limit), provider)
+		ch <- prometheus.MustNewConstMetric(quotaRemainingDesc, prometheus.GaugeValue, float64(quota.Remaining), provider)
+	}
+}
+
+// ruleMetrics exports the hits of the post-processing rules of service, labeled by rule and action
+type ruleMetrics struct {
+	service *weather.WeatherService
+}
+
+var ruleHitsDesc = prometheus.NewDesc("rule_hits_total",
+	"Forecast days matched by the post-processing rule.", []string{"rule", "action"}, nil)
+
+func (m ruleMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- ruleHitsDesc
+}
+
+func (m ruleMetrics) Collect(ch chan<- prometheus.Metric) {
+	for key, hits := range m.service.RuleHits() {
+		ch <- prometheus.MustNewConstMetric(ruleHitsDesc, prometheus.CounterValue, float64(hits), key.Rule, key.Action)
+	}
+}
+
+// canaryMetrics exports the requests and errors of the members of the canary groups of service, labeled by
+// provider and member
+type canaryMetrics struct {
+	service *weather.WeatherService
+}
+
+var (
+	canaryRequestsDesc = prometheus.NewDesc("canary_requests_total",
+		"Requests routed to the member of the canary group of the provider.", []string{"provider", "member"}, nil)
+	canaryErrorsDesc = prometheus.NewDesc("canary_errors_total",
+		"Requests the member of the canary group of the provider failed.", []string{"provider", "member"}, nil)
+)
+
+func (m canaryMetrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- canaryRequestsDesc
+	ch <- canaryErrorsDesc
+}
+
+func (m canaryMetrics) Collect(ch chan<- prometheus.Metric) {
+	for _, canary := range m.service.Canaries() {
+		stats := canary.Stats()
+		for member, counters := range map[string]repositories.CanaryMemberStats{
+			repositories.CanaryMemberPrimary: stats.Primary,
+			repositories.CanaryMemberCanary:  stats.Canary,
+		} {
+			ch <- prometheus.MustNewConstMetric(canaryRequestsDesc, prometheus.CounterValue, float64(counters.Requests), stats.Name, member)
+			ch <- prometheus.MustNewConstMetric(canaryErrorsDesc, prometheus.CounterValue, float64(counters.Errors), stats.Name, member)
+		}
+	}
+}
+
+// providerQuotas converts the configured quotas of the providers, keyed by provider name
+func providerQuotas(cnf *config.Config) map[string]weather.Quota {
+	quotas := make(map[string]weather.Quota)
+	for _, api := range cnf.Weather.APIs {
+		if api.Quota == nil {
+			continue
+		}
+		period := api.Quota.Period
+		if period == "" {
+			period = weather.QuotaDaily
+		}
+		quotas[api.Name] = weather.Quota{Limit: api.Quota.Limit, Period: period, Enforce: api.Quota.Enforce}
+	}
+
+	return quotas
+}
+
+// prewarmLocations converts the configured locations kept warm in the cache
+func prewarmLocations(entries []config.PrewarmConfig) []weather.PrewarmLocation {
+	locations := make([]weather.PrewarmLocation, len(entries))
+	for i, e := range entries {
+		locations[i] = weather.PrewarmLocation{Name: e.Name, Lat: e.Lat, Lon: e.Lon, Days: e.Days}
+	}
+
+	return locations
+}
@@ -0,0 +1,52 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"weather-api/config"
+)
+
+// validateConfig loads a configuration file with the environment overrides and lists all its problems,
+// it fails when there is any
+func validateConfig(args []string, stdout, stderr io.Writer) int {
+	flags := flag.NewFlagSet("validate-config", flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	path := flags.String("file", defaultConfigPath, "configuration file")
+	if err := flags.Parse(args); err != nil {
+		return exitUsage
+	}
+
+	// The provider falls back to the default locations, a missing file must not validate them instead
+	if _, err := os.Stat(*path); err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", *path, err)
+		return exitFailure
+	}
+
+	provider := config.NewFileConfigProvider(*path)
+	cnf, err := provider.Load()
+	if err != nil {
+		fmt.Fprintf(stderr, "%s: %v\n", *path, err)
+		return exitFailure
+	}
+
+	err = provider.Validate(cnf)
+	var validationErr *config.ValidationError
+	switch {
+	case errors.As(err, &validationErr):
+		fmt.Fprintf(stderr, "%s is invalid:\n", *path)
+		for _, problem := range validationErr.Problems {
+			fmt.Fprintf(stderr, "  - %s\n", problem)
+		}
+		return exitFailure
+	case err != nil:
+		fmt.Fprintf(stderr, "%s: %v\n", *path, err)
+		return exitFailure
+	}
+
+	fmt.Fprintf(stdout, "%s: valid\n", *path)
+	return exitOK
+}
@@ -1,20 +1,42 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"net/url"
 	"os"
+	"reflect"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/kelseyhightower/envconfig"
 	"gopkg.in/yaml.v3"
+
+	"weather-api/pkg/geo"
 )
 
-// Config represents the application configuration
+// Config represents the application configuration, the fields tagged secret are masked by Redacted
 type Config struct {
 	App     AppConfig     `yaml:"app"`
 	Server  ServerConfig  `yaml:"server"`
 	Weather WeatherConfig `yaml:"weather"`
 	Log     LogConfig     `yaml:"log"`
+	Admin   AdminConfig   `yaml:"admin"`
+	Auth    AuthConfig    `yaml:"auth"`
+	Debug   DebugConfig   `yaml:"debug"`
+	Tracing TracingConfig `yaml:"tracing"`
+	// Cache, RateLimit and Breaker tune the forecast cache, the rate limit of the clients and the circuit
+	// breaker of the providers
+	Cache     CacheConfig     `yaml:"cache"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Breaker   BreakerConfig   `yaml:"breaker"`
+	Store     StoreConfig     `yaml:"store"`
+	Webhooks  WebhooksConfig  `yaml:"webhooks"`
+
+	// file is the configuration file loaded, see File
+	file string
 }
 
 // AppConfig contains application-specific configuration
@@ -30,27 +52,553 @@ type ServerConfig struct {
 	ReadTimeout  int    `envconfig:"SERVER_READ_TIMEOUT" yaml:"read_timeout" default:"10"`
 	WriteTimeout int    `envconfig:"SERVER_WRITE_TIMEOUT" yaml:"write_timeout" default:"10"`
 	IdleTimeout  int    `envconfig:"SERVER_IDLE_TIMEOUT" yaml:"idle_timeout" default:"120"`
+	// RequestTimeout caps the total time of a forecast request in seconds, the providers that didn't answer by
+	// then are left out of a 504 response, 0 selects DefaultRequestTimeoutSeconds
+	RequestTimeout int `envconfig:"SERVER_REQUEST_TIMEOUT" yaml:"request_timeout"`
+	// BodyLimitKB is the largest request body in KiB, 0 selects 1024
+	BodyLimitKB int `envconfig:"SERVER_BODY_LIMIT_KB" yaml:"body_limit_kb"`
+	// TrustedProxy takes the client address from the X-Forwarded-For header set by a reverse proxy,
+	// only enable it when the server can't be reached without the proxy
+	TrustedProxy bool `envconfig:"SERVER_TRUSTED_PROXY" yaml:"trusted_proxy"`
+	// GRPCPort is the port of the gRPC API, served next to the HTTP one, empty disables it
+	GRPCPort string `envconfig:"SERVER_GRPC_PORT" yaml:"grpc_port"`
+	// DrainSeconds is how long the server answers requests but is not ready before shutting down
+	DrainSeconds int `envconfig:"SERVER_DRAIN_SECONDS" yaml:"drain_seconds"`
+	// Metrics serves the Prometheus metrics of the API on /metrics
+	Metrics bool `envconfig:"SERVER_METRICS" yaml:"metrics"`
+	// TLSCertFile and TLSKeyFile serve HTTPS on Port with the certificate of the files
+	TLSCertFile string `envconfig:"SERVER_TLS_CERT_FILE" yaml:"tls_cert_file"`
+	TLSKeyFile  string `envconfig:"SERVER_TLS_KEY_FILE" yaml:"tls_key_file"`
+	// AutocertHosts serve HTTPS on Port with the certificates of Let's Encrypt for the hosts instead, they are
+	// kept in AutocertCacheDir
+	AutocertHosts    []string `envconfig:"SERVER_AUTOCERT_HOSTS" yaml:"autocert_hosts"`
+	AutocertCacheDir string   `envconfig:"SERVER_AUTOCERT_CACHE_DIR" yaml:"autocert_cache_dir"`
+	// RedirectPort redirects plain HTTP to HTTPS, and answers the Let's Encrypt challenges, empty disables it
+	RedirectPort string `envconfig:"SERVER_REDIRECT_PORT" yaml:"redirect_port"`
+	// CompressionLevel compresses the responses with brotli or gzip: speed, default or best, off disables it,
+	// empty selects default. CompressionMinBytes is the smallest body compressed, 0 selects 1024
+	CompressionLevel    string     `envconfig:"SERVER_COMPRESSION_LEVEL" yaml:"compression_level"`
+	CompressionMinBytes int        `envconfig:"SERVER_COMPRESSION_MIN_BYTES" yaml:"compression_min_bytes"`
+	CORS                CORSConfig `yaml:"cors"`
+}
+
+// CompressionLevels are the values of ServerConfig.CompressionLevel
+var CompressionLevels = []string{"off", "speed", "default", "best"}
+
+// CORSConfig selects the browser origins allowed to call the API
+type CORSConfig struct {
+	// AllowOrigins are origins like https://app.example.com, https://*.example.com for the subdomains or * for
+	// any. Without any, every origin is allowed in development and none elsewhere, production requires them.
+	AllowOrigins []string `envconfig:"SERVER_CORS_ALLOW_ORIGINS" yaml:"allow_origins"`
+	// AllowMethods and AllowHeaders are answered to the preflight requests, empty selects the API methods and
+	// the requested headers
+	AllowMethods []string `envconfig:"SERVER_CORS_ALLOW_METHODS" yaml:"allow_methods"`
+	AllowHeaders []string `envconfig:"SERVER_CORS_ALLOW_HEADERS" yaml:"allow_headers"`
+	// MaxAgeSeconds is how long the browsers cache a preflight response, 0 leaves it to them
+	MaxAgeSeconds    int  `envconfig:"SERVER_CORS_MAX_AGE_SECONDS" yaml:"max_age_seconds"`
+	AllowCredentials bool `envconfig:"SERVER_CORS_ALLOW_CREDENTIALS" yaml:"allow_credentials"`
+}
+
+// TLS reports whether the server serves HTTPS
+func (s ServerConfig) TLS() bool {
+	return s.TLSCertFile != "" || len(s.AutocertHosts) > 0
+}
+
+// DefaultRequestTimeoutSeconds is the request timeout when ServerConfig.RequestTimeout is left to zero
+const DefaultRequestTimeoutSeconds = 10
+
+// RequestBudget returns the longest a forecast request is given
+func (s ServerConfig) RequestBudget() time.Duration {
+	if s.RequestTimeout == 0 {
+		return DefaultRequestTimeoutSeconds * time.Second
+	}
+	return time.Duration(s.RequestTimeout) * time.Second
+}
+
+// The defaults of the settings left to zero in CacheConfig, RateLimitConfig and BreakerConfig
+const (
+	DefaultCacheTTLSeconds         = 300
+	DefaultCacheMaxEntries         = 10000
+	DefaultRateLimit               = 60
+	DefaultRateLimitBurst          = 20
+	DefaultBreakerFailureThreshold = 5
+	DefaultBreakerCooldownSeconds  = 30
+)
+
+// The upper bounds of the cache TTL and of the breaker cooldown, longer ones are mistakes in the unit
+const (
+	maxCacheTTLSeconds        = 24 * 60 * 60
+	maxBreakerCooldownSeconds = 60 * 60
+)
+
+// CacheConfig tunes the cache of the provider forecasts
+type CacheConfig struct {
+	// Enabled keeps up to MaxEntries provider forecasts for TTLSeconds, 0 selects DefaultCacheMaxEntries
+	Enabled    bool `envconfig:"CACHE_ENABLED" yaml:"enabled"`
+	MaxEntries int  `envconfig:"CACHE_MAX_ENTRIES" yaml:"max_entries"`
+	// TTLSeconds is how long a forecast is considered fresh, sent to the clients in Cache-Control even when the
+	// cache is disabled, at most a day, 0 selects DefaultCacheTTLSeconds
+	TTLSeconds int `envconfig:"CACHE_TTL_SECONDS" yaml:"ttl_seconds"`
+	// Backend keeps the forecasts in memory, the default, or in Redis to share them between the replicas
+	Backend string           `envconfig:"CACHE_BACKEND" yaml:"backend"`
+	Redis   RedisCacheConfig `yaml:"redis"`
+}
+
+// CacheBackends are the values of CacheConfig.Backend
+var CacheBackends = []string{"memory", "redis"}
+
+// RedisCacheConfig is the Redis server of the redis cache backend, MaxEntries doesn't apply to it
+type RedisCacheConfig struct {
+	// Address is the host:port of the server
+	Address  string `envconfig:"CACHE_REDIS_ADDRESS" yaml:"address"`
+	Password string `envconfig:"CACHE_REDIS_PASSWORD" yaml:"password" secret:"true"`
+	DB       int    `envconfig:"CACHE_REDIS_DB" yaml:"db"`
+	// KeyPrefix namespaces the keys, e.g. by environment when several share the server
+	KeyPrefix string `envconfig:"CACHE_REDIS_KEY_PREFIX" yaml:"key_prefix"`
+}
+
+// UseRedis reports whether the forecasts are cached in Redis
+func (c CacheConfig) UseRedis() bool {
+	return c.Enabled && c.Backend == "redis"
+}
+
+// Entries returns the number of provider forecasts cached, 0 when the cache is disabled
+func (c CacheConfig) Entries() int {
+	switch {
+	case !c.Enabled:
+		return 0
+	case c.MaxEntries == 0:
+		return DefaultCacheMaxEntries
+	}
+	return c.MaxEntries
+}
+
+// TTL returns how long a forecast is considered fresh
+func (c CacheConfig) TTL() time.Duration {
+	if c.TTLSeconds == 0 {
+		return DefaultCacheTTLSeconds * time.Second
+	}
+	return time.Duration(c.TTLSeconds) * time.Second
+}
+
+// RateLimitConfig limits the requests of every client
+type RateLimitConfig struct {
+	// Enabled limits the requests, true when not set
+	Enabled *bool `envconfig:"RATE_LIMIT_ENABLED" yaml:"enabled,omitempty"`
+	// RequestsPerMinute a client may send, 0 selects DefaultRateLimit, Burst is the number it may send at once,
+	// 0 selects DefaultRateLimitBurst
+	RequestsPerMinute int `envconfig:"RATE_LIMIT_REQUESTS_PER_MINUTE" yaml:"requests_per_minute"`
+	Burst             int `envconfig:"RATE_LIMIT_BURST" yaml:"burst"`
+}
+
+// Limits returns the requests per minute and the burst of a client, zero when the limit is disabled
+func (r RateLimitConfig) Limits() (perMinute, burst int) {
+	if r.Enabled != nil && !*r.Enabled {
+		return 0, 0
+	}
+	perMinute, burst = r.RequestsPerMinute, r.Burst
+	if perMinute == 0 {
+		perMinute = DefaultRateLimit
+	}
+	if burst == 0 {
+		burst = DefaultRateLimitBurst
+	}
+	return perMinute, burst
+}
+
+// BreakerConfig tunes the circuit breaker of the providers
+type BreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit of a provider,
+	// 0 selects DefaultBreakerFailureThreshold
+	FailureThreshold int `envconfig:"BREAKER_FAILURE_THRESHOLD" yaml:"failure_threshold"`
+	// CooldownSeconds is how long an open circuit rejects the calls before one is let through, at most an hour,
+	// 0 selects DefaultBreakerCooldownSeconds
+	CooldownSeconds int `envconfig:"BREAKER_COOLDOWN_SECONDS" yaml:"cooldown_seconds"`
+}
+
+// Threshold returns the number of consecutive failures opening a circuit
+func (b BreakerConfig) Threshold() int {
+	if b.FailureThreshold == 0 {
+		return DefaultBreakerFailureThreshold
+	}
+	return b.FailureThreshold
+}
+
+// Cooldown returns how long an open circuit rejects the calls
+func (b BreakerConfig) Cooldown() time.Duration {
+	if b.CooldownSeconds == 0 {
+		return DefaultBreakerCooldownSeconds * time.Second
+	}
+	return time.Duration(b.CooldownSeconds) * time.Second
+}
+
+// DefaultStoreQueueSize is the number of forecasts waiting to be stored unless StoreConfig.QueueSize is set
+const DefaultStoreQueueSize = 1000
+
+// StoreConfig configures the forecast store, recording every successful provider forecast
+type StoreConfig struct {
+	// Path is the SQLite database file, created and migrated on startup, empty disables the store
+	Path string `envconfig:"STORE_PATH" yaml:"path"`
+	// QueueSize is the number of forecasts waiting to be written, the ones beyond it are dropped,
+	// 0 selects DefaultStoreQueueSize
+	QueueSize int `envconfig:"STORE_QUEUE_SIZE" yaml:"queue_size"`
+	// Accuracy scores the stored forecasts against the observed weather
+	Accuracy AccuracyConfig `yaml:"accuracy"`
+}
+
+// DefaultAccuracyWindowDays is the number of past days scored unless AccuracyConfig.WindowDays is set
+const DefaultAccuracyWindowDays = 30
+
+// maxAccuracyWindowDays bounds the days scored, the archive requests cover the whole window
+const maxAccuracyWindowDays = 366
+
+// AccuracyConfig schedules the scoring of the providers, it needs the forecast store
+type AccuracyConfig struct {
+	// IntervalMinutes is the time between two scorings, 0 disables them
+	IntervalMinutes int `envconfig:"STORE_ACCURACY_INTERVAL_MINUTES" yaml:"interval_minutes"`
+	// WindowDays is the number of past days scored and averaged in the scores, 0 selects
+	// DefaultAccuracyWindowDays
+	WindowDays int `envconfig:"STORE_ACCURACY_WINDOW_DAYS" yaml:"window_days"`
+}
+
+// Interval returns the time between two scorings, zero when disabled
+func (a AccuracyConfig) Interval() time.Duration {
+	return time.Duration(a.IntervalMinutes) * time.Minute
+}
+
+// Window returns the number of past days scored
+func (a AccuracyConfig) Window() int {
+	if a.WindowDays == 0 {
+		return DefaultAccuracyWindowDays
+	}
+	return a.WindowDays
+}
+
+// WebhooksConfig tunes the notifications of the webhook subscriptions, kept by the forecast store
+type WebhooksConfig struct {
+	// MinIntervalMinutes is the shortest check interval of a subscription, 0 selects 5
+	MinIntervalMinutes int `envconfig:"WEBHOOKS_MIN_INTERVAL_MINUTES" yaml:"min_interval_minutes"`
+	// MaxFailures is the number of consecutive failed deliveries disabling a subscription, 0 selects 5
+	MaxFailures int `envconfig:"WEBHOOKS_MAX_FAILURES" yaml:"max_failures"`
+	// TimeoutSeconds bounds a delivery, 0 selects 5
+	TimeoutSeconds int `envconfig:"WEBHOOKS_TIMEOUT_SECONDS" yaml:"timeout_seconds"`
+	// AllowPrivateNetworks lets the webhooks point to the loopback, private and link-local addresses
+	AllowPrivateNetworks bool `envconfig:"WEBHOOKS_ALLOW_PRIVATE_NETWORKS" yaml:"allow_private_networks"`
+}
+
+// Queue returns the number of forecasts waiting to be written
+func (s StoreConfig) Queue() int {
+	if s.QueueSize == 0 {
+		return DefaultStoreQueueSize
+	}
+	return s.QueueSize
 }
 
 // WeatherConfig contains weather API configuration
 type WeatherConfig struct {
 	APIs []WeatherAPIConfig `yaml:"apis"`
+	// HTTPMode selects how providers reach the network: live, record or replay
+	HTTPMode    string `envconfig:"WEATHER_HTTP_MODE" yaml:"http_mode"`
+	FixturesDir string `envconfig:"WEATHER_FIXTURES_DIR" yaml:"fixtures_dir"`
+	// MaxForecastDays is the longest forecast window of a request (default 16), the providers with a
+	// shorter horizon serve the days they have
+	MaxForecastDays int `yaml:"max_forecast_days"`
+	// MaxConcurrentRequests bounds the upstream calls in flight across all providers, 0 means no limit
+	MaxConcurrentRequests int                 `yaml:"max_concurrent_requests"`
+	Rules                 RulesConfig         `yaml:"rules"`
+	Aggregation           AggregationConfig   `yaml:"aggregation"`
+	Compare               CompareConfig       `yaml:"compare"`
+	History               HistoryConfig       `yaml:"history"`
+	Batch                 BatchConfig         `yaml:"batch"`
+	Subscriptions         SubscriptionsConfig `yaml:"subscriptions"`
+	Geolocation           GeolocationConfig   `yaml:"geolocation"`
+	Alerts                AlertsConfig        `yaml:"alerts"`
+	// Prewarm lists the popular locations whose forecasts are refreshed in the cache before they expire
+	Prewarm []PrewarmConfig `yaml:"prewarm"`
+	// ServiceArea rejects the locations the API doesn't serve, every location is served by default
+	ServiceArea ServiceAreaConfig `yaml:"service_area"`
+	// NormalizeLongitude accepts the longitudes beyond 180 degrees, e.g. 200 as -160, they are rejected by default
+	NormalizeLongitude bool `yaml:"normalize_longitude"`
+	// DisabledProviders turns off auxiliary providers by name, see AuxiliaryProviders
+	DisabledProviders []string `yaml:"disabled_providers"`
+	// AllowDegraded starts the service without the providers missing their API key, with a warning, rather than
+	// failing
+	AllowDegraded bool `yaml:"allow_degraded"`
+}
+
+// PrewarmConfig is a location kept warm in the forecast cache, Days defaults to 5
+type PrewarmConfig struct {
+	Name string  `yaml:"name"`
+	Lat  float64 `yaml:"lat"`
+	Lon  float64 `yaml:"lon"`
+	Days int     `yaml:"days"`
+}
+
+// ServiceAreaConfig restricts the locations served to the Allow boxes, anywhere when there is none, out of the
+// Deny boxes. The requests for other locations are answered 422 before any provider is called.
+type ServiceAreaConfig struct {
+	// RejectNullIsland rejects lat=0 and lon=0, sent by the clients that failed to set their location
+	RejectNullIsland bool            `yaml:"reject_null_island"`
+	Allow            []AreaBoxConfig `yaml:"allow"`
+	Deny             []AreaBoxConfig `yaml:"deny"`
+}
+
+// AreaBoxConfig is a bounding box of coordinates, a west edge east of the east one crosses the antimeridian
+type AreaBoxConfig struct {
+	Name  string  `yaml:"name"`
+	South float64 `yaml:"south"`
+	West  float64 `yaml:"west"`
+	North float64 `yaml:"north"`
+	East  float64 `yaml:"east"`
+}
+
+// Area returns the service area the configuration describes
+func (a ServiceAreaConfig) Area() geo.Area {
+	boxes := func(entries []AreaBoxConfig) []geo.Box {
+		var boxes []geo.Box
+		for _, e := range entries {
+			boxes = append(boxes, geo.Box{Name: e.Name, South: e.South, West: e.West, North: e.North, East: e.East})
+		}
+		return boxes
+	}
+
+	return geo.Area{Allow: boxes(a.Allow), Deny: boxes(a.Deny), RejectNullIsland: a.RejectNullIsland}
+}
+
+// AuxiliaryProviders are the providers of the endpoints beyond forecasts, they are enabled unless disabled by name
+var AuxiliaryProviders = []string{"open-meteo-air-quality", "open-meteo-marine"}
+
+// AlertsConfig enables the alert sources of /weather/alerts serving no forecasts,
+// the configured providers exposing alerts are always queried
+type AlertsConfig struct {
+	NWS NWSConfig `yaml:"nws"`
+}
+
+// NWSConfig configures the alerts of the US National Weather Service
+type NWSConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// UserAgent identifies the application to the NWS API, empty selects a default naming this project
+	UserAgent string `yaml:"user_agent"`
+}
+
+// GeolocationConfig enables locating the callers of /weather without coordinates from their IP address
+type GeolocationConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// BatchConfig contains configuration of the batch forecast endpoint
+type BatchConfig struct {
+	// MaxItems is the largest number of locations of a batch (default 50)
+	MaxItems int `yaml:"max_items"`
+	// Concurrency is the number of locations of a batch fetched at the same time (default 4)
+	Concurrency int `yaml:"concurrency"`
+}
+
+// SubscriptionsConfig contains configuration of the live forecast subscriptions
+type SubscriptionsConfig struct {
+	// MinIntervalSeconds is the shortest update interval a subscriber may ask for (default 60)
+	MinIntervalSeconds int `yaml:"min_interval_seconds"`
+	// MaxDurationMinutes is how long a subscription stays open before the server closes it (default 60)
+	MaxDurationMinutes int `yaml:"max_duration_minutes"`
+}
+
+// HistoryConfig contains configuration of the historical weather endpoint
+type HistoryConfig struct {
+	// MaxDays is the longest date range of a request (default 366)
+	MaxDays int `yaml:"max_days"`
+}
+
+// AggregationConfig tunes how provider forecasts are merged by the aggregate endpoint
+type AggregationConfig struct {
+	// OutlierMADs is the distance from the median, in median absolute deviations,
+	// beyond which a provider value is rejected (default 3)
+	OutlierMADs float64 `yaml:"outlier_mads"`
+	// MinProviders is the number of providers an aggregate needs (default 1),
+	// it can be overridden per request with the min_providers query parameter
+	MinProviders int `yaml:"min_providers"`
+	// HighConfidenceSpread and MediumConfidenceSpread are the largest spreads, in °C, of the aggregated days
+	// labeled high (default 2) and medium (default 5) confidence, the days spreading more are labeled low
+	HighConfidenceSpread   float64 `yaml:"high_confidence_spread"`
+	MediumConfidenceSpread float64 `yaml:"medium_confidence_spread"`
+}
+
+// CompareConfig tunes the comparison of the providers by the compare endpoint
+type CompareConfig struct {
+	// Threshold is the difference, in °C, between two providers beyond which a day is flagged (default 3)
+	Threshold float64 `yaml:"threshold"`
+}
+
+// RulesConfig contains the post-processing rules applied to provider forecasts
+type RulesConfig struct {
+	MaxEvaluationMS int          `yaml:"max_evaluation_ms"`
+	Rules           []RuleConfig `yaml:"rules"`
+}
+
+// RuleConfig is a single post-processing rule: when every condition set matches a
+// forecast day, the action (drop, flag_suspect or exclude_from_aggregate) is applied
+type RuleConfig struct {
+	Name       string  `yaml:"name"`
+	Provider   string  `yaml:"provider,omitempty"`
+	Field      string  `yaml:"field,omitempty"`
+	Comparator string  `yaml:"comparator,omitempty"`
+	Value      float64 `yaml:"value,omitempty"`
+	DateScope  string  `yaml:"date_scope,omitempty"`
+	Action     string  `yaml:"action"`
 }
 
 // WeatherAPIConfig represents configuration for a weather API provider
 type WeatherAPIConfig struct {
-	Name    string `yaml:"name" validate:"required"`
-	APIKey  string `yaml:"api_key,omitempty"`
+	Name string `yaml:"name" validate:"required"`
+	// Enabled keeps the provider configured but out of rotation when false, true when not set
+	Enabled *bool  `yaml:"enabled,omitempty"`
+	APIKey  string `yaml:"api_key,omitempty" secret:"true"`
 	BaseURL string `yaml:"base_url,omitempty"`
-	Timeout int    `yaml:"timeout" default:"30"`
+	// Endpoint selects the forecast endpoint of weatherapi: forecast, the 3-hourly and daily endpoints (default),
+	// or onecall, the 8-day daily forecast of One Call 3.0
+	Endpoint string `yaml:"endpoint,omitempty"`
+	// Timeout bounds every request to the provider, in seconds
+	Timeout int           `yaml:"timeout" default:"30"`
+	Canary  *CanaryConfig `yaml:"canary,omitempty"`
+	// LatencyMS and FailureRate tune the artificial behavior of the mock provider
+	LatencyMS   int     `yaml:"latency_ms,omitempty"`
+	FailureRate float64 `yaml:"failure_rate,omitempty"`
+	// Models and PastDays are passed through to Open-Meteo
+	Models   []string `yaml:"models,omitempty"`
+	PastDays int      `yaml:"past_days,omitempty"`
+	// Weight of the provider in the weighted mean aggregation, 1 when not set
+	Weight *float64 `yaml:"weight,omitempty"`
+	// MaxConcurrent bounds the calls in flight to the provider, 0 means no limit
+	MaxConcurrent int `yaml:"max_concurrent,omitempty"`
+	// Quota counts the calls to the provider against the limit of its plan
+	Quota *QuotaConfig `yaml:"quota,omitempty"`
+}
+
+// IsEnabled reports whether the provider serves forecasts, see Enabled
+func (a WeatherAPIConfig) IsEnabled() bool {
+	return a.Enabled == nil || *a.Enabled
+}
+
+// CanaryConfig describes an alternative configuration of a weather API provider
+// that receives a percentage of the provider's traffic
+type CanaryConfig struct {
+	Percent int    `yaml:"percent"`
+	APIKey  string `yaml:"api_key,omitempty" secret:"true"`
+	BaseURL string `yaml:"base_url,omitempty"`
+	// Override holds the other settings of the canary, e.g. its endpoint, the ones left unset are the provider's.
+	// Its name, enabled and canary can't be overridden.
+	Override *WeatherAPIConfig `yaml:"override,omitempty"`
+}
+
+// Member returns the configuration of the canary member of api: api with the settings of Override, then APIKey
+// and BaseURL, replacing its own
+func (c CanaryConfig) Member(api WeatherAPIConfig) WeatherAPIConfig {
+	member := api
+	member.Canary = nil
+
+	if c.Override != nil {
+		dst := reflect.ValueOf(&member).Elem()
+		src := reflect.ValueOf(*c.Override)
+		for i := 0; i < src.NumField(); i++ {
+			switch src.Type().Field(i).Name {
+			case "Name", "Enabled", "Canary":
+				continue
+			}
+			if field := src.Field(i); !field.IsZero() {
+				dst.Field(i).Set(field)
+			}
+		}
+	}
+	if c.APIKey != "" {
+		member.APIKey = c.APIKey
+	}
+	if c.BaseURL != "" {
+		member.BaseURL = c.BaseURL
+	}
+
+	return member
+}
+
+// QuotaPeriods are the reset periods of a provider quota, in UTC
+var QuotaPeriods = []string{"daily", "monthly"}
+
+// QuotaConfig is the quota of calls of a weather API provider, reset every period, daily by default. The calls
+// beyond the limit are counted only, unless Enforce skips the provider until the reset.
+type QuotaConfig struct {
+	Limit   int64  `yaml:"limit"`
+	Period  string `yaml:"period,omitempty"`
+	Enforce bool   `yaml:"enforce,omitempty"`
 }
 
 // LogConfig contains logging configuration
 type LogConfig struct {
 	Level  string `envconfig:"LOG_LEVEL" yaml:"level" default:"info"`
 	Format string `envconfig:"LOG_FORMAT" yaml:"format" default:"json"`
+	// DisableAccess turns off the log of every HTTP request
+	DisableAccess bool `envconfig:"LOG_DISABLE_ACCESS" yaml:"disable_access"`
+	// AccessSampleAfter is the number of requests logged every second before sampling starts, 0 logs every
+	// request, AccessSampleEvery is the share logged beyond it, one in AccessSampleEvery (default 100)
+	AccessSampleAfter int `envconfig:"LOG_ACCESS_SAMPLE_AFTER" yaml:"access_sample_after"`
+	AccessSampleEvery int `envconfig:"LOG_ACCESS_SAMPLE_EVERY" yaml:"access_sample_every"`
+	// RedactKeys are the field names whose values are redacted, in addition to api_key, appid, authorization and token
+	RedactKeys []string `envconfig:"LOG_REDACT_KEYS" yaml:"redact_keys"`
+	// SampleInitial is the number of identical lines logged every second before sampling starts, 0 logs every
+	// line, SampleThereafter is the share logged beyond it, one in SampleThereafter
+	SampleInitial    int `envconfig:"LOG_SAMPLE_INITIAL" yaml:"sample_initial"`
+	SampleThereafter int `envconfig:"LOG_SAMPLE_THEREAFTER" yaml:"sample_thereafter"`
+	// ProviderErrorsEvery logs one in every failures of a provider, ProviderErrorsInterval at most one per
+	// interval in seconds, 0 disables either limit
+	ProviderErrorsEvery    int `envconfig:"LOG_PROVIDER_ERRORS_EVERY" yaml:"provider_errors_every"`
+	ProviderErrorsInterval int `envconfig:"LOG_PROVIDER_ERRORS_INTERVAL" yaml:"provider_errors_interval" default:"60"`
+	// FilePath writes the logs to a file as well, rotated beyond FileMaxSizeMB (default 100), the rotated files
+	// are kept FileMaxBackups at most for FileMaxAgeDays days, 0 keeps all of them
+	FilePath       string `envconfig:"LOG_FILE_PATH" yaml:"file_path"`
+	FileMaxSizeMB  int    `envconfig:"LOG_FILE_MAX_SIZE_MB" yaml:"file_max_size_mb"`
+	FileMaxBackups int    `envconfig:"LOG_FILE_MAX_BACKUPS" yaml:"file_max_backups"`
+	FileMaxAgeDays int    `envconfig:"LOG_FILE_MAX_AGE_DAYS" yaml:"file_max_age_days"`
+	FileCompress   bool   `envconfig:"LOG_FILE_COMPRESS" yaml:"file_compress"`
+}
+
+// AdminConfig contains configuration of the admin API
+type AdminConfig struct {
+	Token string `envconfig:"ADMIN_TOKEN" yaml:"token" secret:"true"`
+}
+
+// TracingConfig exports the traces of the requests over OTLP, the exporter is configured by the standard
+// OTEL_EXPORTER_OTLP_* environment variables
+type TracingConfig struct {
+	Enabled bool `envconfig:"TRACING_ENABLED" yaml:"enabled"`
+}
+
+// DebugConfig exposes the profiling endpoints, see DebugEndpoints
+type DebugConfig struct {
+	// Endpoints enables them outside development
+	Endpoints bool `envconfig:"DEBUG_ENDPOINTS" yaml:"endpoints"`
+	// AllowProduction is the second flag needed to enable them in production
+	AllowProduction bool `envconfig:"DEBUG_ALLOW_PRODUCTION" yaml:"allow_production"`
+}
+
+// AuthConfig enables the API keys of the public endpoints
+type AuthConfig struct {
+	Enabled bool `envconfig:"AUTH_ENABLED" yaml:"enabled"`
+	// Keys are the accepted API keys, only their hash is configured
+	Keys []APIKeyConfig `yaml:"keys"`
+	// KeysFile is a YAML list of more keys in the same shape, added to Keys when the configuration is loaded
+	KeysFile string `envconfig:"AUTH_KEYS_FILE" yaml:"keys_file"`
+	// OpenPaths are the path prefixes served without a key (default /manage, /swagger and /admin,
+	// the admin API has its own token)
+	OpenPaths []string `yaml:"open_paths"`
+}
+
+// APIKeyConfig is an API key, its name identifies the client in the logs and the rate limiter
+type APIKeyConfig struct {
+	Name string `yaml:"name"`
+	// SHA256 is the hex SHA-256 hash of the key
+	SHA256 string `yaml:"sha256" secret:"true"`
 }
 
+// DefaultOpenPaths are the paths served without an API key unless auth.open_paths is set
+var DefaultOpenPaths = []string{"/manage", "/swagger", "/admin"}
+
 // ConfigProvider defines the interface for configuration providers
 type ConfigProvider interface {
 	Load() (*Config, error)
@@ -88,9 +636,33 @@ func (p *FileConfigProvider) Load() (*Config, error) {
 		return nil, fmt.Errorf("failed to process environment variables: %w", err)
 	}
 
+	if err := loadKeysFile(&config.Auth); err != nil {
+		return nil, fmt.Errorf("failed to load API keys: %w", err)
+	}
+
 	return config, nil
 }
 
+// loadKeysFile adds the keys of the keys file to the configured ones
+func loadKeysFile(auth *AuthConfig) error {
+	if auth.KeysFile == "" {
+		return nil
+	}
+
+	data, err := os.ReadFile(auth.KeysFile)
+	if err != nil {
+		return err
+	}
+
+	var keys []APIKeyConfig
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		return fmt.Errorf("parse %s: %w", auth.KeysFile, err)
+	}
+	auth.Keys = append(auth.Keys, keys...)
+
+	return nil
+}
+
 // loadFromFile loads configuration from YAML file
 func (p *FileConfigProvider) loadFromFile(config *Config) error {
 	// Try multiple possible config file locations
@@ -107,18 +679,48 @@ func (p *FileConfigProvider) loadFromFile(config *Config) error {
 
 	for _, path := range configPaths {
 		if configData, err = os.ReadFile(path); err == nil {
+			config.file = absPath(path)
 			break
 		}
 	}
 
 	if err != nil {
 		// Config file is optional, return without error
-		return nil
+		return checkMovedSettings(nil)
 	}
 
 	if err := yaml.Unmarshal(configData, config); err != nil {
 		return fmt.Errorf("failed to parse YAML config: %w", err)
 	}
+	var raw map[string]any
+	if err := yaml.Unmarshal(configData, &raw); err != nil {
+		return fmt.Errorf("failed to parse YAML config: %w", err)
+	}
+
+	return checkMovedSettings(raw)
+}
+
+// movedSettings are the settings moved to another key, with their environment variables
+var movedSettings = []struct{ key, to, env, toEnv string }{
+	{"server.rate_limit", "rate_limit.requests_per_minute", "SERVER_RATE_LIMIT", "RATE_LIMIT_REQUESTS_PER_MINUTE"},
+	{"server.rate_limit_burst", "rate_limit.burst", "SERVER_RATE_LIMIT_BURST", "RATE_LIMIT_BURST"},
+	{"weather.cache_ttl_seconds", "cache.ttl_seconds", "", ""},
+	{"weather.cache_max_entries", "cache.max_entries and cache.enabled", "", ""},
+}
+
+// checkMovedSettings rejects the moved settings set in the file, or in the environment, rather than ignoring them
+func checkMovedSettings(file map[string]any) error {
+	for _, m := range movedSettings {
+		section, name, _ := strings.Cut(m.key, ".")
+		if values, ok := file[section].(map[string]any); ok {
+			if _, ok := values[name]; ok {
+				return fmt.Errorf("%s moved to %s", m.key, m.to)
+			}
+		}
+		if _, ok := os.LookupEnv(m.env); ok && m.env != "" {
+			return fmt.Errorf("%s moved to %s", m.env, m.toEnv)
+		}
+	}
 
 	return nil
 }
@@ -148,6 +750,59 @@ func (p *FileConfigProvider) Validate(config *Config) error {
 	if config.Server.IdleTimeout <= 0 {
 		errors = append(errors, "server.idle_timeout must be positive")
 	}
+	if config.Server.BodyLimitKB < 0 {
+		errors = append(errors, "server.body_limit_kb must not be negative")
+	}
+	if config.Server.RequestTimeout < 0 {
+		errors = append(errors, "server.request_timeout must not be negative")
+	}
+	if config.Server.DrainSeconds < 0 {
+		errors = append(errors, "server.drain_seconds must not be negative")
+	}
+	if config.Server.GRPCPort != "" && config.Server.GRPCPort == config.Server.Port {
+		errors = append(errors, "server.grpc_port must differ from server.port")
+	}
+	if (config.Server.TLSCertFile == "") != (config.Server.TLSKeyFile == "") {
+		errors = append(errors, "server.tls_cert_file and server.tls_key_file must be set together")
+	}
+	if config.Server.TLSCertFile != "" && len(config.Server.AutocertHosts) > 0 {
+		errors = append(errors, "server.tls_cert_file and server.autocert_hosts are exclusive")
+	}
+	if len(config.Server.AutocertHosts) > 0 && config.Server.AutocertCacheDir == "" {
+		errors = append(errors, "server.autocert_cache_dir is required with server.autocert_hosts")
+	}
+	if config.Server.RedirectPort != "" {
+		if !config.Server.TLS() {
+			errors = append(errors, "server.redirect_port requires server.tls_cert_file or server.autocert_hosts")
+		}
+		if config.Server.RedirectPort == config.Server.Port || config.Server.RedirectPort == config.Server.GRPCPort {
+			errors = append(errors, "server.redirect_port must differ from server.port and server.grpc_port")
+		}
+	}
+
+	if config.Server.CompressionLevel != "" && !slices.Contains(CompressionLevels, config.Server.CompressionLevel) {
+		errors = append(errors, "server.compression_level must be one of: "+strings.Join(CompressionLevels, ", "))
+	}
+	if config.Server.CompressionMinBytes < 0 {
+		errors = append(errors, "server.compression_min_bytes must not be negative")
+	}
+	if config.IsProduction() && len(config.Server.CORS.AllowOrigins) == 0 {
+		errors = append(errors, "server.cors.allow_origins is required in production")
+	}
+	for i, origin := range config.Server.CORS.AllowOrigins {
+		if !validOrigin(origin) {
+			errors = append(errors, fmt.Sprintf("server.cors.allow_origins[%d] must be * or an origin like https://example.com", i))
+		}
+		if origin == "*" && config.Server.CORS.AllowCredentials {
+			errors = append(errors, "server.cors.allow_credentials can't allow any origin, list them")
+		}
+	}
+	if config.Server.CORS.AllowCredentials && len(config.Server.CORS.AllowOrigins) == 0 {
+		errors = append(errors, "server.cors.allow_credentials requires server.cors.allow_origins")
+	}
+	if config.Server.CORS.MaxAgeSeconds < 0 {
+		errors = append(errors, "server.cors.max_age_seconds must not be negative")
+	}
 
 	// Validate Weather APIs
 
@@ -158,6 +813,145 @@ func (p *FileConfigProvider) Validate(config *Config) error {
 		if api.Timeout <= 0 {
 			errors = append(errors, fmt.Sprintf("weather.apis[%d].timeout must be positive", i))
 		}
+		if api.LatencyMS < 0 {
+			errors = append(errors, fmt.Sprintf("weather.apis[%d].latency_ms must not be negative", i))
+		}
+		if api.FailureRate < 0 || api.FailureRate > 1 {
+			errors = append(errors, fmt.Sprintf("weather.apis[%d].failure_rate must be between 0 and 1", i))
+		}
+		if api.PastDays < 0 || api.PastDays > 92 {
+			errors = append(errors, fmt.Sprintf("weather.apis[%d].past_days must be between 0 and 92", i))
+		}
+		if api.MaxConcurrent < 0 {
+			errors = append(errors, fmt.Sprintf("weather.apis[%d].max_concurrent must not be negative", i))
+		}
+		if api.Weight != nil && *api.Weight <= 0 {
+			errors = append(errors, fmt.Sprintf("weather.apis[%d].weight must be positive", i))
+		}
+		if api.Canary != nil && (api.Canary.Percent < 0 || api.Canary.Percent > 100) {
+			errors = append(errors, fmt.Sprintf("weather.apis[%d].canary.percent must be between 0 and 100", i))
+		}
+		if api.Canary != nil && api.Canary.Override != nil {
+			override := api.Canary.Override
+			if override.Name != "" && override.Name != api.Name {
+				errors = append(errors, fmt.Sprintf("weather.apis[%d].canary.override.name must be empty or %s", i, api.Name))
+			}
+			if override.Enabled != nil || override.Canary != nil {
+				errors = append(errors, fmt.Sprintf("weather.apis[%d].canary.override must not set enabled or canary", i))
+			}
+			if override.Timeout < 0 {
+				errors = append(errors, fmt.Sprintf("weather.apis[%d].canary.override.timeout must not be negative", i))
+			}
+		}
+		if api.Quota != nil && api.Quota.Limit <= 0 {
+			errors = append(errors, fmt.Sprintf("weather.apis[%d].quota.limit must be positive", i))
+		}
+		if api.Quota != nil && api.Quota.Period != "" && !slices.Contains(QuotaPeriods, api.Quota.Period) {
+			errors = append(errors, fmt.Sprintf("weather.apis[%d].quota.period must be one of: %s", i, strings.Join(QuotaPeriods, ", ")))
+		}
+	}
+
+	if config.Weather.MaxForecastDays < 0 {
+		errors = append(errors, "weather.max_forecast_days must not be negative")
+	}
+	if config.Weather.MaxConcurrentRequests < 0 {
+		errors = append(errors, "weather.max_concurrent_requests must not be negative")
+	}
+	if config.Weather.Aggregation.OutlierMADs < 0 {
+		errors = append(errors, "weather.aggregation.outlier_mads must not be negative")
+	}
+	if config.Weather.Aggregation.MinProviders < 0 {
+		errors = append(errors, "weather.aggregation.min_providers must not be negative")
+	}
+	if config.Weather.Compare.Threshold < 0 {
+		errors = append(errors, "weather.compare.threshold must not be negative")
+	}
+	if high, medium := config.Weather.Aggregation.HighConfidenceSpread, config.Weather.Aggregation.MediumConfidenceSpread; high < 0 || medium < 0 {
+		errors = append(errors, "weather.aggregation.high_confidence_spread and medium_confidence_spread must not be negative")
+	} else if medium == 0 && high > 5 || medium > 0 && high > medium {
+		errors = append(errors, "weather.aggregation.high_confidence_spread must not exceed medium_confidence_spread (default 5)")
+	}
+	if config.Weather.History.MaxDays < 0 {
+		errors = append(errors, "weather.history.max_days must not be negative")
+	}
+	if config.Weather.Batch.MaxItems < 0 {
+		errors = append(errors, "weather.batch.max_items must not be negative")
+	}
+	if config.Weather.Batch.Concurrency < 0 {
+		errors = append(errors, "weather.batch.concurrency must not be negative")
+	}
+	if config.Weather.Subscriptions.MinIntervalSeconds < 0 {
+		errors = append(errors, "weather.subscriptions.min_interval_seconds must not be negative")
+	}
+	if config.Weather.Subscriptions.MaxDurationMinutes < 0 {
+		errors = append(errors, "weather.subscriptions.max_duration_minutes must not be negative")
+	}
+
+	if len(config.Weather.Prewarm) > 0 && !config.Cache.Enabled {
+		errors = append(errors, "weather.prewarm needs the forecast cache, set cache.enabled")
+	}
+	maxDays := config.Weather.MaxForecastDays
+	if maxDays == 0 {
+		maxDays = 16
+	}
+	for i, loc := range config.Weather.Prewarm {
+		if loc.Lat < -90 || loc.Lat > 90 || loc.Lon < -180 || loc.Lon > 180 {
+			errors = append(errors, fmt.Sprintf("weather.prewarm[%d]: lat must be between -90 and 90 and lon between -180 and 180", i))
+		}
+		if loc.Days < 0 || loc.Days > maxDays {
+			errors = append(errors, fmt.Sprintf("weather.prewarm[%d].days must be between 0 and %d", i, maxDays))
+		}
+	}
+
+	area := config.Weather.ServiceArea.Area()
+	for i, box := range area.Allow {
+		if err := box.Validate(); err != nil {
+			errors = append(errors, fmt.Sprintf("weather.service_area.allow[%d]: %v", i, err))
+		}
+	}
+	for i, box := range area.Deny {
+		if err := box.Validate(); err != nil {
+			errors = append(errors, fmt.Sprintf("weather.service_area.deny[%d]: %v", i, err))
+		}
+	}
+
+	for _, name := range config.Weather.DisabledProviders {
+		if !slices.Contains(AuxiliaryProviders, name) {
+			errors = append(errors, fmt.Sprintf("weather.disabled_providers: unknown provider %s, expected one of: %s",
+				name, strings.Join(AuxiliaryProviders, ", ")))
+		}
+	}
+
+	switch config.Weather.HTTPMode {
+	case "", "live":
+	case "record", "replay":
+		if config.Weather.FixturesDir == "" {
+			errors = append(errors, fmt.Sprintf("weather.fixtures_dir is required in %s mode", config.Weather.HTTPMode))
+		}
+	default:
+		errors = append(errors, "weather.http_mode must be one of: live, record, replay")
+	}
+
+	// Validate Auth config
+	if config.Auth.Enabled && len(config.Auth.Keys) == 0 {
+		errors = append(errors, "auth.keys or auth.keys_file is required when auth is enabled")
+	}
+	names := make(map[string]bool, len(config.Auth.Keys))
+	for i, key := range config.Auth.Keys {
+		if key.Name == "" {
+			errors = append(errors, fmt.Sprintf("auth.keys[%d].name is required", i))
+		} else if names[key.Name] {
+			errors = append(errors, fmt.Sprintf("auth.keys[%d].name %s is not unique", i, key.Name))
+		}
+		names[key.Name] = true
+		if !validSHA256(key.SHA256) {
+			errors = append(errors, fmt.Sprintf("auth.keys[%d].sha256 must be a hex SHA-256 hash", i))
+		}
+	}
+
+	// Validate Debug config
+	if config.IsProduction() && config.Debug.Endpoints && !config.Debug.AllowProduction {
+		errors = append(errors, "debug.endpoints requires debug.allow_production in production")
 	}
 
 	// Validate Log config
@@ -167,14 +961,94 @@ func (p *FileConfigProvider) Validate(config *Config) error {
 	if config.Log.Format == "" {
 		errors = append(errors, "log.format is required")
 	}
+	if config.Log.AccessSampleAfter < 0 {
+		errors = append(errors, "log.access_sample_after must not be negative")
+	}
+	if config.Log.AccessSampleEvery < 0 {
+		errors = append(errors, "log.access_sample_every must not be negative")
+	}
+	if config.Log.SampleInitial < 0 {
+		errors = append(errors, "log.sample_initial must not be negative")
+	}
+	if config.Log.SampleThereafter < 0 {
+		errors = append(errors, "log.sample_thereafter must not be negative")
+	}
+	if config.Log.ProviderErrorsEvery < 0 {
+		errors = append(errors, "log.provider_errors_every must not be negative")
+	}
+	if config.Log.ProviderErrorsInterval < 0 {
+		errors = append(errors, "log.provider_errors_interval must not be negative")
+	}
+	if config.Log.FileMaxSizeMB < 0 {
+		errors = append(errors, "log.file_max_size_mb must not be negative")
+	}
+	if config.Log.FileMaxBackups < 0 {
+		errors = append(errors, "log.file_max_backups must not be negative")
+	}
+	if config.Log.FileMaxAgeDays < 0 {
+		errors = append(errors, "log.file_max_age_days must not be negative")
+	}
+
+	// Validate the cache, rate limit and breaker config
+	if config.Cache.MaxEntries < 0 {
+		errors = append(errors, "cache.max_entries must not be negative")
+	}
+	if config.Cache.TTLSeconds < 0 || config.Cache.TTLSeconds > maxCacheTTLSeconds {
+		errors = append(errors, fmt.Sprintf("cache.ttl_seconds must be between 0 and %d (a day)", maxCacheTTLSeconds))
+	}
+	if config.Cache.Backend != "" && !slices.Contains(CacheBackends, config.Cache.Backend) {
+		errors = append(errors, "cache.backend must be one of: "+strings.Join(CacheBackends, ", "))
+	}
+	if config.Cache.UseRedis() && config.Cache.Redis.Address == "" {
+		errors = append(errors, "cache.redis.address is required by the redis backend")
+	}
+	if config.Cache.Redis.DB < 0 {
+		errors = append(errors, "cache.redis.db must not be negative")
+	}
+	if config.RateLimit.RequestsPerMinute < 0 {
+		errors = append(errors, "rate_limit.requests_per_minute must not be negative")
+	}
+	if config.RateLimit.Burst < 0 {
+		errors = append(errors, "rate_limit.burst must not be negative")
+	}
+	if config.Breaker.FailureThreshold < 0 {
+		errors = append(errors, "breaker.failure_threshold must not be negative")
+	}
+	if config.Breaker.CooldownSeconds < 0 || config.Breaker.CooldownSeconds > maxBreakerCooldownSeconds {
+		errors = append(errors, fmt.Sprintf("breaker.cooldown_seconds must be between 0 and %d (an hour)", maxBreakerCooldownSeconds))
+	}
+	if config.Store.QueueSize < 0 {
+		errors = append(errors, "store.queue_size must not be negative")
+	}
+	if config.Store.Accuracy.IntervalMinutes < 0 {
+		errors = append(errors, "store.accuracy.interval_minutes must not be negative")
+	}
+	if config.Store.Accuracy.IntervalMinutes > 0 && config.Store.Path == "" {
+		errors = append(errors, "store.accuracy needs the forecast store, set store.path")
+	}
+	if config.Store.Accuracy.WindowDays < 0 || config.Store.Accuracy.WindowDays > maxAccuracyWindowDays {
+		errors = append(errors, fmt.Sprintf("store.accuracy.window_days must be between 0 and %d", maxAccuracyWindowDays))
+	}
+	if config.Webhooks.MinIntervalMinutes < 0 || config.Webhooks.MaxFailures < 0 || config.Webhooks.TimeoutSeconds < 0 {
+		errors = append(errors, "webhooks.min_interval_minutes, max_failures and timeout_seconds must not be negative")
+	}
 
 	if len(errors) > 0 {
-		return fmt.Errorf("configuration validation failed: %s", strings.Join(errors, "; "))
+		return &ValidationError{Problems: errors}
 	}
 
 	return nil
 }
 
+// ValidationError lists every problem found by Validate
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "configuration validation failed: " + strings.Join(e.Problems, "; ")
+}
+
 // NewConfig creates a new configuration instance
 func NewConfig() (*Config, error) {
 	return NewConfigWithProvider(NewFileConfigProvider("config/config.yaml"))
@@ -194,6 +1068,62 @@ func NewConfigWithProvider(provider ConfigProvider) (*Config, error) {
 	return config, nil
 }
 
+// validSHA256 reports whether s is a hex encoded SHA-256 hash
+func validSHA256(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == sha256.Size
+}
+
+// validOrigin reports whether s is *, or a scheme and a host with an optional port, *. prefixing the host
+// allows its subdomains
+func validOrigin(s string) bool {
+	if s == "*" {
+		return true
+	}
+	u, err := url.Parse(strings.Replace(s, "://*.", "://", 1))
+
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && u.User == nil &&
+		u.Path == "" && u.RawQuery == "" && u.Fragment == ""
+}
+
+// APIKeys returns the names of the API keys keyed by their lowercase hex SHA-256 hash
+func (c *Config) APIKeys() map[string]string {
+	keys := make(map[string]string, len(c.Auth.Keys))
+	for _, key := range c.Auth.Keys {
+		keys[strings.ToLower(key.SHA256)] = key.Name
+	}
+
+	return keys
+}
+
+// OpenPaths returns the path prefixes served without an API key
+func (c *Config) OpenPaths() []string {
+	if c.Auth.OpenPaths != nil {
+		return c.Auth.OpenPaths
+	}
+
+	return DefaultOpenPaths
+}
+
+// DebugEndpoints reports whether the pprof and runtime endpoints are served: always in development,
+// with debug.endpoints elsewhere, in production only with debug.allow_production as well
+func (c *Config) DebugEndpoints() bool {
+	if c.IsProduction() {
+		return c.Debug.Endpoints && c.Debug.AllowProduction
+	}
+
+	return c.IsDevelopment() || c.Debug.Endpoints
+}
+
+// CORSOrigins returns the origins allowed to call the API from a browser, any in development unless configured
+func (c *Config) CORSOrigins() []string {
+	if len(c.Server.CORS.AllowOrigins) == 0 && c.IsDevelopment() {
+		return []string{"*"}
+	}
+
+	return c.Server.CORS.AllowOrigins
+}
+
 // IsDevelopment returns true if the application is running in development mode
 func (c *Config) IsDevelopment() bool {
 	return c.App.Env == "development"
@@ -214,7 +1144,75 @@ func (c *Config) GetWeatherAPIByName(name string) (*WeatherAPIConfig, bool) {
 	return nil, false
 }
 
+// ProviderWeights returns the configured aggregation weights keyed by provider name
+func (c *Config) ProviderWeights() map[string]float64 {
+	weights := make(map[string]float64)
+	for _, api := range c.Weather.APIs {
+		if api.Weight != nil {
+			weights[api.Name] = *api.Weight
+		}
+	}
+	return weights
+}
+
+// ProviderTimeouts returns the configured request timeouts keyed by provider name
+func (c *Config) ProviderTimeouts() map[string]time.Duration {
+	timeouts := make(map[string]time.Duration)
+	for _, api := range c.Weather.APIs {
+		if api.Timeout > 0 {
+			timeouts[api.Name] = time.Duration(api.Timeout) * time.Second
+		}
+	}
+	return timeouts
+}
+
+// ProviderConcurrencyLimits returns the configured concurrency limits keyed by provider name
+func (c *Config) ProviderConcurrencyLimits() map[string]int {
+	limits := make(map[string]int)
+	for _, api := range c.Weather.APIs {
+		if api.MaxConcurrent > 0 {
+			limits[api.Name] = api.MaxConcurrent
+		}
+	}
+	return limits
+}
+
+// ProviderEnabled reports whether the auxiliary provider is not disabled
+func (c *Config) ProviderEnabled(name string) bool {
+	return !slices.Contains(c.Weather.DisabledProviders, name)
+}
+
 // GetWeatherAPIs returns all configured weather APIs
 func (c *Config) GetWeatherAPIs() []WeatherAPIConfig {
 	return c.Weather.APIs
 }
+
+// DiffWeatherAPIs returns the names of the providers added, removed and changed from old to updated, e.g. on a
+// reload. The names are sorted, the API keys changed are reported as a changed provider and never shown.
+func DiffWeatherAPIs(old, updated []WeatherAPIConfig) (added, removed, changed []string) {
+	previous := make(map[string]WeatherAPIConfig, len(old))
+	for _, api := range old {
+		previous[api.Name] = api
+	}
+	next := make(map[string]bool, len(updated))
+	for _, api := range updated {
+		next[api.Name] = true
+		before, ok := previous[api.Name]
+		switch {
+		case !ok:
+			added = append(added, api.Name)
+		case !reflect.DeepEqual(before, api):
+			changed = append(changed, api.Name)
+		}
+	}
+	for _, api := range old {
+		if !next[api.Name] {
+			removed = append(removed, api.Name)
+		}
+	}
+	slices.Sort(added)
+	slices.Sort(removed)
+	slices.Sort(changed)
+
+	return added, removed, changed
+}
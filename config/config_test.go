@@ -1,8 +1,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -23,6 +26,8 @@ func TestNewConfig(t *testing.T) {
 	assert.Equal(t, 10, config.Server.ReadTimeout)
 	assert.Equal(t, 10, config.Server.WriteTimeout)
 	assert.Equal(t, 120, config.Server.IdleTimeout)
+	assert.False(t, config.Server.TrustedProxy)
+	assert.False(t, config.Weather.Geolocation.Enabled)
 	assert.Equal(t, "info", config.Log.Level)
 	assert.Equal(t, "json", config.Log.Format)
 
@@ -37,6 +42,7 @@ func TestConfigWithEnvironmentVariables(t *testing.T) {
 	os.Setenv("APP_ENV", "production")
 	os.Setenv("SERVER_PORT", "9090")
 	os.Setenv("LOG_LEVEL", "debug")
+	os.Setenv("SERVER_CORS_ALLOW_ORIGINS", "https://app.example.com,https://*.example.org")
 
 	defer func() {
 		os.Unsetenv("APP_NAME")
@@ -44,6 +50,7 @@ func TestConfigWithEnvironmentVariables(t *testing.T) {
 		os.Unsetenv("APP_ENV")
 		os.Unsetenv("SERVER_PORT")
 		os.Unsetenv("LOG_LEVEL")
+		os.Unsetenv("SERVER_CORS_ALLOW_ORIGINS")
 	}()
 
 	provider := NewFileConfigProvider("nonexistent.yaml")
@@ -55,6 +62,7 @@ func TestConfigWithEnvironmentVariables(t *testing.T) {
 	assert.Equal(t, "production", config.App.Env)
 	assert.Equal(t, "9090", config.Server.Port)
 	assert.Equal(t, "debug", config.Log.Level)
+	assert.Equal(t, []string{"https://app.example.com", "https://*.example.org"}, config.Server.CORS.AllowOrigins)
 
 	// Without config file, weather APIs should be empty
 	assert.Len(t, config.Weather.APIs, 0)
@@ -119,9 +127,14 @@ func TestConfigValidation(t *testing.T) {
 		},
 	}
 
+	invalidConfig.Log.Level = ""
 	err = provider.Validate(invalidConfig)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "app.name is required")
+
+	var validationErr *ValidationError
+	require.ErrorAs(t, err, &validationErr)
+	assert.Equal(t, []string{"app.name is required", "log.level is required"}, validationErr.Problems)
 }
 
 func TestConfigHelperMethods(t *testing.T) {
@@ -244,3 +257,806 @@ func (m *MockConfigProvider) Load() (*Config, error) {
 func (m *MockConfigProvider) Validate(config *Config) error {
 	return nil
 }
+
+func TestConfigValidation_CanaryPercent(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.APIs = []WeatherAPIConfig{
+		{Name: "weatherapi", APIKey: "key", Timeout: 5, Canary: &CanaryConfig{Percent: 5}},
+	}
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.APIs[0].Canary.Percent = 101
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.apis[0].canary.percent must be between 0 and 100")
+}
+
+func TestConfigValidation_CanaryOverride(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.APIs = []WeatherAPIConfig{
+		{Name: "weatherapi", APIKey: "key", Timeout: 5, Canary: &CanaryConfig{Percent: 5,
+			Override: &WeatherAPIConfig{Endpoint: "onecall"}}},
+	}
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.APIs[0].Canary.Override.Name = "open-meteo"
+	config.Weather.APIs[0].Canary.Override.Canary = &CanaryConfig{Percent: 1}
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.apis[0].canary.override.name must be empty or weatherapi")
+	assert.Contains(t, err.Error(), "weather.apis[0].canary.override must not set enabled or canary")
+}
+
+func TestCanaryConfig_Member(t *testing.T) {
+	weight := 2.0
+	api := WeatherAPIConfig{Name: "weatherapi", APIKey: "primary-key", BaseURL: "https://a/data/2.5/forecast",
+		Timeout: 5, Weight: &weight, MaxConcurrent: 4}
+	api.Canary = &CanaryConfig{Percent: 5, APIKey: "canary-key", Override: &WeatherAPIConfig{
+		Name: "weatherapi", APIKey: "override-key", Endpoint: "onecall", Timeout: 10}}
+
+	member := api.Canary.Member(api)
+	assert.Equal(t, WeatherAPIConfig{Name: "weatherapi", APIKey: "canary-key", BaseURL: "https://a/data/2.5/forecast",
+		Endpoint: "onecall", Timeout: 10, Weight: &weight, MaxConcurrent: 4}, member)
+	assert.NotNil(t, api.Canary, "the provider configuration is left unchanged")
+	assert.Empty(t, api.Endpoint)
+}
+
+func TestConfigValidation_HTTPMode(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.HTTPMode = "replay"
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.fixtures_dir is required in replay mode")
+
+	config.Weather.FixturesDir = "testdata/fixtures"
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.HTTPMode = "offline"
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.http_mode must be one of")
+}
+
+func TestConfigValidation_PastDays(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.APIs = []WeatherAPIConfig{
+		{Name: "open-meteo", Timeout: 5, Models: []string{"icon_seamless"}, PastDays: 3},
+	}
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.APIs[0].PastDays = 93
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.apis[0].past_days must be between 0 and 92")
+}
+
+func TestConfigValidation_OutlierMADs(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.Aggregation.OutlierMADs = 2.5
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.Aggregation.OutlierMADs = -1
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.aggregation.outlier_mads must not be negative")
+
+	config.Weather.Aggregation.OutlierMADs = 0
+	config.Weather.Aggregation.MinProviders = -1
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.aggregation.min_providers must not be negative")
+}
+
+func TestConfigValidation_ConfidenceSpread(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.Aggregation.HighConfidenceSpread = 1.5
+	config.Weather.Aggregation.MediumConfidenceSpread = 3
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.Aggregation.HighConfidenceSpread = 4
+	err = provider.Validate(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.aggregation.high_confidence_spread must not exceed medium_confidence_spread")
+
+	config.Weather.Aggregation.MediumConfidenceSpread = 0
+	assert.NoError(t, provider.Validate(config), "the medium spread defaults to 5")
+
+	config.Weather.Aggregation.HighConfidenceSpread = -1
+	err = provider.Validate(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "high_confidence_spread and medium_confidence_spread must not be negative")
+}
+
+func TestConfigValidation_CompareThreshold(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.Compare.Threshold = 2.5
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.Compare.Threshold = -1
+	err = provider.Validate(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.compare.threshold must not be negative")
+}
+
+func TestConfigValidation_RequestTimeout(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, 10*time.Second, config.Server.RequestBudget())
+	config.Server.RequestTimeout = 20
+	assert.NoError(t, provider.Validate(config))
+	assert.Equal(t, 20*time.Second, config.Server.RequestBudget())
+
+	config.Server.RequestTimeout = -1
+	err = provider.Validate(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "server.request_timeout must not be negative")
+}
+
+func TestConfigValidation_Quota(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.APIs = []WeatherAPIConfig{
+		{Name: "open-meteo", Timeout: 5, Quota: &QuotaConfig{Limit: 10000}},
+		{Name: "weatherapi", Timeout: 5, Quota: &QuotaConfig{Limit: 1000000, Period: "monthly", Enforce: true}},
+	}
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.APIs[0].Quota = &QuotaConfig{Limit: 0}
+	config.Weather.APIs[1].Quota.Period = "weekly"
+	err = provider.Validate(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.apis[0].quota.limit must be positive")
+	assert.Contains(t, err.Error(), "weather.apis[1].quota.period must be one of: daily, monthly")
+}
+
+func TestConfigValidation_Weight(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	weight := 1.5
+	config.Weather.APIs = []WeatherAPIConfig{
+		{Name: "open-meteo", Timeout: 5, Weight: &weight},
+		{Name: "weatherapi", Timeout: 5},
+	}
+	assert.NoError(t, provider.Validate(config))
+	assert.Equal(t, map[string]float64{"open-meteo": 1.5}, config.ProviderWeights())
+
+	for _, w := range []float64{0, -1} {
+		config.Weather.APIs[1].Weight = &w
+		err = provider.Validate(config)
+		assert.Error(t, err)
+		assert.Contains(t, err.Error(), "weather.apis[1].weight must be positive")
+	}
+}
+
+func TestConfigValidation_ConcurrencyLimits(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.MaxConcurrentRequests = 20
+	config.Weather.APIs = []WeatherAPIConfig{
+		{Name: "open-meteo", Timeout: 5, MaxConcurrent: 4},
+		{Name: "weatherapi", Timeout: 5},
+	}
+	assert.NoError(t, provider.Validate(config))
+	assert.Equal(t, map[string]int{"open-meteo": 4}, config.ProviderConcurrencyLimits())
+
+	config.Weather.MaxConcurrentRequests = -1
+	config.Weather.APIs[1].MaxConcurrent = -1
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.max_concurrent_requests must not be negative")
+	assert.Contains(t, err.Error(), "weather.apis[1].max_concurrent must not be negative")
+}
+
+func TestConfigValidation_HistoryMaxDays(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.History.MaxDays = 31
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.History.MaxDays = -1
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.history.max_days must not be negative")
+}
+
+func TestConfigValidation_RateLimit(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	perMinute, burst := config.RateLimit.Limits()
+	assert.Equal(t, DefaultRateLimit, perMinute)
+	assert.Equal(t, DefaultRateLimitBurst, burst)
+
+	config.RateLimit = RateLimitConfig{RequestsPerMinute: 120, Burst: 1}
+	assert.NoError(t, provider.Validate(config))
+	perMinute, burst = config.RateLimit.Limits()
+	assert.Equal(t, 120, perMinute)
+	assert.Equal(t, 1, burst)
+
+	disabled := false
+	config.RateLimit.Enabled = &disabled
+	perMinute, burst = config.RateLimit.Limits()
+	assert.Zero(t, perMinute)
+	assert.Zero(t, burst)
+
+	config.RateLimit = RateLimitConfig{RequestsPerMinute: -1, Burst: -1}
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "rate_limit.requests_per_minute must not be negative")
+	assert.Contains(t, err.Error(), "rate_limit.burst must not be negative")
+}
+
+func TestConfigValidation_GRPCPort(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Server.GRPCPort = "9090"
+	assert.NoError(t, provider.Validate(config))
+
+	config.Server.GRPCPort = config.Server.Port
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server.grpc_port must differ from server.port")
+}
+
+func TestConfigValidation_TLS(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Server.TLSCertFile = "cert.pem"
+	config.Server.TLSKeyFile = "key.pem"
+	config.Server.RedirectPort = "8081"
+	assert.NoError(t, provider.Validate(config))
+	assert.True(t, config.Server.TLS())
+
+	config.Server.TLSKeyFile = ""
+	config.Server.AutocertHosts = []string{"weather.example.com"}
+	config.Server.RedirectPort = config.Server.Port
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server.tls_cert_file and server.tls_key_file must be set together")
+	assert.Contains(t, err.Error(), "server.tls_cert_file and server.autocert_hosts are exclusive")
+	assert.Contains(t, err.Error(), "server.autocert_cache_dir is required with server.autocert_hosts")
+	assert.Contains(t, err.Error(), "server.redirect_port must differ from server.port and server.grpc_port")
+
+	config.Server = ServerConfig{Port: "8080", ReadTimeout: 10, WriteTimeout: 10, IdleTimeout: 120, RedirectPort: "8081"}
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server.redirect_port requires server.tls_cert_file or server.autocert_hosts")
+}
+
+func TestConfigValidation_Compression(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	for _, level := range append([]string{""}, CompressionLevels...) {
+		config.Server.CompressionLevel = level
+		assert.NoError(t, provider.Validate(config), level)
+	}
+
+	config.Server.CompressionLevel = "fastest"
+	config.Server.CompressionMinBytes = -1
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server.compression_level must be one of: off, speed, default, best")
+	assert.Contains(t, err.Error(), "server.compression_min_bytes must not be negative")
+}
+
+func TestConfigValidation_CORS(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	// Any origin in development, none elsewhere unless configured
+	assert.Equal(t, []string{"*"}, config.CORSOrigins())
+	config.App.Env = "staging"
+	assert.Empty(t, config.CORSOrigins())
+	assert.NoError(t, provider.Validate(config))
+
+	config.App.Env = "production"
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server.cors.allow_origins is required in production")
+
+	config.Server.CORS = CORSConfig{AllowOrigins: []string{"https://app.example.com", "http://*.example.org:8080"}, AllowCredentials: true}
+	assert.NoError(t, provider.Validate(config))
+	assert.Equal(t, config.Server.CORS.AllowOrigins, config.CORSOrigins())
+
+	config.Server.CORS = CORSConfig{AllowOrigins: []string{"*", "app.example.com", "https://app.example.com/path"}, AllowCredentials: true, MaxAgeSeconds: -1}
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server.cors.allow_origins[1] must be * or an origin like https://example.com")
+	assert.Contains(t, err.Error(), "server.cors.allow_origins[2] must be * or an origin like https://example.com")
+	assert.Contains(t, err.Error(), "server.cors.allow_credentials can't allow any origin, list them")
+	assert.Contains(t, err.Error(), "server.cors.max_age_seconds must not be negative")
+	assert.NotContains(t, err.Error(), "server.cors.allow_origins[0]")
+
+	config.App.Env = "development"
+	config.Server.CORS = CORSConfig{AllowCredentials: true}
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server.cors.allow_credentials requires server.cors.allow_origins")
+}
+
+func TestConfigValidation_BodyLimit(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Server.BodyLimitKB = 256
+	assert.NoError(t, provider.Validate(config))
+
+	config.Server.BodyLimitKB = -1
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "server.body_limit_kb must not be negative")
+}
+
+func TestConfigValidation_AccessLog(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Log.AccessSampleAfter = 100
+	config.Log.AccessSampleEvery = 10
+	assert.NoError(t, provider.Validate(config))
+
+	config.Log.AccessSampleAfter = -1
+	config.Log.AccessSampleEvery = -1
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "log.access_sample_after must not be negative")
+	assert.Contains(t, err.Error(), "log.access_sample_every must not be negative")
+}
+
+func TestConfigValidation_Cache(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	assert.Zero(t, config.Cache.Entries())
+	assert.Equal(t, DefaultCacheTTLSeconds*time.Second, config.Cache.TTL())
+	config.Cache.Enabled = true
+	assert.Equal(t, DefaultCacheMaxEntries, config.Cache.Entries())
+
+	tests := []struct {
+		ttl, maxEntries int
+		wantError       string
+	}{
+		{0, 0, ""},
+		{86400, 1000, ""},
+		{86401, 0, "cache.ttl_seconds must be between 0 and 86400 (a day)"},
+		{-1, 0, "cache.ttl_seconds must be between 0 and 86400 (a day)"},
+		{60, -1, "cache.max_entries must not be negative"},
+	}
+	for _, tt := range tests {
+		config.Cache.TTLSeconds, config.Cache.MaxEntries = tt.ttl, tt.maxEntries
+		err := provider.Validate(config)
+		if tt.wantError == "" {
+			assert.NoError(t, err, "%+v", tt)
+			continue
+		}
+		require.Error(t, err, "%+v", tt)
+		assert.Contains(t, err.Error(), tt.wantError)
+	}
+
+	config.Cache = CacheConfig{Enabled: true, TTLSeconds: 600, MaxEntries: 1000}
+	assert.Equal(t, 10*time.Minute, config.Cache.TTL())
+	assert.Equal(t, 1000, config.Cache.Entries())
+}
+
+func TestConfigValidation_CacheBackend(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		cache     CacheConfig
+		wantError string
+	}{
+		{"memory", CacheConfig{Enabled: true, Backend: "memory"}, ""},
+		{"redis", CacheConfig{Enabled: true, Backend: "redis", Redis: RedisCacheConfig{Address: "localhost:6379", DB: 2}}, ""},
+		{"redis disabled", CacheConfig{Backend: "redis"}, ""},
+		{"unknown backend", CacheConfig{Enabled: true, Backend: "memcached"}, "cache.backend must be one of: memory, redis"},
+		{"no address", CacheConfig{Enabled: true, Backend: "redis"}, "cache.redis.address is required by the redis backend"},
+		{"negative db", CacheConfig{Enabled: true, Backend: "redis", Redis: RedisCacheConfig{Address: "localhost:6379", DB: -1}}, "cache.redis.db must not be negative"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config.Cache = tt.cache
+			err := provider.Validate(config)
+			if tt.wantError == "" {
+				assert.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantError)
+		})
+	}
+
+	config.Cache = CacheConfig{Enabled: true, Backend: "redis", Redis: RedisCacheConfig{Password: "redis-password"}}
+	assert.Equal(t, "****word", config.Redacted().Cache.Redis.Password)
+}
+
+func TestConfigValidation_Breaker(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, DefaultBreakerFailureThreshold, config.Breaker.Threshold())
+	assert.Equal(t, DefaultBreakerCooldownSeconds*time.Second, config.Breaker.Cooldown())
+
+	tests := []struct {
+		threshold, cooldown int
+		wantError           string
+	}{
+		{1, 3600, ""},
+		{-1, 0, "breaker.failure_threshold must not be negative"},
+		{0, -1, "breaker.cooldown_seconds must be between 0 and 3600 (an hour)"},
+		{0, 3601, "breaker.cooldown_seconds must be between 0 and 3600 (an hour)"},
+	}
+	for _, tt := range tests {
+		config.Breaker = BreakerConfig{FailureThreshold: tt.threshold, CooldownSeconds: tt.cooldown}
+		err := provider.Validate(config)
+		if tt.wantError == "" {
+			assert.NoError(t, err, "%+v", tt)
+			assert.Equal(t, tt.threshold, config.Breaker.Threshold())
+			assert.Equal(t, time.Duration(tt.cooldown)*time.Second, config.Breaker.Cooldown())
+			continue
+		}
+		require.Error(t, err, "%+v", tt)
+		assert.Contains(t, err.Error(), tt.wantError)
+	}
+}
+
+func TestConfigValidation_Store(t *testing.T) {
+	t.Setenv("STORE_PATH", "/var/lib/weather-api/forecasts.db")
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, "/var/lib/weather-api/forecasts.db", config.Store.Path)
+	assert.Equal(t, DefaultStoreQueueSize, config.Store.Queue())
+	assert.NoError(t, provider.Validate(config))
+
+	config.Store.QueueSize = -1
+	err = provider.Validate(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "store.queue_size must not be negative")
+}
+
+func TestConfigValidation_StoreAccuracy(t *testing.T) {
+	t.Setenv("STORE_ACCURACY_INTERVAL_MINUTES", "60")
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Hour, config.Store.Accuracy.Interval())
+	assert.Equal(t, DefaultAccuracyWindowDays, config.Store.Accuracy.Window())
+	err = provider.Validate(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "store.accuracy needs the forecast store, set store.path")
+
+	config.Store.Path = "forecasts.db"
+	assert.NoError(t, provider.Validate(config))
+
+	config.Store.Accuracy.WindowDays = 400
+	err = provider.Validate(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "store.accuracy.window_days must be between 0 and 366")
+}
+
+func TestConfigValidation_Webhooks(t *testing.T) {
+	t.Setenv("WEBHOOKS_MAX_FAILURES", "3")
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	assert.Equal(t, WebhooksConfig{MaxFailures: 3}, config.Webhooks)
+	assert.NoError(t, provider.Validate(config))
+
+	config.Webhooks.TimeoutSeconds = -1
+	err = provider.Validate(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "webhooks.min_interval_minutes, max_failures and timeout_seconds must not be negative")
+}
+
+func TestConfigValidation_Prewarm(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`weather:
+  prewarm:
+    - name: Berlin
+      lat: 52.52
+      lon: 13.41
+      days: 3
+    - name: Nowhere
+      lat: 95
+      lon: 0
+      days: 30
+`), 0o600))
+	provider := NewFileConfigProvider(path)
+	config, err := provider.Load()
+	require.NoError(t, err)
+	assert.Equal(t, PrewarmConfig{Name: "Berlin", Lat: 52.52, Lon: 13.41, Days: 3}, config.Weather.Prewarm[0])
+
+	err = provider.Validate(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.prewarm needs the forecast cache, set cache.enabled")
+	assert.Contains(t, err.Error(), "weather.prewarm[1]: lat must be between -90 and 90")
+	assert.Contains(t, err.Error(), "weather.prewarm[1].days must be between 0 and 16")
+
+	config.Cache.Enabled = true
+	config.Weather.Prewarm = config.Weather.Prewarm[:1]
+	assert.NoError(t, provider.Validate(config))
+}
+
+func TestConfig_CacheRateLimitBreakerEnv(t *testing.T) {
+	t.Setenv("CACHE_ENABLED", "true")
+	t.Setenv("CACHE_TTL_SECONDS", "120")
+	t.Setenv("RATE_LIMIT_ENABLED", "false")
+	t.Setenv("RATE_LIMIT_BURST", "5")
+	t.Setenv("BREAKER_FAILURE_THRESHOLD", "3")
+
+	config, err := NewFileConfigProvider("nonexistent.yaml").Load()
+	require.NoError(t, err)
+	assert.Equal(t, CacheConfig{Enabled: true, TTLSeconds: 120}, config.Cache)
+	require.NotNil(t, config.RateLimit.Enabled)
+	assert.False(t, *config.RateLimit.Enabled)
+	assert.Equal(t, 5, config.RateLimit.Burst)
+	assert.Equal(t, 3, config.Breaker.Threshold())
+}
+
+func TestConfig_MovedSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("weather:\n  cache_max_entries: 100\n"), 0o600))
+	_, err := NewFileConfigProvider(path).Load()
+	assert.ErrorContains(t, err, "weather.cache_max_entries moved to cache.max_entries and cache.enabled")
+
+	require.NoError(t, os.WriteFile(path, []byte("server:\n  rate_limit: 120\n"), 0o600))
+	_, err = NewFileConfigProvider(path).Load()
+	assert.ErrorContains(t, err, "server.rate_limit moved to rate_limit.requests_per_minute")
+
+	t.Setenv("SERVER_RATE_LIMIT_BURST", "30")
+	_, err = NewFileConfigProvider("nonexistent.yaml").Load()
+	assert.ErrorContains(t, err, "SERVER_RATE_LIMIT_BURST moved to RATE_LIMIT_BURST")
+}
+
+func TestConfigValidation_MaxForecastDays(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.MaxForecastDays = 14
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.MaxForecastDays = -1
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.max_forecast_days must not be negative")
+}
+
+func TestConfigValidation_Batch(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.Batch = BatchConfig{MaxItems: 20, Concurrency: 2}
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.Batch = BatchConfig{MaxItems: -1, Concurrency: -1}
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.batch.max_items must not be negative")
+	assert.Contains(t, err.Error(), "weather.batch.concurrency must not be negative")
+}
+
+func TestConfigValidation_Subscriptions(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.Subscriptions = SubscriptionsConfig{MinIntervalSeconds: 30, MaxDurationMinutes: 120}
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.Subscriptions = SubscriptionsConfig{MinIntervalSeconds: -1, MaxDurationMinutes: -1}
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.subscriptions.min_interval_seconds must not be negative")
+	assert.Contains(t, err.Error(), "weather.subscriptions.max_duration_minutes must not be negative")
+}
+
+func TestConfigValidation_ServiceArea(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Weather.ServiceArea = ServiceAreaConfig{
+		RejectNullIsland: true,
+		Allow:            []AreaBoxConfig{{Name: "fiji", South: -25, West: 170, North: -10, East: -170}},
+	}
+	assert.NoError(t, provider.Validate(config))
+
+	config.Weather.ServiceArea = ServiceAreaConfig{
+		Allow: []AreaBoxConfig{{South: 71, West: -10, North: 35, East: 40}},
+		Deny:  []AreaBoxConfig{{South: 45, West: 6, North: 48, East: 200}},
+	}
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.service_area.allow[0]")
+	assert.Contains(t, err.Error(), "weather.service_area.deny[0]")
+}
+
+func TestConfigValidation_DisabledProviders(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+	assert.True(t, config.ProviderEnabled("open-meteo-marine"))
+
+	config.Weather.DisabledProviders = []string{"open-meteo-marine"}
+	require.NoError(t, provider.Validate(config))
+	assert.False(t, config.ProviderEnabled("open-meteo-marine"))
+	assert.True(t, config.ProviderEnabled("open-meteo-air-quality"))
+
+	config.Weather.DisabledProviders = []string{"open-meteo"}
+	err = provider.Validate(config)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "weather.disabled_providers: unknown provider open-meteo")
+}
+
+func TestConfigValidation_Auth(t *testing.T) {
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	config, err := provider.Load()
+	require.NoError(t, err)
+
+	config.Auth = AuthConfig{Enabled: true, Keys: []APIKeyConfig{
+		{Name: "mobile", SHA256: "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08"},
+	}}
+	assert.NoError(t, provider.Validate(config))
+	assert.Equal(t, map[string]string{
+		"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08": "mobile",
+	}, config.APIKeys())
+
+	config.Auth = AuthConfig{Enabled: true}
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "auth.keys or auth.keys_file is required when auth is enabled")
+
+	config.Auth = AuthConfig{Keys: []APIKeyConfig{
+		{Name: "mobile", SHA256: "test"},
+		{Name: "mobile", SHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+		{SHA256: "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"},
+	}}
+	err = provider.Validate(config)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "auth.keys[0].sha256 must be a hex SHA-256 hash")
+	assert.Contains(t, err.Error(), "auth.keys[1].name mobile is not unique")
+	assert.Contains(t, err.Error(), "auth.keys[2].name is required")
+}
+
+func TestConfig_AuthKeysFile(t *testing.T) {
+	keysFile := t.TempDir() + "/keys.yaml"
+	require.NoError(t, os.WriteFile(keysFile, []byte(`
+- name: web
+  sha256: 60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752
+`), 0o600))
+	t.Setenv("AUTH_ENABLED", "true")
+	t.Setenv("AUTH_KEYS_FILE", keysFile)
+
+	config, err := NewConfigWithProvider(NewFileConfigProvider("nonexistent.yaml"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"60303ae22b998861bce3b28f33eec1be758a213c86c93c076dbe9f558c11c752": "web",
+	}, config.APIKeys())
+	assert.Equal(t, DefaultOpenPaths, config.OpenPaths())
+
+	t.Setenv("AUTH_KEYS_FILE", keysFile+".missing")
+	_, err = NewConfigWithProvider(NewFileConfigProvider("nonexistent.yaml"))
+	assert.ErrorContains(t, err, "failed to load API keys")
+}
+
+func TestConfig_DebugEndpoints(t *testing.T) {
+	tests := []struct {
+		env       string
+		debug     DebugConfig
+		enabled   bool
+		wantError bool
+	}{
+		{"development", DebugConfig{}, true, false},
+		{"staging", DebugConfig{}, false, false},
+		{"staging", DebugConfig{Endpoints: true}, true, false},
+		{"production", DebugConfig{}, false, false},
+		{"production", DebugConfig{AllowProduction: true}, false, false},
+		{"production", DebugConfig{Endpoints: true}, false, true},
+		{"production", DebugConfig{Endpoints: true, AllowProduction: true}, true, false},
+	}
+
+	provider := NewFileConfigProvider("nonexistent.yaml")
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s %+v", tt.env, tt.debug), func(t *testing.T) {
+			config, err := provider.Load()
+			require.NoError(t, err)
+			config.App.Env = tt.env
+			config.Server.CORS.AllowOrigins = []string{"https://app.example.com"}
+			config.Debug = tt.debug
+
+			assert.Equal(t, tt.enabled, config.DebugEndpoints())
+			err = provider.Validate(config)
+			if tt.wantError {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), "debug.endpoints requires debug.allow_production in production")
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestConfig_DebugEndpointsEnv(t *testing.T) {
+	t.Setenv("APP_ENV", "staging")
+	t.Setenv("DEBUG_ENDPOINTS", "true")
+
+	config, err := NewFileConfigProvider("nonexistent.yaml").Load()
+	require.NoError(t, err)
+	assert.True(t, config.DebugEndpoints())
+}
+
+func TestDiffWeatherAPIs(t *testing.T) {
+	disabled := false
+	old := []WeatherAPIConfig{
+		{Name: "open-meteo", Timeout: 30},
+		{Name: "weatherapi", APIKey: "old-key", Timeout: 30},
+		{Name: "mock", Timeout: 5},
+	}
+	updated := []WeatherAPIConfig{
+		{Name: "weatherapi", APIKey: "new-key", Timeout: 30},
+		{Name: "open-meteo", Timeout: 30},
+		{Name: "mock", Timeout: 5, Enabled: &disabled},
+		{Name: "openweathermap", APIKey: "key", Timeout: 10},
+	}
+
+	added, removed, changed := DiffWeatherAPIs(old, updated)
+	assert.Equal(t, []string{"openweathermap"}, added)
+	assert.Empty(t, removed)
+	assert.Equal(t, []string{"mock", "weatherapi"}, changed)
+
+	added, removed, changed = DiffWeatherAPIs(updated, old[:1])
+	assert.Empty(t, added)
+	assert.Equal(t, []string{"mock", "openweathermap", "weatherapi"}, removed)
+	assert.Empty(t, changed)
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+
+	"gopkg.in/yaml.v3"
+)
+
+// maskedSuffix is the number of trailing characters of a secret left visible, enough to tell two keys apart.
+// The secrets not longer than twice as many are masked entirely.
+const maskedSuffix = 4
+
+// Redacted returns a deep copy of the configuration with the fields tagged secret, e.g. the API keys and the
+// admin token, masked to their last characters
+func (c *Config) Redacted() *Config {
+	redacted := redact(reflect.ValueOf(c).Elem()).Interface().(Config)
+	redacted.file = c.file
+
+	return &redacted
+}
+
+// redact returns a copy of v, the secret strings of its structs masked. The unexported fields are left out.
+func redact(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.New(v.Type().Elem())
+		copied.Elem().Set(redact(v.Elem()))
+		return copied
+	case reflect.Struct:
+		copied := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			value := redact(v.Field(i))
+			if field.Tag.Get("secret") == "true" && value.Kind() == reflect.String {
+				value = reflect.ValueOf(mask(value.String())).Convert(field.Type)
+			}
+			copied.Field(i).Set(value)
+		}
+		return copied
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			copied.Index(i).Set(redact(v.Index(i)))
+		}
+		return copied
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		copied := reflect.MakeMapWithSize(v.Type(), v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			copied.SetMapIndex(iter.Key(), redact(iter.Value()))
+		}
+		return copied
+	}
+
+	return v
+}
+
+// mask replaces all the secret but its last characters with asterisks, an empty secret stays empty to tell it is
+// not set
+func mask(secret string) string {
+	runes := []rune(secret)
+	switch {
+	case len(runes) == 0:
+		return ""
+	case len(runes) <= 2*maskedSuffix:
+		return "****"
+	}
+
+	return "****" + string(runes[len(runes)-maskedSuffix:])
+}
+
+// File returns the configuration file loaded, empty when none was found and the configuration comes from the
+// defaults and the environment only
+func (c *Config) File() string {
+	return c.file
+}
+
+// Dump returns the effective configuration in YAML, its secrets redacted, preceded by the file it was loaded from
+func (c *Config) Dump() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	err := enc.Encode(struct {
+		File   string  `yaml:"file"`
+		Config *Config `yaml:"config"`
+	}{c.file, c.Redacted()})
+	if err == nil {
+		err = enc.Close()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// absPath returns the absolute path of the file, or the path as it is when the working directory is unknown
+func absPath(path string) string {
+	if abs, err := filepath.Abs(path); err == nil {
+		return abs
+	}
+	return path
+}
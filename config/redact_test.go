@@ -0,0 +1,85 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfig_Dump(t *testing.T) {
+	const (
+		apiKey      = "weatherapi-key-0123456789"
+		canaryKey   = "canary-key-abcdef"
+		adminToken  = "admin-token-s3cr3t"
+		clientHash  = "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"
+		shortSecret = "abc123"
+		envToken    = "env-token-override"
+	)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+weather:
+  apis:
+    - name: weatherapi
+      api_key: `+apiKey+`
+      timeout: 5
+      canary:
+        percent: 10
+        api_key: `+canaryKey+`
+    - name: openweathermap
+      api_key: `+shortSecret+`
+      timeout: 5
+admin:
+  token: `+adminToken+`
+auth:
+  keys:
+    - name: mobile
+      sha256: `+clientHash+`
+`), 0o600))
+	t.Setenv("ADMIN_TOKEN", envToken)
+
+	config, err := NewFileConfigProvider(path).Load()
+	require.NoError(t, err)
+	assert.Equal(t, path, config.File())
+
+	dump, err := config.Dump()
+	require.NoError(t, err)
+	out := string(dump)
+	for _, secret := range []string{apiKey, canaryKey, adminToken, clientHash, shortSecret, envToken} {
+		assert.NotContains(t, out, secret)
+	}
+	assert.Contains(t, out, "file: "+path)
+	assert.Contains(t, out, "api_key: '****6789'")
+	assert.Contains(t, out, "api_key: '****cdef'")
+	assert.Contains(t, out, "api_key: '****'")
+	assert.Contains(t, out, "token: '****ride'")
+	assert.Contains(t, out, "sha256: '****0a08'")
+	assert.Contains(t, out, "name: weatherapi")
+
+	// The configuration itself is left untouched
+	assert.Equal(t, apiKey, config.Weather.APIs[0].APIKey)
+	assert.Equal(t, canaryKey, config.Weather.APIs[0].Canary.APIKey)
+	assert.Equal(t, envToken, config.Admin.Token)
+}
+
+func TestConfig_DumpWithoutFile(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	config, err := NewFileConfigProvider(filepath.Join(dir, "missing.yaml")).Load()
+	require.NoError(t, err)
+	assert.Empty(t, config.File())
+
+	dump, err := config.Dump()
+	require.NoError(t, err)
+	assert.Contains(t, string(dump), `file: ""`)
+}
+
+func TestMask(t *testing.T) {
+	assert.Equal(t, "", mask(""))
+	assert.Equal(t, "****", mask("12345678"))
+	assert.Equal(t, "****6789", mask("123456789"))
+}
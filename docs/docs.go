@@ -24,19 +24,21 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/weather": {
-            "get": {
-                "description": "Retrieves weather forecast data for a specific location from multiple providers",
-                "consumes": [
-                    "application/json"
+        "/admin/cache": {
+            "delete": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
                 ],
+                "description": "Removes every cached forecast, or only those of a location, from every provider, when lat and lon are given",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Weather"
+                    "Admin"
                 ],
-                "summary": "Get weather forecast",
+                "summary": "Purge the forecast cache",
                 "parameters": [
                     {
                         "maximum": 90,
@@ -45,8 +47,7 @@ const docTemplate = `{
                         "example": 40.7128,
                         "description": "Lat coordinate (-90 to 90)",
                         "name": "lat",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
                         "maximum": 180,
@@ -55,100 +56,3625 @@ const docTemplate = `{
                         "example": -74.006,
                         "description": "Lon coordinate (-180 to 180)",
                         "name": "lon",
-                        "in": "query",
-                        "required": true
+                        "in": "query"
                     },
                     {
-                        "maximum": 14,
-                        "minimum": 1,
-                        "type": "integer",
-                        "example": 3,
-                        "description": "Number of forecast days (1-14, default: 5)",
-                        "name": "days",
+                        "type": "string",
+                        "example": "40.7128,-74.006",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
                         "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
-                        "description": "Successful response",
+                        "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/http.WeatherResponse"
+                            "$ref": "#/definitions/http.CachePurgeResult"
                         }
                     },
                     "400": {
-                        "description": "Bad request - invalid parameters",
+                        "description": "Bad Request",
                         "schema": {
-                            "$ref": "#/definitions/http.ErrorResponse"
+                            "$ref": "#/definitions/http.Problem"
                         }
                     },
-                    "500": {
-                        "description": "Internal server error",
+                    "401": {
+                        "description": "Unauthorized",
                         "schema": {
-                            "$ref": "#/definitions/http.ErrorResponse"
+                            "$ref": "#/definitions/http.Problem"
                         }
                     }
                 }
             }
-        }
-    },
-    "definitions": {
-        "http.ErrorResponse": {
-            "type": "object",
-            "properties": {
-                "error": {
-                    "type": "string",
-                    "example": "Missing required parameter: lat"
+        },
+        "/admin/cache/stats": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Returns the number of cached forecasts, the hit and miss counters and an estimate of the memory held",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get forecast cache statistics",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/weather.CacheStats"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
                 }
             }
         },
-        "http.WeatherData": {
-            "type": "object",
-            "properties": {
-                "date": {
-                    "type": "string",
-                    "example": "2025-07-25"
-                },
-                "temp_max": {
-                    "type": "number",
-                    "example": 38
-                },
-                "temp_min": {
-                    "type": "number",
-                    "example": 24.3
+        "/admin/canaries": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Returns the traffic share and per-member counters of every canary group",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "List canary groups",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/repositories.CanaryStats"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
                 }
             }
         },
-        "http.WeatherResponse": {
-            "type": "object",
-            "properties": {
-                "forecast_window": {
-                    "type": "integer",
-                    "example": 5
-                },
-                "forecasts": {
-                    "type": "object",
-                    "additionalProperties": {
-                        "type": "array",
-                        "items": {
-                            "$ref": "#/definitions/http.WeatherData"
+        "/admin/canaries/{name}": {
+            "put": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Changes the share of the provider traffic routed to the canary member",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Set canary percentage",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "New canary percentage",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.CanaryPercentRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/repositories.CanaryStats"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
                         }
                     }
-                },
-                "latitude": {
-                    "type": "number",
-                    "example": 40.7128
-                },
-                "longitude": {
-                    "type": "number",
-                    "example": -74.006
                 }
             }
-        }
-    },
-    "tags": [
-        {
-            "description": "Weather forecast operations",
-            "name": "Weather"
+        },
+        "/admin/config": {
+            "get": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Returns the configuration the instance runs with, the file merged with the environment and the\ndefaults, and the file it was loaded from. The secrets, e.g. the API keys and the admin token, are\nmasked to their last 4 characters.",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Get the effective configuration",
+                "responses": {
+                    "200": {
+                        "description": "YAML configuration",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/providers/{name}/disable": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Puts a forecast provider back in rotation or pulls it out until it is enabled again or the service\nrestarts. The forecasts of a disabled provider carry the disabled error code, it isn't called.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Enable or disable a provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.ProviderState"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/providers/{name}/enable": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Puts a forecast provider back in rotation or pulls it out until it is enabled again or the service\nrestarts. The forecasts of a disabled provider carry the disabled error code, it isn't called.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Enable or disable a provider",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Provider name",
+                        "name": "name",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.ProviderState"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/admin/reload": {
+            "post": {
+                "security": [
+                    {
+                        "AdminToken": []
+                    }
+                ],
+                "description": "Reads the configuration again and replaces the forecast providers of weather.apis, e.g. to add a\nprovider or rotate an API key, like SIGHUP. The other settings, e.g. the ports and the timeouts of the\nserver, need a restart. The current providers are kept when the configuration is invalid.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Admin"
+                ],
+                "summary": "Reload the forecast providers",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/http.ReloadResult"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/air-quality": {
+            "get": {
+                "description": "Retrieves the daily PM2.5, PM10 and ozone concentrations and US Air Quality Index for a specific location",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Air Quality"
+                ],
+                "summary": "Get air quality forecast",
+                "parameters": [
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "example": 40.7128,
+                        "description": "Lat coordinate (-90 to 90), required without coords",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "example": -74.006,
+                        "description": "Lon coordinate (-180 to 180), required without coords",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "40.7128,-74.006",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 5,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 3,
+                        "description": "Number of forecast days (1-5, default: 5)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Daily air quality",
+                        "schema": {
+                            "$ref": "#/definitions/models.AirQuality"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "No air quality provider is configured, or the location is outside the service area",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "The air quality provider is rate limited",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "The air quality provider failed",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request budget exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/geocode": {
+            "get": {
+                "description": "Looks up the places matching a name, for autocompletion, the results are cached and shared with the city parameter of /weather",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Geocoding"
+                ],
+                "summary": "Search places by name",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "venice",
+                        "description": "Place name, 2 to 100 characters",
+                        "name": "q",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "maximum": 10,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 5,
+                        "description": "Number of results (1-10, default: 5)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "IT",
+                        "description": "ISO 3166-1 alpha-2 country code narrowing the search",
+                        "name": "country",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Matching places",
+                        "schema": {
+                            "$ref": "#/definitions/http.GeocodeResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "Geocoding failed",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request budget exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/graphql": {
+            "get": {
+                "description": "Runs the GraphQL query of the query parameter, see POST /graphql",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GraphQL"
+                ],
+                "summary": "Query the GraphQL API with a GET request",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "example": "{ providers { name } }",
+                        "description": "GraphQL query",
+                        "name": "query",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Operation of the query to run",
+                        "name": "operationName",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Variables of the query, as a JSON object",
+                        "name": "variables",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "GraphQL response, with the errors of the query in errors",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - missing or malformed query",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Runs a GraphQL query against the schema in internal/controllers/http/v1/schema.graphql: the forecast,\naggregate and providers fields, so that the clients fetch the fields they need of several locations\nin one round trip. The fields asking for the same forecast share one fetch, a query may fetch up to\n10 distinct ones and nest 5 levels deep. GET takes the query in the query parameter.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "GraphQL"
+                ],
+                "summary": "Query the GraphQL API",
+                "parameters": [
+                    {
+                        "description": "GraphQL query",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.GraphQLRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "GraphQL response, with the errors of the query in errors",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - missing or malformed query",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/providers": {
+            "get": {
+                "description": "Lists the configured forecast providers with whether they need an API key, the result of their last\nhealth check, their circuit state, and the error rate and average latency of their recent calls.\nProviders never checked, or last checked over a minute ago, are checked first with a short timeout.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Providers"
+                ],
+                "summary": "Get provider status",
+                "responses": {
+                    "200": {
+                        "description": "Provider status",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.ProviderStatus"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/providers/accuracy": {
+            "get": {
+                "description": "Lists the mean absolute errors of the stored forecasts of every provider against the weather observed\nby the archive, over the scoring window and by lead time, the number of days between the fetch and the\nday forecast. The forecasts are scored by a background job, see store.accuracy. The coordinates are\nrounded to 4 decimals.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Providers"
+                ],
+                "summary": "Get the accuracy of the providers at a location",
+                "parameters": [
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "example": 40.7128,
+                        "description": "Lat coordinate (-90 to 90), required without coords",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "example": -74.006,
+                        "description": "Lon coordinate (-180 to 180), required without coords",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "40.7128,-74.006",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Accuracy of the providers",
+                        "schema": {
+                            "$ref": "#/definitions/models.AccuracyReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "No forecast store configured",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions": {
+            "get": {
+                "description": "Lists the subscriptions of the API key, the oldest first, without their secrets",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "List the webhook subscriptions",
+                "responses": {
+                    "200": {
+                        "description": "Subscriptions",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.Subscription"
+                            }
+                        }
+                    },
+                    "422": {
+                        "description": "No forecast store configured",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Checks the aggregated forecast of the location every check interval and posts a notification to\nthe webhook when a day of the next condition.days days meets the condition, e.g. temp_max gte 35 in\n°C. A subscription is notified once until its condition is unmet again. The notifications are\nsigned with the secret returned here only. The subscriptions are kept by the forecast store, and\nbelong to the API key creating them: the other keys can't read nor delete them.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Subscribe a webhook to a forecast threshold",
+                "parameters": [
+                    {
+                        "description": "Subscription",
+                        "name": "subscription",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/http.SubscriptionRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Subscription, with its secret",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "No forecast store configured, or the location is outside the service area",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscriptions/{id}": {
+            "get": {
+                "description": "Returns the subscription without its secret, with the state of its notifications. A subscription\nwhose deliveries failed repeatedly is disabled. The subscriptions of another API key are unknown.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Get a webhook subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Subscription",
+                        "schema": {
+                            "$ref": "#/definitions/models.Subscription"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown subscription",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "No forecast store configured",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "The subscriptions of another API key are unknown",
+                "tags": [
+                    "Subscriptions"
+                ],
+                "summary": "Delete a webhook subscription",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription ID",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "Deleted"
+                    },
+                    "404": {
+                        "description": "Unknown subscription",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "No forecast store configured",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/version": {
+            "get": {
+                "description": "Identifies the deployed build: application name, version, git commit, build date and Go version",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Version"
+                ],
+                "summary": "Get build information",
+                "responses": {
+                    "200": {
+                        "description": "Build information",
+                        "schema": {
+                            "$ref": "#/definitions/buildinfo.Info"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather": {
+            "get": {
+                "description": "Retrieves weather forecast data for a specific location from multiple providers\nWithout lat, lon and city the caller is located from its IP address, when enabled in the configuration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/xml",
+                    "application/msgpack",
+                    "application/x-ndjson"
+                ],
+                "tags": [
+                    "Weather"
+                ],
+                "summary": "Get weather forecast",
+                "parameters": [
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "example": 40.7128,
+                        "description": "Lat coordinate (-90 to 90), required without city or coords",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "example": -74.006,
+                        "description": "Lon coordinate (-180 to 180), required without city or coords",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "40.7128,-74.006",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "Berlin",
+                        "description": "City name resolved by geocoding, instead of lat and lon",
+                        "name": "city",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "DE",
+                        "description": "ISO 3166-1 alpha-2 country code narrowing the city",
+                        "name": "country",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 16,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 3,
+                        "description": "Number of forecast days (1 to the configured maximum, 16 by default, default: 5)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-10-07",
+                        "description": "Target day, YYYY-MM-DD, instead of days: only that day is returned by every provider",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Unit system of the returned values (default: metric)",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "open-meteo",
+                        "description": "Comma-separated provider names to query (default: all)",
+                        "name": "providers",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "all",
+                            "first"
+                        ],
+                        "type": "string",
+                        "description": "all providers, or only the first successful one (default: all)",
+                        "name": "mode",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Add the place name of the coordinates in a top-level location field, omitted when the lookup fails",
+                        "name": "resolve_name",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xml",
+                            "msgpack"
+                        ],
+                        "type": "string",
+                        "description": "Response format, takes precedence over the Accept header (default: json)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "date,temp_max",
+                        "description": "Comma-separated day fields to keep, e.g. date,temp_min,temp_max (default: all), not with the XML format",
+                        "name": "fields",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Answer an Envelope: the forecasts in a data array next to the request metadata in meta, JSON and MessagePack only",
+                        "name": "envelope",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Stream each provider forecast as an NDJSON line as soon as it arrives, with mode=all and the JSON format only",
+                        "name": "stream",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of a previous response, answered 304 when it is unchanged",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Forecasts by provider",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/models.Forecast"
+                            }
+                        }
+                    },
+                    "300": {
+                        "description": "Several places match the city",
+                        "schema": {
+                            "$ref": "#/definitions/http.AmbiguousCityProblem"
+                        }
+                    },
+                    "304": {
+                        "description": "The response is unchanged"
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "404": {
+                        "description": "Unknown city",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "The caller IP address can't be located, the location is outside the service area, or the date is in the past or beyond the providers horizon",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "All providers failed (mode=first) or geocoding failed",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request budget exceeded, or the forecasts of the providers that answered in time",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/aggregate": {
+            "get": {
+                "description": "Merges the forecasts of all providers into a single series, providers that failed are skipped",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/csv",
+                    "text/xml",
+                    "application/msgpack"
+                ],
+                "tags": [
+                    "Weather"
+                ],
+                "summary": "Get aggregated weather forecast",
+                "parameters": [
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "example": 40.7128,
+                        "description": "Lat coordinate (-90 to 90), required without coords",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "example": -74.006,
+                        "description": "Lon coordinate (-180 to 180), required without coords",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "40.7128,-74.006",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 16,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 3,
+                        "description": "Number of forecast days (1 to the configured maximum, 16 by default, default: 5)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2023-10-07",
+                        "description": "Target day, YYYY-MM-DD, instead of days: only that day is aggregated",
+                        "name": "date",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Unit system of the returned values (default: metric)",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mean",
+                            "median",
+                            "weighted_mean",
+                            "extremes"
+                        ],
+                        "type": "string",
+                        "description": "Aggregation strategy (default: mean)",
+                        "name": "strategy",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 2,
+                        "description": "Providers required for the aggregate and for every day (default: configured)",
+                        "name": "min_providers",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "json",
+                            "csv",
+                            "xml",
+                            "msgpack"
+                        ],
+                        "type": "string",
+                        "description": "Response format, takes precedence over the Accept header (default: json)",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "date,temp_max",
+                        "description": "Comma-separated day fields to keep, e.g. date,temp_min,temp_max (default: all), not with the XML format",
+                        "name": "fields",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Successful response",
+                        "schema": {
+                            "$ref": "#/definitions/models.AggregatedForecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "The location is outside the service area, or the date is in the past or beyond the providers horizon",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "All providers failed",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "503": {
+                        "description": "Fewer providers than required returned data",
+                        "schema": {
+                            "$ref": "#/definitions/http.QuorumProblem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request budget exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/alerts": {
+            "get": {
+                "description": "Aggregates the active warnings of a location from the providers exposing them, the other providers\nare skipped. An alert reported by several providers is listed once, the most severe alerts first.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Weather"
+                ],
+                "summary": "Get active weather alerts",
+                "parameters": [
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "example": 40.7128,
+                        "description": "Lat coordinate (-90 to 90), required without coords",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "example": -74.006,
+                        "description": "Lon coordinate (-180 to 180), required without coords",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "40.7128,-74.006",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Active alerts",
+                        "schema": {
+                            "$ref": "#/definitions/models.AlertReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "No configured provider supports weather alerts, or the location is outside the service area",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "All alert providers are rate limited",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "All alert providers failed",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request budget exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/batch": {
+            "post": {
+                "description": "Retrieves the forecasts of every location from multiple providers, the results are in the\norder of the request, invalid locations get an error instead of failing the batch",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Weather"
+                ],
+                "summary": "Get weather forecasts for several locations",
+                "parameters": [
+                    {
+                        "description": "Locations, at most 50 by default",
+                        "name": "locations",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/http.BatchRequestItem"
+                            }
+                        }
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Unit system of the returned values (default: metric)",
+                        "name": "units",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Results in request order",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/http.BatchResultItem"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - malformed body or too many locations",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request budget exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/compare": {
+            "get": {
+                "description": "Sets the max and min temperatures of every provider side by side, day by day, with the largest\ndifference between two providers and the pair. The days beyond the configured threshold, 3°C by\ndefault, are flagged, the days the providers disagree the most on come first. A day missing from\na provider is compared among the others and lists it as missing.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Weather"
+                ],
+                "summary": "Compare the forecasts of the providers",
+                "parameters": [
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "example": 40.7128,
+                        "description": "Lat coordinate (-90 to 90), required without coords",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "example": -74.006,
+                        "description": "Lon coordinate (-180 to 180), required without coords",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "40.7128,-74.006",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 16,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 3,
+                        "description": "Number of forecast days (1 to the configured maximum, 16 by default, default: 5)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Unit system of the returned values (default: metric)",
+                        "name": "units",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Comparison of the providers",
+                        "schema": {
+                            "$ref": "#/definitions/models.ForecastComparison"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "The location is outside the service area",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "All providers failed",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request budget exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/current": {
+            "get": {
+                "description": "Retrieves the current conditions for a specific location from every provider supporting them,\nthe other providers are reported with the unsupported error code",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Weather"
+                ],
+                "summary": "Get current weather",
+                "parameters": [
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "example": 40.7128,
+                        "description": "Lat coordinate (-90 to 90), required without coords",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "example": -74.006,
+                        "description": "Lon coordinate (-180 to 180), required without coords",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "40.7128,-74.006",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Unit system of the returned values (default: metric)",
+                        "name": "units",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Current conditions by provider",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/models.CurrentWeather"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "The location is outside the service area",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request budget exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/history": {
+            "get": {
+                "description": "Retrieves the observed daily weather of a past date range from the providers with a weather archive",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Weather"
+                ],
+                "summary": "Get historical weather",
+                "parameters": [
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "example": 40.7128,
+                        "description": "Lat coordinate (-90 to 90), required without coords",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "example": -74.006,
+                        "description": "Lon coordinate (-180 to 180), required without coords",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "40.7128,-74.006",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2024-01-01",
+                        "description": "First day of the range (YYYY-MM-DD)",
+                        "name": "start",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "example": "2024-01-31",
+                        "description": "Last day of the range, not in the future (YYYY-MM-DD)",
+                        "name": "end",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Unit system of the returned values (default: metric)",
+                        "name": "units",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Historical weather by provider",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": {
+                                "$ref": "#/definitions/models.HistoricalWeather"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "No configured provider supports historical weather, or the location is outside the service area",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request budget exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/marine": {
+            "get": {
+                "description": "Retrieves the daily wave height, wave period, wave direction and sea surface temperature of a coastal or offshore location",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Weather"
+                ],
+                "summary": "Get marine forecast",
+                "parameters": [
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "example": 43.2965,
+                        "description": "Lat coordinate (-90 to 90), required without coords",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "example": 5.3698,
+                        "description": "Lon coordinate (-180 to 180), required without coords",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "43.2965,5.3698",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 5,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 3,
+                        "description": "Number of forecast days (1-5, default: 5)",
+                        "name": "days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Daily marine forecast",
+                        "schema": {
+                            "$ref": "#/definitions/models.MarineForecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "The point is inland, no marine provider is configured, or the location is outside the service area",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "429": {
+                        "description": "The marine provider is rate limited",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "502": {
+                        "description": "The marine provider failed",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request budget exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/stored": {
+            "get": {
+                "description": "Retrieves what the providers predicted for a day at a location, every successful fetch recorded by the forecast store, the oldest first. The coordinates are rounded to 4 decimals.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Weather"
+                ],
+                "summary": "Get the stored forecasts of a day",
+                "parameters": [
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "example": 40.7128,
+                        "description": "Lat coordinate (-90 to 90), required without coords",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "example": -74.006,
+                        "description": "Lon coordinate (-180 to 180), required without coords",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "40.7128,-74.006",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "2024-01-15",
+                        "description": "Forecast day (YYYY-MM-DD)",
+                        "name": "date",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stored forecasts of the day",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/models.StoredForecast"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "No forecast store configured",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal server error",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "504": {
+                        "description": "Request budget exceeded",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        },
+        "/weather/subscribe": {
+            "get": {
+                "description": "Keeps the connection open and pushes a forecast event with a fresh aggregate every interval, an\nerror event when it can't be fetched. The server closes the subscription after the configured\nmaximum duration, subscribers to the same location share the upstream fetches.",
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Weather"
+                ],
+                "summary": "Subscribe to live aggregated forecasts",
+                "parameters": [
+                    {
+                        "maximum": 90,
+                        "minimum": -90,
+                        "type": "number",
+                        "example": 40.7128,
+                        "description": "Lat coordinate (-90 to 90), required without coords",
+                        "name": "lat",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 180,
+                        "minimum": -180,
+                        "type": "number",
+                        "example": -74.006,
+                        "description": "Lon coordinate (-180 to 180), required without coords",
+                        "name": "lon",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "40.7128,-74.006",
+                        "description": "Lat and lon combined as lat,lon, instead of lat and lon",
+                        "name": "coords",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 16,
+                        "minimum": 1,
+                        "type": "integer",
+                        "example": 3,
+                        "description": "Number of forecast days (1 to the configured maximum, 16 by default, default: 5)",
+                        "name": "days",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "metric",
+                            "imperial"
+                        ],
+                        "type": "string",
+                        "description": "Unit system of the returned values (default: metric)",
+                        "name": "units",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "mean",
+                            "median",
+                            "weighted_mean",
+                            "extremes"
+                        ],
+                        "type": "string",
+                        "description": "Aggregation strategy (default: mean)",
+                        "name": "strategy",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "example": "10m",
+                        "description": "Time between updates, at least the configured minimum (default: 10m)",
+                        "name": "interval",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "Stream of forecast events",
+                        "schema": {
+                            "$ref": "#/definitions/models.AggregatedForecast"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad request - invalid parameters",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    },
+                    "422": {
+                        "description": "The location is outside the service area",
+                        "schema": {
+                            "$ref": "#/definitions/http.Problem"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "buildinfo.Info": {
+            "type": "object",
+            "properties": {
+                "build_date": {
+                    "type": "string",
+                    "example": "2025-07-25T10:00:00Z"
+                },
+                "commit": {
+                    "type": "string",
+                    "example": "3f2b8c0e9a4d4f5e8b1c2d3e4f5a6b7c8d9e0f1a"
+                },
+                "go_version": {
+                    "type": "string",
+                    "example": "go1.24.3"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "weather-api"
+                },
+                "version": {
+                    "type": "string",
+                    "example": "1.2.0"
+                }
+            }
+        },
+        "http.AmbiguousCityProblem": {
+            "type": "object",
+            "properties": {
+                "candidates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Place"
+                    }
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "missing required parameter: lat; missing required parameter: lon"
+                },
+                "instance": {
+                    "description": "Instance is the request target",
+                    "type": "string",
+                    "example": "/weather?days=3"
+                },
+                "invalid_params": {
+                    "description": "InvalidParams lists every rejected parameter of a validation failure",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.InvalidParam"
+                    }
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f2b8c0e9a4d4f5e"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Missing required parameter"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "/problems/missing-parameter"
+                }
+            }
+        },
+        "http.BatchRequestItem": {
+            "type": "object",
+            "properties": {
+                "coords": {
+                    "type": "string",
+                    "example": "40.7128,-74.006"
+                },
+                "days": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                }
+            }
+        },
+        "http.BatchResultItem": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "error": {
+                    "type": "string",
+                    "example": "latitude must be between -90 and 90, got: 91.000000"
+                },
+                "forecasts": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.Forecast"
+                    }
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                }
+            }
+        },
+        "http.CachePurgeResult": {
+            "type": "object",
+            "properties": {
+                "purged": {
+                    "type": "integer",
+                    "example": 12
+                }
+            }
+        },
+        "http.CanaryPercentRequest": {
+            "type": "object",
+            "properties": {
+                "percent": {
+                    "type": "integer",
+                    "example": 5
+                }
+            }
+        },
+        "http.GeocodeResponse": {
+            "type": "object",
+            "properties": {
+                "query": {
+                    "type": "string",
+                    "example": "venice"
+                },
+                "results": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Place"
+                    }
+                }
+            }
+        },
+        "http.GraphQLRequest": {
+            "type": "object",
+            "properties": {
+                "operationName": {
+                    "type": "string"
+                },
+                "query": {
+                    "type": "string",
+                    "example": "{ forecast(lat: 52.52, lon: 13.41, days: 2) { provider days { date tempMax } } }"
+                },
+                "variables": {
+                    "type": "object",
+                    "additionalProperties": {}
+                }
+            }
+        },
+        "http.InvalidParam": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "lat"
+                },
+                "reason": {
+                    "type": "string",
+                    "example": "missing required parameter: lat"
+                }
+            }
+        },
+        "http.Problem": {
+            "type": "object",
+            "properties": {
+                "detail": {
+                    "type": "string",
+                    "example": "missing required parameter: lat; missing required parameter: lon"
+                },
+                "instance": {
+                    "description": "Instance is the request target",
+                    "type": "string",
+                    "example": "/weather?days=3"
+                },
+                "invalid_params": {
+                    "description": "InvalidParams lists every rejected parameter of a validation failure",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.InvalidParam"
+                    }
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f2b8c0e9a4d4f5e"
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Missing required parameter"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "/problems/missing-parameter"
+                }
+            }
+        },
+        "http.ProviderState": {
+            "type": "object",
+            "properties": {
+                "enabled": {
+                    "type": "boolean",
+                    "example": false
+                },
+                "name": {
+                    "type": "string",
+                    "example": "weatherapi"
+                }
+            }
+        },
+        "http.QuorumProblem": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "detail": {
+                    "type": "string",
+                    "example": "missing required parameter: lat; missing required parameter: lon"
+                },
+                "failures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProviderFailure"
+                    }
+                },
+                "instance": {
+                    "description": "Instance is the request target",
+                    "type": "string",
+                    "example": "/weather?days=3"
+                },
+                "invalid_params": {
+                    "description": "InvalidParams lists every rejected parameter of a validation failure",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/http.InvalidParam"
+                    }
+                },
+                "request_id": {
+                    "type": "string",
+                    "example": "3f2b8c0e9a4d4f5e"
+                },
+                "required": {
+                    "type": "integer",
+                    "example": 2
+                },
+                "status": {
+                    "type": "integer",
+                    "example": 400
+                },
+                "title": {
+                    "type": "string",
+                    "example": "Missing required parameter"
+                },
+                "type": {
+                    "type": "string",
+                    "example": "/problems/missing-parameter"
+                }
+            }
+        },
+        "http.ReloadResult": {
+            "type": "object",
+            "properties": {
+                "added": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "weatherapi"
+                    ]
+                },
+                "changed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "openmeteo"
+                    ]
+                },
+                "removed": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "http.SubscriptionRequest": {
+            "type": "object",
+            "properties": {
+                "check_interval": {
+                    "type": "string",
+                    "example": "30m"
+                },
+                "condition": {
+                    "$ref": "#/definitions/models.SubscriptionCondition"
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                },
+                "webhook_url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/weather"
+                }
+            }
+        },
+        "models.AccuracyReport": {
+            "type": "object",
+            "properties": {
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                },
+                "providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProviderAccuracy"
+                    }
+                },
+                "since": {
+                    "type": "string",
+                    "example": "2023-09-01"
+                }
+            }
+        },
+        "models.AggregatedForecast": {
+            "type": "object",
+            "properties": {
+                "forecast_data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AggregatedWeatherData"
+                    }
+                },
+                "forecast_window": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                },
+                "min_providers": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "omitted_days": {
+                    "description": "OmittedDays lists the days left out because fewer than MinProviders providers reported them",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "providers": {
+                    "description": "Providers lists the providers contributing to at least one day, in configuration order",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "open-meteo",
+                        "weatherapi"
+                    ]
+                },
+                "rejected": {
+                    "description": "Rejected lists the provider days left out of the aggregate as outliers",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.RejectedValue"
+                    }
+                },
+                "strategy": {
+                    "type": "string",
+                    "example": "mean"
+                },
+                "timezone": {
+                    "$ref": "#/definitions/models.Timezone"
+                },
+                "units": {
+                    "type": "string",
+                    "example": "metric"
+                },
+                "weights": {
+                    "description": "Weights lists the effective weight of every contributing provider with the weighted_mean strategy",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number",
+                        "format": "float64"
+                    }
+                }
+            }
+        },
+        "models.AggregatedWeatherData": {
+            "type": "object",
+            "properties": {
+                "confidence": {
+                    "description": "Confidence labels the agreement of the providers from the Spread, unknown with a single provider",
+                    "type": "string",
+                    "enum": [
+                        "high",
+                        "medium",
+                        "low",
+                        "unknown"
+                    ],
+                    "example": "high"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2023-10-01"
+                },
+                "provider_count": {
+                    "description": "ProviderCount is the number of providers the day was aggregated from",
+                    "type": "integer",
+                    "example": 2
+                },
+                "spread": {
+                    "description": "Spread is the largest disagreement between the providers on the max or min temperature of the day",
+                    "type": "number",
+                    "example": 1.4
+                },
+                "temp_max": {
+                    "type": "number",
+                    "example": 37.6
+                },
+                "temp_max_spread": {
+                    "description": "TempMaxSpread and TempMinSpread are the range and the standard deviation of the temperatures of the\nproviders",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.TemperatureSpread"
+                        }
+                    ]
+                },
+                "temp_min": {
+                    "type": "number",
+                    "example": 24.1
+                },
+                "temp_min_spread": {
+                    "$ref": "#/definitions/models.TemperatureSpread"
+                }
+            }
+        },
+        "models.AirQuality": {
+            "type": "object",
+            "properties": {
+                "air_quality_data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.AirQualityData"
+                    }
+                },
+                "cached": {
+                    "description": "Cached is set when the forecast is served from the forecast cache without calling the provider",
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "provider timed out"
+                },
+                "error_code": {
+                    "type": "string",
+                    "example": "timeout"
+                },
+                "fetch_duration_ms": {
+                    "type": "integer",
+                    "example": 182
+                },
+                "fetched_at": {
+                    "type": "string",
+                    "example": "2023-10-01T12:00:00Z"
+                },
+                "forecast_window": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                },
+                "note": {
+                    "description": "Note explains a forecast differing from the request, such as a window clamped to the provider horizon",
+                    "type": "string",
+                    "example": "forecast window clamped from 10 to 5 days, the provider horizon"
+                },
+                "repository_name": {
+                    "type": "string",
+                    "example": "open-meteo-air-quality"
+                },
+                "source_url": {
+                    "description": "SourceURL is the provider request without secrets",
+                    "type": "string",
+                    "example": "https://api.open-meteo.com/v1/forecast?latitude=40.7128\u0026longitude=-74.006"
+                },
+                "stale": {
+                    "description": "Stale is set when the forecast is served from a cache after the provider failed",
+                    "type": "boolean"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "America/New_York"
+                }
+            }
+        },
+        "models.AirQualityData": {
+            "type": "object",
+            "properties": {
+                "aqi": {
+                    "description": "AQI is the highest hourly US Air Quality Index of the day, from 0 to 500",
+                    "type": "integer",
+                    "example": 42
+                },
+                "aqi_category": {
+                    "type": "string",
+                    "example": "good"
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2023-10-01"
+                },
+                "ozone": {
+                    "description": "Ozone is the highest hourly concentration of the day",
+                    "type": "number",
+                    "example": 96
+                },
+                "pm10": {
+                    "type": "number",
+                    "example": 14.1
+                },
+                "pm2_5": {
+                    "description": "PM25 and PM10 are the daily means of the particulate matter concentrations",
+                    "type": "number",
+                    "example": 8.4
+                }
+            }
+        },
+        "models.Alert": {
+            "type": "object",
+            "properties": {
+                "also_reported_by": {
+                    "description": "AlsoReportedBy lists the other providers that reported the same alert",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "description": {
+                    "type": "string",
+                    "example": "Heat index values up to 105 expected."
+                },
+                "event": {
+                    "type": "string",
+                    "example": "Heat Advisory"
+                },
+                "expires": {
+                    "description": "Expires is zero when the provider gave no end to the alert",
+                    "type": "string",
+                    "example": "2025-07-25T20:00:00-04:00"
+                },
+                "onset": {
+                    "type": "string",
+                    "example": "2025-07-25T12:00:00-04:00"
+                },
+                "severity": {
+                    "type": "string",
+                    "example": "moderate"
+                },
+                "source": {
+                    "description": "Source is the provider reporting the alert",
+                    "type": "string",
+                    "example": "nws"
+                }
+            }
+        },
+        "models.AlertReport": {
+            "type": "object",
+            "properties": {
+                "alerts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Alert"
+                    }
+                },
+                "failures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProviderFailure"
+                    }
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                },
+                "providers": {
+                    "description": "Providers lists the providers that answered, in configuration order",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "nws",
+                        "weatherapi"
+                    ]
+                }
+            }
+        },
+        "models.Condition": {
+            "type": "string",
+            "enum": [
+                "clear",
+                "partly-cloudy",
+                "cloudy",
+                "fog",
+                "drizzle",
+                "rain",
+                "snow",
+                "thunderstorm",
+                "unknown"
+            ],
+            "x-enum-varnames": [
+                "ConditionClear",
+                "ConditionPartlyCloudy",
+                "ConditionCloudy",
+                "ConditionFog",
+                "ConditionDrizzle",
+                "ConditionRain",
+                "ConditionSnow",
+                "ConditionThunderstorm",
+                "ConditionUnknown"
+            ]
+        },
+        "models.CurrentConditions": {
+            "type": "object",
+            "properties": {
+                "condition": {
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Condition"
+                        }
+                    ],
+                    "example": "cloudy"
+                },
+                "condition_code": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "feels_like": {
+                    "type": "number",
+                    "example": 20.8
+                },
+                "humidity": {
+                    "description": "%",
+                    "type": "number",
+                    "example": 65
+                },
+                "observed_at": {
+                    "description": "ObservedAt is in the local time of the location",
+                    "type": "string",
+                    "example": "2023-10-01T14:00:00+02:00"
+                },
+                "temperature": {
+                    "type": "number",
+                    "example": 21.4
+                },
+                "wind_direction": {
+                    "description": "degrees",
+                    "type": "number",
+                    "example": 250
+                },
+                "wind_speed": {
+                    "description": "m/s, mph",
+                    "type": "number",
+                    "example": 3.2
+                }
+            }
+        },
+        "models.CurrentWeather": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "description": "Cached is set when the forecast is served from the forecast cache without calling the provider",
+                    "type": "boolean"
+                },
+                "current": {
+                    "description": "Current is null when the provider failed",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.CurrentConditions"
+                        }
+                    ]
+                },
+                "error": {
+                    "type": "string",
+                    "example": "operation not supported by provider"
+                },
+                "error_code": {
+                    "type": "string",
+                    "example": "unsupported"
+                },
+                "fetch_duration_ms": {
+                    "type": "integer",
+                    "example": 182
+                },
+                "fetched_at": {
+                    "type": "string",
+                    "example": "2023-10-01T12:00:00Z"
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                },
+                "note": {
+                    "description": "Note explains a forecast differing from the request, such as a window clamped to the provider horizon",
+                    "type": "string",
+                    "example": "forecast window clamped from 10 to 5 days, the provider horizon"
+                },
+                "repository_name": {
+                    "type": "string",
+                    "example": "openmeteo"
+                },
+                "source_url": {
+                    "description": "SourceURL is the provider request without secrets",
+                    "type": "string",
+                    "example": "https://api.open-meteo.com/v1/forecast?latitude=40.7128\u0026longitude=-74.006"
+                },
+                "stale": {
+                    "description": "Stale is set when the forecast is served from a cache after the provider failed",
+                    "type": "boolean"
+                },
+                "units": {
+                    "type": "string",
+                    "example": "metric"
+                }
+            }
+        },
+        "models.DayComparison": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2023-10-01"
+                },
+                "disagreement": {
+                    "description": "Disagreement is the largest difference between two providers on the max or min temperature of the day,\nPair the two providers",
+                    "type": "number",
+                    "example": 4.2
+                },
+                "flagged": {
+                    "description": "Flagged is set when the disagreement exceeds the threshold",
+                    "type": "boolean",
+                    "example": true
+                },
+                "missing": {
+                    "description": "Missing lists the providers compared that didn't report the day",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "weatherapi"
+                    ]
+                },
+                "pair": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "open-meteo",
+                        "weatherapi"
+                    ]
+                },
+                "providers": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/models.ProviderTemperatures"
+                    }
+                }
+            }
+        },
+        "models.Forecast": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "description": "Cached is set when the forecast is served from the forecast cache without calling the provider",
+                    "type": "boolean"
+                },
+                "error": {
+                    "description": "Error and ErrorCode explain an empty forecast, the message never contains provider URLs or keys",
+                    "type": "string",
+                    "example": "provider timed out"
+                },
+                "error_code": {
+                    "type": "string",
+                    "example": "timeout"
+                },
+                "fetch_duration_ms": {
+                    "type": "integer",
+                    "example": 182
+                },
+                "fetched_at": {
+                    "type": "string",
+                    "example": "2023-10-01T12:00:00Z"
+                },
+                "forecast_data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WeatherData"
+                    }
+                },
+                "forecast_window": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                },
+                "note": {
+                    "description": "Note explains a forecast differing from the request, such as a window clamped to the provider horizon",
+                    "type": "string",
+                    "example": "forecast window clamped from 10 to 5 days, the provider horizon"
+                },
+                "repository_name": {
+                    "type": "string",
+                    "example": "openmeteo"
+                },
+                "source_url": {
+                    "description": "SourceURL is the provider request without secrets",
+                    "type": "string",
+                    "example": "https://api.open-meteo.com/v1/forecast?latitude=40.7128\u0026longitude=-74.006"
+                },
+                "stale": {
+                    "description": "Stale is set when the forecast is served from a cache after the provider failed",
+                    "type": "boolean"
+                },
+                "timezone": {
+                    "$ref": "#/definitions/models.Timezone"
+                },
+                "units": {
+                    "type": "string",
+                    "example": "metric"
+                },
+                "variant": {
+                    "description": "Variant is the member of the canary group of the provider serving the forecast, primary or canary, empty\nwhen the provider has no canary",
+                    "type": "string",
+                    "example": "canary"
+                }
+            }
+        },
+        "models.ForecastComparison": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.DayComparison"
+                    }
+                },
+                "failures": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProviderFailure"
+                    }
+                },
+                "forecast_window": {
+                    "type": "integer",
+                    "example": 5
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                },
+                "providers": {
+                    "description": "Providers lists the providers compared, in configuration order, Failures the ones that returned no forecast",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    },
+                    "example": [
+                        "open-meteo",
+                        "weatherapi"
+                    ]
+                },
+                "threshold": {
+                    "description": "Threshold is the disagreement beyond which a day is flagged",
+                    "type": "number",
+                    "example": 3
+                },
+                "units": {
+                    "type": "string",
+                    "example": "metric"
+                }
+            }
+        },
+        "models.HistoricalWeather": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "description": "Cached is set when the forecast is served from the forecast cache without calling the provider",
+                    "type": "boolean"
+                },
+                "end": {
+                    "type": "string",
+                    "example": "2024-01-31"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "provider timed out"
+                },
+                "error_code": {
+                    "type": "string",
+                    "example": "timeout"
+                },
+                "fetch_duration_ms": {
+                    "type": "integer",
+                    "example": 182
+                },
+                "fetched_at": {
+                    "type": "string",
+                    "example": "2023-10-01T12:00:00Z"
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                },
+                "note": {
+                    "description": "Note explains a forecast differing from the request, such as a window clamped to the provider horizon",
+                    "type": "string",
+                    "example": "forecast window clamped from 10 to 5 days, the provider horizon"
+                },
+                "repository_name": {
+                    "type": "string",
+                    "example": "open-meteo"
+                },
+                "source_url": {
+                    "description": "SourceURL is the provider request without secrets",
+                    "type": "string",
+                    "example": "https://api.open-meteo.com/v1/forecast?latitude=40.7128\u0026longitude=-74.006"
+                },
+                "stale": {
+                    "description": "Stale is set when the forecast is served from a cache after the provider failed",
+                    "type": "boolean"
+                },
+                "start": {
+                    "type": "string",
+                    "example": "2024-01-01"
+                },
+                "timezone": {
+                    "$ref": "#/definitions/models.Timezone"
+                },
+                "units": {
+                    "type": "string",
+                    "example": "metric"
+                },
+                "weather_data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.WeatherData"
+                    }
+                }
+            }
+        },
+        "models.MarineData": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2023-10-01"
+                },
+                "sea_surface_temperature": {
+                    "description": "SeaSurfaceTemperature is the daily mean, in °C",
+                    "type": "number",
+                    "example": 19.8
+                },
+                "wave_direction": {
+                    "description": "WaveDirection is the dominant direction the waves come from, in degrees",
+                    "type": "number",
+                    "example": 220
+                },
+                "wave_height_max": {
+                    "description": "WaveHeightMax is the highest significant wave height of the day, in m",
+                    "type": "number",
+                    "example": 1.2
+                },
+                "wave_period_max": {
+                    "description": "WavePeriodMax is the longest wave period of the day, in s",
+                    "type": "number",
+                    "example": 6.5
+                }
+            }
+        },
+        "models.MarineForecast": {
+            "type": "object",
+            "properties": {
+                "cached": {
+                    "description": "Cached is set when the forecast is served from the forecast cache without calling the provider",
+                    "type": "boolean"
+                },
+                "error": {
+                    "type": "string",
+                    "example": "provider timed out"
+                },
+                "error_code": {
+                    "type": "string",
+                    "example": "timeout"
+                },
+                "fetch_duration_ms": {
+                    "type": "integer",
+                    "example": 182
+                },
+                "fetched_at": {
+                    "type": "string",
+                    "example": "2023-10-01T12:00:00Z"
+                },
+                "forecast_window": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 43.2965
+                },
+                "lon": {
+                    "type": "number",
+                    "example": 5.3698
+                },
+                "marine_data": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.MarineData"
+                    }
+                },
+                "note": {
+                    "description": "Note explains a forecast differing from the request, such as a window clamped to the provider horizon",
+                    "type": "string",
+                    "example": "forecast window clamped from 10 to 5 days, the provider horizon"
+                },
+                "repository_name": {
+                    "type": "string",
+                    "example": "open-meteo-marine"
+                },
+                "source_url": {
+                    "description": "SourceURL is the provider request without secrets",
+                    "type": "string",
+                    "example": "https://api.open-meteo.com/v1/forecast?latitude=40.7128\u0026longitude=-74.006"
+                },
+                "stale": {
+                    "description": "Stale is set when the forecast is served from a cache after the provider failed",
+                    "type": "boolean"
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Paris"
+                }
+            }
+        },
+        "models.Place": {
+            "type": "object",
+            "properties": {
+                "admin1": {
+                    "type": "string",
+                    "example": "Land Berlin"
+                },
+                "country": {
+                    "type": "string",
+                    "example": "Germany"
+                },
+                "country_code": {
+                    "type": "string",
+                    "example": "DE"
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 52.52437
+                },
+                "lon": {
+                    "type": "number",
+                    "example": 13.41053
+                },
+                "name": {
+                    "type": "string",
+                    "example": "Berlin"
+                },
+                "population": {
+                    "type": "integer",
+                    "example": 3426354
+                },
+                "timezone": {
+                    "type": "string",
+                    "example": "Europe/Berlin"
+                }
+            }
+        },
+        "models.ProviderAccuracy": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer",
+                    "example": 28
+                },
+                "lead_days": {
+                    "type": "integer",
+                    "example": 1
+                },
+                "mae": {
+                    "description": "MAE is the mean of MAETempMin and MAETempMax, in °C",
+                    "type": "number",
+                    "example": 1.35
+                },
+                "mae_temp_max": {
+                    "type": "number",
+                    "example": 1.5
+                },
+                "mae_temp_min": {
+                    "type": "number",
+                    "example": 1.2
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "open-meteo"
+                },
+                "variant": {
+                    "description": "Variant is the canary member scored, the members of a canary group are scored apart",
+                    "type": "string",
+                    "example": "canary"
+                }
+            }
+        },
+        "models.ProviderFailure": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string",
+                    "example": "provider timed out"
+                },
+                "error_code": {
+                    "type": "string",
+                    "example": "timeout"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "weatherapi"
+                }
+            }
+        },
+        "models.ProviderHealth": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string",
+                    "example": "2023-10-01T12:00:00Z"
+                },
+                "duration_ms": {
+                    "type": "integer",
+                    "example": 182
+                },
+                "error_code": {
+                    "type": "string",
+                    "example": "timeout"
+                },
+                "healthy": {
+                    "type": "boolean",
+                    "example": true
+                }
+            }
+        },
+        "models.ProviderQuota": {
+            "type": "object",
+            "properties": {
+                "enforced": {
+                    "description": "Enforced skips the provider once the quota is used up, the calls beyond it are only counted otherwise",
+                    "type": "boolean",
+                    "example": true
+                },
+                "limit": {
+                    "type": "integer",
+                    "example": 1000
+                },
+                "period": {
+                    "type": "string",
+                    "enum": [
+                        "daily",
+                        "monthly"
+                    ],
+                    "example": "daily"
+                },
+                "remaining": {
+                    "type": "integer",
+                    "example": 588
+                },
+                "resets_at": {
+                    "description": "ResetsAt is the start of the next period, in UTC",
+                    "type": "string",
+                    "example": "2023-10-02T00:00:00Z"
+                },
+                "used": {
+                    "type": "integer",
+                    "example": 412
+                }
+            }
+        },
+        "models.ProviderStatus": {
+            "type": "object",
+            "properties": {
+                "avg_latency_ms": {
+                    "type": "number",
+                    "example": 182.5
+                },
+                "calls": {
+                    "description": "Calls is the number of recent calls the error rate and the latency are computed over",
+                    "type": "integer",
+                    "example": 100
+                },
+                "circuit": {
+                    "description": "Circuit is the state of the circuit breaker of the provider",
+                    "type": "string",
+                    "example": "none"
+                },
+                "disabled": {
+                    "description": "Disabled is set when the provider was pulled out of rotation at runtime",
+                    "type": "boolean",
+                    "example": false
+                },
+                "error_rate": {
+                    "type": "number",
+                    "example": 0.02
+                },
+                "health": {
+                    "description": "Health is the result of the last health check, missing when it never ran",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ProviderHealth"
+                        }
+                    ]
+                },
+                "last_error_code": {
+                    "description": "LastErrorCode is the error code of the last call when it failed",
+                    "type": "string",
+                    "example": "rate_limited"
+                },
+                "name": {
+                    "type": "string",
+                    "example": "open-meteo"
+                },
+                "quota": {
+                    "description": "Quota is the usage of the quota of the provider, missing when none is configured",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.ProviderQuota"
+                        }
+                    ]
+                },
+                "rate_limited": {
+                    "description": "RateLimited is set when the last call was rejected by the rate limit of the provider",
+                    "type": "boolean",
+                    "example": false
+                },
+                "requires_key": {
+                    "type": "boolean",
+                    "example": false
+                }
+            }
+        },
+        "models.ProviderTemperatures": {
+            "type": "object",
+            "properties": {
+                "temp_max": {
+                    "type": "number",
+                    "example": 37.6
+                },
+                "temp_min": {
+                    "type": "number",
+                    "example": 24.1
+                }
+            }
+        },
+        "models.RejectedValue": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2023-10-01"
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "weatherapi"
+                }
+            }
+        },
+        "models.StoredForecast": {
+            "type": "object",
+            "properties": {
+                "date": {
+                    "type": "string",
+                    "example": "2023-10-01"
+                },
+                "fetched_at": {
+                    "type": "string",
+                    "example": "2023-09-28T12:00:00Z"
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                },
+                "provider": {
+                    "type": "string",
+                    "example": "open-meteo"
+                },
+                "temp_max": {
+                    "type": "number",
+                    "example": 38
+                },
+                "temp_min": {
+                    "type": "number",
+                    "example": 24.3
+                },
+                "variant": {
+                    "description": "Variant is the canary member of the provider that forecast it, see Forecast",
+                    "type": "string",
+                    "example": "canary"
+                }
+            }
+        },
+        "models.Subscription": {
+            "type": "object",
+            "properties": {
+                "check_interval_seconds": {
+                    "type": "integer",
+                    "example": 1800
+                },
+                "condition": {
+                    "$ref": "#/definitions/models.SubscriptionCondition"
+                },
+                "created_at": {
+                    "type": "string",
+                    "example": "2025-07-25T10:00:00Z"
+                },
+                "enabled": {
+                    "description": "Enabled is cleared after repeated failed deliveries",
+                    "type": "boolean",
+                    "example": true
+                },
+                "failures": {
+                    "description": "Failures counts the consecutive failed deliveries, LastError describes the last one",
+                    "type": "integer",
+                    "example": 0
+                },
+                "id": {
+                    "type": "string",
+                    "example": "3f9a0c1e5b7d4a2f"
+                },
+                "last_error": {
+                    "type": "string",
+                    "example": "webhook answered 503 Service Unavailable"
+                },
+                "last_notified_at": {
+                    "type": "string",
+                    "example": "2025-07-25T10:00:00Z"
+                },
+                "lat": {
+                    "type": "number",
+                    "example": 40.7128
+                },
+                "lon": {
+                    "type": "number",
+                    "example": -74.006
+                },
+                "next_check_at": {
+                    "type": "string",
+                    "example": "2025-07-25T10:30:00Z"
+                },
+                "secret": {
+                    "description": "Secret signs the notifications, it is only returned when the subscription is created",
+                    "type": "string",
+                    "example": "9c1d4e..."
+                },
+                "triggered": {
+                    "description": "Triggered is set once the condition met is notified, the next notification waits for it to be unmet",
+                    "type": "boolean",
+                    "example": false
+                },
+                "webhook_url": {
+                    "type": "string",
+                    "example": "https://example.com/hooks/weather"
+                }
+            }
+        },
+        "models.SubscriptionCondition": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "integer",
+                    "example": 3
+                },
+                "field": {
+                    "type": "string",
+                    "enum": [
+                        "temp_max",
+                        "temp_min"
+                    ],
+                    "example": "temp_max"
+                },
+                "op": {
+                    "type": "string",
+                    "enum": [
+                        "lt",
+                        "lte",
+                        "gt",
+                        "gte"
+                    ],
+                    "example": "gte"
+                },
+                "value": {
+                    "type": "number",
+                    "example": 35
+                }
+            }
+        },
+        "models.TemperatureSpread": {
+            "type": "object",
+            "properties": {
+                "max": {
+                    "type": "number",
+                    "example": 38
+                },
+                "min": {
+                    "type": "number",
+                    "example": 37.1
+                },
+                "std_dev": {
+                    "type": "number",
+                    "example": 0.4
+                }
+            }
+        },
+        "models.Timezone": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string",
+                    "example": "Europe/Rome"
+                },
+                "utc_offset_seconds": {
+                    "type": "integer",
+                    "example": 7200
+                }
+            }
+        },
+        "models.WeatherData": {
+            "type": "object",
+            "properties": {
+                "condition": {
+                    "description": "Condition is the dominant condition of the day, ConditionCode the raw provider code it was mapped from",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/models.Condition"
+                        }
+                    ],
+                    "example": "rain"
+                },
+                "condition_code": {
+                    "type": "integer",
+                    "example": 61
+                },
+                "date": {
+                    "type": "string",
+                    "example": "2023-10-01"
+                },
+                "excluded_from_aggregate": {
+                    "type": "boolean"
+                },
+                "feels_like_max": {
+                    "description": "Optional fields, omitted when the provider doesn't supply them",
+                    "type": "number",
+                    "example": 40.1
+                },
+                "feels_like_min": {
+                    "type": "number",
+                    "example": 25
+                },
+                "humidity_mean": {
+                    "description": "%",
+                    "type": "number",
+                    "example": 65
+                },
+                "past": {
+                    "description": "Past marks days before today requested through the provider's past days option",
+                    "type": "boolean"
+                },
+                "precipitation_probability": {
+                    "description": "%",
+                    "type": "number",
+                    "example": 40
+                },
+                "precipitation_sum": {
+                    "description": "mm, in",
+                    "type": "number",
+                    "example": 1.2
+                },
+                "sunrise": {
+                    "description": "Sunrise and Sunset are in the local time of the location",
+                    "type": "string",
+                    "example": "2023-10-01T07:12:00+02:00"
+                },
+                "sunset": {
+                    "type": "string",
+                    "example": "2023-10-01T19:03:00+02:00"
+                },
+                "suspect": {
+                    "description": "Suspect and ExcludedFromAggregate are set by the post-processing rules",
+                    "type": "boolean"
+                },
+                "temp_max": {
+                    "type": "number",
+                    "example": 38
+                },
+                "temp_min": {
+                    "type": "number",
+                    "example": 24.3
+                },
+                "uv_index_max": {
+                    "type": "number",
+                    "example": 5.3
+                },
+                "wind_gusts_max": {
+                    "description": "m/s, mph",
+                    "type": "number",
+                    "example": 9.8
+                },
+                "wind_speed_max": {
+                    "description": "m/s, mph",
+                    "type": "number",
+                    "example": 5.4
+                }
+            }
+        },
+        "repositories.CanaryMemberStats": {
+            "type": "object",
+            "properties": {
+                "errors": {
+                    "type": "integer"
+                },
+                "requests": {
+                    "type": "integer"
+                }
+            }
+        },
+        "repositories.CanaryStats": {
+            "type": "object",
+            "properties": {
+                "canary": {
+                    "$ref": "#/definitions/repositories.CanaryMemberStats"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "percent": {
+                    "type": "integer"
+                },
+                "primary": {
+                    "$ref": "#/definitions/repositories.CanaryMemberStats"
+                }
+            }
+        },
+        "weather.CacheStats": {
+            "type": "object",
+            "properties": {
+                "backend": {
+                    "type": "string",
+                    "example": "memory"
+                },
+                "enabled": {
+                    "type": "boolean",
+                    "example": true
+                },
+                "entries": {
+                    "type": "integer",
+                    "example": 42
+                },
+                "errors": {
+                    "description": "Errors counts the failed operations of a remote cache, the requests went on without it",
+                    "type": "integer",
+                    "example": 0
+                },
+                "hits": {
+                    "type": "integer",
+                    "example": 1234
+                },
+                "max_entries": {
+                    "type": "integer",
+                    "example": 1000
+                },
+                "memory_bytes": {
+                    "description": "MemoryBytes is an estimate of the memory held by the entries of the memory cache",
+                    "type": "integer",
+                    "example": 81920
+                },
+                "misses": {
+                    "type": "integer",
+                    "example": 56
+                },
+                "ttl_seconds": {
+                    "type": "integer",
+                    "example": 300
+                }
+            }
+        }
+    },
+    "securityDefinitions": {
+        "APIKey": {
+            "description": "Required when API keys are enabled in the configuration, also accepted in the api_key query parameter",
+            "type": "apiKey",
+            "name": "X-API-Key",
+            "in": "header"
+        }
+    },
+    "tags": [
+        {
+            "description": "Weather forecast operations",
+            "name": "Weather"
+        },
+        {
+            "description": "Place name lookups",
+            "name": "Geocoding"
+        },
+        {
+            "description": "Air quality forecasts",
+            "name": "Air Quality"
+        },
+        {
+            "description": "Build information of the deployed instance",
+            "name": "Version"
         }
     ]
 }`
@@ -157,7 +3683,7 @@ const docTemplate = `{
 var SwaggerInfo = &swag.Spec{
 	Version:          "1.0.0",
 	Host:             "localhost:8080",
-	BasePath:         "/",
+	BasePath:         "/v1",
 	Schemes:          []string{"http", "https"},
 	Title:            "Weather API",
 	Description:      "A high-performance, multi-provider weather forecast API built with Go and Fiber.\nThis API aggregates weather data from multiple providers to provide reliable and comprehensive weather forecasts.",
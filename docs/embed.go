@@ -0,0 +1,49 @@
+package docs
+
+import (
+	"embed"
+	"encoding/json"
+	"io/fs"
+	"sync"
+)
+
+// files holds the generated documentation, the pattern also matches the package sources so that the build
+// doesn't fail before the documentation is generated
+//
+//go:embed *
+var files embed.FS
+
+var swaggerJSON = sync.OnceValue(func() []byte {
+	return loadSwaggerJSON(files, SwaggerInfo.Version)
+})
+
+// SwaggerJSON returns the generated Swagger specification with the version of SwaggerInfo, or a minimal one
+// with the API title and version when the binary was built without it. Set the version before the first call.
+func SwaggerJSON() []byte {
+	return swaggerJSON()
+}
+
+func loadSwaggerJSON(fsys fs.FS, version string) []byte {
+	var spec map[string]json.RawMessage
+	if data, err := fs.ReadFile(fsys, "swagger.json"); err == nil && json.Unmarshal(data, &spec) == nil {
+		info := map[string]any{}
+		_ = json.Unmarshal(spec["info"], &info)
+		info["version"] = version
+		spec["info"], _ = json.Marshal(info)
+
+		data, _ = json.Marshal(spec)
+		return data
+	}
+
+	data, _ := json.Marshal(map[string]any{
+		"swagger": "2.0",
+		"info": map[string]string{
+			"title":       SwaggerInfo.Title,
+			"version":     version,
+			"description": "The full specification was not generated for this build",
+		},
+		"paths": map[string]any{},
+	})
+
+	return data
+}
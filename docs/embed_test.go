@@ -0,0 +1,49 @@
+package docs
+
+import (
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type spec struct {
+	Swagger string         `json:"swagger"`
+	Info    map[string]any `json:"info"`
+	Paths   map[string]any `json:"paths"`
+}
+
+func TestSwaggerJSON(t *testing.T) {
+	var got spec
+	require.NoError(t, json.Unmarshal(SwaggerJSON(), &got))
+	assert.Equal(t, "2.0", got.Swagger)
+	assert.Equal(t, SwaggerInfo.Version, got.Info["version"])
+	assert.NotEmpty(t, got.Paths)
+}
+
+func TestLoadSwaggerJSON_Version(t *testing.T) {
+	fsys := fstest.MapFS{"swagger.json": {Data: []byte(`{"swagger": "2.0", "info": {"title": "Weather API", "version": "1.0.0"}, "paths": {"/weather": {}}}`)}}
+
+	var got spec
+	require.NoError(t, json.Unmarshal(loadSwaggerJSON(fsys, "1.2.0"), &got))
+	assert.Equal(t, map[string]any{"title": "Weather API", "version": "1.2.0"}, got.Info)
+	assert.Contains(t, got.Paths, "/weather")
+}
+
+func TestLoadSwaggerJSON_Fallback(t *testing.T) {
+	for name, fsys := range map[string]fstest.MapFS{
+		"missing": {},
+		"invalid": {"swagger.json": {Data: []byte("{")}},
+	} {
+		t.Run(name, func(t *testing.T) {
+			var got spec
+			require.NoError(t, json.Unmarshal(loadSwaggerJSON(fsys, "1.2.0"), &got))
+			assert.Equal(t, "2.0", got.Swagger)
+			assert.Equal(t, "Weather API", got.Info["title"])
+			assert.Equal(t, "1.2.0", got.Info["version"])
+			assert.Empty(t, got.Paths)
+		})
+	}
+}
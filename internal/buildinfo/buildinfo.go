@@ -0,0 +1,89 @@
+// Package buildinfo identifies the running build. The version, the commit and the build date are set at link time,
+//
+//	go build -ldflags "-X weather-api/internal/buildinfo.Version=1.2.0 -X weather-api/internal/buildinfo.Commit=$(git rev-parse HEAD)"
+//
+// without them they are read from the build information embedded by the Go toolchain.
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Set with -ldflags "-X weather-api/internal/buildinfo.<name>=<value>", see make build
+var (
+	Version   string
+	Commit    string
+	BuildDate string
+)
+
+const (
+	// DevVersion is the version of a build without version information, e.g. go run
+	DevVersion = "dev"
+	unknown    = "unknown"
+)
+
+// Info describes the running build
+type Info struct {
+	Name      string `json:"name" example:"weather-api"`
+	Version   string `json:"version" example:"1.2.0"`
+	Commit    string `json:"commit" example:"3f2b8c0e9a4d4f5e8b1c2d3e4f5a6b7c8d9e0f1a"`
+	BuildDate string `json:"build_date" example:"2025-07-25T10:00:00Z"`
+	GoVersion string `json:"go_version" example:"go1.24.3"`
+}
+
+// Get returns the build information of the application name
+func Get(name string) Info {
+	info := linked()
+	info.Name = name
+
+	return info
+}
+
+var linked = sync.OnceValue(func() Info {
+	return resolve(Version, Commit, BuildDate, debug.ReadBuildInfo)
+})
+
+// resolve completes the link time values with the embedded build information, the commit time stands in for
+// the build date
+func resolve(version, commit, buildDate string, read func() (*debug.BuildInfo, bool)) Info {
+	info := Info{Version: version, Commit: commit, BuildDate: buildDate, GoVersion: runtime.Version()}
+
+	if bi, ok := read(); ok {
+		if info.Version == "" && bi.Main.Version != "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+
+		var modified bool
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = s.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = s.Value
+				}
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		if modified && commit == "" && info.Commit != "" {
+			info.Commit += "-dirty"
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = DevVersion
+	}
+	if info.Commit == "" {
+		info.Commit = unknown
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = unknown
+	}
+
+	return info
+}
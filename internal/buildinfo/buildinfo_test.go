@@ -0,0 +1,71 @@
+package buildinfo
+
+import (
+	"runtime"
+	"runtime/debug"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolve(t *testing.T) {
+	embedded := func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{
+			Main: debug.Module{Path: "weather-api", Version: "v1.1.0"},
+			Settings: []debug.BuildSetting{
+				{Key: "vcs.revision", Value: "3f2b8c0e"},
+				{Key: "vcs.time", Value: "2025-07-24T08:00:00Z"},
+				{Key: "vcs.modified", Value: "true"},
+			},
+		}, true
+	}
+	missing := func() (*debug.BuildInfo, bool) {
+		return nil, false
+	}
+	devel := func() (*debug.BuildInfo, bool) {
+		return &debug.BuildInfo{Main: debug.Module{Path: "weather-api", Version: "(devel)"}}, true
+	}
+
+	tests := []struct {
+		name                       string
+		version, commit, buildDate string
+		read                       func() (*debug.BuildInfo, bool)
+		want                       Info
+	}{
+		{
+			"ldflags", "1.2.0", "9a4d4f5e", "2025-07-25T10:00:00Z", embedded,
+			Info{Version: "1.2.0", Commit: "9a4d4f5e", BuildDate: "2025-07-25T10:00:00Z"},
+		},
+		{
+			"embedded build information", "", "", "", embedded,
+			Info{Version: "v1.1.0", Commit: "3f2b8c0e-dirty", BuildDate: "2025-07-24T08:00:00Z"},
+		},
+		{
+			"partial ldflags", "1.2.0", "", "", embedded,
+			Info{Version: "1.2.0", Commit: "3f2b8c0e-dirty", BuildDate: "2025-07-24T08:00:00Z"},
+		},
+		{
+			"development build", "", "", "", devel,
+			Info{Version: DevVersion, Commit: unknown, BuildDate: unknown},
+		},
+		{
+			"no build information", "", "", "", missing,
+			Info{Version: DevVersion, Commit: unknown, BuildDate: unknown},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.want.GoVersion = runtime.Version()
+			assert.Equal(t, tt.want, resolve(tt.version, tt.commit, tt.buildDate, tt.read))
+		})
+	}
+}
+
+func TestGet(t *testing.T) {
+	info := Get("weather-api")
+	assert.Equal(t, "weather-api", info.Name)
+	assert.NotEmpty(t, info.Version)
+	assert.NotEmpty(t, info.Commit)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+}
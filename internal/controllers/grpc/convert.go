@@ -0,0 +1,132 @@
+package grpc
+
+import (
+	"math"
+	"slices"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"weather-api/internal/models"
+	weatherv1 "weather-api/proto/weather/v1"
+)
+
+const (
+	defaultForecastWindow = 5
+	maxLatitude           = 90
+	maxLongitude          = 180
+)
+
+// validateLocation checks the coordinates and the forecast window of up to maxDays of a request, zero days
+// select the default window, and returns the window
+func validateLocation(lat, lon float64, days int32, maxDays int) (int, error) {
+	if math.IsNaN(lat) || math.IsInf(lat, 0) || math.IsNaN(lon) || math.IsInf(lon, 0) {
+		return 0, status.Error(codes.InvalidArgument, "lat and lon must be finite numbers")
+	}
+	if math.Abs(lat) > maxLatitude {
+		return 0, status.Errorf(codes.InvalidArgument, "lat must be between %d and %d, got: %g", -maxLatitude, maxLatitude, lat)
+	}
+	if math.Abs(lon) > maxLongitude {
+		return 0, status.Errorf(codes.InvalidArgument, "lon must be between %d and %d, got: %g", -maxLongitude, maxLongitude, lon)
+	}
+
+	if days == 0 {
+		return min(defaultForecastWindow, maxDays), nil
+	}
+	if days < 1 || int(days) > maxDays {
+		return 0, status.Errorf(codes.InvalidArgument, "days must be between 1 and %d, got: %d", maxDays, days)
+	}
+
+	return int(days), nil
+}
+
+// parseProviders matches the provider names case-insensitively against the available ones, an empty list
+// selects every provider
+func parseProviders(names, available []string) ([]string, error) {
+	var providers []string
+	for _, name := range names {
+		i := slices.IndexFunc(available, func(a string) bool { return strings.EqualFold(a, name) })
+		if i < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "unknown provider: %s, valid providers are: %s", name, strings.Join(available, ", "))
+		}
+		if !slices.Contains(providers, available[i]) {
+			providers = append(providers, available[i])
+		}
+	}
+
+	return providers, nil
+}
+
+func forecastMessage(forecast models.Forecast) *weatherv1.Forecast {
+	msg := &weatherv1.Forecast{
+		Provider:       forecast.RepositoryName,
+		Lat:            forecast.Lat,
+		Lon:            forecast.Lon,
+		ForecastWindow: int32(forecast.ForecastWindow),
+		Units:          forecast.Units,
+		Error:          forecast.Error,
+		ErrorCode:      forecast.ErrorCode,
+		Note:           forecast.Note,
+		Cached:         forecast.Cached,
+		Stale:          forecast.Stale,
+		Days:           make([]*weatherv1.Day, 0, len(forecast.ForecastData)),
+	}
+	for _, wd := range forecast.ForecastData {
+		msg.Days = append(msg.Days, &weatherv1.Day{
+			Date:                     wd.Date.String(),
+			TempMax:                  wd.TempMax,
+			TempMin:                  wd.TempMin,
+			PrecipitationSum:         wd.PrecipitationSum,
+			PrecipitationProbability: wd.PrecipitationProbability,
+			WindSpeedMax:             wd.WindSpeedMax,
+			HumidityMean:             wd.HumidityMean,
+			Condition:                string(wd.Condition),
+		})
+	}
+
+	return msg
+}
+
+func aggregateMessage(aggregated models.AggregatedForecast) *weatherv1.GetAggregateForecastResponse {
+	msg := &weatherv1.GetAggregateForecastResponse{
+		Lat:            aggregated.Lat,
+		Lon:            aggregated.Lon,
+		ForecastWindow: int32(aggregated.ForecastWindow),
+		Strategy:       aggregated.Strategy,
+		MinProviders:   int32(aggregated.MinProviders),
+		Units:          aggregated.Units,
+		Providers:      aggregated.Providers,
+		Days:           make([]*weatherv1.AggregatedDay, 0, len(aggregated.ForecastData)),
+	}
+	for _, wd := range aggregated.ForecastData {
+		msg.Days = append(msg.Days, &weatherv1.AggregatedDay{
+			Date:          wd.Date.String(),
+			TempMax:       wd.TempMax,
+			TempMin:       wd.TempMin,
+			Spread:        wd.Spread,
+			ProviderCount: int32(wd.ProviderCount),
+		})
+	}
+
+	return msg
+}
+
+func providerStatusMessage(st models.ProviderStatus) *weatherv1.ProviderStatus {
+	msg := &weatherv1.ProviderStatus{
+		Name:          st.Name,
+		RequiresKey:   st.RequiresKey,
+		Disabled:      st.Disabled,
+		Circuit:       st.Circuit,
+		Calls:         int32(st.Calls),
+		ErrorRate:     st.ErrorRate,
+		AvgLatencyMs:  st.AvgLatencyMS,
+		LastErrorCode: st.LastErrorCode,
+		RateLimited:   st.RateLimited,
+	}
+	if st.Health != nil {
+		msg.Healthy = &st.Health.Healthy
+	}
+
+	return msg
+}
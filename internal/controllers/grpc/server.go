@@ -0,0 +1,277 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net"
+	"sort"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/geo"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+	"weather-api/pkg/units"
+	weatherv1 "weather-api/proto/weather/v1"
+)
+
+// The metadata keys read by the server, the gRPC counterparts of the X-Request-ID and X-API-Key headers
+const (
+	metadataRequestID = "x-request-id"
+	metadataAPIKey    = "x-api-key"
+)
+
+// Forecaster is the part of the weather service used by the gRPC server, *weather.WeatherService implements it
+type Forecaster interface {
+	Providers() []string
+	RequestBudget() time.Duration
+	MaxForecastDays() int
+	FetchProviderForecasts(ctx context.Context, lat, lon float64, forecastWindow int, providers []string) (map[string]models.Forecast, error)
+	AggregateForecasts(ctx context.Context, lat, lon float64, forecastWindow int, strategy string, minProviders int) (models.AggregatedForecast, error)
+	ProviderStatus(ctx context.Context) []models.ProviderStatus
+}
+
+var _ Forecaster = (*weather.WeatherService)(nil)
+
+// server implements the WeatherService of weather.proto on top of the weather service
+type server struct {
+	weatherv1.UnimplementedWeatherServiceServer
+
+	service Forecaster
+	// keys maps the SHA-256 hash of every accepted API key to its name, nil disables the authentication
+	keys map[string]string
+	// area rejects the locations outside the service area
+	area geo.Area
+	l    logger.Logger
+}
+
+// Option configures the optional behaviors of the server
+type Option func(*server)
+
+// WithAPIKeys requires one of the keys in the x-api-key metadata of every call, keyed by the lowercase hex
+// SHA-256 hash of the key as in the HTTP authentication
+func WithAPIKeys(keys map[string]string) Option {
+	return func(s *server) {
+		s.keys = keys
+	}
+}
+
+// WithServiceArea rejects the locations outside the area as invalid arguments, as the HTTP API does
+func WithServiceArea(area geo.Area) Option {
+	return func(s *server) {
+		s.area = area
+	}
+}
+
+// NewServer returns a gRPC server serving the WeatherService, ready to be started with Serve
+func NewServer(service Forecaster, l logger.Logger, opts ...Option) *grpc.Server {
+	s := &server{service: service, l: l}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(s.requestValues, s.authenticate))
+	weatherv1.RegisterWeatherServiceServer(srv, s)
+
+	return srv
+}
+
+// GetForecast fetches the forecast of every requested provider, in provider name order
+func (s *server) GetForecast(ctx context.Context, req *weatherv1.GetForecastRequest) (*weatherv1.GetForecastResponse, error) {
+	days, err := validateLocation(req.GetLat(), req.GetLon(), req.GetDays(), s.service.MaxForecastDays())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.area.Check(req.GetLat(), req.GetLon()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	system, err := units.Parse(req.GetUnits())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "units: %v", err)
+	}
+	providers, err := parseProviders(req.GetProviders(), s.service.Providers())
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.service.RequestBudget())
+	defer cancel()
+
+	forecasts, err := s.service.FetchProviderForecasts(ctx, req.GetLat(), req.GetLon(), days, providers)
+	if err != nil {
+		return nil, s.fetchError(ctx, err)
+	}
+
+	names := make([]string, 0, len(forecasts))
+	failed := 0
+	for name, forecast := range forecasts {
+		names = append(names, name)
+		if forecast.Error != "" {
+			failed++
+		}
+	}
+	if failed == len(forecasts) {
+		return nil, status.Error(codes.Unavailable, "all weather providers failed")
+	}
+	sort.Strings(names)
+
+	resp := &weatherv1.GetForecastResponse{Forecasts: make([]*weatherv1.Forecast, 0, len(names))}
+	for _, name := range names {
+		// Providers always report metric values
+		forecast := forecasts[name]
+		forecast.ConvertUnits(system)
+		resp.Forecasts = append(resp.Forecasts, forecastMessage(forecast))
+	}
+
+	return resp, nil
+}
+
+// GetAggregateForecast merges the forecasts of the providers into a single series
+func (s *server) GetAggregateForecast(ctx context.Context, req *weatherv1.GetAggregateForecastRequest) (*weatherv1.GetAggregateForecastResponse, error) {
+	days, err := validateLocation(req.GetLat(), req.GetLon(), req.GetDays(), s.service.MaxForecastDays())
+	if err != nil {
+		return nil, err
+	}
+	if err := s.area.Check(req.GetLat(), req.GetLon()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	system, err := units.Parse(req.GetUnits())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "units: %v", err)
+	}
+	strategy, err := weather.ParseStrategy(req.GetStrategy())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "strategy: %v", err)
+	}
+	if req.GetMinProviders() < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "min_providers must not be negative, got: %d", req.GetMinProviders())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, s.service.RequestBudget())
+	defer cancel()
+
+	aggregated, err := s.service.AggregateForecasts(ctx, req.GetLat(), req.GetLon(), days, strategy, int(req.GetMinProviders()))
+	if err != nil {
+		return nil, s.fetchError(ctx, err)
+	}
+
+	aggregated.ConvertUnits(system)
+
+	return aggregateMessage(aggregated), nil
+}
+
+// ListProviders returns the status of every forecast provider
+func (s *server) ListProviders(ctx context.Context, _ *weatherv1.ListProvidersRequest) (*weatherv1.ListProvidersResponse, error) {
+	statuses := s.service.ProviderStatus(ctx)
+
+	resp := &weatherv1.ListProvidersResponse{Providers: make([]*weatherv1.ProviderStatus, 0, len(statuses))}
+	for _, st := range statuses {
+		resp.Providers = append(resp.Providers, providerStatusMessage(st))
+	}
+
+	return resp, nil
+}
+
+// fetchError maps an error of the service to the status of the call, a failure of every provider is
+// Unavailable as the call may succeed once they recover
+func (s *server) fetchError(ctx context.Context, err error) error {
+	var quorumErr *weather.QuorumError
+	switch {
+	case errors.Is(err, weather.ErrNoForecasts), errors.As(err, &quorumErr):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "request canceled")
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "weather providers did not answer in time")
+	}
+
+	s.l.Error(err, map[string]any{"request_id": requestid.FromContext(ctx)})
+
+	return status.Error(codes.Internal, "failed to fetch weather data")
+}
+
+// requestValues adds the request ID of the call, taken from the x-request-id metadata when valid, the
+// caller identity used for canary routing and a logger carrying them to the context
+func (s *server) requestValues(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	id := firstMetadata(ctx, metadataRequestID)
+	if !requestid.Valid(id) {
+		id = requestid.Generate()
+	}
+	_ = grpc.SetHeader(ctx, metadata.Pairs(metadataRequestID, id))
+	ctx = requestid.NewContext(ctx, id)
+
+	fields := map[string]any{"request_id": id, "method": info.FullMethod}
+	if p, ok := peer.FromContext(ctx); ok {
+		host, _, err := net.SplitHostPort(p.Addr.String())
+		if err != nil {
+			host = p.Addr.String()
+		}
+		ctx = repositories.WithCanaryKey(ctx, host)
+		fields["ip"] = host
+	}
+	ctx = logger.NewContext(ctx, s.l.WithFields(fields))
+
+	return handler(ctx, req)
+}
+
+// authenticate requires an accepted API key in the x-api-key metadata, a missing key is Unauthenticated
+// and an unknown one PermissionDenied
+func (s *server) authenticate(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if s.keys == nil {
+		return handler(ctx, req)
+	}
+
+	fields := map[string]any{"request_id": requestid.FromContext(ctx), "method": info.FullMethod}
+	key := firstMetadata(ctx, metadataAPIKey)
+	if key == "" {
+		s.l.Warning("call without API key", fields)
+		return nil, status.Error(codes.Unauthenticated, "an API key is required in the x-api-key metadata")
+	}
+	sum := sha256.Sum256([]byte(key))
+	name, ok := s.keys[hex.EncodeToString(sum[:])]
+	if !ok {
+		s.l.Warning("call with an unknown API key", fields)
+		return nil, status.Error(codes.PermissionDenied, "unknown API key")
+	}
+
+	fields["api_key_name"] = name
+	s.l.Debug("authenticated call", fields)
+
+	return handler(ctx, req)
+}
+
+// firstMetadata returns the first value of an incoming metadata key, or an empty string
+func firstMetadata(ctx context.Context, key string) string {
+	md, _ := metadata.FromIncomingContext(ctx)
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+
+	return ""
+}
+
+// Shutdown stops the server once the pending calls are answered, the calls still running when ctx is done
+// are canceled
+func Shutdown(ctx context.Context, srv *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+}
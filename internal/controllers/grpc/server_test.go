@@ -0,0 +1,255 @@
+package grpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"weather-api/internal/models"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/geo"
+	"weather-api/pkg/logger"
+	weatherv1 "weather-api/proto/weather/v1"
+)
+
+type stubForecaster struct {
+	forecasts  map[string]models.Forecast
+	aggregated models.AggregatedForecast
+	err        error
+	// window is the forecast window of the last call
+	window int
+}
+
+func (s *stubForecaster) Providers() []string          { return []string{"open-meteo", "weatherapi"} }
+func (s *stubForecaster) RequestBudget() time.Duration { return time.Second }
+func (s *stubForecaster) MaxForecastDays() int         { return 16 }
+func (s *stubForecaster) ProviderStatus(context.Context) []models.ProviderStatus {
+	return []models.ProviderStatus{
+		{Name: "open-meteo", Circuit: models.CircuitNone, Health: &models.ProviderHealth{Healthy: true}},
+		{Name: "weatherapi", RequiresKey: true, Circuit: models.CircuitNone},
+	}
+}
+
+func (s *stubForecaster) FetchProviderForecasts(_ context.Context, _, _ float64, forecastWindow int, _ []string) (map[string]models.Forecast, error) {
+	s.window = forecastWindow
+	return s.forecasts, s.err
+}
+
+func (s *stubForecaster) AggregateForecasts(_ context.Context, _, _ float64, forecastWindow int, _ string, _ int) (models.AggregatedForecast, error) {
+	s.window = forecastWindow
+	return s.aggregated, s.err
+}
+
+// newTestClient serves the stub over an in-memory connection
+func newTestClient(t *testing.T, service Forecaster, opts ...Option) weatherv1.WeatherServiceClient {
+	t.Helper()
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(service, logger.NopLogger{}, opts...)
+	go func() { _ = srv.Serve(lis) }()
+	t.Cleanup(func() { Shutdown(context.Background(), srv) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+
+	return weatherv1.NewWeatherServiceClient(conn)
+}
+
+func TestServer_GetForecast(t *testing.T) {
+	date, err := models.ParseDate("2025-07-25")
+	require.NoError(t, err)
+	precipitation := 1.5
+
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{
+		"weatherapi": {RepositoryName: "weatherapi", Error: "provider timed out", ErrorCode: "timeout"},
+		"open-meteo": {
+			RepositoryName: "open-meteo", Lat: 52.52, Lon: 13.41, ForecastWindow: 1,
+			ForecastData: []models.WeatherData{{Date: date, TempMax: 25, TempMin: 15, PrecipitationSum: &precipitation}},
+		},
+	}}
+	client := newTestClient(t, stub)
+
+	var header metadata.MD
+	resp, err := client.GetForecast(context.Background(), &weatherv1.GetForecastRequest{Lat: 52.52, Lon: 13.41, Units: "imperial"}, grpc.Header(&header))
+	require.NoError(t, err)
+	assert.Equal(t, defaultForecastWindow, stub.window)
+	assert.NotEmpty(t, header.Get(metadataRequestID))
+
+	require.Len(t, resp.GetForecasts(), 2)
+	forecast := resp.GetForecasts()[0]
+	assert.Equal(t, "open-meteo", forecast.GetProvider())
+	assert.Equal(t, "imperial", forecast.GetUnits())
+	require.Len(t, forecast.GetDays(), 1)
+	assert.Equal(t, "2025-07-25", forecast.GetDays()[0].GetDate())
+	assert.Equal(t, 77.0, forecast.GetDays()[0].GetTempMax())
+	assert.NotNil(t, forecast.GetDays()[0].PrecipitationSum)
+	assert.Nil(t, forecast.GetDays()[0].WindSpeedMax)
+	assert.Equal(t, "timeout", resp.GetForecasts()[1].GetErrorCode())
+}
+
+func TestServer_Errors(t *testing.T) {
+	failed := map[string]models.Forecast{"open-meteo": {RepositoryName: "open-meteo", Error: "provider timed out"}}
+
+	tests := []struct {
+		name string
+		stub *stubForecaster
+		call func(weatherv1.WeatherServiceClient) error
+		code codes.Code
+	}{
+		{
+			name: "latitude out of range",
+			stub: &stubForecaster{},
+			call: func(c weatherv1.WeatherServiceClient) error {
+				_, err := c.GetForecast(context.Background(), &weatherv1.GetForecastRequest{Lat: 91})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "days beyond the maximum",
+			stub: &stubForecaster{},
+			call: func(c weatherv1.WeatherServiceClient) error {
+				_, err := c.GetForecast(context.Background(), &weatherv1.GetForecastRequest{Days: 17})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "unknown provider",
+			stub: &stubForecaster{},
+			call: func(c weatherv1.WeatherServiceClient) error {
+				_, err := c.GetForecast(context.Background(), &weatherv1.GetForecastRequest{Providers: []string{"unknown"}})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "unknown strategy",
+			stub: &stubForecaster{},
+			call: func(c weatherv1.WeatherServiceClient) error {
+				_, err := c.GetAggregateForecast(context.Background(), &weatherv1.GetAggregateForecastRequest{Strategy: "mode"})
+				return err
+			},
+			code: codes.InvalidArgument,
+		},
+		{
+			name: "every provider failed",
+			stub: &stubForecaster{forecasts: failed},
+			call: func(c weatherv1.WeatherServiceClient) error {
+				_, err := c.GetForecast(context.Background(), &weatherv1.GetForecastRequest{})
+				return err
+			},
+			code: codes.Unavailable,
+		},
+		{
+			name: "no forecast to aggregate",
+			stub: &stubForecaster{err: weather.ErrNoForecasts},
+			call: func(c weatherv1.WeatherServiceClient) error {
+				_, err := c.GetAggregateForecast(context.Background(), &weatherv1.GetAggregateForecastRequest{})
+				return err
+			},
+			code: codes.Unavailable,
+		},
+		{
+			name: "quorum not met",
+			stub: &stubForecaster{err: &weather.QuorumError{Required: 2, Available: 1}},
+			call: func(c weatherv1.WeatherServiceClient) error {
+				_, err := c.GetAggregateForecast(context.Background(), &weatherv1.GetAggregateForecastRequest{})
+				return err
+			},
+			code: codes.Unavailable,
+		},
+		{
+			name: "budget exceeded",
+			stub: &stubForecaster{err: context.DeadlineExceeded},
+			call: func(c weatherv1.WeatherServiceClient) error {
+				_, err := c.GetForecast(context.Background(), &weatherv1.GetForecastRequest{})
+				return err
+			},
+			code: codes.DeadlineExceeded,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call(newTestClient(t, tt.stub))
+			assert.Equal(t, tt.code, status.Code(err), err)
+		})
+	}
+}
+
+func TestServer_GetAggregateForecast(t *testing.T) {
+	date, err := models.ParseDate("2025-07-25")
+	require.NoError(t, err)
+
+	stub := &stubForecaster{aggregated: models.AggregatedForecast{
+		Lat: 52.52, Lon: 13.41, ForecastWindow: 3, Strategy: weather.StrategyMedian, MinProviders: 1,
+		Providers:    []string{"open-meteo"},
+		ForecastData: []models.AggregatedWeatherData{{Date: date, TempMax: 25, TempMin: 15, Spread: 1, ProviderCount: 1}},
+	}}
+	client := newTestClient(t, stub)
+
+	resp, err := client.GetAggregateForecast(context.Background(), &weatherv1.GetAggregateForecastRequest{Lat: 52.52, Lon: 13.41, Days: 3, Strategy: "median"})
+	require.NoError(t, err)
+	assert.Equal(t, 3, stub.window)
+	assert.Equal(t, "median", resp.GetStrategy())
+	assert.Equal(t, "metric", resp.GetUnits())
+	assert.Equal(t, []string{"open-meteo"}, resp.GetProviders())
+	require.Len(t, resp.GetDays(), 1)
+	assert.Equal(t, int32(1), resp.GetDays()[0].GetProviderCount())
+}
+
+func TestServer_ListProviders(t *testing.T) {
+	client := newTestClient(t, &stubForecaster{})
+
+	resp, err := client.ListProviders(context.Background(), &weatherv1.ListProvidersRequest{})
+	require.NoError(t, err)
+	require.Len(t, resp.GetProviders(), 2)
+	assert.True(t, resp.GetProviders()[0].GetHealthy())
+	assert.Nil(t, resp.GetProviders()[1].Healthy)
+	assert.True(t, resp.GetProviders()[1].GetRequiresKey())
+}
+
+func TestServer_APIKeys(t *testing.T) {
+	sum := sha256.Sum256([]byte("secret"))
+	client := newTestClient(t, &stubForecaster{}, WithAPIKeys(map[string]string{hex.EncodeToString(sum[:]): "internal"}))
+
+	_, err := client.ListProviders(context.Background(), &weatherv1.ListProvidersRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), metadataAPIKey, "wrong")
+	_, err = client.ListProviders(ctx, &weatherv1.ListProvidersRequest{})
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), metadataAPIKey, "secret")
+	_, err = client.ListProviders(ctx, &weatherv1.ListProvidersRequest{})
+	assert.NoError(t, err)
+}
+
+func TestServer_ServiceArea(t *testing.T) {
+	stub := &stubForecaster{}
+	client := newTestClient(t, stub, WithServiceArea(geo.Area{RejectNullIsland: true}))
+
+	_, err := client.GetForecast(context.Background(), &weatherv1.GetForecastRequest{Lat: 0, Lon: 0})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Zero(t, stub.window)
+
+	_, err = client.GetAggregateForecast(context.Background(), &weatherv1.GetAggregateForecastRequest{Lat: 0, Lon: 0})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.Zero(t, stub.window)
+}
@@ -0,0 +1,297 @@
+package http
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// AdminService is the part of the weather service operated by the admin API, *weather.WeatherService implements it
+type AdminService interface {
+	SetProviderEnabled(name string, enabled bool) error
+	Canaries() []*repositories.CanaryRepository
+	CacheStats() weather.CacheStats
+	PurgeCache() int
+	PurgeCacheLocation(lat, lon float64) int
+}
+
+var _ AdminService = (*weather.WeatherService)(nil)
+
+type adminRoutes struct {
+	service AdminService
+	reload  Reloader
+	config  ConfigDumper
+	l       logger.Logger
+}
+
+// AdminOption mounts the optional routes of the admin API
+type AdminOption func(*adminRoutes)
+
+// WithReloader mounts POST /admin/reload
+func WithReloader(reload Reloader) AdminOption {
+	return func(r *adminRoutes) {
+		r.reload = reload
+	}
+}
+
+// ConfigDumper returns the effective configuration in YAML, its secrets redacted
+type ConfigDumper func() ([]byte, error)
+
+// WithConfigDump mounts GET /admin/config
+func WithConfigDump(dump ConfigDumper) AdminOption {
+	return func(r *adminRoutes) {
+		r.config = dump
+	}
+}
+
+// Reloader reloads the forecast providers from the configuration and reports the providers changed by name
+type Reloader func() (ReloadResult, error)
+
+// ReloadResult lists the providers added, removed and changed by a reload of the configuration
+type ReloadResult struct {
+	Added   []string `json:"added" example:"weatherapi"`
+	Removed []string `json:"removed"`
+	Changed []string `json:"changed" example:"openmeteo"`
+}
+
+// ProviderState is the rotation state of a provider
+type ProviderState struct {
+	Name    string `json:"name" example:"weatherapi"`
+	Enabled bool   `json:"enabled" example:"false"`
+}
+
+// CachePurgeResult reports the forecasts removed from the cache
+type CachePurgeResult struct {
+	Purged int `json:"purged" example:"12"`
+}
+
+// CanaryPercentRequest represents a request to change the canary traffic share
+type CanaryPercentRequest struct {
+	Percent *int `json:"percent" example:"5"`
+}
+
+// NewAdminRouter mounts the admin API, it is disabled when no admin token is configured
+func NewAdminRouter(
+	app *fiber.App,
+	token string,
+	service AdminService,
+	l logger.Logger,
+	opts ...AdminOption,
+) {
+	if token == "" {
+		l.Info("admin API disabled: no admin token configured")
+		return
+	}
+
+	r := &adminRoutes{service: service, l: l}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	admin := app.Group("/admin", adminAuth(token))
+	admin.Get("/canaries", r.handleListCanaries)
+	admin.Put("/canaries/:name", r.handleSetCanaryPercent)
+	admin.Post("/providers/:name/enable", r.handleSetProviderEnabled(true))
+	admin.Post("/providers/:name/disable", r.handleSetProviderEnabled(false))
+	admin.Get("/cache/stats", r.handleCacheStats)
+	admin.Delete("/cache", r.handlePurgeCache)
+	if r.reload != nil {
+		admin.Post("/reload", r.handleReload)
+	}
+	if r.config != nil {
+		admin.Get("/config", r.handleConfig)
+	}
+}
+
+// adminAuth checks the admin token passed as a bearer token
+func adminAuth(token string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		provided := strings.TrimPrefix(c.Get(fiber.HeaderAuthorization), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			return problem(c, fiber.StatusUnauthorized, ProblemUnauthorized, "invalid admin token")
+		}
+
+		return c.Next()
+	}
+}
+
+// handleListCanaries godoc
+// @Summary List canary groups
+// @Description Returns the traffic share and per-member counters of every canary group
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {array} repositories.CanaryStats
+// @Failure 401 {object} Problem
+// @Router /admin/canaries [get]
+func (r *adminRoutes) handleListCanaries(c *fiber.Ctx) error {
+	canaries := r.service.Canaries()
+	stats := make([]repositories.CanaryStats, 0, len(canaries))
+	for _, canary := range canaries {
+		stats = append(stats, canary.Stats())
+	}
+
+	return c.JSON(stats)
+}
+
+// handleSetCanaryPercent godoc
+// @Summary Set canary percentage
+// @Description Changes the share of the provider traffic routed to the canary member
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security AdminToken
+// @Param name path string true "Provider name"
+// @Param request body CanaryPercentRequest true "New canary percentage"
+// @Success 200 {object} repositories.CanaryStats
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /admin/canaries/{name} [put]
+func (r *adminRoutes) handleSetCanaryPercent(c *fiber.Ctx) error {
+	name := c.Params("name")
+
+	var canary *repositories.CanaryRepository
+	for _, c := range r.service.Canaries() {
+		if c.Name() == name {
+			canary = c
+		}
+	}
+	if canary == nil {
+		return problem(c, fiber.StatusNotFound, ProblemNotFound, fmt.Sprintf("no canary configured for provider: %s", name))
+	}
+
+	var req CanaryPercentRequest
+	if err := c.BodyParser(&req); err != nil || req.Percent == nil {
+		return validationProblem(c, paramError("percent", errors.New("request body must contain a percent value")))
+	}
+
+	if err := canary.SetPercent(*req.Percent); err != nil {
+		return validationProblem(c, paramError("percent", err))
+	}
+
+	return c.JSON(canary.Stats())
+}
+
+// handleSetProviderEnabled godoc
+// @Summary Enable or disable a provider
+// @Description Puts a forecast provider back in rotation or pulls it out until it is enabled again or the service
+// @Description restarts. The forecasts of a disabled provider carry the disabled error code, it isn't called.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param name path string true "Provider name"
+// @Success 200 {object} ProviderState
+// @Failure 401 {object} Problem
+// @Failure 404 {object} Problem
+// @Router /admin/providers/{name}/enable [post]
+// @Router /admin/providers/{name}/disable [post]
+func (r *adminRoutes) handleSetProviderEnabled(enabled bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		name := c.Params("name")
+
+		err := r.service.SetProviderEnabled(name, enabled)
+		if errors.Is(err, weather.ErrUnknownProvider) {
+			return problem(c, fiber.StatusNotFound, ProblemNotFound, fmt.Sprintf("unknown weather provider: %s", name))
+		}
+		if err != nil {
+			return problem(c, fiber.StatusInternalServerError, ProblemInternal, "Failed to change the provider state")
+		}
+
+		return c.JSON(ProviderState{Name: name, Enabled: enabled})
+	}
+}
+
+// handleCacheStats godoc
+// @Summary Get forecast cache statistics
+// @Description Returns the number of cached forecasts, the hit and miss counters and an estimate of the memory held
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} weather.CacheStats
+// @Failure 401 {object} Problem
+// @Router /admin/cache/stats [get]
+func (r *adminRoutes) handleCacheStats(c *fiber.Ctx) error {
+	return c.JSON(r.service.CacheStats())
+}
+
+// handlePurgeCache godoc
+// @Summary Purge the forecast cache
+// @Description Removes every cached forecast, or only those of a location, from every provider, when lat and lon are given
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Param lat query number false "Lat coordinate (-90 to 90)" minimum(-90) maximum(90) example(40.7128)
+// @Param lon query number false "Lon coordinate (-180 to 180)" minimum(-180) maximum(180) example(-74.006)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(40.7128,-74.006)
+// @Success 200 {object} CachePurgeResult
+// @Failure 400 {object} Problem
+// @Failure 401 {object} Problem
+// @Router /admin/cache [delete]
+func (r *adminRoutes) handlePurgeCache(c *fiber.Ctx) error {
+	if c.Query("lat") == "" && c.Query("lon") == "" && c.Query("coords") == "" {
+		purged := r.service.PurgeCache()
+		r.l.Warning("forecast cache purged", map[string]any{"purged": purged})
+
+		return c.JSON(CachePurgeResult{Purged: purged})
+	}
+
+	lat, lon, err := validateLocation(c, false)
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	purged := r.service.PurgeCacheLocation(lat, lon)
+	r.l.Info("forecast cache purged for a location", map[string]any{"lat": lat, "lon": lon, "purged": purged})
+
+	return c.JSON(CachePurgeResult{Purged: purged})
+}
+
+// handleReload godoc
+// @Summary Reload the forecast providers
+// @Description Reads the configuration again and replaces the forecast providers of weather.apis, e.g. to add a
+// @Description provider or rotate an API key, like SIGHUP. The other settings, e.g. the ports and the timeouts of the
+// @Description server, need a restart. The current providers are kept when the configuration is invalid.
+// @Tags Admin
+// @Produce json
+// @Security AdminToken
+// @Success 200 {object} ReloadResult
+// @Failure 401 {object} Problem
+// @Failure 500 {object} Problem
+// @Router /admin/reload [post]
+func (r *adminRoutes) handleReload(c *fiber.Ctx) error {
+	result, err := r.reload()
+	if err != nil {
+		return problem(c, fiber.StatusInternalServerError, ProblemInternal, fmt.Sprintf("cannot reload the configuration: %v", err))
+	}
+
+	return c.JSON(result)
+}
+
+// handleConfig godoc
+// @Summary Get the effective configuration
+// @Description Returns the configuration the instance runs with, the file merged with the environment and the
+// @Description defaults, and the file it was loaded from. The secrets, e.g. the API keys and the admin token, are
+// @Description masked to their last 4 characters.
+// @Tags Admin
+// @Produce plain
+// @Security AdminToken
+// @Success 200 {string} string "YAML configuration"
+// @Failure 401 {object} Problem
+// @Router /admin/config [get]
+func (r *adminRoutes) handleConfig(c *fiber.Ctx) error {
+	dump, err := r.config()
+	if err != nil {
+		return problem(c, fiber.StatusInternalServerError, ProblemInternal, "Failed to serialize the configuration")
+	}
+
+	c.Set(fiber.HeaderContentType, "application/yaml")
+	return c.Send(dump)
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/requestid"
+)
+
+// GetAirQuality godoc
+// @Summary Get air quality forecast
+// @Description Retrieves the daily PM2.5, PM10 and ozone concentrations and US Air Quality Index for a specific location
+// @Tags Air Quality
+// @Accept json
+// @Produce json
+// @Param lat query number false "Lat coordinate (-90 to 90), required without coords" minimum(-90) maximum(90) example(40.7128)
+// @Param lon query number false "Lon coordinate (-180 to 180), required without coords" minimum(-180) maximum(180) example(-74.006)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(40.7128,-74.006)
+// @Param days query integer false "Number of forecast days (1-5, default: 5)" minimum(1) maximum(5) example(3)
+// @Success 200 {object} models.AirQuality "Daily air quality"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 422 {object} Problem "No air quality provider is configured, or the location is outside the service area"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 429 {object} Problem "The air quality provider is rate limited"
+// @Failure 502 {object} Problem "The air quality provider failed"
+// @Failure 504 {object} Problem "Request budget exceeded"
+// @Router /air-quality [get]
+// @Example {curl} Example usage:
+//
+//	curl -X GET "http://localhost:8080/air-quality?lat=40.7128&lon=-74.006&days=3"
+func (r *routes) handleAirQualityCall(c *fiber.Ctx) error {
+	lat, lon, days, err := validateParameters(c, maxAuxiliaryWindow, r.normalizeLongitude)
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(c.UserContext()),
+			"lat":        c.Query("lat"),
+			"lon":        c.Query("lon"),
+			"days":       c.Query("days"),
+		})
+
+		return validationProblem(c, err)
+	}
+
+	if err := r.area.Check(lat, lon); err != nil {
+		return areaProblem(c, err)
+	}
+
+	ctx, cancel := r.requestContext(c, r.service.RequestBudget())
+	defer cancel()
+
+	airQuality, err := r.service.FetchAirQuality(ctx, lat, lon, days)
+	if errors.Is(err, weather.ErrNoAirQualityProvider) {
+		return problem(c, fiber.StatusUnprocessableEntity, ProblemUnprocessable, "Air quality is not available")
+	}
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(ctx),
+			"lat":        lat,
+			"lon":        lon,
+			"days":       days,
+		})
+
+		return fetchProblem(c, err)
+	}
+
+	if airQuality.Error != "" {
+		return upstreamProblem(c, airQuality.ErrorCode, "Air quality provider failed: "+airQuality.Error)
+	}
+
+	return c.JSON(airQuality)
+}
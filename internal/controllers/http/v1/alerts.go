@@ -0,0 +1,86 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/models"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/requestid"
+)
+
+// GetWeatherAlerts godoc
+// @Summary Get active weather alerts
+// @Description Aggregates the active warnings of a location from the providers exposing them, the other providers
+// @Description are skipped. An alert reported by several providers is listed once, the most severe alerts first.
+// @Tags Weather
+// @Accept json
+// @Produce json
+// @Param lat query number false "Lat coordinate (-90 to 90), required without coords" minimum(-90) maximum(90) example(40.7128)
+// @Param lon query number false "Lon coordinate (-180 to 180), required without coords" minimum(-180) maximum(180) example(-74.006)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(40.7128,-74.006)
+// @Success 200 {object} models.AlertReport "Active alerts"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 422 {object} Problem "No configured provider supports weather alerts, or the location is outside the service area"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 429 {object} Problem "All alert providers are rate limited"
+// @Failure 502 {object} Problem "All alert providers failed"
+// @Failure 504 {object} Problem "Request budget exceeded"
+// @Router /weather/alerts [get]
+// @Example {curl} Example usage:
+//
+//	curl -X GET "http://localhost:8080/weather/alerts?lat=40.7128&lon=-74.006"
+func (r *routes) handleAlertsCall(c *fiber.Ctx) error {
+	lat, lon, err := validateLocation(c, r.normalizeLongitude)
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(c.UserContext()),
+			"lat":        c.Query("lat"),
+			"lon":        c.Query("lon"),
+		})
+
+		return validationProblem(c, err)
+	}
+
+	if err := r.area.Check(lat, lon); err != nil {
+		return areaProblem(c, err)
+	}
+
+	ctx, cancel := r.requestContext(c, r.service.RequestBudget())
+	defer cancel()
+
+	report, err := r.service.FetchAlerts(ctx, lat, lon)
+	switch {
+	case errors.Is(err, weather.ErrNoAlertProviders):
+		return problem(c, fiber.StatusUnprocessableEntity, ProblemUnprocessable,
+			"No configured provider supports weather alerts")
+	case errors.Is(err, weather.ErrAlertsFailed):
+		return upstreamProblem(c, failuresErrorCode(report.Failures), "All alert providers failed")
+	case err != nil:
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(ctx),
+			"lat":        lat,
+			"lon":        lon,
+		})
+
+		return fetchProblem(c, err)
+	}
+
+	return c.JSON(report)
+}
+
+// failuresErrorCode returns the error code shared by all the failures, unknown when they differ
+func failuresErrorCode(failures []models.ProviderFailure) string {
+	if len(failures) == 0 {
+		return models.ErrorCodeUnknown
+	}
+
+	for _, f := range failures[1:] {
+		if f.ErrorCode != failures[0].ErrorCode {
+			return models.ErrorCodeUnknown
+		}
+	}
+
+	return failures[0].ErrorCode
+}
@@ -0,0 +1,19 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/pkg/geo"
+)
+
+// WithServiceArea rejects the locations outside the service area before any provider is called
+func WithServiceArea(area geo.Area) RouterOption {
+	return func(r *routes) {
+		r.area = area
+	}
+}
+
+// areaProblem answers a location outside the service area, err describes why
+func areaProblem(c *fiber.Ctx, err error) error {
+	return problem(c, fiber.StatusUnprocessableEntity, ProblemOutsideServiceArea, err.Error())
+}
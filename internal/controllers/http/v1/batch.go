@@ -0,0 +1,180 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/models"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/geo"
+	"weather-api/pkg/requestid"
+	"weather-api/pkg/units"
+)
+
+// BatchRequestItem is a location of a batch request, lat and lon or coords combining them as lat,lon, days
+// defaults to 5
+type BatchRequestItem struct {
+	Lat    *float64 `json:"lat" example:"40.7128"`
+	Lon    *float64 `json:"lon" example:"-74.006"`
+	Coords string   `json:"coords,omitempty" example:"40.7128,-74.006"`
+	Days   int      `json:"days,omitempty" example:"3"`
+}
+
+// BatchResultItem is the result of a batch location, either the forecasts by provider or the error
+// of an invalid location
+type BatchResultItem struct {
+	Lat       *float64                   `json:"lat" example:"40.7128"`
+	Lon       *float64                   `json:"lon" example:"-74.006"`
+	Days      int                        `json:"days" example:"3"`
+	Error     string                     `json:"error,omitempty" example:"latitude must be between -90 and 90, got: 91.000000"`
+	Forecasts map[string]models.Forecast `json:"forecasts,omitempty"`
+}
+
+// GetBatchForecast godoc
+// @Summary Get weather forecasts for several locations
+// @Description Retrieves the forecasts of every location from multiple providers, the results are in the
+// @Description order of the request, invalid locations get an error instead of failing the batch
+// @Tags Weather
+// @Accept json
+// @Produce json
+// @Param locations body []BatchRequestItem true "Locations, at most 50 by default"
+// @Param units query string false "Unit system of the returned values (default: metric)" Enums(metric, imperial)
+// @Success 200 {array} BatchResultItem "Results in request order"
+// @Failure 400 {object} Problem "Bad request - malformed body or too many locations"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 504 {object} Problem "Request budget exceeded"
+// @Router /weather/batch [post]
+// @Example {curl} Example usage:
+//
+//	curl -X POST "http://localhost:8080/weather/batch" -d '[{"lat":40.7128,"lon":-74.006,"days":3},{"lat":52.52,"lon":13.41}]'
+func (r *routes) handleBatchCall(c *fiber.Ctx) error {
+	var items []BatchRequestItem
+	if err := json.Unmarshal(c.Body(), &items); err != nil {
+		return problem(c, fiber.StatusBadRequest, ProblemInvalidParameter,
+			"invalid request body, expected a JSON array of locations")
+	}
+
+	if len(items) == 0 {
+		return problem(c, fiber.StatusBadRequest, ProblemInvalidParameter, "batch must contain at least one location")
+	}
+	if maxItems := r.service.BatchMaxItems(); len(items) > maxItems {
+		return problem(c, fiber.StatusBadRequest, ProblemOutOfRange,
+			fmt.Sprintf("batch must not contain more than %d locations, got: %d", maxItems, len(items)))
+	}
+
+	system, err := units.Parse(c.Query("units"))
+	if err != nil {
+		return validationProblem(c, paramError("units", err))
+	}
+
+	// Invalid locations are answered with their error, the valid ones are fetched
+	results := make([]BatchResultItem, len(items))
+	var locations []weather.Location
+	var indexes []int
+	for i, item := range items {
+		results[i] = BatchResultItem{Lat: item.Lat, Lon: item.Lon, Days: item.Days}
+		if results[i].Days == 0 {
+			results[i].Days = min(defaultForecastWindow, r.service.MaxForecastDays())
+		}
+
+		lat, lon, err := validateBatchItem(item, r.service.MaxForecastDays(), r.normalizeLongitude)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		if item.Coords != "" {
+			results[i].Lat, results[i].Lon = &lat, &lon
+		}
+		if err := r.area.Check(lat, lon); err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		locations = append(locations, weather.Location{Lat: lat, Lon: lon, ForecastWindow: results[i].Days})
+		indexes = append(indexes, i)
+	}
+	if len(locations) == 0 {
+		return c.JSON(results)
+	}
+
+	ctx, cancel := r.requestContext(c, r.service.BatchRequestBudget(len(locations)))
+	defer cancel()
+
+	forecasts, err := r.service.FetchBatchForecasts(ctx, locations)
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(ctx),
+			"locations":  len(locations),
+		})
+
+		return fetchProblem(c, err)
+	}
+
+	for i, byProvider := range forecasts {
+		// Providers always report metric values
+		for name, forecast := range byProvider {
+			forecast.ConvertUnits(system)
+			byProvider[name] = forecast
+		}
+		results[indexes[i]].Forecasts = byProvider
+	}
+
+	return c.JSON(results)
+}
+
+// validateBatchItem applies the checks of the /weather parameters to a batch location and returns its normalized
+// coordinates, see checkLocation for normalizeLon
+func validateBatchItem(item BatchRequestItem, maxDays int, normalizeLon bool) (float64, float64, error) {
+	lat, lon, err := batchItemCoordinates(item)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if normalizeLon {
+		lon = geo.NormalizeLongitude(lon)
+	}
+	if err := validateCoordinates(lat, lon); err != nil {
+		return 0, 0, err
+	}
+
+	if item.Days < 0 || item.Days > maxDays {
+		return 0, 0, fmt.Errorf("days must be between 1 and %d", maxDays)
+	}
+
+	return normalizeCoordinate(lat), normalizeCoordinate(lon), nil
+}
+
+// batchItemCoordinates returns the coordinates of a batch location, from lat and lon or from coords
+func batchItemCoordinates(item BatchRequestItem) (float64, float64, error) {
+	if item.Coords == "" {
+		if item.Lat == nil {
+			return 0, 0, fmt.Errorf("missing required field: lat")
+		}
+		if item.Lon == nil {
+			return 0, 0, fmt.Errorf("missing required field: lon")
+		}
+
+		return *item.Lat, *item.Lon, nil
+	}
+
+	if item.Lat != nil || item.Lon != nil {
+		return 0, 0, errors.New("coords can't be combined with lat and lon")
+	}
+	latStr, lonStr, err := splitCoords(item.Coords)
+	if err != nil {
+		return 0, 0, err
+	}
+	lat, err := parseCoordinate(latStr, "latitude")
+	if err != nil {
+		return 0, 0, err
+	}
+	lon, err := parseCoordinate(lonStr, "longitude")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return lat, lon, nil
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/requestid"
+	"weather-api/pkg/units"
+)
+
+// CompareForecasts godoc
+// @Summary Compare the forecasts of the providers
+// @Description Sets the max and min temperatures of every provider side by side, day by day, with the largest
+// @Description difference between two providers and the pair. The days beyond the configured threshold, 3°C by
+// @Description default, are flagged, the days the providers disagree the most on come first. A day missing from
+// @Description a provider is compared among the others and lists it as missing.
+// @Tags Weather
+// @Accept json
+// @Produce json
+// @Param lat query number false "Lat coordinate (-90 to 90), required without coords" minimum(-90) maximum(90) example(40.7128)
+// @Param lon query number false "Lon coordinate (-180 to 180), required without coords" minimum(-180) maximum(180) example(-74.006)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(40.7128,-74.006)
+// @Param days query integer false "Number of forecast days (1 to the configured maximum, 16 by default, default: 5)" minimum(1) maximum(16) example(3)
+// @Param units query string false "Unit system of the returned values (default: metric)" Enums(metric, imperial)
+// @Success 200 {object} models.ForecastComparison "Comparison of the providers"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 422 {object} Problem "The location is outside the service area"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 502 {object} Problem "All providers failed"
+// @Failure 504 {object} Problem "Request budget exceeded"
+// @Router /weather/compare [get]
+// @Example {curl} Example usage:
+//
+//	curl -X GET "http://localhost:8080/weather/compare?lat=40.7128&lon=-74.006&days=5"
+func (r *routes) handleCompareCall(c *fiber.Ctx) error {
+	lat, lon, forecastWindow, err := validateParameters(c, r.service.MaxForecastDays(), r.normalizeLongitude)
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	if err := r.area.Check(lat, lon); err != nil {
+		return areaProblem(c, err)
+	}
+
+	system, err := units.Parse(c.Query("units"))
+	if err != nil {
+		return validationProblem(c, paramError("units", err))
+	}
+
+	ctx, cancel := r.requestContext(c, r.service.RequestBudget())
+	defer cancel()
+
+	comparison, err := r.service.CompareForecasts(ctx, lat, lon, forecastWindow)
+	if errors.Is(err, weather.ErrNoForecasts) {
+		return problem(c, fiber.StatusBadGateway, ProblemUpstreamFailed, "All weather providers failed")
+	}
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id":     requestid.FromContext(ctx),
+			"lat":            lat,
+			"lon":            lon,
+			"forecastWindow": forecastWindow,
+		})
+
+		return fetchProblem(c, err)
+	}
+
+	comparison.ConvertUnits(system)
+
+	return c.JSON(comparison)
+}
@@ -0,0 +1,232 @@
+package http
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+const (
+	formatJSON = "json"
+	formatCSV  = "csv"
+	formatXML  = "xml"
+	// formatMsgPack is MessagePack, the JSON document in a compact binary encoding
+	formatMsgPack = "msgpack"
+)
+
+// encoder writes a response body in one format
+type encoder interface {
+	contentType() string
+	encode(w io.Writer, body any) error
+}
+
+// encoders are the response formats by name of the format parameter, formatOrder lists them in the
+// order of preference when several are accepted
+var (
+	encoders = map[string]encoder{
+		formatJSON:    jsonEncoder{},
+		formatCSV:     csvEncoder{},
+		formatXML:     xmlEncoder{},
+		formatMsgPack: msgpackEncoder{},
+	}
+	formatOrder = []string{formatJSON, formatCSV, formatXML, formatMsgPack}
+)
+
+// negotiate selects the encoder of the response, the format query parameter takes precedence over the
+// Accept header, JSON is served when neither selects a supported format
+func negotiate(c *fiber.Ctx) (encoder, error) {
+	if format := c.Query("format"); format != "" {
+		enc, ok := encoders[strings.ToLower(format)]
+		if !ok {
+			return nil, paramError("format", fmt.Errorf("unsupported format: %s, expected one of: %s", format, strings.Join(formatOrder, ", ")))
+		}
+
+		return enc, nil
+	}
+
+	offers := make([]string, len(formatOrder))
+	for i, format := range formatOrder {
+		offers[i] = encoders[format].contentType()
+	}
+	accepted := c.Accepts(offers...)
+	for _, format := range formatOrder {
+		if enc := encoders[format]; enc.contentType() == accepted {
+			return enc, nil
+		}
+	}
+
+	return encoders[formatJSON], nil
+}
+
+// respond writes the body with the negotiated encoder
+func respond(c *fiber.Ctx, enc encoder, body any) error {
+	c.Set(fiber.HeaderContentType, enc.contentType())
+
+	return enc.encode(c.Response().BodyWriter(), body)
+}
+
+// respondCacheable writes the body like respond with a strong ETag hashed from the encoded body, so that every
+// format has its own, and lets the clients cache it for maxAge. A request whose If-None-Match lists the ETag is
+// answered 304 without a body.
+func respondCacheable(c *fiber.Ctx, enc encoder, body any, maxAge time.Duration) error {
+	var buf bytes.Buffer
+	if err := enc.encode(&buf, body); err != nil {
+		return err
+	}
+
+	sum := sha256.Sum256(buf.Bytes())
+	etag := `"` + hex.EncodeToString(sum[:16]) + `"`
+	c.Set(fiber.HeaderETag, etag)
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+	// The format may be negotiated from the Accept header
+	c.Vary(fiber.HeaderAccept)
+
+	if etagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		c.Status(fiber.StatusNotModified)
+		return nil
+	}
+
+	c.Set(fiber.HeaderContentType, enc.contentType())
+	return c.Send(buf.Bytes())
+}
+
+// etagMatches reports whether an If-None-Match header lists the ETag, with the weak comparison of RFC 9110
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+type jsonEncoder struct{}
+
+func (jsonEncoder) contentType() string {
+	return fiber.MIMEApplicationJSON
+}
+
+func (jsonEncoder) encode(w io.Writer, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(data)
+	return err
+}
+
+// table is implemented by the bodies that can be written as rows, the first row is the header
+type table interface {
+	rows() [][]string
+}
+
+// csvEncoder writes a table as RFC 4180 CSV
+type csvEncoder struct{}
+
+func (csvEncoder) contentType() string {
+	return "text/csv"
+}
+
+func (csvEncoder) encode(w io.Writer, body any) error {
+	t, ok := body.(table)
+	if !ok {
+		return fmt.Errorf("%T can't be encoded as CSV", body)
+	}
+
+	cw := csv.NewWriter(w)
+	cw.UseCRLF = true
+	if err := cw.WriteAll(t.rows()); err != nil {
+		return fmt.Errorf("write CSV: %w", err)
+	}
+
+	return nil
+}
+
+// xmlBody is implemented by the bodies with an XML document
+type xmlBody interface {
+	xmlDocument() any
+}
+
+// xmlEncoder writes the XML document of a body
+type xmlEncoder struct{}
+
+func (xmlEncoder) contentType() string {
+	return fiber.MIMEApplicationXML
+}
+
+func (xmlEncoder) encode(w io.Writer, body any) error {
+	b, ok := body.(xmlBody)
+	if !ok {
+		return fmt.Errorf("%T can't be encoded as XML", body)
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	return xml.NewEncoder(w).Encode(b.xmlDocument())
+}
+
+// msgpackEncoder writes the JSON document of a body in MessagePack, so that both formats share their schema:
+// the same keys, with the dates and the times as the same strings. The whole numbers are written as integers,
+// the other ones as 64-bit floats. The map keys are sorted, the same body always has the same encoding.
+type msgpackEncoder struct{}
+
+func (msgpackEncoder) contentType() string {
+	return "application/msgpack"
+}
+
+func (msgpackEncoder) encode(w io.Writer, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return fmt.Errorf("decode JSON document: %w", err)
+	}
+
+	enc := msgpack.NewEncoder(w)
+	enc.SetSortMapKeys(true)
+	enc.UseCompactInts(true)
+
+	return enc.Encode(msgpackValue(doc))
+}
+
+// msgpackValue replaces the JSON numbers of a decoded document with integers or floats
+func msgpackValue(v any) any {
+	switch v := v.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case map[string]any:
+		for key, value := range v {
+			v[key] = msgpackValue(value)
+		}
+	case []any:
+		for i, value := range v {
+			v[i] = msgpackValue(value)
+		}
+	}
+
+	return v
+}
@@ -0,0 +1,113 @@
+package http
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/requestid"
+)
+
+// Envelope is the /weather response with envelope=true, the forecasts in provider name order with the
+// metadata of the request
+type Envelope struct {
+	Meta ResponseMeta      `json:"meta"`
+	Data []models.Forecast `json:"data"`
+}
+
+// ResponseMeta describes how an enveloped response was obtained
+type ResponseMeta struct {
+	RequestID  string         `json:"request_id" example:"3f2b8c0e9a4d4f5e"`
+	DurationMS int64          `json:"duration_ms" example:"184"`
+	Providers  ProviderCounts `json:"providers"`
+	// CacheHits is the number of forecasts served from the forecast cache
+	CacheHits int               `json:"cache_hits" example:"1"`
+	Location  *ResponseLocation `json:"location,omitempty"`
+	// Parameters are the parameters of the request once defaulted
+	Parameters EffectiveParameters `json:"parameters"`
+	// Partial is set when the request budget ran out before every provider answered, the response is a 504
+	Partial bool `json:"partial,omitempty" example:"false"`
+}
+
+// ProviderCounts counts the providers of the returned forecasts, with mode=first only the first to succeed
+type ProviderCounts struct {
+	Queried   int `json:"queried" example:"3"`
+	Succeeded int `json:"succeeded" example:"2"`
+	Failed    int `json:"failed" example:"1"`
+}
+
+// EffectiveParameters are the parameters a /weather request was served with, every provider forecast
+// notes a window clamped to its horizon
+type EffectiveParameters struct {
+	Lat       float64  `json:"lat" example:"52.52"`
+	Lon       float64  `json:"lon" example:"13.41"`
+	Days      int      `json:"days,omitempty" example:"5"`
+	Date      string   `json:"date,omitempty" example:"2023-10-07"`
+	Units     string   `json:"units" example:"metric"`
+	Mode      string   `json:"mode" example:"all"`
+	Providers []string `json:"providers" example:"open-meteo,weatherapi"`
+	Fields    []string `json:"fields,omitempty" example:"date,temp_max"`
+}
+
+// envelopeBody is the Envelope written by the JSON encoder, the days only have the requested fields
+type envelopeBody struct {
+	Meta ResponseMeta `json:"meta"`
+	Data []any        `json:"data"`
+}
+
+// validateEnvelope checks that an enveloped response is written in JSON, or its MessagePack encoding, in one piece
+func validateEnvelope(enc encoder, stream bool) error {
+	switch enc.(type) {
+	case jsonEncoder, msgpackEncoder:
+	default:
+		return paramError("envelope", errors.New("envelope applies to the JSON and MessagePack formats only"))
+	}
+	if stream {
+		return paramError("envelope", errors.New("envelope can't be combined with stream"))
+	}
+
+	return nil
+}
+
+// newEnvelope wraps the forecasts with the metadata of the request, params are completed with the fields
+func newEnvelope(c *fiber.Ctx, forecasts map[string]models.Forecast, location *ResponseLocation, params EffectiveParameters, fields fieldSet) envelopeBody {
+	names := make([]string, 0, len(forecasts))
+	for name := range forecasts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for name := range fields {
+		params.Fields = append(params.Fields, name)
+	}
+	sort.Strings(params.Fields)
+
+	body := envelopeBody{
+		Meta: ResponseMeta{
+			RequestID: requestid.FromContext(c.UserContext()),
+			Location:  location,
+			Providers: ProviderCounts{Queried: len(forecasts)},
+			// The request started when fasthttp read it
+			DurationMS: time.Since(c.Context().Time()).Milliseconds(),
+			Parameters: params,
+		},
+		Data: make([]any, 0, len(names)),
+	}
+	for _, name := range names {
+		forecast := forecasts[name]
+		if forecast.Error != "" {
+			body.Meta.Providers.Failed++
+		} else {
+			body.Meta.Providers.Succeeded++
+		}
+		if forecast.Cached {
+			body.Meta.CacheHits++
+		}
+		body.Data = append(body.Data, fields.forecast(forecast))
+	}
+
+	return body
+}
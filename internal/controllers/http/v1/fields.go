@@ -0,0 +1,195 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/models"
+)
+
+// fieldSet is the set of the day fields kept by the fields parameter, by JSON name, nil keeps them all
+type fieldSet map[string]bool
+
+// parseFields parses the comma-separated fields parameter against the JSON fields of the day model, so that
+// every field of the model can be selected
+func parseFields(c *fiber.Ctx, day any) (fieldSet, error) {
+	s := c.Query("fields")
+	if s == "" {
+		return nil, nil
+	}
+
+	allowed := jsonFields(reflect.TypeOf(day))
+	fields := make(fieldSet)
+	for _, name := range strings.Split(s, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		if !slices.Contains(allowed, name) {
+			return nil, paramError("fields", fmt.Errorf("unknown field: %s, expected some of: %s", name, strings.Join(allowed, ", ")))
+		}
+		fields[name] = true
+	}
+	if len(fields) == 0 {
+		return nil, paramError("fields", errors.New("fields must name at least one field"))
+	}
+
+	return fields, nil
+}
+
+// validateFields parses the fields parameter of a response in the format of enc, the XML documents have
+// attributes of their own and can't be projected
+func validateFields(c *fiber.Ctx, enc encoder, day any) (fieldSet, error) {
+	fields, err := parseFields(c, day)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := enc.(xmlEncoder); ok && fields != nil {
+		return nil, paramError("fields", errors.New("fields can't be combined with the XML format"))
+	}
+
+	return fields, nil
+}
+
+// jsonFields lists the JSON names of the fields of a struct type, in declaration order
+func jsonFields(t reflect.Type) []string {
+	var names []string
+	for i := range t.NumField() {
+		if name, _ := jsonName(t.Field(i)); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	return names
+}
+
+// jsonName returns the JSON name of a struct field, empty when it isn't marshaled, and whether a zero value
+// is omitted
+func jsonName(field reflect.StructField) (string, bool) {
+	if !field.IsExported() {
+		return "", false
+	}
+
+	tag := field.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = field.Name
+	}
+
+	omit := slices.ContainsFunc(strings.Split(opts, ","), func(opt string) bool {
+		return opt == "omitempty" || opt == "omitzero"
+	})
+
+	return name, omit
+}
+
+// days projects a slice of day structs down to the fields of the set
+func (f fieldSet) days(data any) []projection {
+	v := reflect.ValueOf(data)
+	days := make([]projection, v.Len())
+	for i := range days {
+		days[i] = projection{value: v.Index(i), fields: f}
+	}
+
+	return days
+}
+
+// forecast returns the forecast with its days projected down to the fields of the set, a nil set returns it
+// unchanged
+func (f fieldSet) forecast(forecast models.Forecast) any {
+	if f == nil {
+		return forecast
+	}
+
+	return projectedForecast{Forecast: forecast, ForecastData: f.days(forecast.ForecastData)}
+}
+
+// columns drops the table columns outside the set, the always columns are kept
+func (f fieldSet) columns(rows [][]string, always ...string) [][]string {
+	if f == nil || len(rows) == 0 {
+		return rows
+	}
+
+	var kept []int
+	for i, name := range rows[0] {
+		if f[name] || slices.Contains(always, name) {
+			kept = append(kept, i)
+		}
+	}
+
+	projected := make([][]string, len(rows))
+	for i, row := range rows {
+		projected[i] = make([]string, len(kept))
+		for j, k := range kept {
+			projected[i][j] = row[k]
+		}
+	}
+
+	return projected
+}
+
+// projectedForecast is a forecast whose days only have the requested fields, its ForecastData hides the
+// one of the embedded forecast
+type projectedForecast struct {
+	models.Forecast
+	ForecastData []projection `json:"forecast_data"`
+}
+
+// projectedAggregate is the aggregated forecast counterpart of projectedForecast
+type projectedAggregate struct {
+	models.AggregatedForecast
+	ForecastData []projection `json:"forecast_data"`
+}
+
+// projection marshals a struct with only the fields of the set, in declaration order. The omitempty and
+// omitzero options omit the zero values, which for the flat day models is what encoding/json does.
+type projection struct {
+	value  reflect.Value
+	fields fieldSet
+}
+
+func (p projection) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	t := p.value.Type()
+	for i := range t.NumField() {
+		name, omit := jsonName(t.Field(i))
+		if name == "" || !p.fields[name] {
+			continue
+		}
+
+		value := p.value.Field(i)
+		if omit && value.IsZero() {
+			continue
+		}
+
+		data, err := json.Marshal(value.Interface())
+		if err != nil {
+			return nil, err
+		}
+		key, _ := json.Marshal(name)
+
+		if buf.Len() > 1 {
+			buf.WriteByte(',')
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(data)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,108 @@
+package http
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/pkg/requestid"
+)
+
+const (
+	defaultGeocodeLimit = 5
+	minGeocodeQuery     = 2
+	maxGeocodeQuery     = 100
+)
+
+// GeocodeResponse lists the places matching a geocoding query, the most populous first
+type GeocodeResponse struct {
+	Query   string         `json:"query" example:"venice"`
+	Results []models.Place `json:"results"`
+}
+
+// GetGeocode godoc
+// @Summary Search places by name
+// @Description Looks up the places matching a name, for autocompletion, the results are cached and shared with the city parameter of /weather
+// @Tags Geocoding
+// @Accept json
+// @Produce json
+// @Param q query string true "Place name, 2 to 100 characters" example(venice)
+// @Param limit query integer false "Number of results (1-10, default: 5)" minimum(1) maximum(10) example(5)
+// @Param country query string false "ISO 3166-1 alpha-2 country code narrowing the search" example(IT)
+// @Success 200 {object} GeocodeResponse "Matching places"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 502 {object} Problem "Geocoding failed"
+// @Failure 504 {object} Problem "Request budget exceeded"
+// @Router /geocode [get]
+// @Example {curl} Example usage:
+//
+//	curl -X GET "http://localhost:8080/geocode?q=venice&limit=5"
+func (r *routes) handleGeocodeCall(c *fiber.Ctx) error {
+	query, limit, country, err := validateGeocodeParameters(c)
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	ctx, cancel := r.requestContext(c, r.service.RequestBudget())
+	defer cancel()
+
+	places, err := r.geocoder.Search(ctx, query, country)
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(ctx),
+			"query":      query,
+		})
+
+		status, problemType, detail := fetchErrorStatus(err)
+		if status == fiber.StatusInternalServerError {
+			status, problemType, detail = fiber.StatusBadGateway, ProblemUpstreamFailed, "Failed to search places"
+		}
+		return problem(c, status, problemType, detail)
+	}
+
+	results := make([]models.Place, 0, limit)
+	results = append(results, places[:min(limit, len(places))]...)
+
+	return c.JSON(GeocodeResponse{
+		Query:   query,
+		Results: results,
+	})
+}
+
+func validateGeocodeParameters(c *fiber.Ctx) (string, int, string, error) {
+	v := &ValidationError{}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		v.missing("q")
+	} else if n := utf8.RuneCountInString(query); n < minGeocodeQuery || n > maxGeocodeQuery {
+		v.outOfRange("q", fmt.Sprintf("q must be between %d and %d characters", minGeocodeQuery, maxGeocodeQuery))
+	}
+
+	limit := defaultGeocodeLimit
+	if limitStr := c.Query("limit"); limitStr != "" {
+		var err error
+		limit, err = strconv.Atoi(limitStr)
+		if err != nil {
+			v.invalid("limit", fmt.Sprintf("invalid limit parameter: %s", limitStr))
+		} else if limit < 1 || limit > repositories.MaxGeocodingResults {
+			v.outOfRange("limit", fmt.Sprintf("limit must be between 1 and %d", repositories.MaxGeocodingResults))
+		}
+	}
+
+	country := c.Query("country")
+	if country != "" && len(country) != 2 {
+		v.invalid("country", fmt.Sprintf("invalid country: %s, expected an ISO 3166-1 alpha-2 code", country))
+	}
+
+	if err := v.err(); err != nil {
+		return "", 0, "", err
+	}
+
+	return query, limit, country, nil
+}
@@ -0,0 +1,482 @@
+package http
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/graph-gophers/graphql-go"
+
+	"weather-api/internal/models"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/geo"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+	"weather-api/pkg/units"
+)
+
+const (
+	// maxGraphQLDepth bounds the nesting of a query, the schema is three levels deep
+	maxGraphQLDepth = 5
+	// maxGraphQLFetches bounds the distinct forecasts, aggregates and provider lists of a query, the fields
+	// asking for the same one share its fetch
+	maxGraphQLFetches = 10
+	// maxGraphQLQueryLength bounds the query text
+	maxGraphQLQueryLength = 8 << 10
+)
+
+// The codes of the extensions of the GraphQL errors
+const (
+	graphqlBadUserInput  = "BAD_USER_INPUT"
+	graphqlTooComplex    = "QUERY_TOO_COMPLEX"
+	graphqlUnavailable   = "UNAVAILABLE"
+	graphqlTimeout       = "TIMEOUT"
+	graphqlInternalError = "INTERNAL_SERVER_ERROR"
+)
+
+//go:embed schema.graphql
+var graphqlSchema string
+
+// GraphQLRequest is the body of a POST /graphql request
+type GraphQLRequest struct {
+	Query         string         `json:"query" example:"{ forecast(lat: 52.52, lon: 13.41, days: 2) { provider days { date tempMax } } }"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// NewGraphQLRouter serves the GraphQL API at /graphql on router. The schema evolves by adding fields, it is
+// not mounted under the version prefix of the REST routes.
+func NewGraphQLRouter(router fiber.Router, weatherService Forecaster, l logger.Logger, opts ...RouterOption) {
+	r := newRoutes(weatherService, nil, l, opts...)
+	schema := graphql.MustParseSchema(graphqlSchema, &graphqlResolver{r: r}, graphql.MaxDepth(maxGraphQLDepth))
+
+	router.Get("/graphql", func(c *fiber.Ctx) error {
+		return r.handleGraphQLQueryCall(c, schema)
+	})
+	router.Post("/graphql", func(c *fiber.Ctx) error {
+		return r.handleGraphQLCall(c, schema)
+	})
+}
+
+// GetGraphQL godoc
+// @Summary Query the GraphQL API with a GET request
+// @Description Runs the GraphQL query of the query parameter, see POST /graphql
+// @Tags GraphQL
+// @Produce json
+// @Param query query string true "GraphQL query" example({ providers { name } })
+// @Param operationName query string false "Operation of the query to run"
+// @Param variables query string false "Variables of the query, as a JSON object"
+// @Success 200 {object} object "GraphQL response, with the errors of the query in errors"
+// @Failure 400 {object} Problem "Bad request - missing or malformed query"
+// @Router /graphql [get]
+func (r *routes) handleGraphQLQueryCall(c *fiber.Ctx, schema *graphql.Schema) error {
+	return r.handleGraphQLCall(c, schema)
+}
+
+// PostGraphQL godoc
+// @Summary Query the GraphQL API
+// @Description Runs a GraphQL query against the schema in internal/controllers/http/v1/schema.graphql: the forecast,
+// @Description aggregate and providers fields, so that the clients fetch the fields they need of several locations
+// @Description in one round trip. The fields asking for the same forecast share one fetch, a query may fetch up to
+// @Description 10 distinct ones and nest 5 levels deep. GET takes the query in the query parameter.
+// @Tags GraphQL
+// @Accept json
+// @Produce json
+// @Param request body GraphQLRequest true "GraphQL query"
+// @Success 200 {object} object "GraphQL response, with the errors of the query in errors"
+// @Failure 400 {object} Problem "Bad request - missing or malformed query"
+// @Router /graphql [post]
+// @Example {curl} Example usage:
+//
+//	curl -X POST "http://localhost:8080/graphql" -H "Content-Type: application/json" \
+//	  -d '{"query": "{ berlin: forecast(lat: 52.52, lon: 13.41) { days { tempMax } } }"}'
+func (r *routes) handleGraphQLCall(c *fiber.Ctx, schema *graphql.Schema) error {
+	var req GraphQLRequest
+	if c.Method() == fiber.MethodPost {
+		if err := json.Unmarshal(c.Body(), &req); err != nil {
+			return problem(c, fiber.StatusBadRequest, ProblemInvalidParameter,
+				fmt.Sprintf("request body must be a GraphQL request: %v", err))
+		}
+	} else {
+		req.Query, req.OperationName = c.Query("query"), c.Query("operationName")
+		if s := c.Query("variables"); s != "" {
+			if err := json.Unmarshal([]byte(s), &req.Variables); err != nil {
+				return validationProblem(c, paramError("variables", fmt.Errorf("variables must be a JSON object: %v", err)))
+			}
+		}
+	}
+
+	if strings.TrimSpace(req.Query) == "" {
+		v := &ValidationError{}
+		v.missing("query")
+		return validationProblem(c, v)
+	}
+	if len(req.Query) > maxGraphQLQueryLength {
+		v := &ValidationError{}
+		v.outOfRange("query", fmt.Sprintf("query must be at most %d bytes, got: %d", maxGraphQLQueryLength, len(req.Query)))
+		return validationProblem(c, v)
+	}
+
+	ctx, cancel := r.requestContext(c, r.service.RequestBudget())
+	defer cancel()
+
+	ctx = context.WithValue(ctx, graphqlFetchesKey{}, &graphqlFetches{calls: make(map[string]*graphqlFetch)})
+
+	return c.JSON(schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+}
+
+// graphqlError is a resolver error, its code is reported in the extensions of the GraphQL error
+type graphqlError struct {
+	message string
+	code    string
+}
+
+func (e *graphqlError) Error() string {
+	return e.message
+}
+
+func (e *graphqlError) Extensions() map[string]any {
+	return map[string]any{"code": e.code}
+}
+
+func badUserInput(err error) error {
+	return &graphqlError{message: err.Error(), code: graphqlBadUserInput}
+}
+
+// graphqlFetchError maps an error of the service to the error of the field, the errors of fetch are kept
+func (r *routes) graphqlFetchError(ctx context.Context, err error) error {
+	var gqlErr *graphqlError
+	var quorumErr *weather.QuorumError
+	switch {
+	case errors.As(err, &gqlErr):
+		return err
+	case errors.Is(err, weather.ErrNoForecasts), errors.As(err, &quorumErr):
+		return &graphqlError{message: err.Error(), code: graphqlUnavailable}
+	case errors.Is(err, context.DeadlineExceeded):
+		return &graphqlError{message: "weather providers did not answer in time", code: graphqlTimeout}
+	case errors.Is(err, context.Canceled):
+		return &graphqlError{message: "request canceled", code: graphqlTimeout}
+	}
+
+	r.l.Error(err, map[string]any{"request_id": requestid.FromContext(ctx)})
+
+	return &graphqlError{message: "failed to fetch weather data", code: graphqlInternalError}
+}
+
+type graphqlFetchesKey struct{}
+
+// graphqlFetches shares the fetches of a query between its fields, the fields are resolved concurrently
+type graphqlFetches struct {
+	mu    sync.Mutex
+	calls map[string]*graphqlFetch
+}
+
+type graphqlFetch struct {
+	once  sync.Once
+	value any
+	err   error
+}
+
+// fetch returns the result of the fetch identified by key, fetching it on the first call, and fails once the
+// query asks for more than maxGraphQLFetches distinct fetches
+func fetch[T any](ctx context.Context, key string, f func() (T, error)) (T, error) {
+	var zero T
+	fetches, ok := ctx.Value(graphqlFetchesKey{}).(*graphqlFetches)
+	if !ok {
+		return f()
+	}
+
+	fetches.mu.Lock()
+	call, ok := fetches.calls[key]
+	if !ok {
+		if len(fetches.calls) >= maxGraphQLFetches {
+			fetches.mu.Unlock()
+			return zero, &graphqlError{
+				message: fmt.Sprintf("the query asks for more than %d distinct forecasts", maxGraphQLFetches),
+				code:    graphqlTooComplex,
+			}
+		}
+		call = &graphqlFetch{}
+		fetches.calls[key] = call
+	}
+	fetches.mu.Unlock()
+
+	call.once.Do(func() {
+		call.value, call.err = f()
+	})
+	if call.err != nil {
+		return zero, call.err
+	}
+
+	return call.value.(T), nil
+}
+
+// graphqlResolver resolves the Query type
+type graphqlResolver struct {
+	r *routes
+}
+
+type forecastArgs struct {
+	Lat       float64
+	Lon       float64
+	Days      *int32
+	Providers *[]string
+	Units     *string
+}
+
+func (q *graphqlResolver) Forecast(ctx context.Context, args forecastArgs) ([]*forecastResolver, error) {
+	lat, lon, err := graphqlCoordinates(args.Lat, args.Lon, q.r.area, q.r.normalizeLongitude)
+	if err != nil {
+		return nil, err
+	}
+	days, err := graphqlDays(args.Days, q.r.service.MaxForecastDays())
+	if err != nil {
+		return nil, err
+	}
+	system, err := units.Parse(deref(args.Units))
+	if err != nil {
+		return nil, badUserInput(err)
+	}
+	var providers []string
+	if args.Providers != nil {
+		providers, err = parseProviders(strings.Join(*args.Providers, ","), q.r.service.Providers())
+		if err != nil {
+			return nil, badUserInput(err)
+		}
+	}
+
+	// The providers are fetched together whatever their order
+	sorted := slices.Sorted(slices.Values(providers))
+	key := fmt.Sprintf("forecast:%g,%g,%d,%s", lat, lon, days, strings.Join(sorted, ","))
+	forecasts, err := fetch(ctx, key, func() (map[string]models.Forecast, error) {
+		return q.r.service.FetchProviderForecasts(ctx, lat, lon, days, providers)
+	})
+	if err != nil {
+		return nil, q.r.graphqlFetchError(ctx, err)
+	}
+
+	names := make([]string, 0, len(forecasts))
+	for name := range forecasts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	resolvers := make([]*forecastResolver, 0, len(names))
+	for _, name := range names {
+		// The forecasts are shared with the other fields, the conversion copies the days
+		forecast := forecasts[name]
+		forecast.ConvertUnits(system)
+		resolvers = append(resolvers, &forecastResolver{forecast})
+	}
+
+	return resolvers, nil
+}
+
+type aggregateArgs struct {
+	Lat          float64
+	Lon          float64
+	Days         *int32
+	Strategy     *string
+	MinProviders *int32
+	Units        *string
+}
+
+func (q *graphqlResolver) Aggregate(ctx context.Context, args aggregateArgs) (*aggregateResolver, error) {
+	lat, lon, err := graphqlCoordinates(args.Lat, args.Lon, q.r.area, q.r.normalizeLongitude)
+	if err != nil {
+		return nil, err
+	}
+	days, err := graphqlDays(args.Days, q.r.service.MaxForecastDays())
+	if err != nil {
+		return nil, err
+	}
+	system, err := units.Parse(deref(args.Units))
+	if err != nil {
+		return nil, badUserInput(err)
+	}
+	strategy, err := weather.ParseStrategy(deref(args.Strategy))
+	if err != nil {
+		return nil, badUserInput(err)
+	}
+	var minProviders int
+	if args.MinProviders != nil {
+		minProviders = int(*args.MinProviders)
+	}
+	if minProviders < 0 {
+		return nil, badUserInput(fmt.Errorf("minProviders must not be negative, got: %d", minProviders))
+	}
+
+	key := fmt.Sprintf("aggregate:%g,%g,%d,%s,%d", lat, lon, days, strategy, minProviders)
+	aggregated, err := fetch(ctx, key, func() (models.AggregatedForecast, error) {
+		return q.r.service.AggregateForecasts(ctx, lat, lon, days, strategy, minProviders)
+	})
+	if err != nil {
+		return nil, q.r.graphqlFetchError(ctx, err)
+	}
+
+	aggregated.ConvertUnits(system)
+
+	return &aggregateResolver{aggregated}, nil
+}
+
+func (q *graphqlResolver) Providers(ctx context.Context) ([]*providerStatusResolver, error) {
+	statuses, err := fetch(ctx, "providers", func() ([]models.ProviderStatus, error) {
+		return q.r.service.ProviderStatus(ctx), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	resolvers := make([]*providerStatusResolver, 0, len(statuses))
+	for _, status := range statuses {
+		resolvers = append(resolvers, &providerStatusResolver{status})
+	}
+
+	return resolvers, nil
+}
+
+// graphqlCoordinates checks and normalizes the coordinates of a field, as the lat and lon parameters, they must
+// be in the service area
+func graphqlCoordinates(lat, lon float64, area geo.Area, normalizeLon bool) (float64, float64, error) {
+	if normalizeLon {
+		lon = geo.NormalizeLongitude(lon)
+	}
+	if err := validateCoordinates(lat, lon); err != nil {
+		return 0, 0, badUserInput(err)
+	}
+
+	lat, lon = normalizeCoordinate(lat), normalizeCoordinate(lon)
+	if err := area.Check(lat, lon); err != nil {
+		return 0, 0, badUserInput(err)
+	}
+
+	return lat, lon, nil
+}
+
+// graphqlDays checks the forecast window of a field, null selects the default window
+func graphqlDays(days *int32, maxDays int) (int, error) {
+	if days == nil {
+		return min(defaultForecastWindow, maxDays), nil
+	}
+	if *days < 1 || int(*days) > maxDays {
+		return 0, badUserInput(fmt.Errorf("days must be between 1 and %d, got: %d", maxDays, *days))
+	}
+
+	return int(*days), nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+// optional returns nil for an empty string, the null of the optional GraphQL strings
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+
+	return &s
+}
+
+type forecastResolver struct {
+	f models.Forecast
+}
+
+func (r *forecastResolver) Provider() string      { return r.f.RepositoryName }
+func (r *forecastResolver) Lat() float64          { return r.f.Lat }
+func (r *forecastResolver) Lon() float64          { return r.f.Lon }
+func (r *forecastResolver) ForecastWindow() int32 { return int32(r.f.ForecastWindow) }
+func (r *forecastResolver) Units() string         { return r.f.Units }
+func (r *forecastResolver) Error() *string        { return optional(r.f.Error) }
+func (r *forecastResolver) ErrorCode() *string    { return optional(r.f.ErrorCode) }
+func (r *forecastResolver) Note() *string         { return optional(r.f.Note) }
+func (r *forecastResolver) Cached() bool          { return r.f.Cached }
+func (r *forecastResolver) Stale() bool           { return r.f.Stale }
+
+func (r *forecastResolver) Days() []*dayResolver {
+	days := make([]*dayResolver, len(r.f.ForecastData))
+	for i, day := range r.f.ForecastData {
+		days[i] = &dayResolver{day}
+	}
+
+	return days
+}
+
+type dayResolver struct {
+	d models.WeatherData
+}
+
+func (r *dayResolver) Date() string                       { return r.d.Date.String() }
+func (r *dayResolver) TempMax() float64                   { return r.d.TempMax }
+func (r *dayResolver) TempMin() float64                   { return r.d.TempMin }
+func (r *dayResolver) PrecipitationSum() *float64         { return r.d.PrecipitationSum }
+func (r *dayResolver) PrecipitationProbability() *float64 { return r.d.PrecipitationProbability }
+func (r *dayResolver) WindSpeedMax() *float64             { return r.d.WindSpeedMax }
+func (r *dayResolver) WindGustsMax() *float64             { return r.d.WindGustsMax }
+func (r *dayResolver) HumidityMean() *float64             { return r.d.HumidityMean }
+func (r *dayResolver) UVIndexMax() *float64               { return r.d.UVIndexMax }
+func (r *dayResolver) Condition() *string                 { return optional(string(r.d.Condition)) }
+
+type aggregateResolver struct {
+	a models.AggregatedForecast
+}
+
+func (r *aggregateResolver) Lat() float64          { return r.a.Lat }
+func (r *aggregateResolver) Lon() float64          { return r.a.Lon }
+func (r *aggregateResolver) ForecastWindow() int32 { return int32(r.a.ForecastWindow) }
+func (r *aggregateResolver) Strategy() string      { return r.a.Strategy }
+func (r *aggregateResolver) MinProviders() int32   { return int32(r.a.MinProviders) }
+func (r *aggregateResolver) Units() string         { return r.a.Units }
+func (r *aggregateResolver) Providers() []string   { return r.a.Providers }
+
+func (r *aggregateResolver) Days() []*aggregatedDayResolver {
+	days := make([]*aggregatedDayResolver, len(r.a.ForecastData))
+	for i, day := range r.a.ForecastData {
+		days[i] = &aggregatedDayResolver{day}
+	}
+
+	return days
+}
+
+type aggregatedDayResolver struct {
+	d models.AggregatedWeatherData
+}
+
+func (r *aggregatedDayResolver) Date() string         { return r.d.Date.String() }
+func (r *aggregatedDayResolver) TempMax() float64     { return r.d.TempMax }
+func (r *aggregatedDayResolver) TempMin() float64     { return r.d.TempMin }
+func (r *aggregatedDayResolver) Spread() float64      { return r.d.Spread }
+func (r *aggregatedDayResolver) ProviderCount() int32 { return int32(r.d.ProviderCount) }
+
+type providerStatusResolver struct {
+	s models.ProviderStatus
+}
+
+func (r *providerStatusResolver) Name() string           { return r.s.Name }
+func (r *providerStatusResolver) RequiresKey() bool      { return r.s.RequiresKey }
+func (r *providerStatusResolver) Disabled() bool         { return r.s.Disabled }
+func (r *providerStatusResolver) Circuit() string        { return r.s.Circuit }
+func (r *providerStatusResolver) Calls() int32           { return int32(r.s.Calls) }
+func (r *providerStatusResolver) ErrorRate() float64     { return r.s.ErrorRate }
+func (r *providerStatusResolver) AvgLatencyMS() float64  { return r.s.AvgLatencyMS }
+func (r *providerStatusResolver) LastErrorCode() *string { return optional(r.s.LastErrorCode) }
+func (r *providerStatusResolver) RateLimited() bool      { return r.s.RateLimited }
+
+func (r *providerStatusResolver) Healthy() *bool {
+	if r.s.Health == nil {
+		return nil
+	}
+
+	return &r.s.Health.Healthy
+}
@@ -1,24 +1,55 @@
 package http
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"math"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel/trace"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/geo"
+	"weather-api/pkg/httpserver"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+	"weather-api/pkg/units"
 )
 
 const (
 	defaultForecastWindow = 5
-	maxForecastWindow     = 5
-	maxLatitude           = 90
-	maxLongitude          = 180
-	minLatitude           = -90
-	minLongitude          = -180
+	// maxAuxiliaryWindow bounds the air quality and marine forecasts, the weather forecasts are bounded
+	// by the configured maximum
+	maxAuxiliaryWindow = 5
+	maxLatitude        = 90
+	maxLongitude       = 180
+	minLatitude        = -90
+	minLongitude       = -180
+	// coordinateScale rounds the coordinates to 6 decimals
+	coordinateScale = 1e6
+
+	// modeAll queries every provider, modeFirst returns the first successful one
+	modeAll   = "all"
+	modeFirst = "first"
+
+	statusClientClosedRequest = 499
+
+	// reverseGeocodeTimeout bounds the place name lookup of resolve_name, it runs alongside the forecasts
+	reverseGeocodeTimeout = time.Second
 )
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error string `json:"error" example:"Missing required parameter: lat"`
+// ResponseLocation names the requested coordinates, it is added to the /weather response with resolve_name=true
+type ResponseLocation struct {
+	Name    string `json:"name" example:"Berlin"`
+	Country string `json:"country,omitempty" example:"Germany"`
+	Admin1  string `json:"admin1,omitempty" example:"Berlin"`
 }
 
 // GetWeatherForecast godoc
@@ -26,89 +57,882 @@ type ErrorResponse struct {
 // @Description Retrieves weather forecast data for a specific location from multiple providers
 // @Tags Weather
 // @Accept json
-// @Produce json
-// @Param lat query number true "Lat coordinate (-90 to 90)" minimum(-90) maximum(90) example(40.7128)
-// @Param lon query number true "Lon coordinate (-180 to 180)" minimum(-180) maximum(180) example(-74.006)
-// @Param days query integer false "Number of forecast days (1-14, default: 5)" minimum(1) maximum(14) example(3)
-// @Success 200 {object} WeatherResponse "Successful response"
-// @Failure 400 {object} ErrorResponse "Bad request - invalid parameters"
-// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Produce json,text/csv,xml,application/msgpack,application/x-ndjson
+// @Description Without lat, lon and city the caller is located from its IP address, when enabled in the configuration
+// @Param lat query number false "Lat coordinate (-90 to 90), required without city or coords" minimum(-90) maximum(90) example(40.7128)
+// @Param lon query number false "Lon coordinate (-180 to 180), required without city or coords" minimum(-180) maximum(180) example(-74.006)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(40.7128,-74.006)
+// @Param city query string false "City name resolved by geocoding, instead of lat and lon" example(Berlin)
+// @Param country query string false "ISO 3166-1 alpha-2 country code narrowing the city" example(DE)
+// @Param days query integer false "Number of forecast days (1 to the configured maximum, 16 by default, default: 5)" minimum(1) maximum(16) example(3)
+// @Param date query string false "Target day, YYYY-MM-DD, instead of days: only that day is returned by every provider" example(2023-10-07)
+// @Param units query string false "Unit system of the returned values (default: metric)" Enums(metric, imperial)
+// @Param providers query string false "Comma-separated provider names to query (default: all)" example(open-meteo)
+// @Param mode query string false "all providers, or only the first successful one (default: all)" Enums(all, first)
+// @Param resolve_name query boolean false "Add the place name of the coordinates in a top-level location field, omitted when the lookup fails"
+// @Param format query string false "Response format, takes precedence over the Accept header (default: json)" Enums(json, csv, xml, msgpack)
+// @Param fields query string false "Comma-separated day fields to keep, e.g. date,temp_min,temp_max (default: all), not with the XML format" example(date,temp_max)
+// @Param envelope query boolean false "Answer an Envelope: the forecasts in a data array next to the request metadata in meta, JSON and MessagePack only"
+// @Param stream query boolean false "Stream each provider forecast as an NDJSON line as soon as it arrives, with mode=all and the JSON format only"
+// @Param If-None-Match header string false "ETag of a previous response, answered 304 when it is unchanged"
+// @Success 200 {object} map[string]models.Forecast "Forecasts by provider"
+// @Success 304 "The response is unchanged"
+// @Failure 300 {object} AmbiguousCityProblem "Several places match the city"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 404 {object} Problem "Unknown city"
+// @Failure 422 {object} Problem "The caller IP address can't be located, the location is outside the service area, or the date is in the past or beyond the providers horizon"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 502 {object} Problem "All providers failed (mode=first) or geocoding failed"
+// @Failure 504 {object} Problem "Request budget exceeded, or the forecasts of the providers that answered in time"
 // @Router /weather [get]
 // @Example {curl} Example usage:
 //
 //	curl -X GET "http://localhost:8080/weather?lat=40.7128&lon=-74.006&days=3"
 func (r *routes) handleWeatherCall(c *fiber.Ctx) error {
-	lat, lon, forecastWindow, err := validateParameters(c)
+	var lat, lon float64
+	var forecastWindow int
+	var err error
+
+	city := strings.TrimSpace(c.Query("city"))
+	// Without any location, the caller is located from its IP address when enabled
+	locateCaller := city == "" && c.Query("lat") == "" && c.Query("lon") == "" && c.Query("coords") == "" && r.locator != nil
+	switch {
+	case city != "":
+		forecastWindow, err = validateCityParameters(c, r.service.MaxForecastDays())
+	case locateCaller:
+		forecastWindow, err = validateDays(c, r.service.MaxForecastDays())
+	default:
+		lat, lon, forecastWindow, err = validateParameters(c, r.service.MaxForecastDays(), r.normalizeLongitude)
+	}
 	if err != nil {
 		r.l.Error(err, map[string]any{
+			"request_id":     requestid.FromContext(c.UserContext()),
 			"lat":            c.Query("lat"),
 			"lon":            c.Query("lon"),
+			"city":           c.Query("city"),
 			"forecastWindow": c.Query("days"),
 		})
 
-		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
-			Error: err.Error(),
+		return validationProblem(c, err)
+	}
+
+	date, err := validateTargetDate(c)
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	system, err := units.Parse(c.Query("units"))
+	if err != nil {
+		return validationProblem(c, paramError("units", err))
+	}
+
+	providers, err := parseProviders(c.Query("providers"), r.service.Providers())
+	if err != nil {
+		return validationProblem(c, paramError("providers", err))
+	}
+
+	mode := c.Query("mode", modeAll)
+	if mode != modeAll && mode != modeFirst {
+		return validationProblem(c, paramError("mode",
+			fmt.Errorf("unsupported mode: %s, expected %s or %s", mode, modeAll, modeFirst)))
+	}
+
+	enc, err := negotiate(c)
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	fields, err := validateFields(c, enc, models.WeatherData{})
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	stream := c.QueryBool("stream")
+	if stream {
+		if err := validateStreamParameters(c, mode); err != nil {
+			return validationProblem(c, err)
+		}
+	}
+	if !date.IsZero() && (stream || mode == modeFirst) {
+		return validationProblem(c, paramError("date", errors.New("date can't be combined with stream or mode=first")))
+	}
+	envelope := c.QueryBool("envelope")
+	if envelope {
+		if err := validateEnvelope(enc, stream); err != nil {
+			return validationProblem(c, err)
+		}
+	}
+
+	ctx, cancel := r.requestContext(c, r.service.RequestBudget())
+	defer cancel()
+
+	// location names the coordinates with resolve_name, a city is already named
+	var location func() *ResponseLocation
+	resolveName := c.QueryBool("resolve_name")
+	if city != "" {
+		place, err := r.geocoder.Resolve(ctx, city, c.Query("country"))
+		if err != nil {
+			return r.geocodingError(c, city, err)
+		}
+		lat, lon = place.Lat, place.Lon
+		if resolveName {
+			location = func() *ResponseLocation { return responseLocation(place) }
+		}
+	} else if locateCaller {
+		ip := r.clientIP(c)
+		place, err := r.locator.Locate(ctx, parseIP(ip))
+		if err != nil {
+			return r.locatingError(c, ip, err)
+		}
+		lat, lon = place.Lat, place.Lon
+		if resolveName && place.Name != "" {
+			location = func() *ResponseLocation { return responseLocation(place) }
+		}
+	}
+	if err := r.area.Check(lat, lon); err != nil {
+		return areaProblem(c, err)
+	}
+	if city == "" && !locateCaller && resolveName {
+		location = r.reverseGeocode(ctx, lat, lon)
+	}
+
+	// params describe the request in the envelope, nil without one
+	var params *EffectiveParameters
+	if envelope {
+		params = &EffectiveParameters{Lat: lat, Lon: lon, Days: forecastWindow, Units: system, Mode: mode, Providers: providers}
+		if len(providers) == 0 {
+			params.Providers = r.service.Providers()
+		}
+		if !date.IsZero() {
+			params.Days, params.Date = 0, date.String()
+		}
+	}
+
+	if stream {
+		return r.streamForecasts(ctx, c, lat, lon, forecastWindow, providers, system, fields)
+	}
+	if mode == modeFirst {
+		return r.handleFirstForecast(ctx, c, enc, lat, lon, forecastWindow, providers, system, location, fields, params)
+	}
+
+	var forecasts map[string]models.Forecast
+	if date.IsZero() {
+		forecasts, err = r.service.FetchProviderForecasts(ctx, lat, lon, forecastWindow, providers)
+	} else {
+		forecasts, err = r.service.FetchDateForecasts(ctx, lat, lon, date, providers)
+	}
+	// The forecasts of the providers that answered before the budget ran out are returned with a 504
+	partial := errors.Is(err, weather.ErrBudgetExceeded) && forecasts != nil
+	if err != nil && !partial {
+		r.l.Error(err, map[string]any{
+			"request_id":     requestid.FromContext(ctx),
+			"lat":            lat,
+			"lon":            lon,
+			"forecastWindow": forecastWindow,
 		})
+
+		return fetchProblem(c, err)
 	}
 
-	forecasts, err := r.service.FetchForecasts(c.Context(), lat, lon, forecastWindow)
+	// Providers always report metric values
+	for name, forecast := range forecasts {
+		forecast.ConvertUnits(system)
+		forecasts[name] = forecast
+	}
+
+	return r.weatherResponse(c, enc, forecasts, location, fields, params, partial)
+}
+
+// handleFirstForecast responds with the first successful provider, in the same shape as the full response
+func (r *routes) handleFirstForecast(ctx context.Context, c *fiber.Ctx, enc encoder, lat, lon float64, forecastWindow int, providers []string, system string, location func() *ResponseLocation, fields fieldSet, params *EffectiveParameters) error {
+	forecast, err := r.service.FetchFirstForecast(ctx, lat, lon, forecastWindow, providers)
+	if errors.Is(err, weather.ErrNoForecasts) {
+		return problem(c, fiber.StatusBadGateway, ProblemUpstreamFailed, "All weather providers failed")
+	}
 	if err != nil {
 		r.l.Error(err, map[string]any{
+			"request_id":     requestid.FromContext(ctx),
 			"lat":            lat,
 			"lon":            lon,
 			"forecastWindow": forecastWindow,
 		})
 
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
-			Error: "Failed to fetch weather data",
+		return fetchProblem(c, err)
+	}
+
+	forecast.ConvertUnits(system)
+
+	return r.weatherResponse(c, enc, map[string]models.Forecast{forecast.RepositoryName: forecast}, location, fields, params, false)
+}
+
+// weatherResponse writes the forecasts keyed by provider, with the place name in a location key when
+// it was requested and found, location is nil when it wasn't requested. The days only have the requested
+// fields. The response can be cached by the clients for the cache TTL of the service, unless it is wrapped
+// in an envelope described by params, whose metadata differs with every request. A partial response, missing
+// the providers that didn't answer within the request budget, is a 504 that can't be cached.
+func (r *routes) weatherResponse(c *fiber.Ctx, enc encoder, forecasts map[string]models.Forecast, location func() *ResponseLocation, fields fieldSet, params *EffectiveParameters, partial bool) error {
+	body := forecastsBody{forecasts: forecasts, fields: fields}
+	if location != nil {
+		body.location = location()
+	}
+	if partial {
+		c.Status(fiber.StatusGatewayTimeout)
+	}
+	if params != nil {
+		envelope := newEnvelope(c, forecasts, body.location, *params, fields)
+		envelope.Meta.Partial = partial
+		return respond(c, enc, envelope)
+	}
+	if partial {
+		return respond(c, enc, body)
+	}
+
+	return respondCacheable(c, enc, body, r.service.CacheTTL())
+}
+
+// reverseGeocode starts looking up the place name of the coordinates, so that it runs alongside the forecasts,
+// the returned function waits for the lookup and returns nil when it failed, it never fails the request
+func (r *routes) reverseGeocode(ctx context.Context, lat, lon float64) func() *ResponseLocation {
+	ctx, cancel := context.WithTimeout(ctx, reverseGeocodeTimeout)
+
+	done := make(chan *ResponseLocation, 1)
+	go func() {
+		defer cancel()
+
+		place, err := r.geocoder.Reverse(ctx, lat, lon)
+		if err != nil {
+			r.l.Warning("failed to resolve the place name", map[string]any{
+				"request_id": requestid.FromContext(ctx),
+				"lat":        lat,
+				"lon":        lon,
+				"err":        err.Error(),
+			})
+			done <- nil
+			return
+		}
+
+		done <- responseLocation(place)
+	}()
+
+	return func() *ResponseLocation {
+		return <-done
+	}
+}
+
+func responseLocation(place models.Place) *ResponseLocation {
+	return &ResponseLocation{Name: place.Name, Country: place.Country, Admin1: place.Admin1}
+}
+
+// GetAggregatedForecast godoc
+// @Summary Get aggregated weather forecast
+// @Description Merges the forecasts of all providers into a single series, providers that failed are skipped
+// @Tags Weather
+// @Accept json
+// @Produce json,text/csv,xml,application/msgpack
+// @Param lat query number false "Lat coordinate (-90 to 90), required without coords" minimum(-90) maximum(90) example(40.7128)
+// @Param lon query number false "Lon coordinate (-180 to 180), required without coords" minimum(-180) maximum(180) example(-74.006)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(40.7128,-74.006)
+// @Param days query integer false "Number of forecast days (1 to the configured maximum, 16 by default, default: 5)" minimum(1) maximum(16) example(3)
+// @Param date query string false "Target day, YYYY-MM-DD, instead of days: only that day is aggregated" example(2023-10-07)
+// @Param units query string false "Unit system of the returned values (default: metric)" Enums(metric, imperial)
+// @Param strategy query string false "Aggregation strategy (default: mean)" Enums(mean, median, weighted_mean, extremes)
+// @Param min_providers query integer false "Providers required for the aggregate and for every day (default: configured)" minimum(1) example(2)
+// @Param format query string false "Response format, takes precedence over the Accept header (default: json)" Enums(json, csv, xml, msgpack)
+// @Param fields query string false "Comma-separated day fields to keep, e.g. date,temp_min,temp_max (default: all), not with the XML format" example(date,temp_max)
+// @Success 200 {object} models.AggregatedForecast "Successful response"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 422 {object} Problem "The location is outside the service area, or the date is in the past or beyond the providers horizon"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 502 {object} Problem "All providers failed"
+// @Failure 503 {object} QuorumProblem "Fewer providers than required returned data"
+// @Failure 504 {object} Problem "Request budget exceeded"
+// @Router /weather/aggregate [get]
+func (r *routes) handleAggregateCall(c *fiber.Ctx) error {
+	lat, lon, forecastWindow, err := validateParameters(c, r.service.MaxForecastDays(), r.normalizeLongitude)
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	if err := r.area.Check(lat, lon); err != nil {
+		return areaProblem(c, err)
+	}
+
+	date, err := validateTargetDate(c)
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	system, err := units.Parse(c.Query("units"))
+	if err != nil {
+		return validationProblem(c, paramError("units", err))
+	}
+
+	strategy, err := weather.ParseStrategy(c.Query("strategy"))
+	if err != nil {
+		return validationProblem(c, paramError("strategy", err))
+	}
+
+	enc, err := negotiate(c)
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	fields, err := validateFields(c, enc, models.AggregatedWeatherData{})
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	var minProviders int
+	if s := c.Query("min_providers"); s != "" {
+		minProviders, err = strconv.Atoi(s)
+		if err != nil || minProviders < 1 {
+			v := &ValidationError{}
+			v.outOfRange("min_providers", fmt.Sprintf("min_providers must be a positive integer, got: %s", s))
+			return validationProblem(c, v)
+		}
+	}
+
+	ctx, cancel := r.requestContext(c, r.service.RequestBudget())
+	defer cancel()
+
+	var aggregated models.AggregatedForecast
+	if date.IsZero() {
+		aggregated, err = r.service.AggregateForecasts(ctx, lat, lon, forecastWindow, strategy, minProviders)
+	} else {
+		aggregated, err = r.service.AggregateDateForecasts(ctx, lat, lon, date, strategy, minProviders)
+	}
+	if errors.Is(err, weather.ErrNoForecasts) {
+		return problem(c, fiber.StatusBadGateway, ProblemUpstreamFailed, "All weather providers failed")
+	}
+	var quorumErr *weather.QuorumError
+	if errors.As(err, &quorumErr) {
+		return sendProblem(c, fiber.StatusServiceUnavailable, QuorumProblem{
+			Problem: newProblem(c, fiber.StatusServiceUnavailable, ProblemQuorumNotMet,
+				"Not enough weather providers returned data"),
+			Required:  quorumErr.Required,
+			Available: quorumErr.Available,
+			Failures:  quorumErr.Failures,
+		})
+	}
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id":     requestid.FromContext(ctx),
+			"lat":            lat,
+			"lon":            lon,
+			"forecastWindow": forecastWindow,
+			"strategy":       strategy,
 		})
+
+		return fetchProblem(c, err)
 	}
 
-	return c.JSON(forecasts)
+	aggregated.ConvertUnits(system)
+
+	return respond(c, enc, aggregateBody{AggregatedForecast: aggregated, fields: fields})
 }
 
-func validateParameters(c *fiber.Ctx) (float64, float64, int, error) {
-	latStr := c.Query("lat")
-	lonStr := c.Query("lon")
+// GetCurrentWeather godoc
+// @Summary Get current weather
+// @Description Retrieves the current conditions for a specific location from every provider supporting them,
+// @Description the other providers are reported with the unsupported error code
+// @Tags Weather
+// @Accept json
+// @Produce json
+// @Param lat query number false "Lat coordinate (-90 to 90), required without coords" minimum(-90) maximum(90) example(40.7128)
+// @Param lon query number false "Lon coordinate (-180 to 180), required without coords" minimum(-180) maximum(180) example(-74.006)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(40.7128,-74.006)
+// @Param units query string false "Unit system of the returned values (default: metric)" Enums(metric, imperial)
+// @Success 200 {object} map[string]models.CurrentWeather "Current conditions by provider"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 422 {object} Problem "The location is outside the service area"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 504 {object} Problem "Request budget exceeded"
+// @Router /weather/current [get]
+// @Example {curl} Example usage:
+//
+//	curl -X GET "http://localhost:8080/weather/current?lat=40.7128&lon=-74.006"
+func (r *routes) handleCurrentCall(c *fiber.Ctx) error {
+	lat, lon, err := validateLocation(c, r.normalizeLongitude)
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(c.UserContext()),
+			"lat":        c.Query("lat"),
+			"lon":        c.Query("lon"),
+		})
 
-	if latStr == "" {
-		return 0, 0, 0, fmt.Errorf("missing required parameter: lat")
+		return validationProblem(c, err)
 	}
 
-	if lonStr == "" {
-		return 0, 0, 0, fmt.Errorf("missing required parameter: lon")
+	if err := r.area.Check(lat, lon); err != nil {
+		return areaProblem(c, err)
 	}
 
-	lat, err := strconv.ParseFloat(latStr, 64)
+	system, err := units.Parse(c.Query("units"))
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("invalid latitude format: %s", latStr)
+		return validationProblem(c, paramError("units", err))
 	}
 
-	lon, err := strconv.ParseFloat(lonStr, 64)
+	ctx, cancel := r.requestContext(c, r.service.RequestBudget())
+	defer cancel()
+
+	current, err := r.service.FetchCurrentWeather(ctx, lat, lon)
 	if err != nil {
-		return 0, 0, 0, fmt.Errorf("invalid longitude format: %s", lonStr)
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(ctx),
+			"lat":        lat,
+			"lon":        lon,
+		})
+
+		return fetchProblem(c, err)
 	}
 
-	// Validate latitude and longitude ranges
-	if lat < minLatitude || lat > maxLatitude {
-		return 0, 0, 0, fmt.Errorf("latitude must be between %d and %d, got: %f", minLatitude, maxLatitude, lat)
+	// Providers always report metric values
+	for name, weather := range current {
+		weather.ConvertUnits(system)
+		current[name] = weather
 	}
-	if lon < minLongitude || lon > maxLongitude {
-		return 0, 0, 0, fmt.Errorf("longitude must be between %d and %d, got: %f", minLongitude, maxLongitude, lon)
+
+	return c.JSON(current)
+}
+
+// GetWeatherHistory godoc
+// @Summary Get historical weather
+// @Description Retrieves the observed daily weather of a past date range from the providers with a weather archive
+// @Tags Weather
+// @Accept json
+// @Produce json
+// @Param lat query number false "Lat coordinate (-90 to 90), required without coords" minimum(-90) maximum(90) example(40.7128)
+// @Param lon query number false "Lon coordinate (-180 to 180), required without coords" minimum(-180) maximum(180) example(-74.006)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(40.7128,-74.006)
+// @Param start query string true "First day of the range (YYYY-MM-DD)" example(2024-01-01)
+// @Param end query string true "Last day of the range, not in the future (YYYY-MM-DD)" example(2024-01-31)
+// @Param units query string false "Unit system of the returned values (default: metric)" Enums(metric, imperial)
+// @Success 200 {object} map[string]models.HistoricalWeather "Historical weather by provider"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 422 {object} Problem "No configured provider supports historical weather, or the location is outside the service area"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 504 {object} Problem "Request budget exceeded"
+// @Router /weather/history [get]
+// @Example {curl} Example usage:
+//
+//	curl -X GET "http://localhost:8080/weather/history?lat=40.7128&lon=-74.006&start=2024-01-01&end=2024-01-31"
+func (r *routes) handleHistoryCall(c *fiber.Ctx) error {
+	lat, lon, start, end, err := validateHistoryParameters(c, time.Now(), r.service.HistoryMaxDays(), r.normalizeLongitude)
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(c.UserContext()),
+			"lat":        c.Query("lat"),
+			"lon":        c.Query("lon"),
+			"start":      c.Query("start"),
+			"end":        c.Query("end"),
+		})
+
+		return validationProblem(c, err)
 	}
 
-	// Optional: Validate forecast window if provided
-	daysStr := c.Query("days")
-	days := defaultForecastWindow
-	if daysStr != "" {
-		days, err = strconv.Atoi(daysStr)
-		if err != nil {
-			return 0, 0, 0, fmt.Errorf("invalid days parameter: %s", daysStr)
+	if err := r.area.Check(lat, lon); err != nil {
+		return areaProblem(c, err)
+	}
+
+	system, err := units.Parse(c.Query("units"))
+	if err != nil {
+		return validationProblem(c, paramError("units", err))
+	}
+
+	ctx, cancel := r.requestContext(c, r.service.RequestBudget())
+	defer cancel()
+
+	history, err := r.service.FetchHistory(ctx, lat, lon, start, end)
+	if errors.Is(err, weather.ErrNoHistoricalProviders) {
+		return problem(c, fiber.StatusUnprocessableEntity, ProblemUnprocessable,
+			"None of the configured weather providers supports historical weather, configure open-meteo to enable it")
+	}
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(ctx),
+			"lat":        lat,
+			"lon":        lon,
+			"start":      start.String(),
+			"end":        end.String(),
+		})
+
+		return fetchProblem(c, err)
+	}
+
+	// Providers always report metric values
+	for name, h := range history {
+		h.ConvertUnits(system)
+		history[name] = h
+	}
+
+	return c.JSON(history)
+}
+
+// geocodingError answers a city that couldn't be resolved to a single place
+func (r *routes) geocodingError(c *fiber.Ctx, city string, err error) error {
+	var ambiguous *repositories.AmbiguousPlaceError
+	switch {
+	case errors.Is(err, repositories.ErrPlaceNotFound):
+		return problem(c, fiber.StatusNotFound, ProblemNotFound, fmt.Sprintf("unknown city: %s", city))
+	case errors.As(err, &ambiguous):
+		return sendProblem(c, fiber.StatusMultipleChoices, AmbiguousCityProblem{
+			Problem: newProblem(c, fiber.StatusMultipleChoices, ProblemAmbiguousLocation,
+				"Several places match the city, use lat and lon or country"),
+			Candidates: ambiguous.Candidates,
+		})
+	}
+
+	r.l.Error(err, map[string]any{
+		"request_id": requestid.FromContext(c.UserContext()),
+		"city":       city,
+	})
+
+	status, problemType, detail := fetchErrorStatus(err)
+	if status == fiber.StatusInternalServerError {
+		status, problemType, detail = fiber.StatusBadGateway, ProblemUpstreamFailed, "Failed to resolve the city"
+	}
+	return problem(c, status, problemType, detail)
+}
+
+// fetchErrorStatus maps a service error to the response status, problem type and detail, a request canceled by
+// the client gets the non-standard 499 status, it won't read the response anyway
+func fetchErrorStatus(err error) (int, string, string) {
+	switch {
+	case errors.Is(err, context.Canceled):
+		return statusClientClosedRequest, ProblemCanceled, "Request canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return fiber.StatusGatewayTimeout, ProblemTimeout, "Weather providers did not answer in time"
+	case errors.Is(err, weather.ErrDateOutOfRange):
+		return fiber.StatusUnprocessableEntity, ProblemUnprocessable, err.Error()
+	}
+
+	return fiber.StatusInternalServerError, ProblemInternal, "Failed to fetch weather data"
+}
+
+// disconnectPollInterval is how often the connection of a request is checked for a departed client
+const disconnectPollInterval = 100 * time.Millisecond
+
+// requestContext builds the context passed down to the service, carrying the request values and bounded by
+// the total budget of the request. It derives from the user context, never from the fasthttp request context,
+// which is recycled for another request once the handler returned while the work started by the request, e.g.
+// a cache write, may go on. It is canceled by cancel, when the handler is done, by the server shutdown, or when
+// the client closes the connection, which fasthttp doesn't report while the handler runs so it is polled.
+func (r *routes) requestContext(c *fiber.Ctx, budget time.Duration) (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(r.requestValues(c.UserContext(), c), budget)
+
+	// The channel is closed by the server shutdown, it outlives the fasthttp request context
+	shutdown := c.Context().Done()
+	conn := c.Context().Conn()
+	go func() {
+		ticker := time.NewTicker(disconnectPollInterval)
+		defer ticker.Stop()
+		poll := ticker.C
+		for {
+			select {
+			case <-shutdown:
+				cancel()
+				return
+			case <-ctx.Done():
+				return
+			case <-poll:
+				closed, ok := httpserver.PeerClosed(conn)
+				if closed {
+					cancel()
+					return
+				}
+				if !ok {
+					// The connection can't be inspected, only the shutdown is watched
+					poll = nil
+				}
+			}
 		}
-		if days < 1 || days > maxForecastWindow {
-			return 0, 0, 0, fmt.Errorf("days must be between 1 and %d", maxForecastWindow)
+	}()
+
+	return ctx, cancel
+}
+
+// requestValues adds the request ID, the request logger, the span of the request and the caller identity used for
+// canary routing to parent
+func (r *routes) requestValues(parent context.Context, c *fiber.Ctx) context.Context {
+	ctx := requestid.NewContext(parent, requestid.FromContext(c.UserContext()))
+	ctx = logger.NewContext(ctx, logger.FromContext(c.UserContext(), r.l))
+	ctx = trace.ContextWithSpan(ctx, trace.SpanFromContext(c.UserContext()))
+
+	return repositories.WithCanaryKey(ctx, r.clientIP(c))
+}
+
+// parseProviders matches the comma-separated provider names case-insensitively against the available ones,
+// an empty list selects every provider
+func parseProviders(query string, available []string) ([]string, error) {
+	if query == "" {
+		return nil, nil
+	}
+
+	var providers []string
+	for _, name := range strings.Split(query, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+
+		i := slices.IndexFunc(available, func(a string) bool { return strings.EqualFold(a, name) })
+		if i < 0 {
+			return nil, fmt.Errorf("unknown provider: %s, valid providers are: %s", name, strings.Join(available, ", "))
+		}
+		if !slices.Contains(providers, available[i]) {
+			providers = append(providers, available[i])
 		}
 	}
 
+	return providers, nil
+}
+
+// validateParameters parses the required location and the optional forecast window of up to maxDays,
+// reporting all the invalid parameters at once, see checkLocation for normalizeLon
+func validateParameters(c *fiber.Ctx, maxDays int, normalizeLon bool) (float64, float64, int, error) {
+	v := &ValidationError{}
+	lat, lon := checkLocation(c, v, normalizeLon)
+	days := checkDays(c, v, maxDays)
+	if err := v.err(); err != nil {
+		return 0, 0, 0, err
+	}
+
 	return lat, lon, days, nil
 }
+
+// validateCityParameters checks the parameters of a request by city, it can't also carry coordinates
+func validateCityParameters(c *fiber.Ctx, maxDays int) (int, error) {
+	v := &ValidationError{}
+	for _, name := range []string{"lat", "lon", "coords"} {
+		if c.Query(name) != "" {
+			v.invalid(name, "city can't be combined with coordinates")
+		}
+	}
+
+	if country := c.Query("country"); country != "" && len(country) != 2 {
+		v.invalid("country", fmt.Sprintf("invalid country: %s, expected an ISO 3166-1 alpha-2 code", country))
+	}
+
+	days := checkDays(c, v, maxDays)
+	if err := v.err(); err != nil {
+		return 0, err
+	}
+
+	return days, nil
+}
+
+// validateDays parses the optional forecast window of up to maxDays
+func validateDays(c *fiber.Ctx, maxDays int) (int, error) {
+	v := &ValidationError{}
+	days := checkDays(c, v, maxDays)
+	if err := v.err(); err != nil {
+		return 0, err
+	}
+
+	return days, nil
+}
+
+// validateLocation parses the required location, see checkLocation for normalizeLon
+func validateLocation(c *fiber.Ctx, normalizeLon bool) (float64, float64, error) {
+	v := &ValidationError{}
+	lat, lon := checkLocation(c, v, normalizeLon)
+	if err := v.err(); err != nil {
+		return 0, 0, err
+	}
+
+	return lat, lon, nil
+}
+
+// validateTargetDate parses the optional date parameter, the day to forecast instead of a days window, zero
+// when absent. Whether the providers reach it is up to the service.
+func validateTargetDate(c *fiber.Ctx) (models.Date, error) {
+	s := c.Query("date")
+	if s == "" {
+		return models.Date{}, nil
+	}
+
+	if c.Query("days") != "" {
+		return models.Date{}, paramError("date", errors.New("date can't be combined with days"))
+	}
+
+	date, err := models.ParseDate(s)
+	if err != nil {
+		return models.Date{}, paramError("date", fmt.Errorf("invalid date: %s, expected YYYY-MM-DD", s))
+	}
+
+	return date, nil
+}
+
+// checkDays parses the optional forecast window of up to maxDays into v
+func checkDays(c *fiber.Ctx, v *ValidationError, maxDays int) int {
+	daysStr := c.Query("days")
+	if daysStr == "" {
+		return min(defaultForecastWindow, maxDays)
+	}
+
+	days, err := strconv.Atoi(daysStr)
+	if err != nil {
+		v.invalid("days", fmt.Sprintf("invalid days parameter: %s", daysStr))
+		return 0
+	}
+	if days < 1 || days > maxDays {
+		v.outOfRange("days", fmt.Sprintf("days must be between 1 and %d", maxDays))
+		return 0
+	}
+
+	return days
+}
+
+// checkLocation parses the required location into v, the lat and lon parameters or the coords parameter
+// combining them. With normalizeLon the longitudes beyond 180 degrees are brought back into range, they are
+// rejected otherwise.
+func checkLocation(c *fiber.Ctx, v *ValidationError, normalizeLon bool) (float64, float64) {
+	coords := c.Query("coords")
+	if coords == "" {
+		lat := checkCoordinate(v, "lat", c.Query("lat"), "latitude", minLatitude, maxLatitude, false)
+		lon := checkCoordinate(v, "lon", c.Query("lon"), "longitude", minLongitude, maxLongitude, normalizeLon)
+
+		return lat, lon
+	}
+
+	// Either form is complete on its own, a request mixing them is ambiguous
+	if c.Query("lat") != "" || c.Query("lon") != "" {
+		v.invalid("coords", "coords can't be combined with lat and lon")
+		return 0, 0
+	}
+	latStr, lonStr, err := splitCoords(coords)
+	if err != nil {
+		v.invalid("coords", err.Error())
+		return 0, 0
+	}
+	lat := checkCoordinate(v, "coords", latStr, "latitude", minLatitude, maxLatitude, false)
+	lon := checkCoordinate(v, "coords", lonStr, "longitude", minLongitude, maxLongitude, normalizeLon)
+
+	return lat, lon
+}
+
+// splitCoords splits a lat,lon pair such as 40.7128,-74.006 into its latitude and longitude, the spaces around
+// them are ignored
+func splitCoords(coords string) (string, string, error) {
+	lat, lon, ok := strings.Cut(coords, ",")
+	lat, lon = strings.TrimSpace(lat), strings.TrimSpace(lon)
+	if !ok || lat == "" || lon == "" || strings.Contains(lon, ",") {
+		return "", "", fmt.Errorf("invalid coords format: %s, expected lat,lon", coords)
+	}
+
+	return lat, lon, nil
+}
+
+// parseCoordinate parses a coordinate in decimal degrees
+func parseCoordinate(s, label string) (float64, error) {
+	// ParseFloat also reads NaN, infinities and hexadecimal floats, none of them is a coordinate
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) || strings.ContainsAny(s, "xX") {
+		return 0, fmt.Errorf("invalid %s format: %s, expected a decimal number", label, s)
+	}
+
+	return value, nil
+}
+
+// checkCoordinate parses the required coordinate s of the parameter name between lower and upper, normalize
+// brings the longitudes out of range back into it instead
+func checkCoordinate(v *ValidationError, name, s, label string, lower, upper int, normalize bool) float64 {
+	if s == "" {
+		v.missing(name)
+		return 0
+	}
+
+	value, err := parseCoordinate(s, label)
+	if err != nil {
+		v.invalid(name, err.Error())
+		return 0
+	}
+	if normalize {
+		value = geo.NormalizeLongitude(value)
+	}
+	if value < float64(lower) || value > float64(upper) {
+		v.outOfRange(name, fmt.Sprintf("%s must be between %d and %d, got: %s", label, lower, upper, s))
+		return 0
+	}
+
+	return normalizeCoordinate(value)
+}
+
+// normalizeCoordinate rounds a coordinate to 6 decimals, about 11 centimeters, and turns -0 into 0, so that
+// the same place reaches the providers and the caches as the same value
+func normalizeCoordinate(value float64) float64 {
+	value = math.Round(value*coordinateScale) / coordinateScale
+	if value == 0 {
+		return 0
+	}
+
+	return value
+}
+
+// validateCoordinates checks the latitude and longitude ranges
+func validateCoordinates(lat, lon float64) error {
+	if math.IsNaN(lat) || math.IsInf(lat, 0) || math.IsNaN(lon) || math.IsInf(lon, 0) {
+		return errors.New("latitude and longitude must be finite numbers")
+	}
+	if lat < minLatitude || lat > maxLatitude {
+		return fmt.Errorf("latitude must be between %d and %d, got: %f", minLatitude, maxLatitude, lat)
+	}
+	if lon < minLongitude || lon > maxLongitude {
+		return fmt.Errorf("longitude must be between %d and %d, got: %f", minLongitude, maxLongitude, lon)
+	}
+
+	return nil
+}
+
+// validateHistoryParameters parses the location and the date range of a history request, see checkLocation for
+// normalizeLon
+func validateHistoryParameters(c *fiber.Ctx, now time.Time, maxDays int, normalizeLon bool) (float64, float64, models.Date, models.Date, error) {
+	v := &ValidationError{}
+	lat, lon := checkLocation(c, v, normalizeLon)
+	start, end := checkDateRange(c, v, now, maxDays)
+	if err := v.err(); err != nil {
+		return 0, 0, models.Date{}, models.Date{}, err
+	}
+
+	return lat, lon, start, end, nil
+}
+
+// checkDateRange parses the required start and end dates into v, the range can't end after today in UTC
+// nor span more than maxDays days
+func checkDateRange(c *fiber.Ctx, v *ValidationError, now time.Time, maxDays int) (models.Date, models.Date) {
+	start, startOK := checkDate(c, v, "start")
+	end, endOK := checkDate(c, v, "end")
+	if !startOK || !endOK {
+		return models.Date{}, models.Date{}
+	}
+
+	switch {
+	case end.Before(start.Time):
+		v.invalid("start", "start must not be after end")
+	case end.After(models.NewDate(now.UTC()).Time):
+		v.outOfRange("end", "end must not be in the future")
+	default:
+		if days := int(end.Sub(start.Time).Hours()/24) + 1; days > maxDays {
+			v.outOfRange("end", fmt.Sprintf("date range must not exceed %d days, got: %d", maxDays, days))
+		}
+	}
+
+	return start, end
+}
+
+// checkDate parses a required YYYY-MM-DD date into v
+func checkDate(c *fiber.Ctx, v *ValidationError, name string) (models.Date, bool) {
+	s := c.Query(name)
+	if s == "" {
+		v.missing(name)
+		return models.Date{}, false
+	}
+
+	date, err := models.ParseDate(s)
+	if err != nil {
+		v.invalid(name, fmt.Sprintf("invalid %s date: %s, expected YYYY-MM-DD", name, s))
+		return models.Date{}, false
+	}
+
+	return date, true
+}
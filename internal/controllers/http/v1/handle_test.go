@@ -0,0 +1,3318 @@
+package http
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"weather-api/docs"
+	"weather-api/internal/buildinfo"
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/geo"
+	"weather-api/pkg/httpserver"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// assertGolden compares got with the testdata golden file, -update rewrites it
+func assertGolden(t *testing.T, name string, got []byte) {
+	t.Helper()
+
+	path := filepath.Join("testdata", name)
+	if *update {
+		require.NoError(t, os.WriteFile(path, got, 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), string(got))
+}
+
+type recordingHTTPClient struct {
+	requests []*http.Request
+}
+
+func (c *recordingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	c.requests = append(c.requests, req)
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"daily": {"time": ["2025-01-27"], "temperature_2m_max": [25.5], "temperature_2m_min": [15.2]}}`)),
+		Header:     make(http.Header),
+	}, nil
+}
+
+func newTestApp(httpClient repositories.HTTPClient) *fiber.App {
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+
+	repos := []repositories.WeatherRepository{repositories.NewOpenMeteoRepository(l, httpClient)}
+	NewRouter(app, weather.NewWeatherService(repos, l), repositories.NewGeocodingRepository(l, httpClient), l)
+
+	return app
+}
+
+func TestHandleWeatherCall_RequestIDPropagation(t *testing.T) {
+	client := &recordingHTTPClient{}
+	app := newTestApp(client)
+
+	req := httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1", nil)
+	req.Header.Set(requestid.Header, "client-request-1")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	require.Len(t, client.requests, 1)
+	assert.Equal(t, "client-request-1", resp.Header.Get(requestid.Header))
+	assert.Equal(t, "client-request-1", client.requests[0].Header.Get(requestid.Header))
+}
+
+func TestHandleWeatherCall_GeneratesRequestID(t *testing.T) {
+	client := &recordingHTTPClient{}
+	app := newTestApp(client)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1", nil))
+	require.NoError(t, err)
+
+	id := resp.Header.Get(requestid.Header)
+	assert.NotEmpty(t, id)
+	require.Len(t, client.requests, 1)
+	assert.Equal(t, id, client.requests[0].Header.Get(requestid.Header))
+}
+
+func TestHandleWeatherCall_Units(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		wantUnits string
+		wantMax   float64
+		wantMin   float64
+	}{
+		{"metric by default", "", "metric", 25.5, 15.2},
+		{"imperial", "&units=imperial", "imperial", 77.9, 59.4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newTestApp(&recordingHTTPClient{})
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1"+tt.query, nil))
+			require.NoError(t, err)
+			require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+			var forecasts map[string]models.Forecast
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&forecasts))
+
+			forecast := forecasts["open-meteo"]
+			assert.Equal(t, tt.wantUnits, forecast.Units)
+			require.Len(t, forecast.ForecastData, 1)
+			assert.Equal(t, tt.wantMax, forecast.ForecastData[0].TempMax)
+			assert.Equal(t, tt.wantMin, forecast.ForecastData[0].TempMin)
+		})
+	}
+}
+
+func TestHandleWeatherCall_InvalidUnits(t *testing.T) {
+	client := &recordingHTTPClient{}
+	app := newTestApp(client)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&units=kelvin", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Empty(t, client.requests)
+}
+
+func TestHandleWeatherCall_FetchMetadata(t *testing.T) {
+	app := newTestApp(&recordingHTTPClient{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var forecasts map[string]map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&forecasts))
+
+	forecast := forecasts["open-meteo"]
+	assert.Contains(t, forecast, "fetched_at")
+	assert.Contains(t, forecast, "fetch_duration_ms")
+	assert.Contains(t, forecast, "source_url")
+	assert.NotContains(t, forecast, "stale")
+}
+
+type failingHTTPClient struct{}
+
+func (c *failingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{
+		StatusCode: http.StatusServiceUnavailable,
+		Status:     "503 Service Unavailable",
+		Body:       io.NopCloser(strings.NewReader(`{"error": true}`)),
+		Header:     make(http.Header),
+	}, nil
+}
+
+func TestHandleAggregateCall(t *testing.T) {
+	app := newTestApp(&recordingHTTPClient{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/aggregate?lat=52.52&lon=13.41&days=1&strategy=median&units=imperial", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var aggregated models.AggregatedForecast
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&aggregated))
+
+	assert.Equal(t, "median", aggregated.Strategy)
+	assert.Equal(t, "imperial", aggregated.Units)
+	assert.Equal(t, []string{"open-meteo"}, aggregated.Providers)
+	require.Len(t, aggregated.ForecastData, 1)
+	assert.Equal(t, 77.9, aggregated.ForecastData[0].TempMax)
+	assert.Equal(t, 59.4, aggregated.ForecastData[0].TempMin)
+	assert.Equal(t, 0.0, aggregated.ForecastData[0].Spread)
+	assert.Equal(t, 1, aggregated.ForecastData[0].ProviderCount)
+}
+
+func TestHandleAggregateCall_InvalidStrategy(t *testing.T) {
+	client := &recordingHTTPClient{}
+	app := newTestApp(client)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/aggregate?lat=52.52&lon=13.41&strategy=mode", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Empty(t, client.requests)
+}
+
+func TestHandleAggregateCall_AllProvidersFailed(t *testing.T) {
+	app := newTestApp(&failingHTTPClient{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/aggregate?lat=52.52&lon=13.41&days=1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadGateway, resp.StatusCode)
+}
+
+func TestHandleAggregateCall_Quorum(t *testing.T) {
+	app := newTestApp(&recordingHTTPClient{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/aggregate?lat=52.52&lon=13.41&days=1&min_providers=2", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusServiceUnavailable, resp.StatusCode)
+
+	var body QuorumProblem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, ProblemQuorumNotMet, body.Type)
+	assert.Equal(t, fiber.StatusServiceUnavailable, body.Status)
+	assert.Equal(t, 2, body.Required)
+	assert.Equal(t, 1, body.Available)
+
+	for _, value := range []string{"0", "two"} {
+		resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/weather/aggregate?lat=52.52&lon=13.41&min_providers="+value, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, value)
+	}
+}
+
+// backgroundCache writes the forecasts in the background once released, after the handler returned
+type backgroundCache struct {
+	release chan struct{}
+	writes  chan backgroundWrite
+}
+
+// backgroundWrite is what a background write found in the context of its request
+type backgroundWrite struct {
+	requestID string
+	// marker is the requestMarker local of the fasthttp request context, reached by the lookups of the keys
+	// missing from the request context when it derives from it
+	marker any
+	err    error
+}
+
+// requestMarker is the key of a local set on the fasthttp request context by the test
+type requestMarker struct{}
+
+func (c *backgroundCache) Get(ctx context.Context, key weather.CacheKey) (models.Forecast, bool) {
+	return models.Forecast{}, false
+}
+
+func (c *backgroundCache) Set(ctx context.Context, key weather.CacheKey, forecast models.Forecast) {
+	// Looked up while the handler runs, the fasthttp request context is recycled afterwards
+	marker := ctx.Value(requestMarker{})
+	go func() {
+		<-c.release
+		c.writes <- backgroundWrite{requestID: requestid.FromContext(ctx), marker: marker, err: ctx.Err()}
+	}()
+}
+
+func (c *backgroundCache) Purge(ctx context.Context, match func(key weather.CacheKey) bool) int {
+	return 0
+}
+
+func (c *backgroundCache) Stats(ctx context.Context) weather.CacheStats { return weather.CacheStats{} }
+
+func TestHandleWeatherCall_BackgroundWorkOutlivesHandler(t *testing.T) {
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	client := &recordingHTTPClient{}
+	cache := &backgroundCache{release: make(chan struct{}), writes: make(chan backgroundWrite, 2)}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{repositories.NewOpenMeteoRepository(l, client)},
+		l, weather.WithCache(cache))
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(requestMarker{}, "fasthttp request context")
+		return c.Next()
+	})
+	NewRouter(app, service, repositories.NewGeocodingRepository(l, client), l)
+
+	// The fasthttp request context of the first request is recycled for the second one
+	for _, id := range []string{"first-request", "second-request"} {
+		req := httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1", nil)
+		req.Header.Set(requestid.Header, id)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	}
+
+	close(cache.release)
+	var ids []string
+	for range 2 {
+		select {
+		case write := <-cache.writes:
+			ids = append(ids, write.requestID)
+			assert.Nil(t, write.marker, "the request context derives from the fasthttp request context")
+			assert.ErrorIs(t, write.err, context.Canceled)
+		case <-time.After(time.Second):
+			t.Fatal("the background write didn't run")
+		}
+	}
+	assert.ElementsMatch(t, []string{"first-request", "second-request"}, ids)
+}
+
+// hangingRepository never answers, its calls return when their context is done
+type hangingRepository struct{}
+
+func (hangingRepository) Name() string { return "hanging" }
+
+func (hangingRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	<-ctx.Done()
+	return models.Forecast{}, ctx.Err()
+}
+
+// notifyingRepository never answers like hangingRepository, it reports when it is called and how its call ended
+type notifyingRepository struct {
+	started chan struct{}
+	ended   chan error
+}
+
+func (notifyingRepository) Name() string { return "notifying" }
+
+func (r notifyingRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	r.started <- struct{}{}
+	<-ctx.Done()
+	r.ended <- ctx.Err()
+	return models.Forecast{}, ctx.Err()
+}
+
+func TestHandleWeatherCall_ClientDisconnect(t *testing.T) {
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	repo := notifyingRepository{started: make(chan struct{}, 1), ended: make(chan error, 1)}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{repo}, l)
+	NewRouter(app, service, repositories.NewGeocodingRepository(l, &recordingHTTPClient{}), l)
+
+	// The departed clients are only noticed on a real connection
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	require.NoError(t, err)
+	_, err = fmt.Fprint(conn, "GET /weather?lat=52.52&lon=13.41&days=1 HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	require.NoError(t, err)
+
+	select {
+	case <-repo.started:
+	case <-time.After(time.Second):
+		t.Fatal("the provider wasn't called")
+	}
+	require.NoError(t, conn.Close())
+
+	// The call is canceled well before the provider timeout of 5 seconds
+	select {
+	case err := <-repo.ended:
+		assert.ErrorIs(t, err, context.Canceled)
+	case <-time.After(2 * time.Second):
+		t.Fatal("the call outlived its client")
+	}
+}
+
+func TestHandleWeatherCall_RequestTimeout(t *testing.T) {
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	client := &recordingHTTPClient{}
+	repos := []repositories.WeatherRepository{repositories.NewOpenMeteoRepository(l, client), hangingRepository{}}
+	service := weather.NewWeatherService(repos, l, weather.WithRequestTimeout(100*time.Millisecond))
+	NewRouter(app, service, repositories.NewGeocodingRepository(l, client), l)
+
+	// The fast provider is delivered with the budget exceeded by the other one
+	start := time.Now()
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1", nil))
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, fiber.StatusGatewayTimeout, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(fiber.HeaderCacheControl))
+
+	var forecasts map[string]models.Forecast
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&forecasts))
+	assert.Empty(t, forecasts["open-meteo"].Error)
+	assert.Len(t, forecasts["open-meteo"].ForecastData, 1)
+	assert.Equal(t, models.ErrorCodeTimeout, forecasts["hanging"].ErrorCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1&envelope=true", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusGatewayTimeout, resp.StatusCode)
+
+	var envelope Envelope
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&envelope))
+	assert.True(t, envelope.Meta.Partial)
+	assert.Equal(t, ProviderCounts{Queried: 2, Succeeded: 1, Failed: 1}, envelope.Meta.Providers)
+}
+
+func TestHandleWeatherCall_Providers(t *testing.T) {
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	client := &recordingHTTPClient{}
+	repos := []repositories.WeatherRepository{
+		repositories.NewOpenMeteoRepository(l, client),
+		repositories.NewMockWeatherRepository(0, 0, l),
+	}
+	NewRouter(app, weather.NewWeatherService(repos, l), repositories.NewGeocodingRepository(l, client), l)
+
+	tests := []struct {
+		name          string
+		providers     string
+		wantProviders []string
+		wantRequests  int
+	}{
+		{"missing parameter selects all", "", []string{"mock", "open-meteo"}, 1},
+		{"single provider", "mock", []string{"mock"}, 0},
+		{"case insensitive", "Open-Meteo", []string{"open-meteo"}, 1},
+		{"subset with spaces and duplicates", "MOCK, open-meteo,mock", []string{"mock", "open-meteo"}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.requests = nil
+
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1&providers="+url.QueryEscape(tt.providers), nil))
+			require.NoError(t, err)
+			require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+			var forecasts map[string]models.Forecast
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&forecasts))
+
+			var names []string
+			for name := range forecasts {
+				names = append(names, name)
+			}
+			assert.ElementsMatch(t, tt.wantProviders, names)
+			assert.Len(t, client.requests, tt.wantRequests)
+		})
+	}
+}
+
+func TestHandleWeatherCall_UnknownProvider(t *testing.T) {
+	client := &recordingHTTPClient{}
+	app := newTestApp(client)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&providers=open-meteo,accuweather", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var body Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, ProblemInvalidParameter, body.Type)
+	require.Len(t, body.InvalidParams, 1)
+	assert.Equal(t, "providers", body.InvalidParams[0].Name)
+	assert.Equal(t, "unknown provider: accuweather, valid providers are: open-meteo", body.InvalidParams[0].Reason)
+	assert.Empty(t, client.requests)
+}
+
+func TestHandleWeatherCall_ModeFirst(t *testing.T) {
+	app := newTestApp(&recordingHTTPClient{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1&mode=first", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var forecasts map[string]models.Forecast
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&forecasts))
+	require.Contains(t, forecasts, "open-meteo")
+	assert.Len(t, forecasts["open-meteo"].ForecastData, 1)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&mode=fastest", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp, err = newTestApp(&failingHTTPClient{}).Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1&mode=first", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadGateway, resp.StatusCode)
+}
+
+func TestFetchErrorStatus(t *testing.T) {
+	tests := []struct {
+		err        error
+		wantStatus int
+		wantType   string
+	}{
+		{context.Canceled, statusClientClosedRequest, ProblemCanceled},
+		{fmt.Errorf("fetch: %w", context.DeadlineExceeded), fiber.StatusGatewayTimeout, ProblemTimeout},
+		{errors.New("unknown weather provider: accuweather"), fiber.StatusInternalServerError, ProblemInternal},
+	}
+
+	for _, tt := range tests {
+		status, problemType, detail := fetchErrorStatus(tt.err)
+		assert.Equal(t, tt.wantStatus, status, tt.err.Error())
+		assert.Equal(t, tt.wantType, problemType, tt.err.Error())
+		assert.NotEmpty(t, detail)
+	}
+}
+
+// stubForecaster returns canned forecasts and records the calls of the routes
+type stubForecaster struct {
+	forecasts map[string]models.Forecast
+	current   map[string]models.CurrentWeather
+	history   map[string]models.HistoricalWeather
+	// airQuality is served when set, air quality is unavailable without it
+	airQuality *models.AirQuality
+	alerts     *models.AlertReport
+	marine     *models.MarineForecast
+	// stored is served when set, the forecasts are not stored without it
+	stored []models.StoredForecast
+	// accuracy is served when set, the forecasts are not stored without it
+	accuracy *models.AccuracyReport
+	// subscriptions are served when set, the subscriptions are not stored without it
+	subscriptions map[string]models.Subscription
+	aggregated    *models.AggregatedForecast
+	comparison    *models.ForecastComparison
+	providers     []models.ProviderStatus
+	// date is the target date of the last date request
+	date models.Date
+	// stream is sent in order by FetchForecastsStream, with streamCanceled set forecasts are sent until the
+	// context is canceled, which closes streamCanceled
+	stream         []models.Forecast
+	streamCanceled chan struct{}
+	// subscriptionMax bounds the subscriptions, 200ms when zero, updates counts the shared aggregates
+	subscriptionMax time.Duration
+	updates         atomic.Int32
+	marineErr       error
+	err             error
+	calls           int
+}
+
+func (s *stubForecaster) Providers() []string {
+	return []string{"stub"}
+}
+
+func (s *stubForecaster) RequestBudget() time.Duration {
+	return time.Second
+}
+
+func (s *stubForecaster) HistoryMaxDays() int {
+	return 31
+}
+
+func (s *stubForecaster) MaxForecastDays() int {
+	return 16
+}
+
+func (s *stubForecaster) CacheTTL() time.Duration {
+	return time.Minute
+}
+
+func (s *stubForecaster) BatchMaxItems() int {
+	return 3
+}
+
+func (s *stubForecaster) BatchRequestBudget(items int) time.Duration {
+	return time.Second
+}
+
+func (s *stubForecaster) SubscriptionLimits() (time.Duration, time.Duration) {
+	if s.subscriptionMax > 0 {
+		return 10 * time.Millisecond, s.subscriptionMax
+	}
+	return 10 * time.Millisecond, 200 * time.Millisecond
+}
+
+func (s *stubForecaster) FetchProviderForecasts(ctx context.Context, lat, lon float64, forecastWindow int, providers []string) (map[string]models.Forecast, error) {
+	s.calls++
+	return s.forecasts, s.err
+}
+
+func (s *stubForecaster) FetchFirstForecast(ctx context.Context, lat, lon float64, forecastWindow int, providers []string) (models.Forecast, error) {
+	s.calls++
+	return s.forecasts["stub"], s.err
+}
+
+func (s *stubForecaster) FetchForecastsStream(ctx context.Context, lat, lon float64, forecastWindow int, providers []string) (<-chan models.Forecast, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.streamCanceled != nil {
+		return endlessStream(ctx, s.streamCanceled), nil
+	}
+
+	results := make(chan models.Forecast, len(s.stream))
+	for _, forecast := range s.stream {
+		results <- forecast
+	}
+	close(results)
+
+	return results, nil
+}
+
+func (s *stubForecaster) AggregateForecasts(ctx context.Context, lat, lon float64, forecastWindow int, strategy string, minProviders int) (models.AggregatedForecast, error) {
+	s.calls++
+	if s.aggregated != nil {
+		return *s.aggregated, s.err
+	}
+	return models.AggregatedForecast{}, s.err
+}
+
+func (s *stubForecaster) CompareForecasts(ctx context.Context, lat, lon float64, forecastWindow int) (models.ForecastComparison, error) {
+	s.calls++
+	if s.comparison != nil {
+		return *s.comparison, s.err
+	}
+	return models.ForecastComparison{}, s.err
+}
+
+func (s *stubForecaster) FetchDateForecasts(ctx context.Context, lat, lon float64, date models.Date, providers []string) (map[string]models.Forecast, error) {
+	s.calls++
+	s.date = date
+	return s.forecasts, s.err
+}
+
+func (s *stubForecaster) AggregateDateForecasts(ctx context.Context, lat, lon float64, date models.Date, strategy string, minProviders int) (models.AggregatedForecast, error) {
+	s.calls++
+	s.date = date
+	if s.aggregated != nil {
+		return *s.aggregated, s.err
+	}
+	return models.AggregatedForecast{}, s.err
+}
+
+func (s *stubForecaster) SharedAggregateForecasts(ctx context.Context, lat, lon float64, forecastWindow int, strategy string) (models.AggregatedForecast, error) {
+	s.updates.Add(1)
+	if s.aggregated != nil {
+		return *s.aggregated, s.err
+	}
+	return models.AggregatedForecast{}, s.err
+}
+
+func (s *stubForecaster) FetchCurrentWeather(ctx context.Context, lat, lon float64) (map[string]models.CurrentWeather, error) {
+	s.calls++
+	return s.current, s.err
+}
+
+func (s *stubForecaster) FetchHistory(ctx context.Context, lat, lon float64, start, end models.Date) (map[string]models.HistoricalWeather, error) {
+	s.calls++
+	return s.history, s.err
+}
+
+func (s *stubForecaster) FetchAirQuality(ctx context.Context, lat, lon float64, days int) (models.AirQuality, error) {
+	s.calls++
+	if s.airQuality == nil {
+		return models.AirQuality{}, weather.ErrNoAirQualityProvider
+	}
+
+	return *s.airQuality, s.err
+}
+
+func (s *stubForecaster) FetchAlerts(ctx context.Context, lat, lon float64) (models.AlertReport, error) {
+	s.calls++
+	if s.alerts == nil {
+		return models.AlertReport{}, weather.ErrNoAlertProviders
+	}
+
+	return *s.alerts, s.err
+}
+
+func (s *stubForecaster) FetchMarineForecast(ctx context.Context, lat, lon float64, days int) (models.MarineForecast, error) {
+	s.calls++
+	if s.marine == nil {
+		return models.MarineForecast{}, weather.ErrNoMarineProvider
+	}
+	if s.marineErr != nil {
+		return models.MarineForecast{}, s.marineErr
+	}
+
+	return *s.marine, s.err
+}
+
+func (s *stubForecaster) StoredForecasts(ctx context.Context, lat, lon float64, date models.Date) ([]models.StoredForecast, error) {
+	s.calls++
+	s.date = date
+	if s.stored == nil {
+		return nil, weather.ErrNoForecastStore
+	}
+
+	return s.stored, s.err
+}
+
+func (s *stubForecaster) WebhookMinInterval() time.Duration {
+	return 5 * time.Minute
+}
+
+func (s *stubForecaster) CreateSubscription(ctx context.Context, sub models.Subscription) (models.Subscription, error) {
+	s.calls++
+	if s.subscriptions == nil {
+		return models.Subscription{}, weather.ErrNoForecastStore
+	}
+	sub.ID, sub.Secret, sub.Enabled = fmt.Sprintf("sub%d", len(s.subscriptions)+1), "secret", true
+	s.subscriptions[sub.ID] = sub
+
+	return sub, s.err
+}
+
+func (s *stubForecaster) Subscription(ctx context.Context, owner, id string) (models.Subscription, error) {
+	s.calls++
+	if s.subscriptions == nil {
+		return models.Subscription{}, weather.ErrNoForecastStore
+	}
+	sub, ok := s.subscriptions[id]
+	if !ok || sub.Owner != owner {
+		return models.Subscription{}, repositories.ErrSubscriptionNotFound
+	}
+	sub.Secret = ""
+
+	return sub, s.err
+}
+
+func (s *stubForecaster) Subscriptions(ctx context.Context, owner string) ([]models.Subscription, error) {
+	s.calls++
+	if s.subscriptions == nil {
+		return nil, weather.ErrNoForecastStore
+	}
+	subs := []models.Subscription{}
+	for _, sub := range s.subscriptions {
+		if sub.Owner != owner {
+			continue
+		}
+		sub.Secret = ""
+		subs = append(subs, sub)
+	}
+
+	return subs, s.err
+}
+
+func (s *stubForecaster) DeleteSubscription(ctx context.Context, owner, id string) error {
+	s.calls++
+	if s.subscriptions == nil {
+		return weather.ErrNoForecastStore
+	}
+	if sub, ok := s.subscriptions[id]; !ok || sub.Owner != owner {
+		return repositories.ErrSubscriptionNotFound
+	}
+	delete(s.subscriptions, id)
+
+	return s.err
+}
+
+func (s *stubForecaster) ProviderAccuracy(ctx context.Context, lat, lon float64) (models.AccuracyReport, error) {
+	s.calls++
+	if s.accuracy == nil {
+		return models.AccuracyReport{}, weather.ErrNoForecastStore
+	}
+
+	return *s.accuracy, s.err
+}
+
+func (s *stubForecaster) ProviderStatus(ctx context.Context) []models.ProviderStatus {
+	s.calls++
+	return s.providers
+}
+
+// FetchBatchForecasts returns the canned forecasts moved to every location
+func (s *stubForecaster) FetchBatchForecasts(ctx context.Context, locations []weather.Location) ([]map[string]models.Forecast, error) {
+	s.calls++
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	results := make([]map[string]models.Forecast, len(locations))
+	for i, loc := range locations {
+		results[i] = make(map[string]models.Forecast)
+		for name, forecast := range s.forecasts {
+			forecast.Lat, forecast.Lon, forecast.ForecastWindow = loc.Lat, loc.Lon, loc.ForecastWindow
+			results[i][name] = forecast
+		}
+	}
+
+	return results, nil
+}
+
+// stubGeocoder resolves the cities it knows, other names are unknown
+type stubGeocoder struct {
+	places   map[string][]models.Place
+	err      error
+	searches int
+	// reversed is the place of every coordinate, reverse lookups fail without it
+	reversed *models.Place
+	reverses atomic.Int32
+}
+
+func (g *stubGeocoder) Resolve(ctx context.Context, name, country string) (models.Place, error) {
+	switch places := g.places[name]; len(places) {
+	case 0:
+		return models.Place{}, repositories.ErrPlaceNotFound
+	case 1:
+		return places[0], nil
+	default:
+		return models.Place{}, &repositories.AmbiguousPlaceError{Name: name, Candidates: places}
+	}
+}
+
+func (g *stubGeocoder) Search(ctx context.Context, name, country string) ([]models.Place, error) {
+	g.searches++
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	return g.places[name], nil
+}
+
+func (g *stubGeocoder) Reverse(ctx context.Context, lat, lon float64) (models.Place, error) {
+	g.reverses.Add(1)
+	if g.reversed == nil {
+		return models.Place{}, errors.New("reverse geocoding down")
+	}
+
+	return *g.reversed, nil
+}
+
+func newStubApp(service Forecaster) *fiber.App {
+	return newStubGeocoderApp(service, newStubGeocoder())
+}
+
+func newStubGeocoder() *stubGeocoder {
+	return &stubGeocoder{places: map[string][]models.Place{
+		"Berlin": {{Name: "Berlin", CountryCode: "DE", Lat: 52.52, Lon: 13.41}},
+		"Springfield": {
+			{Name: "Springfield", Admin1: "Illinois", CountryCode: "US", Lat: 39.8, Lon: -89.64},
+			{Name: "Springfield", Admin1: "Missouri", CountryCode: "US", Lat: 37.22, Lon: -93.3},
+		},
+	}}
+}
+
+func newStubGeocoderApp(service Forecaster, geocoder Geocoder) *fiber.App {
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	NewDocsRouter(app)
+	NewRouter(app, service, geocoder, l)
+
+	return app
+}
+
+func TestHandleWeatherCall_StubService(t *testing.T) {
+	date, err := models.ParseDate("2025-07-25")
+	require.NoError(t, err)
+
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{
+		"stub": {
+			RepositoryName: "stub",
+			Lat:            52.52,
+			Lon:            13.41,
+			ForecastWindow: 1,
+			ForecastData:   []models.WeatherData{{Date: date, TempMax: 25.5, TempMin: 15.2}},
+		},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, stub.calls)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"stub": {
+			"repository_name": "stub",
+			"lat": 52.52,
+			"lon": 13.41,
+			"forecast_window": 1,
+			"units": "metric",
+			"fetch_duration_ms": 0,
+			"forecast_data": [{"date": "2025-07-25", "temp_max": 25.5, "temp_min": 15.2}]
+		}
+	}`, string(body))
+}
+
+func TestHandleWeatherCall_StubService_ValidationError(t *testing.T) {
+	stub := &stubForecaster{}
+	app := newStubApp(stub)
+
+	for _, query := range []string{"lon=13.41", "lat=91&lon=13.41", "lat=52.52&lon=13.41&days=0", "lat=52.52&lon=13.41&providers=other"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+	assert.Equal(t, 0, stub.calls)
+}
+
+func TestHandleWeatherCall_MaxForecastDays(t *testing.T) {
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{}}
+	app := newStubApp(stub)
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/weather?lat=52.52&lon=13.41&days=16", fiber.StatusOK},
+		{"/weather?lat=52.52&lon=13.41&days=17", fiber.StatusBadRequest},
+		{"/weather?city=Berlin&days=17", fiber.StatusBadRequest},
+		// The air quality and marine forecasts keep their own horizon
+		{"/air-quality?lat=52.52&lon=13.41&days=6", fiber.StatusBadRequest},
+		{"/weather/marine?lat=43.2965&lon=5.3698&days=6", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, tt.wantStatus, resp.StatusCode, tt.path)
+	}
+	assert.Equal(t, 1, stub.calls)
+}
+
+func TestHandleWeatherCall_Date(t *testing.T) {
+	stub := &stubForecaster{
+		forecasts:  map[string]models.Forecast{"stub": {RepositoryName: "stub", ForecastData: []models.WeatherData{}}},
+		aggregated: &models.AggregatedForecast{},
+	}
+	app := newStubApp(stub)
+
+	for _, path := range []string{"/weather?lat=52.52&lon=13.41&date=2023-10-07", "/weather/aggregate?lat=52.52&lon=13.41&date=2023-10-07"} {
+		stub.date = models.Date{}
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode, path)
+		assert.Equal(t, "2023-10-07", stub.date.String(), path)
+	}
+
+	for _, query := range []string{"date=2023-10-07&days=3", "date=07/10/2023", "date=2023-10-07&mode=first", "date=2023-10-07&stream=true"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+	assert.Equal(t, 2, stub.calls)
+
+	// Out of the range of the providers
+	stub.err = fmt.Errorf("%w: 2023-10-07 is in the past", weather.ErrDateOutOfRange)
+	for _, path := range []string{"/weather?lat=52.52&lon=13.41&date=2023-10-07", "/weather/aggregate?lat=52.52&lon=13.41&date=2023-10-07"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode, path)
+
+		var body Problem
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		assert.Equal(t, ProblemUnprocessable, body.Type)
+		assert.Contains(t, body.Detail, "in the past")
+	}
+}
+
+func TestValidateLocation_DegenerateCoordinates(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		lat, lon, err := validateLocation(c, false)
+		if err != nil {
+			return validationProblem(c, err)
+		}
+		return c.SendString(fmt.Sprintf("%v,%v", lat, lon))
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		want     string
+		wantType string
+	}{
+		{"NaN", "lat=NaN&lon=13.41", "", ProblemInvalidParameter},
+		{"lowercase nan", "lat=52.52&lon=nan", "", ProblemInvalidParameter},
+		{"positive infinity", "lat=%2BInf&lon=13.41", "", ProblemInvalidParameter},
+		{"negative infinity", "lat=52.52&lon=-Infinity", "", ProblemInvalidParameter},
+		{"hexadecimal", "lat=0x1p-2&lon=13.41", "", ProblemInvalidParameter},
+		{"huge", "lat=1e308&lon=13.41", "", ProblemOutOfRange},
+		{"overflow", "lat=1e309&lon=13.41", "", ProblemInvalidParameter},
+		{"excessive precision", "lat=52.520000712345678&lon=13.4100004", "52.520001,13.41", ""},
+		{"negative zero", "lat=-0&lon=-0.0000001", "0,0", ""},
+		{"exponent", "lat=5.252e1&lon=1341e-2", "52.52,13.41", ""},
+		{"bounds", "lat=-90&lon=180", "-90,180", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+			require.NoError(t, err)
+
+			if tt.wantType == "" {
+				require.Equal(t, fiber.StatusOK, resp.StatusCode)
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, string(body))
+				return
+			}
+
+			require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			var body Problem
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tt.wantType, body.Type)
+			require.Len(t, body.InvalidParams, 1)
+		})
+	}
+}
+
+func TestValidateParameters_Coordinates(t *testing.T) {
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		lat, lon, days, err := validateParameters(c, 16, c.Query("normalize") != "")
+		if err != nil {
+			return validationProblem(c, err)
+		}
+		return c.SendString(fmt.Sprintf("%v,%v,%d", lat, lon, days))
+	})
+
+	tests := []struct {
+		name  string
+		query string
+		want  string
+		// wantType is the type of the problem, with wantParams the names of the invalid parameters
+		wantType   string
+		wantParams []string
+	}{
+		{"lat and lon", "lat=40.7128&lon=-74.006", "40.7128,-74.006,5", "", nil},
+		{"coords", "coords=40.7128,-74.006&days=3", "40.7128,-74.006,3", "", nil},
+		{"coords with spaces", "coords=40.7128,%20-74.006", "40.7128,-74.006,5", "", nil},
+		{"coords precision", "coords=52.520000712345678,13.4100004", "52.520001,13.41,5", "", nil},
+		{"coords and lat and lon", "coords=1,2&lat=40.7128&lon=-74.006", "", ProblemInvalidParameter, []string{"coords"}},
+		{"coords and the same lat and lon", "coords=40.7128,-74.006&lat=40.7128&lon=-74.006", "", ProblemInvalidParameter, []string{"coords"}},
+		{"coords and lat", "coords=40.7128,-74.006&lat=40.7128", "", ProblemInvalidParameter, []string{"coords"}},
+		{"coords and lon", "coords=40.7128,-74.006&lon=-74.006", "", ProblemInvalidParameter, []string{"coords"}},
+		{"empty coords", "coords=&lat=40.7128&lon=-74.006", "40.7128,-74.006,5", "", nil},
+		{"coords and invalid days", "coords=40.7128,-74.006&days=0", "", ProblemOutOfRange, []string{"days"}},
+		{"single coordinate", "coords=40.7128", "", ProblemInvalidParameter, []string{"coords"}},
+		{"missing longitude", "coords=40.7128,", "", ProblemInvalidParameter, []string{"coords"}},
+		{"three coordinates", "coords=40.7128,-74.006,10", "", ProblemInvalidParameter, []string{"coords"}},
+		{"malformed coords", "coords=north,-74.006", "", ProblemInvalidParameter, []string{"coords"}},
+		{"NaN coords", "coords=NaN,-74.006", "", ProblemInvalidParameter, []string{"coords"}},
+		{"coords out of range", "coords=91,181", "", ProblemOutOfRange, []string{"coords", "coords"}},
+		{"missing lat and lon", "days=3", "", ProblemMissingParameter, []string{"lat", "lon"}},
+		{"longitude beyond 180", "lat=40.7128&lon=200", "", ProblemOutOfRange, []string{"lon"}},
+		{"normalized longitude", "lat=40.7128&lon=200&normalize=1", "40.7128,-160,5", "", nil},
+		{"normalized negative longitude", "lat=40.7128&lon=-200&normalize=1", "40.7128,160,5", "", nil},
+		{"normalized coords", "coords=40.7128,285.994&normalize=1", "40.7128,-74.006,5", "", nil},
+		{"normalized in range", "lat=40.7128&lon=180&normalize=1", "40.7128,180,5", "", nil},
+		{"latitude not normalized", "lat=100&lon=200&normalize=1", "", ProblemOutOfRange, []string{"lat"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/?"+tt.query, nil))
+			require.NoError(t, err)
+
+			if tt.wantType == "" {
+				require.Equal(t, fiber.StatusOK, resp.StatusCode)
+				body, err := io.ReadAll(resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, string(body))
+				return
+			}
+
+			require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			var body Problem
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tt.wantType, body.Type)
+			var names []string
+			for _, p := range body.InvalidParams {
+				names = append(names, p.Name)
+			}
+			assert.Equal(t, tt.wantParams, names)
+		})
+	}
+}
+
+func TestHandleWeatherCall_ProblemDetails(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantType   string
+		wantParams []string
+	}{
+		{"missing lat and lon", "days=3", ProblemMissingParameter, []string{"lat", "lon"}},
+		{"out of range", "lat=91&lon=181", ProblemOutOfRange, []string{"lat", "lon"}},
+		{"mixed", "lon=13.41&days=17", ProblemInvalidParameter, []string{"lat", "days"}},
+		{"malformed", "lat=NaN&lon=13.41", ProblemInvalidParameter, []string{"lat"}},
+		{"units", "lat=52.52&lon=13.41&units=kelvin", ProblemInvalidParameter, []string{"units"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := newStubApp(&stubForecaster{}).Test(httptest.NewRequest(http.MethodGet, "/weather?"+tt.query, nil))
+			require.NoError(t, err)
+			require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			assert.Equal(t, problemContentType, resp.Header.Get(fiber.HeaderContentType))
+
+			var body Problem
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Equal(t, tt.wantType, body.Type)
+			assert.Equal(t, fiber.StatusBadRequest, body.Status)
+			assert.NotEmpty(t, body.Title)
+			assert.Equal(t, "/weather?"+tt.query, body.Instance)
+
+			var names []string
+			for _, p := range body.InvalidParams {
+				names = append(names, p.Name)
+				assert.NotEmpty(t, p.Reason, p.Name)
+			}
+			assert.Equal(t, tt.wantParams, names)
+		})
+	}
+}
+
+func TestHandleHistoryCall_MissingDateRange(t *testing.T) {
+	resp, err := newStubApp(&stubForecaster{}).Test(httptest.NewRequest(http.MethodGet, "/weather/history?lat=52.52&lon=13.41", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	var body Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, ProblemMissingParameter, body.Type)
+	require.Len(t, body.InvalidParams, 2)
+	assert.Equal(t, "start", body.InvalidParams[0].Name)
+	assert.Equal(t, "end", body.InvalidParams[1].Name)
+}
+
+func TestHandleWeatherCall_StubService_Error(t *testing.T) {
+	app := newStubApp(&stubForecaster{err: errors.New("boom")})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+
+	assert.Equal(t, problemContentType, resp.Header.Get(fiber.HeaderContentType))
+
+	var body Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, ProblemInternal, body.Type)
+	assert.Equal(t, "Failed to fetch weather data", body.Detail)
+	assert.Equal(t, "/weather?lat=52.52&lon=13.41", body.Instance)
+}
+
+func TestHandleCurrentCall(t *testing.T) {
+	stub := &stubForecaster{current: map[string]models.CurrentWeather{
+		"stub": {
+			RepositoryName: "stub",
+			Lat:            52.52,
+			Lon:            13.41,
+			Current: &models.CurrentConditions{
+				ObservedAt:  time.Date(2025, 7, 25, 14, 15, 0, 0, time.UTC),
+				Temperature: 20,
+			},
+		},
+		"other": {
+			RepositoryName: "other",
+			Lat:            52.52,
+			Lon:            13.41,
+			Error:          "operation not supported by provider",
+			ErrorCode:      models.ErrorCodeUnsupported,
+		},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/current?lat=52.52&lon=13.41&units=imperial", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"stub": {
+			"repository_name": "stub",
+			"lat": 52.52,
+			"lon": 13.41,
+			"units": "imperial",
+			"fetch_duration_ms": 0,
+			"current": {"observed_at": "2025-07-25T14:15:00Z", "temperature": 68}
+		},
+		"other": {
+			"repository_name": "other",
+			"lat": 52.52,
+			"lon": 13.41,
+			"units": "imperial",
+			"error": "operation not supported by provider",
+			"error_code": "unsupported",
+			"fetch_duration_ms": 0,
+			"current": null
+		}
+	}`, string(body))
+}
+
+func TestHandleCurrentCall_ValidationError(t *testing.T) {
+	stub := &stubForecaster{}
+	app := newStubApp(stub)
+
+	for _, query := range []string{"lon=13.41", "lat=52.52&lon=181", "lat=52.52&lon=13.41&units=kelvin"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/current?"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+	assert.Equal(t, 0, stub.calls)
+}
+
+func TestHandleHistoryCall(t *testing.T) {
+	start, err := models.ParseDate("2024-01-01")
+	require.NoError(t, err)
+
+	stub := &stubForecaster{history: map[string]models.HistoricalWeather{
+		"stub": {
+			RepositoryName: "stub",
+			Lat:            52.52,
+			Lon:            13.41,
+			Start:          start,
+			End:            start,
+			WeatherData:    []models.WeatherData{{Date: start, TempMax: 4.2, TempMin: -1.3}},
+		},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/history?lat=52.52&lon=13.41&start=2024-01-01&end=2024-01-01", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"stub": {
+			"repository_name": "stub",
+			"lat": 52.52,
+			"lon": 13.41,
+			"start": "2024-01-01",
+			"end": "2024-01-01",
+			"units": "metric",
+			"fetch_duration_ms": 0,
+			"weather_data": [{"date": "2024-01-01", "temp_max": 4.2, "temp_min": -1.3}]
+		}
+	}`, string(body))
+}
+
+func TestHandleHistoryCall_ValidationError(t *testing.T) {
+	stub := &stubForecaster{}
+	app := newStubApp(stub)
+
+	tomorrow := time.Now().UTC().AddDate(0, 0, 1).Format(models.DateLayout)
+	for _, query := range []string{
+		"lat=52.52&lon=13.41&end=2024-01-31",
+		"lat=52.52&lon=13.41&start=2024-01-01",
+		"lat=52.52&lon=13.41&start=01/01/2024&end=2024-01-31",
+		"lat=52.52&lon=13.41&start=2024-01-31&end=2024-01-01",
+		"lat=52.52&lon=13.41&start=2024-01-01&end=" + tomorrow,
+		"lat=52.52&lon=13.41&start=2024-01-01&end=2024-02-01",
+		"lat=91&lon=13.41&start=2024-01-01&end=2024-01-31",
+	} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/history?"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+	assert.Equal(t, 0, stub.calls)
+}
+
+func TestHandleHistoryCall_NoHistoricalProviders(t *testing.T) {
+	app := newStubApp(&stubForecaster{err: weather.ErrNoHistoricalProviders})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/history?lat=52.52&lon=13.41&start=2024-01-01&end=2024-01-31", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	var body Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, ProblemUnprocessable, body.Type)
+	assert.Contains(t, body.Detail, "historical weather")
+}
+
+func TestHandleBatchCall(t *testing.T) {
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{
+		"stub": {RepositoryName: "stub", ForecastData: []models.WeatherData{}},
+	}}
+	app := newStubApp(stub)
+
+	req := httptest.NewRequest(http.MethodPost, "/weather/batch",
+		strings.NewReader(`[{"lat": 52.52, "lon": 13.41, "days": 2}, {"lat": 91, "lon": 0}, {"lat": 40.71, "lon": -74.01}]`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, stub.calls)
+
+	var results []BatchResultItem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&results))
+	require.Len(t, results, 3)
+
+	assert.Equal(t, 52.52, results[0].Forecasts["stub"].Lat)
+	assert.Equal(t, 2, results[0].Forecasts["stub"].ForecastWindow)
+
+	assert.Contains(t, results[1].Error, "latitude must be between")
+	assert.Nil(t, results[1].Forecasts)
+
+	assert.Empty(t, results[2].Error)
+	assert.Equal(t, 40.71, results[2].Forecasts["stub"].Lat)
+	assert.Equal(t, 5, results[2].Days)
+}
+
+func TestHandleBatchCall_Coords(t *testing.T) {
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{
+		"stub": {RepositoryName: "stub", ForecastData: []models.WeatherData{}},
+	}}
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	NewRouter(app, stub, newStubGeocoder(), l, WithLongitudeNormalization(true))
+
+	req := httptest.NewRequest(http.MethodPost, "/weather/batch", strings.NewReader(`[
+		{"coords": "40.7128,-74.006"},
+		{"lat": 40.7128, "lon": 285.994},
+		{"coords": "40.7128", "lat": 40.7128}
+	]`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var results []BatchResultItem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&results))
+	require.Len(t, results, 3)
+
+	// The coordinates of coords are reported as lat and lon
+	assert.Empty(t, results[0].Error)
+	require.NotNil(t, results[0].Lat)
+	assert.Equal(t, 40.7128, *results[0].Lat)
+	assert.Equal(t, -74.006, *results[0].Lon)
+
+	assert.Empty(t, results[1].Error)
+	require.NotNil(t, results[1].Forecasts)
+
+	assert.Contains(t, results[2].Error, "coords can't be combined with lat and lon")
+	assert.Nil(t, results[2].Forecasts)
+}
+
+func TestHandleBatchCall_InvalidBatch(t *testing.T) {
+	stub := &stubForecaster{}
+	app := newStubApp(stub)
+
+	for _, body := range []string{
+		`{"lat": 52.52, "lon": 13.41}`,
+		`[{"lat": 52.52, "lon": 13.41}`,
+		`[{"lat": "north", "lon": 13.41}]`,
+		`[]`,
+		`[{"lat": 1, "lon": 1}, {"lat": 2, "lon": 2}, {"lat": 3, "lon": 3}, {"lat": 4, "lon": 4}]`,
+	} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodPost, "/weather/batch", strings.NewReader(body)))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, body)
+	}
+	assert.Equal(t, 0, stub.calls)
+}
+
+func TestHandleWeatherCall_City(t *testing.T) {
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{
+		"stub": {RepositoryName: "stub", ForecastData: []models.WeatherData{}},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?city=Berlin&days=2", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, stub.calls)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/weather?city=Springfield", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusMultipleChoices, resp.StatusCode)
+
+	var ambiguous AmbiguousCityProblem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&ambiguous))
+	assert.Equal(t, ProblemAmbiguousLocation, ambiguous.Type)
+	assert.Len(t, ambiguous.Candidates, 2)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/weather?city=Atlantis", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+
+	for _, query := range []string{"city=Berlin&lat=52.52&lon=13.41", "city=Berlin&lon=13.41", "city=Berlin&coords=52.52,13.41", "city=Berlin&country=DEU"} {
+		resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/weather?"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+	assert.Equal(t, 1, stub.calls)
+}
+
+func TestHandleGeocodeCall(t *testing.T) {
+	geocoder := newStubGeocoder()
+	app := newStubGeocoderApp(&stubForecaster{}, geocoder)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/geocode?q=Springfield&limit=1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"query": "Springfield",
+		"results": [{"name": "Springfield", "admin1": "Illinois", "country_code": "US", "lat": 39.8, "lon": -89.64}]
+	}`, string(body))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/geocode?q=Atlantis", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var empty GeocodeResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&empty))
+	assert.Empty(t, empty.Results)
+	assert.NotNil(t, empty.Results)
+}
+
+func TestHandleGeocodeCall_ValidationError(t *testing.T) {
+	geocoder := newStubGeocoder()
+	app := newStubGeocoderApp(&stubForecaster{}, geocoder)
+
+	for _, query := range []string{"", "q=v", "q=" + strings.Repeat("v", 101), "q=venice&limit=0", "q=venice&limit=11", "q=venice&limit=five", "q=venice&country=ITA"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/geocode?"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+	assert.Equal(t, 0, geocoder.searches)
+}
+
+func TestHandleGeocodeCall_GeocoderError(t *testing.T) {
+	app := newStubGeocoderApp(&stubForecaster{}, &stubGeocoder{err: errors.New("geocoding down")})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/geocode?q=venice", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadGateway, resp.StatusCode)
+}
+
+func TestHandleWeatherCall_ResolveName(t *testing.T) {
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{
+		"stub": {RepositoryName: "stub", ForecastData: []models.WeatherData{}},
+	}}
+
+	geocoder := newStubGeocoder()
+	geocoder.reversed = &models.Place{Name: "Berlin", Country: "Germany", Admin1: "Berlin", CountryCode: "DE"}
+	app := newStubGeocoderApp(stub, geocoder)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&resolve_name=true", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body map[string]json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Contains(t, body, "stub")
+	assert.JSONEq(t, `{"name": "Berlin", "country": "Germany", "admin1": "Berlin"}`, string(body["location"]))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body = nil
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.NotContains(t, body, "location")
+	assert.Equal(t, int32(1), geocoder.reverses.Load(), "the place name must only be looked up on request")
+}
+
+func TestHandleWeatherCall_ResolveNameFailure(t *testing.T) {
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{
+		"stub": {RepositoryName: "stub", ForecastData: []models.WeatherData{}},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&resolve_name=true", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body map[string]json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Contains(t, body, "stub")
+	assert.NotContains(t, body, "location")
+}
+
+func TestHandleWeatherCall_ResolveNameCity(t *testing.T) {
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{
+		"stub": {RepositoryName: "stub", ForecastData: []models.WeatherData{}},
+	}}
+	geocoder := newStubGeocoder()
+	app := newStubGeocoderApp(stub, geocoder)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?city=Berlin&resolve_name=true", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body map[string]json.RawMessage
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.JSONEq(t, `{"name": "Berlin"}`, string(body["location"]))
+	assert.Equal(t, int32(0), geocoder.reverses.Load(), "a city is already named")
+}
+
+type stubLocator struct {
+	// places are the locatable addresses, any other address is unlocatable
+	places map[string]models.Place
+	err    error
+	ips    []string
+}
+
+func (s *stubLocator) Locate(ctx context.Context, ip netip.Addr) (models.Place, error) {
+	s.ips = append(s.ips, ip.String())
+	if s.err != nil {
+		return models.Place{}, s.err
+	}
+
+	place, ok := s.places[ip.String()]
+	if !ok {
+		return models.Place{}, repositories.ErrUnlocatableIP
+	}
+
+	return place, nil
+}
+
+func newStubLocatorApp(service Forecaster, locator repositories.IPLocator, trustedProxy bool) *fiber.App {
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	NewRouter(app, service, newStubGeocoder(), l, WithIPLocator(locator), WithTrustedProxy(trustedProxy))
+
+	return app
+}
+
+func TestHandleWeatherCall_IPLocationDisabled(t *testing.T) {
+	stub := &stubForecaster{}
+	app := newStubApp(stub)
+
+	req := httptest.NewRequest(http.MethodGet, "/weather", nil)
+	req.Header.Set(fiber.HeaderXForwardedFor, "203.0.113.7")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+	assert.Zero(t, stub.calls)
+}
+
+func TestHandleWeatherCall_IPLocation(t *testing.T) {
+	// app.Test connects from 0.0.0.0, which can't be located
+	tests := []struct {
+		name         string
+		trustedProxy bool
+		forwardedFor string
+		wantIP       string
+		wantStatus   int
+	}{
+		{"direct", false, "", "0.0.0.0", fiber.StatusUnprocessableEntity},
+		{"spoofed header without proxy", false, "203.0.113.7", "0.0.0.0", fiber.StatusUnprocessableEntity},
+		{"trusted proxy", true, "203.0.113.7", "203.0.113.7", fiber.StatusOK},
+		{"spoofed entry before the proxy one", true, "198.51.100.1, 203.0.113.7", "203.0.113.7", fiber.StatusOK},
+		{"private address from the proxy", true, "203.0.113.7, 10.0.0.1", "10.0.0.1", fiber.StatusUnprocessableEntity},
+		{"trusted proxy without header", true, "", "0.0.0.0", fiber.StatusUnprocessableEntity},
+		{"malformed header", true, "not-an-ip", "invalid IP", fiber.StatusUnprocessableEntity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stub := &stubForecaster{forecasts: map[string]models.Forecast{"stub": {RepositoryName: "stub"}}}
+			locator := &stubLocator{places: map[string]models.Place{
+				"203.0.113.7": {Name: "Berlin", Country: "Germany", Lat: 52.52, Lon: 13.41},
+			}}
+			app := newStubLocatorApp(stub, locator, tt.trustedProxy)
+
+			req := httptest.NewRequest(http.MethodGet, "/weather?days=1&resolve_name=true", nil)
+			if tt.forwardedFor != "" {
+				req.Header.Set(fiber.HeaderXForwardedFor, tt.forwardedFor)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, []string{tt.wantIP}, locator.ips)
+
+			var body map[string]json.RawMessage
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			if tt.wantStatus == fiber.StatusOK {
+				assert.Equal(t, 1, stub.calls)
+				assert.JSONEq(t, `{"name": "Berlin", "country": "Germany"}`, string(body["location"]))
+			} else {
+				assert.Zero(t, stub.calls)
+				assert.Contains(t, string(body["detail"]), "can't be located")
+			}
+		})
+	}
+}
+
+func TestHandleWeatherCall_IPLocationSkippedWithCoordinates(t *testing.T) {
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{"stub": {RepositoryName: "stub"}}}
+	locator := &stubLocator{}
+	app := newStubLocatorApp(stub, locator, true)
+
+	for _, target := range []string{"/weather?lat=52.52&lon=13.41", "/weather?city=Berlin", "/weather?lat=52.52"} {
+		_, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+		require.NoError(t, err)
+	}
+
+	assert.Empty(t, locator.ips)
+}
+
+func TestHandleWeatherCall_IPLocationFailure(t *testing.T) {
+	stub := &stubForecaster{}
+	app := newStubLocatorApp(stub, &stubLocator{err: errors.New("connection refused")}, true)
+
+	req := httptest.NewRequest(http.MethodGet, "/weather", nil)
+	req.Header.Set(fiber.HeaderXForwardedFor, "203.0.113.7")
+
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadGateway, resp.StatusCode)
+	assert.Zero(t, stub.calls)
+}
+
+func TestHandleAirQualityCall(t *testing.T) {
+	date, err := models.ParseDate("2025-07-25")
+	require.NoError(t, err)
+
+	pm25, aqi := 8.4, 42
+	stub := &stubForecaster{airQuality: &models.AirQuality{
+		RepositoryName: "open-meteo-air-quality",
+		Lat:            52.52,
+		Lon:            13.41,
+		ForecastWindow: 1,
+		AirQualityData: []models.AirQualityData{{Date: date, PM25: &pm25, AQI: &aqi, AQICategory: models.AQIGood}},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/air-quality?lat=52.52&lon=13.41&days=1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"repository_name": "open-meteo-air-quality",
+		"lat": 52.52,
+		"lon": 13.41,
+		"forecast_window": 1,
+		"fetch_duration_ms": 0,
+		"air_quality_data": [
+			{"date": "2025-07-25", "pm2_5": 8.4, "pm10": null, "ozone": null, "aqi": 42, "aqi_category": "good"}
+		]
+	}`, string(body))
+}
+
+func TestHandleAirQualityCall_ValidationError(t *testing.T) {
+	stub := &stubForecaster{airQuality: &models.AirQuality{}}
+	app := newStubApp(stub)
+
+	for _, query := range []string{"lon=13.41", "lat=91&lon=13.41", "lat=52.52&lon=13.41&days=0"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/air-quality?"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+	assert.Equal(t, 0, stub.calls)
+}
+
+func TestHandleAirQualityCall_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		stub       *stubForecaster
+		wantStatus int
+	}{
+		{"not configured", &stubForecaster{}, fiber.StatusUnprocessableEntity},
+		{"provider failure", &stubForecaster{airQuality: &models.AirQuality{Error: "provider timed out", ErrorCode: models.ErrorCodeTimeout}}, fiber.StatusBadGateway},
+		{"budget exceeded", &stubForecaster{airQuality: &models.AirQuality{}, err: context.DeadlineExceeded}, fiber.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := newStubApp(tt.stub).Test(httptest.NewRequest(http.MethodGet, "/air-quality?lat=52.52&lon=13.41", nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestHandleAlertsCall(t *testing.T) {
+	stub := &stubForecaster{alerts: &models.AlertReport{
+		Lat:       40.71,
+		Lon:       -74.01,
+		Providers: []string{"nws"},
+		Alerts: []models.Alert{{
+			Source:   "nws",
+			Event:    "Heat Advisory",
+			Severity: models.AlertSeverityModerate,
+			Onset:    time.Date(2025, 7, 25, 16, 0, 0, 0, time.UTC),
+		}},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/alerts?lat=40.71&lon=-74.01", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"lat": 40.71,
+		"lon": -74.01,
+		"providers": ["nws"],
+		"alerts": [{"source": "nws", "event": "Heat Advisory", "severity": "moderate", "onset": "2025-07-25T16:00:00Z"}]
+	}`, string(body))
+}
+
+func TestHandleAlertsCall_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		stub       *stubForecaster
+		wantStatus int
+	}{
+		{"missing lon", "lat=40.71", &stubForecaster{}, fiber.StatusBadRequest},
+		{"no alert provider", "lat=40.71&lon=-74.01", &stubForecaster{}, fiber.StatusUnprocessableEntity},
+		{"all providers failed", "lat=40.71&lon=-74.01", &stubForecaster{alerts: &models.AlertReport{}, err: weather.ErrAlertsFailed}, fiber.StatusBadGateway},
+		{"all providers rate limited", "lat=40.71&lon=-74.01", &stubForecaster{alerts: &models.AlertReport{Failures: []models.ProviderFailure{
+			{Provider: "nws", ErrorCode: models.ErrorCodeRateLimited},
+			{Provider: "weatherapi", ErrorCode: models.ErrorCodeRateLimited},
+		}}, err: weather.ErrAlertsFailed}, fiber.StatusTooManyRequests},
+		{"budget exceeded", "lat=40.71&lon=-74.01", &stubForecaster{alerts: &models.AlertReport{}, err: context.DeadlineExceeded}, fiber.StatusGatewayTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := newStubApp(tt.stub).Test(httptest.NewRequest(http.MethodGet, "/weather/alerts?"+tt.query, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestHandleMarineCall(t *testing.T) {
+	date, err := models.ParseDate("2025-07-25")
+	require.NoError(t, err)
+
+	height, temperature := 1.2, 19.8
+	stub := &stubForecaster{marine: &models.MarineForecast{
+		RepositoryName: "open-meteo-marine",
+		Lat:            43.29,
+		Lon:            5.37,
+		ForecastWindow: 1,
+		MarineData:     []models.MarineData{{Date: date, WaveHeightMax: &height, SeaSurfaceTemperature: &temperature}},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/marine?lat=43.29&lon=5.37&days=1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"repository_name": "open-meteo-marine",
+		"lat": 43.29,
+		"lon": 5.37,
+		"forecast_window": 1,
+		"fetch_duration_ms": 0,
+		"marine_data": [
+			{"date": "2025-07-25", "wave_height_max": 1.2, "wave_period_max": null, "wave_direction": null, "sea_surface_temperature": 19.8}
+		]
+	}`, string(body))
+}
+
+func TestHandleStoredCall(t *testing.T) {
+	date, err := models.ParseDate("2025-07-25")
+	require.NoError(t, err)
+
+	fetchedAt := time.Date(2025, 7, 23, 12, 0, 0, 0, time.UTC)
+	stub := &stubForecaster{stored: []models.StoredForecast{
+		{Provider: "open-meteo", Lat: 52.52, Lon: 13.405, Date: date, TempMin: 14, TempMax: 25, FetchedAt: fetchedAt},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/stored?lat=52.52&lon=13.405&date=2025-07-25", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, date, stub.date)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"provider": "open-meteo",
+		"lat": 52.52,
+		"lon": 13.405,
+		"date": "2025-07-25",
+		"temp_min": 14,
+		"temp_max": 25,
+		"fetched_at": "2025-07-23T12:00:00Z"
+	}]`, string(body))
+
+	// Without a store
+	resp, err = newStubApp(&stubForecaster{}).Test(httptest.NewRequest(http.MethodGet, "/weather/stored?lat=52.52&lon=13.405&date=2025-07-25", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	for _, target := range []string{
+		"/weather/stored?lat=52.52&lon=13.405",
+		"/weather/stored?lat=52.52&lon=13.405&date=25-07-2025",
+		"/weather/stored?lat=91&lon=13.405&date=2025-07-25",
+	} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, target)
+	}
+}
+
+func TestHandleCompareCall(t *testing.T) {
+	date := models.NewDate(time.Date(2025, 7, 26, 0, 0, 0, 0, time.UTC))
+	stub := &stubForecaster{comparison: &models.ForecastComparison{
+		Lat:            40.7128,
+		Lon:            -74.006,
+		ForecastWindow: 2,
+		Threshold:      3,
+		Providers:      []string{"open-meteo", "weatherapi"},
+		Days: []models.DayComparison{{
+			Date: date,
+			Providers: map[string]models.ProviderTemperatures{
+				"open-meteo": {TempMax: 30, TempMin: 20},
+				"weatherapi": {TempMax: 35, TempMin: 20},
+			},
+			Disagreement: 5,
+			Pair:         []string{"open-meteo", "weatherapi"},
+			Flagged:      true,
+		}},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/compare?lat=40.7128&lon=-74.006&days=2&units=imperial", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"lat": 40.7128,
+		"lon": -74.006,
+		"forecast_window": 2,
+		"units": "imperial",
+		"threshold": 5.4,
+		"providers": ["open-meteo", "weatherapi"],
+		"days": [{
+			"date": "2025-07-26",
+			"providers": {"open-meteo": {"temp_max": 86, "temp_min": 68}, "weatherapi": {"temp_max": 95, "temp_min": 68}},
+			"disagreement": 9,
+			"pair": ["open-meteo", "weatherapi"],
+			"flagged": true
+		}]
+	}`, string(body))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/weather/compare?lat=40.7128", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp, err = newStubApp(&stubForecaster{err: weather.ErrNoForecasts}).Test(
+		httptest.NewRequest(http.MethodGet, "/weather/compare?lat=40.7128&lon=-74.006", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadGateway, resp.StatusCode)
+}
+
+func TestHandleAccuracyCall(t *testing.T) {
+	since, err := models.ParseDate("2025-06-25")
+	require.NoError(t, err)
+
+	stub := &stubForecaster{accuracy: &models.AccuracyReport{
+		Lat:   52.52,
+		Lon:   13.405,
+		Since: since,
+		Providers: []models.ProviderAccuracy{
+			{Provider: "open-meteo", LeadDays: 1, Days: 28, MAE: 1.25, MAETempMin: 1, MAETempMax: 1.5},
+		},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/providers/accuracy?lat=52.52&lon=13.405", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"lat": 52.52,
+		"lon": 13.405,
+		"since": "2025-06-25",
+		"providers": [{"provider": "open-meteo", "lead_days": 1, "days": 28, "mae": 1.25, "mae_temp_min": 1, "mae_temp_max": 1.5}]
+	}`, string(body))
+
+	// Without a store
+	resp, err = newStubApp(&stubForecaster{}).Test(httptest.NewRequest(http.MethodGet, "/providers/accuracy?lat=52.52&lon=13.405", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/providers/accuracy?lat=52.52", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+}
+
+func TestHandleSubscriptions(t *testing.T) {
+	stub := &stubForecaster{subscriptions: map[string]models.Subscription{}}
+	app := newStubApp(stub)
+	post := func(body string) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodPost, "/subscriptions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := post(`{"lat": 40.7128, "lon": -74.006, "condition": {"field": "temp_max", "op": "gte", "value": 35},
+		"webhook_url": "https://example.com/hooks/weather", "check_interval": "15m"}`)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	var created models.Subscription
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.Equal(t, "sub1", created.ID)
+	assert.Equal(t, "secret", created.Secret)
+	assert.Equal(t, models.SubscriptionCondition{Field: "temp_max", Op: "gte", Value: 35, Days: 3}, created.Condition)
+	assert.Equal(t, 900, created.CheckIntervalSeconds)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/subscriptions/sub1", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var got models.Subscription
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Empty(t, got.Secret)
+	assert.Equal(t, "https://example.com/hooks/weather", got.WebhookURL)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var all []models.Subscription
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&all))
+	assert.Len(t, all, 1)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodDelete, "/subscriptions/sub1", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		resp, err = app.Test(httptest.NewRequest(method, "/subscriptions/sub1", nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode, method)
+	}
+
+	// The check interval defaults to 30 minutes
+	resp = post(`{"lat": 40.7128, "lon": -74.006, "condition": {"field": "temp_min", "op": "lt", "value": 0, "days": 5},
+		"webhook_url": "http://example.com/hooks/weather"}`)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.Equal(t, 1800, created.CheckIntervalSeconds)
+
+	calls := stub.calls
+	for _, body := range []string{
+		`[]`,
+		`{"lon": -74.006, "condition": {"field": "temp_max", "op": "gte", "value": 35}, "webhook_url": "https://example.com"}`,
+		`{"lat": 91, "lon": -74.006, "condition": {"field": "temp_max", "op": "gte", "value": 35}, "webhook_url": "https://example.com"}`,
+		`{"lat": 40.7128, "lon": -74.006, "webhook_url": "https://example.com"}`,
+		`{"lat": 40.7128, "lon": -74.006, "condition": {"field": "humidity", "op": "gte", "value": 35}, "webhook_url": "https://example.com"}`,
+		`{"lat": 40.7128, "lon": -74.006, "condition": {"field": "temp_max", "op": "gte", "value": 35, "days": 17}, "webhook_url": "https://example.com"}`,
+		`{"lat": 40.7128, "lon": -74.006, "condition": {"field": "temp_max", "op": "gte", "value": 35}}`,
+		`{"lat": 40.7128, "lon": -74.006, "condition": {"field": "temp_max", "op": "gte", "value": 35}, "webhook_url": "ftp://example.com"}`,
+		`{"lat": 40.7128, "lon": -74.006, "condition": {"field": "temp_max", "op": "gte", "value": 35}, "webhook_url": "https://example.com", "check_interval": "1m"}`,
+		`{"lat": 40.7128, "lon": -74.006, "condition": {"field": "temp_max", "op": "gte", "value": 35}, "webhook_url": "https://example.com", "check_interval": "often"}`,
+	} {
+		resp := post(body)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, body)
+	}
+	assert.Equal(t, calls, stub.calls, "an invalid subscription doesn't reach the service")
+
+	// Without a store
+	resp, err = newStubApp(&stubForecaster{}).Test(httptest.NewRequest(http.MethodGet, "/subscriptions", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode)
+}
+
+func TestHandleSubscriptions_Owner(t *testing.T) {
+	hash := func(key string) string {
+		sum := sha256.Sum256([]byte(key))
+		return hex.EncodeToString(sum[:])
+	}
+	stub := &stubForecaster{subscriptions: map[string]models.Subscription{}}
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	app.Use(httpserver.APIKeyAuth(httpserver.APIKeyAuthConfig{Keys: map[string]string{
+		hash("alice-key"): "alice",
+		hash("bob-key"):   "bob",
+	}}, l))
+	NewRouter(app, stub, newStubGeocoder(), l)
+	call := func(method, target, key, body string) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(method, target, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(httpserver.HeaderAPIKey, key)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+	list := func(key string) []models.Subscription {
+		t.Helper()
+		resp := call(http.MethodGet, "/subscriptions", key, "")
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		var subs []models.Subscription
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&subs))
+		return subs
+	}
+
+	resp := call(http.MethodPost, "/subscriptions", "alice-key", `{"lat": 40.7128, "lon": -74.006,
+		"condition": {"field": "temp_max", "op": "gte", "value": 35}, "webhook_url": "https://example.com/hooks/weather"}`)
+	require.Equal(t, fiber.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "alice", stub.subscriptions["sub1"].Owner)
+
+	// The subscription is unknown to the other keys
+	assert.Empty(t, list("bob-key"))
+	assert.Equal(t, fiber.StatusNotFound, call(http.MethodGet, "/subscriptions/sub1", "bob-key", "").StatusCode)
+	assert.Equal(t, fiber.StatusNotFound, call(http.MethodDelete, "/subscriptions/sub1", "bob-key", "").StatusCode)
+	assert.Contains(t, stub.subscriptions, "sub1")
+
+	assert.Len(t, list("alice-key"), 1)
+	assert.Equal(t, fiber.StatusOK, call(http.MethodGet, "/subscriptions/sub1", "alice-key", "").StatusCode)
+	assert.Equal(t, fiber.StatusNoContent, call(http.MethodDelete, "/subscriptions/sub1", "alice-key", "").StatusCode)
+	assert.Empty(t, stub.subscriptions)
+}
+
+func TestHandleProvidersCall(t *testing.T) {
+	checkedAt := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	stub := &stubForecaster{providers: []models.ProviderStatus{{
+		Name:          "weatherapi",
+		RequiresKey:   true,
+		Health:        &models.ProviderHealth{Healthy: false, ErrorCode: models.ErrorCodeRateLimited, CheckedAt: checkedAt, DurationMS: 120},
+		Circuit:       models.CircuitNone,
+		Calls:         4,
+		ErrorRate:     0.25,
+		AvgLatencyMS:  150.5,
+		LastErrorCode: models.ErrorCodeRateLimited,
+		RateLimited:   true,
+	}}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/providers", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{
+		"name": "weatherapi",
+		"requires_key": true,
+		"health": {"healthy": false, "error_code": "rate_limited", "checked_at": "2025-07-25T12:00:00Z", "duration_ms": 120},
+		"circuit": "none",
+		"calls": 4,
+		"error_rate": 0.25,
+		"avg_latency_ms": 150.5,
+		"last_error_code": "rate_limited",
+		"rate_limited": true
+	}]`, string(body))
+}
+
+func TestHandleMarineCall_Errors(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		stub       *stubForecaster
+		wantStatus int
+		wantError  string
+	}{
+		{"invalid coordinates", "lat=43.29&lon=190", &stubForecaster{marine: &models.MarineForecast{}}, fiber.StatusBadRequest, "longitude"},
+		{"inland", "lat=48.85&lon=2.35", &stubForecaster{marine: &models.MarineForecast{}, marineErr: repositories.ErrInlandPoint}, fiber.StatusUnprocessableEntity, "inland"},
+		{"disabled", "lat=43.29&lon=5.37", &stubForecaster{}, fiber.StatusUnprocessableEntity, "not available"},
+		{"provider failure", "lat=43.29&lon=5.37", &stubForecaster{marine: &models.MarineForecast{Error: "provider timed out", ErrorCode: models.ErrorCodeTimeout}}, fiber.StatusBadGateway, "timed out"},
+		{"provider rate limited", "lat=43.29&lon=5.37", &stubForecaster{marine: &models.MarineForecast{Error: "rate limit exceeded", ErrorCode: models.ErrorCodeRateLimited}}, fiber.StatusTooManyRequests, "rate limit"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := newStubApp(tt.stub).Test(httptest.NewRequest(http.MethodGet, "/weather/marine?"+tt.query, nil))
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+
+			var body Problem
+			require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+			assert.Contains(t, body.Detail, tt.wantError)
+		})
+	}
+}
+
+func csvForecasts() map[string]models.Forecast {
+	precipitation, probability := 1.2, 40.0
+	sunrise := time.Date(2025, 7, 25, 5, 21, 0, 0, time.FixedZone("CEST", 2*60*60))
+
+	return map[string]models.Forecast{
+		"open-meteo": {RepositoryName: "open-meteo", ForecastData: []models.WeatherData{
+			{Date: models.NewDate(time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)), TempMax: 28.4, TempMin: 16.1,
+				PrecipitationSum: &precipitation, PrecipitationProbability: &probability, Sunrise: &sunrise, Condition: models.Condition("rain")},
+			{Date: models.NewDate(time.Date(2025, 7, 26, 0, 0, 0, 0, time.UTC)), TempMax: 30, TempMin: 17.5},
+		}},
+		// a comma in the provider name is quoted
+		"stub, eu": {RepositoryName: "stub, eu", ForecastData: []models.WeatherData{
+			{Date: models.NewDate(time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)), TempMax: 27.9, TempMin: 15.8},
+		}},
+		"weatherapi": {RepositoryName: "weatherapi", Error: "provider timed out", ErrorCode: models.ErrorCodeTimeout},
+	}
+}
+
+func TestHandleWeatherCall_CSV(t *testing.T) {
+	app := newStubApp(&stubForecaster{forecasts: csvForecasts()})
+
+	byFormat := httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=2&format=csv", nil)
+	byAccept := httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=2", nil)
+	byAccept.Header.Set(fiber.HeaderAccept, "text/csv")
+
+	for _, req := range []*http.Request{byFormat, byAccept} {
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "text/csv", resp.Header.Get(fiber.HeaderContentType))
+
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assertGolden(t, "weather.csv", body)
+	}
+}
+
+func TestHandleWeatherCall_ETag(t *testing.T) {
+	app := newStubApp(&stubForecaster{forecasts: csvForecasts()})
+
+	get := func(query, ifNoneMatch string) *http.Response {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=2"+query, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set(fiber.HeaderIfNoneMatch, ifNoneMatch)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	resp := get("", "")
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	etag := resp.Header.Get(fiber.HeaderETag)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, etag)
+	assert.Equal(t, "public, max-age=60", resp.Header.Get(fiber.HeaderCacheControl))
+	assert.Contains(t, resp.Header.Get(fiber.HeaderVary), fiber.HeaderAccept)
+	assert.Equal(t, etag, get("", "").Header.Get(fiber.HeaderETag), "the ETag is stable")
+
+	t.Run("match", func(t *testing.T) {
+		for _, ifNoneMatch := range []string{etag, "W/" + etag, `"other", ` + etag, "*"} {
+			resp := get("", ifNoneMatch)
+			assert.Equal(t, fiber.StatusNotModified, resp.StatusCode, ifNoneMatch)
+			assert.Equal(t, etag, resp.Header.Get(fiber.HeaderETag))
+			assert.Equal(t, "public, max-age=60", resp.Header.Get(fiber.HeaderCacheControl))
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Empty(t, body)
+		}
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		resp := get("", `"0123456789abcdef0123456789abcdef"`)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.NotEmpty(t, body)
+	})
+
+	t.Run("format", func(t *testing.T) {
+		csvETag := get("&format=csv", "").Header.Get(fiber.HeaderETag)
+		xmlETag := get("&format=xml", "").Header.Get(fiber.HeaderETag)
+		assert.NotEqual(t, etag, csvETag)
+		assert.NotEqual(t, etag, xmlETag)
+		assert.NotEqual(t, csvETag, xmlETag)
+
+		// The JSON ETag doesn't validate the CSV representation
+		assert.Equal(t, fiber.StatusOK, get("&format=csv", etag).StatusCode)
+		assert.Equal(t, fiber.StatusNotModified, get("&format=csv", csvETag).StatusCode)
+	})
+}
+
+func TestHandleWeatherCall_ETagErrors(t *testing.T) {
+	app := newStubApp(&stubForecaster{err: context.DeadlineExceeded})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusGatewayTimeout, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(fiber.HeaderETag))
+	assert.Empty(t, resp.Header.Get(fiber.HeaderCacheControl))
+}
+
+func TestHandleAggregateCall_CSV(t *testing.T) {
+	app := newStubApp(&stubForecaster{aggregated: &models.AggregatedForecast{
+		Providers: []string{"open-meteo", "weatherapi"},
+		ForecastData: []models.AggregatedWeatherData{
+			{Date: models.NewDate(time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)), TempMax: 28.2, TempMin: 16, Spread: 0.5, ProviderCount: 2},
+			{Date: models.NewDate(time.Date(2025, 7, 26, 0, 0, 0, 0, time.UTC)), TempMax: 30.1, TempMin: 17.4, Spread: 1.1, ProviderCount: 2},
+		},
+	}})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/aggregate?lat=52.52&lon=13.41&days=2&format=csv", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/csv", resp.Header.Get(fiber.HeaderContentType))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assertGolden(t, "aggregate.csv", body)
+}
+
+func TestHandleWeatherCall_Envelope(t *testing.T) {
+	forecasts := csvForecasts()
+	cached := forecasts["stub, eu"]
+	cached.Cached = true
+	forecasts["stub, eu"] = cached
+	app := newStubApp(&stubForecaster{forecasts: forecasts})
+
+	req := httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&envelope=true&units=imperial&fields=temp_max,date", nil)
+	req.Header.Set(requestid.Header, "3f2b8c0e9a4d4f5e")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Empty(t, resp.Header.Get(fiber.HeaderETag))
+
+	var body struct {
+		Meta ResponseMeta `json:"meta"`
+		Data []struct {
+			RepositoryName string           `json:"repository_name"`
+			ErrorCode      string           `json:"error_code"`
+			ForecastData   []map[string]any `json:"forecast_data"`
+		} `json:"data"`
+	}
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+
+	assert.Equal(t, "3f2b8c0e9a4d4f5e", body.Meta.RequestID)
+	assert.GreaterOrEqual(t, body.Meta.DurationMS, int64(0))
+	assert.Equal(t, ProviderCounts{Queried: 3, Succeeded: 2, Failed: 1}, body.Meta.Providers)
+	assert.Equal(t, 1, body.Meta.CacheHits)
+	assert.Nil(t, body.Meta.Location)
+	assert.Equal(t, EffectiveParameters{
+		Lat: 52.52, Lon: 13.41, Days: defaultForecastWindow, Units: "imperial", Mode: modeAll,
+		Providers: []string{"stub"}, Fields: []string{"date", "temp_max"},
+	}, body.Meta.Parameters)
+
+	require.Len(t, body.Data, 3)
+	assert.Equal(t, "open-meteo", body.Data[0].RepositoryName)
+	assert.Equal(t, map[string]any{"date": "2025-07-25", "temp_max": 83.1}, body.Data[0].ForecastData[0])
+	assert.Equal(t, "stub, eu", body.Data[1].RepositoryName)
+	assert.Equal(t, models.ErrorCodeTimeout, body.Data[2].ErrorCode)
+}
+
+func TestHandleWeatherCall_EnvelopeFirst(t *testing.T) {
+	app := newStubApp(&stubForecaster{forecasts: map[string]models.Forecast{"stub": {RepositoryName: "stub", ForecastData: []models.WeatherData{}}}})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&envelope=true&mode=first&date=2025-07-25", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&envelope=true&mode=first", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var body Envelope
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	assert.Equal(t, ProviderCounts{Queried: 1, Succeeded: 1}, body.Meta.Providers)
+	assert.Equal(t, modeFirst, body.Meta.Parameters.Mode)
+	require.Len(t, body.Data, 1)
+
+	for _, query := range []string{"&format=csv", "&format=xml", "&stream=true"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&envelope=true"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+}
+
+func TestMsgPack_Parity(t *testing.T) {
+	aggregate := aggregateBody{AggregatedForecast: models.AggregatedForecast{Strategy: "median", Providers: []string{"open-meteo"}, ForecastData: []models.AggregatedWeatherData{
+		{Date: models.NewDate(time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)), TempMax: 28.2, TempMin: 16, Spread: 0.5, ProviderCount: 2},
+	}}}
+
+	for _, body := range []any{forecastsBody{forecasts: csvForecasts()}, aggregate} {
+		var jsonBody, msgpackBody bytes.Buffer
+		require.NoError(t, jsonEncoder{}.encode(&jsonBody, body))
+		require.NoError(t, msgpackEncoder{}.encode(&msgpackBody, body))
+
+		var decoded any
+		require.NoError(t, msgpack.Unmarshal(msgpackBody.Bytes(), &decoded))
+		transcoded, err := json.Marshal(decoded)
+		require.NoError(t, err)
+		assert.JSONEq(t, jsonBody.String(), string(transcoded))
+
+		// The map keys are sorted, the encoding is the same every time
+		var again bytes.Buffer
+		require.NoError(t, msgpackEncoder{}.encode(&again, body))
+		assert.Equal(t, msgpackBody.Bytes(), again.Bytes())
+	}
+
+	// The body decodes into the response structs by their JSON names
+	var buf bytes.Buffer
+	require.NoError(t, msgpackEncoder{}.encode(&buf, forecastsBody{forecasts: csvForecasts()}))
+	dec := msgpack.NewDecoder(&buf)
+	dec.SetCustomStructTag("json")
+	var forecasts map[string]struct {
+		RepositoryName string `json:"repository_name"`
+		ErrorCode      string `json:"error_code"`
+		ForecastData   []struct {
+			Date    string  `json:"date"`
+			TempMax float64 `json:"temp_max"`
+		} `json:"forecast_data"`
+	}
+	require.NoError(t, dec.Decode(&forecasts))
+	assert.Equal(t, "open-meteo", forecasts["open-meteo"].RepositoryName)
+	assert.Equal(t, models.ErrorCodeTimeout, forecasts["weatherapi"].ErrorCode)
+	require.Len(t, forecasts["open-meteo"].ForecastData, 2)
+	assert.Equal(t, "2025-07-25", forecasts["open-meteo"].ForecastData[0].Date)
+	assert.Equal(t, 28.4, forecasts["open-meteo"].ForecastData[0].TempMax)
+	assert.Equal(t, 30.0, forecasts["open-meteo"].ForecastData[1].TempMax)
+}
+
+func TestHandleWeatherCall_MsgPack(t *testing.T) {
+	date, err := models.ParseDate("2025-07-25")
+	require.NoError(t, err)
+	app := newStubApp(&stubForecaster{forecasts: map[string]models.Forecast{
+		"stub": {RepositoryName: "stub", Lat: 52.52, Lon: 13.41, ForecastWindow: 1, ForecastData: []models.WeatherData{{Date: date, TempMax: 25.5, TempMin: 15}}},
+	}})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1", nil))
+	require.NoError(t, err)
+	jsonBody, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	for _, accept := range []string{"", "application/msgpack"} {
+		target := "/weather?lat=52.52&lon=13.41&days=1"
+		if accept == "" {
+			target += "&format=msgpack"
+		}
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(fiber.HeaderAccept, accept)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Equal(t, "application/msgpack", resp.Header.Get(fiber.HeaderContentType))
+		assert.NotEmpty(t, resp.Header.Get(fiber.HeaderETag))
+
+		var decoded any
+		require.NoError(t, msgpack.NewDecoder(resp.Body).Decode(&decoded))
+		transcoded, err := json.Marshal(decoded)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(jsonBody), string(transcoded))
+	}
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1&format=msgpack&envelope=true&fields=date,temp_max", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	var envelope map[string]any
+	require.NoError(t, msgpack.NewDecoder(resp.Body).Decode(&envelope))
+	data := envelope["data"].([]any)
+	require.Len(t, data, 1)
+	assert.Equal(t, []any{map[string]any{"date": "2025-07-25", "temp_max": 25.5}}, data[0].(map[string]any)["forecast_data"])
+}
+
+func TestFields_JSON(t *testing.T) {
+	fields := fieldSet{"date": true, "temp_max": true, "precipitation_sum": true}
+	body := forecastsBody{forecasts: csvForecasts(), fields: fields}
+
+	var buf strings.Builder
+	require.NoError(t, jsonEncoder{}.encode(&buf, body))
+
+	var decoded map[string]struct {
+		RepositoryName string            `json:"repository_name"`
+		ErrorCode      string            `json:"error_code"`
+		ForecastData   []json.RawMessage `json:"forecast_data"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &decoded))
+	assert.Equal(t, "open-meteo", decoded["open-meteo"].RepositoryName)
+	assert.Equal(t, models.ErrorCodeTimeout, decoded["weatherapi"].ErrorCode)
+	// The fields keep the model order, the omitted values stay omitted
+	require.Len(t, decoded["open-meteo"].ForecastData, 2)
+	assert.JSONEq(t, `{"date":"2025-07-25","temp_max":28.4,"precipitation_sum":1.2}`, string(decoded["open-meteo"].ForecastData[0]))
+	assert.Equal(t, `{"date":"2025-07-26","temp_max":30}`, string(decoded["open-meteo"].ForecastData[1]))
+
+	aggregate := aggregateBody{AggregatedForecast: models.AggregatedForecast{Strategy: "median", ForecastData: []models.AggregatedWeatherData{
+		{Date: models.NewDate(time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)), TempMax: 28.2, TempMin: 16, Spread: 0.5, ProviderCount: 2},
+	}}, fields: fieldSet{"provider_count": true}}
+
+	buf.Reset()
+	require.NoError(t, jsonEncoder{}.encode(&buf, aggregate))
+	var aggregated map[string]any
+	require.NoError(t, json.Unmarshal([]byte(buf.String()), &aggregated))
+	assert.Equal(t, "median", aggregated["strategy"])
+	assert.Equal(t, []any{map[string]any{"provider_count": float64(2)}}, aggregated["forecast_data"])
+}
+
+func TestFields_CSV(t *testing.T) {
+	body := forecastsBody{forecasts: csvForecasts(), fields: fieldSet{"date": true, "temp_max": true, "condition": true}}
+
+	var buf strings.Builder
+	require.NoError(t, csvEncoder{}.encode(&buf, body))
+	assert.Equal(t, "date,provider,temp_max,condition\r\n"+
+		"2025-07-25,open-meteo,28.4,rain\r\n"+
+		"2025-07-26,open-meteo,30,\r\n"+
+		"2025-07-25,\"stub, eu\",27.9,\r\n", buf.String())
+
+	aggregate := aggregateBody{AggregatedForecast: models.AggregatedForecast{ForecastData: []models.AggregatedWeatherData{
+		{Date: models.NewDate(time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)), TempMax: 28.2, TempMin: 16, Spread: 0.5, ProviderCount: 2},
+	}}, fields: fieldSet{"temp_min": true, "spread": true}}
+
+	buf.Reset()
+	require.NoError(t, csvEncoder{}.encode(&buf, aggregate))
+	assert.Equal(t, "temp_min,spread\r\n16,0.5\r\n", buf.String())
+}
+
+func TestHandleWeatherCall_Fields(t *testing.T) {
+	app := newStubApp(&stubForecaster{forecasts: csvForecasts(), aggregated: &models.AggregatedForecast{}})
+
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/weather?lat=52.52&lon=13.41&fields=temp_min,temp_max,date", fiber.StatusOK},
+		{"/weather?lat=52.52&lon=13.41&fields=date&format=csv", fiber.StatusOK},
+		{"/weather?lat=52.52&lon=13.41&fields=temp_min&mode=first", fiber.StatusOK},
+		{"/weather/aggregate?lat=52.52&lon=13.41&fields=spread", fiber.StatusOK},
+		{"/weather?lat=52.52&lon=13.41&fields=temperature", fiber.StatusBadRequest},
+		{"/weather?lat=52.52&lon=13.41&fields=,", fiber.StatusBadRequest},
+		{"/weather?lat=52.52&lon=13.41&fields=date&format=xml", fiber.StatusBadRequest},
+		// The aggregated days have fields of their own
+		{"/weather/aggregate?lat=52.52&lon=13.41&fields=condition", fiber.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, tt.path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, tt.wantStatus, resp.StatusCode, tt.path)
+	}
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&fields=temp,date", nil))
+	require.NoError(t, err)
+	var body Problem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+	require.Len(t, body.InvalidParams, 1)
+	assert.Equal(t, "fields", body.InvalidParams[0].Name)
+	assert.Contains(t, body.InvalidParams[0].Reason, "unknown field: temp, expected some of: date, temp_max, temp_min")
+}
+
+func TestNegotiate(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		accept     string
+		wantStatus int
+		wantType   string
+	}{
+		{"default", "", "", fiber.StatusOK, fiber.MIMEApplicationJSON},
+		{"any", "", "*/*", fiber.StatusOK, fiber.MIMEApplicationJSON},
+		{"accept csv", "", "text/csv", fiber.StatusOK, "text/csv"},
+		{"accept weighted", "", "application/json;q=0.5, text/csv", fiber.StatusOK, "text/csv"},
+		{"unsupported accept", "", "image/png", fiber.StatusOK, fiber.MIMEApplicationJSON},
+		{"format csv", "&format=csv", "", fiber.StatusOK, "text/csv"},
+		{"format over accept", "&format=json", "text/csv", fiber.StatusOK, fiber.MIMEApplicationJSON},
+		{"format case insensitive", "&format=CSV", "", fiber.StatusOK, "text/csv"},
+		{"accept xml", "", "application/xml", fiber.StatusOK, fiber.MIMEApplicationXML},
+		{"format xml", "&format=xml", "", fiber.StatusOK, fiber.MIMEApplicationXML},
+		{"format xml over accept csv", "&format=xml", "text/csv", fiber.StatusOK, fiber.MIMEApplicationXML},
+		{"preferred of several", "", "application/xml, text/csv;q=0.9", fiber.StatusOK, fiber.MIMEApplicationXML},
+		{"unknown format", "&format=yaml", "", fiber.StatusBadRequest, problemContentType},
+	}
+
+	app := newStubApp(&stubForecaster{forecasts: csvForecasts()})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41"+tt.query, nil)
+			if tt.accept != "" {
+				req.Header.Set(fiber.HeaderAccept, tt.accept)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			assert.Equal(t, tt.wantType, resp.Header.Get(fiber.HeaderContentType))
+		})
+	}
+}
+
+func TestHandleWeatherCall_XML(t *testing.T) {
+	app := newStubApp(&stubForecaster{forecasts: csvForecasts()})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=2&format=xml", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, fiber.MIMEApplicationXML, resp.Header.Get(fiber.HeaderContentType))
+
+	var doc XMLForecasts
+	require.NoError(t, xml.NewDecoder(resp.Body).Decode(&doc))
+
+	precipitation, probability := 1.2, 40.0
+	want := []XMLForecast{
+		{Provider: "open-meteo", Units: "metric", Days: []XMLDay{
+			{Date: "2025-07-25", Min: 16.1, Max: 28.4, PrecipitationSum: &precipitation, PrecipitationProbability: &probability,
+				Sunrise: "2025-07-25T05:21:00+02:00", Condition: "rain"},
+			{Date: "2025-07-26", Min: 17.5, Max: 30},
+		}},
+		{Provider: "stub, eu", Units: "metric", Days: []XMLDay{{Date: "2025-07-25", Min: 15.8, Max: 27.9}}},
+		{Provider: "weatherapi", Units: "metric", Error: "provider timed out", ErrorCode: models.ErrorCodeTimeout},
+	}
+	assert.Equal(t, "forecasts", doc.XMLName.Local)
+	assert.Nil(t, doc.Location)
+	assert.Equal(t, want, doc.Forecasts)
+}
+
+func TestXMLDocument_RoundTrip(t *testing.T) {
+	body := forecastsBody{forecasts: csvForecasts(), location: &ResponseLocation{Name: "Berlin", Country: "Germany"}}
+
+	var buf strings.Builder
+	require.NoError(t, xmlEncoder{}.encode(&buf, body))
+	assert.True(t, strings.HasPrefix(buf.String(), xml.Header))
+
+	var forecasts XMLForecasts
+	require.NoError(t, xml.Unmarshal([]byte(buf.String()), &forecasts))
+	assert.Equal(t, body.xmlDocument().(XMLForecasts).Forecasts, forecasts.Forecasts)
+	assert.Equal(t, &XMLLocation{Name: "Berlin", Country: "Germany"}, forecasts.Location)
+
+	aggregate := aggregateBody{AggregatedForecast: models.AggregatedForecast{Strategy: "median", Units: "imperial", ForecastData: []models.AggregatedWeatherData{
+		{Date: models.NewDate(time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)), TempMax: 82.8, TempMin: 60.8, Spread: 0.9, ProviderCount: 3},
+	}}}
+
+	buf.Reset()
+	require.NoError(t, xmlEncoder{}.encode(&buf, aggregate))
+
+	var aggregated XMLAggregate
+	require.NoError(t, xml.Unmarshal([]byte(buf.String()), &aggregated))
+	aggregated.XMLName = xml.Name{}
+	assert.Equal(t, aggregate.xmlDocument(), aggregated)
+}
+
+func TestHandleWeatherCall_Stream(t *testing.T) {
+	stub := &stubForecaster{stream: []models.Forecast{
+		{RepositoryName: "fast", ForecastData: []models.WeatherData{{TempMax: 20, TempMin: 10}}},
+		{RepositoryName: "failed", Error: "provider timed out", ErrorCode: models.ErrorCodeTimeout, ForecastData: []models.WeatherData{}},
+	}}
+	app := newStubApp(stub)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&stream=true&units=imperial", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, ndjsonContentType, resp.Header.Get(fiber.HeaderContentType))
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(string(body), "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	var first, second models.Forecast
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &first))
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &second))
+	assert.Equal(t, "fast", first.RepositoryName)
+	assert.Equal(t, "imperial", first.Units)
+	assert.Equal(t, 68.0, first.ForecastData[0].TempMax)
+	assert.Equal(t, "failed", second.RepositoryName)
+	assert.Equal(t, models.ErrorCodeTimeout, second.ErrorCode)
+}
+
+// endlessStream sends forecasts until ctx is canceled
+func endlessStream(ctx context.Context, canceled chan struct{}) <-chan models.Forecast {
+	results := make(chan models.Forecast)
+	go func() {
+		defer close(results)
+		for {
+			select {
+			case <-ctx.Done():
+				close(canceled)
+				return
+			case results <- models.Forecast{RepositoryName: "endless", ForecastData: []models.WeatherData{}}:
+			}
+		}
+	}()
+
+	return results
+}
+
+func TestHandleWeatherCall_StreamClientDisconnect(t *testing.T) {
+	stub := &stubForecaster{streamCanceled: make(chan struct{})}
+	app := newStubApp(stub)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	defer func() { _ = app.Shutdown() }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/weather?lat=52.52&lon=13.41&stream=true")
+	require.NoError(t, err)
+	line, err := bufio.NewReader(resp.Body).ReadString('\n')
+	require.NoError(t, err)
+	assert.Contains(t, line, `"repository_name":"endless"`)
+	require.NoError(t, resp.Body.Close())
+
+	select {
+	case <-stub.streamCanceled:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the fetches were not canceled when the client went away")
+	}
+}
+
+func TestHandleWeatherCall_StreamValidation(t *testing.T) {
+	stub := &stubForecaster{}
+	app := newStubApp(stub)
+
+	for _, query := range []string{"mode=first", "format=csv", "resolve_name=true"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&stream=true&"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+	assert.Zero(t, stub.calls)
+}
+
+// serverEvent is an event of a text/event-stream body
+type serverEvent struct {
+	id    string
+	event string
+	data  string
+}
+
+// readEvent reads the next event of an event stream, skipping comments and the retry field
+func readEvent(t *testing.T, r *bufio.Reader) serverEvent {
+	t.Helper()
+
+	var ev serverEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+
+		switch {
+		case line == "" && ev.event != "":
+			return ev
+		case strings.HasPrefix(line, "id: "):
+			ev.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			ev.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestHandleSubscribeCall(t *testing.T) {
+	stub := &stubForecaster{aggregated: &models.AggregatedForecast{
+		Strategy: weather.StrategyMean,
+		ForecastData: []models.AggregatedWeatherData{
+			{Date: models.NewDate(time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)), TempMax: 28.2, TempMin: 16, ProviderCount: 2},
+		},
+	}}
+	app := newStubApp(stub)
+
+	// The subscription ends after the 200ms maximum of the stub, which completes the response
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/subscribe?lat=52.52&lon=13.41&interval=50ms", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get(fiber.HeaderContentType))
+	assert.Equal(t, "no-cache", resp.Header.Get(fiber.HeaderCacheControl))
+
+	r := bufio.NewReader(resp.Body)
+	for _, id := range []string{"1", "2"} {
+		ev := readEvent(t, r)
+		assert.Equal(t, id, ev.id)
+		assert.Equal(t, "forecast", ev.event)
+
+		var aggregated models.AggregatedForecast
+		require.NoError(t, json.Unmarshal([]byte(ev.data), &aggregated))
+		assert.Equal(t, "metric", aggregated.Units)
+		require.Len(t, aggregated.ForecastData, 1)
+		assert.Equal(t, 28.2, aggregated.ForecastData[0].TempMax)
+	}
+	assert.GreaterOrEqual(t, stub.updates.Load(), int32(2))
+}
+
+func TestHandleSubscribeCall_ErrorEvent(t *testing.T) {
+	app := newStubApp(&stubForecaster{err: weather.ErrNoForecasts})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/subscribe?lat=52.52&lon=13.41&interval=50ms", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	ev := readEvent(t, bufio.NewReader(resp.Body))
+	assert.Equal(t, "error", ev.event)
+
+	var p Problem
+	require.NoError(t, json.Unmarshal([]byte(ev.data), &p))
+	assert.Equal(t, ProblemUpstreamFailed, p.Type)
+	assert.Equal(t, fiber.StatusBadGateway, p.Status)
+	assert.Equal(t, "/weather/subscribe?lat=52.52&lon=13.41&interval=50ms", p.Instance)
+}
+
+func TestHandleSubscribeCall_ClientDisconnect(t *testing.T) {
+	stub := &stubForecaster{aggregated: &models.AggregatedForecast{}, subscriptionMax: time.Minute}
+	app := newStubApp(stub)
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	defer func() { _ = app.Shutdown() }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/weather/subscribe?lat=52.52&lon=13.41&interval=20ms")
+	require.NoError(t, err)
+	assert.Equal(t, "forecast", readEvent(t, bufio.NewReader(resp.Body)).event)
+	require.NoError(t, resp.Body.Close())
+
+	// The ticker stops once a write fails, the updates stop with it
+	var updates int32
+	require.Eventually(t, func() bool {
+		n := stub.updates.Load()
+		stopped := n == updates
+		updates = n
+		return stopped
+	}, 2*time.Second, 100*time.Millisecond)
+}
+
+func TestHandleSubscribeCall_OutlivesWriteTimeout(t *testing.T) {
+	stub := &stubForecaster{aggregated: &models.AggregatedForecast{}, subscriptionMax: time.Minute}
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{WriteTimeout: 100 * time.Millisecond}, l)
+	NewRouter(app, stub, newStubGeocoder(), l)
+
+	// fasthttp only sets the write deadline on a real connection
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	defer func() { _ = app.Shutdown() }()
+
+	resp, err := http.Get("http://" + ln.Addr().String() + "/weather/subscribe?lat=52.52&lon=13.41&interval=50ms")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	// The updates keep coming well after the write timeout of the response
+	r := bufio.NewReader(resp.Body)
+	start := time.Now()
+	for time.Since(start) < 500*time.Millisecond {
+		assert.Equal(t, "forecast", readEvent(t, r).event)
+	}
+}
+
+func TestHandleSubscribeCall_Validation(t *testing.T) {
+	stub := &stubForecaster{}
+	app := newStubApp(stub)
+
+	for _, query := range []string{"interval=soon", "interval=1ms", "interval=-1m", "strategy=mode"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather/subscribe?lat=52.52&lon=13.41&"+query, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode, query)
+	}
+	assert.Zero(t, stub.updates.Load())
+}
+
+func TestAdminSetProviderEnabled(t *testing.T) {
+	l := logger.NopLogger{}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{repositories.NewMockWeatherRepository(0, 0, l)}, l)
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	NewRouter(app, service, newStubGeocoder(), l)
+	NewAdminRouter(app, "s3cret", service, l)
+
+	post := func(target, token string) *http.Response {
+		req := httptest.NewRequest(http.MethodPost, target, nil)
+		if token != "" {
+			req.Header.Set(fiber.HeaderAuthorization, "Bearer "+token)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	assert.Equal(t, fiber.StatusUnauthorized, post("/admin/providers/mock/disable", "").StatusCode)
+	assert.Equal(t, fiber.StatusUnauthorized, post("/admin/providers/mock/disable", "wrong").StatusCode)
+	assert.True(t, service.ProviderEnabled("mock"))
+
+	resp := post("/admin/providers/accuweather/disable", "s3cret")
+	assert.Equal(t, fiber.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get(fiber.HeaderContentType))
+
+	resp = post("/admin/providers/mock/disable", "s3cret")
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"name": "mock", "enabled": false}`, string(body))
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&lon=13.41&days=1", nil))
+	require.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"error_code":"disabled"`)
+
+	resp = post("/admin/providers/mock/enable", "s3cret")
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.True(t, service.ProviderEnabled("mock"))
+}
+
+// countingRepository counts the forecasts fetched from the mock provider
+type countingRepository struct {
+	*repositories.MockWeatherRepository
+	calls atomic.Int32
+}
+
+func (r *countingRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	r.calls.Add(1)
+	return r.MockWeatherRepository.FetchForecast(ctx, lat, lon, forecastWindow)
+}
+
+func TestAdminCache(t *testing.T) {
+	l := logger.NopLogger{}
+	repo := &countingRepository{MockWeatherRepository: repositories.NewMockWeatherRepository(0, 0, l)}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{repo}, l, weather.WithForecastCache(100))
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	NewRouter(app, service, newStubGeocoder(), l)
+	NewAdminRouter(app, "s3cret", service, l)
+
+	admin := func(method, target string) (int, []byte) {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set(fiber.HeaderAuthorization, "Bearer s3cret")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp.StatusCode, body
+	}
+	fetch := func(query string) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?days=1&"+query, nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	}
+
+	fetch("lat=52.52&lon=13.41")
+	fetch("lat=52.52&lon=13.41")
+	fetch("lat=48.85&lon=2.35")
+	require.Equal(t, int32(2), repo.calls.Load())
+
+	status, body := admin(http.MethodGet, "/admin/cache/stats")
+	require.Equal(t, fiber.StatusOK, status)
+	var stats weather.CacheStats
+	require.NoError(t, json.Unmarshal(body, &stats))
+	assert.True(t, stats.Enabled)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Positive(t, stats.MemoryBytes)
+
+	status, body = admin(http.MethodDelete, "/admin/cache?lat=52.52&lon=13.41")
+	require.Equal(t, fiber.StatusOK, status)
+	assert.JSONEq(t, `{"purged": 1}`, string(body))
+	fetch("lat=48.85&lon=2.35")
+	assert.Equal(t, int32(2), repo.calls.Load())
+	fetch("lat=52.52&lon=13.41")
+	assert.Equal(t, int32(3), repo.calls.Load())
+
+	status, body = admin(http.MethodDelete, "/admin/cache")
+	require.Equal(t, fiber.StatusOK, status)
+	assert.JSONEq(t, `{"purged": 2}`, string(body))
+	fetch("lat=48.85&lon=2.35")
+	assert.Equal(t, int32(4), repo.calls.Load())
+
+	status, _ = admin(http.MethodDelete, "/admin/cache?lat=52.52")
+	assert.Equal(t, fiber.StatusBadRequest, status)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodDelete, "/admin/cache", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	assert.Equal(t, 1, service.CacheStats().Entries)
+}
+
+func TestSwaggerDoc(t *testing.T) {
+	// The documentation is served from the binary, wherever it runs from
+	t.Chdir(t.TempDir())
+	app := newStubApp(&stubForecaster{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/swagger/doc.json", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get(fiber.HeaderContentType))
+
+	var spec map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&spec))
+	assert.Equal(t, "2.0", spec["swagger"])
+	assert.Contains(t, spec["paths"], "/weather")
+}
+
+func TestSwaggerDoc_Routes(t *testing.T) {
+	// The paths of the spec are relative to the prefix the routes are mounted at
+	var spec struct {
+		Paths map[string]map[string]any `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(docs.SwaggerJSON(), &spec))
+
+	l := logger.NopLogger{}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{repositories.NewMockWeatherRepository(0, 0, l)}, l)
+	app := fiber.New()
+	NewRouter(app, service, newStubGeocoder(), l)
+	NewGraphQLRouter(app, service, l)
+	NewAdminRouter(app, "s3cret", service, l)
+
+	var checked int
+	for _, route := range app.GetRoutes(true) {
+		// HEAD is added by Fiber to every GET route, USE are the middlewares of the groups
+		if route.Method == fiber.MethodHead || route.Method == "USE" {
+			continue
+		}
+		path := route.Path
+		for _, param := range route.Params {
+			path = strings.Replace(path, ":"+param, "{"+param+"}", 1)
+		}
+		assert.Contains(t, spec.Paths[path], strings.ToLower(route.Method), "%s %s is not documented", route.Method, path)
+		checked++
+	}
+	assert.Greater(t, checked, 20)
+}
+
+func TestNewRouter_DeprecatedAlias(t *testing.T) {
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{
+		"stub": {RepositoryName: "stub", Lat: 52.52, Lon: 13.41, ForecastWindow: 1},
+	}}
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	NewRouter(app.Group("/v1"), stub, newStubGeocoder(), l)
+	sunset := time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+	NewRouter(app, stub, newStubGeocoder(), l, WithDeprecation(sunset, "/v1"))
+
+	get := func(target string) (*http.Response, string) {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+		require.NoError(t, err)
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return resp, string(body)
+	}
+
+	for _, path := range []string{"/weather?lat=52.52&lon=13.41&days=1", "/providers"} {
+		versioned, versionedBody := get("/v1" + path)
+		alias, aliasBody := get(path)
+
+		assert.Equal(t, versionedBody, aliasBody, path)
+		assert.Empty(t, versioned.Header.Get("Deprecation"), path)
+		assert.Empty(t, versioned.Header.Get("Sunset"), path)
+
+		assert.Equal(t, "true", alias.Header.Get("Deprecation"), path)
+		assert.Equal(t, "Thu, 01 Apr 2027 00:00:00 GMT", alias.Header.Get("Sunset"), path)
+		route, _, _ := strings.Cut(path, "?")
+		assert.Equal(t, `</v1`+route+`>; rel="successor-version"`, alias.Header.Get(fiber.HeaderLink), path)
+	}
+}
+
+func TestHandleVersionCall(t *testing.T) {
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	build := buildinfo.Info{Name: "weather-api", Version: "1.2.0", Commit: "3f2b8c0e", BuildDate: "2025-07-25T10:00:00Z", GoVersion: "go1.24.3"}
+	NewRouter(app, &stubForecaster{}, newStubGeocoder(), l, WithBuildInfo(build))
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/version", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"name": "weather-api",
+		"version": "1.2.0",
+		"commit": "3f2b8c0e",
+		"build_date": "2025-07-25T10:00:00Z",
+		"go_version": "go1.24.3"
+	}`, string(body))
+}
+
+func TestHandleVersionCall_Default(t *testing.T) {
+	app := newStubApp(&stubForecaster{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/version", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var info buildinfo.Info
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&info))
+	assert.Equal(t, "weather-api", info.Name)
+	assert.NotEmpty(t, info.Version)
+	assert.Equal(t, runtime.Version(), info.GoVersion)
+}
+
+// countingForecaster counts the fetches of the GraphQL fields, which are resolved concurrently
+type countingForecaster struct {
+	*stubForecaster
+	fetches atomic.Int32
+}
+
+func (s *countingForecaster) FetchProviderForecasts(ctx context.Context, lat, lon float64, forecastWindow int, providers []string) (map[string]models.Forecast, error) {
+	s.fetches.Add(1)
+	return s.forecasts, s.err
+}
+
+func (s *countingForecaster) AggregateForecasts(ctx context.Context, lat, lon float64, forecastWindow int, strategy string, minProviders int) (models.AggregatedForecast, error) {
+	s.fetches.Add(1)
+	return *s.aggregated, s.err
+}
+
+// graphqlQuery posts a GraphQL query and decodes the response
+func graphqlQuery(t *testing.T, service Forecaster, query string) map[string]any {
+	t.Helper()
+
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	NewGraphQLRouter(app, service, l)
+
+	body, err := json.Marshal(GraphQLRequest{Query: query})
+	require.NoError(t, err)
+	req := httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(string(body)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var result map[string]any
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&result))
+
+	return result
+}
+
+func newGraphQLStub() *countingForecaster {
+	date, _ := models.ParseDate("2025-07-25")
+	precipitation := 1.2
+
+	return &countingForecaster{stubForecaster: &stubForecaster{
+		forecasts: map[string]models.Forecast{"stub": {
+			RepositoryName: "stub", Lat: 52.52, Lon: 13.41, ForecastWindow: 1,
+			ForecastData: []models.WeatherData{{Date: date, TempMax: 25.5, TempMin: 15.2, PrecipitationSum: &precipitation}},
+		}},
+		aggregated: &models.AggregatedForecast{
+			Lat: 52.52, Lon: 13.41, ForecastWindow: 1, Strategy: weather.StrategyMean, MinProviders: 1, Providers: []string{"stub"},
+			ForecastData: []models.AggregatedWeatherData{{Date: date, TempMax: 25.5, TempMin: 15.2, ProviderCount: 1}},
+		},
+	}}
+}
+
+func TestGraphQL_Aliases(t *testing.T) {
+	stub := newGraphQLStub()
+
+	result := graphqlQuery(t, stub, `{
+		berlin: forecast(lat: 52.52, lon: 13.41, days: 1) { days { tempMax } }
+		paris: forecast(lat: 48.85, lon: 2.35, days: 1) { days { tempMax } }
+		rome: forecast(lat: 41.9, lon: 12.5, days: 1, units: "imperial") { days { tempMax } }
+	}`)
+	require.Nil(t, result["errors"])
+	assert.Equal(t, int32(3), stub.fetches.Load())
+
+	data := result["data"].(map[string]any)
+	assert.Equal(t, []any{map[string]any{"days": []any{map[string]any{"tempMax": 25.5}}}}, data["berlin"])
+	assert.Equal(t, []any{map[string]any{"days": []any{map[string]any{"tempMax": 77.9}}}}, data["rome"])
+}
+
+func TestGraphQL_SharedFetch(t *testing.T) {
+	stub := newGraphQLStub()
+
+	result := graphqlQuery(t, stub, `{
+		temperatures: forecast(lat: 52.52, lon: 13.41, days: 1) { provider days { date tempMax tempMin } }
+		rain: forecast(lat: 52.520000001, lon: 13.41, days: 1, units: "imperial") { days { precipitationSum } }
+		aggregate(lat: 52.52, lon: 13.41, days: 1) { strategy days { tempMax providerCount } }
+		again: aggregate(lat: 52.52, lon: 13.41, days: 1) { providers }
+	}`)
+	require.Nil(t, result["errors"])
+	// The coordinates are normalized, the units are converted after the fetch
+	assert.Equal(t, int32(2), stub.fetches.Load())
+
+	data := result["data"].(map[string]any)
+	assert.Equal(t, []any{map[string]any{"days": []any{map[string]any{"precipitationSum": 0.05}}}}, data["rain"])
+	assert.Equal(t, map[string]any{"providers": []any{"stub"}}, data["again"])
+}
+
+func TestGraphQL_Limits(t *testing.T) {
+	stub := newGraphQLStub()
+
+	var query strings.Builder
+	query.WriteString("{")
+	for i := range maxGraphQLFetches + 1 {
+		fmt.Fprintf(&query, " f%d: forecast(lat: %d.5, lon: 0.5) { provider }", i, i)
+	}
+	query.WriteString(" }")
+	result := graphqlQuery(t, stub, query.String())
+	require.NotNil(t, result["errors"])
+	assert.Equal(t, int32(maxGraphQLFetches), stub.fetches.Load())
+	errs := result["errors"].([]any)
+	require.Len(t, errs, 1)
+	assert.Equal(t, map[string]any{"code": graphqlTooComplex}, errs[0].(map[string]any)["extensions"])
+
+	result = graphqlQuery(t, stub, `{ __schema { types { fields { type { ofType { ofType { name } } } } } } }`)
+	require.NotNil(t, result["errors"])
+	assert.Contains(t, fmt.Sprint(result["errors"]), "exceeds max depth")
+
+	result = graphqlQuery(t, stub, `{ forecast(lat: 91.5, lon: 0.5) { provider } }`)
+	errs = result["errors"].([]any)
+	require.Len(t, errs, 1)
+	assert.Equal(t, map[string]any{"code": graphqlBadUserInput}, errs[0].(map[string]any)["extensions"])
+}
+
+func TestGraphQL_MissingQuery(t *testing.T) {
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	NewGraphQLRouter(app, newGraphQLStub(), l)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/graphql", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+
+	resp, err = app.Test(httptest.NewRequest(http.MethodGet, "/graphql?query="+url.QueryEscape("{ providers { name } }"), nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestRequestLogger_Correlation(t *testing.T) {
+	l := logger.NewTestLogger()
+	service := weather.NewWeatherService([]repositories.WeatherRepository{repositories.NewMockWeatherRepository(0, 1, l)}, l)
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	NewRouter(app.Group("/v1"), service, nil, l)
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/weather?lat=52.52&lon=13.41", nil)
+	req.Header.Set(requestid.Header, "correlated-request")
+	_, err := app.Test(req)
+	require.NoError(t, err)
+
+	// The lines of the repository and the service carry the fields of the request
+	var repoLines, serviceErrors int
+	for _, entry := range l.Entries() {
+		switch {
+		case entry.Message == "synthesizing mock forecast":
+			repoLines++
+		case entry.Level == logger.LevelError && entry.Fields["repo"] == "mock":
+			serviceErrors++
+		default:
+			continue
+		}
+		assert.Equal(t, "correlated-request", entry.Fields["request_id"], entry.Message)
+		assert.Equal(t, "/v1/weather", entry.Fields["path"], entry.Message)
+		assert.Equal(t, http.MethodGet, entry.Fields["method"], entry.Message)
+		assert.NotEmpty(t, entry.Fields["ip"], entry.Message)
+	}
+	assert.Equal(t, 1, repoLines)
+	assert.Equal(t, 1, serviceErrors)
+
+	// Without a request the lines fall back to the base logger
+	_, err = service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+	last := l.Entries()[len(l.Entries())-1]
+	assert.NotContains(t, last.Fields, "path")
+}
+
+func TestTracing_SpanTree(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+
+	l := logger.NopLogger{}
+	recorder := &recordingHTTPClient{}
+	httpClient := repositories.NewTracingHTTPClient(recorder)
+	weatherAPI, err := repositories.NewWeatherAPIRepository("secret", l, httpClient)
+	require.NoError(t, err)
+	repos := []repositories.WeatherRepository{
+		repositories.NewTracedRepository(repositories.NewOpenMeteoRepository(l, httpClient)),
+		repositories.NewTracedRepository(weatherAPI),
+	}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{Tracing: true}, l)
+	NewRouter(app.Group("/v1"), weather.NewWeatherService(repos, l), nil, l)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/v1/weather?lat=52.52&lon=13.41&days=1", nil))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	spans := exporter.GetSpans()
+	children := make(map[trace.SpanID][]tracetest.SpanStub)
+	var root tracetest.SpanStub
+	for _, span := range spans {
+		if !span.Parent.IsValid() {
+			root = span
+			continue
+		}
+		children[span.Parent.SpanID()] = append(children[span.Parent.SpanID()], span)
+	}
+
+	// The request, a span per provider and a span per provider request
+	require.Len(t, spans, 5)
+	assert.Equal(t, "GET /v1/weather", root.Name)
+	assert.Equal(t, trace.SpanKindServer, root.SpanKind)
+
+	var providers []string
+	for _, span := range children[root.SpanContext.SpanID()] {
+		providers = append(providers, span.Name)
+
+		requests := children[span.SpanContext.SpanID()]
+		require.Len(t, requests, 1, span.Name)
+		assert.Equal(t, trace.SpanKindClient, requests[0].SpanKind)
+		for _, attr := range requests[0].Attributes {
+			switch attr.Key {
+			case "url.full":
+				assert.NotContains(t, attr.Value.AsString(), "secret")
+			case "http.response.status_code":
+				assert.Equal(t, int64(http.StatusOK), attr.Value.AsInt64())
+			case "http.response.body.size":
+				assert.Positive(t, attr.Value.AsInt64())
+			}
+		}
+	}
+	assert.ElementsMatch(t, []string{"provider open-meteo", "provider weatherapi"}, providers)
+
+	// The provider requests carry the trace context
+	require.Len(t, recorder.requests, 2)
+	for _, req := range recorder.requests {
+		assert.Contains(t, req.Header.Get("traceparent"), root.SpanContext.TraceID().String())
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	date, err := models.ParseDate("2025-07-25")
+	require.NoError(t, err)
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{
+		"stub": {RepositoryName: "stub", ForecastWindow: 1, ForecastData: []models.WeatherData{{Date: date}}},
+	}}
+
+	reg := prometheus.NewRegistry()
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{Metrics: reg}, l)
+	NewRouter(app, stub, newStubGeocoder(), l)
+
+	for _, target := range []string{
+		"/weather?lat=52.52&lon=13.41&days=1",
+		"/weather?lat=52.52&lon=13.41&days=1",
+		"/weather?lat=91&lon=13.41",
+		"/unknown/1",
+		"/unknown/2",
+	} {
+		_, err := app.Test(httptest.NewRequest(http.MethodGet, target, nil))
+		require.NoError(t, err)
+	}
+
+	counts := make(map[string]float64)
+	families, err := reg.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			switch family.GetName() {
+			case "http_requests_total":
+				counts[labels["route"]+" "+labels["status"]] = metric.GetCounter().GetValue()
+			case "http_request_duration_seconds":
+				assert.Positive(t, metric.GetHistogram().GetSampleCount())
+			case "http_requests_in_flight":
+				assert.Zero(t, metric.GetGauge().GetValue())
+			}
+		}
+	}
+	// The unknown paths are counted under the route of the middlewares, not one series per path
+	assert.Equal(t, map[string]float64{"/weather 200": 2, "/weather 400": 1, "/ 404": 2}, counts)
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, httpserver.MetricsPath, nil))
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Contains(t, string(body), `http_requests_total{method="GET",route="/weather",status="200"} 2`)
+}
+
+func TestHandleWeatherCall_ServiceArea(t *testing.T) {
+	stub := &stubForecaster{forecasts: map[string]models.Forecast{
+		"stub": {RepositoryName: "stub", ForecastData: []models.WeatherData{}},
+	}}
+	l := logger.NopLogger{}
+	app := httpserver.InitFiberServer("test-app", httpserver.Options{}, l)
+	NewRouter(app, stub, newStubGeocoder(), l, WithServiceArea(geo.Area{
+		Allow:            []geo.Box{{Name: "europe", South: 35, West: -10, North: 71, East: 40}},
+		Deny:             []geo.Box{{Name: "alps", South: 45, West: 6, North: 48, East: 14}},
+		RejectNullIsland: true,
+	}))
+
+	tests := []struct {
+		name  string
+		query string
+		// wantDetail is part of the detail of the problem, the location is served when empty
+		wantDetail string
+	}{
+		{"null island", "lat=0&lon=0", "Null Island"},
+		{"outside", "lat=40.71&lon=-74.01", "service area"},
+		{"denied", "lat=46.5&lon=10", "alps"},
+		{"allowed", "lat=52.52&lon=13.41", ""},
+		{"allowed city", "city=Berlin", ""},
+	}
+
+	for _, tt := range tests {
+		stub.calls = 0
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather?"+tt.query, nil))
+		require.NoError(t, err, tt.name)
+
+		if tt.wantDetail == "" {
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode, tt.name)
+			assert.Equal(t, 1, stub.calls, tt.name)
+			continue
+		}
+
+		require.Equal(t, fiber.StatusUnprocessableEntity, resp.StatusCode, tt.name)
+		assert.Zero(t, stub.calls, tt.name)
+
+		var body Problem
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body), tt.name)
+		assert.Equal(t, ProblemOutsideServiceArea, body.Type, tt.name)
+		assert.Contains(t, body.Detail, tt.wantDetail, tt.name)
+	}
+
+	// The items of a batch are rejected one by one
+	stub.calls = 0
+	req := httptest.NewRequest(http.MethodPost, "/weather/batch", strings.NewReader(`[{"lat": 52.52, "lon": 13.41}, {"lat": 0, "lon": 0}]`))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, 1, stub.calls)
+
+	var results []BatchResultItem
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&results))
+	require.Len(t, results, 2)
+	assert.Empty(t, results[0].Error)
+	assert.Contains(t, results[1].Error, "Null Island")
+}
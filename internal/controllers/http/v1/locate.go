@@ -0,0 +1,46 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/repositories"
+	"weather-api/pkg/httpserver"
+	"weather-api/pkg/requestid"
+)
+
+// locatingError answers a caller whose IP address couldn't be located
+func (r *routes) locatingError(c *fiber.Ctx, ip string, err error) error {
+	r.l.Error(err, map[string]any{
+		"request_id": requestid.FromContext(c.UserContext()),
+		"ip":         ip,
+	})
+
+	switch {
+	case errors.Is(err, repositories.ErrUnlocatableIP):
+		return problem(c, fiber.StatusUnprocessableEntity, ProblemUnprocessable,
+			"The client IP address can't be located, use lat and lon or city")
+	case errors.Is(err, context.DeadlineExceeded):
+		return problem(c, fiber.StatusGatewayTimeout, ProblemTimeout, "Request budget exceeded")
+	}
+
+	return problem(c, fiber.StatusBadGateway, ProblemUpstreamFailed, "Failed to locate the client IP address")
+}
+
+// clientIP returns the address of the caller, see httpserver.ClientIP
+func (r *routes) clientIP(c *fiber.Ctx) string {
+	return httpserver.ClientIP(c, r.trustedProxy)
+}
+
+// parseIP parses an address, an invalid address is returned as the zero netip.Addr the locator rejects
+func parseIP(ip string) netip.Addr {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return netip.Addr{}
+	}
+
+	return addr.Unmap()
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/requestid"
+)
+
+// GetMarineForecast godoc
+// @Summary Get marine forecast
+// @Description Retrieves the daily wave height, wave period, wave direction and sea surface temperature of a coastal or offshore location
+// @Tags Weather
+// @Accept json
+// @Produce json
+// @Param lat query number false "Lat coordinate (-90 to 90), required without coords" minimum(-90) maximum(90) example(43.2965)
+// @Param lon query number false "Lon coordinate (-180 to 180), required without coords" minimum(-180) maximum(180) example(5.3698)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(43.2965,5.3698)
+// @Param days query integer false "Number of forecast days (1-5, default: 5)" minimum(1) maximum(5) example(3)
+// @Success 200 {object} models.MarineForecast "Daily marine forecast"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 422 {object} Problem "The point is inland, no marine provider is configured, or the location is outside the service area"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 429 {object} Problem "The marine provider is rate limited"
+// @Failure 502 {object} Problem "The marine provider failed"
+// @Failure 504 {object} Problem "Request budget exceeded"
+// @Router /weather/marine [get]
+// @Example {curl} Example usage:
+//
+//	curl -X GET "http://localhost:8080/weather/marine?lat=43.2965&lon=5.3698&days=3"
+func (r *routes) handleMarineCall(c *fiber.Ctx) error {
+	lat, lon, days, err := validateParameters(c, maxAuxiliaryWindow, r.normalizeLongitude)
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(c.UserContext()),
+			"lat":        c.Query("lat"),
+			"lon":        c.Query("lon"),
+			"days":       c.Query("days"),
+		})
+
+		return validationProblem(c, err)
+	}
+
+	if err := r.area.Check(lat, lon); err != nil {
+		return areaProblem(c, err)
+	}
+
+	ctx, cancel := r.requestContext(c, r.service.RequestBudget())
+	defer cancel()
+
+	forecast, err := r.service.FetchMarineForecast(ctx, lat, lon, days)
+	switch {
+	case errors.Is(err, weather.ErrNoMarineProvider):
+		return problem(c, fiber.StatusUnprocessableEntity, ProblemUnprocessable, "Marine forecasts are not available")
+	case errors.Is(err, repositories.ErrInlandPoint):
+		return problem(c, fiber.StatusUnprocessableEntity, ProblemUnprocessable,
+			"No marine forecast at this point, it is likely inland")
+	case err != nil:
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(ctx),
+			"lat":        lat,
+			"lon":        lon,
+			"days":       days,
+		})
+
+		return fetchProblem(c, err)
+	}
+
+	if forecast.Error != "" {
+		return upstreamProblem(c, forecast.ErrorCode, "Marine provider failed: "+forecast.Error)
+	}
+
+	return c.JSON(forecast)
+}
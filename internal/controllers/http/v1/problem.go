@@ -0,0 +1,199 @@
+package http
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/requestid"
+)
+
+// problemContentType is the media type of the RFC 7807 error responses
+const problemContentType = "application/problem+json"
+
+// Problem types, relative URIs that stay stable so that clients can switch on them
+const (
+	ProblemMissingParameter  = "/problems/missing-parameter"
+	ProblemOutOfRange        = "/problems/out-of-range"
+	ProblemInvalidParameter  = "/problems/invalid-parameter"
+	ProblemNotFound          = "/problems/not-found"
+	ProblemAmbiguousLocation = "/problems/ambiguous-location"
+	// ProblemUnprocessable is a valid request the configured providers can't serve, e.g. an inland marine forecast
+	ProblemUnprocessable  = "/problems/unprocessable"
+	ProblemUpstreamFailed = "/problems/upstream-failure"
+	ProblemRateLimited    = "/problems/rate-limited"
+	ProblemQuorumNotMet   = "/problems/quorum-not-met"
+	ProblemTimeout        = "/problems/timeout"
+	ProblemCanceled       = "/problems/canceled"
+	ProblemUnauthorized   = "/problems/unauthorized"
+	ProblemInternal       = "/problems/internal"
+
+	// ProblemOutsideServiceArea is a location the API is configured not to serve, Null Island included
+	ProblemOutsideServiceArea = "/problems/outside-service-area"
+)
+
+var problemTitles = map[string]string{
+	ProblemMissingParameter:  "Missing required parameter",
+	ProblemOutOfRange:        "Parameter out of range",
+	ProblemInvalidParameter:  "Invalid parameter",
+	ProblemNotFound:          "Not found",
+	ProblemAmbiguousLocation: "Ambiguous location",
+	ProblemUnprocessable:     "Request can't be served",
+	ProblemUpstreamFailed:    "Upstream provider failure",
+	ProblemRateLimited:       "Upstream provider rate limit exceeded",
+	ProblemQuorumNotMet:      "Not enough providers returned data",
+	ProblemTimeout:           "Request budget exceeded",
+	ProblemCanceled:          "Request canceled",
+	ProblemUnauthorized:      "Unauthorized",
+	ProblemInternal:          "Internal server error",
+
+	ProblemOutsideServiceArea: "Location outside the service area",
+}
+
+// Problem is an RFC 7807 problem details error, served as application/problem+json
+type Problem struct {
+	Type   string `json:"type" example:"/problems/missing-parameter"`
+	Title  string `json:"title" example:"Missing required parameter"`
+	Status int    `json:"status" example:"400"`
+	Detail string `json:"detail,omitempty" example:"missing required parameter: lat; missing required parameter: lon"`
+	// Instance is the request target
+	Instance  string `json:"instance,omitempty" example:"/weather?days=3"`
+	RequestID string `json:"request_id,omitempty" example:"3f2b8c0e9a4d4f5e"`
+	// InvalidParams lists every rejected parameter of a validation failure
+	InvalidParams []InvalidParam `json:"invalid_params,omitempty"`
+}
+
+// InvalidParam explains why a request parameter was rejected
+type InvalidParam struct {
+	Name   string `json:"name" example:"lat"`
+	Reason string `json:"reason" example:"missing required parameter: lat"`
+	// problemType is the problem type of the reason
+	problemType string
+}
+
+// AmbiguousCityProblem lists the places matching a city name, the request can be retried with their coordinates
+// or a country
+type AmbiguousCityProblem struct {
+	Problem
+	Candidates []models.Place `json:"candidates"`
+}
+
+// QuorumProblem represents an aggregate refused for lack of providers
+type QuorumProblem struct {
+	Problem
+	Required  int                      `json:"required" example:"2"`
+	Available int                      `json:"available" example:"1"`
+	Failures  []models.ProviderFailure `json:"failures"`
+}
+
+// ValidationError collects the invalid parameters of a request, so that a request is rejected with all of them
+type ValidationError struct {
+	Params []InvalidParam
+}
+
+func (e *ValidationError) Error() string {
+	reasons := make([]string, len(e.Params))
+	for i, p := range e.Params {
+		reasons[i] = p.Reason
+	}
+
+	return strings.Join(reasons, "; ")
+}
+
+func (e *ValidationError) add(problemType, name, reason string) {
+	e.Params = append(e.Params, InvalidParam{Name: name, Reason: reason, problemType: problemType})
+}
+
+func (e *ValidationError) missing(name string) {
+	e.add(ProblemMissingParameter, name, "missing required parameter: "+name)
+}
+
+func (e *ValidationError) outOfRange(name, reason string) {
+	e.add(ProblemOutOfRange, name, reason)
+}
+
+func (e *ValidationError) invalid(name, reason string) {
+	e.add(ProblemInvalidParameter, name, reason)
+}
+
+// err returns the collected problems, nil when there are none
+func (e *ValidationError) err() error {
+	if len(e.Params) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// problemType is the type shared by all the invalid parameters, ProblemInvalidParameter when they differ
+func (e *ValidationError) problemType() string {
+	typ := e.Params[0].problemType
+	for _, p := range e.Params[1:] {
+		if p.problemType != typ {
+			return ProblemInvalidParameter
+		}
+	}
+
+	return typ
+}
+
+// paramError reports an invalid parameter rejected by a parser, e.g. units.Parse
+func paramError(name string, err error) error {
+	v := &ValidationError{}
+	v.invalid(name, err.Error())
+
+	return v
+}
+
+// newProblem builds the problem of the request
+func newProblem(c *fiber.Ctx, status int, problemType, detail string) Problem {
+	return Problem{
+		Type:      problemType,
+		Title:     problemTitles[problemType],
+		Status:    status,
+		Detail:    detail,
+		Instance:  c.OriginalURL(),
+		RequestID: requestid.FromContext(c.UserContext()),
+	}
+}
+
+// sendProblem responds with a problem, or a type embedding one
+func sendProblem(c *fiber.Ctx, status int, body any) error {
+	return c.Status(status).JSON(body, problemContentType)
+}
+
+// problem responds with a problem of the given type
+func problem(c *fiber.Ctx, status int, problemType, detail string) error {
+	return sendProblem(c, status, newProblem(c, status, problemType, detail))
+}
+
+// validationProblem responds 400 to invalid parameters, a ValidationError lists them in invalid_params
+func validationProblem(c *fiber.Ctx, err error) error {
+	var v *ValidationError
+	if !errors.As(err, &v) {
+		return problem(c, fiber.StatusBadRequest, ProblemInvalidParameter, err.Error())
+	}
+
+	p := newProblem(c, fiber.StatusBadRequest, v.problemType(), v.Error())
+	p.InvalidParams = v.Params
+
+	return sendProblem(c, fiber.StatusBadRequest, p)
+}
+
+// fetchProblem responds to a service error
+func fetchProblem(c *fiber.Ctx, err error) error {
+	status, problemType, detail := fetchErrorStatus(err)
+
+	return problem(c, status, problemType, detail)
+}
+
+// upstreamProblem responds to a failed provider, a provider rate limit is passed on to the client as 429
+func upstreamProblem(c *fiber.Ctx, errorCode, detail string) error {
+	if errorCode == models.ErrorCodeRateLimited {
+		return problem(c, fiber.StatusTooManyRequests, ProblemRateLimited, detail)
+	}
+
+	return problem(c, fiber.StatusBadGateway, ProblemUpstreamFailed, detail)
+}
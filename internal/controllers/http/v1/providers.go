@@ -0,0 +1,76 @@
+package http
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/requestid"
+)
+
+// GetProviders godoc
+// @Summary Get provider status
+// @Description Lists the configured forecast providers with whether they need an API key, the result of their last
+// @Description health check, their circuit state, and the error rate and average latency of their recent calls.
+// @Description Providers never checked, or last checked over a minute ago, are checked first with a short timeout.
+// @Tags Providers
+// @Produce json
+// @Success 200 {array} models.ProviderStatus "Provider status"
+// @Router /providers [get]
+// @Example {curl} Example usage:
+//
+//	curl -X GET "http://localhost:8080/providers"
+func (r *routes) handleProvidersCall(c *fiber.Ctx) error {
+	return c.JSON(r.service.ProviderStatus(c.UserContext()))
+}
+
+// GetProviderAccuracy godoc
+// @Summary Get the accuracy of the providers at a location
+// @Description Lists the mean absolute errors of the stored forecasts of every provider against the weather observed
+// @Description by the archive, over the scoring window and by lead time, the number of days between the fetch and the
+// @Description day forecast. The forecasts are scored by a background job, see store.accuracy. The coordinates are
+// @Description rounded to 4 decimals.
+// @Tags Providers
+// @Produce json
+// @Param lat query number false "Lat coordinate (-90 to 90), required without coords" minimum(-90) maximum(90) example(40.7128)
+// @Param lon query number false "Lon coordinate (-180 to 180), required without coords" minimum(-180) maximum(180) example(-74.006)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(40.7128,-74.006)
+// @Success 200 {object} models.AccuracyReport "Accuracy of the providers"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 422 {object} Problem "No forecast store configured"
+// @Failure 500 {object} Problem "Internal server error"
+// @Router /providers/accuracy [get]
+// @Example {curl} Example usage:
+//
+//	curl -X GET "http://localhost:8080/providers/accuracy?lat=40.7128&lon=-74.006"
+func (r *routes) handleAccuracyCall(c *fiber.Ctx) error {
+	v := &ValidationError{}
+	lat, lon := checkLocation(c, v, r.normalizeLongitude)
+	if err := v.err(); err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(c.UserContext()),
+			"lat":        c.Query("lat"),
+			"lon":        c.Query("lon"),
+		})
+
+		return validationProblem(c, err)
+	}
+
+	report, err := r.service.ProviderAccuracy(c.UserContext(), lat, lon)
+	if errors.Is(err, weather.ErrNoForecastStore) {
+		return problem(c, fiber.StatusUnprocessableEntity, ProblemUnprocessable,
+			"The forecasts are not stored, set store.path to enable it")
+	}
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(c.UserContext()),
+			"lat":        lat,
+			"lon":        lon,
+		})
+
+		return fetchProblem(c, err)
+	}
+
+	return c.JSON(report)
+}
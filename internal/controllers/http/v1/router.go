@@ -1,47 +1,204 @@
 package http
 
 import (
-	"os"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/swagger"
 
+	"weather-api/docs"
+	"weather-api/internal/buildinfo"
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
 	"weather-api/internal/services/weather"
+	"weather-api/pkg/geo"
 	"weather-api/pkg/logger"
 )
 
+// Forecaster is the part of the weather service used by the routes, *weather.WeatherService implements it
+type Forecaster interface {
+	Providers() []string
+	RequestBudget() time.Duration
+	HistoryMaxDays() int
+	MaxForecastDays() int
+	CacheTTL() time.Duration
+	BatchMaxItems() int
+	BatchRequestBudget(items int) time.Duration
+	SubscriptionLimits() (minInterval, maxDuration time.Duration)
+	FetchProviderForecasts(ctx context.Context, lat, lon float64, forecastWindow int, providers []string) (map[string]models.Forecast, error)
+	FetchFirstForecast(ctx context.Context, lat, lon float64, forecastWindow int, providers []string) (models.Forecast, error)
+	FetchForecastsStream(ctx context.Context, lat, lon float64, forecastWindow int, providers []string) (<-chan models.Forecast, error)
+	AggregateForecasts(ctx context.Context, lat, lon float64, forecastWindow int, strategy string, minProviders int) (models.AggregatedForecast, error)
+	FetchDateForecasts(ctx context.Context, lat, lon float64, date models.Date, providers []string) (map[string]models.Forecast, error)
+	AggregateDateForecasts(ctx context.Context, lat, lon float64, date models.Date, strategy string, minProviders int) (models.AggregatedForecast, error)
+	SharedAggregateForecasts(ctx context.Context, lat, lon float64, forecastWindow int, strategy string) (models.AggregatedForecast, error)
+	CompareForecasts(ctx context.Context, lat, lon float64, forecastWindow int) (models.ForecastComparison, error)
+	FetchCurrentWeather(ctx context.Context, lat, lon float64) (map[string]models.CurrentWeather, error)
+	FetchHistory(ctx context.Context, lat, lon float64, start, end models.Date) (map[string]models.HistoricalWeather, error)
+	FetchBatchForecasts(ctx context.Context, locations []weather.Location) ([]map[string]models.Forecast, error)
+	FetchAirQuality(ctx context.Context, lat, lon float64, days int) (models.AirQuality, error)
+	FetchAlerts(ctx context.Context, lat, lon float64) (models.AlertReport, error)
+	FetchMarineForecast(ctx context.Context, lat, lon float64, days int) (models.MarineForecast, error)
+	StoredForecasts(ctx context.Context, lat, lon float64, date models.Date) ([]models.StoredForecast, error)
+	ProviderStatus(ctx context.Context) []models.ProviderStatus
+	ProviderAccuracy(ctx context.Context, lat, lon float64) (models.AccuracyReport, error)
+	WebhookMinInterval() time.Duration
+	CreateSubscription(ctx context.Context, sub models.Subscription) (models.Subscription, error)
+	Subscription(ctx context.Context, owner, id string) (models.Subscription, error)
+	Subscriptions(ctx context.Context, owner string) ([]models.Subscription, error)
+	DeleteSubscription(ctx context.Context, owner, id string) error
+}
+
+var _ Forecaster = (*weather.WeatherService)(nil)
+
+// Geocoder resolves the city parameter and serves /geocode, *repositories.GeocodingRepository implements it
+type Geocoder interface {
+	Resolve(ctx context.Context, name, country string) (models.Place, error)
+	Search(ctx context.Context, name, country string) ([]models.Place, error)
+	Reverse(ctx context.Context, lat, lon float64) (models.Place, error)
+}
+
+var _ Geocoder = (*repositories.GeocodingRepository)(nil)
+
 type routes struct {
-	service *weather.WeatherService
-	l       *logger.Logger
+	service  Forecaster
+	geocoder Geocoder
+	// locator locates the callers of /weather without coordinates, nil disables it
+	locator      repositories.IPLocator
+	trustedProxy bool
+	// area rejects the locations the API doesn't serve, the zero area serves them all
+	area geo.Area
+	// normalizeLongitude brings the longitudes beyond 180 degrees back into range instead of rejecting them
+	normalizeLongitude bool
+	build              buildinfo.Info
+	// deprecation sets the headers of a deprecated alias, nil for the current routes
+	deprecation fiber.Handler
+	l           logger.Logger
 }
 
-func NewRouter(
-	app *fiber.App,
-	weatherService *weather.WeatherService,
-	l *logger.Logger,
-) {
-	r := &routes{
-		service: weatherService,
-		l:       l,
+// RouterOption configures the optional behaviors of the routes
+type RouterOption func(*routes)
+
+// WithIPLocator locates the callers of /weather without coordinates from their IP address
+func WithIPLocator(locator repositories.IPLocator) RouterOption {
+	return func(r *routes) {
+		r.locator = locator
 	}
+}
 
-	// Swagger documentation
-	app.Get("/swagger/doc.json", func(c *fiber.Ctx) error {
-		// Read the generated swagger.json file
-		swaggerData, err := os.ReadFile("docs/swagger.json")
-		if err != nil {
-			return c.Status(fiber.ErrInternalServerError.Code).JSON(fiber.Map{"error": "Failed to read Swagger documentation"})
-		}
+// WithTrustedProxy takes the client address from the X-Forwarded-For header of the reverse proxy
+func WithTrustedProxy(trusted bool) RouterOption {
+	return func(r *routes) {
+		r.trustedProxy = trusted
+	}
+}
+
+// WithLongitudeNormalization accepts the longitudes beyond 180 degrees, e.g. 200 as -160, they are rejected as
+// out of range otherwise
+func WithLongitudeNormalization(enabled bool) RouterOption {
+	return func(r *routes) {
+		r.normalizeLongitude = enabled
+	}
+}
+
+// WithBuildInfo sets the build served by /version, the application is named weather-api without it
+func WithBuildInfo(info buildinfo.Info) RouterOption {
+	return func(r *routes) {
+		r.build = info
+	}
+}
 
+// LegacySunset is the date the unversioned aliases of the v1 routes are removed
+var LegacySunset = time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC)
+
+// WithDeprecation marks the routes as a deprecated alias of the routes under successor, the prefix of the
+// version replacing them. Their responses carry the Deprecation, Sunset and Link headers.
+func WithDeprecation(sunset time.Time, successor string) RouterOption {
+	return func(r *routes) {
+		r.deprecation = deprecationHeaders(sunset, successor)
+	}
+}
+
+// NewDocsRouter serves the swagger documentation of the API
+func NewDocsRouter(router fiber.Router) {
+	router.Get("/swagger/doc.json", func(c *fiber.Ctx) error {
+		// The generated swagger.json is embedded in the binary, see docs.SwaggerJSON
 		c.Set("Content-Type", "application/json")
-		return c.Send(swaggerData)
+		return c.Send(docs.SwaggerJSON())
 	})
 
-	app.Get("/swagger/*", swagger.New(swagger.Config{
+	router.Get("/swagger/*", swagger.New(swagger.Config{
 		URL:         "/swagger/doc.json",
 		DeepLinking: true,
 	}))
+}
+
+// NewRouter mounts the v1 API routes on router, usually the /v1 group of the app so that another version
+// can be mounted next to it
+func NewRouter(
+	router fiber.Router,
+	weatherService Forecaster,
+	geocoder Geocoder,
+	l logger.Logger,
+	opts ...RouterOption,
+) {
+	r := newRoutes(weatherService, geocoder, l, opts...)
+
+	// handlers prepends the deprecation headers of an alias, a group middleware would apply to every route
+	// sharing the prefix of the alias
+	handlers := func(handler fiber.Handler) []fiber.Handler {
+		if r.deprecation == nil {
+			return []fiber.Handler{handler}
+		}
+		return []fiber.Handler{r.deprecation, handler}
+	}
 
 	// API routes
-	app.Get("/weather", r.handleWeatherCall)
+	router.Get("/weather", handlers(r.handleWeatherCall)...)
+	router.Get("/weather/aggregate", handlers(r.handleAggregateCall)...)
+	router.Get("/weather/compare", handlers(r.handleCompareCall)...)
+	router.Get("/weather/subscribe", handlers(r.handleSubscribeCall)...)
+	router.Get("/weather/current", handlers(r.handleCurrentCall)...)
+	router.Get("/weather/history", handlers(r.handleHistoryCall)...)
+	router.Get("/weather/alerts", handlers(r.handleAlertsCall)...)
+	router.Get("/weather/marine", handlers(r.handleMarineCall)...)
+	router.Get("/weather/stored", handlers(r.handleStoredCall)...)
+	router.Post("/weather/batch", handlers(r.handleBatchCall)...)
+	router.Get("/geocode", handlers(r.handleGeocodeCall)...)
+	router.Get("/air-quality", handlers(r.handleAirQualityCall)...)
+	router.Get("/providers", handlers(r.handleProvidersCall)...)
+	router.Get("/providers/accuracy", handlers(r.handleAccuracyCall)...)
+	router.Get("/version", handlers(r.handleVersionCall)...)
+	router.Post("/subscriptions", handlers(r.handleCreateSubscriptionCall)...)
+	router.Get("/subscriptions", handlers(r.handleListSubscriptionsCall)...)
+	router.Get("/subscriptions/:id", handlers(r.handleGetSubscriptionCall)...)
+	router.Delete("/subscriptions/:id", handlers(r.handleDeleteSubscriptionCall)...)
+}
+
+func newRoutes(weatherService Forecaster, geocoder Geocoder, l logger.Logger, opts ...RouterOption) *routes {
+	r := &routes{
+		service:  weatherService,
+		geocoder: geocoder,
+		build:    buildinfo.Get("weather-api"),
+		l:        l,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
+}
+
+// deprecationHeaders announces the removal of a deprecated route, RFC 8594, and links the route replacing it
+func deprecationHeaders(sunset time.Time, successor string) fiber.Handler {
+	sunsetHeader := sunset.UTC().Format(http.TimeFormat)
+	return func(c *fiber.Ctx) error {
+		c.Set("Deprecation", "true")
+		c.Set("Sunset", sunsetHeader)
+		c.Append(fiber.HeaderLink, fmt.Sprintf(`<%s%s>; rel="successor-version"`, successor, c.Path()))
+		return c.Next()
+	}
 }
@@ -0,0 +1,87 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/models"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/requestid"
+)
+
+// GetStoredForecasts godoc
+// @Summary Get the stored forecasts of a day
+// @Description Retrieves what the providers predicted for a day at a location, every successful fetch recorded by the forecast store, the oldest first. The coordinates are rounded to 4 decimals.
+// @Tags Weather
+// @Accept json
+// @Produce json
+// @Param lat query number false "Lat coordinate (-90 to 90), required without coords" minimum(-90) maximum(90) example(40.7128)
+// @Param lon query number false "Lon coordinate (-180 to 180), required without coords" minimum(-180) maximum(180) example(-74.006)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(40.7128,-74.006)
+// @Param date query string true "Forecast day (YYYY-MM-DD)" example(2024-01-15)
+// @Success 200 {array} models.StoredForecast "Stored forecasts of the day"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 422 {object} Problem "No forecast store configured"
+// @Failure 500 {object} Problem "Internal server error"
+// @Failure 504 {object} Problem "Request budget exceeded"
+// @Router /weather/stored [get]
+// @Example {curl} Example usage:
+//
+//	curl -X GET "http://localhost:8080/weather/stored?lat=40.7128&lon=-74.006&date=2024-01-15"
+func (r *routes) handleStoredCall(c *fiber.Ctx) error {
+	lat, lon, date, err := validateStoredParameters(c, r.normalizeLongitude)
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(c.UserContext()),
+			"lat":        c.Query("lat"),
+			"lon":        c.Query("lon"),
+			"date":       c.Query("date"),
+		})
+
+		return validationProblem(c, err)
+	}
+
+	ctx, cancel := r.requestContext(c, r.service.RequestBudget())
+	defer cancel()
+
+	stored, err := r.service.StoredForecasts(ctx, lat, lon, date)
+	if errors.Is(err, weather.ErrNoForecastStore) {
+		return problem(c, fiber.StatusUnprocessableEntity, ProblemUnprocessable,
+			"The forecasts are not stored, set store.path to enable it")
+	}
+	if err != nil {
+		r.l.Error(err, map[string]any{
+			"request_id": requestid.FromContext(ctx),
+			"lat":        lat,
+			"lon":        lon,
+			"date":       date.String(),
+		})
+
+		return fetchProblem(c, err)
+	}
+
+	return c.JSON(stored)
+}
+
+// validateStoredParameters parses the required location and date of a stored forecasts request, see checkLocation
+// for normalizeLon
+func validateStoredParameters(c *fiber.Ctx, normalizeLon bool) (float64, float64, models.Date, error) {
+	v := &ValidationError{}
+	lat, lon := checkLocation(c, v, normalizeLon)
+
+	s := c.Query("date")
+	date, err := models.ParseDate(s)
+	switch {
+	case s == "":
+		v.missing("date")
+	case err != nil:
+		v.invalid("date", fmt.Sprintf("invalid date: %s, expected YYYY-MM-DD", s))
+	}
+	if err := v.err(); err != nil {
+		return 0, 0, models.Date{}, err
+	}
+
+	return lat, lon, date, nil
+}
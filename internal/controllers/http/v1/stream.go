@@ -0,0 +1,84 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/pkg/httpserver"
+	"weather-api/pkg/requestid"
+)
+
+const ndjsonContentType = "application/x-ndjson"
+
+// streamForecasts writes every provider forecast as a JSON line as soon as it is fetched, failed providers
+// included, the days only have the requested fields. The body is written after the handler returned, so the fetches run in a context detached from the
+// request that keeps its deadline. A client going away is noticed at the next line, which cancels the remaining
+// fetches.
+func (r *routes) streamForecasts(reqCtx context.Context, c *fiber.Ctx, lat, lon float64, forecastWindow int, providers []string, system string, fields fieldSet) error {
+	deadline, _ := reqCtx.Deadline()
+	ctx, cancel := context.WithDeadline(r.requestValues(context.Background(), c), deadline)
+
+	requestID := requestid.FromContext(ctx)
+	results, err := r.service.FetchForecastsStream(ctx, lat, lon, forecastWindow, providers)
+	if err != nil {
+		cancel()
+		r.l.Error(err, map[string]any{
+			"request_id":     requestID,
+			"lat":            lat,
+			"lon":            lon,
+			"forecastWindow": forecastWindow,
+		})
+
+		return fetchProblem(c, err)
+	}
+
+	writeDeadline := httpserver.NewStreamDeadline(c)
+	c.Set(fiber.HeaderContentType, ndjsonContentType)
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		enc := json.NewEncoder(w)
+		streamed := 0
+		for forecast := range results {
+			// Providers always report metric values
+			forecast.ConvertUnits(system)
+			writeDeadline.Renew()
+			if err := enc.Encode(fields.forecast(forecast)); err != nil {
+				r.l.Error(err, map[string]any{"request_id": requestID, "repo": forecast.RepositoryName})
+				return
+			}
+			if err := w.Flush(); err != nil {
+				r.l.Warning("forecast stream aborted by the client", map[string]any{
+					"request_id": requestID,
+					"streamed":   streamed,
+					"err":        err.Error(),
+				})
+				return
+			}
+			streamed++
+		}
+	})
+
+	return nil
+}
+
+// validateStreamParameters rejects the parameters a stream of forecasts can't honor
+func validateStreamParameters(c *fiber.Ctx, mode string) error {
+	v := &ValidationError{}
+	if mode != modeAll {
+		v.invalid("mode", fmt.Sprintf("stream can't be combined with mode=%s", mode))
+	}
+	if format := c.Query("format"); format != "" && !strings.EqualFold(format, formatJSON) {
+		v.invalid("format", fmt.Sprintf("stream can't be combined with format=%s", format))
+	}
+	if c.QueryBool("resolve_name") {
+		v.invalid("resolve_name", "stream can't be combined with resolve_name")
+	}
+
+	return v.err()
+}
@@ -0,0 +1,195 @@
+package http
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/httpserver"
+	"weather-api/pkg/requestid"
+	"weather-api/pkg/units"
+)
+
+const (
+	eventStreamContentType   = "text/event-stream"
+	defaultSubscribeInterval = 10 * time.Minute
+	// subscribeHeartbeat is the longest time without writing to a subscriber, a client going away is only
+	// noticed on a write
+	subscribeHeartbeat = 15 * time.Second
+)
+
+// SubscribeForecast godoc
+// @Summary Subscribe to live aggregated forecasts
+// @Description Keeps the connection open and pushes a forecast event with a fresh aggregate every interval, an
+// @Description error event when it can't be fetched. The server closes the subscription after the configured
+// @Description maximum duration, subscribers to the same location share the upstream fetches.
+// @Tags Weather
+// @Produce text/event-stream
+// @Param lat query number false "Lat coordinate (-90 to 90), required without coords" minimum(-90) maximum(90) example(40.7128)
+// @Param lon query number false "Lon coordinate (-180 to 180), required without coords" minimum(-180) maximum(180) example(-74.006)
+// @Param coords query string false "Lat and lon combined as lat,lon, instead of lat and lon" example(40.7128,-74.006)
+// @Param days query integer false "Number of forecast days (1 to the configured maximum, 16 by default, default: 5)" minimum(1) maximum(16) example(3)
+// @Param units query string false "Unit system of the returned values (default: metric)" Enums(metric, imperial)
+// @Param strategy query string false "Aggregation strategy (default: mean)" Enums(mean, median, weighted_mean, extremes)
+// @Param interval query string false "Time between updates, at least the configured minimum (default: 10m)" example(10m)
+// @Success 200 {object} models.AggregatedForecast "Stream of forecast events"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 422 {object} Problem "The location is outside the service area"
+// @Router /weather/subscribe [get]
+func (r *routes) handleSubscribeCall(c *fiber.Ctx) error {
+	lat, lon, forecastWindow, err := validateParameters(c, r.service.MaxForecastDays(), r.normalizeLongitude)
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	if err := r.area.Check(lat, lon); err != nil {
+		return areaProblem(c, err)
+	}
+
+	system, err := units.Parse(c.Query("units"))
+	if err != nil {
+		return validationProblem(c, paramError("units", err))
+	}
+
+	strategy, err := weather.ParseStrategy(c.Query("strategy"))
+	if err != nil {
+		return validationProblem(c, paramError("strategy", err))
+	}
+
+	minInterval, maxDuration := r.service.SubscriptionLimits()
+	interval, err := parseInterval(c.Query("interval"), minInterval)
+	if err != nil {
+		return validationProblem(c, err)
+	}
+
+	// The stream is written after the handler returned, the request is no longer available then
+	ctx, cancel := context.WithTimeout(r.requestValues(context.Background(), c), maxDuration)
+	instance := strings.Clone(c.OriginalURL())
+	deadline := httpserver.NewStreamDeadline(c)
+
+	c.Set(fiber.HeaderContentType, eventStreamContentType)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer cancel()
+
+		requestID := requestid.FromContext(ctx)
+		fetch := func(id int) error {
+			fetchCtx, cancelFetch := context.WithTimeout(ctx, r.service.RequestBudget())
+			defer cancelFetch()
+
+			aggregated, err := r.service.SharedAggregateForecasts(fetchCtx, lat, lon, forecastWindow, strategy)
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			deadline.Renew()
+			if err != nil {
+				r.l.Error(err, map[string]any{"request_id": requestID, "lat": lat, "lon": lon, "strategy": strategy})
+				return writeEvent(w, id, "error", subscriptionProblem(err, instance, requestID))
+			}
+
+			aggregated.ConvertUnits(system)
+			return writeEvent(w, id, "forecast", aggregated)
+		}
+
+		// Reconnecting clients wait for the next update
+		if _, err := fmt.Fprintf(w, "retry: %d\n", interval.Milliseconds()); err != nil {
+			return
+		}
+
+		updates := time.NewTicker(interval)
+		defer updates.Stop()
+		heartbeat := time.NewTicker(subscribeHeartbeat)
+		defer heartbeat.Stop()
+
+		id := 1
+		err := fetch(id)
+		for err == nil {
+			select {
+			case <-ctx.Done():
+				err = ctx.Err()
+			case <-updates.C:
+				id++
+				err = fetch(id)
+			case <-heartbeat.C:
+				deadline.Renew()
+				err = writeComment(w, "keep-alive")
+			}
+		}
+
+		r.l.Info("forecast subscription closed", map[string]any{
+			"request_id": requestID,
+			"updates":    id,
+			"err":        err.Error(),
+		})
+	})
+
+	return nil
+}
+
+// parseInterval parses the update interval of a subscription, empty selects the default
+func parseInterval(s string, minInterval time.Duration) (time.Duration, error) {
+	if s == "" {
+		return max(defaultSubscribeInterval, minInterval), nil
+	}
+
+	v := &ValidationError{}
+	interval, err := time.ParseDuration(s)
+	switch {
+	case err != nil:
+		v.invalid("interval", fmt.Sprintf("interval must be a duration such as 10m, got: %s", s))
+	case interval < minInterval:
+		v.outOfRange("interval", fmt.Sprintf("interval must be at least %s, got: %s", minInterval, s))
+	}
+
+	return interval, v.err()
+}
+
+// subscriptionProblem describes a failed update in an error event
+func subscriptionProblem(err error, instance, requestID string) Problem {
+	status, problemType, detail := fetchErrorStatus(err)
+	var quorumErr *weather.QuorumError
+	switch {
+	case errors.Is(err, weather.ErrNoForecasts):
+		status, problemType, detail = fiber.StatusBadGateway, ProblemUpstreamFailed, "All weather providers failed"
+	case errors.As(err, &quorumErr):
+		status, problemType, detail = fiber.StatusServiceUnavailable, ProblemQuorumNotMet, "Not enough weather providers returned data"
+	}
+
+	return Problem{
+		Type:      problemType,
+		Title:     problemTitles[problemType],
+		Status:    status,
+		Detail:    detail,
+		Instance:  instance,
+		RequestID: requestID,
+	}
+}
+
+// writeEvent writes a server-sent event with the JSON of data and flushes it to the client
+func writeEvent(w *bufio.Writer, id int, event string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, payload); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
+
+// writeComment writes a comment line, ignored by the clients, and flushes it
+func writeComment(w *bufio.Writer, comment string) error {
+	if _, err := fmt.Fprintf(w, ": %s\n\n", comment); err != nil {
+		return err
+	}
+
+	return w.Flush()
+}
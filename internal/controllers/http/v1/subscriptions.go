@@ -0,0 +1,209 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/geo"
+	"weather-api/pkg/httpserver"
+	"weather-api/pkg/requestid"
+)
+
+// defaultCheckInterval is the check interval of a subscription without one
+const defaultCheckInterval = 30 * time.Minute
+
+// SubscriptionRequest creates a webhook subscription, check_interval defaults to 30m
+type SubscriptionRequest struct {
+	Lat           *float64                      `json:"lat" example:"40.7128"`
+	Lon           *float64                      `json:"lon" example:"-74.006"`
+	Condition     *models.SubscriptionCondition `json:"condition"`
+	WebhookURL    string                        `json:"webhook_url" example:"https://example.com/hooks/weather"`
+	CheckInterval string                        `json:"check_interval,omitempty" example:"30m"`
+}
+
+// CreateSubscription godoc
+// @Summary Subscribe a webhook to a forecast threshold
+// @Description Checks the aggregated forecast of the location every check interval and posts a notification to
+// @Description the webhook when a day of the next condition.days days meets the condition, e.g. temp_max gte 35 in
+// @Description °C. A subscription is notified once until its condition is unmet again. The notifications are
+// @Description signed with the secret returned here only. The subscriptions are kept by the forecast store, and
+// @Description belong to the API key creating them: the other keys can't read nor delete them.
+// @Tags Subscriptions
+// @Accept json
+// @Produce json
+// @Param subscription body SubscriptionRequest true "Subscription"
+// @Success 201 {object} models.Subscription "Subscription, with its secret"
+// @Failure 400 {object} Problem "Bad request - invalid parameters"
+// @Failure 422 {object} Problem "No forecast store configured, or the location is outside the service area"
+// @Failure 500 {object} Problem "Internal server error"
+// @Router /subscriptions [post]
+// @Example {curl} Example usage:
+//
+//	curl -X POST "http://localhost:8080/subscriptions" -d '{"lat":40.7128,"lon":-74.006,"condition":{"field":"temp_max","op":"gte","value":35,"days":3},"webhook_url":"https://example.com/hooks/weather"}'
+func (r *routes) handleCreateSubscriptionCall(c *fiber.Ctx) error {
+	var req SubscriptionRequest
+	if err := json.Unmarshal(c.Body(), &req); err != nil {
+		return problem(c, fiber.StatusBadRequest, ProblemInvalidParameter,
+			"invalid request body, expected a JSON subscription")
+	}
+
+	sub, err := r.validateSubscription(req)
+	if err != nil {
+		return validationProblem(c, err)
+	}
+	sub.Owner = httpserver.APIKeyName(c)
+	if err := r.area.Check(sub.Lat, sub.Lon); err != nil {
+		return areaProblem(c, err)
+	}
+
+	created, err := r.service.CreateSubscription(c.UserContext(), sub)
+	if err != nil {
+		return r.subscriptionProblem(c, err, "")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(created)
+}
+
+// validateSubscription checks the subscription request, the condition days default to 3
+func (r *routes) validateSubscription(req SubscriptionRequest) (models.Subscription, error) {
+	v := &ValidationError{}
+	sub := models.Subscription{WebhookURL: req.WebhookURL}
+
+	// JSON has no NaN nor infinities, the range is the only check left
+	switch {
+	case req.Lat == nil:
+		v.missing("lat")
+	case *req.Lat < minLatitude || *req.Lat > maxLatitude:
+		v.outOfRange("lat", fmt.Sprintf("latitude must be between %d and %d, got: %f", minLatitude, maxLatitude, *req.Lat))
+	default:
+		sub.Lat = normalizeCoordinate(*req.Lat)
+	}
+	lon := req.Lon
+	if lon != nil && r.normalizeLongitude {
+		normalized := geo.NormalizeLongitude(*lon)
+		lon = &normalized
+	}
+	switch {
+	case lon == nil:
+		v.missing("lon")
+	case *lon < minLongitude || *lon > maxLongitude:
+		v.outOfRange("lon", fmt.Sprintf("longitude must be between %d and %d, got: %f", minLongitude, maxLongitude, *lon))
+	default:
+		sub.Lon = normalizeCoordinate(*lon)
+	}
+
+	if req.Condition == nil {
+		v.missing("condition")
+	} else if condition, err := weather.ParseCondition(*req.Condition, r.service.MaxForecastDays()); err != nil {
+		v.invalid("condition", err.Error())
+	} else {
+		sub.Condition = condition
+	}
+
+	if u, err := url.Parse(req.WebhookURL); req.WebhookURL == "" {
+		v.missing("webhook_url")
+	} else if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		v.invalid("webhook_url", fmt.Sprintf("webhook_url must be an http or https URL, got: %s", req.WebhookURL))
+	}
+
+	minInterval := r.service.WebhookMinInterval()
+	interval := max(defaultCheckInterval, minInterval)
+	if req.CheckInterval != "" {
+		var err error
+		interval, err = time.ParseDuration(req.CheckInterval)
+		switch {
+		case err != nil:
+			v.invalid("check_interval", fmt.Sprintf("check_interval must be a duration such as 30m, got: %s", req.CheckInterval))
+		case interval < minInterval:
+			v.outOfRange("check_interval", fmt.Sprintf("check_interval must be at least %s, got: %s", minInterval, req.CheckInterval))
+		}
+	}
+	sub.CheckIntervalSeconds = int(interval.Seconds())
+
+	return sub, v.err()
+}
+
+// ListSubscriptions godoc
+// @Summary List the webhook subscriptions
+// @Description Lists the subscriptions of the API key, the oldest first, without their secrets
+// @Tags Subscriptions
+// @Produce json
+// @Success 200 {array} models.Subscription "Subscriptions"
+// @Failure 422 {object} Problem "No forecast store configured"
+// @Failure 500 {object} Problem "Internal server error"
+// @Router /subscriptions [get]
+func (r *routes) handleListSubscriptionsCall(c *fiber.Ctx) error {
+	subs, err := r.service.Subscriptions(c.UserContext(), httpserver.APIKeyName(c))
+	if err != nil {
+		return r.subscriptionProblem(c, err, "")
+	}
+
+	return c.JSON(subs)
+}
+
+// GetSubscription godoc
+// @Summary Get a webhook subscription
+// @Description Returns the subscription without its secret, with the state of its notifications. A subscription
+// @Description whose deliveries failed repeatedly is disabled. The subscriptions of another API key are unknown.
+// @Tags Subscriptions
+// @Produce json
+// @Param id path string true "Subscription ID"
+// @Success 200 {object} models.Subscription "Subscription"
+// @Failure 404 {object} Problem "Unknown subscription"
+// @Failure 422 {object} Problem "No forecast store configured"
+// @Failure 500 {object} Problem "Internal server error"
+// @Router /subscriptions/{id} [get]
+func (r *routes) handleGetSubscriptionCall(c *fiber.Ctx) error {
+	id := c.Params("id")
+	sub, err := r.service.Subscription(c.UserContext(), httpserver.APIKeyName(c), id)
+	if err != nil {
+		return r.subscriptionProblem(c, err, id)
+	}
+
+	return c.JSON(sub)
+}
+
+// DeleteSubscription godoc
+// @Summary Delete a webhook subscription
+// @Description The subscriptions of another API key are unknown
+// @Tags Subscriptions
+// @Param id path string true "Subscription ID"
+// @Success 204 "Deleted"
+// @Failure 404 {object} Problem "Unknown subscription"
+// @Failure 422 {object} Problem "No forecast store configured"
+// @Failure 500 {object} Problem "Internal server error"
+// @Router /subscriptions/{id} [delete]
+func (r *routes) handleDeleteSubscriptionCall(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if err := r.service.DeleteSubscription(c.UserContext(), httpserver.APIKeyName(c), id); err != nil {
+		return r.subscriptionProblem(c, err, id)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// subscriptionProblem answers a failed subscription call
+func (r *routes) subscriptionProblem(c *fiber.Ctx, err error, id string) error {
+	switch {
+	case errors.Is(err, weather.ErrNoForecastStore):
+		return problem(c, fiber.StatusUnprocessableEntity, ProblemUnprocessable,
+			"The subscriptions are not stored, set store.path to enable them")
+	case errors.Is(err, repositories.ErrSubscriptionNotFound):
+		return problem(c, fiber.StatusNotFound, ProblemNotFound, fmt.Sprintf("unknown subscription: %s", id))
+	}
+
+	r.l.Error(err, map[string]any{
+		"request_id":   requestid.FromContext(c.UserContext()),
+		"subscription": id,
+	})
+
+	return fetchProblem(c, err)
+}
@@ -0,0 +1,160 @@
+package http
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"time"
+
+	"weather-api/internal/models"
+)
+
+// forecastsBody is the /weather response, the forecasts keyed by provider with the place name in a location
+// key when it was requested and found, the days only have the requested fields
+type forecastsBody struct {
+	forecasts map[string]models.Forecast
+	location  *ResponseLocation
+	fields    fieldSet
+}
+
+func (b forecastsBody) MarshalJSON() ([]byte, error) {
+	if b.location == nil && b.fields == nil {
+		return json.Marshal(b.forecasts)
+	}
+
+	response := make(map[string]any, len(b.forecasts)+1)
+	for name, forecast := range b.forecasts {
+		response[name] = b.fields.forecast(forecast)
+	}
+	if b.location != nil {
+		response["location"] = b.location
+	}
+
+	return json.Marshal(response)
+}
+
+// dayColumn is an optional column of the forecast table, written when at least one day has a value
+type dayColumn struct {
+	name  string
+	value func(wd models.WeatherData) string
+}
+
+var dayColumns = []dayColumn{
+	{"feels_like_min", func(wd models.WeatherData) string { return formatOptional(wd.FeelsLikeMin) }},
+	{"feels_like_max", func(wd models.WeatherData) string { return formatOptional(wd.FeelsLikeMax) }},
+	{"precipitation_sum", func(wd models.WeatherData) string { return formatOptional(wd.PrecipitationSum) }},
+	{"precipitation_probability", func(wd models.WeatherData) string { return formatOptional(wd.PrecipitationProbability) }},
+	{"wind_speed_max", func(wd models.WeatherData) string { return formatOptional(wd.WindSpeedMax) }},
+	{"wind_gusts_max", func(wd models.WeatherData) string { return formatOptional(wd.WindGustsMax) }},
+	{"humidity_mean", func(wd models.WeatherData) string { return formatOptional(wd.HumidityMean) }},
+	{"sunrise", func(wd models.WeatherData) string { return formatTime(wd.Sunrise) }},
+	{"sunset", func(wd models.WeatherData) string { return formatTime(wd.Sunset) }},
+	{"uv_index_max", func(wd models.WeatherData) string { return formatOptional(wd.UVIndexMax) }},
+	{"condition", func(wd models.WeatherData) string { return string(wd.Condition) }},
+}
+
+var _ table = forecastsBody{}
+
+// ForecastRows returns the table of the CSV /weather response, the first row is the header
+func ForecastRows(forecasts map[string]models.Forecast) [][]string {
+	return forecastsBody{forecasts: forecasts}.rows()
+}
+
+// rows writes one row per provider per day, providers in name order, failed providers have no rows
+func (b forecastsBody) rows() [][]string {
+	providers := make([]string, 0, len(b.forecasts))
+	for name := range b.forecasts {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+
+	var columns []dayColumn
+	for _, column := range dayColumns {
+		if b.hasValues(column) {
+			columns = append(columns, column)
+		}
+	}
+
+	header := []string{"date", "provider", "temp_min", "temp_max"}
+	for _, column := range columns {
+		header = append(header, column.name)
+	}
+
+	rows := [][]string{header}
+	for _, name := range providers {
+		for _, wd := range b.forecasts[name].ForecastData {
+			row := []string{wd.Date.String(), name, formatFloat(wd.TempMin), formatFloat(wd.TempMax)}
+			for _, column := range columns {
+				row = append(row, column.value(wd))
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return b.fields.columns(rows, "provider")
+}
+
+func (b forecastsBody) hasValues(column dayColumn) bool {
+	for _, forecast := range b.forecasts {
+		for _, wd := range forecast.ForecastData {
+			if column.value(wd) != "" {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// aggregateBody is the /weather/aggregate response, the days only have the requested fields
+type aggregateBody struct {
+	models.AggregatedForecast
+	fields fieldSet
+}
+
+func (b aggregateBody) MarshalJSON() ([]byte, error) {
+	if b.fields == nil {
+		return json.Marshal(b.AggregatedForecast)
+	}
+
+	return json.Marshal(projectedAggregate{AggregatedForecast: b.AggregatedForecast, ForecastData: b.fields.days(b.ForecastData)})
+}
+
+var _ table = aggregateBody{}
+
+// rows writes one row per aggregated day
+func (b aggregateBody) rows() [][]string {
+	rows := [][]string{{"date", "temp_min", "temp_max", "spread", "provider_count"}}
+	for _, wd := range b.ForecastData {
+		rows = append(rows, []string{
+			wd.Date.String(),
+			formatFloat(wd.TempMin),
+			formatFloat(wd.TempMax),
+			formatFloat(wd.Spread),
+			strconv.Itoa(wd.ProviderCount),
+		})
+	}
+
+	return b.fields.columns(rows)
+}
+
+func formatFloat(v float64) string {
+	return strconv.FormatFloat(v, 'f', -1, 64)
+}
+
+// formatOptional formats a value a provider may not supply, empty when it is missing
+func formatOptional(v *float64) string {
+	if v == nil {
+		return ""
+	}
+
+	return formatFloat(*v)
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
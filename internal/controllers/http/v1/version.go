@@ -0,0 +1,19 @@
+package http
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// GetVersion godoc
+// @Summary Get build information
+// @Description Identifies the deployed build: application name, version, git commit, build date and Go version
+// @Tags Version
+// @Produce json
+// @Success 200 {object} buildinfo.Info "Build information"
+// @Router /version [get]
+// @Example {curl} Example usage:
+//
+//	curl -X GET "http://localhost:8080/version"
+func (r *routes) handleVersionCall(c *fiber.Ctx) error {
+	return c.JSON(r.build)
+}
@@ -0,0 +1,121 @@
+package http
+
+import (
+	"encoding/xml"
+	"sort"
+)
+
+// XMLForecasts is the XML document of /weather, one forecast element per provider in name order
+type XMLForecasts struct {
+	XMLName   xml.Name      `xml:"forecasts"`
+	Location  *XMLLocation  `xml:"location,omitempty"`
+	Forecasts []XMLForecast `xml:"forecast"`
+}
+
+// XMLLocation is the place name added with resolve_name=true
+type XMLLocation struct {
+	Name    string `xml:"name,attr"`
+	Country string `xml:"country,attr,omitempty"`
+	Admin1  string `xml:"admin1,attr,omitempty"`
+}
+
+// XMLForecast is the forecast of a provider, a failed provider has an error and no days
+type XMLForecast struct {
+	Provider  string   `xml:"provider,attr"`
+	Units     string   `xml:"units,attr,omitempty"`
+	Error     string   `xml:"error,attr,omitempty"`
+	ErrorCode string   `xml:"error_code,attr,omitempty"`
+	Days      []XMLDay `xml:"day"`
+}
+
+// XMLDay is a forecast day, the optional attributes are omitted when the provider doesn't supply them
+type XMLDay struct {
+	Date                     string   `xml:"date,attr"`
+	Min                      float64  `xml:"min,attr"`
+	Max                      float64  `xml:"max,attr"`
+	FeelsLikeMin             *float64 `xml:"feels_like_min,attr,omitempty"`
+	FeelsLikeMax             *float64 `xml:"feels_like_max,attr,omitempty"`
+	PrecipitationSum         *float64 `xml:"precipitation_sum,attr,omitempty"`
+	PrecipitationProbability *float64 `xml:"precipitation_probability,attr,omitempty"`
+	WindSpeedMax             *float64 `xml:"wind_speed_max,attr,omitempty"`
+	WindGustsMax             *float64 `xml:"wind_gusts_max,attr,omitempty"`
+	HumidityMean             *float64 `xml:"humidity_mean,attr,omitempty"`
+	Sunrise                  string   `xml:"sunrise,attr,omitempty"`
+	Sunset                   string   `xml:"sunset,attr,omitempty"`
+	UVIndexMax               *float64 `xml:"uv_index_max,attr,omitempty"`
+	Condition                string   `xml:"condition,attr,omitempty"`
+}
+
+// XMLAggregate is the XML document of /weather/aggregate
+type XMLAggregate struct {
+	XMLName  xml.Name          `xml:"aggregate"`
+	Strategy string            `xml:"strategy,attr"`
+	Units    string            `xml:"units,attr,omitempty"`
+	Days     []XMLAggregateDay `xml:"day"`
+}
+
+// XMLAggregateDay is an aggregated day, providers is the number of providers it was aggregated from
+type XMLAggregateDay struct {
+	Date      string  `xml:"date,attr"`
+	Min       float64 `xml:"min,attr"`
+	Max       float64 `xml:"max,attr"`
+	Spread    float64 `xml:"spread,attr"`
+	Providers int     `xml:"providers,attr"`
+}
+
+var _ xmlBody = forecastsBody{}
+
+func (b forecastsBody) xmlDocument() any {
+	providers := make([]string, 0, len(b.forecasts))
+	for name := range b.forecasts {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+
+	doc := XMLForecasts{Forecasts: make([]XMLForecast, 0, len(providers))}
+	if b.location != nil {
+		doc.Location = &XMLLocation{Name: b.location.Name, Country: b.location.Country, Admin1: b.location.Admin1}
+	}
+	for _, name := range providers {
+		forecast := b.forecasts[name]
+		f := XMLForecast{Provider: name, Units: forecast.Units, Error: forecast.Error, ErrorCode: forecast.ErrorCode}
+		for _, wd := range forecast.ForecastData {
+			f.Days = append(f.Days, XMLDay{
+				Date:                     wd.Date.String(),
+				Min:                      wd.TempMin,
+				Max:                      wd.TempMax,
+				FeelsLikeMin:             wd.FeelsLikeMin,
+				FeelsLikeMax:             wd.FeelsLikeMax,
+				PrecipitationSum:         wd.PrecipitationSum,
+				PrecipitationProbability: wd.PrecipitationProbability,
+				WindSpeedMax:             wd.WindSpeedMax,
+				WindGustsMax:             wd.WindGustsMax,
+				HumidityMean:             wd.HumidityMean,
+				Sunrise:                  formatTime(wd.Sunrise),
+				Sunset:                   formatTime(wd.Sunset),
+				UVIndexMax:               wd.UVIndexMax,
+				Condition:                string(wd.Condition),
+			})
+		}
+		doc.Forecasts = append(doc.Forecasts, f)
+	}
+
+	return doc
+}
+
+var _ xmlBody = aggregateBody{}
+
+func (b aggregateBody) xmlDocument() any {
+	doc := XMLAggregate{Strategy: b.Strategy, Units: b.Units}
+	for _, wd := range b.ForecastData {
+		doc.Days = append(doc.Days, XMLAggregateDay{
+			Date:      wd.Date.String(),
+			Min:       wd.TempMin,
+			Max:       wd.TempMax,
+			Spread:    wd.Spread,
+			Providers: wd.ProviderCount,
+		})
+	}
+
+	return doc
+}
@@ -0,0 +1,101 @@
+package models
+
+import (
+	"weather-api/pkg/units"
+)
+
+// AggregatedForecast is the consensus of the providers that returned a forecast
+type AggregatedForecast struct {
+	Lat            float64   `json:"lat" example:"40.7128"`
+	Lon            float64   `json:"lon" example:"-74.006"`
+	ForecastWindow int       `json:"forecast_window" example:"5"`
+	Strategy       string    `json:"strategy" example:"mean"`
+	MinProviders   int       `json:"min_providers" example:"1"`
+	Units          string    `json:"units,omitempty" example:"metric"`
+	Timezone       *Timezone `json:"timezone,omitempty"`
+	// Providers lists the providers contributing to at least one day, in configuration order
+	Providers []string `json:"providers" example:"open-meteo,weatherapi"`
+	// Weights lists the effective weight of every contributing provider with the weighted_mean strategy
+	Weights map[string]float64 `json:"weights,omitempty"`
+	// Rejected lists the provider days left out of the aggregate as outliers
+	Rejected []RejectedValue `json:"rejected,omitempty"`
+	// OmittedDays lists the days left out because fewer than MinProviders providers reported them
+	OmittedDays  []Date                  `json:"omitted_days,omitempty" swaggertype:"array,string"`
+	ForecastData []AggregatedWeatherData `json:"forecast_data"`
+}
+
+// RejectedValue identifies a provider day rejected by the outlier filter
+type RejectedValue struct {
+	Provider string `json:"provider" example:"weatherapi"`
+	Date     Date   `json:"date" swaggertype:"string" example:"2023-10-01"`
+}
+
+// ProviderFailure explains why a provider didn't contribute to an aggregate
+type ProviderFailure struct {
+	Provider  string `json:"provider" example:"weatherapi"`
+	Error     string `json:"error" example:"provider timed out"`
+	ErrorCode string `json:"error_code" example:"timeout"`
+}
+
+type AggregatedWeatherData struct {
+	Date    Date    `json:"date" swaggertype:"string" example:"2023-10-01"`
+	TempMax float64 `json:"temp_max" example:"37.6"`
+	TempMin float64 `json:"temp_min" example:"24.1"`
+	// Spread is the largest disagreement between the providers on the max or min temperature of the day
+	Spread float64 `json:"spread" example:"1.4"`
+	// TempMaxSpread and TempMinSpread are the range and the standard deviation of the temperatures of the
+	// providers
+	TempMaxSpread TemperatureSpread `json:"temp_max_spread"`
+	TempMinSpread TemperatureSpread `json:"temp_min_spread"`
+	// Confidence labels the agreement of the providers from the Spread, unknown with a single provider
+	Confidence string `json:"confidence" example:"high" enums:"high,medium,low,unknown"`
+	// ProviderCount is the number of providers the day was aggregated from
+	ProviderCount int `json:"provider_count" example:"2"`
+}
+
+// The confidence labels of an aggregated day
+const (
+	ConfidenceHigh    = "high"
+	ConfidenceMedium  = "medium"
+	ConfidenceLow     = "low"
+	ConfidenceUnknown = "unknown"
+)
+
+// TemperatureSpread is the disagreement of the providers on a temperature of the day
+type TemperatureSpread struct {
+	Min    float64 `json:"min" example:"37.1"`
+	Max    float64 `json:"max" example:"38.0"`
+	StdDev float64 `json:"std_dev" example:"0.4"`
+}
+
+// convertUnits returns the spread converted from metric to the given system
+func (ts TemperatureSpread) convertUnits(system string) TemperatureSpread {
+	return TemperatureSpread{
+		Min:    units.Temperature(ts.Min, system),
+		Max:    units.Temperature(ts.Max, system),
+		StdDev: units.TemperatureDifference(ts.StdDev, system),
+	}
+}
+
+// ConvertUnits converts the metric aggregated data to the given unit system and records it in Units
+func (f *AggregatedForecast) ConvertUnits(system string) {
+	if f.Units != "" && f.Units != units.Metric {
+		return
+	}
+
+	f.Units = system
+	if system == units.Metric {
+		return
+	}
+
+	data := make([]AggregatedWeatherData, len(f.ForecastData))
+	for i, wd := range f.ForecastData {
+		wd.TempMax = units.Temperature(wd.TempMax, system)
+		wd.TempMin = units.Temperature(wd.TempMin, system)
+		wd.Spread = units.TemperatureDifference(wd.Spread, system)
+		wd.TempMaxSpread = wd.TempMaxSpread.convertUnits(system)
+		wd.TempMinSpread = wd.TempMinSpread.convertUnits(system)
+		data[i] = wd
+	}
+	f.ForecastData = data
+}
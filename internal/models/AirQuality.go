@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+)
+
+// US AQI categories, by upper bound of the index
+const (
+	AQIGood                        = "good"
+	AQIModerate                    = "moderate"
+	AQIUnhealthyForSensitiveGroups = "unhealthy_for_sensitive_groups"
+	AQIUnhealthy                   = "unhealthy"
+	AQIVeryUnhealthy               = "very_unhealthy"
+	AQIHazardous                   = "hazardous"
+)
+
+// AirQuality is the daily air quality forecast at a location
+type AirQuality struct {
+	RepositoryName string  `json:"repository_name" example:"open-meteo-air-quality"`
+	Lat            float64 `json:"lat" example:"40.7128"`
+	Lon            float64 `json:"lon" example:"-74.006"`
+	ForecastWindow int     `json:"forecast_window" example:"3"`
+	Timezone       string  `json:"timezone,omitempty" example:"America/New_York"`
+	Error          string  `json:"error,omitempty" example:"provider timed out"`
+	ErrorCode      string  `json:"error_code,omitempty" example:"timeout"`
+	FetchMetadata
+	AirQualityData []AirQualityData `json:"air_quality_data"`
+}
+
+// AirQualityData summarizes the hourly values of a day, concentrations are in µg/m³ and
+// a value is null when the provider reported none that day
+type AirQualityData struct {
+	Date Date `json:"date" swaggertype:"string" example:"2023-10-01"`
+	// PM25 and PM10 are the daily means of the particulate matter concentrations
+	PM25 *float64 `json:"pm2_5" example:"8.4"`
+	PM10 *float64 `json:"pm10" example:"14.1"`
+	// Ozone is the highest hourly concentration of the day
+	Ozone *float64 `json:"ozone" example:"96"`
+	// AQI is the highest hourly US Air Quality Index of the day, from 0 to 500
+	AQI         *int   `json:"aqi" example:"42"`
+	AQICategory string `json:"aqi_category,omitempty" example:"good"`
+}
+
+func (a *AirQuality) RequestParams() string {
+	return fmt.Sprintf("lat: %.4f lon: %.4f days: %d air quality", a.Lat, a.Lon, a.ForecastWindow)
+}
+
+// AQICategory returns the US EPA category of an Air Quality Index
+func AQICategory(aqi int) string {
+	switch {
+	case aqi <= 50:
+		return AQIGood
+	case aqi <= 100:
+		return AQIModerate
+	case aqi <= 150:
+		return AQIUnhealthyForSensitiveGroups
+	case aqi <= 200:
+		return AQIUnhealthy
+	case aqi <= 300:
+		return AQIVeryUnhealthy
+	}
+
+	return AQIHazardous
+}
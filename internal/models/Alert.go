@@ -0,0 +1,73 @@
+package models
+
+import (
+	"strings"
+	"time"
+)
+
+// Alert severities, from the most to the least severe, following the CAP severity levels
+const (
+	AlertSeverityExtreme  = "extreme"
+	AlertSeveritySevere   = "severe"
+	AlertSeverityModerate = "moderate"
+	AlertSeverityMinor    = "minor"
+	AlertSeverityUnknown  = "unknown"
+)
+
+// Alert is an active weather warning issued for a location
+type Alert struct {
+	// Source is the provider reporting the alert
+	Source   string    `json:"source" example:"nws"`
+	Event    string    `json:"event" example:"Heat Advisory"`
+	Severity string    `json:"severity" example:"moderate"`
+	Onset    time.Time `json:"onset" example:"2025-07-25T12:00:00-04:00"`
+	// Expires is zero when the provider gave no end to the alert
+	Expires     time.Time `json:"expires,omitzero" example:"2025-07-25T20:00:00-04:00"`
+	Description string    `json:"description,omitempty" example:"Heat index values up to 105 expected."`
+	// AlsoReportedBy lists the other providers that reported the same alert
+	AlsoReportedBy []string `json:"also_reported_by,omitempty"`
+}
+
+// AlertReport lists the active alerts of a location, most severe first
+type AlertReport struct {
+	Lat float64 `json:"lat" example:"40.7128"`
+	Lon float64 `json:"lon" example:"-74.006"`
+	// Providers lists the providers that answered, in configuration order
+	Providers []string          `json:"providers" example:"nws,weatherapi"`
+	Failures  []ProviderFailure `json:"failures,omitempty"`
+	Alerts    []Alert           `json:"alerts"`
+}
+
+// AlertSeverity normalizes a provider severity, unknown values map to AlertSeverityUnknown
+func AlertSeverity(severity string) string {
+	switch s := strings.ToLower(strings.TrimSpace(severity)); s {
+	case AlertSeverityExtreme, AlertSeveritySevere, AlertSeverityModerate, AlertSeverityMinor:
+		return s
+	}
+
+	return AlertSeverityUnknown
+}
+
+// SeverityRank orders the severities, the higher the more severe
+func SeverityRank(severity string) int {
+	switch severity {
+	case AlertSeverityExtreme:
+		return 4
+	case AlertSeveritySevere:
+		return 3
+	case AlertSeverityModerate:
+		return 2
+	case AlertSeverityMinor:
+		return 1
+	}
+
+	return 0
+}
+
+// Overlaps reports whether the alerts are active at a common time, a zero Expires is open-ended
+func (a Alert) Overlaps(other Alert) bool {
+	startsBeforeOtherEnds := other.Expires.IsZero() || a.Onset.Before(other.Expires)
+	otherStartsBeforeEnd := a.Expires.IsZero() || other.Onset.Before(a.Expires)
+
+	return startsBeforeOtherEnds && otherStartsBeforeEnd
+}
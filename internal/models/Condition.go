@@ -0,0 +1,39 @@
+package models
+
+// Condition is the weather condition normalized across providers
+type Condition string
+
+const (
+	ConditionClear        Condition = "clear"
+	ConditionPartlyCloudy Condition = "partly-cloudy"
+	ConditionCloudy       Condition = "cloudy"
+	ConditionFog          Condition = "fog"
+	ConditionDrizzle      Condition = "drizzle"
+	ConditionRain         Condition = "rain"
+	ConditionSnow         Condition = "snow"
+	ConditionThunderstorm Condition = "thunderstorm"
+	ConditionUnknown      Condition = "unknown"
+)
+
+// conditionSeverity ranks the conditions, the daily condition is the most severe one of the day
+var conditionSeverity = map[Condition]int{
+	ConditionUnknown:      0,
+	ConditionClear:        1,
+	ConditionPartlyCloudy: 2,
+	ConditionCloudy:       3,
+	ConditionFog:          4,
+	ConditionDrizzle:      5,
+	ConditionRain:         6,
+	ConditionSnow:         7,
+	ConditionThunderstorm: 8,
+}
+
+// Severity returns the rank of the condition, higher is worse
+func (c Condition) Severity() int {
+	return conditionSeverity[c]
+}
+
+// WorseThan reports whether c is more severe than other
+func (c Condition) WorseThan(other Condition) bool {
+	return c.Severity() > other.Severity()
+}
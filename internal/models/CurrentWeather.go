@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"weather-api/pkg/units"
+)
+
+// CurrentConditions are the latest observed or modeled conditions at a location
+type CurrentConditions struct {
+	// ObservedAt is in the local time of the location
+	ObservedAt    time.Time `json:"observed_at" example:"2023-10-01T14:00:00+02:00"`
+	Temperature   float64   `json:"temperature" example:"21.4"`
+	FeelsLike     *float64  `json:"feels_like,omitempty" example:"20.8"`
+	WindSpeed     *float64  `json:"wind_speed,omitempty" example:"3.2"`     // m/s, mph
+	WindDirection *float64  `json:"wind_direction,omitempty" example:"250"` // degrees
+	Humidity      *float64  `json:"humidity,omitempty" example:"65"`        // %
+	Condition     Condition `json:"condition,omitempty" example:"cloudy"`
+	ConditionCode *int      `json:"condition_code,omitempty" example:"3"`
+}
+
+type CurrentWeather struct {
+	RepositoryName string  `json:"repository_name" example:"openmeteo"`
+	Lat            float64 `json:"lat" example:"40.7128"`
+	Lon            float64 `json:"lon" example:"-74.006"`
+	Units          string  `json:"units,omitempty" example:"metric"`
+	Error          string  `json:"error,omitempty" example:"operation not supported by provider"`
+	ErrorCode      string  `json:"error_code,omitempty" example:"unsupported"`
+	FetchMetadata
+	// Current is null when the provider failed
+	Current *CurrentConditions `json:"current"`
+}
+
+func (w *CurrentWeather) RequestParams() string {
+	return fmt.Sprintf("lat: %.4f lon: %.4f current", w.Lat, w.Lon)
+}
+
+// ConvertUnits converts the metric conditions to the given unit system and records it in Units
+func (w *CurrentWeather) ConvertUnits(system string) {
+	if w.Units != "" && w.Units != units.Metric {
+		return
+	}
+
+	w.Units = system
+	if system == units.Metric || w.Current == nil {
+		return
+	}
+
+	current := *w.Current
+	current.Temperature = units.Temperature(current.Temperature, system)
+	current.FeelsLike = convertValue(current.FeelsLike, system, units.Temperature)
+	current.WindSpeed = convertValue(current.WindSpeed, system, units.Speed)
+	w.Current = &current
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// DateLayout is the format of a Date in JSON
+const DateLayout = "2006-01-02"
+
+// Date is a calendar day, it marshals as YYYY-MM-DD and as null when zero
+type Date struct {
+	time.Time
+}
+
+// NewDate returns the calendar day of t in its own location, stored as midnight UTC
+func NewDate(t time.Time) Date {
+	return Date{time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)}
+}
+
+// ParseDate parses a YYYY-MM-DD date
+func ParseDate(s string) (Date, error) {
+	t, err := time.Parse(DateLayout, s)
+	if err != nil {
+		return Date{}, err
+	}
+
+	return Date{t}, nil
+}
+
+func (d Date) String() string {
+	if d.IsZero() {
+		return ""
+	}
+
+	return d.Format(DateLayout)
+}
+
+func (d Date) MarshalJSON() ([]byte, error) {
+	if d.IsZero() {
+		return []byte("null"), nil
+	}
+
+	return []byte(`"` + d.Format(DateLayout) + `"`), nil
+}
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		*d = Date{}
+		return nil
+	}
+
+	if len(data) < 2 || data[0] != '"' || data[len(data)-1] != '"' {
+		return fmt.Errorf("invalid date: %s", data)
+	}
+
+	parsed, err := ParseDate(string(data[1 : len(data)-1]))
+	if err != nil {
+		return fmt.Errorf("invalid date: %w", err)
+	}
+
+	*d = parsed
+	return nil
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDate_JSON(t *testing.T) {
+	date := NewDate(time.Date(2025, 7, 25, 23, 30, 0, 0, time.FixedZone("", 2*3600)))
+
+	data, err := json.Marshal(WeatherData{Date: date, TempMax: 25, TempMin: 15})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if want := `{"date":"2025-07-25","temp_max":25,"temp_min":15}`; string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	var decoded WeatherData
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Failed to unmarshal: %v", err)
+	}
+	if !decoded.Date.Equal(date.Time) {
+		t.Errorf("Expected %v after round trip, got %v", date, decoded.Date)
+	}
+}
+
+func TestDate_ZeroAndInvalid(t *testing.T) {
+	data, err := json.Marshal(WeatherData{})
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if want := `{"date":null,"temp_max":0,"temp_min":0}`; string(data) != want {
+		t.Errorf("Expected %s, got %s", want, data)
+	}
+
+	var decoded WeatherData
+	if err := json.Unmarshal([]byte(`{"date":null}`), &decoded); err != nil || !decoded.Date.IsZero() {
+		t.Errorf("Expected zero date from null, got %v (%v)", decoded.Date, err)
+	}
+
+	for _, invalid := range []string{`{"date":"2025-07-25T00:00:00Z"}`, `{"date":20250725}`, `{"date":"25/07/2025"}`} {
+		if err := json.Unmarshal([]byte(invalid), &decoded); err == nil {
+			t.Errorf("Expected error for %s", invalid)
+		}
+	}
+}
+
+func TestFilterByDate(t *testing.T) {
+	first, _ := ParseDate("2025-07-25")
+	second, _ := ParseDate("2025-07-26")
+	data := []WeatherData{{Date: first}, {}, {Date: second}}
+
+	if got := FilterByDate(data, second); got != 2 {
+		t.Errorf("Expected index 2, got %d", got)
+	}
+	if got := FilterByDate(data, Date{}); got != -1 {
+		t.Errorf("Expected zero date not to match, got %d", got)
+	}
+}
@@ -0,0 +1,17 @@
+package models
+
+// Error codes reported on a forecast when its provider failed
+const (
+	ErrorCodeTimeout      = "timeout"
+	ErrorCodeCanceled     = "canceled"
+	ErrorCodeRateLimited  = "rate_limited"
+	ErrorCodeUpstreamHTTP = "upstream_http_error"
+	ErrorCodeUnavailable  = "unavailable"
+	ErrorCodeParse        = "parse_error"
+	ErrorCodeNoData       = "no_data"
+	ErrorCodeUnsupported  = "unsupported"
+	ErrorCodeDisabled     = "disabled"
+	// ErrorCodeQuotaExhausted is reported without calling the provider, its enforced quota is used up
+	ErrorCodeQuotaExhausted = "quota_exhausted"
+	ErrorCodeUnknown        = "unknown"
+)
@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// FetchMetadata describes when and how a forecast was obtained from its provider
+type FetchMetadata struct {
+	FetchedAt       time.Time `json:"fetched_at,omitzero" example:"2023-10-01T12:00:00Z"`
+	FetchDurationMS int64     `json:"fetch_duration_ms" example:"182"`
+	// SourceURL is the provider request without secrets
+	SourceURL string `json:"source_url,omitempty" example:"https://api.open-meteo.com/v1/forecast?latitude=40.7128&longitude=-74.006"`
+	// Stale is set when the forecast is served from a cache after the provider failed
+	Stale bool `json:"stale,omitempty"`
+	// Cached is set when the forecast is served from the forecast cache without calling the provider
+	Cached bool `json:"cached,omitempty"`
+	// Note explains a forecast differing from the request, such as a window clamped to the provider horizon
+	Note string `json:"note,omitempty" example:"forecast window clamped from 10 to 5 days, the provider horizon"`
+}
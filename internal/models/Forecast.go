@@ -1,15 +1,47 @@
 package models
 
-import "fmt"
+import (
+	"fmt"
+
+	"weather-api/pkg/units"
+)
 
 type Forecast struct {
-	RepositoryName string        `json:"repository_name" example:"openmeteo"`
-	Lat            float64       `json:"lat" example:"40.7128"`
-	Lon            float64       `json:"lon" example:"-74.006"`
-	ForecastWindow int           `json:"forecast_window" example:"5"`
-	ForecastData   []WeatherData `json:"forecast_data"`
+	RepositoryName string `json:"repository_name" example:"openmeteo"`
+	// Variant is the member of the canary group of the provider serving the forecast, primary or canary, empty
+	// when the provider has no canary
+	Variant        string    `json:"variant,omitempty" example:"canary"`
+	Lat            float64   `json:"lat" example:"40.7128"`
+	Lon            float64   `json:"lon" example:"-74.006"`
+	ForecastWindow int       `json:"forecast_window" example:"5"`
+	Units          string    `json:"units,omitempty" example:"metric"`
+	Timezone       *Timezone `json:"timezone,omitempty"`
+	// Error and ErrorCode explain an empty forecast, the message never contains provider URLs or keys
+	Error     string `json:"error,omitempty" example:"provider timed out"`
+	ErrorCode string `json:"error_code,omitempty" example:"timeout"`
+	FetchMetadata
+	ForecastData []WeatherData `json:"forecast_data"`
 }
 
 func (f *Forecast) RequestParams() string {
 	return fmt.Sprintf("lat: %.4f lon: %.4f days: %d", f.Lat, f.Lon, f.ForecastWindow)
 }
+
+// ConvertUnits converts the metric forecast data to the given unit system and records it in Units,
+// the converted days are copies so data shared with other forecasts is left untouched
+func (f *Forecast) ConvertUnits(system string) {
+	if f.Units != "" && f.Units != units.Metric {
+		return
+	}
+
+	f.Units = system
+	if system == units.Metric {
+		return
+	}
+
+	data := make([]WeatherData, len(f.ForecastData))
+	for i, wd := range f.ForecastData {
+		data[i] = wd.convertUnits(system)
+	}
+	f.ForecastData = data
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"weather-api/pkg/units"
+)
+
+// ForecastComparison sets the forecasts of the providers side by side, day by day, the days they disagree the most
+// on first
+type ForecastComparison struct {
+	Lat            float64 `json:"lat" example:"40.7128"`
+	Lon            float64 `json:"lon" example:"-74.006"`
+	ForecastWindow int     `json:"forecast_window" example:"5"`
+	Units          string  `json:"units,omitempty" example:"metric"`
+	// Threshold is the disagreement beyond which a day is flagged
+	Threshold float64 `json:"threshold" example:"3"`
+	// Providers lists the providers compared, in configuration order, Failures the ones that returned no forecast
+	Providers []string          `json:"providers" example:"open-meteo,weatherapi"`
+	Failures  []ProviderFailure `json:"failures,omitempty"`
+	Days      []DayComparison   `json:"days"`
+}
+
+// DayComparison is the temperatures forecast for a day by every provider reporting it
+type DayComparison struct {
+	Date      Date                            `json:"date" swaggertype:"string" example:"2023-10-01"`
+	Providers map[string]ProviderTemperatures `json:"providers"`
+	// Missing lists the providers compared that didn't report the day
+	Missing []string `json:"missing,omitempty" example:"weatherapi"`
+	// Disagreement is the largest difference between two providers on the max or min temperature of the day,
+	// Pair the two providers
+	Disagreement float64  `json:"disagreement" example:"4.2"`
+	Pair         []string `json:"pair,omitempty" example:"open-meteo,weatherapi"`
+	// Flagged is set when the disagreement exceeds the threshold
+	Flagged bool `json:"flagged" example:"true"`
+}
+
+// ProviderTemperatures is the forecast of a provider for a day
+type ProviderTemperatures struct {
+	TempMax float64 `json:"temp_max" example:"37.6"`
+	TempMin float64 `json:"temp_min" example:"24.1"`
+}
+
+// ConvertUnits converts the metric comparison to the given unit system and records it in Units
+func (f *ForecastComparison) ConvertUnits(system string) {
+	if f.Units != "" && f.Units != units.Metric {
+		return
+	}
+
+	f.Units = system
+	if system == units.Metric {
+		return
+	}
+
+	f.Threshold = units.TemperatureDifference(f.Threshold, system)
+	days := make([]DayComparison, len(f.Days))
+	for i, day := range f.Days {
+		providers := make(map[string]ProviderTemperatures, len(day.Providers))
+		for name, t := range day.Providers {
+			providers[name] = ProviderTemperatures{
+				TempMax: units.Temperature(t.TempMax, system),
+				TempMin: units.Temperature(t.TempMin, system),
+			}
+		}
+		day.Providers = providers
+		day.Disagreement = units.TemperatureDifference(day.Disagreement, system)
+		days[i] = day
+	}
+	f.Days = days
+}
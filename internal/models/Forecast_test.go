@@ -0,0 +1,35 @@
+package models
+
+import (
+	"testing"
+
+	"weather-api/pkg/units"
+)
+
+func TestForecast_ConvertUnits(t *testing.T) {
+	feelsMax, feelsMin, wind, rain := 30.0, -5.0, 10.0, 25.4
+	original := WeatherData{TempMax: 25, TempMin: -10, FeelsLikeMax: &feelsMax, FeelsLikeMin: &feelsMin, WindSpeedMax: &wind, PrecipitationSum: &rain}
+	forecast := Forecast{ForecastData: []WeatherData{original}}
+
+	forecast.ConvertUnits(units.Imperial)
+
+	day := forecast.ForecastData[0]
+	if forecast.Units != units.Imperial {
+		t.Errorf("Expected units imperial, got %q", forecast.Units)
+	}
+	if day.TempMax != 77 || day.TempMin != 14 || *day.FeelsLikeMax != 86 || *day.FeelsLikeMin != 23 {
+		t.Errorf("Expected every temperature converted, got %+v", day)
+	}
+	if *day.WindSpeedMax != 22.4 || *day.PrecipitationSum != 1 {
+		t.Errorf("Expected wind and precipitation converted, got %v %v", *day.WindSpeedMax, *day.PrecipitationSum)
+	}
+	if feelsMax != 30 || original.TempMax != 25 {
+		t.Error("Expected the original data to be left untouched")
+	}
+
+	// Converting twice must not convert the values again
+	forecast.ConvertUnits(units.Imperial)
+	if forecast.ForecastData[0].TempMax != 77 {
+		t.Errorf("Expected no double conversion, got %v", forecast.ForecastData[0].TempMax)
+	}
+}
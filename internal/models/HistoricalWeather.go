@@ -0,0 +1,44 @@
+package models
+
+import (
+	"fmt"
+
+	"weather-api/pkg/units"
+)
+
+// HistoricalWeather is the observed daily weather of a past date range, reported by an archive provider
+type HistoricalWeather struct {
+	RepositoryName string    `json:"repository_name" example:"open-meteo"`
+	Lat            float64   `json:"lat" example:"40.7128"`
+	Lon            float64   `json:"lon" example:"-74.006"`
+	Start          Date      `json:"start" swaggertype:"string" example:"2024-01-01"`
+	End            Date      `json:"end" swaggertype:"string" example:"2024-01-31"`
+	Units          string    `json:"units,omitempty" example:"metric"`
+	Timezone       *Timezone `json:"timezone,omitempty"`
+	Error          string    `json:"error,omitempty" example:"provider timed out"`
+	ErrorCode      string    `json:"error_code,omitempty" example:"timeout"`
+	FetchMetadata
+	WeatherData []WeatherData `json:"weather_data"`
+}
+
+func (h *HistoricalWeather) RequestParams() string {
+	return fmt.Sprintf("lat: %.4f lon: %.4f start: %s end: %s", h.Lat, h.Lon, h.Start, h.End)
+}
+
+// ConvertUnits converts the metric weather data to the given unit system and records it in Units
+func (h *HistoricalWeather) ConvertUnits(system string) {
+	if h.Units != "" && h.Units != units.Metric {
+		return
+	}
+
+	h.Units = system
+	if system == units.Metric {
+		return
+	}
+
+	data := make([]WeatherData, len(h.WeatherData))
+	for i, wd := range h.WeatherData {
+		data[i] = wd.convertUnits(system)
+	}
+	h.WeatherData = data
+}
@@ -0,0 +1,28 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+type HourlyWeatherData struct {
+	Time *time.Time `json:"time" example:"2023-10-01T14:00:00+02:00"`
+	Temp float64    `json:"temp" example:"21.4"`
+	// PrecipitationProbability is a percentage, nil when the provider doesn't report it
+	PrecipitationProbability *float64 `json:"precipitation_probability,omitempty" example:"40"`
+}
+
+type HourlyForecast struct {
+	RepositoryName string  `json:"repository_name" example:"openmeteo"`
+	Lat            float64 `json:"lat" example:"40.7128"`
+	Lon            float64 `json:"lon" example:"-74.006"`
+	Hours          int     `json:"hours" example:"24"`
+	Error          string  `json:"error,omitempty" example:"operation not supported by provider"`
+	ErrorCode      string  `json:"error_code,omitempty" example:"unsupported"`
+	FetchMetadata
+	HourlyData []HourlyWeatherData `json:"hourly_data"`
+}
+
+func (f *HourlyForecast) RequestParams() string {
+	return fmt.Sprintf("lat: %.4f lon: %.4f hours: %d", f.Lat, f.Lon, f.Hours)
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"fmt"
+)
+
+// MarineForecast is the daily sea state forecast at a coastal or offshore location
+type MarineForecast struct {
+	RepositoryName string  `json:"repository_name" example:"open-meteo-marine"`
+	Lat            float64 `json:"lat" example:"43.2965"`
+	Lon            float64 `json:"lon" example:"5.3698"`
+	ForecastWindow int     `json:"forecast_window" example:"3"`
+	Timezone       string  `json:"timezone,omitempty" example:"Europe/Paris"`
+	Error          string  `json:"error,omitempty" example:"provider timed out"`
+	ErrorCode      string  `json:"error_code,omitempty" example:"timeout"`
+	FetchMetadata
+	MarineData []MarineData `json:"marine_data"`
+}
+
+// MarineData is a day of a marine forecast, a value is null when the provider reported none that day
+type MarineData struct {
+	Date Date `json:"date" swaggertype:"string" example:"2023-10-01"`
+	// WaveHeightMax is the highest significant wave height of the day, in m
+	WaveHeightMax *float64 `json:"wave_height_max" example:"1.2"`
+	// WavePeriodMax is the longest wave period of the day, in s
+	WavePeriodMax *float64 `json:"wave_period_max" example:"6.5"`
+	// WaveDirection is the dominant direction the waves come from, in degrees
+	WaveDirection *float64 `json:"wave_direction" example:"220"`
+	// SeaSurfaceTemperature is the daily mean, in °C
+	SeaSurfaceTemperature *float64 `json:"sea_surface_temperature" example:"19.8"`
+}
+
+func (f *MarineForecast) RequestParams() string {
+	return fmt.Sprintf("lat: %.4f lon: %.4f days: %d marine", f.Lat, f.Lon, f.ForecastWindow)
+}
@@ -0,0 +1,13 @@
+package models
+
+// Place is a named location resolved by geocoding
+type Place struct {
+	Name        string  `json:"name" example:"Berlin"`
+	Admin1      string  `json:"admin1,omitempty" example:"Land Berlin"`
+	Country     string  `json:"country,omitempty" example:"Germany"`
+	CountryCode string  `json:"country_code,omitempty" example:"DE"`
+	Lat         float64 `json:"lat" example:"52.52437"`
+	Lon         float64 `json:"lon" example:"13.41053"`
+	Population  int     `json:"population,omitempty" example:"3426354"`
+	Timezone    string  `json:"timezone,omitempty" example:"Europe/Berlin"`
+}
@@ -0,0 +1,36 @@
+package models
+
+// ForecastError is how far what a provider predicted for a day, LeadDays before it, was from the observed weather.
+// The errors are absolute, averaged over the Samples fetched on the same day.
+type ForecastError struct {
+	Provider     string
+	Variant      string
+	Lat          float64
+	Lon          float64
+	Date         Date
+	LeadDays     int
+	TempMinError float64
+	TempMaxError float64
+	Samples      int
+}
+
+// ProviderAccuracy is the mean absolute error of a provider forecasting LeadDays ahead, over the Days scored
+type ProviderAccuracy struct {
+	Provider string `json:"provider" example:"open-meteo"`
+	// Variant is the canary member scored, the members of a canary group are scored apart
+	Variant  string `json:"variant,omitempty" example:"canary"`
+	LeadDays int    `json:"lead_days" example:"1"`
+	Days     int    `json:"days" example:"28"`
+	// MAE is the mean of MAETempMin and MAETempMax, in °C
+	MAE        float64 `json:"mae" example:"1.35"`
+	MAETempMin float64 `json:"mae_temp_min" example:"1.2"`
+	MAETempMax float64 `json:"mae_temp_max" example:"1.5"`
+}
+
+// AccuracyReport lists the accuracy of the providers at a location since a day, by provider and lead time
+type AccuracyReport struct {
+	Lat       float64            `json:"lat" example:"40.7128"`
+	Lon       float64            `json:"lon" example:"-74.006"`
+	Since     Date               `json:"since" swaggertype:"string" example:"2023-09-01"`
+	Providers []ProviderAccuracy `json:"providers"`
+}
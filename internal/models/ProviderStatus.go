@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// CircuitNone is the circuit state of a provider without a circuit breaker
+const CircuitNone = "none"
+
+// ProviderStatus describes the configuration and the recent behavior of a forecast provider
+type ProviderStatus struct {
+	Name        string `json:"name" example:"open-meteo"`
+	RequiresKey bool   `json:"requires_key" example:"false"`
+	// Disabled is set when the provider was pulled out of rotation at runtime
+	Disabled bool `json:"disabled,omitempty" example:"false"`
+	// Health is the result of the last health check, missing when it never ran
+	Health *ProviderHealth `json:"health,omitempty"`
+	// Circuit is the state of the circuit breaker of the provider
+	Circuit string `json:"circuit" example:"none"`
+	// Calls is the number of recent calls the error rate and the latency are computed over
+	Calls        int     `json:"calls" example:"100"`
+	ErrorRate    float64 `json:"error_rate" example:"0.02"`
+	AvgLatencyMS float64 `json:"avg_latency_ms" example:"182.5"`
+	// LastErrorCode is the error code of the last call when it failed
+	LastErrorCode string `json:"last_error_code,omitempty" example:"rate_limited"`
+	// RateLimited is set when the last call was rejected by the rate limit of the provider
+	RateLimited bool `json:"rate_limited" example:"false"`
+	// Quota is the usage of the quota of the provider, missing when none is configured
+	Quota *ProviderQuota `json:"quota,omitempty"`
+}
+
+// ProviderQuota is the number of calls made to a provider in the current period of its quota
+type ProviderQuota struct {
+	Limit     int64  `json:"limit" example:"1000"`
+	Used      int64  `json:"used" example:"412"`
+	Remaining int64  `json:"remaining" example:"588"`
+	Period    string `json:"period" example:"daily" enums:"daily,monthly"`
+	// ResetsAt is the start of the next period, in UTC
+	ResetsAt time.Time `json:"resets_at" example:"2023-10-02T00:00:00Z"`
+	// Enforced skips the provider once the quota is used up, the calls beyond it are only counted otherwise
+	Enforced bool `json:"enforced" example:"true"`
+}
+
+// QuotaUsage is the number of calls made to a provider in the period of its quota starting at PeriodStart
+type QuotaUsage struct {
+	Provider    string
+	PeriodStart time.Time
+	Used        int64
+}
+
+// ProviderHealth is the result of a provider health check
+type ProviderHealth struct {
+	Healthy    bool      `json:"healthy" example:"true"`
+	ErrorCode  string    `json:"error_code,omitempty" example:"timeout"`
+	CheckedAt  time.Time `json:"checked_at" example:"2023-10-01T12:00:00Z"`
+	DurationMS int64     `json:"duration_ms" example:"182"`
+}
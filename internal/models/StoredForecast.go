@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// StoredForecast is a day of a provider forecast as it was predicted at FetchedAt, recorded by the forecast store
+type StoredForecast struct {
+	Provider string `json:"provider" example:"open-meteo"`
+	// Variant is the canary member of the provider that forecast it, see Forecast
+	Variant   string    `json:"variant,omitempty" example:"canary"`
+	Lat       float64   `json:"lat" example:"40.7128"`
+	Lon       float64   `json:"lon" example:"-74.006"`
+	Date      Date      `json:"date" swaggertype:"string" example:"2023-10-01"`
+	TempMin   float64   `json:"temp_min" example:"24.3"`
+	TempMax   float64   `json:"temp_max" example:"38.0"`
+	FetchedAt time.Time `json:"fetched_at" example:"2023-09-28T12:00:00Z"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// SubscriptionCondition is met by a forecast day whose Field compares with Value by Op, e.g. temp_max gte 35,
+// over the next Days days
+type SubscriptionCondition struct {
+	Field string  `json:"field" example:"temp_max" enums:"temp_max,temp_min"`
+	Op    string  `json:"op" example:"gte" enums:"lt,lte,gt,gte"`
+	Value float64 `json:"value" example:"35"`
+	Days  int     `json:"days" example:"3"`
+}
+
+// Subscription notifies its webhook when the aggregated forecast of the location starts meeting the condition
+type Subscription struct {
+	ID string `json:"id" example:"3f9a0c1e5b7d4a2f"`
+	// Owner is the name of the API key that created the subscription, the only one reading or deleting it, empty
+	// without authentication
+	Owner      string                `json:"-"`
+	Lat        float64               `json:"lat" example:"40.7128"`
+	Lon        float64               `json:"lon" example:"-74.006"`
+	Condition  SubscriptionCondition `json:"condition"`
+	WebhookURL string                `json:"webhook_url" example:"https://example.com/hooks/weather"`
+	// Secret signs the notifications, it is only returned when the subscription is created
+	Secret               string `json:"secret,omitempty" example:"9c1d4e..."`
+	CheckIntervalSeconds int    `json:"check_interval_seconds" example:"1800"`
+	// Enabled is cleared after repeated failed deliveries
+	Enabled bool `json:"enabled" example:"true"`
+	// Triggered is set once the condition met is notified, the next notification waits for it to be unmet
+	Triggered bool `json:"triggered" example:"false"`
+	// Failures counts the consecutive failed deliveries, LastError describes the last one
+	Failures       int        `json:"failures" example:"0"`
+	LastError      string     `json:"last_error,omitempty" example:"webhook answered 503 Service Unavailable"`
+	CreatedAt      time.Time  `json:"created_at" example:"2025-07-25T10:00:00Z"`
+	NextCheckAt    time.Time  `json:"next_check_at" example:"2025-07-25T10:30:00Z"`
+	LastNotifiedAt *time.Time `json:"last_notified_at,omitempty" example:"2025-07-25T10:00:00Z"`
+}
+
+// SubscriptionNotification is the body posted to the webhook of a subscription, Matches are the forecast days
+// meeting the condition
+type SubscriptionNotification struct {
+	SubscriptionID string                  `json:"subscription_id" example:"3f9a0c1e5b7d4a2f"`
+	Lat            float64                 `json:"lat" example:"40.7128"`
+	Lon            float64                 `json:"lon" example:"-74.006"`
+	Condition      SubscriptionCondition   `json:"condition"`
+	Matches        []AggregatedWeatherData `json:"matches"`
+	SentAt         time.Time               `json:"sent_at" example:"2025-07-25T10:00:00Z"`
+}
@@ -0,0 +1,7 @@
+package models
+
+// Timezone describes the resolved timezone of a forecast location
+type Timezone struct {
+	Name             string `json:"name" example:"Europe/Rome"`
+	UTCOffsetSeconds int    `json:"utc_offset_seconds" example:"7200"`
+}
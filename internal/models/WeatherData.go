@@ -1,19 +1,79 @@
 package models
 
-import "time"
+import (
+	"slices"
+	"time"
+
+	"weather-api/pkg/units"
+)
 
 type WeatherData struct {
-	Date    *time.Time `json:"date" example:"2023-10-01"`
-	TempMax float64    `json:"temp_max" example:"38.0"`
-	TempMin float64    `json:"temp_min" example:"24.3"`
+	Date    Date    `json:"date" swaggertype:"string" example:"2023-10-01"`
+	TempMax float64 `json:"temp_max" example:"38.0"`
+	TempMin float64 `json:"temp_min" example:"24.3"`
+	// Optional fields, omitted when the provider doesn't supply them
+	FeelsLikeMax             *float64 `json:"feels_like_max,omitempty" example:"40.1"`
+	FeelsLikeMin             *float64 `json:"feels_like_min,omitempty" example:"25.0"`
+	PrecipitationSum         *float64 `json:"precipitation_sum,omitempty" example:"1.2"`        // mm, in
+	PrecipitationProbability *float64 `json:"precipitation_probability,omitempty" example:"40"` // %
+	WindSpeedMax             *float64 `json:"wind_speed_max,omitempty" example:"5.4"`           // m/s, mph
+	WindGustsMax             *float64 `json:"wind_gusts_max,omitempty" example:"9.8"`           // m/s, mph
+	HumidityMean             *float64 `json:"humidity_mean,omitempty" example:"65"`             // %
+	// Sunrise and Sunset are in the local time of the location
+	Sunrise    *time.Time `json:"sunrise,omitempty" example:"2023-10-01T07:12:00+02:00"`
+	Sunset     *time.Time `json:"sunset,omitempty" example:"2023-10-01T19:03:00+02:00"`
+	UVIndexMax *float64   `json:"uv_index_max,omitempty" example:"5.3"`
+	// Condition is the dominant condition of the day, ConditionCode the raw provider code it was mapped from
+	Condition     Condition `json:"condition,omitempty" example:"rain"`
+	ConditionCode *int      `json:"condition_code,omitempty" example:"61"`
+	// Past marks days before today requested through the provider's past days option
+	Past bool `json:"past,omitempty"`
+	// Suspect and ExcludedFromAggregate are set by the post-processing rules
+	Suspect               bool `json:"suspect,omitempty"`
+	ExcludedFromAggregate bool `json:"excluded_from_aggregate,omitempty"`
 }
 
 // FilterByDate returns the index of the WeatherData with the matching date, or -1 if not found
-func FilterByDate(data []WeatherData, date *time.Time) int {
+func FilterByDate(data []WeatherData, date Date) int {
 	for i, wd := range data {
-		if wd.Date != nil && date != nil && wd.Date.Equal(*date) {
+		if !wd.Date.IsZero() && wd.Date.Equal(date.Time) {
 			return i
 		}
 	}
 	return -1
 }
+
+// SortWeatherData sorts the days by date, the oldest first, the days of the same date keep their order
+func SortWeatherData(data []WeatherData) {
+	slices.SortStableFunc(data, compareDates)
+}
+
+// WeatherDataSorted reports whether the days are sorted by date, the oldest first
+func WeatherDataSorted(data []WeatherData) bool {
+	return slices.IsSortedFunc(data, compareDates)
+}
+
+func compareDates(a, b WeatherData) int {
+	return a.Date.Compare(b.Date.Time)
+}
+
+// convertUnits returns the day with every temperature, speed and amount converted from metric to the given system
+func (wd WeatherData) convertUnits(system string) WeatherData {
+	wd.TempMax = units.Temperature(wd.TempMax, system)
+	wd.TempMin = units.Temperature(wd.TempMin, system)
+	wd.FeelsLikeMax = convertValue(wd.FeelsLikeMax, system, units.Temperature)
+	wd.FeelsLikeMin = convertValue(wd.FeelsLikeMin, system, units.Temperature)
+	wd.WindSpeedMax = convertValue(wd.WindSpeedMax, system, units.Speed)
+	wd.WindGustsMax = convertValue(wd.WindGustsMax, system, units.Speed)
+	wd.PrecipitationSum = convertValue(wd.PrecipitationSum, system, units.Length)
+
+	return wd
+}
+
+func convertValue(v *float64, system string, convert func(float64, string) float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	converted := convert(*v, system)
+	return &converted
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"math/rand/v2"
+	"testing"
+)
+
+func TestSortWeatherData(t *testing.T) {
+	var days []WeatherData
+	for _, s := range []string{"2025-07-25", "2025-07-26", "2025-07-27", "2025-07-28", "2025-07-29", "2025-08-01"} {
+		date, _ := ParseDate(s)
+		days = append(days, WeatherData{Date: date})
+	}
+
+	for range 20 {
+		shuffled := append([]WeatherData(nil), days...)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+
+		SortWeatherData(shuffled)
+		if !WeatherDataSorted(shuffled) {
+			t.Fatalf("Expected the days sorted, got %v", shuffled)
+		}
+		for i := range days {
+			if !shuffled[i].Date.Equal(days[i].Date.Time) {
+				t.Fatalf("Expected %s at %d, got %s", days[i].Date, i, shuffled[i].Date)
+			}
+		}
+	}
+
+	if WeatherDataSorted([]WeatherData{days[1], days[0]}) {
+		t.Error("Expected the days out of order to be reported")
+	}
+	if !WeatherDataSorted(nil) || !WeatherDataSorted([]WeatherData{days[0], days[0]}) {
+		t.Error("Expected no days and the days of the same date to be sorted")
+	}
+}
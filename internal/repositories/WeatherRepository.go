@@ -2,7 +2,11 @@ package repositories
 
 import (
 	"context"
+	"fmt"
+	"maps"
 	"net/http"
+	"slices"
+	"strings"
 
 	"weather-api/config"
 	"weather-api/internal/models"
@@ -27,23 +31,271 @@ type WeatherRepository interface {
 	FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error)
 }
 
-func InitWeatherRepositories(cfg *config.Config, l *logger.Logger) ([]WeatherRepository, error) {
+// KeyedProvider is implemented by the providers requiring an API key
+type KeyedProvider interface {
+	RequiresAPIKey() bool
+}
+
+// RequiresAPIKey reports whether repo needs an API key to serve forecasts
+func RequiresAPIKey(repo WeatherRepository) bool {
+	keyed, ok := As[KeyedProvider](repo)
+	return ok && keyed.RequiresAPIKey()
+}
+
+// HorizonProvider is implemented by the providers forecasting a limited number of days
+type HorizonProvider interface {
+	// MaxDays is the longest forecast window served, zero is unlimited
+	MaxDays() int
+}
+
+// MaxDays returns the longest forecast window repo serves, zero when it isn't limited
+func MaxDays(repo WeatherRepository) int {
+	if horizon, ok := As[HorizonProvider](repo); ok {
+		return max(horizon.MaxDays(), 0)
+	}
+
+	return 0
+}
+
+func InitWeatherRepositories(cfg *config.Config, l logger.Logger) ([]WeatherRepository, error) {
 	var repos []WeatherRepository
-	httpClient := &DefaultHTTPClient{}
-
-	for _, api := range cfg.Weather.APIs {
-		switch api.Name {
-		case "open-meteo":
-			repos = append(repos, NewOpenMeteoRepository(l, httpClient))
-		case "weatherapi":
-			repo, err := NewWeatherAPIRepository(api.APIKey, l, httpClient)
+
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	// Forecasts are keyed by provider name, a duplicate would silently hide the other provider
+	seen := make(map[string]int, len(cfg.Weather.APIs))
+
+	var enabled, disabled, degraded []string
+
+	for i, api := range cfg.Weather.APIs {
+		// A misspelled provider is an error even when disabled, it would silently never be enabled
+		if _, ok := providers[api.Name]; !ok {
+			return nil, fmt.Errorf("unknown weather provider %q in weather.apis[%d], expected one of: %s",
+				api.Name, i, strings.Join(WeatherProviders(), ", "))
+		}
+		if !api.IsEnabled() {
+			disabled = append(disabled, api.Name)
+			continue
+		}
+		// A provider without its key would fail every request, the service rather fails to start, or runs without it
+		if err := checkAPIKey(api); err != nil {
+			if !cfg.Weather.AllowDegraded {
+				return nil, fmt.Errorf("weather.apis[%d]: %w, set it or disable the provider", i, err)
+			}
+			l.Warning("weather provider disabled: no usable API key, weather.allow_degraded keeps the service running without it", map[string]any{
+				"provider": api.Name,
+				"err":      err,
+			})
+			degraded = append(degraded, api.Name)
+			continue
+		}
+		if api.Name == "mock" && cfg.IsProduction() {
+			l.Warning("mock weather provider is configured in production, it serves synthetic data", map[string]any{
+				"env": cfg.App.Env,
+			})
+		}
+
+		repo, err := newWeatherRepository(api, l, httpClient)
+		if err != nil {
+			return nil, err
+		}
+
+		if first, ok := seen[repo.Name()]; ok {
+			return nil, fmt.Errorf("duplicate weather provider %q in weather.apis[%d] and weather.apis[%d], provider names must be unique", repo.Name(), first, i)
+		}
+		seen[repo.Name()] = i
+
+		if api.Canary != nil {
+			canary, err := newWeatherRepository(api.Canary.Member(api), l, httpClient)
 			if err != nil {
-				return nil, err
+				return nil, fmt.Errorf("failed to initialize canary for %s: %w", api.Name, err)
 			}
-			repos = append(repos, repo)
-			// add more cases for new providers to extend the app
+
+			repo = NewCanaryRepository(repo, canary, api.Canary.Percent, l)
 		}
+		if cfg.Tracing.Enabled {
+			repo = NewTracedRepository(repo)
+		}
+
+		repos = append(repos, repo)
+		enabled = append(enabled, repo.Name())
 	}
 
+	l.Info("weather providers", map[string]any{"enabled": enabled, "disabled": disabled, "degraded": degraded})
+
 	return repos, nil
 }
+
+// InitGeocodingRepository builds the geocoding repository, it shares the HTTP mode of the weather providers
+func InitGeocodingRepository(cfg *config.Config, l logger.Logger) (*GeocodingRepository, error) {
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewGeocodingRepository(l, httpClient), nil
+}
+
+// InitAirQualityRepository builds the air quality repository, it shares the HTTP mode of the weather providers,
+// nil when disabled
+func InitAirQualityRepository(cfg *config.Config, l logger.Logger) (AirQualityProvider, error) {
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := NewAirQualityRepository(l, httpClient)
+	if !cfg.ProviderEnabled(repo.Name()) {
+		return nil, nil
+	}
+
+	return repo, nil
+}
+
+// InitMarineRepository builds the marine repository, it shares the HTTP mode of the weather providers,
+// nil when disabled
+func InitMarineRepository(cfg *config.Config, l logger.Logger) (MarineProvider, error) {
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	repo := NewMarineRepository(l, httpClient)
+	if !cfg.ProviderEnabled(repo.Name()) {
+		return nil, nil
+	}
+
+	return repo, nil
+}
+
+// InitAlertSources builds the alert providers serving no forecasts enabled in the configuration
+func InitAlertSources(cfg *config.Config, l logger.Logger) ([]AlertSource, error) {
+	var sources []AlertSource
+	if !cfg.Weather.Alerts.NWS.Enabled {
+		return sources, nil
+	}
+
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(sources, NewNWSRepository(cfg.Weather.Alerts.NWS.UserAgent, l, httpClient)), nil
+}
+
+// InitIPLocator builds the IP locator of the callers without coordinates, nil when geolocation is disabled
+func InitIPLocator(cfg *config.Config, l logger.Logger) (IPLocator, error) {
+	if !cfg.Weather.Geolocation.Enabled {
+		return nil, nil
+	}
+
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewIPAPILocator(l, httpClient), nil
+}
+
+// newHTTPClient selects the HTTP client of the providers according to the configured mode, traced when tracing
+// is enabled
+func newHTTPClient(cfg *config.Config) (HTTPClient, error) {
+	var client HTTPClient
+	switch cfg.Weather.HTTPMode {
+	case "", HTTPModeLive:
+		client = &DefaultHTTPClient{}
+	case HTTPModeRecord, HTTPModeReplay:
+		recording, err := NewRecordingHTTPClient(cfg.Weather.HTTPMode, cfg.Weather.FixturesDir, &DefaultHTTPClient{})
+		if err != nil {
+			return nil, err
+		}
+		client = recording
+	default:
+		return nil, fmt.Errorf("unsupported weather.http_mode: %s", cfg.Weather.HTTPMode)
+	}
+
+	if cfg.Tracing.Enabled {
+		client = NewTracingHTTPClient(client)
+	}
+
+	return client, nil
+}
+
+// ProviderFactory builds a forecast provider from its entry of weather.apis
+type ProviderFactory func(api config.WeatherAPIConfig, l logger.Logger, httpClient HTTPClient) (WeatherRepository, error)
+
+// registeredProvider is a forecast provider of weather.apis
+type registeredProvider struct {
+	factory ProviderFactory
+	// requiresKey is set for the providers registered with RegisterKeyedProvider
+	requiresKey bool
+}
+
+// providers are the forecast providers of weather.apis by name, see RegisterProvider
+var providers = map[string]registeredProvider{}
+
+// RegisterProvider makes a forecast provider available to weather.apis under name, the providers register
+// themselves from the init of their file. Registering a name twice panics.
+func RegisterProvider(name string, factory ProviderFactory) {
+	register(name, registeredProvider{factory: factory})
+}
+
+// RegisterKeyedProvider registers a forecast provider like RegisterProvider, its entries of weather.apis must set
+// an API key
+func RegisterKeyedProvider(name string, factory ProviderFactory) {
+	register(name, registeredProvider{factory: factory, requiresKey: true})
+}
+
+func register(name string, provider registeredProvider) {
+	if _, ok := providers[name]; ok {
+		panic("weather provider registered twice: " + name)
+	}
+	providers[name] = provider
+}
+
+// APIKeyPlaceholder is the API key of the sample configurations, it must be replaced by a real one
+const APIKeyPlaceholder = "YOUR-API-KEY-HERE"
+
+// checkAPIKey returns an error when the provider of api requires an API key, and it, or the one of its canary, is
+// missing or still the placeholder
+func checkAPIKey(api config.WeatherAPIConfig) error {
+	if !providers[api.Name].requiresKey {
+		return nil
+	}
+
+	keys := map[string]string{"api_key": api.APIKey}
+	if api.Canary != nil {
+		if key := api.Canary.Member(api).APIKey; key != api.APIKey {
+			keys["canary.api_key"] = key
+		}
+	}
+	for _, field := range slices.Sorted(maps.Keys(keys)) {
+		key := strings.TrimSpace(keys[field])
+		switch {
+		case key == "":
+			return fmt.Errorf("%s requires an API key, %s is empty", api.Name, field)
+		case strings.EqualFold(key, APIKeyPlaceholder):
+			return fmt.Errorf("%s requires an API key, %s is the placeholder %s", api.Name, field, APIKeyPlaceholder)
+		}
+	}
+
+	return nil
+}
+
+// WeatherProviders returns the sorted names of the forecast providers of weather.apis
+func WeatherProviders() []string {
+	return slices.Sorted(maps.Keys(providers))
+}
+
+// newWeatherRepository builds a single repository from its provider configuration
+func newWeatherRepository(api config.WeatherAPIConfig, l logger.Logger, httpClient HTTPClient) (WeatherRepository, error) {
+	provider, ok := providers[api.Name]
+	if !ok {
+		return nil, fmt.Errorf("unknown weather provider %q, expected one of: %s", api.Name, strings.Join(WeatherProviders(), ", "))
+	}
+
+	return provider.factory(api, l, httpClient)
+}
@@ -0,0 +1,163 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+)
+
+const OpenMeteoAirQualityURL = "https://air-quality-api.open-meteo.com/v1/air-quality"
+
+// openMeteoAirQualityParams are the hourly variables requested from the Open-Meteo Air Quality API,
+// it has no daily variables so the days are summarized from the hours
+const openMeteoAirQualityParams = "pm2_5,pm10,ozone,us_aqi"
+
+// AirQualityProvider supplies daily air quality forecasts, *AirQualityRepository implements it
+type AirQualityProvider interface {
+	Name() string
+	FetchAirQuality(ctx context.Context, lat, lon float64, days int) (models.AirQuality, error)
+}
+
+// AirQualityRepository fetches air quality forecasts from the Open-Meteo Air Quality API
+type AirQualityRepository struct {
+	baseURL string
+	openMeteoAPI
+}
+
+var _ AirQualityProvider = (*AirQualityRepository)(nil)
+
+func NewAirQualityRepository(l logger.Logger, httpClient HTTPClient) *AirQualityRepository {
+	return &AirQualityRepository{
+		baseURL:      OpenMeteoAirQualityURL,
+		openMeteoAPI: newOpenMeteoAPI("openmeteo air quality", l, httpClient),
+	}
+}
+
+func (a *AirQualityRepository) Name() string {
+	return "open-meteo-air-quality"
+}
+
+type OpenMeteoAirQualityHourly struct {
+	Time  []string   `json:"time"`
+	PM25  []*float64 `json:"pm2_5"`
+	PM10  []*float64 `json:"pm10"`
+	Ozone []*float64 `json:"ozone"`
+	USAQI []*float64 `json:"us_aqi"`
+}
+
+func (a *AirQualityRepository) FetchAirQuality(ctx context.Context, lat, lon float64, days int) (models.AirQuality, error) {
+	airQuality := models.AirQuality{
+		RepositoryName: a.Name(),
+		Lat:            lat,
+		Lon:            lon,
+		ForecastWindow: days,
+		AirQualityData: []models.AirQualityData{},
+	}
+
+	url := fmt.Sprintf("%s?latitude=%f&longitude=%f&hourly=%s&forecast_days=%d&timezone=auto", a.baseURL, lat, lon, openMeteoAirQualityParams, days)
+
+	body, err := a.get(ctx, url, airQuality.RequestParams(), &airQuality.FetchMetadata)
+	if err != nil {
+		return airQuality, err
+	}
+
+	var response struct {
+		Timezone string                     `json:"timezone"`
+		Hourly   *OpenMeteoAirQualityHourly `json:"hourly"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return airQuality, invalidResponse("failed to parse JSON response: %w", err)
+	}
+	if response.Hourly == nil || len(response.Hourly.Time) == 0 {
+		return airQuality, ErrNoData
+	}
+	airQuality.Timezone = response.Timezone
+
+	data, err := dailyAirQuality(*response.Hourly)
+	if err != nil {
+		return airQuality, err
+	}
+	airQuality.AirQualityData = data
+
+	return airQuality, nil
+}
+
+// airQualityDay accumulates the hourly values of a day
+type airQualityDay struct {
+	date       models.Date
+	pm25, pm10 []float64
+	ozone, aqi *float64
+}
+
+// dailyAirQuality summarizes the hourly values by local day, in the order of the hours
+func dailyAirQuality(hourly OpenMeteoAirQualityHourly) ([]models.AirQualityData, error) {
+	var days []*airQualityDay
+	for i, t := range hourly.Time {
+		if len(t) < len(models.DateLayout) {
+			return nil, invalidResponse("failed to parse time %s", t)
+		}
+		date, err := models.ParseDate(t[:len(models.DateLayout)])
+		if err != nil {
+			return nil, invalidResponse("failed to parse time %s: %w", t, err)
+		}
+
+		if len(days) == 0 || !days[len(days)-1].date.Equal(date.Time) {
+			days = append(days, &airQualityDay{date: date})
+		}
+		day := days[len(days)-1]
+
+		if v := valueAt(hourly.PM25, i); v != nil {
+			day.pm25 = append(day.pm25, *v)
+		}
+		if v := valueAt(hourly.PM10, i); v != nil {
+			day.pm10 = append(day.pm10, *v)
+		}
+		day.ozone = maxValue(day.ozone, valueAt(hourly.Ozone, i))
+		day.aqi = maxValue(day.aqi, valueAt(hourly.USAQI, i))
+	}
+
+	data := make([]models.AirQualityData, 0, len(days))
+	for _, day := range days {
+		wd := models.AirQualityData{
+			Date:  day.date,
+			PM25:  meanValue(day.pm25),
+			PM10:  meanValue(day.pm10),
+			Ozone: day.ozone,
+		}
+		if day.aqi != nil {
+			aqi := int(math.Round(*day.aqi))
+			wd.AQI = &aqi
+			wd.AQICategory = models.AQICategory(aqi)
+		}
+		data = append(data, wd)
+	}
+
+	return data, nil
+}
+
+// meanValue returns the mean rounded to one decimal, nil without values
+func meanValue(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := math.Round(sum/float64(len(values))*10) / 10
+
+	return &mean
+}
+
+func maxValue(current, v *float64) *float64 {
+	if v == nil || (current != nil && *current >= *v) {
+		return current
+	}
+
+	return v
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+)
+
+func TestAirQualityRepository_FetchAirQuality(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if !strings.Contains(req.URL.String(), "hourly=pm2_5,pm10,ozone,us_aqi") || !strings.Contains(req.URL.String(), "forecast_days=2") {
+				t.Errorf("Unexpected URL: %s", req.URL.String())
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"timezone": "Europe/Berlin",
+					"hourly": {
+						"time": ["2025-01-27T00:00", "2025-01-27T01:00", "2025-01-28T00:00", "2025-01-28T01:00"],
+						"pm2_5": [8.0, 9.0, 30.2, null],
+						"pm10": [12.0, 15.0, null, null],
+						"ozone": [40.0, 55.5, null, 20.0],
+						"us_aqi": [33, 41, 88.6, 151]
+					}
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewAirQualityRepository(logger.NopLogger{}, mockClient)
+
+	result, err := repo.FetchAirQuality(context.Background(), 52.52, 13.41, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.RepositoryName != "open-meteo-air-quality" || result.Timezone != "Europe/Berlin" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	if len(result.AirQualityData) != 2 {
+		t.Fatalf("Expected 2 days, got %d", len(result.AirQualityData))
+	}
+
+	first := result.AirQualityData[0]
+	if first.Date.String() != "2025-01-27" || *first.PM25 != 8.5 || *first.PM10 != 13.5 || *first.Ozone != 55.5 {
+		t.Errorf("Unexpected first day: %+v", first)
+	}
+	if *first.AQI != 41 || first.AQICategory != models.AQIGood {
+		t.Errorf("Expected AQI 41 (good), got %d (%s)", *first.AQI, first.AQICategory)
+	}
+
+	second := result.AirQualityData[1]
+	if *second.PM25 != 30.2 || second.PM10 != nil || *second.Ozone != 20 {
+		t.Errorf("Unexpected second day: %+v", second)
+	}
+	if *second.AQI != 151 || second.AQICategory != models.AQIUnhealthy {
+		t.Errorf("Expected AQI 151 (unhealthy), got %d (%s)", *second.AQI, second.AQICategory)
+	}
+}
+
+func TestAirQualityRepository_FetchAirQuality_NoData(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"timezone": "GMT"}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewAirQualityRepository(logger.NopLogger{}, mockClient)
+
+	if _, err := repo.FetchAirQuality(context.Background(), 52.52, 13.41, 1); !errors.Is(err, ErrNoData) {
+		t.Errorf("Expected ErrNoData, got: %v", err)
+	}
+}
+
+func TestAirQualityRepository_FetchAirQuality_HTTPError(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Status:     "400 Bad Request",
+				Body:       io.NopCloser(strings.NewReader(`{"error": true, "reason": "Invalid forecast_days"}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewAirQualityRepository(logger.NopLogger{}, mockClient)
+
+	_, err := repo.FetchAirQuality(context.Background(), 52.52, 13.41, 1)
+
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTPStatusError 400, got: %v", err)
+	}
+}
+
+func TestAirQualityRepository_Name(t *testing.T) {
+	repo := NewAirQualityRepository(logger.NopLogger{}, &MockHTTPClient{})
+
+	if repo.Name() != "open-meteo-air-quality" {
+		t.Errorf("Expected name 'open-meteo-air-quality', got '%s'", repo.Name())
+	}
+}
@@ -0,0 +1,28 @@
+package repositories
+
+import (
+	"context"
+
+	"weather-api/internal/models"
+)
+
+// AlertProvider is implemented by the providers exposing the active weather alerts of a location
+type AlertProvider interface {
+	FetchAlerts(ctx context.Context, lat, lon float64) ([]models.Alert, error)
+}
+
+// AlertSource is an alert provider that doesn't serve forecasts, e.g. NWSRepository
+type AlertSource interface {
+	Name() string
+	AlertProvider
+}
+
+// FetchAlerts fetches the active alerts from repo, or returns ErrUnsupported when the provider has none
+func FetchAlerts(ctx context.Context, repo WeatherRepository, lat, lon float64) ([]models.Alert, error) {
+	alerts, ok := As[AlertProvider](repo)
+	if !ok {
+		return nil, ErrUnsupported
+	}
+
+	return alerts.FetchAlerts(ctx, lat, lon)
+}
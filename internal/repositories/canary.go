@@ -0,0 +1,260 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand/v2"
+	"sync/atomic"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+)
+
+const (
+	CanaryMemberPrimary = "primary"
+	CanaryMemberCanary  = "canary"
+)
+
+type canaryKeyCtx struct{}
+
+// WithCanaryKey stores the identity used to assign a request to a canary member.
+// Requests carrying the same key are always routed to the same member.
+func WithCanaryKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, canaryKeyCtx{}, key)
+}
+
+func canaryKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(canaryKeyCtx{}).(string)
+	return key
+}
+
+// CanaryMemberStats holds the request and error counters of a canary member
+type CanaryMemberStats struct {
+	Requests int64 `json:"requests"`
+	Errors   int64 `json:"errors"`
+}
+
+// CanaryStats describes the current state of a canary group
+type CanaryStats struct {
+	Name    string            `json:"name"`
+	Percent int               `json:"percent"`
+	Primary CanaryMemberStats `json:"primary"`
+	Canary  CanaryMemberStats `json:"canary"`
+}
+
+type canaryMember struct {
+	repo     WeatherRepository
+	requests atomic.Int64
+	errors   atomic.Int64
+}
+
+// CanaryRepository routes a percentage of the traffic of a provider to an
+// alternative configuration of the same provider
+type CanaryRepository struct {
+	primary *canaryMember
+	canary  *canaryMember
+	percent atomic.Int32
+	l       logger.Logger
+}
+
+func NewCanaryRepository(primary, canary WeatherRepository, percent int, l logger.Logger) *CanaryRepository {
+	c := &CanaryRepository{
+		primary: &canaryMember{repo: primary},
+		canary:  &canaryMember{repo: canary},
+		l:       l,
+	}
+	c.percent.Store(int32(clampPercent(percent)))
+
+	return c
+}
+
+// Name returns the name of the primary member, so the group is transparent to clients
+func (c *CanaryRepository) Name() string {
+	return c.primary.repo.Name()
+}
+
+// Percent returns the share of the traffic routed to the canary member
+func (c *CanaryRepository) Percent() int {
+	return int(c.percent.Load())
+}
+
+// SetPercent changes the share of the traffic routed to the canary member
+func (c *CanaryRepository) SetPercent(percent int) error {
+	if percent < 0 || percent > 100 {
+		return fmt.Errorf("canary percent must be between 0 and 100, got: %d", percent)
+	}
+
+	c.percent.Store(int32(percent))
+
+	c.l.Info("canary percent updated", map[string]any{
+		"repo":    c.Name(),
+		"percent": percent,
+	})
+
+	return nil
+}
+
+// RequiresAPIKey reports whether one of the members needs an API key
+func (c *CanaryRepository) RequiresAPIKey() bool {
+	return RequiresAPIKey(c.primary.repo) || RequiresAPIKey(c.canary.repo)
+}
+
+// MaxDays is the shortest horizon of the members, either of them may serve a request
+func (c *CanaryRepository) MaxDays() int {
+	primary, canary := MaxDays(c.primary.repo), MaxDays(c.canary.repo)
+	if primary == 0 || (canary != 0 && canary < primary) {
+		return canary
+	}
+
+	return primary
+}
+
+// Stats returns the per-member counters of the group
+func (c *CanaryRepository) Stats() CanaryStats {
+	return CanaryStats{
+		Name:    c.Name(),
+		Percent: c.Percent(),
+		Primary: CanaryMemberStats{Requests: c.primary.requests.Load(), Errors: c.primary.errors.Load()},
+		Canary:  CanaryMemberStats{Requests: c.canary.requests.Load(), Errors: c.canary.errors.Load()},
+	}
+}
+
+func (c *CanaryRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	member, memberName := c.pick(ctx)
+	member.requests.Add(1)
+
+	forecast, err := member.repo.FetchForecast(ctx, lat, lon, forecastWindow)
+	if err != nil {
+		member.errors.Add(1)
+		return forecast, fmt.Errorf("%s member: %w", memberName, err)
+	}
+
+	logger.FromContext(ctx, c.l).Debug("canary member served forecast", map[string]any{
+		"repo":   c.Name(),
+		"member": memberName,
+	})
+
+	forecast.RepositoryName = c.Name()
+	forecast.Variant = memberName
+
+	return forecast, nil
+}
+
+// FetchHourlyForecast routes the hourly forecast like FetchForecast,
+// members without hourly support return ErrUnsupported
+func (c *CanaryRepository) FetchHourlyForecast(ctx context.Context, lat, lon float64, hours int) (models.HourlyForecast, error) {
+	member, memberName := c.pick(ctx)
+	member.requests.Add(1)
+
+	forecast, err := FetchHourlyForecast(ctx, member.repo, lat, lon, hours)
+	if err != nil {
+		member.errors.Add(1)
+		return forecast, fmt.Errorf("%s member: %w", memberName, err)
+	}
+
+	forecast.RepositoryName = c.Name()
+
+	return forecast, nil
+}
+
+// FetchCurrent routes the current conditions like FetchForecast,
+// members without current conditions return ErrUnsupported
+func (c *CanaryRepository) FetchCurrent(ctx context.Context, lat, lon float64) (models.CurrentWeather, error) {
+	member, memberName := c.pick(ctx)
+	member.requests.Add(1)
+
+	current, err := FetchCurrent(ctx, member.repo, lat, lon)
+	if err != nil {
+		member.errors.Add(1)
+		return current, fmt.Errorf("%s member: %w", memberName, err)
+	}
+
+	current.RepositoryName = c.Name()
+
+	return current, nil
+}
+
+// FetchHistory routes the history like FetchForecast, members without an archive return ErrUnsupported
+func (c *CanaryRepository) FetchHistory(ctx context.Context, lat, lon float64, start, end models.Date) (models.HistoricalWeather, error) {
+	member, memberName := c.pick(ctx)
+	member.requests.Add(1)
+
+	history, err := FetchHistory(ctx, member.repo, lat, lon, start, end)
+	if err != nil {
+		member.errors.Add(1)
+		return history, fmt.Errorf("%s member: %w", memberName, err)
+	}
+
+	history.RepositoryName = c.Name()
+
+	return history, nil
+}
+
+// FetchAlerts routes the alerts like FetchForecast, members without alerts return ErrUnsupported
+func (c *CanaryRepository) FetchAlerts(ctx context.Context, lat, lon float64) ([]models.Alert, error) {
+	member, memberName := c.pick(ctx)
+	member.requests.Add(1)
+
+	alerts, err := FetchAlerts(ctx, member.repo, lat, lon)
+	if err != nil {
+		member.errors.Add(1)
+		return nil, fmt.Errorf("%s member: %w", memberName, err)
+	}
+
+	for i := range alerts {
+		alerts[i].Source = c.Name()
+	}
+
+	return alerts, nil
+}
+
+// pick selects the member serving the request
+func (c *CanaryRepository) pick(ctx context.Context) (*canaryMember, string) {
+	if c.useCanary(canaryKeyFromContext(ctx)) {
+		return c.canary, CanaryMemberCanary
+	}
+
+	return c.primary, CanaryMemberPrimary
+}
+
+// useCanary decides whether the request identified by key goes to the canary member
+func (c *CanaryRepository) useCanary(key string) bool {
+	percent := c.percent.Load()
+	if percent <= 0 {
+		return false
+	}
+	if percent >= 100 {
+		return true
+	}
+
+	return canaryBucket(key) < uint32(percent)
+}
+
+// canaryBucket maps a key to a bucket in [0, 100), requests without a key are assigned randomly
+func canaryBucket(key string) uint32 {
+	if key == "" {
+		return rand.Uint32N(100)
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(key))
+
+	return h.Sum32() % 100
+}
+
+// Canaries returns the canary groups among the given repositories
+func Canaries(repos []WeatherRepository) []*CanaryRepository {
+	var canaries []*CanaryRepository
+	for _, repo := range repos {
+		if c, ok := As[*CanaryRepository](repo); ok {
+			canaries = append(canaries, c)
+		}
+	}
+
+	return canaries
+}
+
+func clampPercent(percent int) int {
+	return max(0, min(100, percent))
+}
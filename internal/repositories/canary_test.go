@@ -0,0 +1,231 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"weather-api/config"
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+)
+
+// stubRepository is a minimal WeatherRepository counting its calls
+type stubRepository struct {
+	name  string
+	err   error
+	calls int
+}
+
+func (s *stubRepository) Name() string {
+	return s.name
+}
+
+func (s *stubRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	s.calls++
+	if s.err != nil {
+		return models.Forecast{}, s.err
+	}
+
+	return models.Forecast{RepositoryName: s.name, Lat: lat, Lon: lon, ForecastWindow: forecastWindow}, nil
+}
+
+func newTestCanary(percent int) (*CanaryRepository, *stubRepository, *stubRepository) {
+	primary := &stubRepository{name: "weatherapi"}
+	canary := &stubRepository{name: "weatherapi"}
+
+	return NewCanaryRepository(primary, canary, percent, logger.NopLogger{}), primary, canary
+}
+
+func TestCanaryRepository_SplitRatio(t *testing.T) {
+	repo, primary, canary := newTestCanary(5)
+
+	const requests = 20000
+	for i := 0; i < requests; i++ {
+		ctx := WithCanaryKey(context.Background(), fmt.Sprintf("client-%d", i))
+		if _, err := repo.FetchForecast(ctx, 45.44, 12.33, 5); err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+	}
+
+	if primary.calls+canary.calls != requests {
+		t.Fatalf("Expected %d calls in total, got %d", requests, primary.calls+canary.calls)
+	}
+
+	share := float64(canary.calls) / requests * 100
+	if share < 4 || share > 6 {
+		t.Errorf("Expected canary share around 5%%, got %.2f%%", share)
+	}
+
+	stats := repo.Stats()
+	if stats.Canary.Requests != int64(canary.calls) || stats.Primary.Requests != int64(primary.calls) {
+		t.Errorf("Expected stats to match member calls, got %+v", stats)
+	}
+}
+
+func TestCanaryRepository_Stickiness(t *testing.T) {
+	repo, primary, canary := newTestCanary(50)
+
+	for i := 0; i < 50; i++ {
+		key := fmt.Sprintf("client-%d", i)
+		ctx := WithCanaryKey(context.Background(), key)
+
+		_, _ = repo.FetchForecast(ctx, 45.44, 12.33, 5)
+		firstCanary := canary.calls
+
+		for j := 0; j < 10; j++ {
+			_, _ = repo.FetchForecast(ctx, 45.44, 12.33, 5)
+		}
+
+		wentToCanary := firstCanary > 0 && canary.calls == firstCanary+10
+		wentToPrimary := canary.calls == firstCanary
+		if !wentToCanary && !wentToPrimary {
+			t.Fatalf("Expected key %s to stick to a single member", key)
+		}
+
+		primary.calls, canary.calls = 0, 0
+	}
+}
+
+func TestCanaryRepository_ZeroAndFullPercent(t *testing.T) {
+	repo, primary, canary := newTestCanary(0)
+
+	for i := 0; i < 1000; i++ {
+		ctx := WithCanaryKey(context.Background(), fmt.Sprintf("client-%d", i))
+		_, _ = repo.FetchForecast(ctx, 45.44, 12.33, 5)
+	}
+	if canary.calls != 0 {
+		t.Errorf("Expected no canary calls at 0%%, got %d", canary.calls)
+	}
+
+	if err := repo.SetPercent(100); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	primary.calls = 0
+
+	for i := 0; i < 1000; i++ {
+		ctx := WithCanaryKey(context.Background(), fmt.Sprintf("client-%d", i))
+		_, _ = repo.FetchForecast(ctx, 45.44, 12.33, 5)
+	}
+	if primary.calls != 0 {
+		t.Errorf("Expected no primary calls at 100%%, got %d", primary.calls)
+	}
+}
+
+func TestCanaryRepository_SetPercentInvalid(t *testing.T) {
+	repo, _, _ := newTestCanary(5)
+
+	for _, percent := range []int{-1, 101} {
+		if err := repo.SetPercent(percent); err == nil {
+			t.Errorf("Expected error for percent %d, got nil", percent)
+		}
+	}
+
+	if repo.Percent() != 5 {
+		t.Errorf("Expected percent to stay 5, got %d", repo.Percent())
+	}
+}
+
+func TestCanaryRepository_ErrorAttribution(t *testing.T) {
+	repo, _, canary := newTestCanary(100)
+	canary.err = errors.New("one call unavailable")
+
+	forecast, err := repo.FetchForecast(context.Background(), 45.44, 12.33, 5)
+	if err == nil {
+		t.Fatal("Expected error from canary member, got nil")
+	}
+
+	stats := repo.Stats()
+	if stats.Canary.Errors != 1 || stats.Primary.Errors != 0 {
+		t.Errorf("Expected error attributed to canary member, got %+v", stats)
+	}
+	if forecast.RepositoryName != "" {
+		t.Errorf("Expected empty forecast on error, got %+v", forecast)
+	}
+}
+
+func TestCanaryRepository_FetchHourlyForecastUnsupported(t *testing.T) {
+	repo, _, _ := newTestCanary(0)
+
+	_, err := repo.FetchHourlyForecast(context.Background(), 45.44, 12.33, 24)
+	if !errors.Is(err, ErrUnsupported) {
+		t.Errorf("Expected ErrUnsupported, got: %v", err)
+	}
+	if repo.Stats().Primary.Errors != 1 {
+		t.Errorf("Expected error attributed to primary member, got %+v", repo.Stats())
+	}
+}
+
+func TestCanaryRepository_MaxDays(t *testing.T) {
+	l := logger.NopLogger{}
+	openMeteo := NewOpenMeteoRepository(l, &DefaultHTTPClient{})
+	unlimited := &stubRepository{name: "open-meteo"}
+
+	cases := []struct {
+		name            string
+		primary, canary WeatherRepository
+		want            int
+	}{
+		{"both unlimited", unlimited, &stubRepository{name: "open-meteo"}, 0},
+		{"unlimited primary", unlimited, openMeteo, OpenMeteoMaxDays},
+		{"unlimited canary", openMeteo, unlimited, OpenMeteoMaxDays},
+		{"both limited", openMeteo, openMeteo, OpenMeteoMaxDays},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			repo := NewCanaryRepository(tc.primary, tc.canary, 10, l)
+			if got := MaxDays(repo); got != tc.want {
+				t.Errorf("Expected max days %d, got %d", tc.want, got)
+			}
+		})
+	}
+}
+
+func TestInitWeatherRepositories_CanaryEndpoint(t *testing.T) {
+	const base = "https://owm.example.com/data/2.5/forecast"
+	cfg := &config.Config{
+		App: config.AppConfig{Env: "development"},
+		Weather: config.WeatherConfig{APIs: []config.WeatherAPIConfig{{
+			Name: "weatherapi", APIKey: "0123456789abcdef", BaseURL: base, Timeout: 5,
+			Canary: &config.CanaryConfig{Percent: 5, Override: &config.WeatherAPIConfig{Endpoint: WeatherAPIEndpointOneCall}},
+		}}},
+	}
+
+	repos, err := InitWeatherRepositories(cfg, logger.NopLogger{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	group, ok := repos[0].(*CanaryRepository)
+	if !ok {
+		t.Fatalf("Expected a canary group, got %T", repos[0])
+	}
+
+	primary := group.primary.repo.(*WeatherAPIRepository)
+	canary := group.canary.repo.(*WeatherAPIRepository)
+	if primary.endpoint != WeatherAPIEndpointForecast || canary.endpoint != WeatherAPIEndpointOneCall {
+		t.Errorf("Expected the forecast endpoint for the primary and One Call for the canary, got %s and %s",
+			primary.endpoint, canary.endpoint)
+	}
+	// The settings the override leaves unset are the provider's
+	if canary.APIKey != primary.APIKey || canary.baseURL != base {
+		t.Errorf("Expected the canary to keep the API key and base URL of the provider, got %s and %s", canary.APIKey, canary.baseURL)
+	}
+	if MaxDays(group) != WeatherAPIOneCallMaxDays {
+		t.Errorf("Expected the group to be limited to the One Call horizon, got %d", MaxDays(group))
+	}
+}
+
+func TestCanaryRepository_Variant(t *testing.T) {
+	for percent, want := range map[int]string{0: CanaryMemberPrimary, 100: CanaryMemberCanary} {
+		repo, _, _ := newTestCanary(percent)
+
+		forecast, err := repo.FetchForecast(context.Background(), 45.44, 12.33, 3)
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if forecast.RepositoryName != "weatherapi" || forecast.Variant != want {
+			t.Errorf("Expected the forecast of weatherapi tagged %s at %d%%, got %s tagged %q", want, percent, forecast.RepositoryName, forecast.Variant)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import "weather-api/internal/models"
+
+// wmoConditions maps the WMO weather interpretation codes reported by Open-Meteo
+var wmoConditions = map[int]models.Condition{
+	0:  models.ConditionClear,
+	1:  models.ConditionClear,
+	2:  models.ConditionPartlyCloudy,
+	3:  models.ConditionCloudy,
+	45: models.ConditionFog,
+	48: models.ConditionFog,
+	51: models.ConditionDrizzle,
+	53: models.ConditionDrizzle,
+	55: models.ConditionDrizzle,
+	56: models.ConditionDrizzle,
+	57: models.ConditionDrizzle,
+	61: models.ConditionRain,
+	63: models.ConditionRain,
+	65: models.ConditionRain,
+	66: models.ConditionRain,
+	67: models.ConditionRain,
+	71: models.ConditionSnow,
+	73: models.ConditionSnow,
+	75: models.ConditionSnow,
+	77: models.ConditionSnow,
+	80: models.ConditionRain,
+	81: models.ConditionRain,
+	82: models.ConditionRain,
+	85: models.ConditionSnow,
+	86: models.ConditionSnow,
+	95: models.ConditionThunderstorm,
+	96: models.ConditionThunderstorm,
+	99: models.ConditionThunderstorm,
+}
+
+// wmoCondition normalizes a WMO weather code
+func wmoCondition(code int) models.Condition {
+	if c, ok := wmoConditions[code]; ok {
+		return c
+	}
+	return models.ConditionUnknown
+}
+
+// owmConditionGroups maps the OpenWeatherMap condition id groups (the hundreds digit)
+var owmConditionGroups = map[int]models.Condition{
+	2: models.ConditionThunderstorm,
+	3: models.ConditionDrizzle,
+	5: models.ConditionRain,
+	6: models.ConditionSnow,
+	7: models.ConditionFog,
+}
+
+// owmConditions maps the OpenWeatherMap ids not covered by their group
+var owmConditions = map[int]models.Condition{
+	771: models.ConditionThunderstorm, // squalls
+	781: models.ConditionThunderstorm, // tornado
+	800: models.ConditionClear,
+	801: models.ConditionPartlyCloudy,
+	802: models.ConditionPartlyCloudy,
+	803: models.ConditionCloudy,
+	804: models.ConditionCloudy,
+}
+
+// owmCondition normalizes an OpenWeatherMap condition id
+func owmCondition(id int) models.Condition {
+	if c, ok := owmConditions[id]; ok {
+		return c
+	}
+	if c, ok := owmConditionGroups[id/100]; ok {
+		return c
+	}
+	return models.ConditionUnknown
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"weather-api/internal/models"
+)
+
+func TestWMOCondition(t *testing.T) {
+	tests := []struct {
+		code int
+		want models.Condition
+	}{
+		{0, models.ConditionClear},
+		{2, models.ConditionPartlyCloudy},
+		{3, models.ConditionCloudy},
+		{45, models.ConditionFog},
+		{53, models.ConditionDrizzle},
+		{63, models.ConditionRain},
+		{82, models.ConditionRain},
+		{75, models.ConditionSnow},
+		{86, models.ConditionSnow},
+		{99, models.ConditionThunderstorm},
+		{42, models.ConditionUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := wmoCondition(tt.code); got != tt.want {
+			t.Errorf("wmoCondition(%d) = %s, want %s", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestOWMCondition(t *testing.T) {
+	tests := []struct {
+		id   int
+		want models.Condition
+	}{
+		{211, models.ConditionThunderstorm},
+		{301, models.ConditionDrizzle},
+		{500, models.ConditionRain},
+		{511, models.ConditionRain},
+		{601, models.ConditionSnow},
+		{741, models.ConditionFog},
+		{781, models.ConditionThunderstorm},
+		{800, models.ConditionClear},
+		{802, models.ConditionPartlyCloudy},
+		{804, models.ConditionCloudy},
+		{999, models.ConditionUnknown},
+	}
+
+	for _, tt := range tests {
+		if got := owmCondition(tt.id); got != tt.want {
+			t.Errorf("owmCondition(%d) = %s, want %s", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestDailyTemperaturesWeatherAPI_WorstCondition(t *testing.T) {
+	body := `{
+		"list": [
+			{"dt_txt": "2025-07-25 09:00:00", "main": {"temp_min": 18, "temp_max": 20}, "weather": [{"id": 800}]},
+			{"dt_txt": "2025-07-25 12:00:00", "main": {"temp_min": 21, "temp_max": 24}, "weather": [{"id": 211}]},
+			{"dt_txt": "2025-07-25 15:00:00", "main": {"temp_min": 22, "temp_max": 25}, "weather": [{"id": 501}]},
+			{"dt_txt": "2025-07-26 00:00:00", "main": {"temp_min": 16, "temp_max": 17}, "weather": [{"id": 803}]},
+			{"dt_txt": "2025-07-26 03:00:00", "main": {"temp_min": 15, "temp_max": 16}, "weather": [{"id": 801}]}
+		]
+	}`
+
+	var response WeatherAPIResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	days, err := dailyTemperaturesWeatherAPI(response, time.UTC)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if days[0].Condition != models.ConditionThunderstorm || days[0].ConditionCode == nil || *days[0].ConditionCode != 211 {
+		t.Errorf("Expected thunderstorm (211) to win the day, got %s %v", days[0].Condition, days[0].ConditionCode)
+	}
+	if days[1].Condition != models.ConditionCloudy || days[1].ConditionCode == nil || *days[1].ConditionCode != 803 {
+		t.Errorf("Expected cloudy (803) to win the day, got %s %v", days[1].Condition, days[1].ConditionCode)
+	}
+}
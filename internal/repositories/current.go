@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"weather-api/internal/models"
+)
+
+// CurrentWeatherRepository is implemented by the providers able to serve current conditions
+type CurrentWeatherRepository interface {
+	FetchCurrent(ctx context.Context, lat, lon float64) (models.CurrentWeather, error)
+}
+
+// FetchCurrent fetches the current conditions from repo, or returns ErrUnsupported
+// when the provider can't supply them
+func FetchCurrent(ctx context.Context, repo WeatherRepository, lat, lon float64) (models.CurrentWeather, error) {
+	current, ok := As[CurrentWeatherRepository](repo)
+	if !ok {
+		return models.CurrentWeather{}, ErrUnsupported
+	}
+
+	return current.FetchCurrent(ctx, lat, lon)
+}
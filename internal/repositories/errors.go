@@ -0,0 +1,40 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrUnsupported is returned by providers for operations they can't serve
+	ErrUnsupported = errors.New("operation not supported by provider")
+	// ErrNoData is returned when a provider answers without any forecast data
+	ErrNoData = errors.New("no forecast data available")
+)
+
+// HTTPStatusError is returned when a provider answers with an unsuccessful HTTP status
+type HTTPStatusError struct {
+	StatusCode int
+	Status     string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("HTTP error (status %d): %s", e.StatusCode, e.Status)
+}
+
+// ResponseError is returned when a provider response can't be decoded
+type ResponseError struct {
+	Err error
+}
+
+func (e *ResponseError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ResponseError) Unwrap() error {
+	return e.Err
+}
+
+func invalidResponse(format string, args ...any) error {
+	return &ResponseError{Err: fmt.Errorf(format, args...)}
+}
@@ -0,0 +1,276 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+const (
+	OpenMeteoGeocodingURL = "https://geocoding-api.open-meteo.com/v1/search"
+	// BigDataCloudReverseURL is the free reverse geocoding endpoint of BigDataCloud, Open-Meteo has none
+	BigDataCloudReverseURL = "https://api.bigdatacloud.net/data/reverse-geocode-client"
+
+	// MaxGeocodingResults is the number of places requested for a name, and returned by Search
+	MaxGeocodingResults = 10
+	// geocodingCacheSize bounds the resolutions kept in memory, per direction
+	geocodingCacheSize = 1000
+	// reversePrecision rounds the coordinates of reverse lookups to 2 decimals, about 1 km
+	reversePrecision = 100
+	// dominantPopulation is how many times more populous than every other candidate a place must be
+	// for the name to resolve to it, "Paris" is Paris, France rather than Paris, Texas
+	dominantPopulation = 10
+)
+
+// ErrPlaceNotFound is returned when geocoding finds no place with the name
+var ErrPlaceNotFound = errors.New("place not found")
+
+// AmbiguousPlaceError is returned when a name matches several places without a clear winner
+type AmbiguousPlaceError struct {
+	Name       string
+	Candidates []models.Place
+}
+
+func (e *AmbiguousPlaceError) Error() string {
+	return fmt.Sprintf("%d places match %q", len(e.Candidates), e.Name)
+}
+
+// GeocodingRepository resolves place names to coordinates with the Open-Meteo geocoding API and
+// coordinates to place names with BigDataCloud, the lookups are cached in memory
+type GeocodingRepository struct {
+	baseURL    string
+	reverseURL string
+	httpClient HTTPClient
+	l          logger.Logger
+
+	mu      sync.RWMutex
+	cache   map[string][]models.Place
+	reverse map[[2]int]models.Place
+}
+
+func NewGeocodingRepository(l logger.Logger, httpClient HTTPClient) *GeocodingRepository {
+	return &GeocodingRepository{
+		baseURL:    OpenMeteoGeocodingURL,
+		reverseURL: BigDataCloudReverseURL,
+		httpClient: httpClient,
+		l:          l,
+		cache:      make(map[string][]models.Place),
+		reverse:    make(map[[2]int]models.Place),
+	}
+}
+
+// Resolve returns the place a name refers to, optionally restricted to an ISO 3166-1 alpha-2 country code.
+// It fails with ErrPlaceNotFound for unknown names and an AmbiguousPlaceError when several places match.
+func (g *GeocodingRepository) Resolve(ctx context.Context, name, country string) (models.Place, error) {
+	candidates, err := g.Search(ctx, name, country)
+	if err != nil {
+		return models.Place{}, err
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return models.Place{}, ErrPlaceNotFound
+	case len(candidates) == 1:
+		return candidates[0], nil
+	}
+
+	// Candidates are sorted by population, the first one wins when it dwarfs the others
+	for _, other := range candidates[1:] {
+		if candidates[0].Population < dominantPopulation*other.Population || candidates[0].Population == 0 {
+			return models.Place{}, &AmbiguousPlaceError{Name: name, Candidates: candidates}
+		}
+	}
+
+	return candidates[0], nil
+}
+
+// Search returns the places matching a name, the most populous first
+func (g *GeocodingRepository) Search(ctx context.Context, name, country string) ([]models.Place, error) {
+	key := strings.ToLower(strings.TrimSpace(name)) + "|" + strings.ToUpper(country)
+
+	g.mu.RLock()
+	candidates, ok := g.cache[key]
+	g.mu.RUnlock()
+	if ok {
+		return candidates, nil
+	}
+
+	candidates, err := g.search(ctx, name, country)
+	if err != nil {
+		return nil, err
+	}
+
+	g.mu.Lock()
+	cachePut(g.cache, key, candidates)
+	g.mu.Unlock()
+
+	return candidates, nil
+}
+
+// Reverse returns the place at the coordinates, rounded to about 1 km, it fails with ErrPlaceNotFound
+// away from any locality, at sea for instance
+func (g *GeocodingRepository) Reverse(ctx context.Context, lat, lon float64) (models.Place, error) {
+	key := [2]int{int(math.Round(lat * reversePrecision)), int(math.Round(lon * reversePrecision))}
+	lat, lon = float64(key[0])/reversePrecision, float64(key[1])/reversePrecision
+
+	g.mu.RLock()
+	place, ok := g.reverse[key]
+	g.mu.RUnlock()
+	if ok {
+		return place, nil
+	}
+
+	query := url.Values{}
+	query.Set("latitude", strconv.FormatFloat(lat, 'f', -1, 64))
+	query.Set("longitude", strconv.FormatFloat(lon, 'f', -1, 64))
+	query.Set("localityLanguage", "en")
+
+	body, err := g.get(ctx, g.reverseURL+"?"+query.Encode())
+	if err != nil {
+		return models.Place{}, err
+	}
+
+	var response struct {
+		City                 string `json:"city"`
+		Locality             string `json:"locality"`
+		PrincipalSubdivision string `json:"principalSubdivision"`
+		CountryName          string `json:"countryName"`
+		CountryCode          string `json:"countryCode"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return models.Place{}, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	place = models.Place{
+		Name:        response.City,
+		Admin1:      response.PrincipalSubdivision,
+		Country:     response.CountryName,
+		CountryCode: response.CountryCode,
+		Lat:         lat,
+		Lon:         lon,
+	}
+	if place.Name == "" {
+		place.Name = response.Locality
+	}
+	if place.Name == "" {
+		return models.Place{}, ErrPlaceNotFound
+	}
+
+	g.mu.Lock()
+	cachePut(g.reverse, key, place)
+	g.mu.Unlock()
+
+	return place, nil
+}
+
+// cachePut stores a lookup, dropping an arbitrary entry of a full cache keeps it bounded,
+// places rarely change
+func cachePut[K comparable, V any](cache map[K]V, key K, value V) {
+	if len(cache) >= geocodingCacheSize {
+		for k := range cache {
+			delete(cache, k)
+			break
+		}
+	}
+	cache[key] = value
+}
+
+func (g *GeocodingRepository) search(ctx context.Context, name, country string) ([]models.Place, error) {
+	query := url.Values{}
+	query.Set("name", strings.TrimSpace(name))
+	query.Set("count", fmt.Sprint(MaxGeocodingResults))
+	query.Set("language", "en")
+	query.Set("format", "json")
+	if country != "" {
+		query.Set("countryCode", strings.ToUpper(country))
+	}
+
+	logger.FromContext(ctx, g.l).Info("making geocoding API request", map[string]any{
+		"request_id": requestid.FromContext(ctx),
+		"name":       name,
+		"country":    country,
+	})
+
+	body, err := g.get(ctx, g.baseURL+"?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Results []struct {
+			Name        string  `json:"name"`
+			Admin1      string  `json:"admin1"`
+			Country     string  `json:"country"`
+			CountryCode string  `json:"country_code"`
+			Latitude    float64 `json:"latitude"`
+			Longitude   float64 `json:"longitude"`
+			Population  int     `json:"population"`
+			Timezone    string  `json:"timezone"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	places := make([]models.Place, 0, len(response.Results))
+	for _, r := range response.Results {
+		places = append(places, models.Place{
+			Name:        r.Name,
+			Admin1:      r.Admin1,
+			Country:     r.Country,
+			CountryCode: r.CountryCode,
+			Lat:         r.Latitude,
+			Lon:         r.Longitude,
+			Population:  r.Population,
+			Timezone:    r.Timezone,
+		})
+	}
+
+	// Open-Meteo ranks by relevance, which is mostly but not always population
+	sort.SliceStable(places, func(i, j int) bool {
+		return places[i].Population > places[j].Population
+	})
+
+	return places, nil
+}
+
+// get performs a GET request and returns the body of a successful response
+func (g *GeocodingRepository) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if requestID := requestid.FromContext(ctx); requestID != "" {
+		req.Header.Set(requestid.Header, requestID)
+	}
+
+	resp, err := g.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return body, nil
+}
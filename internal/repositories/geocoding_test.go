@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"weather-api/pkg/logger"
+)
+
+func geocodingClient(requests *int, body string) *MockHTTPClient {
+	return &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			*requests++
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+}
+
+func TestGeocodingRepository_Resolve(t *testing.T) {
+	var requests int
+	client := geocodingClient(&requests, `{"results": [
+		{"name": "Paris", "country": "United States", "country_code": "US", "latitude": 33.66, "longitude": -95.56, "population": 24782},
+		{"name": "Paris", "country": "France", "country_code": "FR", "latitude": 48.85, "longitude": 2.35, "population": 2138551}
+	]}`)
+	repo := NewGeocodingRepository(logger.NopLogger{}, client)
+
+	for range 2 {
+		place, err := repo.Resolve(context.Background(), "Paris", "")
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if place.CountryCode != "FR" || place.Lat != 48.85 || place.Lon != 2.35 {
+			t.Errorf("Expected the most populous Paris, got: %+v", place)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected the second resolution from the cache, got %d requests", requests)
+	}
+}
+
+func TestGeocodingRepository_Resolve_Ambiguous(t *testing.T) {
+	var requests int
+	client := geocodingClient(&requests, `{"results": [
+		{"name": "Springfield", "admin1": "Missouri", "country_code": "US", "latitude": 37.22, "longitude": -93.3, "population": 169176},
+		{"name": "Springfield", "admin1": "Massachusetts", "country_code": "US", "latitude": 42.1, "longitude": -72.59, "population": 155929}
+	]}`)
+	repo := NewGeocodingRepository(logger.NopLogger{}, client)
+
+	_, err := repo.Resolve(context.Background(), "Springfield", "US")
+
+	var ambiguous *AmbiguousPlaceError
+	if !errors.As(err, &ambiguous) {
+		t.Fatalf("Expected AmbiguousPlaceError, got: %v", err)
+	}
+	if len(ambiguous.Candidates) != 2 || ambiguous.Candidates[0].Admin1 != "Missouri" {
+		t.Errorf("Unexpected candidates: %+v", ambiguous.Candidates)
+	}
+}
+
+func TestGeocodingRepository_Resolve_NotFound(t *testing.T) {
+	var requests int
+	repo := NewGeocodingRepository(logger.NopLogger{}, geocodingClient(&requests, `{"generationtime_ms": 0.5}`))
+
+	if _, err := repo.Resolve(context.Background(), "Atlantis", ""); !errors.Is(err, ErrPlaceNotFound) {
+		t.Errorf("Expected ErrPlaceNotFound, got: %v", err)
+	}
+}
+
+func TestGeocodingRepository_Search_Query(t *testing.T) {
+	client := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query()
+			if query.Get("name") != "New York" || query.Get("countryCode") != "US" {
+				t.Errorf("Unexpected query: %s", req.URL.RawQuery)
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"results": []}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+	repo := NewGeocodingRepository(logger.NopLogger{}, client)
+
+	if _, err := repo.Search(context.Background(), " New York ", "us"); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+}
+
+func TestGeocodingRepository_Reverse(t *testing.T) {
+	var requests int
+	client := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requests++
+			query := req.URL.Query()
+			if query.Get("latitude") != "52.52" || query.Get("longitude") != "13.41" {
+				t.Errorf("Expected rounded coordinates, got: %s", req.URL.RawQuery)
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"city": "Berlin", "locality": "Mitte", "principalSubdivision": "Berlin",
+					"countryName": "Germany", "countryCode": "DE"
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+	repo := NewGeocodingRepository(logger.NopLogger{}, client)
+
+	for _, coords := range [][2]float64{{52.5200066, 13.4095}, {52.519, 13.411}} {
+		place, err := repo.Reverse(context.Background(), coords[0], coords[1])
+		if err != nil {
+			t.Fatalf("Expected no error, got: %v", err)
+		}
+		if place.Name != "Berlin" || place.Country != "Germany" || place.Admin1 != "Berlin" {
+			t.Errorf("Unexpected place: %+v", place)
+		}
+	}
+
+	if requests != 1 {
+		t.Errorf("Expected nearby coordinates from the cache, got %d requests", requests)
+	}
+}
+
+func TestGeocodingRepository_Reverse_NotFound(t *testing.T) {
+	var requests int
+	client := geocodingClient(&requests, `{"city": "", "locality": "", "countryName": ""}`)
+	repo := NewGeocodingRepository(logger.NopLogger{}, client)
+
+	if _, err := repo.Reverse(context.Background(), 0, -30); !errors.Is(err, ErrPlaceNotFound) {
+		t.Errorf("Expected ErrPlaceNotFound, got: %v", err)
+	}
+}
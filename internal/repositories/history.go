@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"weather-api/internal/models"
+)
+
+// HistoricalProvider is implemented by the providers with a weather archive
+type HistoricalProvider interface {
+	FetchHistory(ctx context.Context, lat, lon float64, start, end models.Date) (models.HistoricalWeather, error)
+}
+
+// FetchHistory fetches the observed weather between start and end, both included, from repo,
+// or returns ErrUnsupported when the provider has no archive
+func FetchHistory(ctx context.Context, repo WeatherRepository, lat, lon float64, start, end models.Date) (models.HistoricalWeather, error) {
+	historical, ok := As[HistoricalProvider](repo)
+	if !ok {
+		return models.HistoricalWeather{}, ErrUnsupported
+	}
+
+	return historical.FetchHistory(ctx, lat, lon, start, end)
+}
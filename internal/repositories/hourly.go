@@ -0,0 +1,23 @@
+package repositories
+
+import (
+	"context"
+
+	"weather-api/internal/models"
+)
+
+// HourlyWeatherRepository is implemented by the providers able to serve hourly forecasts
+type HourlyWeatherRepository interface {
+	FetchHourlyForecast(ctx context.Context, lat, lon float64, hours int) (models.HourlyForecast, error)
+}
+
+// FetchHourlyForecast fetches the hourly forecast from repo, or returns ErrUnsupported
+// when the provider has no hourly support
+func FetchHourlyForecast(ctx context.Context, repo WeatherRepository, lat, lon float64, hours int) (models.HourlyForecast, error) {
+	hourly, ok := As[HourlyWeatherRepository](repo)
+	if !ok {
+		return models.HourlyForecast{}, ErrUnsupported
+	}
+
+	return hourly.FetchHourlyForecast(ctx, lat, lon, hours)
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/netip"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+// IPAPIBaseURL is the free ip-api.com endpoint, it is only served over plain HTTP
+const IPAPIBaseURL = "http://ip-api.com/json/"
+
+// ErrUnlocatableIP is returned for private, loopback and other addresses the locator can't place
+var ErrUnlocatableIP = errors.New("IP address can't be located")
+
+// IPLocator finds the approximate location of an IP address
+type IPLocator interface {
+	Locate(ctx context.Context, ip netip.Addr) (models.Place, error)
+}
+
+// IPAPILocator locates IP addresses with ip-api.com
+type IPAPILocator struct {
+	baseURL    string
+	httpClient HTTPClient
+	l          logger.Logger
+}
+
+var _ IPLocator = (*IPAPILocator)(nil)
+
+func NewIPAPILocator(l logger.Logger, httpClient HTTPClient) *IPAPILocator {
+	return &IPAPILocator{
+		baseURL:    IPAPIBaseURL,
+		httpClient: httpClient,
+		l:          l,
+	}
+}
+
+func (i *IPAPILocator) Locate(ctx context.Context, ip netip.Addr) (models.Place, error) {
+	if !ip.IsValid() || !ip.IsGlobalUnicast() || ip.IsPrivate() {
+		return models.Place{}, ErrUnlocatableIP
+	}
+
+	requestID := requestid.FromContext(ctx)
+
+	logger.FromContext(ctx, i.l).Info("making ip-api request", map[string]any{
+		"request_id": requestID,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", i.baseURL+ip.String()+"?fields=status,message,country,countryCode,regionName,city,lat,lon,timezone", nil)
+	if err != nil {
+		return models.Place{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	if requestID != "" {
+		req.Header.Set(requestid.Header, requestID)
+	}
+
+	resp, err := i.httpClient.Do(req)
+	if err != nil {
+		return models.Place{}, fmt.Errorf("failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return models.Place{}, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return models.Place{}, &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	var response struct {
+		Status      string  `json:"status"`
+		Message     string  `json:"message"`
+		City        string  `json:"city"`
+		RegionName  string  `json:"regionName"`
+		Country     string  `json:"country"`
+		CountryCode string  `json:"countryCode"`
+		Lat         float64 `json:"lat"`
+		Lon         float64 `json:"lon"`
+		Timezone    string  `json:"timezone"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return models.Place{}, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	// A failed lookup still answers 200, with the reason in the message, e.g. "reserved range"
+	if response.Status != "success" {
+		return models.Place{}, fmt.Errorf("%w: %s", ErrUnlocatableIP, response.Message)
+	}
+
+	return models.Place{
+		Name:        response.City,
+		Admin1:      response.RegionName,
+		Country:     response.Country,
+		CountryCode: response.CountryCode,
+		Lat:         response.Lat,
+		Lon:         response.Lon,
+		Timezone:    response.Timezone,
+	}, nil
+}
@@ -0,0 +1,68 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"weather-api/pkg/logger"
+)
+
+func TestIPAPILocator_Locate(t *testing.T) {
+	var requests int
+	var path string
+	client := geocodingClient(&requests, `{"status": "success", "country": "Germany", "countryCode": "DE",
+		"regionName": "Berlin", "city": "Berlin", "lat": 52.52, "lon": 13.405, "timezone": "Europe/Berlin"}`)
+	do := client.DoFunc
+	client.DoFunc = func(req *http.Request) (*http.Response, error) {
+		path = req.URL.Path
+		return do(req)
+	}
+	locator := NewIPAPILocator(logger.NopLogger{}, client)
+
+	place, err := locator.Locate(context.Background(), netip.MustParseAddr("203.0.113.7"))
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if place.Name != "Berlin" || place.CountryCode != "DE" || place.Lat != 52.52 || place.Lon != 13.405 {
+		t.Errorf("Unexpected place: %+v", place)
+	}
+	if path != "/json/203.0.113.7" {
+		t.Errorf("Expected the address in the path, got: %s", path)
+	}
+}
+
+func TestIPAPILocator_Locate_Fail(t *testing.T) {
+	var requests int
+	locator := NewIPAPILocator(logger.NopLogger{},
+		geocodingClient(&requests, `{"status": "fail", "message": "reserved range"}`))
+
+	if _, err := locator.Locate(context.Background(), netip.MustParseAddr("203.0.113.7")); !errors.Is(err, ErrUnlocatableIP) {
+		t.Errorf("Expected ErrUnlocatableIP, got: %v", err)
+	}
+}
+
+func TestIPAPILocator_Locate_PrivateAddress(t *testing.T) {
+	var requests int
+	locator := NewIPAPILocator(logger.NopLogger{}, geocodingClient(&requests, `{}`))
+
+	for _, ip := range []netip.Addr{
+		{},
+		netip.MustParseAddr("127.0.0.1"),
+		netip.MustParseAddr("10.1.2.3"),
+		netip.MustParseAddr("192.168.0.10"),
+		netip.MustParseAddr("0.0.0.0"),
+		netip.MustParseAddr("::1"),
+		netip.MustParseAddr("fd00::1"),
+	} {
+		if _, err := locator.Locate(context.Background(), ip); !errors.Is(err, ErrUnlocatableIP) {
+			t.Errorf("Expected ErrUnlocatableIP for %s, got: %v", ip, err)
+		}
+	}
+
+	if requests != 0 {
+		t.Errorf("Expected no request for unlocatable addresses, got %d", requests)
+	}
+}
@@ -0,0 +1,127 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+)
+
+const OpenMeteoMarineURL = "https://marine-api.open-meteo.com/v1/marine"
+
+const (
+	openMeteoMarineDailyParams = "wave_height_max,wave_period_max,wave_direction_dominant"
+	// openMeteoMarineHourlyParams has no daily aggregate, it is averaged by day
+	openMeteoMarineHourlyParams = "sea_surface_temperature"
+)
+
+// ErrInlandPoint is returned for points without marine data, the Marine API answers them with nulls
+var ErrInlandPoint = errors.New("no marine data at this point, it is likely inland")
+
+// MarineProvider supplies daily marine forecasts, *MarineRepository implements it
+type MarineProvider interface {
+	Name() string
+	FetchMarineForecast(ctx context.Context, lat, lon float64, days int) (models.MarineForecast, error)
+}
+
+// MarineRepository fetches wave and sea surface forecasts from the Open-Meteo Marine API
+type MarineRepository struct {
+	baseURL string
+	openMeteoAPI
+}
+
+var _ MarineProvider = (*MarineRepository)(nil)
+
+func NewMarineRepository(l logger.Logger, httpClient HTTPClient) *MarineRepository {
+	return &MarineRepository{
+		baseURL:      OpenMeteoMarineURL,
+		openMeteoAPI: newOpenMeteoAPI("openmeteo marine", l, httpClient),
+	}
+}
+
+func (m *MarineRepository) Name() string {
+	return "open-meteo-marine"
+}
+
+type OpenMeteoMarineResponse struct {
+	Timezone string `json:"timezone"`
+	Daily    *struct {
+		Time                  []string   `json:"time"`
+		WaveHeightMax         []*float64 `json:"wave_height_max"`
+		WavePeriodMax         []*float64 `json:"wave_period_max"`
+		WaveDirectionDominant []*float64 `json:"wave_direction_dominant"`
+	} `json:"daily"`
+	Hourly *struct {
+		Time                  []string   `json:"time"`
+		SeaSurfaceTemperature []*float64 `json:"sea_surface_temperature"`
+	} `json:"hourly"`
+}
+
+func (m *MarineRepository) FetchMarineForecast(ctx context.Context, lat, lon float64, days int) (models.MarineForecast, error) {
+	forecast := models.MarineForecast{
+		RepositoryName: m.Name(),
+		Lat:            lat,
+		Lon:            lon,
+		ForecastWindow: days,
+		MarineData:     []models.MarineData{},
+	}
+
+	url := fmt.Sprintf("%s?latitude=%f&longitude=%f&daily=%s&hourly=%s&forecast_days=%d&timezone=auto",
+		m.baseURL, lat, lon, openMeteoMarineDailyParams, openMeteoMarineHourlyParams, days)
+
+	body, err := m.get(ctx, url, forecast.RequestParams(), &forecast.FetchMetadata)
+	if err != nil {
+		return forecast, err
+	}
+
+	var response OpenMeteoMarineResponse
+	if err = json.Unmarshal(body, &response); err != nil {
+		return forecast, invalidResponse("failed to parse JSON response: %w", err)
+	}
+	if response.Daily == nil || len(response.Daily.Time) == 0 {
+		return forecast, ErrNoData
+	}
+	forecast.Timezone = response.Timezone
+
+	// Sea surface temperatures by local day
+	temperatures := make(map[string][]float64)
+	if response.Hourly != nil {
+		for i, t := range response.Hourly.Time {
+			v := valueAt(response.Hourly.SeaSurfaceTemperature, i)
+			if v == nil || len(t) < len(models.DateLayout) {
+				continue
+			}
+			day := t[:len(models.DateLayout)]
+			temperatures[day] = append(temperatures[day], *v)
+		}
+	}
+
+	hasData := false
+	for i, t := range response.Daily.Time {
+		date, err := models.ParseDate(t)
+		if err != nil {
+			return forecast, invalidResponse("failed to parse date %s: %w", t, err)
+		}
+
+		md := models.MarineData{
+			Date:                  date,
+			WaveHeightMax:         valueAt(response.Daily.WaveHeightMax, i),
+			WavePeriodMax:         valueAt(response.Daily.WavePeriodMax, i),
+			WaveDirection:         valueAt(response.Daily.WaveDirectionDominant, i),
+			SeaSurfaceTemperature: meanValue(temperatures[t]),
+		}
+		if md.WaveHeightMax != nil || md.WavePeriodMax != nil || md.WaveDirection != nil || md.SeaSurfaceTemperature != nil {
+			hasData = true
+		}
+		forecast.MarineData = append(forecast.MarineData, md)
+	}
+
+	if !hasData {
+		return forecast, ErrInlandPoint
+	}
+
+	return forecast, nil
+}
@@ -0,0 +1,95 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"weather-api/pkg/logger"
+)
+
+func marineClient(body string) *MockHTTPClient {
+	return &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(body)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+}
+
+func TestMarineRepository_FetchMarineForecast(t *testing.T) {
+	client := marineClient(`{
+		"timezone": "Europe/Paris",
+		"daily": {
+			"time": ["2025-07-25", "2025-07-26"],
+			"wave_height_max": [1.2, null],
+			"wave_period_max": [6.5, 7.1],
+			"wave_direction_dominant": [220, 240]
+		},
+		"hourly": {
+			"time": ["2025-07-25T00:00", "2025-07-25T12:00", "2025-07-26T00:00"],
+			"sea_surface_temperature": [19.6, 20.1, null]
+		}
+	}`)
+	do := client.DoFunc
+	client.DoFunc = func(req *http.Request) (*http.Response, error) {
+		if !strings.Contains(req.URL.String(), "daily=wave_height_max,wave_period_max,wave_direction_dominant") ||
+			!strings.Contains(req.URL.String(), "forecast_days=2") {
+			t.Errorf("Unexpected URL: %s", req.URL.String())
+		}
+		return do(req)
+	}
+
+	repo := NewMarineRepository(logger.NopLogger{}, client)
+
+	result, err := repo.FetchMarineForecast(context.Background(), 43.29, 5.37, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if result.RepositoryName != "open-meteo-marine" || result.Timezone != "Europe/Paris" || len(result.MarineData) != 2 {
+		t.Fatalf("Unexpected result: %+v", result)
+	}
+
+	first := result.MarineData[0]
+	if *first.WaveHeightMax != 1.2 || *first.WavePeriodMax != 6.5 || *first.WaveDirection != 220 || *first.SeaSurfaceTemperature != 19.9 {
+		t.Errorf("Unexpected first day: %+v", first)
+	}
+
+	second := result.MarineData[1]
+	if second.WaveHeightMax != nil || second.SeaSurfaceTemperature != nil || *second.WavePeriodMax != 7.1 {
+		t.Errorf("Unexpected second day: %+v", second)
+	}
+}
+
+func TestMarineRepository_FetchMarineForecast_Inland(t *testing.T) {
+	client := marineClient(`{
+		"timezone": "Europe/Paris",
+		"daily": {
+			"time": ["2025-07-25"],
+			"wave_height_max": [null],
+			"wave_period_max": [null],
+			"wave_direction_dominant": [null]
+		},
+		"hourly": {"time": ["2025-07-25T00:00"], "sea_surface_temperature": [null]}
+	}`)
+
+	repo := NewMarineRepository(logger.NopLogger{}, client)
+
+	if _, err := repo.FetchMarineForecast(context.Background(), 48.85, 2.35, 1); !errors.Is(err, ErrInlandPoint) {
+		t.Errorf("Expected ErrInlandPoint, got: %v", err)
+	}
+}
+
+func TestMarineRepository_FetchMarineForecast_NoData(t *testing.T) {
+	repo := NewMarineRepository(logger.NopLogger{}, marineClient(`{"timezone": "GMT"}`))
+
+	if _, err := repo.FetchMarineForecast(context.Background(), 43.29, 5.37, 1); !errors.Is(err, ErrNoData) {
+		t.Errorf("Expected ErrNoData, got: %v", err)
+	}
+}
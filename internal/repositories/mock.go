@@ -0,0 +1,175 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand/v2"
+	"time"
+
+	"weather-api/config"
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+)
+
+// MockWeatherRepository synthesizes plausible forecasts without any network access,
+// it is meant for demos and load tests and must not be used in production
+type MockWeatherRepository struct {
+	latency     time.Duration
+	failureRate float64
+	now         func() time.Time
+	l           logger.Logger
+}
+
+func init() {
+	RegisterProvider("mock", func(api config.WeatherAPIConfig, l logger.Logger, _ HTTPClient) (WeatherRepository, error) {
+		return NewMockWeatherRepository(time.Duration(api.LatencyMS)*time.Millisecond, api.FailureRate, l), nil
+	})
+}
+
+func NewMockWeatherRepository(latency time.Duration, failureRate float64, l logger.Logger) *MockWeatherRepository {
+	return &MockWeatherRepository{
+		latency:     latency,
+		failureRate: failureRate,
+		now:         time.Now,
+		l:           l,
+	}
+}
+
+func (m *MockWeatherRepository) Name() string {
+	return "mock"
+}
+
+func (m *MockWeatherRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	forecast := models.Forecast{
+		RepositoryName: m.Name(),
+		Lat:            lat,
+		Lon:            lon,
+		ForecastWindow: forecastWindow,
+	}
+
+	logger.FromContext(ctx, m.l).Debug("synthesizing mock forecast", map[string]any{
+		"params": forecast.RequestParams(),
+	})
+
+	if err := m.simulate(ctx, &forecast.FetchMetadata); err != nil {
+		return forecast, err
+	}
+
+	today := m.now().UTC().Truncate(24 * time.Hour)
+
+	for i := 0; i < forecastWindow; i++ {
+		date := today.AddDate(0, 0, i)
+		forecast.ForecastData = append(forecast.ForecastData, mockDay(lat, lon, date))
+	}
+
+	return forecast, nil
+}
+
+// FetchHourlyForecast synthesizes hourly temperatures following a daily curve between the mock minimum and maximum
+func (m *MockWeatherRepository) FetchHourlyForecast(ctx context.Context, lat, lon float64, hours int) (models.HourlyForecast, error) {
+	forecast := models.HourlyForecast{
+		RepositoryName: m.Name(),
+		Lat:            lat,
+		Lon:            lon,
+		Hours:          hours,
+	}
+
+	if err := m.simulate(ctx, &forecast.FetchMetadata); err != nil {
+		return forecast, err
+	}
+
+	start := m.now().UTC().Truncate(time.Hour)
+
+	for i := 0; i < hours; i++ {
+		t := start.Add(time.Duration(i) * time.Hour)
+		day := mockDay(lat, lon, t.Truncate(24*time.Hour))
+
+		// Coldest at 03:00, warmest at 15:00
+		phase := (1 - math.Cos(2*math.Pi*float64(t.Hour()-3)/24)) / 2
+		temp := day.TempMin + (day.TempMax-day.TempMin)*phase
+
+		forecast.HourlyData = append(forecast.HourlyData, models.HourlyWeatherData{
+			Time: &t,
+			Temp: math.Round(temp*10) / 10,
+		})
+	}
+
+	return forecast, nil
+}
+
+// FetchCurrent synthesizes the current temperature on the same daily curve as the hourly forecast
+func (m *MockWeatherRepository) FetchCurrent(ctx context.Context, lat, lon float64) (models.CurrentWeather, error) {
+	current := models.CurrentWeather{
+		RepositoryName: m.Name(),
+		Lat:            lat,
+		Lon:            lon,
+	}
+
+	if err := m.simulate(ctx, &current.FetchMetadata); err != nil {
+		return current, err
+	}
+
+	now := m.now().UTC().Truncate(15 * time.Minute)
+	day := mockDay(lat, lon, now.Truncate(24*time.Hour))
+	phase := (1 - math.Cos(2*math.Pi*(float64(now.Hour())+float64(now.Minute())/60-3)/24)) / 2
+
+	current.Current = &models.CurrentConditions{
+		ObservedAt:  now,
+		Temperature: math.Round((day.TempMin+(day.TempMax-day.TempMin)*phase)*10) / 10,
+	}
+
+	return current, nil
+}
+
+// simulate applies the configured latency and failure rate, recording them as the fetch metadata
+func (m *MockWeatherRepository) simulate(ctx context.Context, meta *models.FetchMetadata) error {
+	start := m.now()
+	meta.FetchedAt = start
+	defer func() {
+		meta.FetchDurationMS = m.now().Sub(start).Milliseconds()
+	}()
+
+	if m.latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(m.latency):
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if m.failureRate > 0 && rand.Float64() < m.failureRate {
+		return errors.New("mock provider failure")
+	}
+
+	return nil
+}
+
+// mockDay builds the forecast of a single day, deterministic for the given location and date
+func mockDay(lat, lon float64, date time.Time) models.WeatherData {
+	h := fnv.New64a()
+	_, _ = fmt.Fprintf(h, "%.4f:%.4f:%s", lat, lon, date.Format("2006-01-02"))
+	rng := rand.New(rand.NewPCG(h.Sum64(), 0))
+
+	// Colder towards the poles, warmer in the local summer
+	season := math.Cos(2 * math.Pi * float64(date.YearDay()-196) / 365)
+	if lat < 0 {
+		season = -season
+	}
+	mean := 27 - 0.4*math.Abs(lat) + 15*season*math.Abs(lat)/90
+
+	tempMin := mean - 4 - rng.Float64()*4
+	tempMax := mean + 2 + rng.Float64()*6
+
+	return models.WeatherData{
+		Date:    models.NewDate(date),
+		TempMax: math.Round(tempMax*10) / 10,
+		TempMin: math.Round(tempMin*10) / 10,
+	}
+}
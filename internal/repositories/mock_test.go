@@ -0,0 +1,244 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-api/config"
+	"weather-api/pkg/logger"
+)
+
+func newTestMock(latency time.Duration, failureRate float64) *MockWeatherRepository {
+	repo := NewMockWeatherRepository(latency, failureRate, logger.NopLogger{})
+	repo.now = func() time.Time { return time.Date(2025, 7, 25, 15, 30, 0, 0, time.UTC) }
+	return repo
+}
+
+func TestMockWeatherRepository_FetchForecast_Deterministic(t *testing.T) {
+	repo := newTestMock(0, 0)
+
+	first, err := repo.FetchForecast(context.Background(), 45.44, 12.33, 7)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	second, _ := repo.FetchForecast(context.Background(), 45.44, 12.33, 7)
+
+	if len(first.ForecastData) != 7 {
+		t.Fatalf("Expected exactly 7 days, got %d", len(first.ForecastData))
+	}
+
+	expectedDate := time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)
+	for i, day := range first.ForecastData {
+		if !day.Date.Equal(expectedDate.AddDate(0, 0, i)) {
+			t.Errorf("Expected day %d to be %v, got %v", i, expectedDate.AddDate(0, 0, i), day.Date)
+		}
+		if day.TempMax < day.TempMin {
+			t.Errorf("Expected max >= min on day %d, got %f < %f", i, day.TempMax, day.TempMin)
+		}
+		if day.TempMax != second.ForecastData[i].TempMax || day.TempMin != second.ForecastData[i].TempMin {
+			t.Errorf("Expected day %d to be deterministic", i)
+		}
+	}
+
+	other, _ := repo.FetchForecast(context.Background(), -33.87, 151.21, 7)
+	if other.ForecastData[0].TempMax == first.ForecastData[0].TempMax {
+		t.Error("Expected different locations to produce different forecasts")
+	}
+}
+
+func TestMockWeatherRepository_FetchForecast_FailureRate(t *testing.T) {
+	repo := newTestMock(0, 1)
+
+	if _, err := repo.FetchForecast(context.Background(), 45.44, 12.33, 3); err == nil {
+		t.Error("Expected error with failure rate 1, got nil")
+	}
+}
+
+func TestMockWeatherRepository_FetchForecast_LatencyHonorsContext(t *testing.T) {
+	repo := newTestMock(time.Second, 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	if _, err := repo.FetchForecast(ctx, 45.44, 12.33, 3); err == nil {
+		t.Error("Expected error when context expires, got nil")
+	}
+	if time.Since(start) > 500*time.Millisecond {
+		t.Error("Expected latency to be interrupted by the context")
+	}
+}
+
+func TestInitWeatherRepositories_Mock(t *testing.T) {
+	cfg := &config.Config{
+		App:     config.AppConfig{Env: "development"},
+		Weather: config.WeatherConfig{APIs: []config.WeatherAPIConfig{{Name: "mock", Timeout: 5}}},
+	}
+
+	repos, err := InitWeatherRepositories(cfg, logger.NopLogger{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(repos) != 1 || repos[0].Name() != "mock" {
+		t.Errorf("Expected the mock repository to be registered, got %v", repos)
+	}
+}
+
+func TestInitWeatherRepositories_DuplicateName(t *testing.T) {
+	cfg := &config.Config{
+		App: config.AppConfig{Env: "development"},
+		Weather: config.WeatherConfig{APIs: []config.WeatherAPIConfig{
+			{Name: "mock", Timeout: 5},
+			{Name: "open-meteo", Timeout: 5},
+			{Name: "mock", Timeout: 5},
+		}},
+	}
+
+	_, err := InitWeatherRepositories(cfg, logger.NopLogger{})
+	if err == nil {
+		t.Fatal("Expected an error for duplicate provider names")
+	}
+	if !strings.Contains(err.Error(), `duplicate weather provider "mock"`) {
+		t.Errorf("Expected the duplicate provider to be named in the error, got: %v", err)
+	}
+}
+
+func TestInitWeatherRepositories_UnknownName(t *testing.T) {
+	disabled := false
+	for _, api := range []config.WeatherAPIConfig{
+		{Name: "open-meteoo", Timeout: 5},
+		{Name: "weather-api", Timeout: 5, Enabled: &disabled},
+	} {
+		cfg := &config.Config{
+			App:     config.AppConfig{Env: "development"},
+			Weather: config.WeatherConfig{APIs: []config.WeatherAPIConfig{{Name: "mock", Timeout: 5}, api}},
+		}
+
+		_, err := InitWeatherRepositories(cfg, logger.NopLogger{})
+		if err == nil {
+			t.Fatalf("Expected an error for the unknown provider %s", api.Name)
+		}
+		want := fmt.Sprintf(`unknown weather provider %q in weather.apis[1], expected one of: mock, open-meteo, weatherapi`, api.Name)
+		if err.Error() != want {
+			t.Errorf("Expected %q, got: %v", want, err)
+		}
+	}
+}
+
+func TestInitWeatherRepositories_Disabled(t *testing.T) {
+	enabled, disabled := true, false
+	cfg := &config.Config{
+		App: config.AppConfig{Env: "development"},
+		Weather: config.WeatherConfig{APIs: []config.WeatherAPIConfig{
+			{Name: "mock", Timeout: 5, Enabled: &enabled},
+			// The API key of a disabled provider isn't required
+			{Name: "weatherapi", Timeout: 5, Enabled: &disabled},
+			{Name: "open-meteo", Timeout: 5},
+		}},
+	}
+	l := logger.NewTestLogger()
+
+	repos, err := InitWeatherRepositories(cfg, l)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(repos) != 2 || repos[0].Name() != "mock" || repos[1].Name() != "open-meteo" {
+		t.Errorf("Expected the enabled providers only, got %v", repos)
+	}
+
+	summary := l.Filter(logger.LevelInfo)
+	if len(summary) != 1 || summary[0].Message != "weather providers" {
+		t.Fatalf("Expected a summary of the providers, got %v", summary)
+	}
+	if got := summary[0].Fields["disabled"]; !slices.Equal(got.([]string), []string{"weatherapi"}) {
+		t.Errorf("Expected weatherapi to be logged as disabled, got %v", got)
+	}
+	if got := summary[0].Fields["enabled"]; !slices.Equal(got.([]string), []string{"mock", "open-meteo"}) {
+		t.Errorf("Expected mock and open-meteo to be logged as enabled, got %v", got)
+	}
+}
+
+func TestRegisterProvider_Duplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected registering a provider twice to panic")
+		}
+	}()
+
+	RegisterProvider("mock", nil)
+}
+
+func TestInitWeatherRepositories_APIKey(t *testing.T) {
+	tests := []struct {
+		name string
+		api  config.WeatherAPIConfig
+		want string
+	}{
+		{"missing", config.WeatherAPIConfig{Name: "weatherapi", Timeout: 5}, "weatherapi requires an API key, api_key is empty"},
+		{"blank", config.WeatherAPIConfig{Name: "weatherapi", APIKey: "  ", Timeout: 5}, "weatherapi requires an API key, api_key is empty"},
+		{"placeholder", config.WeatherAPIConfig{Name: "weatherapi", APIKey: APIKeyPlaceholder, Timeout: 5},
+			"weatherapi requires an API key, api_key is the placeholder YOUR-API-KEY-HERE"},
+		{"canary placeholder", config.WeatherAPIConfig{Name: "weatherapi", APIKey: "0123456789", Timeout: 5,
+			Canary: &config.CanaryConfig{Percent: 5, APIKey: "your-api-key-here"}},
+			"weatherapi requires an API key, canary.api_key is the placeholder YOUR-API-KEY-HERE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				App:     config.AppConfig{Env: "development"},
+				Weather: config.WeatherConfig{APIs: []config.WeatherAPIConfig{{Name: "mock", Timeout: 5}, tt.api}},
+			}
+
+			_, err := InitWeatherRepositories(cfg, logger.NopLogger{})
+			if err == nil {
+				t.Fatal("Expected the startup to fail without a usable API key")
+			}
+			want := "weather.apis[1]: " + tt.want + ", set it or disable the provider"
+			if err.Error() != want {
+				t.Errorf("Expected %q, got: %v", want, err)
+			}
+
+			// The degraded mode runs without the provider
+			cfg.Weather.AllowDegraded = true
+			l := logger.NewTestLogger()
+			repos, err := InitWeatherRepositories(cfg, l)
+			if err != nil {
+				t.Fatalf("Expected no error in degraded mode, got: %v", err)
+			}
+			if len(repos) != 1 || repos[0].Name() != "mock" {
+				t.Errorf("Expected the mock provider only, got %v", repos)
+			}
+			warnings := l.Filter(logger.LevelWarning)
+			if len(warnings) != 1 || warnings[0].Fields["provider"] != "weatherapi" {
+				t.Fatalf("Expected a warning naming the disabled provider, got %v", warnings)
+			}
+			if got := l.Filter(logger.LevelInfo)[0].Fields["degraded"]; !slices.Equal(got.([]string), []string{"weatherapi"}) {
+				t.Errorf("Expected weatherapi to be logged as degraded, got %v", got)
+			}
+		})
+	}
+}
+
+func TestInitWeatherRepositories_APIKeySet(t *testing.T) {
+	cfg := &config.Config{
+		App: config.AppConfig{Env: "development"},
+		Weather: config.WeatherConfig{APIs: []config.WeatherAPIConfig{
+			{Name: "weatherapi", APIKey: "0123456789abcdef", Timeout: 5},
+			// The providers without a key ignore it
+			{Name: "open-meteo", APIKey: APIKeyPlaceholder, Timeout: 5},
+		}},
+	}
+
+	repos, err := InitWeatherRepositories(cfg, logger.NopLogger{})
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(repos) != 2 {
+		t.Errorf("Expected both providers, got %v", repos)
+	}
+}
@@ -0,0 +1,149 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+const (
+	NWSAlertsURL = "https://api.weather.gov/alerts/active"
+	// NWSDefaultUserAgent identifies the application, the NWS API rejects requests without a User-Agent
+	NWSDefaultUserAgent = "weather-api (github.com/pavelerokhin/weather-api)"
+)
+
+// NWSRepository fetches the active alerts of the US National Weather Service, it serves no forecasts
+// and only covers the United States, other locations have no alerts
+type NWSRepository struct {
+	baseURL    string
+	userAgent  string
+	httpClient HTTPClient
+	l          logger.Logger
+}
+
+var _ AlertSource = (*NWSRepository)(nil)
+
+// NewNWSRepository builds the NWS alert source, an empty userAgent selects NWSDefaultUserAgent
+func NewNWSRepository(userAgent string, l logger.Logger, httpClient HTTPClient) *NWSRepository {
+	if userAgent == "" {
+		userAgent = NWSDefaultUserAgent
+	}
+
+	return &NWSRepository{
+		baseURL:    NWSAlertsURL,
+		userAgent:  userAgent,
+		httpClient: httpClient,
+		l:          l,
+	}
+}
+
+func (n *NWSRepository) Name() string {
+	return "nws"
+}
+
+// NWSAlertsResponse is the GeoJSON feature collection of the active alerts
+type NWSAlertsResponse struct {
+	Features []struct {
+		Properties struct {
+			Event       string `json:"event"`
+			Severity    string `json:"severity"`
+			Effective   string `json:"effective"`
+			Onset       string `json:"onset"`
+			Expires     string `json:"expires"`
+			Ends        string `json:"ends"`
+			Description string `json:"description"`
+		} `json:"properties"`
+	} `json:"features"`
+}
+
+func (n *NWSRepository) FetchAlerts(ctx context.Context, lat, lon float64) ([]models.Alert, error) {
+	requestID := requestid.FromContext(ctx)
+
+	logger.FromContext(ctx, n.l).Info("making nws alerts request", map[string]any{
+		"request_id": requestID,
+		"params":     fmt.Sprintf("lat: %.4f lon: %.4f alerts", lat, lon),
+	})
+
+	// The API accepts at most 4 decimals
+	url := fmt.Sprintf("%s?point=%.4f,%.4f", n.baseURL, lat, lon)
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("User-Agent", n.userAgent)
+	req.Header.Set("Accept", "application/geo+json")
+	if requestID != "" {
+		req.Header.Set(requestid.Header, requestID)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	var response NWSAlertsResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	alerts := make([]models.Alert, 0, len(response.Features))
+	for _, feature := range response.Features {
+		p := feature.Properties
+
+		// onset is missing for alerts effective immediately, ends for alerts without a known end
+		onset, err := parseNWSTime(p.Onset, p.Effective)
+		if err != nil {
+			return nil, err
+		}
+		expires, err := parseNWSTime(p.Ends, p.Expires)
+		if err != nil {
+			return nil, err
+		}
+
+		alerts = append(alerts, models.Alert{
+			Source:      n.Name(),
+			Event:       p.Event,
+			Severity:    models.AlertSeverity(p.Severity),
+			Onset:       onset,
+			Expires:     expires,
+			Description: p.Description,
+		})
+	}
+
+	return alerts, nil
+}
+
+// parseNWSTime parses the first non-empty time, zero when all are empty
+func parseNWSTime(values ...string) (time.Time, error) {
+	for _, v := range values {
+		if v == "" {
+			continue
+		}
+
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return time.Time{}, invalidResponse("failed to parse time %s: %w", v, err)
+		}
+
+		return t, nil
+	}
+
+	return time.Time{}, nil
+}
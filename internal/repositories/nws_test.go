@@ -0,0 +1,102 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+)
+
+func TestNWSRepository_FetchAlerts(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("point") != "40.7128,-74.0060" {
+				t.Errorf("Expected the point with 4 decimals, got: %s", req.URL.String())
+			}
+			if req.Header.Get("User-Agent") != NWSDefaultUserAgent {
+				t.Errorf("Expected the default User-Agent, got: %s", req.Header.Get("User-Agent"))
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"type": "FeatureCollection",
+					"features": [
+						{"properties": {
+							"event": "Heat Advisory",
+							"severity": "Moderate",
+							"effective": "2025-07-25T08:00:00-04:00",
+							"onset": "2025-07-25T12:00:00-04:00",
+							"expires": "2025-07-25T16:00:00-04:00",
+							"ends": "2025-07-25T20:00:00-04:00",
+							"description": "Heat index values up to 105 expected."
+						}},
+						{"properties": {
+							"event": "Special Weather Statement",
+							"severity": "Unknown",
+							"effective": "2025-07-25T09:00:00-04:00",
+							"onset": null,
+							"expires": "2025-07-25T10:00:00-04:00",
+							"ends": null
+						}}
+					]
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewNWSRepository("", logger.NopLogger{}, mockClient)
+
+	alerts, err := repo.FetchAlerts(context.Background(), 40.7128, -74.006)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %d", len(alerts))
+	}
+
+	heat := alerts[0]
+	if heat.Source != "nws" || heat.Event != "Heat Advisory" || heat.Severity != models.AlertSeverityModerate {
+		t.Errorf("Unexpected alert: %+v", heat)
+	}
+	if heat.Onset.Format(time.RFC3339) != "2025-07-25T12:00:00-04:00" || heat.Expires.Format(time.RFC3339) != "2025-07-25T20:00:00-04:00" {
+		t.Errorf("Expected onset and ends, got: %s - %s", heat.Onset, heat.Expires)
+	}
+
+	statement := alerts[1]
+	if statement.Onset.Format(time.RFC3339) != "2025-07-25T09:00:00-04:00" || statement.Expires.Format(time.RFC3339) != "2025-07-25T10:00:00-04:00" {
+		t.Errorf("Expected effective and expires without onset and ends, got: %s - %s", statement.Onset, statement.Expires)
+	}
+	if statement.Severity != models.AlertSeverityUnknown {
+		t.Errorf("Expected unknown severity, got %s", statement.Severity)
+	}
+}
+
+func TestNWSRepository_FetchAlerts_HTTPError(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusBadRequest,
+				Status:     "400 Bad Request",
+				Body:       io.NopCloser(strings.NewReader(`{"title": "Bad Request", "detail": "Invalid point"}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewNWSRepository("my-app (me@example.com)", logger.NopLogger{}, mockClient)
+
+	_, err := repo.FetchAlerts(context.Background(), 52.52, 13.41)
+
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) || statusErr.StatusCode != http.StatusBadRequest {
+		t.Errorf("Expected HTTPStatusError 400, got: %v", err)
+	}
+}
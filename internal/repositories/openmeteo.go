@@ -5,25 +5,55 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"strings"
 	"time"
 
+	"weather-api/config"
 	"weather-api/internal/models"
 	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
 )
 
 const (
-	OpenMeteoBaseURL = "https://api.open-meteo.com/v1/forecast"
+	OpenMeteoBaseURL    = "https://api.open-meteo.com/v1/forecast"
+	OpenMeteoArchiveURL = "https://archive-api.open-meteo.com/v1/archive"
+
+	// OpenMeteoMaxDays is the horizon of the daily forecast
+	OpenMeteoMaxDays = 16
 )
 
 type OpenMeteoRepository struct {
+	baseURL    string
+	archiveURL string
+	// models selects the forecast models, the first one with data is used
+	models []string
+	// pastDays requests recent past days in front of the forecast
+	pastDays   int
 	httpClient HTTPClient
-	l          *logger.Logger
+	now        func() time.Time
+	l          logger.Logger
 }
 
-func NewOpenMeteoRepository(l *logger.Logger, httpClient HTTPClient) *OpenMeteoRepository {
+func init() {
+	RegisterProvider("open-meteo", func(api config.WeatherAPIConfig, l logger.Logger, httpClient HTTPClient) (WeatherRepository, error) {
+		repo := NewOpenMeteoRepository(l, httpClient)
+		if api.BaseURL != "" {
+			repo.baseURL = api.BaseURL
+		}
+		repo.models = api.Models
+		repo.pastDays = api.PastDays
+		return repo, nil
+	})
+}
+
+func NewOpenMeteoRepository(l logger.Logger, httpClient HTTPClient) *OpenMeteoRepository {
 	return &OpenMeteoRepository{
+		baseURL:    OpenMeteoBaseURL,
+		archiveURL: OpenMeteoArchiveURL,
 		httpClient: httpClient,
+		now:        time.Now,
 		l:          l,
 	}
 }
@@ -32,10 +62,52 @@ func (o *OpenMeteoRepository) Name() string {
 	return "open-meteo"
 }
 
+// MaxDays implements HorizonProvider
+func (o *OpenMeteoRepository) MaxDays() int {
+	return OpenMeteoMaxDays
+}
+
 type OpenMeteoResponse struct {
-	Time             []string  `json:"time"`
-	Temperature2mMax []float64 `json:"temperature_2m_max"`
-	Temperature2mMin []float64 `json:"temperature_2m_min"`
+	Time                        []string   `json:"time"`
+	Temperature2mMax            []float64  `json:"temperature_2m_max"`
+	Temperature2mMin            []float64  `json:"temperature_2m_min"`
+	ApparentTemperatureMax      []*float64 `json:"apparent_temperature_max"`
+	ApparentTemperatureMin      []*float64 `json:"apparent_temperature_min"`
+	PrecipitationSum            []*float64 `json:"precipitation_sum"`
+	PrecipitationProbabilityMax []*float64 `json:"precipitation_probability_max"`
+	WindSpeed10mMax             []*float64 `json:"wind_speed_10m_max"`
+	WindGusts10mMax             []*float64 `json:"wind_gusts_10m_max"`
+	RelativeHumidity2mMean      []*float64 `json:"relative_humidity_2m_mean"`
+	WeatherCode                 []*int     `json:"weather_code"`
+	UVIndexMax                  []*float64 `json:"uv_index_max"`
+	// Sunrise and Sunset are in the local time of the location, without an offset
+	Sunrise []*string `json:"sunrise"`
+	Sunset  []*string `json:"sunset"`
+}
+
+// openMeteoDailyParams are the daily variables requested from Open-Meteo
+const openMeteoDailyParams = "temperature_2m_max,temperature_2m_min,apparent_temperature_max,apparent_temperature_min," +
+	"precipitation_sum,precipitation_probability_max,wind_speed_10m_max,wind_gusts_10m_max,relative_humidity_2m_mean,weather_code," +
+	"uv_index_max,sunrise,sunset"
+
+// openMeteoArchiveParams are the daily variables requested from the Open-Meteo archive,
+// it has no precipitation probability nor UV index
+const openMeteoArchiveParams = "temperature_2m_max,temperature_2m_min,apparent_temperature_max,apparent_temperature_min," +
+	"precipitation_sum,wind_speed_10m_max,wind_gusts_10m_max,relative_humidity_2m_mean,weather_code,sunrise,sunset"
+
+type OpenMeteoHourlyResponse struct {
+	Time                     []string   `json:"time"`
+	Temperature2m            []*float64 `json:"temperature_2m"`
+	PrecipitationProbability []*float64 `json:"precipitation_probability"`
+}
+
+// OpenMeteoCurrentWeather is the current_weather block, Time is in the local time of the location without an offset
+type OpenMeteoCurrentWeather struct {
+	Time          string   `json:"time"`
+	Temperature   *float64 `json:"temperature"`
+	WindSpeed     *float64 `json:"windspeed"`
+	WindDirection *float64 `json:"winddirection"`
+	WeatherCode   *int     `json:"weathercode"`
 }
 
 func (o *OpenMeteoRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
@@ -46,67 +118,386 @@ func (o *OpenMeteoRepository) FetchForecast(ctx context.Context, lat, lon float6
 		ForecastWindow: forecastWindow,
 	}
 
-	url := fmt.Sprintf("%s?latitude=%f&longitude=%f&daily=temperature_2m_max,temperature_2m_min&forecast_days=%d&timezone=auto", OpenMeteoBaseURL, lat, lon, forecastWindow)
+	url := o.forecastURL(lat, lon, forecastWindow)
 
-	o.l.Info("making openmeteo API request", map[string]any{
-		"params": forecast.RequestParams(),
+	body, err := o.get(ctx, url, forecast.RequestParams(), &forecast.FetchMetadata)
+	if err != nil {
+		return forecast, err
+	}
+
+	var response struct {
+		Timezone         string            `json:"timezone"`
+		UTCOffsetSeconds int               `json:"utc_offset_seconds"`
+		Daily            OpenMeteoResponse `json:"daily"`
+	}
+
+	if err = json.Unmarshal(body, &response); err != nil {
+		return forecast, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	logger.FromContext(ctx, o.l).Info("parsed API response", map[string]any{
+		"days": len(response.Daily.Time),
+	})
+
+	// With several models Open-Meteo suffixes every variable with the model name
+	if len(o.models) > 1 {
+		if err = o.selectModel(ctx, body, &response.Daily); err != nil {
+			return forecast, err
+		}
+	}
+
+	// Validate that we have forecast data
+	if len(response.Daily.Time) == 0 {
+		return forecast, ErrNoData
+	}
+
+	// Convert API response to weather forecast models
+	forecastData, err := dailyTemperaturesOpenMeteo(response.Daily, openMeteoLocation(response.Timezone, response.UTCOffsetSeconds))
+	if err != nil {
+		return forecast, fmt.Errorf("failed to build forecast: %w", err)
+	}
+
+	// Past days come first and don't count against the forecast window
+	if o.pastDays > 0 && o.pastDays < len(response.Daily.Time) {
+		today, err := models.ParseDate(response.Daily.Time[o.pastDays])
+		if err != nil {
+			return forecast, invalidResponse("failed to parse date %s: %w", response.Daily.Time[o.pastDays], err)
+		}
+		for i := range forecastData {
+			forecastData[i].Past = forecastData[i].Date.Before(today.Time)
+		}
+	}
+
+	forecast.ForecastData = forecastData
+
+	if response.Timezone != "" {
+		forecast.Timezone = &models.Timezone{
+			Name:             response.Timezone,
+			UTCOffsetSeconds: response.UTCOffsetSeconds,
+		}
+	}
+
+	return forecast, nil
+}
+
+// FetchHourlyForecast fetches the hourly temperature and precipitation probability starting at the current hour
+func (o *OpenMeteoRepository) FetchHourlyForecast(ctx context.Context, lat, lon float64, hours int) (models.HourlyForecast, error) {
+	forecast := models.HourlyForecast{
+		RepositoryName: o.Name(),
+		Lat:            lat,
+		Lon:            lon,
+		Hours:          hours,
+	}
+
+	url := fmt.Sprintf("%s?latitude=%f&longitude=%f&hourly=temperature_2m,precipitation_probability&forecast_hours=%d&timezone=auto", o.baseURL, lat, lon, hours)
+	// Only the preferred model is requested, so the variables aren't suffixed
+	if len(o.models) > 0 {
+		url += "&models=" + o.models[0]
+	}
+
+	body, err := o.get(ctx, url, forecast.RequestParams(), &forecast.FetchMetadata)
+	if err != nil {
+		return forecast, err
+	}
+
+	var response struct {
+		Timezone         string                  `json:"timezone"`
+		UTCOffsetSeconds int                     `json:"utc_offset_seconds"`
+		Hourly           OpenMeteoHourlyResponse `json:"hourly"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return forecast, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	if len(response.Hourly.Time) == 0 {
+		return forecast, ErrNoData
+	}
+
+	// Times are reported in the local time of the location without an offset
+	loc := openMeteoLocation(response.Timezone, response.UTCOffsetSeconds)
+	n := min(len(response.Hourly.Time), len(response.Hourly.Temperature2m), hours)
+
+	for i := 0; i < n; i++ {
+		if response.Hourly.Temperature2m[i] == nil {
+			continue
+		}
+
+		t, err := time.ParseInLocation("2006-01-02T15:04", response.Hourly.Time[i], loc)
+		if err != nil {
+			return forecast, invalidResponse("failed to parse time %s: %w", response.Hourly.Time[i], err)
+		}
+
+		hour := models.HourlyWeatherData{
+			Time: &t,
+			Temp: *response.Hourly.Temperature2m[i],
+		}
+		if i < len(response.Hourly.PrecipitationProbability) {
+			hour.PrecipitationProbability = response.Hourly.PrecipitationProbability[i]
+		}
+
+		forecast.HourlyData = append(forecast.HourlyData, hour)
+	}
+
+	return forecast, nil
+}
+
+// FetchCurrent fetches the current conditions, modeled for the current quarter hour
+func (o *OpenMeteoRepository) FetchCurrent(ctx context.Context, lat, lon float64) (models.CurrentWeather, error) {
+	current := models.CurrentWeather{
+		RepositoryName: o.Name(),
+		Lat:            lat,
+		Lon:            lon,
+	}
+
+	url := fmt.Sprintf("%s?latitude=%f&longitude=%f&current_weather=true&timezone=auto&wind_speed_unit=ms", o.baseURL, lat, lon)
+	if len(o.models) > 0 {
+		url += "&models=" + o.models[0]
+	}
+
+	body, err := o.get(ctx, url, current.RequestParams(), &current.FetchMetadata)
+	if err != nil {
+		return current, err
+	}
+
+	var response struct {
+		Timezone         string                   `json:"timezone"`
+		UTCOffsetSeconds int                      `json:"utc_offset_seconds"`
+		CurrentWeather   *OpenMeteoCurrentWeather `json:"current_weather"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return current, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	if response.CurrentWeather == nil || response.CurrentWeather.Temperature == nil {
+		return current, ErrNoData
+	}
+
+	loc := openMeteoLocation(response.Timezone, response.UTCOffsetSeconds)
+	observedAt, err := time.ParseInLocation("2006-01-02T15:04", response.CurrentWeather.Time, loc)
+	if err != nil {
+		return current, invalidResponse("failed to parse time %s: %w", response.CurrentWeather.Time, err)
+	}
+
+	current.Current = &models.CurrentConditions{
+		ObservedAt:    observedAt,
+		Temperature:   *response.CurrentWeather.Temperature,
+		WindSpeed:     response.CurrentWeather.WindSpeed,
+		WindDirection: response.CurrentWeather.WindDirection,
+		ConditionCode: response.CurrentWeather.WeatherCode,
+	}
+	if code := response.CurrentWeather.WeatherCode; code != nil {
+		current.Current.Condition = wmoCondition(*code)
+	}
+
+	return current, nil
+}
+
+// FetchHistory fetches the daily reanalysis between start and end from the archive API,
+// the most recent days are missing until the archive catches up and are left out
+func (o *OpenMeteoRepository) FetchHistory(ctx context.Context, lat, lon float64, start, end models.Date) (models.HistoricalWeather, error) {
+	history := models.HistoricalWeather{
+		RepositoryName: o.Name(),
+		Lat:            lat,
+		Lon:            lon,
+		Start:          start,
+		End:            end,
+	}
+
+	url := fmt.Sprintf("%s?latitude=%f&longitude=%f&start_date=%s&end_date=%s&daily=%s&timezone=auto&wind_speed_unit=ms",
+		o.archiveURL, lat, lon, start, end, openMeteoArchiveParams)
+
+	body, err := o.get(ctx, url, history.RequestParams(), &history.FetchMetadata)
+	if err != nil {
+		return history, err
+	}
+
+	var response struct {
+		Timezone         string `json:"timezone"`
+		UTCOffsetSeconds int    `json:"utc_offset_seconds"`
+		Daily            struct {
+			OpenMeteoResponse
+			// Temperature2mMax and Temperature2mMin are null for the days not in the archive yet
+			Temperature2mMax []*float64 `json:"temperature_2m_max"`
+			Temperature2mMin []*float64 `json:"temperature_2m_min"`
+		} `json:"daily"`
+	}
+	if err = json.Unmarshal(body, &response); err != nil {
+		return history, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	daily := response.Daily.OpenMeteoResponse
+	daily.Temperature2mMax = derefTemperatures(response.Daily.Temperature2mMax)
+	daily.Temperature2mMin = derefTemperatures(response.Daily.Temperature2mMin)
+
+	data, err := dailyTemperaturesOpenMeteo(daily, openMeteoLocation(response.Timezone, response.UTCOffsetSeconds))
+	if err != nil {
+		return history, fmt.Errorf("failed to build history: %w", err)
+	}
+	if len(data) == 0 {
+		return history, ErrNoData
+	}
+
+	history.WeatherData = data
+
+	if response.Timezone != "" {
+		history.Timezone = &models.Timezone{
+			Name:             response.Timezone,
+			UTCOffsetSeconds: response.UTCOffsetSeconds,
+		}
+	}
+
+	return history, nil
+}
+
+// get performs a GET request against the provider and returns the body of a successful response
+func (o *OpenMeteoRepository) get(ctx context.Context, url, params string, meta *models.FetchMetadata) ([]byte, error) {
+	l := logger.FromContext(ctx, o.l)
+	requestID := requestid.FromContext(ctx)
+
+	l.Info("making openmeteo API request", map[string]any{
+		"request_id": requestID,
+		"params":     params,
 	})
 
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return forecast, fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if requestID != "" {
+		req.Header.Set(requestid.Header, requestID)
+	}
+
+	start := o.now()
+	meta.FetchedAt = start
+	meta.SourceURL = sanitizeURL(req.URL)
+
 	resp, err := o.httpClient.Do(req)
 	if err != nil {
-		return forecast, fmt.Errorf("failed to do request: %w", err)
+		return nil, fmt.Errorf("failed to do request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	o.l.Info("received openmeteo API response", map[string]any{
+	l.Info("received openmeteo API response", map[string]any{
+		"request_id": requestID,
 		"status":     resp.StatusCode,
 		"statusText": resp.Status,
 	})
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return forecast, fmt.Errorf("failed to read response body: %w", err)
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
+	meta.FetchDurationMS = o.now().Sub(start).Milliseconds()
 
 	// Check for HTTP error status codes
 	if resp.StatusCode != http.StatusOK {
-		return forecast, fmt.Errorf("HTTP error (status %d): %s", resp.StatusCode, resp.Status)
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
 	}
 
-	var response struct {
-		Daily OpenMeteoResponse `json:"daily"`
-	}
+	return body, nil
+}
 
-	if err = json.Unmarshal(body, &response); err != nil {
-		return forecast, fmt.Errorf("failed to parse JSON response: %w", err)
+// forecastURL builds the request URL, the past days are requested on top of the forecast days
+func (o *OpenMeteoRepository) forecastURL(lat, lon float64, forecastWindow int) string {
+	url := fmt.Sprintf("%s?latitude=%f&longitude=%f&daily=%s&forecast_days=%d&timezone=auto&wind_speed_unit=ms", o.baseURL, lat, lon, openMeteoDailyParams, forecastWindow)
+
+	if len(o.models) > 0 {
+		url += "&models=" + strings.Join(o.models, ",")
+	}
+	if o.pastDays > 0 {
+		url += fmt.Sprintf("&past_days=%d", o.pastDays)
 	}
 
-	o.l.Info("parsed API response", map[string]any{
-		"days": len(response.Daily.Time),
-	})
+	return url
+}
 
-	// Validate that we have forecast data
-	if len(response.Daily.Time) == 0 {
-		return forecast, fmt.Errorf("no forecast data available")
+// selectModel fills the daily temperatures from the first configured model that returned them
+func (o *OpenMeteoRepository) selectModel(ctx context.Context, body []byte, daily *OpenMeteoResponse) error {
+	var raw struct {
+		Daily map[string]json.RawMessage `json:"daily"`
+	}
+	if err := json.Unmarshal(body, &raw); err != nil {
+		return invalidResponse("failed to parse JSON response: %w", err)
 	}
 
-	// Convert API response to weather forecast models
-	forecastData, err := dailyTemperaturesOpenMeteo(response.Daily)
-	if err != nil {
-		return forecast, fmt.Errorf("failed to build forecast: %w", err)
+	for _, model := range o.models {
+		maxData, okMax := raw.Daily["temperature_2m_max_"+model]
+		minData, okMin := raw.Daily["temperature_2m_min_"+model]
+		if !okMax || !okMin {
+			continue
+		}
+
+		var maxTemps, minTemps []*float64
+		if err := json.Unmarshal(maxData, &maxTemps); err != nil {
+			return invalidResponse("failed to parse temperatures of model %s: %w", model, err)
+		}
+		if err := json.Unmarshal(minData, &minTemps); err != nil {
+			return invalidResponse("failed to parse temperatures of model %s: %w", model, err)
+		}
+
+		// A model not covering the location reports only nulls
+		if !hasValues(maxTemps) || !hasValues(minTemps) {
+			continue
+		}
+
+		daily.Temperature2mMax = derefTemperatures(maxTemps)
+		daily.Temperature2mMin = derefTemperatures(minTemps)
+
+		optional := map[string]any{
+			"apparent_temperature_max":      &daily.ApparentTemperatureMax,
+			"apparent_temperature_min":      &daily.ApparentTemperatureMin,
+			"precipitation_sum":             &daily.PrecipitationSum,
+			"precipitation_probability_max": &daily.PrecipitationProbabilityMax,
+			"wind_speed_10m_max":            &daily.WindSpeed10mMax,
+			"wind_gusts_10m_max":            &daily.WindGusts10mMax,
+			"relative_humidity_2m_mean":     &daily.RelativeHumidity2mMean,
+			"weather_code":                  &daily.WeatherCode,
+			"uv_index_max":                  &daily.UVIndexMax,
+			"sunrise":                       &daily.Sunrise,
+			"sunset":                        &daily.Sunset,
+		}
+		for name, dst := range optional {
+			if data, ok := raw.Daily[name+"_"+model]; ok {
+				if err := json.Unmarshal(data, dst); err != nil {
+					return invalidResponse("failed to parse %s of model %s: %w", name, model, err)
+				}
+			}
+		}
+
+		logger.FromContext(ctx, o.l).Debug("selected openmeteo model", map[string]any{
+			"model": model,
+		})
+
+		return nil
 	}
 
-	forecast.ForecastData = forecastData
+	return fmt.Errorf("%w from any of the models: %s", ErrNoData, strings.Join(o.models, ","))
+}
 
-	return forecast, nil
+func hasValues(temps []*float64) bool {
+	for _, t := range temps {
+		if t != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// derefTemperatures replaces missing values with NaN, such days are skipped by the max/min check
+func derefTemperatures(temps []*float64) []float64 {
+	values := make([]float64, len(temps))
+	for i, t := range temps {
+		if t == nil {
+			values[i] = math.NaN()
+			continue
+		}
+		values[i] = *t
+	}
+	return values
 }
 
 // buildForecastFromResponse converts the API response to weather forecast models
-func dailyTemperaturesOpenMeteo(daily OpenMeteoResponse) ([]models.WeatherData, error) {
+func dailyTemperaturesOpenMeteo(daily OpenMeteoResponse, loc *time.Location) ([]models.WeatherData, error) {
 	var forecastDays []models.WeatherData
 
 	// Find the minimum length to avoid index out of bounds
@@ -114,31 +505,94 @@ func dailyTemperaturesOpenMeteo(daily OpenMeteoResponse) ([]models.WeatherData,
 
 	// Build forecast for each day
 	for i := 0; i < minLength; i++ {
-		dayForecast, err := createDayForecast(daily, i)
+		dayForecast, err := createDayForecast(daily, i, loc)
 		if err != nil {
 			return nil, err
 		}
 
+		// Skip days with inconsistent or missing temperature data
+		if dayForecast.TempMax < dayForecast.TempMin || math.IsNaN(dayForecast.TempMax) || math.IsNaN(dayForecast.TempMin) {
+			continue
+		}
+
 		forecastDays = append(forecastDays, *dayForecast)
 	}
+	models.SortWeatherData(forecastDays)
 
 	return forecastDays, nil
 }
 
 // createDayForecast creates a single day forecast, validating temperature data
-func createDayForecast(daily OpenMeteoResponse, index int) (*models.WeatherData, error) {
+func createDayForecast(daily OpenMeteoResponse, index int, loc *time.Location) (*models.WeatherData, error) {
 	maxTemp := daily.Temperature2mMax[index]
 	minTemp := daily.Temperature2mMin[index]
 
 	// Parse the date string
-	date, err := time.Parse("2006-01-02", daily.Time[index])
+	date, err := models.ParseDate(daily.Time[index])
+	if err != nil {
+		return nil, invalidResponse("failed to parse date %s: %w", daily.Time[index], err)
+	}
+
+	day := &models.WeatherData{
+		Date:                     date,
+		TempMax:                  maxTemp,
+		TempMin:                  minTemp,
+		FeelsLikeMax:             valueAt(daily.ApparentTemperatureMax, index),
+		FeelsLikeMin:             valueAt(daily.ApparentTemperatureMin, index),
+		PrecipitationSum:         valueAt(daily.PrecipitationSum, index),
+		PrecipitationProbability: valueAt(daily.PrecipitationProbabilityMax, index),
+		WindSpeedMax:             valueAt(daily.WindSpeed10mMax, index),
+		WindGustsMax:             valueAt(daily.WindGusts10mMax, index),
+		HumidityMean:             valueAt(daily.RelativeHumidity2mMean, index),
+		UVIndexMax:               valueAt(daily.UVIndexMax, index),
+	}
+
+	if day.Sunrise, err = localTimeAt(daily.Sunrise, index, loc); err != nil {
+		return nil, err
+	}
+	if day.Sunset, err = localTimeAt(daily.Sunset, index, loc); err != nil {
+		return nil, err
+	}
+
+	// The daily weather code is already the most severe condition of the day
+	if index < len(daily.WeatherCode) && daily.WeatherCode[index] != nil {
+		day.Condition = wmoCondition(*daily.WeatherCode[index])
+		day.ConditionCode = daily.WeatherCode[index]
+	}
+
+	return day, nil
+}
+
+// openMeteoLocation returns the location of the reported timezone, falling back to the fixed
+// offset when the name isn't known, so local times spanning a DST change keep the right offset
+func openMeteoLocation(name string, offset int) *time.Location {
+	if name != "" {
+		if loc, err := time.LoadLocation(name); err == nil {
+			return loc
+		}
+	}
+
+	return time.FixedZone(name, offset)
+}
+
+// localTimeAt parses an optional local time variable, nil when it's missing
+func localTimeAt(values []*string, index int, loc *time.Location) (*time.Time, error) {
+	if index >= len(values) || values[index] == nil {
+		return nil, nil
+	}
+
+	t, err := time.ParseInLocation("2006-01-02T15:04", *values[index], loc)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse date %s: %w", daily.Time[index], err)
+		return nil, invalidResponse("failed to parse time %s: %w", *values[index], err)
 	}
 
-	return &models.WeatherData{
-		Date:    &date,
-		TempMax: maxTemp,
-		TempMin: minTemp,
-	}, nil
+	return &t, nil
+}
+
+// valueAt returns the value of an optional daily variable, nil when it's missing
+func valueAt(values []*float64, index int) *float64 {
+	if index >= len(values) {
+		return nil
+	}
+	return values[index]
 }
@@ -0,0 +1,70 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+// openMeteoAPI performs the requests of the auxiliary Open-Meteo APIs, e.g. air quality and marine
+type openMeteoAPI struct {
+	// api names the API in the logs
+	api        string
+	httpClient HTTPClient
+	now        func() time.Time
+	l          logger.Logger
+}
+
+func newOpenMeteoAPI(api string, l logger.Logger, httpClient HTTPClient) openMeteoAPI {
+	return openMeteoAPI{
+		api:        api,
+		httpClient: httpClient,
+		now:        time.Now,
+		l:          l,
+	}
+}
+
+func (a *openMeteoAPI) get(ctx context.Context, url, params string, meta *models.FetchMetadata) ([]byte, error) {
+	requestID := requestid.FromContext(ctx)
+
+	logger.FromContext(ctx, a.l).Info(fmt.Sprintf("making %s API request", a.api), map[string]any{
+		"request_id": requestID,
+		"params":     params,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if requestID != "" {
+		req.Header.Set(requestid.Header, requestID)
+	}
+
+	start := a.now()
+	meta.FetchedAt = start
+	meta.SourceURL = sanitizeURL(req.URL)
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	meta.FetchDurationMS = a.now().Sub(start).Milliseconds()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return body, nil
+}
@@ -2,6 +2,8 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -9,7 +11,9 @@ import (
 	"testing"
 	"time"
 
+	"weather-api/internal/models"
 	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
 )
 
 // MockHTTPClient is a mock implementation of HTTPClient for testing
@@ -56,7 +60,7 @@ func TestOpenMeteoRepository_FetchForecast_Success(t *testing.T) {
 		},
 	}
 
-	logger := logger.NewZapLogger("test-app")
+	logger := logger.NopLogger{}
 	repo := NewOpenMeteoRepository(logger, mockClient)
 
 	ctx := context.Background()
@@ -74,7 +78,7 @@ func TestOpenMeteoRepository_FetchForecast_Success(t *testing.T) {
 
 	// Verify the first day
 	expectedDate1, _ := time.Parse("2006-01-02", "2025-01-27")
-	if result.ForecastData[0].Date == nil || !result.ForecastData[0].Date.Equal(expectedDate1) {
+	if result.ForecastData[0].Date.IsZero() || !result.ForecastData[0].Date.Equal(expectedDate1) {
 		t.Errorf("Expected date 2025-01-27, got %v", result.ForecastData[0].Date)
 	}
 	if result.ForecastData[0].TempMax != 25.5 {
@@ -86,7 +90,7 @@ func TestOpenMeteoRepository_FetchForecast_Success(t *testing.T) {
 
 	// Verify the second day
 	expectedDate2, _ := time.Parse("2006-01-02", "2025-01-28")
-	if result.ForecastData[1].Date == nil || !result.ForecastData[1].Date.Equal(expectedDate2) {
+	if result.ForecastData[1].Date.IsZero() || !result.ForecastData[1].Date.Equal(expectedDate2) {
 		t.Errorf("Expected date 2025-01-28, got %v", result.ForecastData[1].Date)
 	}
 	if result.ForecastData[1].TempMax != 26.2 {
@@ -97,6 +101,46 @@ func TestOpenMeteoRepository_FetchForecast_Success(t *testing.T) {
 	}
 }
 
+func TestOpenMeteoRepository_FetchForecast_Unsorted(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			response := `{
+				"daily": {
+					"time": ["2025-01-29", "2025-01-27", "2025-01-28"],
+					"temperature_2m_max": [27.1, 25.5, 26.2],
+					"temperature_2m_min": [17.0, 15.2, 16.1]
+				}
+			}`
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(response)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+	result, err := repo.FetchForecast(context.Background(), 52.52, 13.41, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	want := []struct {
+		date    string
+		tempMax float64
+	}{{"2025-01-27", 25.5}, {"2025-01-28", 26.2}, {"2025-01-29", 27.1}}
+	if len(result.ForecastData) != len(want) {
+		t.Fatalf("Expected %d days, got %d", len(want), len(result.ForecastData))
+	}
+	for i, w := range want {
+		day := result.ForecastData[i]
+		if day.Date.String() != w.date || day.TempMax != w.tempMax {
+			t.Errorf("Expected %s with max temp %v at %d, got %s with %v", w.date, w.tempMax, i, day.Date, day.TempMax)
+		}
+	}
+}
+
 func TestOpenMeteoRepository_FetchForecast_HTTPError(t *testing.T) {
 	// Create mock HTTP client that returns HTTP error
 	mockClient := &MockHTTPClient{
@@ -109,7 +153,7 @@ func TestOpenMeteoRepository_FetchForecast_HTTPError(t *testing.T) {
 		},
 	}
 
-	logger := logger.NewZapLogger("test-app")
+	logger := logger.NopLogger{}
 	repo := NewOpenMeteoRepository(logger, mockClient)
 
 	ctx := context.Background()
@@ -133,7 +177,7 @@ func TestOpenMeteoRepository_FetchForecast_NetworkError(t *testing.T) {
 		},
 	}
 
-	logger := logger.NewZapLogger("test-app")
+	logger := logger.NopLogger{}
 	repo := NewOpenMeteoRepository(logger, mockClient)
 
 	ctx := context.Background()
@@ -161,7 +205,7 @@ func TestOpenMeteoRepository_FetchForecast_InvalidJSON(t *testing.T) {
 		},
 	}
 
-	logger := logger.NewZapLogger("test-app")
+	logger := logger.NopLogger{}
 	repo := NewOpenMeteoRepository(logger, mockClient)
 
 	ctx := context.Background()
@@ -197,7 +241,7 @@ func TestOpenMeteoRepository_FetchForecast_EmptyData(t *testing.T) {
 		},
 	}
 
-	logger := logger.NewZapLogger("test-app")
+	logger := logger.NopLogger{}
 	repo := NewOpenMeteoRepository(logger, mockClient)
 
 	ctx := context.Background()
@@ -233,7 +277,7 @@ func TestOpenMeteoRepository_FetchForecast_InvalidTemperatureData(t *testing.T)
 		},
 	}
 
-	logger := logger.NewZapLogger("test-app")
+	logger := logger.NopLogger{}
 	repo := NewOpenMeteoRepository(logger, mockClient)
 
 	ctx := context.Background()
@@ -271,7 +315,7 @@ func TestOpenMeteoRepository_FetchForecast_ContextCancellation(t *testing.T) {
 		},
 	}
 
-	logger := logger.NewZapLogger("test-app")
+	logger := logger.NopLogger{}
 	repo := NewOpenMeteoRepository(logger, mockClient)
 
 	// Create a context that cancels immediately
@@ -296,12 +340,8 @@ func TestOpenMeteoRepository_Name(t *testing.T) {
 }
 
 func TestOpenMeteoRepository_RealAPI(t *testing.T) {
-	t.Skip("Skipping real API test - uncomment to test against actual Open-Meteo API")
-
-	// This test makes a real HTTP call to the Open-Meteo API
-	logger := logger.NewZapLogger("test-app")
-	httpClient := &DefaultHTTPClient{}
-	repo := NewOpenMeteoRepository(logger, httpClient)
+	// The responses of the real Open-Meteo API are replayed from testdata, see replayHTTPClient
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, replayHTTPClient(t))
 
 	ctx := context.Background()
 	lat := 52.52 // Berlin latitude
@@ -312,8 +352,11 @@ func TestOpenMeteoRepository_RealAPI(t *testing.T) {
 		t.Fatalf("Real API call failed: %v", err)
 	}
 
-	if len(result.ForecastData) == 0 {
-		t.Fatal("Expected weather data, got empty result")
+	if len(result.ForecastData) != 3 {
+		t.Fatalf("Expected 3 days of weather data, got %d", len(result.ForecastData))
+	}
+	if result.Timezone == nil || result.Timezone.Name != "Europe/Berlin" {
+		t.Errorf("Expected timezone Europe/Berlin, got %+v", result.Timezone)
 	}
 
 	// Verify each response has proper weather data
@@ -327,3 +370,523 @@ func TestOpenMeteoRepository_RealAPI(t *testing.T) {
 		}
 	}
 }
+
+func TestOpenMeteoRepository_FetchForecast_PropagatesRequestID(t *testing.T) {
+	var outboundID string
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			outboundID = req.Header.Get(requestid.Header)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"daily": {"time": ["2025-01-27"], "temperature_2m_max": [25.5], "temperature_2m_min": [15.2]}}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+
+	ctx := requestid.NewContext(context.Background(), "req-123")
+	if _, err := repo.FetchForecast(ctx, 52.52, 13.41, 1); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if outboundID != "req-123" {
+		t.Errorf("Expected outbound X-Request-ID req-123, got %q", outboundID)
+	}
+}
+
+func TestOpenMeteoRepository_FetchForecast_Timezone(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"timezone": "Europe/Berlin",
+					"utc_offset_seconds": 3600,
+					"daily": {"time": ["2025-01-27"], "temperature_2m_max": [25.5], "temperature_2m_min": [15.2]}
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+
+	result, err := repo.FetchForecast(context.Background(), 52.52, 13.41, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if result.Timezone == nil || result.Timezone.Name != "Europe/Berlin" || result.Timezone.UTCOffsetSeconds != 3600 {
+		t.Errorf("Expected Europe/Berlin with offset 3600, got %+v", result.Timezone)
+	}
+}
+
+func TestOpenMeteoRepository_FetchForecast_ModelsAndPastDays(t *testing.T) {
+	var requestedURL string
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requestedURL = req.URL.String()
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"daily": {
+						"time": ["2025-01-25", "2025-01-26", "2025-01-27", "2025-01-28"],
+						"temperature_2m_max": [20.1, 21.4, 25.5, 26.2],
+						"temperature_2m_min": [10.3, 11.0, 15.2, 16.1]
+					}
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+	repo.models = []string{"ecmwf_ifs04"}
+	repo.pastDays = 2
+
+	result, err := repo.FetchForecast(context.Background(), 52.52, 13.41, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	for _, param := range []string{"models=ecmwf_ifs04", "past_days=2", "forecast_days=2"} {
+		if !strings.Contains(requestedURL, param) {
+			t.Errorf("Expected %s in URL, got: %s", param, requestedURL)
+		}
+	}
+
+	if len(result.ForecastData) != 4 {
+		t.Fatalf("Expected 2 past and 2 forecast days, got %d", len(result.ForecastData))
+	}
+	for i, day := range result.ForecastData {
+		if day.Past != (i < 2) {
+			t.Errorf("Expected day %d past=%v, got %v", i, i < 2, day.Past)
+		}
+	}
+}
+
+func TestOpenMeteoRepository_FetchForecast_SeveralModels(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if !strings.Contains(req.URL.String(), "models=ecmwf_ifs04,icon_seamless") {
+				t.Errorf("Expected models in URL, got: %s", req.URL.String())
+			}
+
+			// The first model doesn't cover the location
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"daily": {
+						"time": ["2025-01-27", "2025-01-28"],
+						"temperature_2m_max_ecmwf_ifs04": [null, null],
+						"temperature_2m_min_ecmwf_ifs04": [null, null],
+						"temperature_2m_max_icon_seamless": [25.5, null],
+						"temperature_2m_min_icon_seamless": [15.2, 16.1]
+					}
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+	repo.models = []string{"ecmwf_ifs04", "icon_seamless"}
+
+	result, err := repo.FetchForecast(context.Background(), 52.52, 13.41, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(result.ForecastData) != 1 {
+		t.Fatalf("Expected 1 day with complete data, got %d", len(result.ForecastData))
+	}
+	if result.ForecastData[0].TempMax != 25.5 || result.ForecastData[0].TempMin != 15.2 {
+		t.Errorf("Expected icon_seamless temperatures, got %+v", result.ForecastData[0])
+	}
+}
+
+func TestOpenMeteoRepository_FetchHourlyForecast(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			for _, param := range []string{"hourly=temperature_2m,precipitation_probability", "forecast_hours=3"} {
+				if !strings.Contains(req.URL.String(), param) {
+					t.Errorf("Expected %s in URL, got: %s", param, req.URL.String())
+				}
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"timezone": "Europe/Berlin",
+					"utc_offset_seconds": 3600,
+					"hourly": {
+						"time": ["2025-01-27T14:00", "2025-01-27T15:00", "2025-01-27T16:00"],
+						"temperature_2m": [5.1, null, 4.2],
+						"precipitation_probability": [10, 20, null]
+					}
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+
+	result, err := repo.FetchHourlyForecast(context.Background(), 52.52, 13.41, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(result.HourlyData) != 2 {
+		t.Fatalf("Expected 2 hours with temperature, got %d", len(result.HourlyData))
+	}
+
+	first := result.HourlyData[0]
+	if !first.Time.Equal(time.Date(2025, 1, 27, 13, 0, 0, 0, time.UTC)) || first.Temp != 5.1 {
+		t.Errorf("Unexpected first hour: %+v", first)
+	}
+	if first.PrecipitationProbability == nil || *first.PrecipitationProbability != 10 {
+		t.Errorf("Expected precipitation probability 10, got %v", first.PrecipitationProbability)
+	}
+	if result.HourlyData[1].PrecipitationProbability != nil {
+		t.Errorf("Expected missing precipitation probability, got %v", *result.HourlyData[1].PrecipitationProbability)
+	}
+}
+
+func TestOpenMeteoRepository_FetchCurrent(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if !strings.Contains(req.URL.String(), "current_weather=true") {
+				t.Errorf("Expected current_weather=true in URL, got: %s", req.URL.String())
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"timezone": "Europe/Berlin",
+					"utc_offset_seconds": 3600,
+					"current_weather": {
+						"time": "2025-01-27T14:15",
+						"temperature": 5.3,
+						"windspeed": 4.1,
+						"winddirection": 250,
+						"weathercode": 3
+					}
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+
+	result, err := repo.FetchCurrent(context.Background(), 52.52, 13.41)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	current := result.Current
+	if current == nil {
+		t.Fatal("Expected current conditions")
+	}
+	if current.ObservedAt.Format(time.RFC3339) != "2025-01-27T14:15:00+01:00" || current.Temperature != 5.3 {
+		t.Errorf("Unexpected current conditions: %+v", current)
+	}
+	if current.WindSpeed == nil || *current.WindSpeed != 4.1 || current.WindDirection == nil || *current.WindDirection != 250 {
+		t.Errorf("Unexpected wind: %v %v", current.WindSpeed, current.WindDirection)
+	}
+	if current.Condition != models.ConditionCloudy {
+		t.Errorf("Expected condition %s, got %s", models.ConditionCloudy, current.Condition)
+	}
+}
+
+func TestOpenMeteoRepository_FetchCurrent_NoData(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"timezone": "GMT", "utc_offset_seconds": 0}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+
+	if _, err := repo.FetchCurrent(context.Background(), 52.52, 13.41); !errors.Is(err, ErrNoData) {
+		t.Errorf("Expected ErrNoData, got: %v", err)
+	}
+}
+
+func TestOpenMeteoRepository_FetchHistory(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			url := req.URL.String()
+			if !strings.HasPrefix(url, OpenMeteoArchiveURL+"?") || !strings.Contains(url, "start_date=2025-01-25&end_date=2025-01-27") {
+				t.Errorf("Expected the archive date range in URL, got: %s", url)
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"timezone": "Europe/Berlin",
+					"utc_offset_seconds": 3600,
+					"daily": {
+						"time": ["2025-01-25", "2025-01-26", "2025-01-27"],
+						"temperature_2m_max": [4.2, 5.1, null],
+						"temperature_2m_min": [-1.3, 0.4, null],
+						"precipitation_sum": [0, 2.5, null],
+						"weather_code": [3, 61, null]
+					}
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+
+	start, _ := models.ParseDate("2025-01-25")
+	end, _ := models.ParseDate("2025-01-27")
+	result, err := repo.FetchHistory(context.Background(), 52.52, 13.41, start, end)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(result.WeatherData) != 2 {
+		t.Fatalf("Expected the 2 archived days, got %d", len(result.WeatherData))
+	}
+
+	second := result.WeatherData[1]
+	if second.Date.String() != "2025-01-26" || second.TempMax != 5.1 || second.TempMin != 0.4 {
+		t.Errorf("Unexpected second day: %+v", second)
+	}
+	if second.PrecipitationSum == nil || *second.PrecipitationSum != 2.5 || second.Condition != models.ConditionRain {
+		t.Errorf("Unexpected precipitation or condition: %v %s", second.PrecipitationSum, second.Condition)
+	}
+	if result.Timezone == nil || result.Timezone.Name != "Europe/Berlin" {
+		t.Errorf("Expected Europe/Berlin timezone, got %+v", result.Timezone)
+	}
+}
+
+func TestOpenMeteoRepository_FetchForecast_OptionalFields(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			for _, param := range []string{"precipitation_sum", "wind_speed_10m_max", "relative_humidity_2m_mean", "wind_speed_unit=ms"} {
+				if !strings.Contains(req.URL.String(), param) {
+					t.Errorf("Expected %s in URL, got: %s", param, req.URL.String())
+				}
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"daily": {
+						"time": ["2025-01-27", "2025-01-28"],
+						"temperature_2m_max": [25.5, 26.2],
+						"temperature_2m_min": [15.2, 16.1],
+						"apparent_temperature_max": [27.1, 28.0],
+						"apparent_temperature_min": [14.8, 15.9],
+						"precipitation_sum": [2.4, 0],
+						"precipitation_probability_max": [80, null],
+						"wind_speed_10m_max": [5.5, 3.2],
+						"wind_gusts_10m_max": [9.1, 6.0],
+						"weather_code": [61, null]
+					}
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+
+	result, err := repo.FetchForecast(context.Background(), 52.52, 13.41, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	first := result.ForecastData[0]
+	if first.FeelsLikeMax == nil || *first.FeelsLikeMax != 27.1 || first.FeelsLikeMin == nil || *first.FeelsLikeMin != 14.8 {
+		t.Errorf("Expected feels-like 14.8..27.1, got %v..%v", first.FeelsLikeMin, first.FeelsLikeMax)
+	}
+	if first.PrecipitationSum == nil || *first.PrecipitationSum != 2.4 {
+		t.Errorf("Expected precipitation sum 2.4, got %v", first.PrecipitationSum)
+	}
+	if first.PrecipitationProbability == nil || *first.PrecipitationProbability != 80 {
+		t.Errorf("Expected precipitation probability 80, got %v", first.PrecipitationProbability)
+	}
+	if first.WindSpeedMax == nil || *first.WindSpeedMax != 5.5 || first.WindGustsMax == nil || *first.WindGustsMax != 9.1 {
+		t.Errorf("Expected wind 5.5 gusts 9.1, got %v %v", first.WindSpeedMax, first.WindGustsMax)
+	}
+
+	if first.Condition != models.ConditionRain || first.ConditionCode == nil || *first.ConditionCode != 61 {
+		t.Errorf("Expected rain (61), got %s %v", first.Condition, first.ConditionCode)
+	}
+
+	second := result.ForecastData[1]
+	if second.Condition != "" || second.ConditionCode != nil {
+		t.Errorf("Expected missing condition to be omitted, got %s %v", second.Condition, second.ConditionCode)
+	}
+	if second.PrecipitationProbability != nil {
+		t.Errorf("Expected null precipitation probability to be omitted, got %v", *second.PrecipitationProbability)
+	}
+	if second.HumidityMean != nil {
+		t.Errorf("Expected missing humidity to be omitted, got %v", *second.HumidityMean)
+	}
+}
+
+func TestOpenMeteoRepository_FetchForecast_SunAndUV(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			for _, param := range []string{"uv_index_max", "sunrise", "sunset"} {
+				if !strings.Contains(req.URL.String(), param) {
+					t.Errorf("Expected %s in URL, got: %s", param, req.URL.String())
+				}
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"timezone": "Europe/Berlin",
+					"utc_offset_seconds": 3600,
+					"daily": {
+						"time": ["2025-03-29", "2025-03-30"],
+						"temperature_2m_max": [15.5, 16.2],
+						"temperature_2m_min": [5.2, 6.1],
+						"uv_index_max": [3.45, null],
+						"sunrise": ["2025-03-29T05:56", "2025-03-30T06:54"],
+						"sunset": ["2025-03-29T18:38", null]
+					}
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+
+	result, err := repo.FetchForecast(context.Background(), 52.52, 13.41, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	first, second := result.ForecastData[0], result.ForecastData[1]
+	if first.UVIndexMax == nil || *first.UVIndexMax != 3.45 {
+		t.Errorf("Expected UV index 3.45, got %v", first.UVIndexMax)
+	}
+
+	data, err := json.Marshal(first)
+	if err != nil {
+		t.Fatalf("Failed to marshal day: %v", err)
+	}
+	for _, want := range []string{`"sunrise":"2025-03-29T05:56:00+01:00"`, `"sunset":"2025-03-29T18:38:00+01:00"`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Expected %s in %s", want, data)
+		}
+	}
+
+	// The second day is after the switch to summer time
+	data, err = json.Marshal(second)
+	if err != nil {
+		t.Fatalf("Failed to marshal day: %v", err)
+	}
+	if !strings.Contains(string(data), `"sunrise":"2025-03-30T06:54:00+02:00"`) {
+		t.Errorf("Expected sunrise in summer time in %s", data)
+	}
+	if strings.Contains(string(data), "sunset") || strings.Contains(string(data), "uv_index_max") {
+		t.Errorf("Expected missing fields to be omitted in %s", data)
+	}
+}
+
+// stepClock returns a clock starting at start and advancing by step on every call
+func stepClock(start time.Time, step time.Duration) func() time.Time {
+	now := start
+	return func() time.Time {
+		t := now
+		now = now.Add(step)
+		return t
+	}
+}
+
+func TestOpenMeteoRepository_FetchForecast_FetchMetadata(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"daily": {"time": ["2025-01-27"], "temperature_2m_max": [25.5], "temperature_2m_min": [15.2]}}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	start := time.Date(2025, 1, 27, 12, 0, 0, 0, time.UTC)
+	repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+	repo.now = stepClock(start, 182*time.Millisecond)
+
+	result, err := repo.FetchForecast(context.Background(), 52.52, 13.41, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if !result.FetchedAt.Equal(start) {
+		t.Errorf("Expected fetched at %v, got %v", start, result.FetchedAt)
+	}
+	if result.FetchDurationMS != 182 {
+		t.Errorf("Expected fetch duration 182ms, got %d", result.FetchDurationMS)
+	}
+	if !strings.HasPrefix(result.SourceURL, OpenMeteoBaseURL+"?") {
+		t.Errorf("Expected source URL of the provider, got %s", result.SourceURL)
+	}
+	if result.Stale {
+		t.Error("Expected fresh forecast")
+	}
+}
+
+func TestOpenMeteoRepository_FetchForecast_TypedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+		check  func(err error) bool
+	}{
+		{"rate limited", http.StatusTooManyRequests, `{}`, func(err error) bool {
+			var statusErr *HTTPStatusError
+			return errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests
+		}},
+		{"invalid json", http.StatusOK, `{"daily": [`, func(err error) bool {
+			var responseErr *ResponseError
+			return errors.As(err, &responseErr)
+		}},
+		{"invalid date", http.StatusOK, `{"daily": {"time": ["27/01/2025"], "temperature_2m_max": [25.5], "temperature_2m_min": [15.2]}}`, func(err error) bool {
+			var responseErr *ResponseError
+			return errors.As(err, &responseErr)
+		}},
+		{"no data", http.StatusOK, `{"daily": {"time": []}}`, func(err error) bool {
+			return errors.Is(err, ErrNoData)
+		}},
+	}
+
+	for _, tt := range tests {
+		mockClient := &MockHTTPClient{
+			DoFunc: func(req *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: tt.status,
+					Status:     http.StatusText(tt.status),
+					Body:       io.NopCloser(strings.NewReader(tt.body)),
+					Header:     make(http.Header),
+				}, nil
+			},
+		}
+
+		repo := NewOpenMeteoRepository(logger.NopLogger{}, mockClient)
+
+		_, err := repo.FetchForecast(context.Background(), 52.52, 13.41, 1)
+		if err == nil || !tt.check(err) {
+			t.Errorf("%s: unexpected error type: %v", tt.name, err)
+		}
+	}
+}
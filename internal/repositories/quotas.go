@@ -0,0 +1,66 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"weather-api/internal/models"
+)
+
+// QuotaStore keeps the calls counted against the quotas of the providers across restarts, a ForecastStore
+// implementing it persists them
+type QuotaStore interface {
+	// QuotaUsage returns the last usage saved of every provider
+	QuotaUsage(ctx context.Context) ([]models.QuotaUsage, error)
+	// SaveQuotaUsage records the usage of the providers, replacing the one saved before
+	SaveQuotaUsage(ctx context.Context, usage []models.QuotaUsage) error
+}
+
+// QuotaUsage returns the usage of every provider, ordered by provider name
+func (s *SQLiteForecastStore) QuotaUsage(ctx context.Context) ([]models.QuotaUsage, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT provider, period_start, used FROM quota_usage ORDER BY provider`)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the quota usage: %w", err)
+	}
+	defer rows.Close()
+
+	var usage []models.QuotaUsage
+	for rows.Next() {
+		var u models.QuotaUsage
+		var periodStart int64
+		if err := rows.Scan(&u.Provider, &periodStart, &u.Used); err != nil {
+			return nil, fmt.Errorf("cannot read the quota usage: %w", err)
+		}
+		u.PeriodStart = time.UnixMilli(periodStart).UTC()
+		usage = append(usage, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read the quota usage: %w", err)
+	}
+
+	return usage, nil
+}
+
+// SaveQuotaUsage upserts the usage of the providers in a single transaction
+func (s *SQLiteForecastStore) SaveQuotaUsage(ctx context.Context, usage []models.QuotaUsage) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("cannot save the quota usage: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for _, u := range usage {
+		_, err := tx.ExecContext(ctx, `INSERT INTO quota_usage (provider, period_start, used) VALUES (?, ?, ?)
+			ON CONFLICT (provider) DO UPDATE SET period_start = excluded.period_start, used = excluded.used`,
+			u.Provider, u.PeriodStart.UnixMilli(), u.Used)
+		if err != nil {
+			return fmt.Errorf("cannot save the quota usage of %s: %w", u.Provider, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("cannot save the quota usage: %w", err)
+	}
+
+	return nil
+}
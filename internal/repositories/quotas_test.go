@@ -0,0 +1,51 @@
+package repositories
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"weather-api/internal/models"
+)
+
+func TestSQLiteForecastStore_QuotaUsage(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forecasts.db")
+	store, err := OpenSQLiteForecastStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	day := time.Date(2025, 7, 24, 0, 0, 0, 0, time.UTC)
+	usage := []models.QuotaUsage{
+		{Provider: "weatherapi", PeriodStart: day, Used: 12},
+		{Provider: "open-meteo", PeriodStart: day, Used: 3},
+	}
+	if err := store.SaveQuotaUsage(ctx, usage); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The next period replaces the usage of the provider
+	next := models.QuotaUsage{Provider: "weatherapi", PeriodStart: day.AddDate(0, 0, 1), Used: 1}
+	if err := store.SaveQuotaUsage(ctx, []models.QuotaUsage{next}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = store.Close()
+
+	// The usage outlives the process
+	store, err = OpenSQLiteForecastStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer store.Close()
+
+	got, err := store.QuotaUsage(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []models.QuotaUsage{usage[1], next}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %+v, got %+v", want, got)
+	}
+}
@@ -0,0 +1,151 @@
+package repositories
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	HTTPModeLive   = "live"
+	HTTPModeRecord = "record"
+	HTTPModeReplay = "replay"
+)
+
+// secretQueryParams are removed from the request URL before it is stored or matched,
+// so fixtures never contain API keys and can be replayed with any key
+var secretQueryParams = []string{"appid", "api_key", "apikey", "key", "token"}
+
+// RecordedExchange is a provider request/response pair stored as a JSON fixture
+type RecordedExchange struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	StatusCode int                 `json:"status_code"`
+	Header     map[string][]string `json:"header,omitempty"`
+	Body       string              `json:"body"`
+}
+
+// RecordingHTTPClient saves provider traffic to fixtures in record mode
+// and serves responses from those fixtures in replay mode
+type RecordingHTTPClient struct {
+	mode  string
+	dir   string
+	inner HTTPClient
+}
+
+func NewRecordingHTTPClient(mode, dir string, inner HTTPClient) (*RecordingHTTPClient, error) {
+	if mode != HTTPModeRecord && mode != HTTPModeReplay {
+		return nil, fmt.Errorf("unsupported recording mode: %s", mode)
+	}
+	if strings.TrimSpace(dir) == "" {
+		return nil, fmt.Errorf("fixtures directory cannot be empty")
+	}
+
+	if mode == HTTPModeRecord {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create fixtures directory: %w", err)
+		}
+	}
+
+	return &RecordingHTTPClient{
+		mode:  mode,
+		dir:   dir,
+		inner: inner,
+	}, nil
+}
+
+func (c *RecordingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	if err := req.Context().Err(); err != nil {
+		return nil, err
+	}
+
+	if c.mode == HTTPModeReplay {
+		return c.replay(req)
+	}
+
+	return c.record(req)
+}
+
+func (c *RecordingHTTPClient) record(req *http.Request) (*http.Response, error) {
+	resp, err := c.inner.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	exchange := RecordedExchange{
+		Method:     req.Method,
+		URL:        sanitizeURL(req.URL),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(body),
+	}
+
+	data, err := json.MarshalIndent(exchange, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode fixture: %w", err)
+	}
+
+	if err := os.WriteFile(c.fixturePath(req), data, 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	return exchange.response(req), nil
+}
+
+func (c *RecordingHTTPClient) replay(req *http.Request) (*http.Response, error) {
+	data, err := os.ReadFile(c.fixturePath(req))
+	if err != nil {
+		return nil, fmt.Errorf("no recorded fixture for %s %s: %w", req.Method, sanitizeURL(req.URL), err)
+	}
+
+	var exchange RecordedExchange
+	if err := json.Unmarshal(data, &exchange); err != nil {
+		return nil, fmt.Errorf("failed to parse fixture: %w", err)
+	}
+
+	return exchange.response(req), nil
+}
+
+// fixturePath derives a stable file name from the method and the sanitized URL
+func (c *RecordingHTTPClient) fixturePath(req *http.Request) string {
+	sum := sha256.Sum256([]byte(req.Method + " " + sanitizeURL(req.URL)))
+
+	return filepath.Join(c.dir, req.URL.Hostname()+"-"+hex.EncodeToString(sum[:8])+".json")
+}
+
+func (e RecordedExchange) response(req *http.Request) *http.Response {
+	return &http.Response{
+		StatusCode: e.StatusCode,
+		Status:     fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		Header:     http.Header(e.Header),
+		Body:       io.NopCloser(bytes.NewReader([]byte(e.Body))),
+		Request:    req,
+	}
+}
+
+// sanitizeURL drops secret query parameters, the remaining ones are encoded in sorted order
+func sanitizeURL(u *url.URL) string {
+	query := u.Query()
+	for _, param := range secretQueryParams {
+		query.Del(param)
+	}
+
+	clean := *u
+	clean.RawQuery = query.Encode()
+
+	return clean.String()
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"testing"
+
+	"weather-api/pkg/logger"
+)
+
+// fixturesDir holds the recorded traffic of the real provider APIs replayed by the _RealAPI tests
+const fixturesDir = "testdata/fixtures"
+
+// replayHTTPClient serves the provider requests from fixturesDir, RECORD_FIXTURES=1 records them again from the
+// real APIs instead
+func replayHTTPClient(t *testing.T) HTTPClient {
+	t.Helper()
+
+	mode := HTTPModeReplay
+	if os.Getenv("RECORD_FIXTURES") != "" {
+		mode = HTTPModeRecord
+	}
+	client, err := NewRecordingHTTPClient(mode, fixturesDir, &DefaultHTTPClient{})
+	if err != nil {
+		t.Fatalf("Failed to create %s client: %v", mode, err)
+	}
+
+	return client
+}
+
+func TestRecordingHTTPClient_RecordReplayRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	l := logger.NopLogger{}
+
+	liveCalls := 0
+	live := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			liveCalls++
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"daily": {"time": ["2025-01-27", "2025-01-28"], "temperature_2m_max": [25.5, 26.2], "temperature_2m_min": [15.2, 16.1]}}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	recorder, err := NewRecordingHTTPClient(HTTPModeRecord, dir, live)
+	if err != nil {
+		t.Fatalf("Failed to create recorder: %v", err)
+	}
+
+	recorded, err := NewOpenMeteoRepository(l, recorder).FetchForecast(context.Background(), 52.52, 13.41, 2)
+	if err != nil {
+		t.Fatalf("Expected no error while recording, got: %v", err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 fixture file, got %d", len(files))
+	}
+
+	player, err := NewRecordingHTTPClient(HTTPModeReplay, dir, &MockHTTPClient{})
+	if err != nil {
+		t.Fatalf("Failed to create player: %v", err)
+	}
+
+	replayed, err := NewOpenMeteoRepository(l, player).FetchForecast(context.Background(), 52.52, 13.41, 2)
+	if err != nil {
+		t.Fatalf("Expected no error while replaying, got: %v", err)
+	}
+
+	if liveCalls != 1 {
+		t.Errorf("Expected replay not to touch the network, got %d live calls", liveCalls)
+	}
+	if len(replayed.ForecastData) != len(recorded.ForecastData) {
+		t.Fatalf("Expected %d replayed days, got %d", len(recorded.ForecastData), len(replayed.ForecastData))
+	}
+	for i := range recorded.ForecastData {
+		if replayed.ForecastData[i].TempMax != recorded.ForecastData[i].TempMax ||
+			replayed.ForecastData[i].TempMin != recorded.ForecastData[i].TempMin {
+			t.Errorf("Expected replayed day %d to match recorded one", i)
+		}
+	}
+}
+
+func TestRecordingHTTPClient_StripsAPIKey(t *testing.T) {
+	dir := t.TempDir()
+	l := logger.NopLogger{}
+
+	live := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"list": [{"dt": 1753455600, "dt_txt": "2025-07-25 15:00:00", "main": {"temp_min": 21.7, "temp_max": 22.52}}]}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	recorder, _ := NewRecordingHTTPClient(HTTPModeRecord, dir, live)
+	repo, _ := NewWeatherAPIRepository("secret-key", l, recorder)
+	if _, err := repo.FetchForecast(context.Background(), 40.7128, -74.0060, 1); err != nil {
+		t.Fatalf("Expected no error while recording, got: %v", err)
+	}
+
+	files, _ := os.ReadDir(dir)
+	if len(files) != 1 {
+		t.Fatalf("Expected 1 fixture file, got %d", len(files))
+	}
+	data, _ := os.ReadFile(dir + "/" + files[0].Name())
+	if strings.Contains(string(data), "secret-key") {
+		t.Error("Expected fixture not to contain the API key")
+	}
+
+	// A different key must still match the recorded fixture
+	player, _ := NewRecordingHTTPClient(HTTPModeReplay, dir, &MockHTTPClient{})
+	repo, _ = NewWeatherAPIRepository("another-key", l, player)
+	if _, err := repo.FetchForecast(context.Background(), 40.7128, -74.0060, 1); err != nil {
+		t.Errorf("Expected fixture to be replayed with another key, got: %v", err)
+	}
+}
+
+func TestRecordingHTTPClient_ReplayMissingFixture(t *testing.T) {
+	player, err := NewRecordingHTTPClient(HTTPModeReplay, t.TempDir(), &MockHTTPClient{})
+	if err != nil {
+		t.Fatalf("Failed to create player: %v", err)
+	}
+
+	_, err = NewOpenMeteoRepository(logger.NopLogger{}, player).FetchForecast(context.Background(), 52.52, 13.41, 2)
+	if err == nil || !strings.Contains(err.Error(), "no recorded fixture") {
+		t.Errorf("Expected missing fixture error, got: %v", err)
+	}
+}
+
+func TestNewRecordingHTTPClient_InvalidArguments(t *testing.T) {
+	if _, err := NewRecordingHTTPClient(HTTPModeLive, t.TempDir(), nil); err == nil {
+		t.Error("Expected error for live mode, got nil")
+	}
+	if _, err := NewRecordingHTTPClient(HTTPModeReplay, " ", nil); err == nil {
+		t.Error("Expected error for empty directory, got nil")
+	}
+}
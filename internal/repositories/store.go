@@ -0,0 +1,344 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	_ "modernc.org/sqlite" // registers the sqlite driver, pure Go
+
+	"weather-api/config"
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+)
+
+// ForecastStore records the successful provider forecasts, to compare later what was predicted for a day
+type ForecastStore interface {
+	// Save records every day of a provider forecast
+	Save(ctx context.Context, forecast models.Forecast) error
+	// Stored returns the forecasts recorded for the date at the location, the oldest first
+	Stored(ctx context.Context, lat, lon float64, date models.Date) ([]models.StoredForecast, error)
+	Close() error
+}
+
+// storeCoordinateScale rounds the stored coordinates to 4 decimals, about 11 meters, like the forecast cache
+const storeCoordinateScale = 1e4
+
+// storeMigrations are applied in order on open, the applied ones are recorded in schema_migrations.
+// A migration is never edited once released, a change of the schema is a new migration.
+var storeMigrations = []string{
+	`CREATE TABLE forecasts (
+		provider   TEXT    NOT NULL,
+		lat_e4     INTEGER NOT NULL,
+		lon_e4     INTEGER NOT NULL,
+		date       TEXT    NOT NULL,
+		temp_min   REAL    NOT NULL,
+		temp_max   REAL    NOT NULL,
+		fetched_at INTEGER NOT NULL
+	);
+	CREATE INDEX forecasts_location_date ON forecasts (lat_e4, lon_e4, date);`,
+	`CREATE TABLE forecast_errors (
+		provider       TEXT    NOT NULL,
+		lat_e4         INTEGER NOT NULL,
+		lon_e4         INTEGER NOT NULL,
+		date           TEXT    NOT NULL,
+		lead_days      INTEGER NOT NULL,
+		temp_min_error REAL    NOT NULL,
+		temp_max_error REAL    NOT NULL,
+		samples        INTEGER NOT NULL,
+		PRIMARY KEY (provider, lat_e4, lon_e4, date, lead_days)
+	);
+	CREATE INDEX forecasts_date ON forecasts (date);`,
+	`CREATE TABLE subscriptions (
+		id               TEXT    PRIMARY KEY,
+		lat_e4           INTEGER NOT NULL,
+		lon_e4           INTEGER NOT NULL,
+		field            TEXT    NOT NULL,
+		op               TEXT    NOT NULL,
+		value            REAL    NOT NULL,
+		days             INTEGER NOT NULL,
+		webhook_url      TEXT    NOT NULL,
+		secret           TEXT    NOT NULL,
+		check_interval_s INTEGER NOT NULL,
+		enabled          INTEGER NOT NULL,
+		triggered        INTEGER NOT NULL,
+		failures         INTEGER NOT NULL,
+		last_error       TEXT    NOT NULL,
+		created_at       INTEGER NOT NULL,
+		next_check_at    INTEGER NOT NULL,
+		last_notified_at INTEGER
+	);
+	CREATE INDEX subscriptions_next_check_at ON subscriptions (next_check_at);`,
+	`CREATE TABLE quota_usage (
+		provider     TEXT    PRIMARY KEY,
+		period_start INTEGER NOT NULL,
+		used         INTEGER NOT NULL
+	);`,
+	`ALTER TABLE subscriptions ADD COLUMN owner TEXT NOT NULL DEFAULT '';
+	CREATE INDEX subscriptions_owner ON subscriptions (owner, created_at);`,
+	// The canary members of a provider are stored and scored apart, the variant joins the key of the errors
+	`ALTER TABLE forecasts ADD COLUMN variant TEXT NOT NULL DEFAULT '';
+	CREATE TABLE forecast_errors_variant (
+		provider       TEXT    NOT NULL,
+		variant        TEXT    NOT NULL DEFAULT '',
+		lat_e4         INTEGER NOT NULL,
+		lon_e4         INTEGER NOT NULL,
+		date           TEXT    NOT NULL,
+		lead_days      INTEGER NOT NULL,
+		temp_min_error REAL    NOT NULL,
+		temp_max_error REAL    NOT NULL,
+		samples        INTEGER NOT NULL,
+		PRIMARY KEY (provider, variant, lat_e4, lon_e4, date, lead_days)
+	);
+	INSERT INTO forecast_errors_variant
+		(provider, lat_e4, lon_e4, date, lead_days, temp_min_error, temp_max_error, samples)
+		SELECT provider, lat_e4, lon_e4, date, lead_days, temp_min_error, temp_max_error, samples FROM forecast_errors;
+	DROP TABLE forecast_errors;
+	ALTER TABLE forecast_errors_variant RENAME TO forecast_errors;`,
+}
+
+// AccuracyStore keeps the errors of the stored forecasts against the observed weather, a ForecastStore
+// implementing it enables the accuracy scores of the providers
+type AccuracyStore interface {
+	// Unscored returns the stored forecasts of the days between start and end, both included, whose provider and
+	// variant have no error recorded at the location for the day
+	Unscored(ctx context.Context, start, end models.Date) ([]models.StoredForecast, error)
+	// SaveErrors records the errors, replacing the ones of the same provider, variant, location, day and lead time
+	SaveErrors(ctx context.Context, errors []models.ForecastError) error
+	// Accuracy returns the mean errors of the providers at the location over the days since, by variant and lead
+	// time
+	Accuracy(ctx context.Context, lat, lon float64, since models.Date) ([]models.ProviderAccuracy, error)
+}
+
+// SQLiteForecastStore is a ForecastStore in a SQLite database file
+type SQLiteForecastStore struct {
+	db *sql.DB
+}
+
+var (
+	_ ForecastStore     = (*SQLiteForecastStore)(nil)
+	_ AccuracyStore     = (*SQLiteForecastStore)(nil)
+	_ SubscriptionStore = (*SQLiteForecastStore)(nil)
+	_ QuotaStore        = (*SQLiteForecastStore)(nil)
+)
+
+// InitForecastStore opens the forecast store of the configuration, nil when store.path is empty
+func InitForecastStore(cfg *config.Config, l logger.Logger) (ForecastStore, error) {
+	if cfg.Store.Path == "" {
+		return nil, nil
+	}
+
+	store, err := OpenSQLiteForecastStore(cfg.Store.Path)
+	if err != nil {
+		return nil, err
+	}
+	l.Info("forecast store opened", map[string]any{"path": cfg.Store.Path})
+
+	return store, nil
+}
+
+// OpenSQLiteForecastStore opens, or creates, the database at path and migrates its schema
+func OpenSQLiteForecastStore(path string) (*SQLiteForecastStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("cannot open the forecast store %s: %w", path, err)
+	}
+	// SQLite serializes the writes, a single connection avoids the busy errors between them
+	db.SetMaxOpenConns(1)
+
+	if err := migrate(db); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("cannot migrate the forecast store %s: %w", path, err)
+	}
+
+	return &SQLiteForecastStore{db: db}, nil
+}
+
+// migrate applies the migrations missing from schema_migrations, each in its own transaction
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`); err != nil {
+		return err
+	}
+
+	var applied int
+	if err := db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&applied); err != nil {
+		return err
+	}
+	if applied > len(storeMigrations) {
+		return fmt.Errorf("schema version %d is newer than this build, %d", applied, len(storeMigrations))
+	}
+
+	for version := applied + 1; version <= len(storeMigrations); version++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(storeMigrations[version-1]); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Save records the days of the forecast with a date, FetchedAt is the time of the record when not set
+func (s *SQLiteForecastStore) Save(ctx context.Context, forecast models.Forecast) error {
+	fetchedAt := forecast.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO forecasts
+		(provider, variant, lat_e4, lon_e4, date, temp_min, temp_max, fetched_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, day := range forecast.ForecastData {
+		if day.Date.IsZero() {
+			continue
+		}
+		_, err := stmt.ExecContext(ctx, forecast.RepositoryName, forecast.Variant, toE4(forecast.Lat), toE4(forecast.Lon),
+			day.Date.String(), day.TempMin, day.TempMax, fetchedAt.UnixMilli())
+		if err != nil {
+			return fmt.Errorf("cannot store the forecast of %s: %w", forecast.RepositoryName, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Stored returns the forecasts recorded for the date at the location, rounded to 4 decimals, ordered by the time
+// they were fetched
+func (s *SQLiteForecastStore) Stored(ctx context.Context, lat, lon float64, date models.Date) ([]models.StoredForecast, error) {
+	return s.queryForecasts(ctx, `SELECT provider, variant, lat_e4, lon_e4, date, temp_min, temp_max, fetched_at
+		FROM forecasts WHERE lat_e4 = ? AND lon_e4 = ? AND date = ?
+		ORDER BY fetched_at, provider, variant`, toE4(lat), toE4(lon), date.String())
+}
+
+// Unscored returns the stored forecasts of the days between start and end without errors, ordered by location,
+// day and the time they were fetched
+func (s *SQLiteForecastStore) Unscored(ctx context.Context, start, end models.Date) ([]models.StoredForecast, error) {
+	return s.queryForecasts(ctx, `SELECT f.provider, f.variant, f.lat_e4, f.lon_e4, f.date, f.temp_min, f.temp_max, f.fetched_at
+		FROM forecasts f
+		WHERE f.date BETWEEN ? AND ? AND NOT EXISTS (
+			SELECT 1 FROM forecast_errors e
+			WHERE e.provider = f.provider AND e.variant = f.variant AND e.lat_e4 = f.lat_e4 AND e.lon_e4 = f.lon_e4
+				AND e.date = f.date
+		)
+		ORDER BY f.lat_e4, f.lon_e4, f.date, f.fetched_at, f.provider, f.variant`, start.String(), end.String())
+}
+
+// queryForecasts returns the forecasts selected by query, with the columns of the forecasts table
+func (s *SQLiteForecastStore) queryForecasts(ctx context.Context, query string, args ...any) ([]models.StoredForecast, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the stored forecasts: %w", err)
+	}
+	defer rows.Close()
+
+	stored := []models.StoredForecast{}
+	for rows.Next() {
+		var latE4, lonE4, fetchedAt int64
+		var date string
+		var f models.StoredForecast
+		if err := rows.Scan(&f.Provider, &f.Variant, &latE4, &lonE4, &date, &f.TempMin, &f.TempMax, &fetchedAt); err != nil {
+			return nil, fmt.Errorf("cannot read the stored forecasts: %w", err)
+		}
+		if f.Date, err = models.ParseDate(date); err != nil {
+			return nil, fmt.Errorf("cannot read the stored forecasts: %w", err)
+		}
+		f.Lat, f.Lon = fromE4(latE4), fromE4(lonE4)
+		f.FetchedAt = time.UnixMilli(fetchedAt).UTC()
+		stored = append(stored, f)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read the stored forecasts: %w", err)
+	}
+
+	return stored, nil
+}
+
+// SaveErrors records the errors in a transaction
+func (s *SQLiteForecastStore) SaveErrors(ctx context.Context, errors []models.ForecastError) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT OR REPLACE INTO forecast_errors
+		(provider, variant, lat_e4, lon_e4, date, lead_days, temp_min_error, temp_max_error, samples)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, e := range errors {
+		_, err := stmt.ExecContext(ctx, e.Provider, e.Variant, toE4(e.Lat), toE4(e.Lon), e.Date.String(), e.LeadDays,
+			e.TempMinError, e.TempMaxError, e.Samples)
+		if err != nil {
+			return fmt.Errorf("cannot store the forecast errors of %s: %w", e.Provider, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Accuracy returns the mean absolute errors of the providers at the location, rounded to 4 decimals, over the
+// days since, ordered by provider, variant and lead time
+func (s *SQLiteForecastStore) Accuracy(ctx context.Context, lat, lon float64, since models.Date) ([]models.ProviderAccuracy, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT provider, variant, lead_days, COUNT(*), AVG(temp_min_error), AVG(temp_max_error)
+		FROM forecast_errors WHERE lat_e4 = ? AND lon_e4 = ? AND date >= ?
+		GROUP BY provider, variant, lead_days ORDER BY provider, variant, lead_days`, toE4(lat), toE4(lon), since.String())
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the forecast accuracy: %w", err)
+	}
+	defer rows.Close()
+
+	accuracy := []models.ProviderAccuracy{}
+	for rows.Next() {
+		var a models.ProviderAccuracy
+		if err := rows.Scan(&a.Provider, &a.Variant, &a.LeadDays, &a.Days, &a.MAETempMin, &a.MAETempMax); err != nil {
+			return nil, fmt.Errorf("cannot read the forecast accuracy: %w", err)
+		}
+		a.MAE = (a.MAETempMin + a.MAETempMax) / 2
+		accuracy = append(accuracy, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read the forecast accuracy: %w", err)
+	}
+
+	return accuracy, nil
+}
+
+// Close closes the database
+func (s *SQLiteForecastStore) Close() error {
+	return s.db.Close()
+}
+
+func toE4(v float64) int64 {
+	return int64(math.Round(v * storeCoordinateScale))
+}
+
+func fromE4(v int64) float64 {
+	return float64(v) / storeCoordinateScale
+}
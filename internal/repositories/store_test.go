@@ -0,0 +1,317 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"weather-api/internal/models"
+)
+
+func storedDate(t *testing.T, s string) models.Date {
+	t.Helper()
+	date, err := models.ParseDate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return date
+}
+
+func TestSQLiteForecastStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forecasts.db")
+	store, err := OpenSQLiteForecastStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	ctx := context.Background()
+	day1, day2 := storedDate(t, "2025-07-25"), storedDate(t, "2025-07-26")
+	first := time.Date(2025, 7, 23, 12, 0, 0, 0, time.UTC)
+	forecasts := []models.Forecast{
+		{
+			RepositoryName: "open-meteo",
+			Lat:            52.52001,
+			Lon:            13.40495,
+			FetchMetadata:  models.FetchMetadata{FetchedAt: first},
+			ForecastData:   []models.WeatherData{{Date: day1, TempMin: 14, TempMax: 25}, {Date: day2, TempMin: 15, TempMax: 27}},
+		},
+		{
+			RepositoryName: "weatherapi",
+			Lat:            52.52,
+			Lon:            13.405,
+			FetchMetadata:  models.FetchMetadata{FetchedAt: first.Add(time.Hour)},
+			ForecastData:   []models.WeatherData{{Date: day1, TempMin: 13.5, TempMax: 24.2}, {}},
+		},
+		// Another location
+		{
+			RepositoryName: "open-meteo",
+			Lat:            48.8566,
+			Lon:            2.3522,
+			FetchMetadata:  models.FetchMetadata{FetchedAt: first},
+			ForecastData:   []models.WeatherData{{Date: day1, TempMin: 16, TempMax: 28}},
+		},
+	}
+	for _, f := range forecasts {
+		if err := store.Save(ctx, f); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	stored, err := store.Stored(ctx, 52.52, 13.405, day1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []models.StoredForecast{
+		{Provider: "open-meteo", Lat: 52.52, Lon: 13.405, Date: day1, TempMin: 14, TempMax: 25, FetchedAt: first},
+		{Provider: "weatherapi", Lat: 52.52, Lon: 13.405, Date: day1, TempMin: 13.5, TempMax: 24.2, FetchedAt: first.Add(time.Hour)},
+	}
+	if len(stored) != len(want) {
+		t.Fatalf("expected %d stored forecasts, got %d: %+v", len(want), len(stored), stored)
+	}
+	for i := range want {
+		if !stored[i].FetchedAt.Equal(want[i].FetchedAt) || stored[i].Date != want[i].Date {
+			t.Errorf("stored forecast %d: expected %+v, got %+v", i, want[i], stored[i])
+		}
+		stored[i].FetchedAt, stored[i].Date = want[i].FetchedAt, want[i].Date
+		if stored[i] != want[i] {
+			t.Errorf("stored forecast %d: expected %+v, got %+v", i, want[i], stored[i])
+		}
+	}
+
+	// A day nothing was predicted for
+	stored, err = store.Stored(ctx, 52.52, 13.405, storedDate(t, "2025-08-01"))
+	if err != nil || len(stored) != 0 {
+		t.Errorf("expected no stored forecasts, got %+v, %v", stored, err)
+	}
+
+	// The records and the schema survive a restart, the migrations aren't applied twice
+	if err := store.Close(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	store, err = OpenSQLiteForecastStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error reopening the store: %v", err)
+	}
+	defer store.Close()
+
+	stored, err = store.Stored(ctx, 52.52, 13.405, day2)
+	if err != nil || len(stored) != 1 || stored[0].TempMax != 27 {
+		t.Errorf("expected the forecast of day 2 after a restart, got %+v, %v", stored, err)
+	}
+	var version int
+	if err := store.db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil || version != len(storeMigrations) {
+		t.Errorf("expected schema version %d, got %d, %v", len(storeMigrations), version, err)
+	}
+}
+
+func TestOpenSQLiteForecastStore_NewerSchema(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forecasts.db")
+	store, err := OpenSQLiteForecastStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.db.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, len(storeMigrations)+1); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = store.Close()
+
+	// A database migrated by a newer build is not touched
+	if _, err := OpenSQLiteForecastStore(path); err == nil {
+		t.Error("expected an error opening a database of a newer schema")
+	}
+}
+
+func TestSQLiteForecastStore_Accuracy(t *testing.T) {
+	store, err := OpenSQLiteForecastStore(filepath.Join(t.TempDir(), "forecasts.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	day1, day2, day3 := storedDate(t, "2025-07-24"), storedDate(t, "2025-07-25"), storedDate(t, "2025-07-26")
+	fetchedAt := time.Date(2025, 7, 23, 12, 0, 0, 0, time.UTC)
+	for _, f := range []models.Forecast{
+		{
+			RepositoryName: "open-meteo",
+			Lat:            52.52,
+			Lon:            13.405,
+			FetchMetadata:  models.FetchMetadata{FetchedAt: fetchedAt},
+			ForecastData:   []models.WeatherData{{Date: day1}, {Date: day2}, {Date: day3}},
+		},
+		{
+			RepositoryName: "weatherapi",
+			Lat:            52.52,
+			Lon:            13.405,
+			FetchMetadata:  models.FetchMetadata{FetchedAt: fetchedAt},
+			ForecastData:   []models.WeatherData{{Date: day1}, {Date: day2}},
+		},
+	} {
+		if err := store.Save(ctx, f); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	unscored, err := store.Unscored(ctx, day1, day2)
+	if err != nil || len(unscored) != 4 {
+		t.Fatalf("expected 4 unscored forecasts, got %+v, %v", unscored, err)
+	}
+
+	errors := []models.ForecastError{
+		{Provider: "open-meteo", Lat: 52.52, Lon: 13.405, Date: day1, LeadDays: 1, TempMinError: 1, TempMaxError: 2, Samples: 1},
+		{Provider: "open-meteo", Lat: 52.52, Lon: 13.405, Date: day2, LeadDays: 2, TempMinError: 2, TempMaxError: 4, Samples: 1},
+		{Provider: "weatherapi", Lat: 52.52, Lon: 13.405, Date: day1, LeadDays: 1, TempMinError: 0.5, TempMaxError: 1.5, Samples: 2},
+		// Another location
+		{Provider: "open-meteo", Lat: 48.8566, Lon: 2.3522, Date: day1, LeadDays: 1, TempMinError: 9, TempMaxError: 9, Samples: 1},
+	}
+	if err := store.SaveErrors(ctx, errors); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Scored again, the errors are replaced
+	if err := store.SaveErrors(ctx, errors[:1]); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	unscored, err = store.Unscored(ctx, day1, day3)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(unscored) != 2 || unscored[0].Provider != "weatherapi" || unscored[0].Date != day2 || unscored[1].Date != day3 {
+		t.Errorf("expected the forecasts of weatherapi on day 2 and open-meteo on day 3, got %+v", unscored)
+	}
+
+	accuracy, err := store.Accuracy(ctx, 52.52, 13.405, day1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []models.ProviderAccuracy{
+		{Provider: "open-meteo", LeadDays: 1, Days: 1, MAE: 1.5, MAETempMin: 1, MAETempMax: 2},
+		{Provider: "open-meteo", LeadDays: 2, Days: 1, MAE: 3, MAETempMin: 2, MAETempMax: 4},
+		{Provider: "weatherapi", LeadDays: 1, Days: 1, MAE: 1, MAETempMin: 0.5, MAETempMax: 1.5},
+	}
+	if len(accuracy) != len(want) {
+		t.Fatalf("expected %d scores, got %+v", len(want), accuracy)
+	}
+	for i := range want {
+		if accuracy[i] != want[i] {
+			t.Errorf("score %d: expected %+v, got %+v", i, want[i], accuracy[i])
+		}
+	}
+
+	// The days before since are left out
+	accuracy, err = store.Accuracy(ctx, 52.52, 13.405, day2)
+	if err != nil || len(accuracy) != 1 || accuracy[0].LeadDays != 2 {
+		t.Errorf("expected the score of day 2 only, got %+v, %v", accuracy, err)
+	}
+}
+
+func TestSQLiteForecastStore_Variants(t *testing.T) {
+	store, err := OpenSQLiteForecastStore(filepath.Join(t.TempDir(), "forecasts.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	day := storedDate(t, "2025-07-24")
+	fetchedAt := time.Date(2025, 7, 23, 12, 0, 0, 0, time.UTC)
+	for _, variant := range []string{CanaryMemberPrimary, CanaryMemberCanary} {
+		f := models.Forecast{
+			RepositoryName: "weatherapi",
+			Variant:        variant,
+			Lat:            52.52,
+			Lon:            13.405,
+			FetchMetadata:  models.FetchMetadata{FetchedAt: fetchedAt},
+			ForecastData:   []models.WeatherData{{Date: day}},
+		}
+		if err := store.Save(ctx, f); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	stored, err := store.Stored(ctx, 52.52, 13.405, day)
+	if err != nil || len(stored) != 2 || stored[0].Variant != CanaryMemberCanary || stored[1].Variant != CanaryMemberPrimary {
+		t.Fatalf("expected the forecasts of both members, got %+v, %v", stored, err)
+	}
+
+	// The members are scored apart
+	canary := models.ForecastError{Provider: "weatherapi", Variant: CanaryMemberCanary, Lat: 52.52, Lon: 13.405, Date: day,
+		LeadDays: 1, TempMinError: 1, TempMaxError: 3, Samples: 1}
+	if err := store.SaveErrors(ctx, []models.ForecastError{canary}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	unscored, err := store.Unscored(ctx, day, day)
+	if err != nil || len(unscored) != 1 || unscored[0].Variant != CanaryMemberPrimary {
+		t.Fatalf("expected the forecast of the primary member unscored, got %+v, %v", unscored, err)
+	}
+
+	primary := canary
+	primary.Variant, primary.TempMinError, primary.TempMaxError = CanaryMemberPrimary, 0, 1
+	if err := store.SaveErrors(ctx, []models.ForecastError{primary}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	accuracy, err := store.Accuracy(ctx, 52.52, 13.405, day)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []models.ProviderAccuracy{
+		{Provider: "weatherapi", Variant: CanaryMemberCanary, LeadDays: 1, Days: 1, MAE: 2, MAETempMin: 1, MAETempMax: 3},
+		{Provider: "weatherapi", Variant: CanaryMemberPrimary, LeadDays: 1, Days: 1, MAE: 0.5, MAETempMin: 0, MAETempMax: 1},
+	}
+	if len(accuracy) != len(want) || accuracy[0] != want[0] || accuracy[1] != want[1] {
+		t.Errorf("expected %+v, got %+v", want, accuracy)
+	}
+}
+
+func TestOpenSQLiteForecastStore_MigratesErrorsToVariants(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "forecasts.db")
+	store, err := OpenSQLiteForecastStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = store.Close()
+
+	// A database of the schema before the variants, with an error recorded
+	db, err := sql.Open("sqlite", "file:"+path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, stmt := range []string{
+		`DROP TABLE forecasts`,
+		`DROP TABLE forecast_errors`,
+		`DROP TABLE subscriptions`,
+		`DROP TABLE quota_usage`,
+		`DELETE FROM schema_migrations`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	// The variants are added by the sixth migration
+	for version, migration := range storeMigrations[:5] {
+		if _, err := db.Exec(migration); err != nil {
+			t.Fatalf("migration %d: %v", version+1, err)
+		}
+		if _, err := db.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, version+1); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if _, err := db.Exec(`INSERT INTO forecast_errors VALUES ('open-meteo', 525200, 134050, '2025-07-24', 1, 1, 2, 1)`); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	_ = db.Close()
+
+	store, err = OpenSQLiteForecastStore(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer store.Close()
+
+	accuracy, err := store.Accuracy(context.Background(), 52.52, 13.405, storedDate(t, "2025-07-24"))
+	want := models.ProviderAccuracy{Provider: "open-meteo", LeadDays: 1, Days: 1, MAE: 1.5, MAETempMin: 1, MAETempMax: 2}
+	if err != nil || len(accuracy) != 1 || accuracy[0] != want {
+		t.Errorf("expected the error recorded before the migration, got %+v, %v", accuracy, err)
+	}
+}
@@ -0,0 +1,145 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"weather-api/internal/models"
+)
+
+// ErrSubscriptionNotFound is returned for an unknown subscription ID
+var ErrSubscriptionNotFound = errors.New("subscription not found")
+
+// SubscriptionStore keeps the webhook subscriptions, a ForecastStore implementing it enables them
+type SubscriptionStore interface {
+	// CreateSubscription records a new subscription
+	CreateSubscription(ctx context.Context, sub models.Subscription) error
+	// UpdateSubscription records the state of a subscription after a check, an unknown or deleted one is ignored
+	UpdateSubscription(ctx context.Context, sub models.Subscription) error
+	// Subscription returns the subscription of the owner, ErrSubscriptionNotFound when unknown
+	Subscription(ctx context.Context, owner, id string) (models.Subscription, error)
+	// Subscriptions returns every subscription of the owner, the oldest first
+	Subscriptions(ctx context.Context, owner string) ([]models.Subscription, error)
+	// DueSubscriptions returns the enabled subscriptions to check at now, the most overdue first
+	DueSubscriptions(ctx context.Context, now time.Time) ([]models.Subscription, error)
+	// DeleteSubscription removes the subscription of the owner, ErrSubscriptionNotFound when unknown
+	DeleteSubscription(ctx context.Context, owner, id string) error
+}
+
+// subscriptionColumns are the columns scanned by scanSubscription, in order
+const subscriptionColumns = `id, owner, lat_e4, lon_e4, field, op, value, days, webhook_url, secret, check_interval_s,
+	enabled, triggered, failures, last_error, created_at, next_check_at, last_notified_at`
+
+// CreateSubscription records the subscription, its coordinates rounded to 4 decimals
+func (s *SQLiteForecastStore) CreateSubscription(ctx context.Context, sub models.Subscription) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO subscriptions (`+subscriptionColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		sub.ID, sub.Owner, toE4(sub.Lat), toE4(sub.Lon), sub.Condition.Field, sub.Condition.Op, sub.Condition.Value,
+		sub.Condition.Days, sub.WebhookURL, sub.Secret, sub.CheckIntervalSeconds, sub.Enabled, sub.Triggered,
+		sub.Failures, sub.LastError, sub.CreatedAt.UnixMilli(), sub.NextCheckAt.UnixMilli(), unixMilli(sub.LastNotifiedAt))
+	if err != nil {
+		return fmt.Errorf("cannot store the subscription: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateSubscription records the state of the subscription, its definition is never changed
+func (s *SQLiteForecastStore) UpdateSubscription(ctx context.Context, sub models.Subscription) error {
+	_, err := s.db.ExecContext(ctx, `UPDATE subscriptions
+		SET enabled = ?, triggered = ?, failures = ?, last_error = ?, next_check_at = ?, last_notified_at = ?
+		WHERE id = ?`,
+		sub.Enabled, sub.Triggered, sub.Failures, sub.LastError, sub.NextCheckAt.UnixMilli(),
+		unixMilli(sub.LastNotifiedAt), sub.ID)
+	if err != nil {
+		return fmt.Errorf("cannot update the subscription %s: %w", sub.ID, err)
+	}
+
+	return nil
+}
+
+// Subscription returns the subscription of the owner with its secret
+func (s *SQLiteForecastStore) Subscription(ctx context.Context, owner, id string) (models.Subscription, error) {
+	subs, err := s.querySubscriptions(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE id = ? AND owner = ?`, id, owner)
+	if err != nil {
+		return models.Subscription{}, err
+	}
+	if len(subs) == 0 {
+		return models.Subscription{}, ErrSubscriptionNotFound
+	}
+
+	return subs[0], nil
+}
+
+// Subscriptions returns every subscription of the owner with its secret, ordered by creation
+func (s *SQLiteForecastStore) Subscriptions(ctx context.Context, owner string) ([]models.Subscription, error) {
+	return s.querySubscriptions(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE owner = ? ORDER BY created_at, id`, owner)
+}
+
+// DueSubscriptions returns the enabled subscriptions whose next check is at or before now
+func (s *SQLiteForecastStore) DueSubscriptions(ctx context.Context, now time.Time) ([]models.Subscription, error) {
+	return s.querySubscriptions(ctx, `SELECT `+subscriptionColumns+` FROM subscriptions
+		WHERE enabled = 1 AND next_check_at <= ? ORDER BY next_check_at, id`, now.UnixMilli())
+}
+
+// DeleteSubscription removes the subscription of the owner
+func (s *SQLiteForecastStore) DeleteSubscription(ctx context.Context, owner, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM subscriptions WHERE id = ? AND owner = ?`, id, owner)
+	if err != nil {
+		return fmt.Errorf("cannot delete the subscription %s: %w", id, err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrSubscriptionNotFound
+	}
+
+	return nil
+}
+
+// querySubscriptions returns the subscriptions selected by query, with subscriptionColumns
+func (s *SQLiteForecastStore) querySubscriptions(ctx context.Context, query string, args ...any) ([]models.Subscription, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	subs := []models.Subscription{}
+	for rows.Next() {
+		var sub models.Subscription
+		var latE4, lonE4, createdAt, nextCheckAt int64
+		var lastNotifiedAt sql.NullInt64
+		err := rows.Scan(&sub.ID, &sub.Owner, &latE4, &lonE4, &sub.Condition.Field, &sub.Condition.Op, &sub.Condition.Value,
+			&sub.Condition.Days, &sub.WebhookURL, &sub.Secret, &sub.CheckIntervalSeconds, &sub.Enabled, &sub.Triggered,
+			&sub.Failures, &sub.LastError, &createdAt, &nextCheckAt, &lastNotifiedAt)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read the subscriptions: %w", err)
+		}
+		sub.Lat, sub.Lon = fromE4(latE4), fromE4(lonE4)
+		sub.CreatedAt = time.UnixMilli(createdAt).UTC()
+		sub.NextCheckAt = time.UnixMilli(nextCheckAt).UTC()
+		if lastNotifiedAt.Valid {
+			t := time.UnixMilli(lastNotifiedAt.Int64).UTC()
+			sub.LastNotifiedAt = &t
+		}
+		subs = append(subs, sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read the subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+// unixMilli returns t in unix milliseconds, NULL when nil
+func unixMilli(t *time.Time) sql.NullInt64 {
+	if t == nil {
+		return sql.NullInt64{}
+	}
+
+	return sql.NullInt64{Int64: t.UnixMilli(), Valid: true}
+}
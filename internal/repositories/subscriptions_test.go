@@ -0,0 +1,125 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"weather-api/internal/models"
+)
+
+func TestSQLiteForecastStore_Subscriptions(t *testing.T) {
+	store, err := OpenSQLiteForecastStore(filepath.Join(t.TempDir(), "forecasts.db"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC)
+	first := models.Subscription{
+		ID:                   "a1",
+		Owner:                "partner",
+		Lat:                  52.52,
+		Lon:                  13.405,
+		Condition:            models.SubscriptionCondition{Field: "temp_max", Op: "gte", Value: 35, Days: 3},
+		WebhookURL:           "https://example.com/hook",
+		Secret:               "secret",
+		CheckIntervalSeconds: 1800,
+		Enabled:              true,
+		CreatedAt:            now,
+		NextCheckAt:          now,
+	}
+	second := first
+	second.ID, second.CreatedAt, second.NextCheckAt = "b2", now.Add(time.Minute), now.Add(time.Hour)
+	other := first
+	other.ID, other.Owner, other.NextCheckAt = "c3", "other", now.Add(5*time.Hour)
+	for _, sub := range []models.Subscription{first, second, other} {
+		if err := store.CreateSubscription(ctx, sub); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	if err := store.CreateSubscription(ctx, first); err == nil {
+		t.Error("expected an error creating a subscription twice")
+	}
+
+	got, err := store.Subscription(ctx, "partner", "a1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != first {
+		t.Errorf("expected %+v, got %+v", first, got)
+	}
+	// The subscriptions of another owner are unknown
+	if _, err := store.Subscription(ctx, "other", "a1"); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound for another owner, got %v", err)
+	}
+	if err := store.DeleteSubscription(ctx, "other", "a1"); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound deleting for another owner, got %v", err)
+	}
+
+	due, err := store.DueSubscriptions(ctx, now)
+	if err != nil || len(due) != 1 || due[0].ID != "a1" {
+		t.Errorf("expected a1 due, got %+v, %v", due, err)
+	}
+
+	// The state is updated, the definition is kept
+	notified := now.Add(time.Second)
+	first.Triggered, first.Failures, first.LastError = true, 2, "webhook answered 503 Service Unavailable"
+	first.NextCheckAt, first.LastNotifiedAt = now.Add(2*time.Hour), &notified
+	first.WebhookURL = "https://example.com/other"
+	if err := store.UpdateSubscription(ctx, first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err = store.Subscription(ctx, "partner", "a1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !got.Triggered || got.Failures != 2 || got.LastError != first.LastError || !got.NextCheckAt.Equal(first.NextCheckAt) {
+		t.Errorf("expected the updated state, got %+v", got)
+	}
+	if got.LastNotifiedAt == nil || !got.LastNotifiedAt.Equal(notified) {
+		t.Errorf("expected last notified at %v, got %v", notified, got.LastNotifiedAt)
+	}
+	if got.WebhookURL != "https://example.com/hook" {
+		t.Errorf("expected the webhook kept, got %s", got.WebhookURL)
+	}
+
+	// A disabled subscription is never due
+	second.Enabled = false
+	if err := store.UpdateSubscription(ctx, second); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	due, err = store.DueSubscriptions(ctx, now.Add(3*time.Hour))
+	if err != nil || len(due) != 1 || due[0].ID != "a1" {
+		t.Errorf("expected a1 due, got %+v, %v", due, err)
+	}
+
+	all, err := store.Subscriptions(ctx, "partner")
+	if err != nil || len(all) != 2 || all[0].ID != "a1" || all[1].ID != "b2" {
+		t.Errorf("expected a1 and b2, got %+v, %v", all, err)
+	}
+	all, err = store.Subscriptions(ctx, "other")
+	if err != nil || len(all) != 1 || all[0].ID != "c3" {
+		t.Errorf("expected c3, got %+v, %v", all, err)
+	}
+
+	if err := store.DeleteSubscription(ctx, "partner", "a1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := store.DeleteSubscription(ctx, "partner", "a1"); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound, got %v", err)
+	}
+	if _, err := store.Subscription(ctx, "partner", "a1"); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound, got %v", err)
+	}
+	// The update of a deleted subscription doesn't bring it back
+	if err := store.UpdateSubscription(ctx, first); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := store.Subscription(ctx, "partner", "a1"); !errors.Is(err, ErrSubscriptionNotFound) {
+		t.Errorf("expected ErrSubscriptionNotFound after an update, got %v", err)
+	}
+}
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+	"time"
+)
+
+type timezoneKeyCtx struct{}
+
+// WithTimezone stores the timezone resolved for the location of the request, the repositories take the local
+// days and times of their forecasts in it rather than in the offset they report
+func WithTimezone(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, timezoneKeyCtx{}, loc)
+}
+
+// TimezoneFromContext returns the timezone of WithTimezone, nil when unset
+func TimezoneFromContext(ctx context.Context) *time.Location {
+	loc, _ := ctx.Value(timezoneKeyCtx{}).(*time.Location)
+	return loc
+}
+
+// responseLocation returns the timezone of WithTimezone, or the fixed offset in seconds reported by the provider,
+// UTC without either
+func responseLocation(ctx context.Context, offset *int) *time.Location {
+	if loc := TimezoneFromContext(ctx); loc != nil {
+		return loc
+	}
+	if offset != nil {
+		return time.FixedZone("", *offset)
+	}
+
+	return time.UTC
+}
@@ -0,0 +1,141 @@
+package repositories
+
+import (
+	"context"
+	"io"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+
+	"weather-api/internal/models"
+)
+
+const tracerName = "weather-api/internal/repositories"
+
+// Wrapper is implemented by the repositories decorating another one, see As
+type Wrapper interface {
+	Unwrap() WeatherRepository
+}
+
+// As returns repo as T, looking through the decorators of repo, like errors.As does for the wrapped errors
+func As[T any](repo WeatherRepository) (T, bool) {
+	for repo != nil {
+		if provider, ok := repo.(T); ok {
+			return provider, true
+		}
+		wrapper, ok := repo.(Wrapper)
+		if !ok {
+			break
+		}
+		repo = wrapper.Unwrap()
+	}
+
+	var zero T
+	return zero, false
+}
+
+// TracedRepository records a span for every forecast fetched by a provider, the optional features of the
+// provider are reached through As
+type TracedRepository struct {
+	repo   WeatherRepository
+	tracer trace.Tracer
+}
+
+// NewTracedRepository traces repo with the global tracer provider
+func NewTracedRepository(repo WeatherRepository) *TracedRepository {
+	return &TracedRepository{repo: repo, tracer: otel.Tracer(tracerName)}
+}
+
+func (t *TracedRepository) Name() string {
+	return t.repo.Name()
+}
+
+// Unwrap returns the traced repository
+func (t *TracedRepository) Unwrap() WeatherRepository {
+	return t.repo
+}
+
+func (t *TracedRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	ctx, span := t.tracer.Start(ctx, "provider "+t.repo.Name(), trace.WithAttributes(
+		attribute.String("provider.name", t.repo.Name()),
+		attribute.Float64("location.lat", lat),
+		attribute.Float64("location.lon", lon),
+		attribute.Int("forecast.window", forecastWindow),
+	))
+	defer span.End()
+
+	forecast, err := t.repo.FetchForecast(ctx, lat, lon, forecastWindow)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	return forecast, err
+}
+
+// tracingHTTPClient records a client span for every provider request and propagates the trace context in its
+// headers
+type tracingHTTPClient struct {
+	next   HTTPClient
+	tracer trace.Tracer
+}
+
+// NewTracingHTTPClient traces the requests of next with the global tracer provider and propagator
+func NewTracingHTTPClient(next HTTPClient) HTTPClient {
+	return &tracingHTTPClient{next: next, tracer: otel.Tracer(tracerName)}
+}
+
+func (c *tracingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	// The secret query parameters are left out of the URL, like in the fixtures
+	ctx, span := c.tracer.Start(req.Context(), "HTTP "+req.Method, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", sanitizeURL(req.URL)),
+			attribute.String("server.address", req.URL.Hostname()),
+		))
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := c.next.Do(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		return resp, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	// The span ends once the body is read and closed, with its size
+	resp.Body = &spanBody{ReadCloser: resp.Body, span: span}
+
+	return resp, nil
+}
+
+// spanBody counts the bytes of a response body and ends its span on Close
+type spanBody struct {
+	io.ReadCloser
+	span trace.Span
+	n    int64
+}
+
+func (b *spanBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+
+	return n, err
+}
+
+func (b *spanBody) Close() error {
+	b.span.SetAttributes(attribute.Int64("http.response.body.size", b.n))
+	b.span.End()
+
+	return b.ReadCloser.Close()
+}
@@ -0,0 +1,30 @@
+package repositories
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/pkg/logger"
+)
+
+func TestTracedRepository_As(t *testing.T) {
+	l := logger.NopLogger{}
+	mock := NewMockWeatherRepository(0, 0, l)
+	canary := NewCanaryRepository(mock, NewMockWeatherRepository(0, 0, l), 10, l)
+
+	traced := NewTracedRepository(mock)
+	_, ok := As[HourlyWeatherRepository](traced)
+	assert.True(t, ok)
+	_, ok = As[HistoricalProvider](traced)
+	assert.False(t, ok)
+
+	// The optional features of the traced repository are still served
+	hourly, err := FetchHourlyForecast(context.Background(), traced, 52.52, 13.41, 3)
+	require.NoError(t, err)
+	assert.Len(t, hourly.HourlyData, 3)
+
+	assert.Equal(t, []*CanaryRepository{canary}, Canaries([]WeatherRepository{NewTracedRepository(canary), traced}))
+}
@@ -6,33 +6,116 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"math"
 	"net/http"
 	"strings"
 	"time"
 
+	"weather-api/config"
 	"weather-api/internal/models"
 	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
 )
 
 const (
-	WeatherAPIBaseURL = "https://api.openweathermap.org/data/2.5/forecast"
+	WeatherAPIBaseURL      = "https://api.openweathermap.org/data/2.5/forecast"
+	WeatherAPIDailyBaseURL = "https://api.openweathermap.org/data/2.5/forecast/daily"
+	WeatherAPICurrentURL   = "https://api.openweathermap.org/data/2.5/weather"
+	// WeatherAPIOneCallURL serves the alerts, and the forecasts of the onecall endpoint, it needs a One Call
+	// subscription on top of the API key
+	WeatherAPIOneCallURL = "https://api.openweathermap.org/data/3.0/onecall"
+
+	// WeatherAPIHourlyMaxDays is the horizon of the 3-hourly forecast endpoint
+	WeatherAPIHourlyMaxDays = 5
+	// WeatherAPIDailyMaxDays is the horizon of the daily forecast endpoint
+	WeatherAPIDailyMaxDays = 16
+	// WeatherAPIOneCallMaxDays is the horizon of the daily forecast of the One Call API
+	WeatherAPIOneCallMaxDays = 8
+)
+
+// The forecast endpoints of weatherapi, selected by the endpoint setting of its configuration
+const (
+	WeatherAPIEndpointForecast = "forecast"
+	WeatherAPIEndpointOneCall  = "onecall"
 )
 
 type WeatherAPIRepository struct {
-	APIKey     string
+	APIKey       string
+	baseURL      string
+	dailyBaseURL string
+	currentURL   string
+	oneCallURL   string
+	// endpoint serves the forecasts, WeatherAPIEndpointForecast or WeatherAPIEndpointOneCall
+	endpoint   string
 	httpClient HTTPClient
-	l          *logger.Logger
+	now        func() time.Time
+	l          logger.Logger
+}
+
+func init() {
+	RegisterKeyedProvider("weatherapi", func(api config.WeatherAPIConfig, l logger.Logger, httpClient HTTPClient) (WeatherRepository, error) {
+		repo, err := NewWeatherAPIRepository(api.APIKey, l, httpClient)
+		if err != nil {
+			return nil, err
+		}
+		if api.BaseURL != "" {
+			if err := repo.setBaseURL(api.BaseURL); err != nil {
+				return nil, err
+			}
+		}
+		if err := repo.setEndpoint(api.Endpoint); err != nil {
+			return nil, err
+		}
+		return repo, nil
+	})
+}
+
+// setEndpoint selects the endpoint serving the forecasts, the forecast endpoints when empty
+func (w *WeatherAPIRepository) setEndpoint(endpoint string) error {
+	switch endpoint {
+	case "":
+		w.endpoint = WeatherAPIEndpointForecast
+	case WeatherAPIEndpointForecast, WeatherAPIEndpointOneCall:
+		w.endpoint = endpoint
+	default:
+		return fmt.Errorf("weatherapi endpoint %q must be %s or %s", endpoint, WeatherAPIEndpointForecast, WeatherAPIEndpointOneCall)
+	}
+
+	return nil
 }
 
-func NewWeatherAPIRepository(apiKey string, l *logger.Logger, httpClient HTTPClient) (*WeatherAPIRepository, error) {
+// setBaseURL points every endpoint of the repository at the host of baseURL, the 3-hourly forecast endpoint. The
+// daily, current and One Call endpoints are derived from it so an override, e.g. a canary, never leaks to production.
+func (w *WeatherAPIRepository) setBaseURL(baseURL string) error {
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	root, ok := strings.CutSuffix(baseURL, "/data/2.5/forecast")
+	if !ok {
+		return fmt.Errorf("weatherapi base_url %q must end with /data/2.5/forecast, the other endpoints are derived from it", baseURL)
+	}
+
+	w.baseURL = baseURL
+	w.dailyBaseURL = baseURL + "/daily"
+	w.currentURL = root + "/data/2.5/weather"
+	w.oneCallURL = root + "/data/3.0/onecall"
+
+	return nil
+}
+
+func NewWeatherAPIRepository(apiKey string, l logger.Logger, httpClient HTTPClient) (*WeatherAPIRepository, error) {
 	if strings.TrimSpace(apiKey) == "" {
 		return nil, errors.New("API key cannot be empty")
 	}
 
 	return &WeatherAPIRepository{
-		APIKey:     apiKey,
-		httpClient: httpClient,
-		l:          l,
+		APIKey:       apiKey,
+		baseURL:      WeatherAPIBaseURL,
+		dailyBaseURL: WeatherAPIDailyBaseURL,
+		currentURL:   WeatherAPICurrentURL,
+		oneCallURL:   WeatherAPIOneCallURL,
+		endpoint:     WeatherAPIEndpointForecast,
+		httpClient:   httpClient,
+		now:          time.Now,
+		l:            l,
 	}, nil
 }
 
@@ -40,15 +123,120 @@ func (w *WeatherAPIRepository) Name() string {
 	return "weatherapi"
 }
 
+// RequiresAPIKey implements KeyedProvider
+func (w *WeatherAPIRepository) RequiresAPIKey() bool {
+	return true
+}
+
+// MaxDays implements HorizonProvider, the longer windows are served by the daily endpoint
+func (w *WeatherAPIRepository) MaxDays() int {
+	if w.endpoint == WeatherAPIEndpointOneCall {
+		return WeatherAPIOneCallMaxDays
+	}
+
+	return WeatherAPIDailyMaxDays
+}
+
 type WeatherAPIResponse struct {
-	List []struct {
-		Dt    int64  `json:"dt"`
-		DtTxt string `json:"dt_txt"`
-		Main  struct {
-			TempMin float64 `json:"temp_min"`
-			TempMax float64 `json:"temp_max"`
-		} `json:"main"`
-	} `json:"list"`
+	City struct {
+		// Timezone is the shift in seconds from UTC
+		Timezone *int `json:"timezone"`
+		// Sunrise and Sunset are unix times of the current day only
+		Sunrise *int64 `json:"sunrise"`
+		Sunset  *int64 `json:"sunset"`
+	} `json:"city"`
+	List []WeatherAPISlot `json:"list"`
+}
+
+// WeatherAPISlot is a 3-hour slot of the forecast endpoint
+type WeatherAPISlot struct {
+	Dt    int64  `json:"dt"`
+	DtTxt string `json:"dt_txt"`
+	Main  struct {
+		Temp      float64  `json:"temp"`
+		TempMin   float64  `json:"temp_min"`
+		TempMax   float64  `json:"temp_max"`
+		FeelsLike *float64 `json:"feels_like"`
+		Humidity  *float64 `json:"humidity"`
+	} `json:"main"`
+	Wind struct {
+		Speed *float64 `json:"speed"`
+		Gust  *float64 `json:"gust"`
+	} `json:"wind"`
+	// Rain and Snow are the volumes of the last 3 hours in mm, omitted when there is none
+	Rain struct {
+		ThreeHours float64 `json:"3h"`
+	} `json:"rain"`
+	Snow struct {
+		ThreeHours float64 `json:"3h"`
+	} `json:"snow"`
+	// Pop is the probability of precipitation in [0, 1]
+	Pop     *float64            `json:"pop"`
+	Weather []WeatherAPIWeather `json:"weather"`
+}
+
+// WeatherAPIWeather is an OpenWeatherMap condition, the first one of a list is the primary
+type WeatherAPIWeather struct {
+	ID int `json:"id"`
+}
+
+// WeatherAPIDailyResponse is the response of the daily (16-day) forecast endpoint
+type WeatherAPIDailyResponse struct {
+	City struct {
+		Timezone *int `json:"timezone"`
+	} `json:"city"`
+	List []WeatherAPIDay `json:"list"`
+}
+
+// WeatherAPIDay is a day of the daily forecast endpoint
+type WeatherAPIDay struct {
+	Dt   int64 `json:"dt"`
+	Temp struct {
+		Min float64 `json:"min"`
+		Max float64 `json:"max"`
+	} `json:"temp"`
+	// FeelsLike has no min/max, they are taken over the parts of the day
+	FeelsLike map[string]float64 `json:"feels_like"`
+	Humidity  *float64           `json:"humidity"`
+	Speed     *float64           `json:"speed"`
+	Gust      *float64           `json:"gust"`
+	Pop       *float64           `json:"pop"`
+	// Rain and Snow are the daily volumes in mm, omitted when there is none
+	Rain    float64             `json:"rain"`
+	Snow    float64             `json:"snow"`
+	Sunrise *int64              `json:"sunrise"`
+	Sunset  *int64              `json:"sunset"`
+	Weather []WeatherAPIWeather `json:"weather"`
+}
+
+// WeatherAPIOneCallResponse is the response of the One Call API, its days are the ones of the daily endpoint but
+// for the names of the wind fields
+type WeatherAPIOneCallResponse struct {
+	Timezone string `json:"timezone"`
+	// TimezoneOffset is the shift in seconds from UTC
+	TimezoneOffset *int `json:"timezone_offset"`
+	Daily          []struct {
+		WeatherAPIDay
+		WindSpeed *float64 `json:"wind_speed"`
+		WindGust  *float64 `json:"wind_gust"`
+	} `json:"daily"`
+}
+
+// WeatherAPICurrentResponse is the response of the current weather endpoint
+type WeatherAPICurrentResponse struct {
+	Dt int64 `json:"dt"`
+	// Timezone is the shift in seconds from UTC
+	Timezone *int `json:"timezone"`
+	Main     *struct {
+		Temp      float64  `json:"temp"`
+		FeelsLike *float64 `json:"feels_like"`
+		Humidity  *float64 `json:"humidity"`
+	} `json:"main"`
+	Wind struct {
+		Speed *float64 `json:"speed"`
+		Deg   *float64 `json:"deg"`
+	} `json:"wind"`
+	Weather []WeatherAPIWeather `json:"weather"`
 }
 
 func (w *WeatherAPIRepository) FetchForecast(
@@ -69,110 +257,539 @@ func (w *WeatherAPIRepository) FetchForecast(
 		return forecast, errors.New("API key cannot be empty")
 	}
 
-	url := fmt.Sprintf("%s?lat=%f&lon=%f&units=metric&appid=%s", WeatherAPIBaseURL, lat, lon, w.APIKey)
+	if w.endpoint == WeatherAPIEndpointOneCall {
+		return w.fetchOneCallForecast(ctx, forecast)
+	}
 
-	w.l.Info("making weatherapi API request", map[string]any{
-		"params": forecast.RequestParams(),
-	})
+	// The 3-hourly endpoint only covers 5 days, longer windows use the daily endpoint
+	if forecastWindow > WeatherAPIHourlyMaxDays {
+		return w.fetchDailyForecast(ctx, forecast)
+	}
 
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	url := fmt.Sprintf("%s?lat=%f&lon=%f&units=metric&appid=%s", w.baseURL, lat, lon, w.APIKey)
+
+	body, err := w.get(ctx, url, forecast.RequestParams(), &forecast.FetchMetadata)
 	if err != nil {
-		return forecast, fmt.Errorf("failed to create request: %w", err)
+		return forecast, err
 	}
 
-	resp, err := w.httpClient.Do(req)
-	if err != nil {
-		return forecast, fmt.Errorf("failed to do request: %w", err)
+	var response WeatherAPIResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return forecast, invalidResponse("failed to parse JSON response: %w", err)
 	}
-	defer resp.Body.Close()
 
-	w.l.Info("received weatherapi API response", map[string]any{
-		"status":     resp.StatusCode,
-		"statusText": resp.Status,
+	logger.FromContext(ctx, w.l).Info("parsed API response", map[string]any{
+		"items": len(response.List),
 	})
 
-	body, err := io.ReadAll(resp.Body)
+	// Check if we have any data
+	if len(response.List) == 0 {
+		return forecast, ErrNoData
+	}
+
+	// Process daily temperatures, grouped by local day
+	loc := responseLocation(ctx, response.City.Timezone)
+	dailyTemps, err := dailyTemperaturesWeatherAPI(response, loc)
 	if err != nil {
-		return forecast, fmt.Errorf("failed to read response body: %w", err)
+		return forecast, fmt.Errorf("failed to process daily temperatures: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return forecast, fmt.Errorf("HTTP error (status %d): %s", resp.StatusCode, resp.Status)
+	setCitySunTimes(dailyTemps, response, loc)
+
+	forecast.ForecastData = dailyTemps[:min(len(dailyTemps), forecastWindow)]
+
+	// OpenWeatherMap only reports the offset, the timezone name is resolved by the service
+	if response.City.Timezone != nil {
+		forecast.Timezone = &models.Timezone{UTCOffsetSeconds: *response.City.Timezone}
+	}
+
+	return forecast, nil
+}
+
+// FetchHourlyForecast maps the 3-hourly slots directly, so the forecast has one entry every 3 hours
+func (w *WeatherAPIRepository) FetchHourlyForecast(ctx context.Context, lat, lon float64, hours int) (models.HourlyForecast, error) {
+	forecast := models.HourlyForecast{
+		RepositoryName: w.Name(),
+		Lat:            lat,
+		Lon:            lon,
+		Hours:          hours,
+	}
+
+	if strings.TrimSpace(w.APIKey) == "" {
+		return forecast, errors.New("API key cannot be empty")
+	}
+
+	slots := min((hours+2)/3, WeatherAPIHourlyMaxDays*8)
+	url := fmt.Sprintf("%s?lat=%f&lon=%f&cnt=%d&units=metric&appid=%s", w.baseURL, lat, lon, slots, w.APIKey)
+
+	body, err := w.get(ctx, url, forecast.RequestParams(), &forecast.FetchMetadata)
+	if err != nil {
+		return forecast, err
 	}
 
 	var response WeatherAPIResponse
 	if err := json.Unmarshal(body, &response); err != nil {
-		return forecast, fmt.Errorf("failed to parse JSON response: %w", err)
+		return forecast, invalidResponse("failed to parse JSON response: %w", err)
 	}
 
-	w.l.Info("parsed API response", map[string]any{
-		"items": len(response.List),
+	if len(response.List) == 0 {
+		return forecast, ErrNoData
+	}
+
+	loc := responseLocation(ctx, response.City.Timezone)
+	for _, item := range response.List[:min(len(response.List), slots)] {
+		t := time.Unix(item.Dt, 0).In(loc)
+
+		hour := models.HourlyWeatherData{
+			Time: &t,
+			Temp: item.Main.Temp,
+		}
+		hour.PrecipitationProbability = popPercent(item.Pop)
+
+		forecast.HourlyData = append(forecast.HourlyData, hour)
+	}
+
+	return forecast, nil
+}
+
+// FetchCurrent fetches the latest observation of the nearest station
+func (w *WeatherAPIRepository) FetchCurrent(ctx context.Context, lat, lon float64) (models.CurrentWeather, error) {
+	current := models.CurrentWeather{
+		RepositoryName: w.Name(),
+		Lat:            lat,
+		Lon:            lon,
+	}
+
+	if strings.TrimSpace(w.APIKey) == "" {
+		return current, errors.New("API key cannot be empty")
+	}
+
+	url := fmt.Sprintf("%s?lat=%f&lon=%f&units=metric&appid=%s", w.currentURL, lat, lon, w.APIKey)
+
+	body, err := w.get(ctx, url, current.RequestParams(), &current.FetchMetadata)
+	if err != nil {
+		return current, err
+	}
+
+	var response WeatherAPICurrentResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return current, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	if response.Main == nil || response.Dt == 0 {
+		return current, ErrNoData
+	}
+
+	loc := responseLocation(ctx, response.Timezone)
+	current.Current = &models.CurrentConditions{
+		ObservedAt:    time.Unix(response.Dt, 0).In(loc),
+		Temperature:   response.Main.Temp,
+		FeelsLike:     response.Main.FeelsLike,
+		WindSpeed:     response.Wind.Speed,
+		WindDirection: response.Wind.Deg,
+		Humidity:      response.Main.Humidity,
+	}
+	if len(response.Weather) > 0 {
+		id := response.Weather[0].ID
+		current.Current.ConditionCode = &id
+		current.Current.Condition = owmCondition(id)
+	}
+
+	return current, nil
+}
+
+// WeatherAPIAlert is a national weather alert of the One Call API, times are unix times
+type WeatherAPIAlert struct {
+	SenderName  string `json:"sender_name"`
+	Event       string `json:"event"`
+	Start       int64  `json:"start"`
+	End         int64  `json:"end"`
+	Description string `json:"description"`
+}
+
+// FetchAlerts fetches the active alerts from the One Call API, it reports no severity
+func (w *WeatherAPIRepository) FetchAlerts(ctx context.Context, lat, lon float64) ([]models.Alert, error) {
+	if strings.TrimSpace(w.APIKey) == "" {
+		return nil, errors.New("API key cannot be empty")
+	}
+
+	url := fmt.Sprintf("%s?lat=%f&lon=%f&exclude=current,minutely,hourly,daily&appid=%s", w.oneCallURL, lat, lon, w.APIKey)
+
+	var meta models.FetchMetadata
+	body, err := w.get(ctx, url, fmt.Sprintf("lat: %.4f lon: %.4f alerts", lat, lon), &meta)
+	if err != nil {
+		return nil, err
+	}
+
+	var response struct {
+		Alerts []WeatherAPIAlert `json:"alerts"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	alerts := make([]models.Alert, 0, len(response.Alerts))
+	for _, alert := range response.Alerts {
+		a := models.Alert{
+			Source:      w.Name(),
+			Event:       alert.Event,
+			Severity:    models.AlertSeverityUnknown,
+			Onset:       time.Unix(alert.Start, 0).UTC(),
+			Description: alert.Description,
+		}
+		if alert.End != 0 {
+			a.Expires = time.Unix(alert.End, 0).UTC()
+		}
+		alerts = append(alerts, a)
+	}
+
+	return alerts, nil
+}
+
+// fetchDailyForecast fetches the forecast from the daily endpoint, which reports min/max directly
+func (w *WeatherAPIRepository) fetchDailyForecast(ctx context.Context, forecast models.Forecast) (models.Forecast, error) {
+	days := min(forecast.ForecastWindow, WeatherAPIDailyMaxDays)
+	url := fmt.Sprintf("%s?lat=%f&lon=%f&cnt=%d&units=metric&appid=%s", w.dailyBaseURL, forecast.Lat, forecast.Lon, days, w.APIKey)
+
+	body, err := w.get(ctx, url, forecast.RequestParams(), &forecast.FetchMetadata)
+	if err != nil {
+		return forecast, err
+	}
+
+	var response WeatherAPIDailyResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return forecast, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	logger.FromContext(ctx, w.l).Info("parsed API response", map[string]any{
+		"days": len(response.List),
 	})
 
-	// Check if we have any data
 	if len(response.List) == 0 {
-		return forecast, fmt.Errorf("no forecast data available")
+		return forecast, ErrNoData
+	}
+
+	forecast.ForecastData = dailyForecastWeatherAPI(response, responseLocation(ctx, response.City.Timezone))
+	forecast.ForecastData = forecast.ForecastData[:min(len(forecast.ForecastData), forecast.ForecastWindow)]
+
+	if response.City.Timezone != nil {
+		forecast.Timezone = &models.Timezone{UTCOffsetSeconds: *response.City.Timezone}
 	}
 
-	// Process daily temperatures
-	dailyTemps, err := dailyTemperaturesWeatherAPI(response)
+	return forecast, nil
+}
+
+// fetchOneCallForecast fetches the forecast from the daily forecast of the One Call API
+func (w *WeatherAPIRepository) fetchOneCallForecast(ctx context.Context, forecast models.Forecast) (models.Forecast, error) {
+	url := fmt.Sprintf("%s?lat=%f&lon=%f&exclude=current,minutely,hourly,alerts&units=metric&appid=%s", w.oneCallURL, forecast.Lat, forecast.Lon, w.APIKey)
+
+	body, err := w.get(ctx, url, forecast.RequestParams(), &forecast.FetchMetadata)
 	if err != nil {
-		return forecast, fmt.Errorf("failed to process daily temperatures: %w", err)
+		return forecast, err
+	}
+
+	var response WeatherAPIOneCallResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return forecast, invalidResponse("failed to parse JSON response: %w", err)
+	}
+
+	logger.FromContext(ctx, w.l).Info("parsed API response", map[string]any{
+		"days": len(response.Daily),
+	})
+
+	if len(response.Daily) == 0 {
+		return forecast, ErrNoData
+	}
+
+	daily := WeatherAPIDailyResponse{List: make([]WeatherAPIDay, 0, len(response.Daily))}
+	for _, item := range response.Daily {
+		day := item.WeatherAPIDay
+		day.Speed, day.Gust = item.WindSpeed, item.WindGust
+		daily.List = append(daily.List, day)
 	}
 
-	forecast.ForecastData = dailyTemps[:forecastWindow]
+	forecast.ForecastData = dailyForecastWeatherAPI(daily, responseLocation(ctx, response.TimezoneOffset))
+	forecast.ForecastData = forecast.ForecastData[:min(len(forecast.ForecastData), forecast.ForecastWindow)]
+
+	if response.TimezoneOffset != nil {
+		forecast.Timezone = &models.Timezone{Name: response.Timezone, UTCOffsetSeconds: *response.TimezoneOffset}
+	}
 
 	return forecast, nil
 }
 
-func dailyTemperaturesWeatherAPI(response WeatherAPIResponse) ([]models.WeatherData, error) {
+// get performs a GET request against the provider and returns the body of a successful response
+func (w *WeatherAPIRepository) get(ctx context.Context, url, params string, meta *models.FetchMetadata) ([]byte, error) {
+	l := logger.FromContext(ctx, w.l)
+	requestID := requestid.FromContext(ctx)
+
+	l.Info("making weatherapi API request", map[string]any{
+		"request_id": requestID,
+		"params":     params,
+	})
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if requestID != "" {
+		req.Header.Set(requestid.Header, requestID)
+	}
+
+	start := w.now()
+	meta.FetchedAt = start
+	meta.SourceURL = sanitizeURL(req.URL)
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to do request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	l.Info("received weatherapi API response", map[string]any{
+		"request_id": requestID,
+		"status":     resp.StatusCode,
+		"statusText": resp.Status,
+	})
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	meta.FetchDurationMS = w.now().Sub(start).Milliseconds()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &HTTPStatusError{StatusCode: resp.StatusCode, Status: resp.Status}
+	}
+
+	return body, nil
+}
+
+// dailyForecastWeatherAPI converts the daily endpoint response, dates are taken in the local time of loc
+func dailyForecastWeatherAPI(response WeatherAPIDailyResponse, loc *time.Location) []models.WeatherData {
+	forecastDays := make([]models.WeatherData, 0, len(response.List))
+	for _, item := range response.List {
+		date := models.NewDate(time.Unix(item.Dt, 0).In(loc))
+
+		precipitation := item.Rain + item.Snow
+
+		forecastDays = append(forecastDays, models.WeatherData{
+			Date:                     date,
+			TempMax:                  item.Temp.Max,
+			TempMin:                  item.Temp.Min,
+			PrecipitationSum:         &precipitation,
+			PrecipitationProbability: popPercent(item.Pop),
+			WindSpeedMax:             item.Speed,
+			WindGustsMax:             item.Gust,
+			HumidityMean:             item.Humidity,
+			Sunrise:                  unixTime(item.Sunrise, loc),
+			Sunset:                   unixTime(item.Sunset, loc),
+		})
+
+		day := &forecastDays[len(forecastDays)-1]
+		for _, feelsLike := range item.FeelsLike {
+			day.FeelsLikeMin = mergeValue(day.FeelsLikeMin, &feelsLike, math.Min)
+			day.FeelsLikeMax = mergeValue(day.FeelsLikeMax, &feelsLike, math.Max)
+		}
+		setWorstCondition(day, item.Weather)
+	}
+	models.SortWeatherData(forecastDays)
+
+	return forecastDays
+}
+
+// dailyTemperaturesWeatherAPI groups the 3-hour slots by their local day in loc
+func dailyTemperaturesWeatherAPI(response WeatherAPIResponse, loc *time.Location) ([]models.WeatherData, error) {
 	var dailyTemps []models.WeatherData
+	// humidity accumulates the slots of the day with the same index as dailyTemps
+	var humidity []meanAccumulator
 
-	// Group temperatures by date
+	// Group slots by date
 	for _, item := range response.List {
-		// Parse the date from dt_txt (format: "2025-07-25 18:00:00")
-		date, err := parseDate(item.DtTxt)
+		date, err := slotDate(item.DtTxt, loc)
 		if err != nil {
-			return dailyTemps, fmt.Errorf("failed to parse date from dt_txt %s: %w", item.DtTxt, err)
+			// Skip slots with an unexpected date format
+			continue
 		}
 
-		index := models.FilterByDate(dailyTemps, date)
+		slot := slotWeatherData(item)
+		slot.Date = date
 
+		index := models.FilterByDate(dailyTemps, date)
 		if index == -1 {
-			// Create new entry for this date
-			dailyTemps = append(dailyTemps, models.WeatherData{
-				Date:    date,
-				TempMin: item.Main.TempMin,
-				TempMax: item.Main.TempMax,
-			})
-			continue
+			// The first slot of the day starts a new entry
+			dailyTemps = append(dailyTemps, slot)
+			humidity = append(humidity, meanAccumulator{})
+			index = len(dailyTemps) - 1
+		} else {
+			mergeDay(&dailyTemps[index], slot)
 		}
 
-		// Update existing entry
-		if item.Main.TempMin < dailyTemps[index].TempMin {
-			dailyTemps[index].TempMin = item.Main.TempMin
-		}
-		if item.Main.TempMax > dailyTemps[index].TempMax {
-			dailyTemps[index].TempMax = item.Main.TempMax
-		}
+		humidity[index].add(item.Main.Humidity)
+	}
+
+	for i := range dailyTemps {
+		*dailyTemps[i].PrecipitationSum = math.Round(*dailyTemps[i].PrecipitationSum*100) / 100
+		dailyTemps[i].HumidityMean = humidity[i].mean()
 	}
+	// The slots are usually chronological but nothing guarantees it, the days keep the order of their first slot
+	models.SortWeatherData(dailyTemps)
 
 	return dailyTemps, nil
 }
 
-func parseDate(dateStr string) (*time.Time, error) {
+// slotWeatherData converts a 3-hour slot to a day holding only that slot
+func slotWeatherData(item WeatherAPISlot) models.WeatherData {
+	// Precipitation is omitted by the provider when there is none
+	precipitation := item.Rain.ThreeHours + item.Snow.ThreeHours
+
+	slot := models.WeatherData{
+		TempMin:                  item.Main.TempMin,
+		TempMax:                  item.Main.TempMax,
+		FeelsLikeMin:             copyValue(item.Main.FeelsLike),
+		FeelsLikeMax:             copyValue(item.Main.FeelsLike),
+		PrecipitationSum:         &precipitation,
+		PrecipitationProbability: popPercent(item.Pop),
+		WindSpeedMax:             copyValue(item.Wind.Speed),
+		WindGustsMax:             copyValue(item.Wind.Gust),
+	}
+	setWorstCondition(&slot, item.Weather)
+
+	return slot
+}
+
+// mergeDay folds a slot into its day: min/max fields keep the extreme,
+// amounts are summed and the condition is the most severe one
+func mergeDay(day *models.WeatherData, slot models.WeatherData) {
+	day.TempMin = min(day.TempMin, slot.TempMin)
+	day.TempMax = max(day.TempMax, slot.TempMax)
+	day.FeelsLikeMin = mergeValue(day.FeelsLikeMin, slot.FeelsLikeMin, math.Min)
+	day.FeelsLikeMax = mergeValue(day.FeelsLikeMax, slot.FeelsLikeMax, math.Max)
+	day.PrecipitationSum = mergeValue(day.PrecipitationSum, slot.PrecipitationSum, sum)
+	day.PrecipitationProbability = mergeValue(day.PrecipitationProbability, slot.PrecipitationProbability, math.Max)
+	day.WindSpeedMax = mergeValue(day.WindSpeedMax, slot.WindSpeedMax, math.Max)
+	day.WindGustsMax = mergeValue(day.WindGustsMax, slot.WindGustsMax, math.Max)
+
+	if slot.ConditionCode != nil && (day.ConditionCode == nil || slot.Condition.WorseThan(day.Condition)) {
+		day.Condition = slot.Condition
+		day.ConditionCode = slot.ConditionCode
+	}
+}
+
+// mergeValue combines two optional values, a missing value leaves the other one unchanged
+func mergeValue(current, v *float64, combine func(a, b float64) float64) *float64 {
+	if v == nil {
+		return current
+	}
+	if current == nil {
+		return copyValue(v)
+	}
+	value := combine(*current, *v)
+	return &value
+}
+
+func sum(a, b float64) float64 {
+	return a + b
+}
+
+func copyValue(v *float64) *float64 {
+	if v == nil {
+		return nil
+	}
+	value := *v
+	return &value
+}
+
+// setCitySunTimes assigns the sunrise and sunset of the city object to the day they belong to in loc
+func setCitySunTimes(days []models.WeatherData, response WeatherAPIResponse, loc *time.Location) {
+	if response.City.Sunrise == nil {
+		return
+	}
+
+	sunrise := unixTime(response.City.Sunrise, loc)
+	if index := models.FilterByDate(days, models.NewDate(*sunrise)); index != -1 {
+		days[index].Sunrise = sunrise
+		days[index].Sunset = unixTime(response.City.Sunset, loc)
+	}
+}
+
+// unixTime converts an optional unix time to the given location
+func unixTime(sec *int64, loc *time.Location) *time.Time {
+	if sec == nil {
+		return nil
+	}
+	t := time.Unix(*sec, 0).In(loc)
+	return &t
+}
+
+// setWorstCondition keeps the most severe condition reported for the day
+func setWorstCondition(day *models.WeatherData, weather []WeatherAPIWeather) {
+	for _, w := range weather {
+		condition := owmCondition(w.ID)
+		if day.ConditionCode == nil || condition.WorseThan(day.Condition) {
+			id := w.ID
+			day.Condition = condition
+			day.ConditionCode = &id
+		}
+	}
+}
+
+// meanAccumulator averages the reported values of an optional field
+type meanAccumulator struct {
+	sum float64
+	n   int
+}
+
+func (m *meanAccumulator) add(v *float64) {
+	if v == nil {
+		return
+	}
+	m.sum += *v
+	m.n++
+}
+
+// mean returns nil when no value was reported
+func (m *meanAccumulator) mean() *float64 {
+	if m.n == 0 {
+		return nil
+	}
+	mean := math.Round(m.sum/float64(m.n)*10) / 10
+	return &mean
+}
+
+// popPercent converts the probability of precipitation from [0, 1] to a percentage
+func popPercent(pop *float64) *float64 {
+	if pop == nil {
+		return nil
+	}
+	percent := math.Round(*pop * 100)
+	return &percent
+}
+
+// slotDate returns the local date in loc of a slot from its dt_txt, the UTC time of the slot such as
+// "2025-07-25 18:00:00", a dt_txt with a date only is taken as is
+func slotDate(dtTxt string, loc *time.Location) (models.Date, error) {
+	t, err := time.ParseInLocation(time.DateTime, dtTxt, time.UTC)
+	if err != nil {
+		return parseDate(dtTxt)
+	}
+
+	return models.NewDate(t.In(loc)), nil
+}
+
+func parseDate(dateStr string) (models.Date, error) {
 	if len(dateStr) < 10 {
 		// Skip if the date format is unexpected
-		return nil, fmt.Errorf("invalid date string: %s", dateStr)
+		return models.Date{}, invalidResponse("invalid date string: %s", dateStr)
 	}
 
 	dateStr = dateStr[:10] // Extract just the date part
 
 	// Parse the date string in the format "2025-07-25"
-	t, err := time.Parse("2006-01-02", dateStr)
+	date, err := models.ParseDate(dateStr)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse date %s: %w", dateStr, err)
+		return models.Date{}, invalidResponse("failed to parse date %s: %w", dateStr, err)
 	}
 
-	return &t, nil
+	return date, nil
 }
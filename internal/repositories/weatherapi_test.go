@@ -2,14 +2,19 @@ package repositories
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strings"
 	"testing"
 	"time"
 
+	"weather-api/config"
+	"weather-api/internal/models"
 	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
 )
 
 func TestWeatherAPIRepository_FetchForecast_Success(t *testing.T) {
@@ -49,7 +54,7 @@ func TestWeatherAPIRepository_FetchForecast_Success(t *testing.T) {
 		},
 	}
 
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 	repo, err := NewWeatherAPIRepository("test-key", l, mockClient)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
@@ -76,7 +81,7 @@ func TestWeatherAPIRepository_FetchForecast_Success(t *testing.T) {
 
 	// Verify the first day (2025-07-25)
 	expectedDate1, _ := time.Parse("2006-01-02", "2025-07-25")
-	if result.ForecastData[0].Date == nil || !result.ForecastData[0].Date.Equal(expectedDate1) {
+	if result.ForecastData[0].Date.IsZero() || !result.ForecastData[0].Date.Equal(expectedDate1) {
 		t.Errorf("Expected date 2025-07-25, got %v", result.ForecastData[0].Date)
 	}
 	// The min temp should be 19.88 (lowest of all readings for that day)
@@ -90,7 +95,7 @@ func TestWeatherAPIRepository_FetchForecast_Success(t *testing.T) {
 
 	// Verify the second day (2025-07-26)
 	expectedDate2, _ := time.Parse("2006-01-02", "2025-07-26")
-	if result.ForecastData[1].Date == nil || !result.ForecastData[1].Date.Equal(expectedDate2) {
+	if result.ForecastData[1].Date.IsZero() || !result.ForecastData[1].Date.Equal(expectedDate2) {
 		t.Errorf("Expected date 2025-07-26, got %v", result.ForecastData[1].Date)
 	}
 	// The min temp should be 20.42 (lowest of all readings for that day)
@@ -115,7 +120,7 @@ func TestWeatherAPIRepository_FetchForecast_HTTPError(t *testing.T) {
 		},
 	}
 
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 	repo, err := NewWeatherAPIRepository("invalid-key", l, mockClient)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
@@ -143,7 +148,7 @@ func TestWeatherAPIRepository_FetchForecast_NetworkError(t *testing.T) {
 		},
 	}
 
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 	repo, err := NewWeatherAPIRepository("test-key", l, mockClient)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
@@ -175,7 +180,7 @@ func TestWeatherAPIRepository_FetchForecast_InvalidJSON(t *testing.T) {
 		},
 	}
 
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 	repo, err := NewWeatherAPIRepository("test-key", l, mockClient)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
@@ -209,7 +214,7 @@ func TestWeatherAPIRepository_FetchForecast_EmptyData(t *testing.T) {
 		},
 	}
 
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 	repo, err := NewWeatherAPIRepository("test-key", l, mockClient)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
@@ -247,7 +252,7 @@ func TestWeatherAPIRepository_FetchForecast_InvalidDateFormat(t *testing.T) {
 		},
 	}
 
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 	repo, err := NewWeatherAPIRepository("test-key", l, mockClient)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
@@ -289,7 +294,7 @@ func TestWeatherAPIRepository_FetchForecast_ContextCancellation(t *testing.T) {
 		},
 	}
 
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 	repo, err := NewWeatherAPIRepository("test-key", l, mockClient)
 	if err != nil {
 		t.Fatalf("Failed to create repository: %v", err)
@@ -318,12 +323,16 @@ func TestWeatherAPIRepository_Name(t *testing.T) {
 }
 
 func TestWeatherAPIRepository_RealAPI(t *testing.T) {
-	t.Skip("Skipping real API test - uncomment to test against actual OpenWeatherMap API")
-
-	// This test makes a real HTTP call to the OpenWeatherMap API
-	l := logger.NewZapLogger("test-app")
-	httpClient := &DefaultHTTPClient{}
-	repo, err := NewWeatherAPIRepository("REAL_API_KEY", l, httpClient) // Replace with valid API key
+	// The responses of the real OpenWeatherMap API are replayed from testdata, see replayHTTPClient. The key is
+	// stripped from the fixtures, any key replays them and a real one is only needed to record them again.
+	apiKey := os.Getenv("OPENWEATHERMAP_API_KEY")
+	if apiKey == "" {
+		apiKey = "replay-key"
+	}
+	repo, err := NewWeatherAPIRepository(apiKey, logger.NopLogger{}, replayHTTPClient(t))
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
 
 	ctx := context.Background()
 	lat := 45.44 // Venice latitude
@@ -335,8 +344,11 @@ func TestWeatherAPIRepository_RealAPI(t *testing.T) {
 		t.Fatalf("Real API call failed: %v", err)
 	}
 
-	if len(result.ForecastData) == 0 {
-		t.Fatal("Expected weather data, got empty result")
+	if len(result.ForecastData) != forecastWindow {
+		t.Fatalf("Expected %d days of weather data, got %d", forecastWindow, len(result.ForecastData))
+	}
+	if result.Timezone == nil || result.Timezone.UTCOffsetSeconds != 7200 {
+		t.Errorf("Expected offset-only timezone 7200, got %+v", result.Timezone)
 	}
 
 	// Verify each response has proper weather data
@@ -350,3 +362,744 @@ func TestWeatherAPIRepository_RealAPI(t *testing.T) {
 		}
 	}
 }
+
+func TestWeatherAPIRepository_FetchForecast_PropagatesRequestID(t *testing.T) {
+	var outboundID string
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			outboundID = req.Header.Get(requestid.Header)
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"list": [{"dt": 1753455600, "dt_txt": "2025-07-25 15:00:00", "main": {"temp_min": 21.7, "temp_max": 22.52}}]}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	repo, err := NewWeatherAPIRepository("test-key", logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	ctx := requestid.NewContext(context.Background(), "req-456")
+	if _, err := repo.FetchForecast(ctx, 40.7128, -74.0060, 1); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if outboundID != "req-456" {
+		t.Errorf("Expected outbound X-Request-ID req-456, got %q", outboundID)
+	}
+}
+
+func TestWeatherAPIRepository_FetchForecast_TimezoneOffset(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"city": {"timezone": -14400},
+					"list": [{"dt": 1753455600, "dt_txt": "2025-07-25 15:00:00", "main": {"temp_min": 21.7, "temp_max": 22.52}}]
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo, err := NewWeatherAPIRepository("test-key", logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	result, err := repo.FetchForecast(context.Background(), 40.7128, -74.0060, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if result.Timezone == nil || result.Timezone.Name != "" || result.Timezone.UTCOffsetSeconds != -14400 {
+		t.Errorf("Expected offset-only timezone -14400, got %+v", result.Timezone)
+	}
+}
+
+func TestWeatherAPIRepository_FetchForecast_DailyEndpoint(t *testing.T) {
+	var requestedURL string
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requestedURL = req.URL.String()
+
+			var list []string
+			for i := 0; i < 10; i++ {
+				// Noon at UTC+2, so every item falls on its own local day
+				dt := time.Date(2025, 7, 25+i, 10, 0, 0, 0, time.UTC).Unix()
+				list = append(list, fmt.Sprintf(`{"dt": %d, "temp": {"day": 25, "min": %d, "max": %d}}`, dt, 15+i, 25+i))
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(fmt.Sprintf(`{
+					"city": {"timezone": 7200},
+					"cnt": 10,
+					"list": [%s]
+				}`, strings.Join(list, ",")))),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo, err := NewWeatherAPIRepository("test-key", logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	result, err := repo.FetchForecast(context.Background(), 45.4408, 12.3155, 10)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if !strings.HasPrefix(requestedURL, WeatherAPIDailyBaseURL+"?") {
+		t.Errorf("Expected daily endpoint, got %s", requestedURL)
+	}
+	if !strings.Contains(requestedURL, "cnt=10") {
+		t.Errorf("Expected cnt=10 in URL, got %s", requestedURL)
+	}
+
+	if len(result.ForecastData) != 10 {
+		t.Fatalf("Expected 10 days, got %d", len(result.ForecastData))
+	}
+
+	first := result.ForecastData[0]
+	if first.Date.Format("2006-01-02") != "2025-07-25" || first.TempMin != 15 || first.TempMax != 25 {
+		t.Errorf("Unexpected first day: %+v", first)
+	}
+
+	last := result.ForecastData[9]
+	if last.Date.Format("2006-01-02") != "2025-08-03" || last.TempMin != 24 || last.TempMax != 34 {
+		t.Errorf("Unexpected last day: %+v", last)
+	}
+
+	if result.Timezone == nil || result.Timezone.UTCOffsetSeconds != 7200 {
+		t.Errorf("Expected offset-only timezone 7200, got %+v", result.Timezone)
+	}
+}
+
+func TestWeatherAPIRepository_BaseURLOverride(t *testing.T) {
+	var requested []string
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requested = append(requested, req.URL.String())
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"list": [{"dt": 1753455600, "temp": {"min": 15, "max": 25}}]}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	const base = "https://owm-canary.example.com/proxy/data/2.5/forecast"
+	repo, err := providers["weatherapi"].factory(config.WeatherAPIConfig{Name: "weatherapi", APIKey: "test-key", BaseURL: base}, logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	weatherAPI := repo.(*WeatherAPIRepository)
+
+	// Windows longer than 5 days use the daily endpoint, it must follow the override as well
+	if _, err := weatherAPI.FetchForecast(context.Background(), 45.4408, 12.3155, 10); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	_, _ = weatherAPI.FetchCurrent(context.Background(), 45.4408, 12.3155)
+	_, _ = weatherAPI.FetchAlerts(context.Background(), 45.4408, 12.3155)
+
+	expected := []string{
+		base + "/daily?",
+		"https://owm-canary.example.com/proxy/data/2.5/weather?",
+		"https://owm-canary.example.com/proxy/data/3.0/onecall?",
+	}
+	if len(requested) != len(expected) {
+		t.Fatalf("Expected %d requests, got %v", len(expected), requested)
+	}
+	for i, prefix := range expected {
+		if !strings.HasPrefix(requested[i], prefix) {
+			t.Errorf("Expected request %d to %s, got %s", i, prefix, requested[i])
+		}
+	}
+}
+
+func TestWeatherAPIRepository_BaseURLOverrideInvalid(t *testing.T) {
+	api := config.WeatherAPIConfig{Name: "weatherapi", APIKey: "test-key", BaseURL: WeatherAPIOneCallURL}
+	if _, err := providers["weatherapi"].factory(api, logger.NopLogger{}, &MockHTTPClient{}); err == nil {
+		t.Error("Expected error for a base_url that is not the forecast endpoint, got nil")
+	}
+}
+
+func TestWeatherAPIRepository_OneCallEndpoint(t *testing.T) {
+	var requestedURL string
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requestedURL = req.URL.String()
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"timezone": "Asia/Tokyo",
+					"timezone_offset": 32400,
+					"daily": [
+						{"dt": 1753412400, "temp": {"min": 24, "max": 31}, "wind_speed": 4.5, "wind_gust": 9.1, "pop": 0.4},
+						{"dt": 1753498800, "temp": {"min": 25, "max": 32}, "wind_speed": 3.2}
+					]
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	api := config.WeatherAPIConfig{Name: "weatherapi", APIKey: "test-key", Endpoint: WeatherAPIEndpointOneCall}
+	repo, err := providers["weatherapi"].factory(api, logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	if got := MaxDays(repo); got != WeatherAPIOneCallMaxDays {
+		t.Errorf("Expected max days %d, got %d", WeatherAPIOneCallMaxDays, got)
+	}
+
+	// The short windows use One Call as well
+	result, err := repo.FetchForecast(context.Background(), 35.6762, 139.6503, 3)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if !strings.HasPrefix(requestedURL, WeatherAPIOneCallURL+"?") || !strings.Contains(requestedURL, "exclude=current,minutely,hourly,alerts") {
+		t.Errorf("Expected the One Call daily forecast, got %s", requestedURL)
+	}
+	if len(result.ForecastData) != 2 {
+		t.Fatalf("Expected 2 days, got %d", len(result.ForecastData))
+	}
+	first := result.ForecastData[0]
+	if first.Date.Format("2006-01-02") != "2025-07-25" || first.TempMin != 24 || first.TempMax != 31 {
+		t.Errorf("Unexpected first day: %+v", first)
+	}
+	if first.WindSpeedMax == nil || *first.WindSpeedMax != 4.5 || first.WindGustsMax == nil || *first.WindGustsMax != 9.1 {
+		t.Errorf("Expected the wind of One Call, got %+v", first)
+	}
+	if first.PrecipitationProbability == nil || *first.PrecipitationProbability != 40 {
+		t.Errorf("Expected a 40%% precipitation probability, got %v", first.PrecipitationProbability)
+	}
+	if result.Timezone == nil || *result.Timezone != (models.Timezone{Name: "Asia/Tokyo", UTCOffsetSeconds: 32400}) {
+		t.Errorf("Expected the Asia/Tokyo timezone, got %+v", result.Timezone)
+	}
+}
+
+func TestWeatherAPIRepository_EndpointInvalid(t *testing.T) {
+	api := config.WeatherAPIConfig{Name: "weatherapi", APIKey: "test-key", Endpoint: "hourly"}
+	if _, err := providers["weatherapi"].factory(api, logger.NopLogger{}, &MockHTTPClient{}); err == nil {
+		t.Error("Expected error for an unknown endpoint, got nil")
+	}
+}
+
+func TestWeatherAPIRepository_FetchForecast_HourlyEndpointForShortWindow(t *testing.T) {
+	var requestedURL string
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requestedURL = req.URL.String()
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"list": [{"dt": 1753455600, "dt_txt": "2025-07-25 15:00:00", "main": {"temp_min": 21.7, "temp_max": 22.52}}]
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo, err := NewWeatherAPIRepository("test-key", logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	if _, err := repo.FetchForecast(context.Background(), 45.4408, 12.3155, WeatherAPIHourlyMaxDays); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if !strings.HasPrefix(requestedURL, WeatherAPIBaseURL+"?") || strings.Contains(requestedURL, "cnt=") {
+		t.Errorf("Expected 3-hourly endpoint, got %s", requestedURL)
+	}
+}
+
+func TestWeatherAPIRepository_FetchForecast_DailyEndpointCapsDays(t *testing.T) {
+	var requestedURL string
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			requestedURL = req.URL.String()
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"list": [{"dt": 1753455600, "temp": {"min": 15, "max": 25}}]}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	repo, err := NewWeatherAPIRepository("test-key", logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	if _, err := repo.FetchForecast(context.Background(), 45.4408, 12.3155, 30); err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if !strings.Contains(requestedURL, fmt.Sprintf("cnt=%d", WeatherAPIDailyMaxDays)) {
+		t.Errorf("Expected cnt capped at %d, got %s", WeatherAPIDailyMaxDays, requestedURL)
+	}
+}
+
+func TestWeatherAPIRepository_FetchHourlyForecast(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.String(), WeatherAPIBaseURL+"?") || !strings.Contains(req.URL.String(), "cnt=2") {
+				t.Errorf("Expected 2 slots from the 3-hourly endpoint, got: %s", req.URL.String())
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"city": {"timezone": 7200},
+					"list": [
+						{"dt": 1753455600, "main": {"temp": 22.1, "temp_min": 21.7, "temp_max": 22.5}, "pop": 0.35},
+						{"dt": 1753466400, "main": {"temp": 20.4, "temp_min": 20.1, "temp_max": 20.9}}
+					]
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo, err := NewWeatherAPIRepository("test-key", logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	result, err := repo.FetchHourlyForecast(context.Background(), 45.4408, 12.3155, 6)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if len(result.HourlyData) != 2 {
+		t.Fatalf("Expected 2 slots, got %d", len(result.HourlyData))
+	}
+
+	first := result.HourlyData[0]
+	if first.Time.Format(time.RFC3339) != "2025-07-25T17:00:00+02:00" || first.Temp != 22.1 {
+		t.Errorf("Unexpected first slot: %+v", first)
+	}
+	if first.PrecipitationProbability == nil || *first.PrecipitationProbability != 35 {
+		t.Errorf("Expected precipitation probability 35, got %v", first.PrecipitationProbability)
+	}
+	if result.HourlyData[1].PrecipitationProbability != nil {
+		t.Errorf("Expected missing precipitation probability, got %v", *result.HourlyData[1].PrecipitationProbability)
+	}
+}
+
+func TestWeatherAPIRepository_FetchCurrent(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.String(), WeatherAPICurrentURL+"?") || !strings.Contains(req.URL.String(), "units=metric") {
+				t.Errorf("Expected the metric current weather endpoint, got: %s", req.URL.String())
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"dt": 1753455600,
+					"timezone": 7200,
+					"main": {"temp": 22.1, "feels_like": 22.4, "humidity": 65},
+					"wind": {"speed": 3.2, "deg": 180},
+					"weather": [{"id": 500, "main": "Rain"}]
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo, err := NewWeatherAPIRepository("test-key", logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	result, err := repo.FetchCurrent(context.Background(), 45.4408, 12.3155)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	current := result.Current
+	if current == nil {
+		t.Fatal("Expected current conditions")
+	}
+	if current.ObservedAt.Format(time.RFC3339) != "2025-07-25T17:00:00+02:00" || current.Temperature != 22.1 {
+		t.Errorf("Unexpected current conditions: %+v", current)
+	}
+	if current.FeelsLike == nil || *current.FeelsLike != 22.4 || current.Humidity == nil || *current.Humidity != 65 {
+		t.Errorf("Unexpected feels like or humidity: %v %v", current.FeelsLike, current.Humidity)
+	}
+	if current.Condition != models.ConditionRain || current.ConditionCode == nil || *current.ConditionCode != 500 {
+		t.Errorf("Unexpected condition: %s %v", current.Condition, current.ConditionCode)
+	}
+}
+
+func TestDailyTemperaturesWeatherAPI_Accumulation(t *testing.T) {
+	body := `{
+		"list": [
+			{"dt_txt": "2025-07-25 09:00:00", "main": {"temp_min": 18, "temp_max": 20, "humidity": 80}, "wind": {"speed": 3.1, "gust": 5.2}, "rain": {"3h": 0.4}, "pop": 0.2},
+			{"dt_txt": "2025-07-25 12:00:00", "main": {"temp_min": 21, "temp_max": 24, "humidity": 60}, "wind": {"speed": 6.4, "gust": 4.0}, "rain": {"3h": 1.25}, "snow": {"3h": 0.1}, "pop": 0.75},
+			{"dt_txt": "2025-07-25 15:00:00", "main": {"temp_min": 22, "temp_max": 25, "humidity": 55}, "wind": {"speed": 4.0}, "pop": 0.1},
+			{"dt_txt": "2025-07-26 00:00:00", "main": {"temp_min": 16, "temp_max": 17}}
+		]
+	}`
+
+	var response WeatherAPIResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	days, err := dailyTemperaturesWeatherAPI(response, time.UTC)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(days) != 2 {
+		t.Fatalf("Expected 2 days, got %d", len(days))
+	}
+
+	tests := []struct {
+		field string
+		got   *float64
+		want  float64
+	}{
+		{"precipitation_sum (sum)", days[0].PrecipitationSum, 1.75},
+		{"precipitation_probability (max)", days[0].PrecipitationProbability, 75},
+		{"wind_speed_max (max)", days[0].WindSpeedMax, 6.4},
+		{"wind_gusts_max (max)", days[0].WindGustsMax, 5.2},
+		{"humidity_mean (mean)", days[0].HumidityMean, 65},
+		{"precipitation_sum without rain", days[1].PrecipitationSum, 0},
+	}
+
+	for _, tt := range tests {
+		if tt.got == nil {
+			t.Errorf("%s: expected %v, got nil", tt.field, tt.want)
+			continue
+		}
+		if *tt.got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.field, tt.want, *tt.got)
+		}
+	}
+
+	if days[1].WindSpeedMax != nil || days[1].HumidityMean != nil || days[1].PrecipitationProbability != nil {
+		t.Errorf("Expected fields not reported by the provider to be nil, got %+v", days[1])
+	}
+}
+
+func TestDailyTemperaturesWeatherAPI_Unsorted(t *testing.T) {
+	body := `{
+		"list": [
+			{"dt_txt": "2025-07-27 09:00:00", "main": {"temp_min": 19, "temp_max": 23}},
+			{"dt_txt": "2025-07-25 12:00:00", "main": {"temp_min": 21, "temp_max": 24}},
+			{"dt_txt": "2025-07-26 00:00:00", "main": {"temp_min": 16, "temp_max": 17}},
+			{"dt_txt": "2025-07-25 09:00:00", "main": {"temp_min": 18, "temp_max": 20}}
+		]
+	}`
+
+	var response WeatherAPIResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	days, err := dailyTemperaturesWeatherAPI(response, time.UTC)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	want := []string{"2025-07-25", "2025-07-26", "2025-07-27"}
+	if len(days) != len(want) {
+		t.Fatalf("Expected %d days, got %d", len(want), len(days))
+	}
+	for i, date := range want {
+		if days[i].Date.String() != date {
+			t.Errorf("Expected %s at %d, got %s", date, i, days[i].Date)
+		}
+	}
+	if days[0].TempMin != 18 || days[0].TempMax != 24 {
+		t.Errorf("Expected the slots of a day merged whatever their order, got %+v", days[0])
+	}
+}
+
+func TestDailyForecastWeatherAPI_Unsorted(t *testing.T) {
+	var response WeatherAPIDailyResponse
+	body := `{"city": {"timezone": 0}, "list": [{"dt": 1753617600}, {"dt": 1753444800}, {"dt": 1753531200}]}`
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	days := dailyForecastWeatherAPI(response, time.UTC)
+	if !models.WeatherDataSorted(days) || days[0].Date.String() != "2025-07-25" {
+		t.Errorf("Expected the days sorted from 2025-07-25, got %v", days)
+	}
+}
+
+func TestDailyForecastWeatherAPI_Location(t *testing.T) {
+	var response WeatherAPIDailyResponse
+	body := `{"city": {"timezone": 0}, "list": [{"dt": 1753444800}]}`
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+	auckland, err := time.LoadLocation("Pacific/Auckland")
+	if err != nil {
+		t.Fatalf("Failed to load location: %v", err)
+	}
+
+	// 2025-07-25 12:00 UTC is midnight of the next day at UTC+12
+	days := dailyForecastWeatherAPI(response, auckland)
+	if len(days) != 1 || days[0].Date.String() != "2025-07-26" {
+		t.Errorf("Expected 2025-07-26, got %v", days)
+	}
+}
+
+func TestWeatherAPIRepository_FetchForecast_LocalDays(t *testing.T) {
+	// The slots from 2025-07-25 21:00 UTC, 17:00 in New York
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"city": {"timezone": -14400},
+					"list": [
+						{"dt_txt": "2025-07-25 21:00:00", "main": {"temp_min": 20, "temp_max": 25}},
+						{"dt_txt": "2025-07-26 00:00:00", "main": {"temp_min": 19, "temp_max": 23}},
+						{"dt_txt": "2025-07-26 03:00:00", "main": {"temp_min": 17, "temp_max": 18}},
+						{"dt_txt": "2025-07-26 06:00:00", "main": {"temp_min": 16, "temp_max": 16.5}}
+					]
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+	repo, err := NewWeatherAPIRepository("test-key", logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	if err != nil {
+		t.Fatalf("Failed to load location: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		expected []models.WeatherData
+	}{
+		{
+			// Split at the local midnight of the reported offset, not at the UTC one
+			name: "reported offset",
+			ctx:  context.Background(),
+			expected: []models.WeatherData{
+				{Date: models.NewDate(time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)), TempMin: 17, TempMax: 25},
+				{Date: models.NewDate(time.Date(2025, 7, 26, 0, 0, 0, 0, time.UTC)), TempMin: 16, TempMax: 16.5},
+			},
+		},
+		{
+			// The timezone resolved by the service wins, every slot is in the morning of 2025-07-26 in Tokyo
+			name: "resolved timezone",
+			ctx:  WithTimezone(context.Background(), tokyo),
+			expected: []models.WeatherData{
+				{Date: models.NewDate(time.Date(2025, 7, 26, 0, 0, 0, 0, time.UTC)), TempMin: 16, TempMax: 25},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := repo.FetchForecast(tt.ctx, 40.7128, -74.006, 5)
+			if err != nil {
+				t.Fatalf("Expected no error, got: %v", err)
+			}
+			if len(result.ForecastData) != len(tt.expected) {
+				t.Fatalf("Expected %d days, got %v", len(tt.expected), result.ForecastData)
+			}
+			for i, day := range result.ForecastData {
+				want := tt.expected[i]
+				if day.Date != want.Date || day.TempMin != want.TempMin || day.TempMax != want.TempMax {
+					t.Errorf("Expected day %d %s %.1f-%.1f, got %s %.1f-%.1f", i, want.Date, want.TempMin, want.TempMax,
+						day.Date, day.TempMin, day.TempMax)
+				}
+			}
+		})
+	}
+}
+
+func TestWeatherAPIRepository_FetchForecast_SunTimes(t *testing.T) {
+	// Sunrise 2025-07-25 06:01 and sunset 20:39 at UTC+2
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"city": {"timezone": 7200, "sunrise": 1753416060, "sunset": 1753468740},
+					"list": [
+						{"dt_txt": "2025-07-25 15:00:00", "main": {"temp_min": 21.7, "temp_max": 22.5}},
+						{"dt_txt": "2025-07-26 15:00:00", "main": {"temp_min": 20.1, "temp_max": 23.9}}
+					]
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo, err := NewWeatherAPIRepository("test-key", logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	result, err := repo.FetchForecast(context.Background(), 45.4408, 12.3155, 2)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	first := result.ForecastData[0]
+	if first.Sunrise == nil || first.Sunrise.Format(time.RFC3339) != "2025-07-25T06:01:00+02:00" {
+		t.Errorf("Expected sunrise 2025-07-25T06:01:00+02:00, got %v", first.Sunrise)
+	}
+	if first.Sunset == nil || first.Sunset.Format(time.RFC3339) != "2025-07-25T20:39:00+02:00" {
+		t.Errorf("Expected sunset 2025-07-25T20:39:00+02:00, got %v", first.Sunset)
+	}
+	if first.UVIndexMax != nil {
+		t.Errorf("Expected no UV index, got %v", *first.UVIndexMax)
+	}
+
+	// The city object only covers the current day
+	if result.ForecastData[1].Sunrise != nil || result.ForecastData[1].Sunset != nil {
+		t.Errorf("Expected no sun times for the second day, got %+v", result.ForecastData[1])
+	}
+}
+
+func TestDailyTemperaturesWeatherAPI_FeelsLike(t *testing.T) {
+	body := `{
+		"list": [
+			{"dt_txt": "2025-07-25 09:00:00", "main": {"temp_min": 18, "temp_max": 20, "feels_like": 17.4}},
+			{"dt_txt": "2025-07-25 12:00:00", "main": {"temp_min": 21, "temp_max": 24, "feels_like": 25.9}},
+			{"dt_txt": "2025-07-25 15:00:00", "main": {"temp_min": 22, "temp_max": 25, "feels_like": 24.1}},
+			{"dt_txt": "2025-07-26 00:00:00", "main": {"temp_min": 16, "temp_max": 17}},
+			{"dt_txt": "2025-07-26 03:00:00", "main": {"temp_min": 15, "temp_max": 16, "feels_like": 14.2}}
+		]
+	}`
+
+	var response WeatherAPIResponse
+	if err := json.Unmarshal([]byte(body), &response); err != nil {
+		t.Fatalf("Failed to parse response: %v", err)
+	}
+
+	days, err := dailyTemperaturesWeatherAPI(response, time.UTC)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		day      int
+		min, max float64
+	}{
+		{"aggregated over three slots", 0, 17.4, 25.9},
+		{"slot without feels-like is skipped", 1, 14.2, 14.2},
+	}
+
+	for _, tt := range tests {
+		day := days[tt.day]
+		if day.FeelsLikeMin == nil || day.FeelsLikeMax == nil {
+			t.Errorf("%s: expected feels-like %v..%v, got nil", tt.name, tt.min, tt.max)
+			continue
+		}
+		if *day.FeelsLikeMin != tt.min || *day.FeelsLikeMax != tt.max {
+			t.Errorf("%s: expected feels-like %v..%v, got %v..%v", tt.name, tt.min, tt.max, *day.FeelsLikeMin, *day.FeelsLikeMax)
+		}
+	}
+
+	if days[0].TempMin != 18 || days[0].TempMax != 25 {
+		t.Errorf("Expected temperatures 18..25, got %v..%v", days[0].TempMin, days[0].TempMax)
+	}
+}
+
+func TestWeatherAPIRepository_FetchForecast_FetchMetadata(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"list": [{"dt_txt": "2025-07-25 15:00:00", "main": {"temp_min": 21.7, "temp_max": 22.5}}]}`)),
+				Header:     make(http.Header),
+			}, nil
+		},
+	}
+
+	repo, err := NewWeatherAPIRepository("secret-key", logger.NopLogger{}, mockClient)
+	if err != nil {
+		t.Fatalf("Failed to create repository: %v", err)
+	}
+
+	start := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	repo.now = stepClock(start, 1250*time.Millisecond)
+
+	result, err := repo.FetchForecast(context.Background(), 45.4408, 12.3155, 1)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+
+	if !result.FetchedAt.Equal(start) || result.FetchDurationMS != 1250 {
+		t.Errorf("Expected fetched at %v in 1250ms, got %v in %dms", start, result.FetchedAt, result.FetchDurationMS)
+	}
+	if strings.Contains(result.SourceURL, "secret-key") {
+		t.Errorf("Expected API key stripped from source URL, got %s", result.SourceURL)
+	}
+	if !strings.HasPrefix(result.SourceURL, WeatherAPIBaseURL+"?") {
+		t.Errorf("Expected source URL of the provider, got %s", result.SourceURL)
+	}
+}
+
+func TestWeatherAPIRepository_FetchAlerts(t *testing.T) {
+	mockClient := &MockHTTPClient{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if !strings.HasPrefix(req.URL.String(), WeatherAPIOneCallURL+"?") || !strings.Contains(req.URL.String(), "exclude=current,minutely,hourly,daily") {
+				t.Errorf("Expected the One Call endpoint without forecasts, got: %s", req.URL.String())
+			}
+
+			return &http.Response{
+				StatusCode: http.StatusOK,
+				Body: io.NopCloser(strings.NewReader(`{
+					"lat": 40.71,
+					"lon": -74.01,
+					"alerts": [
+						{"sender_name": "NWS New York City", "event": "Heat Advisory", "start": 1753459200, "end": 1753488000, "description": "Heat index values up to 105."},
+						{"sender_name": "NWS New York City", "event": "Air Quality Alert", "start": 1753459200, "end": 0}
+					]
+				}`)),
+				Header: make(http.Header),
+			}, nil
+		},
+	}
+
+	repo, _ := NewWeatherAPIRepository("test-api-key", logger.NopLogger{}, mockClient)
+
+	alerts, err := repo.FetchAlerts(context.Background(), 40.71, -74.01)
+	if err != nil {
+		t.Fatalf("Expected no error, got: %v", err)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("Expected 2 alerts, got %d", len(alerts))
+	}
+
+	heat := alerts[0]
+	if heat.Source != "weatherapi" || heat.Event != "Heat Advisory" || heat.Severity != models.AlertSeverityUnknown {
+		t.Errorf("Unexpected alert: %+v", heat)
+	}
+	if !heat.Onset.Equal(time.Unix(1753459200, 0)) || !heat.Expires.Equal(time.Unix(1753488000, 0)) {
+		t.Errorf("Unexpected alert times: %s - %s", heat.Onset, heat.Expires)
+	}
+	if !alerts[1].Expires.IsZero() {
+		t.Errorf("Expected an open-ended alert, got expiry %s", alerts[1].Expires)
+	}
+}
@@ -0,0 +1,252 @@
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"weather-api/config"
+	"weather-api/internal/models"
+)
+
+const (
+	// MaxRules caps the number of rules so evaluation cost stays bounded
+	MaxRules = 50
+
+	defaultMaxEvaluation = 5 * time.Millisecond
+)
+
+const (
+	ActionDrop    = "drop"
+	ActionSuspect = "flag_suspect"
+	ActionExclude = "exclude_from_aggregate"
+)
+
+const (
+	ScopeAny    = ""
+	ScopePast   = "past"
+	ScopeToday  = "today"
+	ScopeFuture = "future"
+)
+
+var comparators = map[string]func(a, b float64) bool{
+	"lt":  func(a, b float64) bool { return a < b },
+	"lte": func(a, b float64) bool { return a <= b },
+	"gt":  func(a, b float64) bool { return a > b },
+	"gte": func(a, b float64) bool { return a >= b },
+	"eq":  func(a, b float64) bool { return a == b },
+	"ne":  func(a, b float64) bool { return a != b },
+}
+
+// fields lists the numeric WeatherData fields rules can compare
+var fields = map[string]func(d *models.WeatherData) float64{
+	"temp_max": func(d *models.WeatherData) float64 { return d.TempMax },
+	"temp_min": func(d *models.WeatherData) float64 { return d.TempMin },
+}
+
+type rule struct {
+	name       string
+	provider   string
+	field      func(d *models.WeatherData) float64
+	comparator func(a, b float64) bool
+	value      float64
+	scope      string
+	action     string
+	hits       atomic.Int64
+}
+
+// Engine applies the configured post-processing rules to provider forecasts
+type Engine struct {
+	rules         []*rule
+	maxEvaluation time.Duration
+	now           func() time.Time
+}
+
+// NewEngine validates the configured rules and builds the engine
+func NewEngine(cfg config.RulesConfig) (*Engine, error) {
+	if len(cfg.Rules) > MaxRules {
+		return nil, fmt.Errorf("too many rules: %d, at most %d are allowed", len(cfg.Rules), MaxRules)
+	}
+
+	e := &Engine{
+		maxEvaluation: defaultMaxEvaluation,
+		now:           time.Now,
+	}
+	if cfg.MaxEvaluationMS > 0 {
+		e.maxEvaluation = time.Duration(cfg.MaxEvaluationMS) * time.Millisecond
+	}
+
+	var errors []string
+	for i, rc := range cfg.Rules {
+		r, err := newRule(i, rc)
+		if err != nil {
+			errors = append(errors, err.Error())
+			continue
+		}
+		e.rules = append(e.rules, r)
+	}
+
+	if len(errors) > 0 {
+		return nil, fmt.Errorf("invalid rules: %s", strings.Join(errors, "; "))
+	}
+
+	return e, nil
+}
+
+func newRule(i int, rc config.RuleConfig) (*rule, error) {
+	r := &rule{
+		name:     rc.Name,
+		provider: rc.Provider,
+		value:    rc.Value,
+		scope:    rc.DateScope,
+		action:   rc.Action,
+	}
+	if r.name == "" {
+		r.name = fmt.Sprintf("rule-%d", i)
+	}
+
+	switch rc.Action {
+	case ActionDrop, ActionSuspect, ActionExclude:
+	default:
+		return nil, fmt.Errorf("rules[%d].action must be one of: %s, %s, %s", i, ActionDrop, ActionSuspect, ActionExclude)
+	}
+
+	switch rc.DateScope {
+	case ScopeAny, ScopePast, ScopeToday, ScopeFuture:
+	default:
+		return nil, fmt.Errorf("rules[%d].date_scope must be one of: past, today, future", i)
+	}
+
+	if rc.Field != "" {
+		field, ok := fields[rc.Field]
+		if !ok {
+			return nil, fmt.Errorf("rules[%d].field is unknown: %s", i, rc.Field)
+		}
+		comparator, ok := comparators[rc.Comparator]
+		if !ok {
+			return nil, fmt.Errorf("rules[%d].comparator must be one of: lt, lte, gt, gte, eq, ne", i)
+		}
+		r.field, r.comparator = field, comparator
+	} else if rc.Comparator != "" {
+		return nil, fmt.Errorf("rules[%d].comparator requires a field", i)
+	}
+
+	if r.provider == "" && r.field == nil && r.scope == ScopeAny {
+		return nil, fmt.Errorf("rules[%d] must have at least one condition", i)
+	}
+
+	return r, nil
+}
+
+// HitKey identifies the hits of the rules sharing a name and an action
+type HitKey struct {
+	Rule   string
+	Action string
+}
+
+// Hits returns how many times the rules matched, by rule name and action
+func (e *Engine) Hits() map[HitKey]int64 {
+	if e == nil {
+		return nil
+	}
+
+	hits := make(map[HitKey]int64, len(e.rules))
+	for _, r := range e.rules {
+		hits[HitKey{Rule: r.name, Action: r.action}] += r.hits.Load()
+	}
+
+	return hits
+}
+
+// Apply evaluates the rules against every day of the forecast. All matching rules apply,
+// a drop wins over any other action. Evaluation stops, leaving the remaining days untouched,
+// once the evaluation budget is exhausted; Apply reports whether it completed.
+func (e *Engine) Apply(forecast *models.Forecast) bool {
+	if e == nil || len(e.rules) == 0 || len(forecast.ForecastData) == 0 {
+		return true
+	}
+
+	deadline := time.Now().Add(e.maxEvaluation)
+	today := localToday(e.now(), forecast.Timezone)
+
+	kept := make([]models.WeatherData, 0, len(forecast.ForecastData))
+	for i, day := range forecast.ForecastData {
+		if time.Now().After(deadline) {
+			forecast.ForecastData = append(kept, forecast.ForecastData[i:]...)
+			return false
+		}
+
+		if e.applyDay(forecast.RepositoryName, &day, today) {
+			kept = append(kept, day)
+		}
+	}
+	forecast.ForecastData = kept
+
+	return true
+}
+
+// applyDay applies the matching rules to a single day and reports whether the day is kept
+func (e *Engine) applyDay(provider string, day *models.WeatherData, today time.Time) bool {
+	for _, r := range e.rules {
+		if !r.matches(provider, day, today) {
+			continue
+		}
+
+		r.hits.Add(1)
+
+		switch r.action {
+		case ActionDrop:
+			return false
+		case ActionSuspect:
+			day.Suspect = true
+		case ActionExclude:
+			day.ExcludedFromAggregate = true
+		}
+	}
+
+	return true
+}
+
+func (r *rule) matches(provider string, day *models.WeatherData, today time.Time) bool {
+	if r.provider != "" && !strings.EqualFold(r.provider, provider) {
+		return false
+	}
+
+	if r.scope != ScopeAny {
+		if day.Date.IsZero() {
+			return false
+		}
+		date := day.Date.Time
+		switch r.scope {
+		case ScopePast:
+			if !date.Before(today) {
+				return false
+			}
+		case ScopeToday:
+			if !date.Equal(today) {
+				return false
+			}
+		case ScopeFuture:
+			if !date.After(today) {
+				return false
+			}
+		}
+	}
+
+	if r.field != nil && !r.comparator(r.field(day), r.value) {
+		return false
+	}
+
+	return true
+}
+
+// localToday returns the current date at the forecast location as a UTC midnight
+func localToday(now time.Time, tz *models.Timezone) time.Time {
+	if tz != nil {
+		now = now.UTC().Add(time.Duration(tz.UTCOffsetSeconds) * time.Second)
+	}
+	now = now.UTC()
+
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
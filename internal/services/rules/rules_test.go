@@ -0,0 +1,171 @@
+package rules
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/config"
+	"weather-api/internal/models"
+)
+
+var testNow = time.Date(2025, 7, 25, 10, 0, 0, 0, time.UTC)
+
+func day(offset int, tempMin, tempMax float64) models.WeatherData {
+	date := time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC).AddDate(0, 0, offset)
+	return models.WeatherData{Date: models.NewDate(date), TempMin: tempMin, TempMax: tempMax}
+}
+
+func newTestEngine(t *testing.T, rules ...config.RuleConfig) *Engine {
+	t.Helper()
+
+	e, err := NewEngine(config.RulesConfig{Rules: rules})
+	require.NoError(t, err)
+	e.now = func() time.Time { return testNow }
+
+	return e
+}
+
+func TestEngine_ComparatorsAndActions(t *testing.T) {
+	comparatorCases := []struct {
+		comparator string
+		value      float64
+		matches    bool
+	}{
+		{"lt", 31, true}, {"lt", 30, false},
+		{"lte", 30, true}, {"lte", 29, false},
+		{"gt", 29, true}, {"gt", 30, false},
+		{"gte", 30, true}, {"gte", 31, false},
+		{"eq", 30, true}, {"eq", 31, false},
+		{"ne", 31, true}, {"ne", 30, false},
+	}
+
+	for _, action := range []string{ActionDrop, ActionSuspect, ActionExclude} {
+		for _, cc := range comparatorCases {
+			t.Run(action+"/"+cc.comparator, func(t *testing.T) {
+				e := newTestEngine(t, config.RuleConfig{Field: "temp_max", Comparator: cc.comparator, Value: cc.value, Action: action})
+
+				forecast := models.Forecast{RepositoryName: "open-meteo", ForecastData: []models.WeatherData{day(0, 20, 30)}}
+				require.True(t, e.Apply(&forecast))
+
+				if !cc.matches {
+					require.Len(t, forecast.ForecastData, 1)
+					assert.False(t, forecast.ForecastData[0].Suspect)
+					assert.False(t, forecast.ForecastData[0].ExcludedFromAggregate)
+					return
+				}
+
+				switch action {
+				case ActionDrop:
+					assert.Empty(t, forecast.ForecastData)
+				case ActionSuspect:
+					require.Len(t, forecast.ForecastData, 1)
+					assert.True(t, forecast.ForecastData[0].Suspect)
+				case ActionExclude:
+					require.Len(t, forecast.ForecastData, 1)
+					assert.True(t, forecast.ForecastData[0].ExcludedFromAggregate)
+				}
+			})
+		}
+	}
+}
+
+func TestEngine_ProviderAndDateScope(t *testing.T) {
+	e := newTestEngine(t, config.RuleConfig{Name: "drop-owm-today", Provider: "weatherapi", DateScope: ScopeToday, Action: ActionDrop})
+
+	owm := models.Forecast{RepositoryName: "weatherapi", ForecastData: []models.WeatherData{day(0, 20, 30), day(1, 21, 31)}}
+	e.Apply(&owm)
+	require.Len(t, owm.ForecastData, 1)
+	assert.Equal(t, day(1, 21, 31), owm.ForecastData[0])
+
+	openMeteo := models.Forecast{RepositoryName: "open-meteo", ForecastData: []models.WeatherData{day(0, 20, 30)}}
+	e.Apply(&openMeteo)
+	assert.Len(t, openMeteo.ForecastData, 1)
+
+	assert.Equal(t, int64(1), e.Hits()[HitKey{Rule: "drop-owm-today", Action: ActionDrop}])
+}
+
+func TestEngine_DateScopeUsesLocationTimezone(t *testing.T) {
+	e := newTestEngine(t, config.RuleConfig{DateScope: ScopeToday, Action: ActionSuspect})
+
+	// 10:00 UTC is already the next day in Kiritimati (UTC+14)
+	forecast := models.Forecast{
+		Timezone:     &models.Timezone{Name: "Pacific/Kiritimati", UTCOffsetSeconds: 14 * 3600},
+		ForecastData: []models.WeatherData{day(0, 5, 10), day(1, 6, 11)},
+	}
+	e.Apply(&forecast)
+
+	assert.False(t, forecast.ForecastData[0].Suspect)
+	assert.True(t, forecast.ForecastData[1].Suspect)
+}
+
+func TestEngine_PastAndFutureScopes(t *testing.T) {
+	e := newTestEngine(t,
+		config.RuleConfig{DateScope: ScopePast, Action: ActionDrop},
+		config.RuleConfig{DateScope: ScopeFuture, Action: ActionExclude},
+	)
+
+	forecast := models.Forecast{ForecastData: []models.WeatherData{day(-1, 1, 2), day(0, 3, 4), day(1, 5, 6)}}
+	e.Apply(&forecast)
+
+	require.Len(t, forecast.ForecastData, 2)
+	assert.False(t, forecast.ForecastData[0].ExcludedFromAggregate)
+	assert.True(t, forecast.ForecastData[1].ExcludedFromAggregate)
+}
+
+func TestEngine_Precedence(t *testing.T) {
+	e := newTestEngine(t,
+		config.RuleConfig{Name: "suspect-hot", Field: "temp_max", Comparator: "gt", Value: 50, Action: ActionSuspect},
+		config.RuleConfig{Name: "exclude-hot", Field: "temp_max", Comparator: "gt", Value: 50, Action: ActionExclude},
+		config.RuleConfig{Name: "drop-absurd", Field: "temp_max", Comparator: "gt", Value: 70, Action: ActionDrop},
+	)
+
+	forecast := models.Forecast{ForecastData: []models.WeatherData{day(0, 30, 55), day(1, 30, 85)}}
+	e.Apply(&forecast)
+
+	// Flags accumulate, a drop wins regardless of rule order
+	require.Len(t, forecast.ForecastData, 1)
+	assert.True(t, forecast.ForecastData[0].Suspect)
+	assert.True(t, forecast.ForecastData[0].ExcludedFromAggregate)
+	assert.Equal(t, 55.0, forecast.ForecastData[0].TempMax)
+}
+
+func TestEngine_EvaluationBudget(t *testing.T) {
+	e := newTestEngine(t, config.RuleConfig{Field: "temp_max", Comparator: "gt", Value: 0, Action: ActionDrop})
+	e.maxEvaluation = -time.Second
+
+	forecast := models.Forecast{ForecastData: []models.WeatherData{day(0, 1, 2), day(1, 1, 2)}}
+
+	assert.False(t, e.Apply(&forecast))
+	assert.Len(t, forecast.ForecastData, 2)
+}
+
+func TestNewEngine_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		rule config.RuleConfig
+		err  string
+	}{
+		{"unknown action", config.RuleConfig{Provider: "mock", Action: "delete"}, "action must be one of"},
+		{"unknown field", config.RuleConfig{Field: "humidity", Comparator: "gt", Action: ActionDrop}, "field is unknown"},
+		{"unknown comparator", config.RuleConfig{Field: "temp_max", Comparator: "~", Action: ActionDrop}, "comparator must be one of"},
+		{"comparator without field", config.RuleConfig{Comparator: "gt", Action: ActionDrop}, "comparator requires a field"},
+		{"unknown scope", config.RuleConfig{DateScope: "tomorrow", Action: ActionDrop}, "date_scope must be one of"},
+		{"no condition", config.RuleConfig{Action: ActionDrop}, "at least one condition"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewEngine(config.RulesConfig{Rules: []config.RuleConfig{tt.rule}})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	tooMany := make([]config.RuleConfig, MaxRules+1)
+	_, err := NewEngine(config.RulesConfig{Rules: tooMany})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "too many rules")
+}
@@ -0,0 +1,175 @@
+package timezone
+
+import (
+	"container/list"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"weather-api/internal/models"
+)
+
+// coordinatePrecision is the number of decimals coordinates are rounded to for caching,
+// about 1km which is far below the size of any timezone
+const coordinatePrecision = 100
+
+const (
+	// maxEntries bounds the cache, the least recently used location is evicted beyond it
+	maxEntries = 10000
+	// cacheTTL is how long a resolved name is kept, the zone of a location hardly ever changes
+	cacheTTL = 24 * time.Hour
+)
+
+type coordinate struct {
+	lat int64
+	lon int64
+}
+
+type cacheEntry struct {
+	key     coordinate
+	loc     *time.Location
+	expires time.Time
+}
+
+// Resolver resolves the timezone name of a location once and caches it per coordinate, so every
+// local-time computation for the location uses the same zone. The offset is computed on every call,
+// it follows the daylight saving time changes of the zone.
+type Resolver struct {
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+
+	mu    sync.Mutex
+	order *list.List
+	items map[coordinate]*list.Element
+}
+
+func NewResolver() *Resolver {
+	return &Resolver{
+		maxEntries: maxEntries,
+		ttl:        cacheTTL,
+		now:        time.Now,
+		order:      list.New(),
+		items:      make(map[coordinate]*list.Element),
+	}
+}
+
+// Resolve returns the timezone of the location. A cached name wins, otherwise the first timezone
+// reported by a provider with a valid IANA name is used. When the providers only reported offsets,
+// the first one is used as a fixed zone, and when nothing was reported the timezone is estimated
+// offline from the coordinates. Only the names are cached, the next request of the location gets
+// another chance to resolve its name.
+func (r *Resolver) Resolve(lat, lon float64, reported []models.Timezone) models.Timezone {
+	key := coordinateKey(lat, lon)
+
+	loc, ok := r.get(key)
+	if !ok {
+		loc, ok = fromReported(reported)
+		switch {
+		case ok:
+			r.set(key, loc)
+		case len(reported) > 0:
+			offset := reported[0].UTCOffsetSeconds
+			return models.Timezone{Name: offsetName(offset), UTCOffsetSeconds: offset}
+		default:
+			return Estimate(lon)
+		}
+	}
+
+	_, offset := r.now().In(loc).Zone()
+
+	return models.Timezone{Name: loc.String(), UTCOffsetSeconds: offset}
+}
+
+// Cached returns the location of the timezone name resolved for the location, false when none is cached
+func (r *Resolver) Cached(lat, lon float64) (*time.Location, bool) {
+	return r.get(coordinateKey(lat, lon))
+}
+
+func coordinateKey(lat, lon float64) coordinate {
+	return coordinate{
+		lat: int64(math.Round(lat * coordinatePrecision)),
+		lon: int64(math.Round(lon * coordinatePrecision)),
+	}
+}
+
+func (r *Resolver) get(key coordinate) (*time.Location, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	e, ok := r.items[key]
+	if !ok {
+		return nil, false
+	}
+	entry := e.Value.(*cacheEntry)
+	if r.now().After(entry.expires) {
+		r.order.Remove(e)
+		delete(r.items, key)
+		return nil, false
+	}
+	r.order.MoveToFront(e)
+
+	return entry.loc, true
+}
+
+func (r *Resolver) set(key coordinate, loc *time.Location) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if e, ok := r.items[key]; ok {
+		r.order.Remove(e)
+	}
+	r.items[key] = r.order.PushFront(&cacheEntry{key: key, loc: loc, expires: r.now().Add(r.ttl)})
+
+	if r.order.Len() > r.maxEntries {
+		oldest := r.order.Back()
+		r.order.Remove(oldest)
+		delete(r.items, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+// fromReported returns the location of the first reported timezone with a valid IANA name
+func fromReported(reported []models.Timezone) (*time.Location, bool) {
+	for _, tz := range reported {
+		if tz.Name == "" {
+			continue
+		}
+
+		loc, err := time.LoadLocation(tz.Name)
+		if err != nil {
+			continue
+		}
+
+		return loc, true
+	}
+
+	return nil, false
+}
+
+// offsetName names a fixed offset as UTC+hh:mm, a name time.LoadLocation rejects so that the offset is kept
+func offsetName(offset int) string {
+	sign := '+'
+	if offset < 0 {
+		sign, offset = '-', -offset
+	}
+
+	return fmt.Sprintf("UTC%c%02d:%02d", sign, offset/3600, offset%3600/60)
+}
+
+// Estimate approximates the timezone of a location from its longitude using nautical
+// time zones, it is only a fallback for providers that don't report a timezone
+func Estimate(lon float64) models.Timezone {
+	hours := int(math.Round(lon / 15))
+	hours = max(-12, min(12, hours))
+
+	if hours == 0 {
+		return models.Timezone{Name: "Etc/GMT", UTCOffsetSeconds: 0}
+	}
+
+	// Etc/GMT names have an inverted sign: Etc/GMT-2 is UTC+2
+	return models.Timezone{
+		Name:             fmt.Sprintf("Etc/GMT%+d", -hours),
+		UTCOffsetSeconds: hours * 3600,
+	}
+}
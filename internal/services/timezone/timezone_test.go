@@ -0,0 +1,126 @@
+package timezone
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"weather-api/internal/models"
+)
+
+func TestEstimate(t *testing.T) {
+	tests := []struct {
+		name     string
+		lon      float64
+		expected models.Timezone
+	}{
+		{name: "greenwich", lon: 0.1, expected: models.Timezone{Name: "Etc/GMT", UTCOffsetSeconds: 0}},
+		{name: "venice", lon: 12.33, expected: models.Timezone{Name: "Etc/GMT-1", UTCOffsetSeconds: 3600}},
+		{name: "new york", lon: -74.006, expected: models.Timezone{Name: "Etc/GMT+5", UTCOffsetSeconds: -5 * 3600}},
+		{name: "antimeridian", lon: 180, expected: models.Timezone{Name: "Etc/GMT-12", UTCOffsetSeconds: 12 * 3600}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Estimate(tt.lon))
+		})
+	}
+}
+
+func TestResolver_PrefersReportedName(t *testing.T) {
+	r := NewResolver()
+	r.now = func() time.Time { return time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC) }
+
+	tz := r.Resolve(45.44, 12.33, []models.Timezone{
+		{UTCOffsetSeconds: 3600},
+		{Name: "Europe/Rome", UTCOffsetSeconds: 7200},
+	})
+
+	assert.Equal(t, models.Timezone{Name: "Europe/Rome", UTCOffsetSeconds: 7200}, tz)
+}
+
+func TestResolver_FallsBackToEstimate(t *testing.T) {
+	r := NewResolver()
+
+	tz := r.Resolve(40.7128, -74.006, nil)
+
+	assert.Equal(t, Estimate(-74.006), tz)
+}
+
+func TestResolver_ReportedOffset(t *testing.T) {
+	r := NewResolver()
+
+	// OpenWeatherMap only reports an offset, it wins over the estimate from the longitude, UTC-5
+	tz := r.Resolve(40.7128, -74.006, []models.Timezone{{UTCOffsetSeconds: -4 * 3600}, {Name: "Not/AZone"}})
+	assert.Equal(t, models.Timezone{Name: "UTC-04:00", UTCOffsetSeconds: -4 * 3600}, tz)
+	assert.Equal(t, models.Timezone{Name: "UTC+05:30", UTCOffsetSeconds: 19800},
+		r.Resolve(28.61, 77.21, []models.Timezone{{UTCOffsetSeconds: 19800}}))
+
+	// The offset is not cached, a name reported later wins
+	_, ok := r.Cached(40.7128, -74.006)
+	assert.False(t, ok)
+	assert.Equal(t, "America/New_York", r.Resolve(40.7128, -74.006, []models.Timezone{{Name: "America/New_York"}}).Name)
+	loc, ok := r.Cached(40.7128, -74.006)
+	assert.True(t, ok)
+	assert.Equal(t, "America/New_York", loc.String())
+}
+
+func TestResolver_CachesPerCoordinate(t *testing.T) {
+	r := NewResolver()
+
+	first := r.Resolve(45.44, 12.33, []models.Timezone{{Name: "Europe/Rome"}})
+	second := r.Resolve(45.4401, 12.3299, []models.Timezone{{Name: "Asia/Tokyo"}})
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, "Europe/Rome", second.Name)
+}
+
+func TestResolver_DoesNotCacheEstimate(t *testing.T) {
+	r := NewResolver()
+
+	assert.Equal(t, Estimate(12.33), r.Resolve(45.44, 12.33, nil))
+
+	// The location is resolved as soon as a provider reports its name
+	tz := r.Resolve(45.44, 12.33, []models.Timezone{{Name: "Europe/Rome"}})
+	assert.Equal(t, "Europe/Rome", tz.Name)
+}
+
+func TestResolver_OffsetFollowsDST(t *testing.T) {
+	r := NewResolver()
+	now := time.Date(2025, 3, 29, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	winter := r.Resolve(45.44, 12.33, []models.Timezone{{Name: "Europe/Rome"}})
+	assert.Equal(t, 3600, winter.UTCOffsetSeconds)
+
+	// The cached name is resolved to the summer offset the day after the change
+	now = now.Add(24 * time.Hour)
+	summer := r.Resolve(45.44, 12.33, nil)
+	assert.Equal(t, models.Timezone{Name: "Europe/Rome", UTCOffsetSeconds: 7200}, summer)
+}
+
+func TestResolver_Bounded(t *testing.T) {
+	r := NewResolver()
+	r.maxEntries = 2
+
+	r.Resolve(45.44, 12.33, []models.Timezone{{Name: "Europe/Rome"}})
+	r.Resolve(35.68, 139.69, []models.Timezone{{Name: "Asia/Tokyo"}})
+	r.Resolve(40.71, -74.01, []models.Timezone{{Name: "America/New_York"}})
+
+	assert.Equal(t, 2, r.order.Len())
+	// Venice was the least recently used, it was evicted
+	assert.Equal(t, Estimate(12.33), r.Resolve(45.44, 12.33, nil))
+	assert.Equal(t, "Asia/Tokyo", r.Resolve(35.68, 139.69, nil).Name)
+}
+
+func TestResolver_Expires(t *testing.T) {
+	r := NewResolver()
+	now := time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)
+	r.now = func() time.Time { return now }
+
+	r.Resolve(45.44, 12.33, []models.Timezone{{Name: "Europe/Rome"}})
+
+	now = now.Add(cacheTTL + time.Second)
+	assert.Equal(t, Estimate(12.33), r.Resolve(45.44, 12.33, nil))
+}
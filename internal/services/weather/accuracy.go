@@ -0,0 +1,242 @@
+package weather
+
+import (
+	"context"
+	"math"
+	"slices"
+	"time"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/timezone"
+	"weather-api/pkg/logger"
+)
+
+// defaultAccuracyWindowDays is the number of past days scored and averaged unless set by WithAccuracyScoring
+const defaultAccuracyWindowDays = 30
+
+// WithAccuracyScoring scores the stored forecasts every interval against the weather observed on the last
+// windowDays days, see ScoreAccuracy. It needs a forecast store keeping the errors, zero interval schedules
+// nothing and zero windowDays keeps the default of 30 days.
+func WithAccuracyScoring(interval time.Duration, windowDays int) Option {
+	return func(s *WeatherService) {
+		s.accuracyInterval = interval
+		if windowDays > 0 {
+			s.accuracyWindowDays = windowDays
+		}
+	}
+}
+
+// accuracyStore returns the store of the forecast errors, false when the forecast store doesn't keep them
+func (s *WeatherService) accuracyStore() (repositories.AccuracyStore, bool) {
+	if s.store == nil {
+		return nil, false
+	}
+	store, ok := s.store.store.(repositories.AccuracyStore)
+
+	return store, ok
+}
+
+// scoreAccuracyEvery runs ScoreAccuracy every interval until the shutdown
+func (s *WeatherService) scoreAccuracyEvery(interval time.Duration) {
+	ctx, end := s.begin(context.Background())
+	go func() {
+		defer end()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case now := <-ticker.C:
+				if _, err := s.ScoreAccuracy(ctx, now); err != nil && ctx.Err() == nil {
+					s.l.Error(err, map[string]any{"job": "accuracy"})
+				}
+			}
+		}
+	}()
+}
+
+// ScoreAccuracy compares the stored forecasts of the days of the window not scored yet with the weather
+// observed by the archive and records their errors, by provider, canary member, location, day and lead time. The
+// lead time is the number of days between the local day the forecast was fetched, in the timezone of the
+// location, and the day forecast. A day missing from the archive, not observed yet, is scored by a later run. It
+// returns the number of errors recorded.
+func (s *WeatherService) ScoreAccuracy(ctx context.Context, now time.Time) (int, error) {
+	store, ok := s.accuracyStore()
+	if !ok {
+		return 0, ErrNoForecastStore
+	}
+
+	// The archive is asked for the days of the window at once
+	window := min(s.accuracyWindowDays, s.historyMaxDays)
+	today := models.NewDate(now.UTC())
+	start := models.Date{Time: today.AddDate(0, 0, -window)}
+	end := models.Date{Time: today.AddDate(0, 0, -1)}
+	forecasts, err := store.Unscored(ctx, start, end)
+	if err != nil {
+		return 0, err
+	}
+
+	var scored []models.ForecastError
+	for _, location := range groupByLocation(forecasts) {
+		errs, err := s.scoreLocation(ctx, location)
+		if err != nil {
+			return 0, err
+		}
+		scored = append(scored, errs...)
+	}
+	if len(scored) == 0 {
+		return 0, nil
+	}
+	if err := store.SaveErrors(ctx, scored); err != nil {
+		return 0, err
+	}
+
+	logger.FromContext(ctx, s.l).Info("forecast accuracy scored", map[string]any{
+		"forecasts": len(forecasts),
+		"errors":    len(scored),
+		"start":     start.String(),
+		"end":       end.String(),
+	})
+
+	return len(scored), nil
+}
+
+// groupByLocation splits the forecasts, ordered by location, into the forecasts of every location
+func groupByLocation(forecasts []models.StoredForecast) [][]models.StoredForecast {
+	var groups [][]models.StoredForecast
+	for i := 0; i < len(forecasts); {
+		j := i + 1
+		for j < len(forecasts) && forecasts[j].Lat == forecasts[i].Lat && forecasts[j].Lon == forecasts[i].Lon {
+			j++
+		}
+		groups = append(groups, forecasts[i:j])
+		i = j
+	}
+
+	return groups
+}
+
+// errorKey identifies the error of a provider, or of one of its canary members, for a day and a lead time
+type errorKey struct {
+	provider string
+	variant  string
+	date     string
+	lead     int
+}
+
+// scoreLocation computes the errors of the forecasts of a location against its observed weather, the errors of
+// the forecasts fetched on the same local day are averaged
+func (s *WeatherService) scoreLocation(ctx context.Context, forecasts []models.StoredForecast) ([]models.ForecastError, error) {
+	lat, lon := forecasts[0].Lat, forecasts[0].Lon
+	first := slices.MinFunc(forecasts, func(a, b models.StoredForecast) int { return a.Date.Compare(b.Date.Time) })
+	last := slices.MaxFunc(forecasts, func(a, b models.StoredForecast) int { return a.Date.Compare(b.Date.Time) })
+
+	history, err := s.FetchHistory(ctx, lat, lon, first.Date, last.Date)
+	if err != nil {
+		return nil, err
+	}
+	observed, tz, ok := observations(history)
+	if !ok {
+		return nil, nil
+	}
+	if tz == nil {
+		estimate := timezone.Estimate(lon)
+		tz = &estimate
+	}
+	loc := location(*tz)
+
+	sums := make(map[errorKey]*models.ForecastError)
+	var keys []errorKey
+	for _, f := range forecasts {
+		day, ok := observed[f.Date.String()]
+		if !ok {
+			continue
+		}
+		lead := int(math.Round(f.Date.Sub(models.NewDate(f.FetchedAt.In(loc)).Time).Hours() / 24))
+		if lead < 0 {
+			continue
+		}
+
+		key := errorKey{provider: f.Provider, variant: f.Variant, date: f.Date.String(), lead: lead}
+		sum, ok := sums[key]
+		if !ok {
+			sum = &models.ForecastError{Provider: f.Provider, Variant: f.Variant, Lat: lat, Lon: lon, Date: f.Date, LeadDays: lead}
+			sums[key] = sum
+			keys = append(keys, key)
+		}
+		sum.TempMinError += math.Abs(f.TempMin - day.TempMin)
+		sum.TempMaxError += math.Abs(f.TempMax - day.TempMax)
+		sum.Samples++
+	}
+
+	errs := make([]models.ForecastError, len(keys))
+	for i, key := range keys {
+		e := *sums[key]
+		e.TempMinError /= float64(e.Samples)
+		e.TempMaxError /= float64(e.Samples)
+		errs[i] = e
+	}
+
+	return errs, nil
+}
+
+// observations returns the observed days of the first archive answering, by date, and its timezone
+func observations(history map[string]models.HistoricalWeather) (map[string]models.WeatherData, *models.Timezone, bool) {
+	names := make([]string, 0, len(history))
+	for name := range history {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		h := history[name]
+		if h.Error != "" {
+			continue
+		}
+		days := make(map[string]models.WeatherData, len(h.WeatherData))
+		for _, day := range h.WeatherData {
+			days[day.Date.String()] = day
+		}
+
+		return days, h.Timezone, true
+	}
+
+	return nil, nil, false
+}
+
+// location returns the time location of tz, a fixed offset when its name is unknown
+func location(tz models.Timezone) *time.Location {
+	if loc, err := time.LoadLocation(tz.Name); err == nil {
+		return loc
+	}
+
+	return time.FixedZone(tz.Name, tz.UTCOffsetSeconds)
+}
+
+// ProviderAccuracy returns the mean absolute errors of the providers at the location over the scoring window, by
+// lead time
+func (s *WeatherService) ProviderAccuracy(ctx context.Context, lat, lon float64) (models.AccuracyReport, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	store, ok := s.accuracyStore()
+	if !ok {
+		return models.AccuracyReport{}, ErrNoForecastStore
+	}
+
+	since := models.Date{Time: models.NewDate(time.Now().UTC()).AddDate(0, 0, -s.accuracyWindowDays)}
+	scores, err := store.Accuracy(ctx, lat, lon, since)
+	if err != nil {
+		return models.AccuracyReport{}, err
+	}
+
+	return models.AccuracyReport{
+		Lat:       roundCoordinate(lat),
+		Lon:       roundCoordinate(lon),
+		Since:     since,
+		Providers: scores,
+	}, nil
+}
@@ -0,0 +1,123 @@
+package weather_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// archiveRepository is a MockRepository whose archive observed days, in the timezone tz
+type archiveRepository struct {
+	MockRepository
+	days []models.WeatherData
+	tz   *models.Timezone
+}
+
+func (m *archiveRepository) FetchHistory(ctx context.Context, lat, lon float64, start, end models.Date) (models.HistoricalWeather, error) {
+	m.callCount++
+
+	history := models.HistoricalWeather{RepositoryName: m.name, Lat: lat, Lon: lon, Start: start, End: end, Timezone: m.tz}
+	for _, day := range m.days {
+		if !day.Date.Before(start.Time) && !day.Date.After(end.Time) {
+			history.WeatherData = append(history.WeatherData, day)
+		}
+	}
+
+	return history, nil
+}
+
+func TestWeatherService_ScoreAccuracy(t *testing.T) {
+	store, err := repositories.OpenSQLiteForecastStore(filepath.Join(t.TempDir(), "forecasts.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	ctx := context.Background()
+	now := time.Now().UTC()
+	today := models.NewDate(now)
+	day := models.Date{Time: today.AddDate(0, 0, -2)}
+	// Not observed yet
+	nextDay := models.Date{Time: today.AddDate(0, 0, -1)}
+
+	// In Tokyo, UTC+9, 20:00 UTC is the next local day
+	lat, lon := 35.6762, 139.6503
+	for _, f := range []models.Forecast{
+		{
+			RepositoryName: "open-meteo",
+			FetchMetadata:  models.FetchMetadata{FetchedAt: day.AddDate(0, 0, -2).Add(20 * time.Hour)},
+			ForecastData:   []models.WeatherData{{Date: day, TempMin: 20, TempMax: 30}, {Date: nextDay, TempMin: 21, TempMax: 31}},
+		},
+		// Fetched again on the same local day
+		{
+			RepositoryName: "open-meteo",
+			FetchMetadata:  models.FetchMetadata{FetchedAt: day.AddDate(0, 0, -1).Add(2 * time.Hour)},
+			ForecastData:   []models.WeatherData{{Date: day, TempMin: 21, TempMax: 31}},
+		},
+		{
+			RepositoryName: "weatherapi",
+			FetchMetadata:  models.FetchMetadata{FetchedAt: day.AddDate(0, 0, -3).Add(12 * time.Hour)},
+			ForecastData:   []models.WeatherData{{Date: day, TempMin: 18, TempMax: 33}},
+		},
+		// The canary member of weatherapi is scored apart
+		{
+			RepositoryName: "weatherapi",
+			Variant:        repositories.CanaryMemberCanary,
+			FetchMetadata:  models.FetchMetadata{FetchedAt: day.AddDate(0, 0, -3).Add(12 * time.Hour)},
+			ForecastData:   []models.WeatherData{{Date: day, TempMin: 20, TempMax: 32}},
+		},
+	} {
+		f.Lat, f.Lon = lat, lon
+		require.NoError(t, store.Save(ctx, f))
+	}
+
+	archive := &archiveRepository{
+		MockRepository: MockRepository{name: "open-meteo"},
+		days:           []models.WeatherData{{Date: day, TempMin: 20, TempMax: 32}},
+		tz:             &models.Timezone{Name: "Asia/Tokyo", UTCOffsetSeconds: 9 * 3600},
+	}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{archive}, logger.NopLogger{},
+		weather.WithForecastStore(store, 10))
+	defer service.Shutdown(ctx)
+
+	scored, err := service.ScoreAccuracy(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 3, scored)
+	assert.Equal(t, 1, archive.callCount, "a single archive request by location")
+
+	report, err := service.ProviderAccuracy(ctx, lat, lon)
+	require.NoError(t, err)
+	assert.Equal(t, models.Date{Time: today.AddDate(0, 0, -30)}, report.Since)
+	assert.Equal(t, []models.ProviderAccuracy{
+		{Provider: "open-meteo", LeadDays: 1, Days: 1, MAE: 1, MAETempMin: 0.5, MAETempMax: 1.5},
+		{Provider: "weatherapi", LeadDays: 3, Days: 1, MAE: 1.5, MAETempMin: 2, MAETempMax: 1},
+		{Provider: "weatherapi", Variant: repositories.CanaryMemberCanary, LeadDays: 3, Days: 1},
+	}, report.Providers)
+
+	// The scored days are not scored again, the day not observed yet is retried
+	scored, err = service.ScoreAccuracy(ctx, now)
+	require.NoError(t, err)
+	assert.Zero(t, scored)
+	assert.Equal(t, 2, archive.callCount)
+
+	archive.days = append(archive.days, models.WeatherData{Date: nextDay, TempMin: 22, TempMax: 31})
+	scored, err = service.ScoreAccuracy(ctx, now)
+	require.NoError(t, err)
+	assert.Equal(t, 1, scored)
+}
+
+func TestWeatherService_ScoreAccuracy_NoStore(t *testing.T) {
+	service := newCachedService(cachedRepository())
+
+	_, err := service.ScoreAccuracy(context.Background(), time.Now())
+	assert.ErrorIs(t, err, weather.ErrNoForecastStore)
+	_, err = service.ProviderAccuracy(context.Background(), 52.52, 13.41)
+	assert.ErrorIs(t, err, weather.ErrNoForecastStore)
+}
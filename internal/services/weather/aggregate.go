@@ -0,0 +1,356 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+
+	"weather-api/internal/models"
+)
+
+const (
+	StrategyMean   = "mean"
+	StrategyMedian = "median"
+	// StrategyWeightedMean weighs the providers with their configured weight
+	StrategyWeightedMean = "weighted_mean"
+	// StrategyExtremes takes the lowest min and the highest max reported by the providers
+	StrategyExtremes = "extremes"
+)
+
+const (
+	defaultOutlierMADs  = 3
+	defaultMinProviders = 1
+	// minOutlierProviders is the number of providers needed for a majority to reject an outlier
+	minOutlierProviders = 3
+	// minOutlierMAD floors the median absolute deviation, in °C
+	minOutlierMAD = 2.0
+	// defaultHighConfidenceSpread and defaultMediumConfidenceSpread are the largest spreads, in °C, of a day of
+	// high and medium confidence
+	defaultHighConfidenceSpread   = 2.0
+	defaultMediumConfidenceSpread = 5.0
+)
+
+// ErrNoForecasts is returned by AggregateForecasts and FetchFirstForecast when no provider returned a forecast
+var ErrNoForecasts = errors.New("no provider returned a forecast")
+
+// QuorumError is returned by AggregateForecasts when fewer providers than required returned usable data
+type QuorumError struct {
+	Required  int
+	Available int
+	Failures  []models.ProviderFailure
+}
+
+func (e *QuorumError) Error() string {
+	return fmt.Sprintf("%d providers returned usable data, at least %d are required", e.Available, e.Required)
+}
+
+// ParseStrategy validates an aggregation strategy name, an empty name selects the mean
+func ParseStrategy(strategy string) (string, error) {
+	switch strategy {
+	case "", StrategyMean:
+		return StrategyMean, nil
+	case StrategyMedian, StrategyWeightedMean, StrategyExtremes:
+		return strategy, nil
+	}
+
+	return "", fmt.Errorf("unsupported strategy: %s, expected %s, %s, %s or %s",
+		strategy, StrategyMean, StrategyMedian, StrategyWeightedMean, StrategyExtremes)
+}
+
+// AggregateForecasts fetches the forecasts from all available APIs and merges them into a single series,
+// providers that returned an error are skipped and days are aggregated from the providers reporting them.
+// Fewer than minProviders usable providers fail with a QuorumError and days reported by fewer providers
+// are omitted, zero selects the configured minimum.
+func (s *WeatherService) AggregateForecasts(ctx context.Context, lat, lon float64, forecastWindow int, strategy string, minProviders int) (models.AggregatedForecast, error) {
+	if minProviders <= 0 {
+		minProviders = s.minProviders
+	}
+
+	forecasts, err := s.FetchOrderedForecasts(ctx, lat, lon, forecastWindow)
+	if err != nil {
+		return models.AggregatedForecast{}, err
+	}
+
+	return s.aggregate(forecasts, lat, lon, forecastWindow, strategy, minProviders)
+}
+
+// dayValues collects the temperatures reported for a day, in provider order
+type dayValues struct {
+	date      models.Date
+	providers []string
+	weights   []float64
+	maxTemps  []float64
+	minTemps  []float64
+}
+
+func (d *dayValues) add(provider string, weight, tempMax, tempMin float64) {
+	d.providers = append(d.providers, provider)
+	d.weights = append(d.weights, weight)
+	d.maxTemps = append(d.maxTemps, tempMax)
+	d.minTemps = append(d.minTemps, tempMin)
+}
+
+func (s *WeatherService) aggregate(forecasts []models.Forecast, lat, lon float64, forecastWindow int, strategy string, minProviders int) (models.AggregatedForecast, error) {
+	result := models.AggregatedForecast{
+		Lat:            lat,
+		Lon:            lon,
+		ForecastWindow: forecastWindow,
+		Strategy:       strategy,
+		MinProviders:   minProviders,
+		Providers:      []string{},
+		ForecastData:   []models.AggregatedWeatherData{},
+	}
+
+	var failures []models.ProviderFailure
+	days := make(map[string]*dayValues)
+	for _, forecast := range forecasts {
+		if forecast.Error != "" {
+			failures = append(failures, models.ProviderFailure{
+				Provider:  forecast.RepositoryName,
+				Error:     forecast.Error,
+				ErrorCode: forecast.ErrorCode,
+			})
+			continue
+		}
+
+		usable := false
+		for _, wd := range forecast.ForecastData {
+			if wd.ExcludedFromAggregate || wd.Date.IsZero() {
+				continue
+			}
+
+			key := wd.Date.Format(models.DateLayout)
+			day, ok := days[key]
+			if !ok {
+				day = &dayValues{date: wd.Date}
+				days[key] = day
+			}
+			day.add(forecast.RepositoryName, s.weight(forecast.RepositoryName), wd.TempMax, wd.TempMin)
+			usable = true
+		}
+
+		if !usable {
+			failures = append(failures, models.ProviderFailure{
+				Provider:  forecast.RepositoryName,
+				Error:     "no usable forecast data",
+				ErrorCode: models.ErrorCodeNoData,
+			})
+			continue
+		}
+		if result.Timezone == nil {
+			result.Timezone = forecast.Timezone
+		}
+	}
+
+	usable := len(forecasts) - len(failures)
+	if usable == 0 {
+		return result, ErrNoForecasts
+	}
+	if usable < minProviders {
+		return result, &QuorumError{Required: minProviders, Available: usable, Failures: failures}
+	}
+
+	contributors := make(map[string]bool)
+	for _, day := range days {
+		kept, rejected := rejectOutliers(day, s.outlierMADs)
+		for _, provider := range rejected {
+			result.Rejected = append(result.Rejected, models.RejectedValue{Provider: provider, Date: day.date})
+		}
+
+		if len(kept.providers) < minProviders {
+			result.OmittedDays = append(result.OmittedDays, day.date)
+			continue
+		}
+		for _, provider := range kept.providers {
+			contributors[provider] = true
+		}
+
+		result.ForecastData = append(result.ForecastData, s.aggregateDay(kept, strategy))
+	}
+
+	for _, forecast := range forecasts {
+		if !contributors[forecast.RepositoryName] {
+			continue
+		}
+
+		result.Providers = append(result.Providers, forecast.RepositoryName)
+		if strategy == StrategyWeightedMean {
+			if result.Weights == nil {
+				result.Weights = make(map[string]float64)
+			}
+			result.Weights[forecast.RepositoryName] = s.weight(forecast.RepositoryName)
+		}
+	}
+
+	sort.Slice(result.ForecastData, func(i, j int) bool {
+		return result.ForecastData[i].Date.Before(result.ForecastData[j].Date.Time)
+	})
+	sort.SliceStable(result.Rejected, func(i, j int) bool {
+		return result.Rejected[i].Date.Before(result.Rejected[j].Date.Time)
+	})
+	sort.Slice(result.OmittedDays, func(i, j int) bool {
+		return result.OmittedDays[i].Before(result.OmittedDays[j].Time)
+	})
+
+	return result, nil
+}
+
+// weight returns the aggregation weight of a provider, 1 when it isn't configured
+func (s *WeatherService) weight(provider string) float64 {
+	if w, ok := s.current().weights[provider]; ok {
+		return w
+	}
+
+	return 1
+}
+
+// rejectOutliers drops the providers whose max or min temperature is more than mads median absolute
+// deviations away from the median of the day, it needs at least 3 providers to tell which one is off
+func rejectOutliers(day *dayValues, mads float64) (*dayValues, []string) {
+	if len(day.providers) < minOutlierProviders {
+		return day, nil
+	}
+
+	maxOutliers := outliers(day.maxTemps, mads)
+	minOutliers := outliers(day.minTemps, mads)
+
+	kept := &dayValues{date: day.date}
+	var rejected []string
+	for i, provider := range day.providers {
+		if maxOutliers[i] || minOutliers[i] {
+			rejected = append(rejected, provider)
+			continue
+		}
+		kept.add(provider, day.weights[i], day.maxTemps[i], day.minTemps[i])
+	}
+
+	return kept, rejected
+}
+
+// outliers flags the values further than mads median absolute deviations from the median,
+// the deviation is floored so that agreeing providers don't turn rounding differences into outliers
+func outliers(values []float64, mads float64) []bool {
+	m := median(values)
+
+	deviations := make([]float64, len(values))
+	for i, v := range values {
+		deviations[i] = math.Abs(v - m)
+	}
+	mad := math.Max(median(deviations), minOutlierMAD)
+
+	flagged := make([]bool, len(values))
+	for i, d := range deviations {
+		flagged[i] = d > mads*mad
+	}
+
+	return flagged
+}
+
+func (s *WeatherService) aggregateDay(day *dayValues, strategy string) models.AggregatedWeatherData {
+	wd := models.AggregatedWeatherData{
+		Date:          day.date,
+		Spread:        roundTemp(math.Max(spread(day.maxTemps), spread(day.minTemps))),
+		TempMaxSpread: temperatureSpread(day.maxTemps),
+		TempMinSpread: temperatureSpread(day.minTemps),
+		ProviderCount: len(day.maxTemps),
+	}
+	wd.Confidence = s.confidence(wd.Spread, wd.ProviderCount)
+
+	switch strategy {
+	case StrategyMedian:
+		wd.TempMax = median(day.maxTemps)
+		wd.TempMin = median(day.minTemps)
+	case StrategyWeightedMean:
+		wd.TempMax = weightedMean(day.maxTemps, day.weights)
+		wd.TempMin = weightedMean(day.minTemps, day.weights)
+	case StrategyExtremes:
+		wd.TempMax = slices.Max(day.maxTemps)
+		wd.TempMin = slices.Min(day.minTemps)
+	default:
+		wd.TempMax = mean(day.maxTemps)
+		wd.TempMin = mean(day.minTemps)
+	}
+
+	wd.TempMax = roundTemp(wd.TempMax)
+	wd.TempMin = roundTemp(wd.TempMin)
+
+	return wd
+}
+
+func mean(values []float64) float64 {
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+
+	return sum / float64(len(values))
+}
+
+func weightedMean(values, weights []float64) float64 {
+	var sum, total float64
+	for i, v := range values {
+		sum += v * weights[i]
+		total += weights[i]
+	}
+
+	return sum / total
+}
+
+func median(values []float64) float64 {
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+
+	return sorted[mid]
+}
+
+func spread(values []float64) float64 {
+	return slices.Max(values) - slices.Min(values)
+}
+
+// stdDev returns the population standard deviation of the values
+func stdDev(values []float64) float64 {
+	m := mean(values)
+
+	var sum float64
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+// temperatureSpread returns the range and the standard deviation of the temperatures of the providers
+func temperatureSpread(values []float64) models.TemperatureSpread {
+	return models.TemperatureSpread{
+		Min:    slices.Min(values),
+		Max:    slices.Max(values),
+		StdDev: roundTemp(stdDev(values)),
+	}
+}
+
+// confidence labels the agreement of the providers on a day from its spread, a single provider can't tell
+func (s *WeatherService) confidence(spread float64, providers int) string {
+	switch {
+	case providers < 2:
+		return models.ConfidenceUnknown
+	case spread <= s.highConfidenceSpread:
+		return models.ConfidenceHigh
+	case spread <= s.mediumConfidenceSpread:
+		return models.ConfidenceMedium
+	}
+
+	return models.ConfidenceLow
+}
+
+// roundTemp rounds an aggregated temperature to one decimal, as the providers report them
+func roundTemp(v float64) float64 {
+	return math.Round(v*10) / 10
+}
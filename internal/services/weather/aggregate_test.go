@@ -0,0 +1,346 @@
+package weather_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+func day(offset int, tempMax, tempMin float64) models.WeatherData {
+	return models.WeatherData{
+		Date:    models.NewDate(time.Date(2025, 7, 25+offset, 0, 0, 0, 0, time.UTC)),
+		TempMax: tempMax,
+		TempMin: tempMin,
+	}
+}
+
+func newAggregateService(repos ...repositories.WeatherRepository) *weather.WeatherService {
+	return weather.NewWeatherService(repos, logger.NopLogger{})
+}
+
+func TestParseStrategy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", weather.StrategyMean, false},
+		{"mean", weather.StrategyMean, false},
+		{"median", weather.StrategyMedian, false},
+		{"weighted_mean", weather.StrategyWeightedMean, false},
+		{"extremes", weather.StrategyExtremes, false},
+		{"mode", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := weather.ParseStrategy(tt.in)
+		if tt.wantErr {
+			assert.Error(t, err, tt.in)
+			continue
+		}
+		assert.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got)
+	}
+}
+
+func TestWeatherService_AggregateForecasts_Strategies(t *testing.T) {
+	service := newAggregateService(
+		&MockRepository{name: "repo-1", forecastData: models.Forecast{RepositoryName: "repo-1", ForecastData: []models.WeatherData{day(0, 30, 20)}}},
+		&MockRepository{name: "repo-2", forecastData: models.Forecast{RepositoryName: "repo-2", ForecastData: []models.WeatherData{day(0, 31, 18)}}},
+		&MockRepository{name: "repo-3", forecastData: models.Forecast{RepositoryName: "repo-3", ForecastData: []models.WeatherData{day(0, 35, 19)}}},
+	)
+
+	tests := []struct {
+		strategy string
+		wantMax  float64
+		wantMin  float64
+	}{
+		{weather.StrategyMean, 32, 19},
+		{weather.StrategyMedian, 31, 19},
+		{weather.StrategyExtremes, 35, 18},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			aggregated, err := service.AggregateForecasts(context.Background(), 40.7128, -74.0060, 1, tt.strategy, 0)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.strategy, aggregated.Strategy)
+			assert.Equal(t, []string{"repo-1", "repo-2", "repo-3"}, aggregated.Providers)
+			require.Len(t, aggregated.ForecastData, 1)
+			assert.Equal(t, tt.wantMax, aggregated.ForecastData[0].TempMax)
+			assert.Equal(t, tt.wantMin, aggregated.ForecastData[0].TempMin)
+			assert.Equal(t, 5.0, aggregated.ForecastData[0].Spread)
+			assert.Equal(t, 3, aggregated.ForecastData[0].ProviderCount)
+		})
+	}
+}
+
+func TestWeatherService_AggregateForecasts_DifferentDayCounts(t *testing.T) {
+	service := newAggregateService(
+		&MockRepository{name: "short", forecastData: models.Forecast{RepositoryName: "short", ForecastData: []models.WeatherData{day(0, 30, 20)}}},
+		&MockRepository{name: "long", forecastData: models.Forecast{RepositoryName: "long", ForecastData: []models.WeatherData{day(1, 28, 17), day(0, 32, 22)}}},
+	)
+
+	aggregated, err := service.AggregateForecasts(context.Background(), 40.7128, -74.0060, 2, weather.StrategyMean, 0)
+	require.NoError(t, err)
+
+	require.Len(t, aggregated.ForecastData, 2)
+	assert.Equal(t, "2025-07-25", aggregated.ForecastData[0].Date.Format(models.DateLayout))
+	assert.Equal(t, 31.0, aggregated.ForecastData[0].TempMax)
+	assert.Equal(t, 2, aggregated.ForecastData[0].ProviderCount)
+	assert.Equal(t, "2025-07-26", aggregated.ForecastData[1].Date.Format(models.DateLayout))
+	assert.Equal(t, 28.0, aggregated.ForecastData[1].TempMax)
+	assert.Equal(t, 0.0, aggregated.ForecastData[1].Spread)
+	assert.Equal(t, 1, aggregated.ForecastData[1].ProviderCount)
+}
+
+func TestWeatherService_AggregateForecasts_SingleSurvivingProvider(t *testing.T) {
+	excluded := day(1, 60, 50)
+	excluded.ExcludedFromAggregate = true
+
+	service := newAggregateService(
+		&MockRepository{name: "failure-repo", shouldFail: true},
+		&MockRepository{name: "success-repo", forecastData: models.Forecast{RepositoryName: "success-repo", ForecastData: []models.WeatherData{day(0, 30.25, 20), excluded}}},
+	)
+
+	aggregated, err := service.AggregateForecasts(context.Background(), 40.7128, -74.0060, 2, weather.StrategyMedian, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"success-repo"}, aggregated.Providers)
+	require.Len(t, aggregated.ForecastData, 1)
+	assert.Equal(t, 30.3, aggregated.ForecastData[0].TempMax)
+	assert.Equal(t, 20.0, aggregated.ForecastData[0].TempMin)
+	assert.Equal(t, 0.0, aggregated.ForecastData[0].Spread)
+	require.NotNil(t, aggregated.Timezone)
+}
+
+func TestWeatherService_AggregateForecasts_AllFailures(t *testing.T) {
+	service := newAggregateService(
+		&MockRepository{name: "failure-repo-1", shouldFail: true},
+		&MockRepository{name: "failure-repo-2", shouldFail: true},
+	)
+
+	_, err := service.AggregateForecasts(context.Background(), 40.7128, -74.0060, 1, weather.StrategyMean, 0)
+	assert.ErrorIs(t, err, weather.ErrNoForecasts)
+}
+
+func TestWeatherService_AggregateForecasts_OutlierRejection(t *testing.T) {
+	tests := []struct {
+		name         string
+		mads         float64
+		temps        [][2]float64 // max, min per provider
+		wantRejected []string
+		wantMax      float64
+		wantMin      float64
+	}{
+		{
+			name:         "unit bug on the max",
+			temps:        [][2]float64{{30, 20}, {31, 19}, {85, 20}},
+			wantRejected: []string{"repo-3"},
+			wantMax:      30.5,
+			wantMin:      19.5,
+		},
+		{
+			name:         "broken min",
+			temps:        [][2]float64{{30, -40}, {31, 19}, {30, 20}, {29, 20}},
+			wantRejected: []string{"repo-1"},
+			wantMax:      30,
+			wantMin:      19.7,
+		},
+		{
+			name:    "agreeing providers are kept",
+			temps:   [][2]float64{{30, 20}, {30, 20}, {31.5, 21}},
+			wantMax: 30.5,
+			wantMin: 20.3,
+		},
+		{
+			name:    "two providers never reject",
+			temps:   [][2]float64{{30, 20}, {85, 20}},
+			wantMax: 57.5,
+			wantMin: 20,
+		},
+		{
+			name:         "tighter threshold",
+			mads:         1,
+			temps:        [][2]float64{{30, 20}, {30, 20}, {33, 20}},
+			wantRejected: []string{"repo-3"},
+			wantMax:      30,
+			wantMin:      20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var repos []repositories.WeatherRepository
+			for i, temps := range tt.temps {
+				name := fmt.Sprintf("repo-%d", i+1)
+				repos = append(repos, &MockRepository{
+					name:         name,
+					forecastData: models.Forecast{RepositoryName: name, ForecastData: []models.WeatherData{day(0, temps[0], temps[1])}},
+				})
+			}
+			service := weather.NewWeatherService(repos, logger.NopLogger{}, weather.WithOutlierThreshold(tt.mads))
+
+			aggregated, err := service.AggregateForecasts(context.Background(), 40.7128, -74.0060, 1, weather.StrategyMean, 0)
+			require.NoError(t, err)
+
+			var rejected []string
+			for _, r := range aggregated.Rejected {
+				assert.Equal(t, "2025-07-25", r.Date.Format(models.DateLayout))
+				rejected = append(rejected, r.Provider)
+				assert.NotContains(t, aggregated.Providers, r.Provider)
+			}
+			assert.Equal(t, tt.wantRejected, rejected)
+
+			require.Len(t, aggregated.ForecastData, 1)
+			assert.Equal(t, tt.wantMax, aggregated.ForecastData[0].TempMax)
+			assert.Equal(t, tt.wantMin, aggregated.ForecastData[0].TempMin)
+			assert.Equal(t, len(tt.temps)-len(tt.wantRejected), aggregated.ForecastData[0].ProviderCount)
+		})
+	}
+}
+
+func TestWeatherService_AggregateForecasts_WeightedMean(t *testing.T) {
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "trusted", forecastData: models.Forecast{RepositoryName: "trusted", ForecastData: []models.WeatherData{day(0, 30, 20), day(1, 28, 18)}}},
+		&MockRepository{name: "unweighted", forecastData: models.Forecast{RepositoryName: "unweighted", ForecastData: []models.WeatherData{day(0, 32, 22)}}},
+		&MockRepository{name: "noisy", forecastData: models.Forecast{RepositoryName: "noisy", ForecastData: []models.WeatherData{day(0, 33, 21), day(1, 24, 14)}}},
+	}
+	service := weather.NewWeatherService(repos, logger.NopLogger{},
+		weather.WithWeights(map[string]float64{"trusted": 2.5, "noisy": 0.5}))
+
+	aggregated, err := service.AggregateForecasts(context.Background(), 40.7128, -74.0060, 2, weather.StrategyWeightedMean, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]float64{"trusted": 2.5, "unweighted": 1, "noisy": 0.5}, aggregated.Weights)
+	require.Len(t, aggregated.ForecastData, 2)
+	// (30*2.5 + 32*1 + 33*0.5) / 4 and (20*2.5 + 22*1 + 21*0.5) / 4
+	assert.Equal(t, 30.9, aggregated.ForecastData[0].TempMax)
+	assert.Equal(t, 20.6, aggregated.ForecastData[0].TempMin)
+	// (28*2.5 + 24*0.5) / 3 and (18*2.5 + 14*0.5) / 3
+	assert.Equal(t, 27.3, aggregated.ForecastData[1].TempMax)
+	assert.Equal(t, 17.3, aggregated.ForecastData[1].TempMin)
+
+	unweighted, err := service.AggregateForecasts(context.Background(), 40.7128, -74.0060, 2, weather.StrategyMean, 0)
+	require.NoError(t, err)
+	assert.Nil(t, unweighted.Weights)
+	assert.Equal(t, 31.7, unweighted.ForecastData[0].TempMax)
+}
+
+func TestWeatherService_AggregateForecasts_Quorum(t *testing.T) {
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "repo-1", forecastData: models.Forecast{RepositoryName: "repo-1", ForecastData: []models.WeatherData{day(0, 30, 20), day(1, 28, 18)}}},
+		&MockRepository{name: "failure-repo", err: context.DeadlineExceeded},
+		&MockRepository{name: "repo-2", forecastData: models.Forecast{RepositoryName: "repo-2", ForecastData: []models.WeatherData{day(0, 32, 22)}}},
+	}
+	service := weather.NewWeatherService(repos, logger.NopLogger{}, weather.WithMinProviders(2))
+
+	aggregated, err := service.AggregateForecasts(context.Background(), 40.7128, -74.0060, 2, weather.StrategyMean, 0)
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, aggregated.MinProviders)
+	assert.Equal(t, []string{"repo-1", "repo-2"}, aggregated.Providers)
+	require.Len(t, aggregated.ForecastData, 1)
+	assert.Equal(t, "2025-07-25", aggregated.ForecastData[0].Date.Format(models.DateLayout))
+	require.Len(t, aggregated.OmittedDays, 1)
+	assert.Equal(t, "2025-07-26", aggregated.OmittedDays[0].Format(models.DateLayout))
+
+	_, err = service.AggregateForecasts(context.Background(), 40.7128, -74.0060, 2, weather.StrategyMean, 3)
+	var quorumErr *weather.QuorumError
+	require.ErrorAs(t, err, &quorumErr)
+	assert.Equal(t, 3, quorumErr.Required)
+	assert.Equal(t, 2, quorumErr.Available)
+	assert.Equal(t, []models.ProviderFailure{
+		{Provider: "failure-repo", Error: "provider timed out", ErrorCode: models.ErrorCodeTimeout},
+	}, quorumErr.Failures)
+}
+
+func TestWeatherService_AggregateForecasts_Confidence(t *testing.T) {
+	type wantDay struct {
+		tempMax    models.TemperatureSpread
+		tempMin    models.TemperatureSpread
+		confidence string
+	}
+
+	tests := []struct {
+		name      string
+		providers [][]models.WeatherData
+		opts      []weather.Option
+		want      []wantDay
+	}{
+		{
+			name:      "agreeing providers",
+			providers: [][]models.WeatherData{{day(0, 30, 20)}, {day(0, 31, 20)}},
+			want:      []wantDay{{models.TemperatureSpread{Min: 30, Max: 31, StdDev: 0.5}, models.TemperatureSpread{Min: 20, Max: 20}, models.ConfidenceHigh}},
+		},
+		{
+			name:      "medium spread of the min temperature",
+			providers: [][]models.WeatherData{{day(0, 30, 17)}, {day(0, 30, 20)}},
+			want:      []wantDay{{models.TemperatureSpread{Min: 30, Max: 30}, models.TemperatureSpread{Min: 17, Max: 20, StdDev: 1.5}, models.ConfidenceMedium}},
+		},
+		{
+			name:      "disagreeing providers",
+			providers: [][]models.WeatherData{{day(0, 24, 15)}, {day(0, 32, 16)}},
+			want:      []wantDay{{models.TemperatureSpread{Min: 24, Max: 32, StdDev: 4}, models.TemperatureSpread{Min: 15, Max: 16, StdDev: 0.5}, models.ConfidenceLow}},
+		},
+		{
+			name:      "three providers",
+			providers: [][]models.WeatherData{{day(0, 30, 20)}, {day(0, 31, 21)}, {day(0, 32, 22)}},
+			want:      []wantDay{{models.TemperatureSpread{Min: 30, Max: 32, StdDev: 0.8}, models.TemperatureSpread{Min: 20, Max: 22, StdDev: 0.8}, models.ConfidenceHigh}},
+		},
+		{
+			name:      "single provider",
+			providers: [][]models.WeatherData{{day(0, 30, 20)}},
+			want:      []wantDay{{models.TemperatureSpread{Min: 30, Max: 30}, models.TemperatureSpread{Min: 20, Max: 20}, models.ConfidenceUnknown}},
+		},
+		{
+			name:      "day missing from a provider",
+			providers: [][]models.WeatherData{{day(0, 30, 20), day(1, 29, 19)}, {day(0, 30, 20)}},
+			want: []wantDay{
+				{models.TemperatureSpread{Min: 30, Max: 30}, models.TemperatureSpread{Min: 20, Max: 20}, models.ConfidenceHigh},
+				{models.TemperatureSpread{Min: 29, Max: 29}, models.TemperatureSpread{Min: 19, Max: 19}, models.ConfidenceUnknown},
+			},
+		},
+		{
+			name:      "configured thresholds",
+			providers: [][]models.WeatherData{{day(0, 30, 20)}, {day(0, 31, 20)}, {day(1, 24, 15)}, {day(1, 32, 16)}},
+			opts:      []weather.Option{weather.WithConfidenceThresholds(0.5, 10)},
+			want: []wantDay{
+				{models.TemperatureSpread{Min: 30, Max: 31, StdDev: 0.5}, models.TemperatureSpread{Min: 20, Max: 20}, models.ConfidenceMedium},
+				{models.TemperatureSpread{Min: 24, Max: 32, StdDev: 4}, models.TemperatureSpread{Min: 15, Max: 16, StdDev: 0.5}, models.ConfidenceMedium},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var repos []repositories.WeatherRepository
+			for i, days := range tt.providers {
+				name := fmt.Sprintf("repo-%d", i+1)
+				repos = append(repos, &MockRepository{name: name, forecastData: models.Forecast{RepositoryName: name, ForecastData: days}})
+			}
+			service := weather.NewWeatherService(repos, logger.NopLogger{}, tt.opts...)
+
+			aggregated, err := service.AggregateForecasts(context.Background(), 40.7128, -74.0060, 2, weather.StrategyMean, 0)
+			require.NoError(t, err)
+
+			require.Len(t, aggregated.ForecastData, len(tt.want))
+			for i, want := range tt.want {
+				got := aggregated.ForecastData[i]
+				assert.Equal(t, want.tempMax, got.TempMaxSpread, "temp_max_spread of day %d", i)
+				assert.Equal(t, want.tempMin, got.TempMinSpread, "temp_min_spread of day %d", i)
+				assert.Equal(t, want.confidence, got.Confidence, "confidence of day %d", i)
+			}
+		})
+	}
+}
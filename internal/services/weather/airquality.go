@@ -0,0 +1,74 @@
+package weather
+
+import (
+	"context"
+	"errors"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+// ErrNoAirQualityProvider is returned by FetchAirQuality when no air quality provider is configured
+var ErrNoAirQualityProvider = errors.New("no air quality provider configured")
+
+// WithAirQuality serves the air quality forecasts from provider
+func WithAirQuality(provider repositories.AirQualityProvider) Option {
+	return func(s *WeatherService) {
+		s.airQuality = provider
+	}
+}
+
+// FetchAirQuality fetches the daily air quality forecast, a provider failure is reported in the
+// Error of the result like FetchForecasts, a canceled or expired request context fails the request
+func (s *WeatherService) FetchAirQuality(ctx context.Context, lat, lon float64, days int) (models.AirQuality, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	l := logger.FromContext(ctx, s.l)
+	if s.airQuality == nil {
+		return models.AirQuality{}, ErrNoAirQualityProvider
+	}
+
+	requestID := requestid.FromContext(ctx)
+	name := s.airQuality.Name()
+
+	l.Info("starting air quality fetch", map[string]any{
+		"request_id": requestID,
+		"lat":        lat,
+		"lon":        lon,
+		"days":       days,
+	})
+
+	var airQuality models.AirQuality
+	err := s.callProvider(ctx, name, func(ctx context.Context) (err error) {
+		airQuality, err = s.airQuality.FetchAirQuality(ctx, lat, lon, days)
+		return err
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		l.Warning("air quality fetch aborted", map[string]any{"request_id": requestID, "err": ctxErr.Error()})
+		return models.AirQuality{}, ctxErr
+	}
+	if err != nil {
+		code, message := classifyError(err)
+		s.logProviderError(l, name, err, map[string]any{"request_id": requestID, "err": err, "error_code": code})
+
+		return models.AirQuality{
+			RepositoryName: name,
+			Lat:            lat,
+			Lon:            lon,
+			ForecastWindow: days,
+			Error:          message,
+			ErrorCode:      code,
+			AirQualityData: []models.AirQualityData{},
+		}, nil
+	}
+
+	l.Info("completed air quality fetch", map[string]any{
+		"request_id": requestID,
+		"days":       len(airQuality.AirQualityData),
+	})
+
+	return airQuality, nil
+}
@@ -0,0 +1,87 @@
+package weather_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// mockAirQualityProvider returns the requested location, or err
+type mockAirQualityProvider struct {
+	err   error
+	delay time.Duration
+}
+
+func (m *mockAirQualityProvider) Name() string {
+	return "air"
+}
+
+func (m *mockAirQualityProvider) FetchAirQuality(ctx context.Context, lat, lon float64, days int) (models.AirQuality, error) {
+	select {
+	case <-time.After(m.delay):
+	case <-ctx.Done():
+		return models.AirQuality{}, ctx.Err()
+	}
+	if m.err != nil {
+		return models.AirQuality{}, m.err
+	}
+
+	return models.AirQuality{RepositoryName: m.Name(), Lat: lat, Lon: lon, ForecastWindow: days}, nil
+}
+
+func TestWeatherService_FetchAirQuality(t *testing.T) {
+	service := weather.NewWeatherService(nil, logger.NopLogger{},
+		weather.WithAirQuality(&mockAirQualityProvider{}))
+
+	result, err := service.FetchAirQuality(context.Background(), 52.52, 13.41, 3)
+	require.NoError(t, err)
+	assert.Equal(t, models.AirQuality{RepositoryName: "air", Lat: 52.52, Lon: 13.41, ForecastWindow: 3}, result)
+}
+
+func TestWeatherService_FetchAirQuality_NotConfigured(t *testing.T) {
+	service := weather.NewWeatherService(nil, logger.NopLogger{})
+
+	_, err := service.FetchAirQuality(context.Background(), 52.52, 13.41, 3)
+	assert.ErrorIs(t, err, weather.ErrNoAirQualityProvider)
+}
+
+func TestWeatherService_FetchAirQuality_ProviderFailure(t *testing.T) {
+	service := weather.NewWeatherService(nil, logger.NopLogger{},
+		weather.WithAirQuality(&mockAirQualityProvider{err: &repositories.HTTPStatusError{StatusCode: 500, Status: "500 Internal Server Error"}}))
+
+	result, err := service.FetchAirQuality(context.Background(), 52.52, 13.41, 3)
+	require.NoError(t, err)
+	assert.Equal(t, models.ErrorCodeUpstreamHTTP, result.ErrorCode)
+	assert.Equal(t, "provider returned HTTP 500", result.Error)
+	assert.Equal(t, "air", result.RepositoryName)
+}
+
+func TestWeatherService_FetchAirQuality_ProviderTimeout(t *testing.T) {
+	service := weather.NewWeatherService(nil, logger.NopLogger{},
+		weather.WithAirQuality(&mockAirQualityProvider{delay: time.Second}),
+		weather.WithProviderTimeouts(map[string]time.Duration{"air": 10 * time.Millisecond}))
+
+	result, err := service.FetchAirQuality(context.Background(), 52.52, 13.41, 3)
+	require.NoError(t, err)
+	assert.Equal(t, models.ErrorCodeTimeout, result.ErrorCode)
+}
+
+func TestWeatherService_FetchAirQuality_Canceled(t *testing.T) {
+	service := weather.NewWeatherService(nil, logger.NopLogger{},
+		weather.WithAirQuality(&mockAirQualityProvider{delay: time.Second}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := service.FetchAirQuality(ctx, 52.52, 13.41, 3)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+}
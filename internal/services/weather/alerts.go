@@ -0,0 +1,175 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+var (
+	// ErrNoAlertProviders is returned by FetchAlerts when no configured provider exposes alerts
+	ErrNoAlertProviders = errors.New("no configured provider supports weather alerts")
+	// ErrAlertsFailed is returned by FetchAlerts when every provider exposing alerts failed
+	ErrAlertsFailed = errors.New("every alert provider failed")
+)
+
+// WithAlertSources adds alert providers serving no forecasts to the providers exposing alerts
+func WithAlertSources(sources ...repositories.AlertSource) Option {
+	return func(s *WeatherService) {
+		s.alertSources = append(s.alertSources, sources...)
+	}
+}
+
+// alertSource adapts a weather provider to an alert source, providers without alerts return ErrUnsupported
+type alertSource struct {
+	repositories.WeatherRepository
+}
+
+func (a alertSource) FetchAlerts(ctx context.Context, lat, lon float64) ([]models.Alert, error) {
+	return repositories.FetchAlerts(ctx, a.WeatherRepository, lat, lon)
+}
+
+// alertResult is the answer of an alert source
+type alertResult struct {
+	index  int
+	alerts []models.Alert
+	err    error
+}
+
+// FetchAlerts fetches the active alerts from the providers exposing them and the alert sources, the providers
+// without alerts are skipped. Alerts of the same event active at a common time are reported once, the
+// most severe first. The report lists the failed providers, ErrAlertsFailed is returned when all failed.
+func (s *WeatherService) FetchAlerts(ctx context.Context, lat, lon float64) (models.AlertReport, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	l := logger.FromContext(ctx, s.l)
+	requestID := requestid.FromContext(ctx)
+
+	var sources []repositories.AlertSource
+	for _, repo := range s.current().repos {
+		if _, ok := repositories.As[repositories.AlertProvider](repo); ok {
+			sources = append(sources, alertSource{repo})
+		}
+	}
+	sources = append(sources, s.alertSources...)
+
+	report := models.AlertReport{Lat: lat, Lon: lon, Providers: []string{}, Alerts: []models.Alert{}}
+	if len(sources) == 0 {
+		return report, ErrNoAlertProviders
+	}
+
+	l.Info("starting alerts fetch", map[string]any{
+		"request_id": requestID,
+		"lat":        lat,
+		"lon":        lon,
+		"sources":    len(sources),
+	})
+
+	results := make([]alertResult, len(sources))
+	resultsChan := make(chan alertResult)
+	var wg sync.WaitGroup
+
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source repositories.AlertSource) {
+			defer wg.Done()
+
+			var alerts []models.Alert
+			err := s.callProvider(ctx, source.Name(), func(ctx context.Context) (err error) {
+				alerts, err = source.FetchAlerts(ctx, lat, lon)
+				return err
+			})
+
+			resultsChan <- alertResult{index: i, alerts: alerts, err: err}
+		}(i, source)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+
+	for result := range resultsChan {
+		results[result.index] = result
+	}
+
+	if err := ctx.Err(); err != nil {
+		l.Warning("alerts fetch aborted", map[string]any{"request_id": requestID, "err": err.Error()})
+		return report, err
+	}
+
+	var alerts []models.Alert
+	supported := 0
+	for i, result := range results {
+		name := sources[i].Name()
+		if errors.Is(result.err, repositories.ErrUnsupported) {
+			continue
+		}
+		supported++
+
+		if result.err != nil {
+			code, message := classifyError(result.err)
+			s.logProviderError(l, name, result.err, map[string]any{"request_id": requestID, "err": result.err, "error_code": code})
+
+			report.Failures = append(report.Failures, models.ProviderFailure{Provider: name, Error: message, ErrorCode: code})
+			continue
+		}
+
+		report.Providers = append(report.Providers, name)
+		alerts = append(alerts, result.alerts...)
+	}
+
+	if supported == 0 {
+		return report, ErrNoAlertProviders
+	}
+	if len(report.Providers) == 0 {
+		return report, ErrAlertsFailed
+	}
+
+	report.Alerts = dedupeAlerts(alerts)
+
+	l.Info("completed alerts fetch", map[string]any{
+		"request_id": requestID,
+		"alerts":     len(report.Alerts),
+	})
+
+	return report, nil
+}
+
+// dedupeAlerts merges the alerts of the same event active at a common time into the most severe of them,
+// the result is sorted by decreasing severity then onset
+func dedupeAlerts(alerts []models.Alert) []models.Alert {
+	sort.SliceStable(alerts, func(i, j int) bool {
+		ri, rj := models.SeverityRank(alerts[i].Severity), models.SeverityRank(alerts[j].Severity)
+		if ri != rj {
+			return ri > rj
+		}
+		return alerts[i].Onset.Before(alerts[j].Onset)
+	})
+
+	kept := make([]models.Alert, 0, len(alerts))
+	for _, alert := range alerts {
+		i := slices.IndexFunc(kept, func(k models.Alert) bool {
+			return strings.EqualFold(strings.TrimSpace(k.Event), strings.TrimSpace(alert.Event)) && k.Overlaps(alert)
+		})
+		if i < 0 {
+			kept = append(kept, alert)
+			continue
+		}
+
+		if alert.Source != kept[i].Source && !slices.Contains(kept[i].AlsoReportedBy, alert.Source) {
+			kept[i].AlsoReportedBy = append(kept[i].AlsoReportedBy, alert.Source)
+		}
+	}
+
+	return kept
+}
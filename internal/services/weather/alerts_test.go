@@ -0,0 +1,129 @@
+package weather_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// MockAlertRepository is a MockRepository that also exposes alerts
+type MockAlertRepository struct {
+	MockRepository
+	alerts []models.Alert
+}
+
+func (m *MockAlertRepository) FetchAlerts(ctx context.Context, lat, lon float64) ([]models.Alert, error) {
+	m.callCount++
+
+	if m.shouldFail {
+		return nil, errors.New("mock repository error")
+	}
+
+	return m.alerts, nil
+}
+
+// mockAlertSource serves alerts without forecasts
+type mockAlertSource struct {
+	name   string
+	alerts []models.Alert
+}
+
+func (m *mockAlertSource) Name() string {
+	return m.name
+}
+
+func (m *mockAlertSource) FetchAlerts(ctx context.Context, lat, lon float64) ([]models.Alert, error) {
+	return m.alerts, nil
+}
+
+func alertAt(source, event, severity string, onsetHour, expiresHour int) models.Alert {
+	day := time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)
+
+	return models.Alert{
+		Source:   source,
+		Event:    event,
+		Severity: severity,
+		Onset:    day.Add(time.Duration(onsetHour) * time.Hour),
+		Expires:  day.Add(time.Duration(expiresHour) * time.Hour),
+	}
+}
+
+func TestWeatherService_FetchAlerts(t *testing.T) {
+	owm := &MockAlertRepository{
+		MockRepository: MockRepository{name: "weatherapi"},
+		alerts: []models.Alert{
+			alertAt("weatherapi", "heat advisory", models.AlertSeverityUnknown, 13, 21),
+			alertAt("weatherapi", "Flood Watch", models.AlertSeverityUnknown, 0, 6),
+		},
+	}
+	forecastOnly := &MockRepository{name: "forecast-only"}
+	nws := &mockAlertSource{name: "nws", alerts: []models.Alert{
+		alertAt("nws", "Heat Advisory", models.AlertSeverityModerate, 12, 20),
+		alertAt("nws", "Heat Advisory", models.AlertSeverityModerate, 36, 44),
+		alertAt("nws", "Severe Thunderstorm Warning", models.AlertSeveritySevere, 15, 16),
+	}}
+
+	service := weather.NewWeatherService([]repositories.WeatherRepository{owm, forecastOnly}, logger.NopLogger{},
+		weather.WithAlertSources(nws))
+
+	report, err := service.FetchAlerts(context.Background(), 40.71, -74.01)
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"weatherapi", "nws"}, report.Providers)
+	assert.Empty(t, report.Failures)
+	assert.Equal(t, 0, forecastOnly.callCount, "providers without alerts must be skipped")
+
+	want := []models.Alert{
+		alertAt("nws", "Severe Thunderstorm Warning", models.AlertSeveritySevere, 15, 16),
+		alertAt("nws", "Heat Advisory", models.AlertSeverityModerate, 12, 20),
+		alertAt("nws", "Heat Advisory", models.AlertSeverityModerate, 36, 44),
+		alertAt("weatherapi", "Flood Watch", models.AlertSeverityUnknown, 0, 6),
+	}
+	want[1].AlsoReportedBy = []string{"weatherapi"}
+	assert.Equal(t, want, report.Alerts)
+}
+
+func TestWeatherService_FetchAlerts_PartialFailure(t *testing.T) {
+	failing := &MockAlertRepository{MockRepository: MockRepository{name: "failing", shouldFail: true}}
+	nws := &mockAlertSource{name: "nws"}
+
+	service := weather.NewWeatherService([]repositories.WeatherRepository{failing}, logger.NopLogger{},
+		weather.WithAlertSources(nws))
+
+	report, err := service.FetchAlerts(context.Background(), 40.71, -74.01)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nws"}, report.Providers)
+	assert.Equal(t, []models.ProviderFailure{{Provider: "failing", Error: "provider error", ErrorCode: models.ErrorCodeUnknown}}, report.Failures)
+	assert.NotNil(t, report.Alerts)
+	assert.Empty(t, report.Alerts)
+}
+
+func TestWeatherService_FetchAlerts_AllFailed(t *testing.T) {
+	failing := &MockAlertRepository{MockRepository: MockRepository{name: "failing", shouldFail: true}}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{failing, &MockRepository{name: "forecast-only"}}, logger.NopLogger{})
+
+	report, err := service.FetchAlerts(context.Background(), 40.71, -74.01)
+	assert.ErrorIs(t, err, weather.ErrAlertsFailed)
+	assert.Len(t, report.Failures, 1)
+}
+
+func TestWeatherService_FetchAlerts_NoProviders(t *testing.T) {
+	forecastOnly := &MockRepository{name: "forecast-only"}
+	// A canary without alert members reports them unsupported
+	canary := repositories.NewCanaryRepository(&MockRepository{name: "primary"}, &MockRepository{name: "canary"}, 50, logger.NopLogger{})
+
+	service := weather.NewWeatherService([]repositories.WeatherRepository{forecastOnly, canary}, logger.NopLogger{})
+
+	_, err := service.FetchAlerts(context.Background(), 40.71, -74.01)
+	assert.ErrorIs(t, err, weather.ErrNoAlertProviders)
+	assert.Equal(t, 0, forecastOnly.callCount)
+}
@@ -0,0 +1,82 @@
+package weather
+
+import (
+	"context"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+const (
+	defaultBatchMaxItems    = 50
+	defaultBatchConcurrency = 4
+)
+
+// Location is a point of a batch forecast request
+type Location struct {
+	Lat            float64
+	Lon            float64
+	ForecastWindow int
+}
+
+// WithBatchLimits sets the largest number of locations of a batch and how many of them are fetched
+// concurrently, zero keeps the defaults of 50 locations, 4 at a time
+func WithBatchLimits(maxItems, concurrency int) Option {
+	return func(s *WeatherService) {
+		if maxItems > 0 {
+			s.batchMaxItems = maxItems
+		}
+		if concurrency > 0 {
+			s.batchConcurrency = concurrency
+		}
+	}
+}
+
+// BatchMaxItems returns the largest number of locations a batch may contain
+func (s *WeatherService) BatchMaxItems() int {
+	return s.batchMaxItems
+}
+
+// BatchRequestBudget returns the total time a batch of the given size should be given,
+// the locations are fetched in rounds of the batch concurrency
+func (s *WeatherService) BatchRequestBudget(items int) time.Duration {
+	rounds := max((items+s.batchConcurrency-1)/s.batchConcurrency, 1)
+
+	return time.Duration(rounds) * s.RequestBudget()
+}
+
+// FetchBatchForecasts fetches the forecasts of every location from all available APIs, a few locations
+// at a time, the results are in the order of the locations. Provider failures are reported in the
+// forecasts like FetchForecasts, a canceled or expired request context fails the whole batch.
+func (s *WeatherService) FetchBatchForecasts(ctx context.Context, locations []Location) ([]map[string]models.Forecast, error) {
+	logger.FromContext(ctx, s.l).Info("starting batch forecast fetch", map[string]any{
+		"request_id": requestid.FromContext(ctx),
+		"locations":  len(locations),
+	})
+
+	results := make([]map[string]models.Forecast, len(locations))
+
+	var g errgroup.Group
+	g.SetLimit(s.batchConcurrency)
+	for i, loc := range locations {
+		g.Go(func() error {
+			forecasts, err := s.FetchForecasts(ctx, loc.Lat, loc.Lon, loc.ForecastWindow)
+			if err != nil {
+				return err
+			}
+
+			results[i] = forecasts
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
@@ -0,0 +1,72 @@
+package weather_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// echoRepository returns the requested location, the higher the latitude the faster
+type echoRepository struct{}
+
+func (r *echoRepository) Name() string {
+	return "echo"
+}
+
+func (r *echoRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	select {
+	case <-ctx.Done():
+		return models.Forecast{}, ctx.Err()
+	case <-time.After(time.Duration(50-lat) * time.Millisecond):
+	}
+
+	return models.Forecast{RepositoryName: r.Name(), Lat: lat, Lon: lon, ForecastWindow: forecastWindow, ForecastData: []models.WeatherData{}}, nil
+}
+
+func TestWeatherService_FetchBatchForecasts_Order(t *testing.T) {
+	service := weather.NewWeatherService([]repositories.WeatherRepository{&echoRepository{}}, logger.NopLogger{})
+
+	locations := []weather.Location{
+		{Lat: 10, Lon: 1, ForecastWindow: 1},
+		{Lat: 20, Lon: 2, ForecastWindow: 2},
+		{Lat: 30, Lon: 3, ForecastWindow: 3},
+		{Lat: 40, Lon: 4, ForecastWindow: 4},
+	}
+
+	results, err := service.FetchBatchForecasts(context.Background(), locations)
+	require.NoError(t, err)
+	require.Len(t, results, len(locations))
+
+	for i, loc := range locations {
+		forecast := results[i]["echo"]
+		assert.Equal(t, loc.Lat, forecast.Lat, "result %d out of order", i)
+		assert.Equal(t, loc.ForecastWindow, forecast.ForecastWindow)
+	}
+}
+
+func TestWeatherService_FetchBatchForecasts_Concurrency(t *testing.T) {
+	probe := &concurrencyProbe{}
+	service := weather.NewWeatherService(slowRepositories(1, probe), logger.NopLogger{},
+		weather.WithBatchLimits(10, 2))
+
+	locations := make([]weather.Location, 6)
+	for i := range locations {
+		locations[i] = weather.Location{Lat: float64(i), ForecastWindow: 1}
+	}
+
+	results, err := service.FetchBatchForecasts(context.Background(), locations)
+	require.NoError(t, err)
+
+	assert.Len(t, results, 6)
+	assert.Equal(t, int64(2), probe.peak.Load())
+	assert.Equal(t, 10, service.BatchMaxItems())
+	assert.Equal(t, 3*service.RequestBudget(), service.BatchRequestBudget(6))
+}
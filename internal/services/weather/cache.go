@@ -0,0 +1,241 @@
+package weather
+
+import (
+	"container/list"
+	"context"
+	"math"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+
+	"weather-api/internal/models"
+)
+
+// Cache keeps the successful provider forecasts for the cache TTL, in memory or in Redis to be shared by the
+// replicas. A cache that fails is skipped, it never fails a request.
+type Cache interface {
+	// Get returns a copy of the cached forecast, the rules applied to it leave the cache untouched
+	Get(ctx context.Context, key CacheKey) (models.Forecast, bool)
+	// Set caches a copy of the forecast
+	Set(ctx context.Context, key CacheKey, forecast models.Forecast)
+	// Purge removes every entry matching the filter, nil removes them all, and returns the number removed
+	Purge(ctx context.Context, match func(key CacheKey) bool) int
+	Stats(ctx context.Context) CacheStats
+}
+
+// Cache backends reported in CacheStats
+const (
+	CacheBackendMemory = "memory"
+	CacheBackendRedis  = "redis"
+)
+
+// cacheTimeout bounds the cache operations of the admin routes, they have no request context
+const cacheTimeout = 5 * time.Second
+
+// CacheStats describes the content and the effectiveness of the forecast cache
+type CacheStats struct {
+	Enabled    bool   `json:"enabled" example:"true"`
+	Backend    string `json:"backend,omitempty" example:"memory"`
+	Entries    int    `json:"entries" example:"42"`
+	MaxEntries int    `json:"max_entries" example:"1000"`
+	TTLSeconds int    `json:"ttl_seconds" example:"300"`
+	Hits       int64  `json:"hits" example:"1234"`
+	Misses     int64  `json:"misses" example:"56"`
+	// Errors counts the failed operations of a remote cache, the requests went on without it
+	Errors int64 `json:"errors" example:"0"`
+	// MemoryBytes is an estimate of the memory held by the entries of the memory cache
+	MemoryBytes int64 `json:"memory_bytes" example:"81920"`
+}
+
+// CacheKey identifies the forecast of a provider for a location, the coordinates are rounded to
+// 4 decimals, about 11 meters
+type CacheKey struct {
+	Provider string
+	Lat, Lon float64
+	Window   int
+}
+
+func newCacheKey(provider string, lat, lon float64, window int) CacheKey {
+	return CacheKey{Provider: provider, Lat: roundCoordinate(lat), Lon: roundCoordinate(lon), Window: window}
+}
+
+func roundCoordinate(v float64) float64 {
+	return math.Round(v*1e4) / 1e4
+}
+
+type cacheEntry struct {
+	key      CacheKey
+	forecast models.Forecast
+	expires  time.Time
+	size     int64
+}
+
+// forecastCache keeps the successful forecasts of the providers in memory for ttl, the least recently used entry
+// is evicted beyond maxEntries
+type forecastCache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu    sync.Mutex
+	order *list.List
+	items map[CacheKey]*list.Element
+	bytes int64
+
+	hits   atomic.Int64
+	misses atomic.Int64
+}
+
+func newForecastCache(ttl time.Duration, maxEntries int, now func() time.Time) *forecastCache {
+	return &forecastCache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        now,
+		order:      list.New(),
+		items:      make(map[CacheKey]*list.Element),
+	}
+}
+
+var _ Cache = (*forecastCache)(nil)
+
+// Get returns a copy of the cached forecast
+func (c *forecastCache) Get(_ context.Context, key CacheKey) (models.Forecast, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[key]
+	if ok && c.now().After(e.Value.(*cacheEntry).expires) {
+		c.remove(e)
+		ok = false
+	}
+	if !ok {
+		c.misses.Add(1)
+		return models.Forecast{}, false
+	}
+
+	c.hits.Add(1)
+	c.order.MoveToFront(e)
+
+	return cloneForecast(e.Value.(*cacheEntry).forecast), true
+}
+
+// Set caches a copy of the forecast
+func (c *forecastCache) Set(_ context.Context, key CacheKey, forecast models.Forecast) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[key]; ok {
+		c.remove(e)
+	}
+
+	entry := &cacheEntry{key: key, forecast: cloneForecast(forecast), expires: c.now().Add(c.ttl), size: forecastSize(forecast)}
+	c.items[key] = c.order.PushFront(entry)
+	c.bytes += entry.size
+
+	if c.order.Len() > c.maxEntries {
+		c.remove(c.order.Back())
+	}
+}
+
+// Purge removes every entry matching the filter, nil removes them all, and returns the number removed
+func (c *forecastCache) Purge(_ context.Context, match func(key CacheKey) bool) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var purged int
+	for key, e := range c.items {
+		if match == nil || match(key) {
+			c.remove(e)
+			purged++
+		}
+	}
+
+	return purged
+}
+
+func (c *forecastCache) Stats(context.Context) CacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return CacheStats{
+		Enabled:     true,
+		Backend:     CacheBackendMemory,
+		Entries:     c.order.Len(),
+		MaxEntries:  c.maxEntries,
+		TTLSeconds:  int(c.ttl.Seconds()),
+		Hits:        c.hits.Load(),
+		Misses:      c.misses.Load(),
+		MemoryBytes: c.bytes,
+	}
+}
+
+// remove deletes an entry, c.mu must be held
+func (c *forecastCache) remove(e *list.Element) {
+	entry := e.Value.(*cacheEntry)
+	c.order.Remove(e)
+	delete(c.items, entry.key)
+	c.bytes -= entry.size
+}
+
+func cloneForecast(forecast models.Forecast) models.Forecast {
+	forecast.ForecastData = slices.Clone(forecast.ForecastData)
+	return forecast
+}
+
+// forecastSize estimates the memory held by a cached forecast, the optional values of the days are left out
+func forecastSize(forecast models.Forecast) int64 {
+	size := int(unsafe.Sizeof(cacheEntry{})) +
+		len(forecast.RepositoryName) + len(forecast.Units) + len(forecast.SourceURL) +
+		len(forecast.ForecastData)*int(unsafe.Sizeof(models.WeatherData{}))
+	if forecast.Timezone != nil {
+		size += int(unsafe.Sizeof(models.Timezone{})) + len(forecast.Timezone.Name)
+	}
+
+	return int64(size)
+}
+
+// WithCache caches the provider forecasts in cache rather than in memory, see WithForecastCache
+func WithCache(cache Cache) Option {
+	return func(s *WeatherService) {
+		s.cache = cache
+	}
+}
+
+// CacheStats returns the counters of the forecast cache, zero when it is disabled
+func (s *WeatherService) CacheStats() CacheStats {
+	if s.cache == nil {
+		return CacheStats{}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+
+	return s.cache.Stats(ctx)
+}
+
+// PurgeCache empties the forecast cache and returns the number of forecasts removed
+func (s *WeatherService) PurgeCache() int {
+	return s.purgeCache(nil)
+}
+
+// PurgeCacheLocation removes the cached forecasts of a location, from every provider, and returns the number
+// of forecasts removed
+func (s *WeatherService) PurgeCacheLocation(lat, lon float64) int {
+	lat, lon = roundCoordinate(lat), roundCoordinate(lon)
+	return s.purgeCache(func(key CacheKey) bool {
+		return key.Lat == lat && key.Lon == lon
+	})
+}
+
+func (s *WeatherService) purgeCache(match func(key CacheKey) bool) int {
+	if s.cache == nil {
+		return 0
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cacheTimeout)
+	defer cancel()
+
+	return s.cache.Purge(ctx, match)
+}
@@ -0,0 +1,139 @@
+package weather_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+func newCachedService(repo *MockRepository, opts ...weather.Option) *weather.WeatherService {
+	return weather.NewWeatherService([]repositories.WeatherRepository{repo}, logger.NopLogger{}, opts...)
+}
+
+func cachedRepository() *MockRepository {
+	date, _ := models.ParseDate("2025-07-25")
+	return &MockRepository{name: "open-meteo", forecastData: models.Forecast{
+		RepositoryName: "open-meteo",
+		ForecastData:   []models.WeatherData{{Date: date, TempMax: 25.5, TempMin: 15.2}},
+	}}
+}
+
+func TestForecastCache(t *testing.T) {
+	repo := cachedRepository()
+	service := newCachedService(repo, weather.WithForecastCache(10))
+
+	first, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+	// The forecast returned to a caller is its own
+	first["open-meteo"].ForecastData[0].TempMax = 99
+
+	second, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 1, repo.callCount)
+	assert.Equal(t, 25.5, second["open-meteo"].ForecastData[0].TempMax)
+	assert.False(t, first["open-meteo"].Cached)
+	assert.True(t, second["open-meteo"].Cached)
+
+	// Another forecast window is another entry
+	_, err = service.FetchForecasts(context.Background(), 52.52, 13.41, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.callCount)
+
+	stats := service.CacheStats()
+	assert.True(t, stats.Enabled)
+	assert.Equal(t, 2, stats.Entries)
+	assert.Equal(t, 10, stats.MaxEntries)
+	assert.Equal(t, 300, stats.TTLSeconds)
+	assert.Equal(t, int64(1), stats.Hits)
+	assert.Equal(t, int64(2), stats.Misses)
+	assert.Positive(t, stats.MemoryBytes)
+}
+
+func TestForecastCache_Purge(t *testing.T) {
+	repo := cachedRepository()
+	service := newCachedService(repo, weather.WithForecastCache(10))
+	fetch := func(lat, lon float64) {
+		_, err := service.FetchForecasts(context.Background(), lat, lon, 1)
+		require.NoError(t, err)
+	}
+
+	fetch(52.52, 13.41)
+	fetch(48.85, 2.35)
+	require.Equal(t, 2, repo.callCount)
+
+	// The coordinates match once rounded to 4 decimals
+	assert.Equal(t, 1, service.PurgeCacheLocation(52.520001, 13.41))
+	assert.Zero(t, service.PurgeCacheLocation(40.71, -74.01))
+	fetch(48.85, 2.35)
+	assert.Equal(t, 2, repo.callCount)
+	fetch(52.52, 13.41)
+	assert.Equal(t, 3, repo.callCount)
+
+	assert.Equal(t, 2, service.PurgeCache())
+	assert.Zero(t, service.CacheStats().Entries)
+	assert.Zero(t, service.CacheStats().MemoryBytes)
+	fetch(48.85, 2.35)
+	assert.Equal(t, 4, repo.callCount)
+}
+
+func TestForecastCache_Expiry(t *testing.T) {
+	repo := cachedRepository()
+	service := newCachedService(repo, weather.WithCacheTTL(20*time.Millisecond), weather.WithForecastCache(10))
+
+	for range 2 {
+		_, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 1, repo.callCount)
+
+	time.Sleep(30 * time.Millisecond)
+	_, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.callCount)
+}
+
+func TestForecastCache_Eviction(t *testing.T) {
+	repo := cachedRepository()
+	service := newCachedService(repo, weather.WithForecastCache(1))
+
+	for _, lat := range []float64{52.52, 48.85, 52.52} {
+		_, err := service.FetchForecasts(context.Background(), lat, 13.41, 1)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, repo.callCount)
+	assert.Equal(t, 1, service.CacheStats().Entries)
+}
+
+func TestForecastCache_FailuresNotCached(t *testing.T) {
+	repo := &MockRepository{name: "open-meteo", shouldFail: true}
+	service := newCachedService(repo, weather.WithForecastCache(10))
+
+	for range 2 {
+		forecasts, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+		require.NoError(t, err)
+		assert.NotEmpty(t, forecasts["open-meteo"].ErrorCode)
+	}
+	assert.Equal(t, 2, repo.callCount)
+	assert.Zero(t, service.CacheStats().Entries)
+}
+
+func TestForecastCache_Disabled(t *testing.T) {
+	repo := cachedRepository()
+	service := newCachedService(repo)
+
+	for range 2 {
+		_, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 2, repo.callCount)
+	assert.Equal(t, weather.CacheStats{}, service.CacheStats())
+	assert.Zero(t, service.PurgeCache())
+}
@@ -0,0 +1,116 @@
+package weather
+
+import (
+	"cmp"
+	"context"
+	"math"
+	"slices"
+
+	"weather-api/internal/models"
+)
+
+// defaultCompareThreshold is the disagreement, in °C, beyond which a compared day is flagged
+const defaultCompareThreshold = 3.0
+
+// WithCompareThreshold sets the disagreement, in °C, beyond which CompareForecasts flags a day, zero keeps the
+// default of 3
+func WithCompareThreshold(threshold float64) Option {
+	return func(s *WeatherService) {
+		if threshold > 0 {
+			s.compareThreshold = threshold
+		}
+	}
+}
+
+// CompareForecasts fetches the forecasts of every provider and sets them side by side, day by day. The days are
+// aligned by date, a day missing from a provider is compared among the others and lists it as missing. The days
+// are sorted by disagreement, the largest first, and flagged beyond the threshold. It fails with ErrNoForecasts
+// when no provider returned a forecast.
+func (s *WeatherService) CompareForecasts(ctx context.Context, lat, lon float64, forecastWindow int) (models.ForecastComparison, error) {
+	forecasts, err := s.FetchOrderedForecasts(ctx, lat, lon, forecastWindow)
+	if err != nil {
+		return models.ForecastComparison{}, err
+	}
+
+	return compareForecasts(forecasts, lat, lon, forecastWindow, s.compareThreshold)
+}
+
+func compareForecasts(forecasts []models.Forecast, lat, lon float64, forecastWindow int, threshold float64) (models.ForecastComparison, error) {
+	comparison := models.ForecastComparison{
+		Lat:            lat,
+		Lon:            lon,
+		ForecastWindow: forecastWindow,
+		Threshold:      threshold,
+		Providers:      []string{},
+		Days:           []models.DayComparison{},
+	}
+
+	days := make(map[string]*models.DayComparison)
+	for _, forecast := range forecasts {
+		if forecast.Error != "" {
+			comparison.Failures = append(comparison.Failures, models.ProviderFailure{
+				Provider:  forecast.RepositoryName,
+				Error:     forecast.Error,
+				ErrorCode: forecast.ErrorCode,
+			})
+			continue
+		}
+
+		comparison.Providers = append(comparison.Providers, forecast.RepositoryName)
+		for _, wd := range forecast.ForecastData {
+			if wd.Date.IsZero() {
+				continue
+			}
+
+			key := wd.Date.Format(models.DateLayout)
+			day, ok := days[key]
+			if !ok {
+				day = &models.DayComparison{Date: wd.Date, Providers: make(map[string]models.ProviderTemperatures)}
+				days[key] = day
+			}
+			day.Providers[forecast.RepositoryName] = models.ProviderTemperatures{TempMax: wd.TempMax, TempMin: wd.TempMin}
+		}
+	}
+	if len(comparison.Providers) == 0 {
+		return comparison, ErrNoForecasts
+	}
+
+	for _, day := range days {
+		for _, provider := range comparison.Providers {
+			if _, ok := day.Providers[provider]; !ok {
+				day.Missing = append(day.Missing, provider)
+			}
+		}
+		disagree(day, comparison.Providers)
+		day.Flagged = day.Disagreement > threshold
+		comparison.Days = append(comparison.Days, *day)
+	}
+
+	slices.SortFunc(comparison.Days, func(a, b models.DayComparison) int {
+		return cmp.Or(cmp.Compare(b.Disagreement, a.Disagreement), a.Date.Compare(b.Date.Time))
+	})
+
+	return comparison, nil
+}
+
+// disagree sets the largest difference between two providers of the day on the max or min temperature, and the
+// pair, the first in configuration order on a tie
+func disagree(day *models.DayComparison, providers []string) {
+	for i, a := range providers {
+		ta, ok := day.Providers[a]
+		if !ok {
+			continue
+		}
+		for _, b := range providers[i+1:] {
+			tb, ok := day.Providers[b]
+			if !ok {
+				continue
+			}
+
+			d := roundTemp(math.Max(math.Abs(ta.TempMax-tb.TempMax), math.Abs(ta.TempMin-tb.TempMin)))
+			if day.Pair == nil || d > day.Disagreement {
+				day.Disagreement, day.Pair = d, []string{a, b}
+			}
+		}
+	}
+}
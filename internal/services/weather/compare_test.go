@@ -0,0 +1,91 @@
+package weather_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// divergentRepositories disagree on the max temperature of the 26th and on the min temperature of the 27th, and
+// report different days
+func divergentRepositories() []repositories.WeatherRepository {
+	forecast := func(name string, days ...models.WeatherData) *MockRepository {
+		return &MockRepository{name: name, forecastData: models.Forecast{RepositoryName: name, ForecastData: days}}
+	}
+
+	return []repositories.WeatherRepository{
+		forecast("repo-1", day(0, 30, 20), day(1, 28, 18), day(2, 25, 15), day(3, 24, 14)),
+		forecast("repo-2", day(1, 33, 18), day(0, 31, 20)),
+		forecast("repo-3", day(1, 28, 17), day(2, 25, 19)),
+		&MockRepository{name: "repo-4", shouldFail: true},
+	}
+}
+
+func TestWeatherService_CompareForecasts(t *testing.T) {
+	service := weather.NewWeatherService(divergentRepositories(), logger.NopLogger{})
+
+	comparison, err := service.CompareForecasts(context.Background(), 40.7128, -74.0060, 4)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3.0, comparison.Threshold)
+	assert.Equal(t, []string{"repo-1", "repo-2", "repo-3"}, comparison.Providers)
+	require.Len(t, comparison.Failures, 1)
+	assert.Equal(t, "repo-4", comparison.Failures[0].Provider)
+
+	tests := []struct {
+		date         string
+		providers    int
+		missing      []string
+		disagreement float64
+		pair         []string
+		flagged      bool
+	}{
+		{"2025-07-26", 3, nil, 5, []string{"repo-1", "repo-2"}, true},
+		{"2025-07-27", 2, []string{"repo-2"}, 4, []string{"repo-1", "repo-3"}, true},
+		{"2025-07-25", 2, []string{"repo-3"}, 1, []string{"repo-1", "repo-2"}, false},
+		{"2025-07-28", 1, []string{"repo-2", "repo-3"}, 0, nil, false},
+	}
+
+	require.Len(t, comparison.Days, len(tests))
+	for i, tt := range tests {
+		got := comparison.Days[i]
+		assert.Equal(t, tt.date, got.Date.String(), "day %d", i)
+		assert.Len(t, got.Providers, tt.providers, "providers of %s", tt.date)
+		assert.Equal(t, tt.missing, got.Missing, "missing of %s", tt.date)
+		assert.Equal(t, tt.disagreement, got.Disagreement, "disagreement of %s", tt.date)
+		assert.Equal(t, tt.pair, got.Pair, "pair of %s", tt.date)
+		assert.Equal(t, tt.flagged, got.Flagged, "flagged %s", tt.date)
+	}
+	assert.Equal(t, models.ProviderTemperatures{TempMax: 33, TempMin: 18}, comparison.Days[0].Providers["repo-2"])
+}
+
+func TestWeatherService_CompareForecasts_Threshold(t *testing.T) {
+	service := weather.NewWeatherService(divergentRepositories(), logger.NopLogger{}, weather.WithCompareThreshold(4.5))
+
+	comparison, err := service.CompareForecasts(context.Background(), 40.7128, -74.0060, 4)
+	require.NoError(t, err)
+
+	var flagged []string
+	for _, d := range comparison.Days {
+		if d.Flagged {
+			flagged = append(flagged, d.Date.String())
+		}
+	}
+	assert.Equal(t, []string{"2025-07-26"}, flagged)
+}
+
+func TestWeatherService_CompareForecasts_AllFailures(t *testing.T) {
+	service := weather.NewWeatherService([]repositories.WeatherRepository{
+		&MockRepository{name: "repo-1", shouldFail: true},
+	}, logger.NopLogger{})
+
+	_, err := service.CompareForecasts(context.Background(), 40.7128, -74.0060, 4)
+	assert.ErrorIs(t, err, weather.ErrNoForecasts)
+}
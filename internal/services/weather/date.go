@@ -0,0 +1,113 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/timezone"
+)
+
+// ErrDateOutOfRange is returned for a target date in the past or beyond the horizon of every provider
+var ErrDateOutOfRange = errors.New("forecast date out of range")
+
+// DateWindow returns the forecast window reaching date at the location, one for today. Today is the local
+// day estimated from the longitude, the date must be within the horizon of at least one enabled provider.
+func (s *WeatherService) DateWindow(lon float64, date models.Date) (int, error) {
+	tz := timezone.Estimate(lon)
+	today := models.NewDate(time.Now().UTC().Add(time.Duration(tz.UTCOffsetSeconds) * time.Second))
+
+	window := int(date.Sub(today.Time).Hours()/24) + 1
+	if window < 1 {
+		return 0, fmt.Errorf("%w: %s is in the past, today is %s", ErrDateOutOfRange, date, today)
+	}
+	if horizon := s.horizon(); window > horizon {
+		return 0, fmt.Errorf("%w: %s is beyond the %d-day horizon of the providers", ErrDateOutOfRange, date, horizon)
+	}
+
+	return window, nil
+}
+
+// horizon is the longest forecast window served by an enabled provider, bounded by the configured maximum
+func (s *WeatherService) horizon() int {
+	var horizon int
+	for _, repo := range s.current().repos {
+		if !s.ProviderEnabled(repo.Name()) {
+			continue
+		}
+
+		maxDays := repositories.MaxDays(repo)
+		if maxDays == 0 {
+			maxDays = s.maxForecastDays
+		}
+		horizon = max(horizon, maxDays)
+	}
+
+	return min(horizon, s.maxForecastDays)
+}
+
+// FetchDateForecasts fetches the forecasts of the given providers, keyed by provider name, down to the day
+// of date only, see DateWindow
+func (s *WeatherService) FetchDateForecasts(ctx context.Context, lat, lon float64, date models.Date, providers []string) (map[string]models.Forecast, error) {
+	window, err := s.DateWindow(lon, date)
+	if err != nil {
+		return nil, err
+	}
+
+	forecasts, err := s.FetchProviderForecasts(ctx, lat, lon, window, providers)
+	if forecasts == nil {
+		return nil, err
+	}
+
+	for name, forecast := range forecasts {
+		forecasts[name] = SelectDate(forecast, date)
+	}
+
+	return forecasts, err
+}
+
+// AggregateDateForecasts merges the forecasts of every provider for the day of date only, see
+// AggregateForecasts and DateWindow
+func (s *WeatherService) AggregateDateForecasts(ctx context.Context, lat, lon float64, date models.Date, strategy string, minProviders int) (models.AggregatedForecast, error) {
+	if minProviders <= 0 {
+		minProviders = s.minProviders
+	}
+
+	window, err := s.DateWindow(lon, date)
+	if err != nil {
+		return models.AggregatedForecast{}, err
+	}
+
+	forecasts, err := s.FetchOrderedForecasts(ctx, lat, lon, window)
+	if err != nil {
+		return models.AggregatedForecast{}, err
+	}
+
+	for i, forecast := range forecasts {
+		forecasts[i] = SelectDate(forecast, date)
+	}
+
+	return s.aggregate(forecasts, lat, lon, window, strategy, minProviders)
+}
+
+// SelectDate keeps the day of date in the forecast, a provider whose horizon ends before it keeps no day
+// and a note instead
+func SelectDate(forecast models.Forecast, date models.Date) models.Forecast {
+	if forecast.Error != "" {
+		return forecast
+	}
+
+	i := models.FilterByDate(forecast.ForecastData, date)
+	if i < 0 {
+		forecast.ForecastData = []models.WeatherData{}
+		forecast.Note = fmt.Sprintf("no forecast for %s, it is beyond the provider horizon", date)
+		return forecast
+	}
+
+	forecast.ForecastData = []models.WeatherData{forecast.ForecastData[i]}
+
+	return forecast
+}
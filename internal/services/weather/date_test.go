@@ -0,0 +1,116 @@
+package weather_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// The tests locate the forecasts on the Greenwich meridian, where today is the day in UTC
+const dateTestLat, dateTestLon = 51.4779, 0.0
+
+func inDays(days int) models.Date {
+	return models.NewDate(time.Now().UTC().AddDate(0, 0, days))
+}
+
+func newDateTestService() (*weather.WeatherService, *horizonRepository, *horizonRepository) {
+	short := &horizonRepository{name: "short", maxDays: 5}
+	long := &horizonRepository{name: "long", maxDays: 16}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{short, long}, logger.NopLogger{})
+
+	return service, short, long
+}
+
+func TestWeatherService_DateWindow(t *testing.T) {
+	service, _, _ := newDateTestService()
+
+	tests := []struct {
+		name       string
+		date       models.Date
+		wantWindow int
+		wantErr    bool
+	}{
+		{"today", inDays(0), 1, false},
+		{"tomorrow", inDays(1), 2, false},
+		{"horizon edge", inDays(15), 16, false},
+		{"beyond the horizon", inDays(16), 0, true},
+		{"yesterday", inDays(-1), 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := service.DateWindow(dateTestLon, tt.date)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, weather.ErrDateOutOfRange)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantWindow, window)
+		})
+	}
+}
+
+func TestWeatherService_DateWindow_DisabledProvider(t *testing.T) {
+	service, _, long := newDateTestService()
+	require.NoError(t, service.SetProviderEnabled(long.name, false))
+
+	// Only the 5-day provider is left
+	window, err := service.DateWindow(dateTestLon, inDays(4))
+	require.NoError(t, err)
+	assert.Equal(t, 5, window)
+
+	_, err = service.DateWindow(dateTestLon, inDays(5))
+	assert.ErrorIs(t, err, weather.ErrDateOutOfRange)
+}
+
+func TestWeatherService_FetchDateForecasts(t *testing.T) {
+	service, short, long := newDateTestService()
+
+	forecasts, err := service.FetchDateForecasts(context.Background(), dateTestLat, dateTestLon, inDays(0), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, short.requested)
+	assert.Equal(t, 1, long.requested)
+	for _, name := range []string{"short", "long"} {
+		require.Len(t, forecasts[name].ForecastData, 1, name)
+		assert.Equal(t, inDays(0), forecasts[name].ForecastData[0].Date, name)
+	}
+
+	// Beyond the horizon of the short provider, it keeps no day
+	forecasts, err = service.FetchDateForecasts(context.Background(), dateTestLat, dateTestLon, inDays(15), nil)
+	require.NoError(t, err)
+	assert.Equal(t, 5, short.requested)
+	assert.Equal(t, 16, long.requested)
+	assert.Empty(t, forecasts["short"].ForecastData)
+	assert.Contains(t, forecasts["short"].Note, "beyond the provider horizon")
+	require.Len(t, forecasts["long"].ForecastData, 1)
+	assert.Equal(t, inDays(15), forecasts["long"].ForecastData[0].Date)
+
+	_, err = service.FetchDateForecasts(context.Background(), dateTestLat, dateTestLon, inDays(-1), nil)
+	assert.ErrorIs(t, err, weather.ErrDateOutOfRange)
+}
+
+func TestWeatherService_AggregateDateForecasts(t *testing.T) {
+	service, _, _ := newDateTestService()
+
+	aggregated, err := service.AggregateDateForecasts(context.Background(), dateTestLat, dateTestLon, inDays(2), weather.StrategyMean, 2)
+	require.NoError(t, err)
+	require.Len(t, aggregated.ForecastData, 1)
+	assert.Equal(t, inDays(2), aggregated.ForecastData[0].Date)
+	assert.Equal(t, []string{"short", "long"}, aggregated.Providers)
+
+	// Only the long provider reaches the date
+	_, err = service.AggregateDateForecasts(context.Background(), dateTestLat, dateTestLon, inDays(10), weather.StrategyMean, 2)
+	var quorumErr *weather.QuorumError
+	require.ErrorAs(t, err, &quorumErr)
+	assert.Equal(t, 1, quorumErr.Available)
+
+	_, err = service.AggregateDateForecasts(context.Background(), dateTestLat, dateTestLon, inDays(16), weather.StrategyMean, 1)
+	assert.ErrorIs(t, err, weather.ErrDateOutOfRange)
+}
@@ -0,0 +1,67 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/pkg/logger"
+)
+
+// ErrBudgetExceeded is returned when the request budget ran out before every provider answered, along with the
+// forecasts of the ones that did. It wraps context.DeadlineExceeded.
+var ErrBudgetExceeded = fmt.Errorf("request budget exceeded: %w", context.DeadlineExceeded)
+
+// classifyError maps a repository error to an error code and a message safe to return to clients,
+// provider errors may contain request URLs with API keys so their text is never passed through
+func classifyError(err error) (code, message string) {
+	var statusErr *repositories.HTTPStatusError
+	var responseErr *repositories.ResponseError
+	var netErr net.Error
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return models.ErrorCodeTimeout, "provider timed out"
+	case errors.Is(err, context.Canceled):
+		return models.ErrorCodeCanceled, "request canceled"
+	case errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusTooManyRequests:
+		return models.ErrorCodeRateLimited, "provider rate limit exceeded"
+	case errors.As(err, &statusErr):
+		return models.ErrorCodeUpstreamHTTP, fmt.Sprintf("provider returned HTTP %d", statusErr.StatusCode)
+	case errors.As(err, &responseErr):
+		return models.ErrorCodeParse, "invalid provider response"
+	case errors.Is(err, repositories.ErrNoData):
+		return models.ErrorCodeNoData, "provider returned no forecast data"
+	case errors.Is(err, repositories.ErrUnsupported):
+		return models.ErrorCodeUnsupported, "operation not supported by provider"
+	case errors.Is(err, ErrQuotaExhausted):
+		return models.ErrorCodeQuotaExhausted, "provider quota exhausted"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return models.ErrorCodeTimeout, "provider timed out"
+	case errors.As(err, &netErr):
+		return models.ErrorCodeUnavailable, "provider unreachable"
+	}
+
+	return models.ErrorCodeUnknown, "provider error"
+}
+
+// logProviderError logs a failed provider call with its repo, the repeated failures of a provider are throttled
+// and summed up by the next failure logged
+func (s *WeatherService) logProviderError(l logger.Logger, repo string, err error, fields map[string]any) {
+	ok, suppressed, elapsed := s.errorLog.Allow(repo)
+	if !ok {
+		return
+	}
+
+	fields["repo"] = repo
+	if suppressed > 0 {
+		l.Warning(fmt.Sprintf("%s failed %d times in the last %s", repo, suppressed+1, elapsed.Round(time.Second)),
+			map[string]any{"repo": repo, "failures": suppressed + 1, "suppressed": suppressed})
+	}
+	l.Error(err, fields)
+}
@@ -0,0 +1,78 @@
+package weather
+
+import (
+	"context"
+	"sync/atomic"
+
+	"golang.org/x/sync/semaphore"
+)
+
+// limiter bounds the number of concurrent upstream calls globally, a nil semaphore means no limit. The limits of
+// the providers are kept by the providerSet, so they are replaced with the providers.
+type limiter struct {
+	global   *semaphore.Weighted
+	inFlight atomic.Int64
+}
+
+// providerSlots is the semaphore of a provider limited to n concurrent calls
+type providerSlots struct {
+	n   int
+	sem *semaphore.Weighted
+}
+
+func newLimiter(global int) *limiter {
+	l := &limiter{}
+	if global > 0 {
+		l.global = semaphore.NewWeighted(int64(global))
+	}
+
+	return l
+}
+
+// newProviderSlots returns the semaphores of the providers, a provider keeps its semaphore of previous, and the
+// calls it counts, when its limit is unchanged. The calls in flight release the semaphore they acquired.
+func newProviderSlots(perProvider map[string]int, previous map[string]providerSlots) map[string]providerSlots {
+	slots := make(map[string]providerSlots, len(perProvider))
+	for provider, n := range perProvider {
+		if n <= 0 {
+			continue
+		}
+		if previous[provider].n == n {
+			slots[provider] = previous[provider]
+			continue
+		}
+		slots[provider] = providerSlots{n: n, sem: semaphore.NewWeighted(int64(n))}
+	}
+
+	return slots
+}
+
+// acquire waits for a slot of the provider, limited by sem when not nil, the wait is bounded by the context
+func (l *limiter) acquire(ctx context.Context, sem *semaphore.Weighted) (release func(), err error) {
+	if sem != nil {
+		if err := sem.Acquire(ctx, 1); err != nil {
+			return nil, err
+		}
+	}
+
+	if l.global != nil {
+		if err := l.global.Acquire(ctx, 1); err != nil {
+			if sem != nil {
+				sem.Release(1)
+			}
+			return nil, err
+		}
+	}
+
+	l.inFlight.Add(1)
+
+	return func() {
+		l.inFlight.Add(-1)
+		if l.global != nil {
+			l.global.Release(1)
+		}
+		if sem != nil {
+			sem.Release(1)
+		}
+	}, nil
+}
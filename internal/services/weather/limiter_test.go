@@ -0,0 +1,112 @@
+package weather_test
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// concurrencyProbe records the peak number of concurrent calls of the repositories sharing it
+type concurrencyProbe struct {
+	current atomic.Int64
+	peak    atomic.Int64
+}
+
+type slowRepository struct {
+	name  string
+	probe *concurrencyProbe
+}
+
+func (r *slowRepository) Name() string {
+	return r.name
+}
+
+func (r *slowRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	n := r.probe.current.Add(1)
+	defer r.probe.current.Add(-1)
+	for {
+		peak := r.probe.peak.Load()
+		if n <= peak || r.probe.peak.CompareAndSwap(peak, n) {
+			break
+		}
+	}
+
+	select {
+	case <-ctx.Done():
+		return models.Forecast{}, ctx.Err()
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	return models.Forecast{RepositoryName: r.name, ForecastData: []models.WeatherData{}}, nil
+}
+
+func slowRepositories(n int, probe *concurrencyProbe) []repositories.WeatherRepository {
+	repos := make([]repositories.WeatherRepository, n)
+	for i := range repos {
+		repos[i] = &slowRepository{name: fmt.Sprintf("slow-repo-%d", i+1), probe: probe}
+	}
+	return repos
+}
+
+func TestWeatherService_ConcurrencyLimit_Global(t *testing.T) {
+	probe := &concurrencyProbe{}
+	service := weather.NewWeatherService(slowRepositories(5, probe), logger.NopLogger{},
+		weather.WithConcurrencyLimits(2, nil))
+
+	results, err := service.FetchForecasts(context.Background(), 40.7128, -74.0060, 1)
+	require.NoError(t, err)
+
+	assert.Len(t, results, 5)
+	for name, forecast := range results {
+		assert.Empty(t, forecast.Error, name)
+	}
+	assert.Equal(t, int64(2), probe.peak.Load())
+	assert.Equal(t, int64(0), service.InFlight())
+}
+
+func TestWeatherService_ConcurrencyLimit_PerProvider(t *testing.T) {
+	probe := &concurrencyProbe{}
+	repo := slowRepositories(1, probe)
+	service := weather.NewWeatherService(repo, logger.NopLogger{},
+		weather.WithConcurrencyLimits(0, map[string]int{"slow-repo-1": 1}))
+
+	done := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, _ = service.FetchForecasts(context.Background(), 40.7128, -74.0060, 1)
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+
+	assert.Equal(t, int64(1), probe.peak.Load())
+}
+
+func TestWeatherService_ConcurrencyLimit_WaitBoundedByContext(t *testing.T) {
+	probe := &concurrencyProbe{}
+	service := weather.NewWeatherService(slowRepositories(3, probe), logger.NopLogger{},
+		weather.WithConcurrencyLimits(1, nil))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := service.FetchForecasts(ctx, 40.7128, -74.0060, 1)
+
+	// The calls still waiting for a slot give up with the request
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Less(t, time.Since(start), 50*time.Millisecond)
+	assert.Equal(t, int64(1), probe.peak.Load())
+}
@@ -0,0 +1,78 @@
+package weather
+
+import (
+	"context"
+	"errors"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+// ErrNoMarineProvider is returned by FetchMarineForecast when no marine provider is configured
+var ErrNoMarineProvider = errors.New("no marine provider configured")
+
+// WithMarine serves the marine forecasts from provider
+func WithMarine(provider repositories.MarineProvider) Option {
+	return func(s *WeatherService) {
+		s.marine = provider
+	}
+}
+
+// FetchMarineForecast fetches the daily marine forecast, a provider failure is reported in the Error of the
+// result like FetchForecasts. A point without marine data fails with repositories.ErrInlandPoint and a
+// canceled or expired request context fails the request.
+func (s *WeatherService) FetchMarineForecast(ctx context.Context, lat, lon float64, days int) (models.MarineForecast, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	l := logger.FromContext(ctx, s.l)
+	if s.marine == nil {
+		return models.MarineForecast{}, ErrNoMarineProvider
+	}
+
+	requestID := requestid.FromContext(ctx)
+	name := s.marine.Name()
+
+	l.Info("starting marine forecast fetch", map[string]any{
+		"request_id": requestID,
+		"lat":        lat,
+		"lon":        lon,
+		"days":       days,
+	})
+
+	var forecast models.MarineForecast
+	err := s.callProvider(ctx, name, func(ctx context.Context) (err error) {
+		forecast, err = s.marine.FetchMarineForecast(ctx, lat, lon, days)
+		return err
+	})
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		l.Warning("marine forecast fetch aborted", map[string]any{"request_id": requestID, "err": ctxErr.Error()})
+		return models.MarineForecast{}, ctxErr
+	}
+	if errors.Is(err, repositories.ErrInlandPoint) {
+		return models.MarineForecast{}, err
+	}
+	if err != nil {
+		code, message := classifyError(err)
+		s.logProviderError(l, name, err, map[string]any{"request_id": requestID, "err": err, "error_code": code})
+
+		return models.MarineForecast{
+			RepositoryName: name,
+			Lat:            lat,
+			Lon:            lon,
+			ForecastWindow: days,
+			Error:          message,
+			ErrorCode:      code,
+			MarineData:     []models.MarineData{},
+		}, nil
+	}
+
+	l.Info("completed marine forecast fetch", map[string]any{
+		"request_id": requestID,
+		"days":       len(forecast.MarineData),
+	})
+
+	return forecast, nil
+}
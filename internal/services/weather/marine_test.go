@@ -0,0 +1,57 @@
+package weather_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// mockMarineProvider returns the requested location, or err
+type mockMarineProvider struct {
+	err error
+}
+
+func (m *mockMarineProvider) Name() string {
+	return "marine"
+}
+
+func (m *mockMarineProvider) FetchMarineForecast(ctx context.Context, lat, lon float64, days int) (models.MarineForecast, error) {
+	if m.err != nil {
+		return models.MarineForecast{}, m.err
+	}
+
+	return models.MarineForecast{RepositoryName: m.Name(), Lat: lat, Lon: lon, ForecastWindow: days}, nil
+}
+
+func TestWeatherService_FetchMarineForecast(t *testing.T) {
+	service := weather.NewWeatherService(nil, logger.NopLogger{}, weather.WithMarine(&mockMarineProvider{}))
+
+	result, err := service.FetchMarineForecast(context.Background(), 43.29, 5.37, 3)
+	require.NoError(t, err)
+	assert.Equal(t, models.MarineForecast{RepositoryName: "marine", Lat: 43.29, Lon: 5.37, ForecastWindow: 3}, result)
+}
+
+func TestWeatherService_FetchMarineForecast_Errors(t *testing.T) {
+	service := weather.NewWeatherService(nil, logger.NopLogger{})
+	_, err := service.FetchMarineForecast(context.Background(), 43.29, 5.37, 3)
+	assert.ErrorIs(t, err, weather.ErrNoMarineProvider)
+
+	service = weather.NewWeatherService(nil, logger.NopLogger{},
+		weather.WithMarine(&mockMarineProvider{err: repositories.ErrInlandPoint}))
+	_, err = service.FetchMarineForecast(context.Background(), 48.85, 2.35, 3)
+	assert.ErrorIs(t, err, repositories.ErrInlandPoint)
+
+	service = weather.NewWeatherService(nil, logger.NopLogger{},
+		weather.WithMarine(&mockMarineProvider{err: errors.New("connection reset")}))
+	result, err := service.FetchMarineForecast(context.Background(), 43.29, 5.37, 3)
+	require.NoError(t, err)
+	assert.Equal(t, models.ErrorCodeUnknown, result.ErrorCode)
+}
@@ -0,0 +1,219 @@
+package weather
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+)
+
+// defaultPrewarmDays is the forecast window warmed unless set by the location
+const defaultPrewarmDays = 5
+
+// PrewarmLocation is a popular location whose forecasts are kept in the cache, Days defaults to 5
+type PrewarmLocation struct {
+	Name string
+	Lat  float64
+	Lon  float64
+	Days int
+}
+
+// PrewarmOptions configures the cache warming, see WithPrewarm
+type PrewarmOptions struct {
+	Locations []PrewarmLocation
+	// Now is the clock of the schedule, time.Now when nil
+	Now func() time.Time
+	// Manual disables the background warming, the cache is only warmed by WarmCache
+	Manual bool
+}
+
+// PrewarmStats counts the refreshes of the warmed locations and the cache hits they served
+type PrewarmStats struct {
+	Locations int   `json:"locations" example:"20"`
+	Refreshes int64 `json:"refreshes" example:"1200"`
+	// Failures are the refreshes a provider failed, the forecasts cached before are kept until they expire
+	Failures int64 `json:"failures" example:"3"`
+	Hits     int64 `json:"hits" example:"45210"`
+}
+
+// prewarmer schedules the refreshes of the warmed locations, a nil prewarmer warms nothing
+type prewarmer struct {
+	locations []PrewarmLocation
+	now       func() time.Time
+	manual    bool
+	// warm are the rounded coordinates of the locations, the cache hits at them are counted
+	warm map[[2]float64]bool
+
+	// mu guards next, the time of the next refresh of every location, set by the first WarmCache
+	mu   sync.Mutex
+	next []time.Time
+
+	refreshes atomic.Int64
+	failures  atomic.Int64
+	hits      atomic.Int64
+}
+
+// WithPrewarm keeps the forecasts of the locations warm in the cache: every location is refreshed from all the
+// providers a tenth of the cache TTL before its forecasts expire, the locations staggered over the TTL so that
+// the providers are not called all at once. It needs the forecast cache.
+func WithPrewarm(opts PrewarmOptions) Option {
+	return func(s *WeatherService) {
+		if len(opts.Locations) == 0 {
+			return
+		}
+
+		p := &prewarmer{
+			locations: slices.Clone(opts.Locations),
+			now:       opts.Now,
+			manual:    opts.Manual,
+			warm:      make(map[[2]float64]bool, len(opts.Locations)),
+		}
+		if p.now == nil {
+			p.now = time.Now
+		}
+		for i, loc := range p.locations {
+			if loc.Days <= 0 {
+				p.locations[i].Days = defaultPrewarmDays
+			}
+			p.warm[[2]float64{roundCoordinate(loc.Lat), roundCoordinate(loc.Lon)}] = true
+		}
+		s.prewarm = p
+	}
+}
+
+// hit counts a cache hit when the key is of a warmed location
+func (p *prewarmer) hit(key CacheKey) {
+	if p != nil && p.warm[[2]float64{key.Lat, key.Lon}] {
+		p.hits.Add(1)
+	}
+}
+
+// PrewarmStats returns the counters of the cache warming, zero when no location is warmed
+func (s *WeatherService) PrewarmStats() PrewarmStats {
+	p := s.prewarm
+	if p == nil {
+		return PrewarmStats{}
+	}
+
+	return PrewarmStats{
+		Locations: len(p.locations),
+		Refreshes: p.refreshes.Load(),
+		Failures:  p.failures.Load(),
+		Hits:      p.hits.Load(),
+	}
+}
+
+// prewarmInterval returns the time between two refreshes of a location, and the delay of the retry of a failed
+// refresh, a tenth of the cache TTL
+func (s *WeatherService) prewarmInterval() (interval, retry time.Duration) {
+	retry = s.cacheTTL / 10
+
+	return s.cacheTTL - retry, retry
+}
+
+// WarmCache refreshes the forecasts of the warmed locations due, and returns the time the next one is due. The
+// first call schedules the locations one after the other over the refresh interval, the first one right away.
+func (s *WeatherService) WarmCache(ctx context.Context) time.Time {
+	p := s.prewarm
+	if p == nil || s.cache == nil {
+		return time.Time{}
+	}
+
+	interval, retry := s.prewarmInterval()
+	now := p.now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.next == nil {
+		p.next = make([]time.Time, len(p.locations))
+		spacing := interval / time.Duration(len(p.locations))
+		for i := range p.next {
+			p.next[i] = now.Add(time.Duration(i) * spacing)
+		}
+	}
+
+	for i, loc := range p.locations {
+		if p.next[i].After(now) {
+			continue
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		if s.refreshLocation(ctx, loc) {
+			p.next[i] = now.Add(interval)
+		} else {
+			p.next[i] = now.Add(retry)
+		}
+	}
+
+	return slices.MinFunc(p.next, func(a, b time.Time) int { return a.Compare(b) })
+}
+
+// refreshLocation fetches the forecasts of the location from every provider into the cache, bypassing the
+// forecasts cached, and reports whether every provider answered
+func (s *WeatherService) refreshLocation(ctx context.Context, loc PrewarmLocation) bool {
+	ctx, cancel := context.WithTimeout(withCacheRefresh(ctx), s.RequestBudget())
+	defer cancel()
+
+	s.prewarm.refreshes.Add(1)
+	forecasts, err := s.FetchForecasts(ctx, loc.Lat, loc.Lon, loc.Days)
+	var failed []string
+	for name, forecast := range forecasts {
+		if forecast.Error != "" && forecast.ErrorCode != models.ErrorCodeDisabled {
+			failed = append(failed, name)
+		}
+	}
+	if err == nil && len(failed) == 0 {
+		return true
+	}
+
+	s.prewarm.failures.Add(1)
+	fields := map[string]any{"location": loc.Name, "lat": loc.Lat, "lon": loc.Lon, "failed": failed}
+	if err != nil {
+		fields["err"] = err.Error()
+	}
+	logger.FromContext(ctx, s.l).Warning("cache warming failed, retrying soon", fields)
+
+	return false
+}
+
+// warmCacheInBackground runs WarmCache when the next location is due, until the shutdown
+func (s *WeatherService) warmCacheInBackground() {
+	ctx, end := s.begin(context.Background())
+	go func() {
+		defer end()
+
+		timer := time.NewTimer(0)
+		defer timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+			}
+
+			next := s.WarmCache(ctx)
+			timer.Reset(max(next.Sub(s.prewarm.now()), time.Second))
+		}
+	}()
+}
+
+// cacheRefreshKey marks the context of a fetch refreshing the cache, see withCacheRefresh
+type cacheRefreshKey struct{}
+
+// withCacheRefresh returns a context whose forecast fetches skip the cached forecasts, and cache the fresh ones
+func withCacheRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheRefreshKey{}, true)
+}
+
+// isCacheRefresh reports whether the fetches of ctx refresh the cache
+func isCacheRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(cacheRefreshKey{}).(bool)
+	return refresh
+}
@@ -0,0 +1,78 @@
+package weather_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/services/weather"
+)
+
+func TestWeatherService_WarmCache(t *testing.T) {
+	repo := cachedRepository()
+	clock := &fakeClock{now: time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC)}
+	start := clock.now
+	service := newCachedService(repo,
+		weather.WithForecastCache(10),
+		weather.WithCacheTTL(100*time.Second),
+		weather.WithPrewarm(weather.PrewarmOptions{
+			Locations: []weather.PrewarmLocation{
+				{Name: "Berlin", Lat: 52.52, Lon: 13.41, Days: 3},
+				{Name: "Paris", Lat: 48.8566, Lon: 2.3522},
+			},
+			Now:    clock.Now,
+			Manual: true,
+		}))
+	ctx := context.Background()
+
+	// Refreshed 10 seconds before the TTL, the two locations 45 seconds apart
+	next := service.WarmCache(ctx)
+	assert.Equal(t, 1, repo.callCount)
+	assert.Equal(t, start.Add(45*time.Second), next)
+
+	clock.now = next
+	next = service.WarmCache(ctx)
+	assert.Equal(t, 2, repo.callCount)
+	assert.Equal(t, start.Add(90*time.Second), next)
+
+	clock.advance(15 * time.Second)
+	assert.Equal(t, next, service.WarmCache(ctx), "nothing is due")
+	assert.Equal(t, 2, repo.callCount)
+
+	// The cached forecast is refreshed rather than served
+	clock.now = next
+	next = service.WarmCache(ctx)
+	assert.Equal(t, 3, repo.callCount)
+	assert.Equal(t, start.Add(135*time.Second), next)
+
+	forecasts, err := service.FetchForecasts(ctx, 52.52, 13.41, 3)
+	require.NoError(t, err)
+	assert.True(t, forecasts["open-meteo"].Cached)
+	_, err = service.FetchForecasts(ctx, 40.7128, -74.006, 3)
+	require.NoError(t, err)
+	assert.Equal(t, 4, repo.callCount)
+
+	// A failed refresh is retried a tenth of the TTL later
+	repo.shouldFail = true
+	clock.now = next
+	next = service.WarmCache(ctx)
+	assert.Equal(t, start.Add(145*time.Second), next)
+
+	assert.Equal(t, weather.PrewarmStats{Locations: 2, Refreshes: 4, Failures: 1, Hits: 1}, service.PrewarmStats())
+}
+
+func TestWeatherService_WarmCache_Background(t *testing.T) {
+	repo := cachedRepository()
+	service := newCachedService(repo,
+		weather.WithForecastCache(10),
+		weather.WithPrewarm(weather.PrewarmOptions{Locations: []weather.PrewarmLocation{{Lat: 52.52, Lon: 13.41}}}))
+
+	require.Eventually(t, func() bool { return service.PrewarmStats().Refreshes == 1 }, time.Second, 5*time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, service.Shutdown(ctx), "the warming stops on shutdown")
+}
@@ -0,0 +1,239 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/pkg/logger"
+)
+
+const (
+	// metricsWindow is the number of recent calls of a provider the error rate and the latency cover
+	metricsWindow = 100
+	// healthCheckTimeout bounds a health check, the status of the providers is served quickly
+	healthCheckTimeout = 3 * time.Second
+	// healthCheckInterval is the age beyond which ProviderStatus checks a provider again
+	healthCheckInterval = time.Minute
+)
+
+// The health checks fetch a one day forecast of this location
+const (
+	healthCheckLat = 52.52
+	healthCheckLon = 13.41
+)
+
+// ErrNotReady is returned by Ready when the service can't serve forecasts
+var ErrNotReady = errors.New("weather service not ready")
+
+// providerMetrics records the outcome of the recent calls of every provider
+type providerMetrics struct {
+	mu        sync.Mutex
+	providers map[string]*callWindow
+	// checks shares the health check of a provider between concurrent status requests
+	checks singleflight.Group
+}
+
+// callWindow is a ring of the recent calls of a provider
+type callWindow struct {
+	calls         [metricsWindow]callOutcome
+	next          int
+	size          int
+	lastErrorCode string
+	health        *models.ProviderHealth
+}
+
+type callOutcome struct {
+	failed  bool
+	latency time.Duration
+}
+
+func newProviderMetrics() *providerMetrics {
+	return &providerMetrics{providers: make(map[string]*callWindow)}
+}
+
+// window returns the calls of a provider, m.mu must be held
+func (m *providerMetrics) window(provider string) *callWindow {
+	w, ok := m.providers[provider]
+	if !ok {
+		w = &callWindow{}
+		m.providers[provider] = w
+	}
+
+	return w
+}
+
+// record adds a call to the window of the provider, errorCode is empty when it succeeded
+func (m *providerMetrics) record(provider string, latency time.Duration, errorCode string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w := m.window(provider)
+	w.calls[w.next] = callOutcome{failed: errorCode != "", latency: latency}
+	w.next = (w.next + 1) % metricsWindow
+	w.size = min(w.size+1, metricsWindow)
+	w.lastErrorCode = errorCode
+}
+
+func (m *providerMetrics) setHealth(provider string, health models.ProviderHealth) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.window(provider).health = &health
+}
+
+// status returns the metrics of a provider
+func (m *providerMetrics) status(provider string) models.ProviderStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w := m.window(provider)
+	status := models.ProviderStatus{
+		Name:          provider,
+		Circuit:       models.CircuitNone,
+		Calls:         w.size,
+		LastErrorCode: w.lastErrorCode,
+		RateLimited:   w.lastErrorCode == models.ErrorCodeRateLimited,
+	}
+	if w.health != nil {
+		health := *w.health
+		status.Health = &health
+	}
+	if w.size == 0 {
+		return status
+	}
+
+	var failed int
+	var latency time.Duration
+	for _, call := range w.calls[:w.size] {
+		if call.failed {
+			failed++
+		}
+		latency += call.latency
+	}
+	status.ErrorRate = float64(failed) / float64(w.size)
+	status.AvgLatencyMS = float64(latency.Microseconds()) / float64(w.size) / 1000
+
+	return status
+}
+
+// ProviderStatus returns the status of every forecast provider: the result of its last health check,
+// the error rate and the average latency of its recent calls. The providers never checked, or last
+// checked over a minute ago, are checked first with a short timeout.
+func (s *WeatherService) ProviderStatus(ctx context.Context) []models.ProviderStatus {
+	set := s.current()
+	repos := set.repos
+	var wg sync.WaitGroup
+	for _, repo := range repos {
+		if health := s.metrics.status(repo.Name()).Health; health != nil && time.Since(health.CheckedAt) < healthCheckInterval {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.checkHealth(ctx, repo)
+		}()
+	}
+	wg.Wait()
+
+	statuses := make([]models.ProviderStatus, 0, len(repos))
+	for _, repo := range repos {
+		status := s.metrics.status(repo.Name())
+		status.RequiresKey = repositories.RequiresAPIKey(repo)
+		status.Disabled = !s.ProviderEnabled(repo.Name())
+		status.Quota = s.quotas.status(repo.Name(), set.quotas)
+		statuses = append(statuses, status)
+	}
+
+	return statuses
+}
+
+// checkHealth fetches a short forecast from the provider and records the result, concurrent checks of
+// a provider share the call. The check outlives a canceled request, its result serves the next one.
+func (s *WeatherService) checkHealth(ctx context.Context, repo repositories.WeatherRepository) {
+	_, _, _ = s.metrics.checks.Do(repo.Name(), func() (any, error) {
+		ctx, end := s.begin(context.WithoutCancel(ctx))
+		defer end()
+		ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+		defer cancel()
+
+		start := time.Now()
+		err := s.quotas.take(repo.Name(), s.current().quotas)
+		if err == nil {
+			_, err = repo.FetchForecast(ctx, healthCheckLat, healthCheckLon, 1)
+		}
+		health := models.ProviderHealth{
+			Healthy:    err == nil,
+			CheckedAt:  start.UTC(),
+			DurationMS: time.Since(start).Milliseconds(),
+		}
+		if err != nil {
+			health.ErrorCode, _ = classifyError(err)
+			logger.FromContext(ctx, s.l).Warning("provider health check failed", map[string]any{
+				"repo":       repo.Name(),
+				"err":        err,
+				"error_code": health.ErrorCode,
+			})
+		}
+		s.metrics.setHealth(repo.Name(), health)
+
+		return nil, nil
+	})
+}
+
+// Ready reports whether the service can serve forecasts: a provider is configured and enough of the providers in
+// rotation may be healthy to reach the quorum of the aggregation. The providers are never called, the ones not
+// health checked within the last minute count as healthy.
+func (s *WeatherService) Ready(ctx context.Context) error {
+	repos := s.current().repos
+	if len(repos) == 0 {
+		return fmt.Errorf("%w: no weather provider configured", ErrNotReady)
+	}
+
+	var available, unhealthy int
+	for _, repo := range repos {
+		if !s.ProviderEnabled(repo.Name()) {
+			continue
+		}
+		health := s.metrics.status(repo.Name()).Health
+		if health != nil && !health.Healthy && time.Since(health.CheckedAt) < healthCheckInterval {
+			unhealthy++
+			continue
+		}
+		available++
+	}
+	if available < s.minProviders {
+		return fmt.Errorf("%w: %d providers available, %d unhealthy, the quorum is %d",
+			ErrNotReady, available, unhealthy, s.minProviders)
+	}
+
+	return nil
+}
+
+// SetProviderEnabled puts a provider back in rotation or pulls it out, the forecasts of a disabled provider
+// carry the disabled error code without calling it. The state is kept in memory only.
+func (s *WeatherService) SetProviderEnabled(name string, enabled bool) error {
+	disabled, ok := s.current().disabled[name]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+
+	if disabled.Swap(!enabled) == enabled {
+		s.l.Warning("provider rotation changed", map[string]any{"repo": name, "enabled": enabled})
+	}
+
+	return nil
+}
+
+// ProviderEnabled reports whether a provider is in rotation, unknown providers are reported enabled
+func (s *WeatherService) ProviderEnabled(name string) bool {
+	disabled, ok := s.current().disabled[name]
+	return !ok || !disabled.Load()
+}
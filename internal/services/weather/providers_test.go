@@ -0,0 +1,210 @@
+package weather_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// keyedRepository is a provider requiring an API key
+type keyedRepository struct {
+	*MockRepository
+}
+
+func (r keyedRepository) RequiresAPIKey() bool {
+	return true
+}
+
+func TestProviderStatus_LazyHealthCheck(t *testing.T) {
+	healthy := &MockRepository{name: "open-meteo", forecastData: models.Forecast{RepositoryName: "open-meteo"}}
+	limited := &MockRepository{name: "weatherapi", err: &repositories.HTTPStatusError{StatusCode: 429, Status: "429 Too Many Requests"}}
+	service := weather.NewWeatherService(
+		[]repositories.WeatherRepository{healthy, keyedRepository{limited}},
+		logger.NopLogger{},
+	)
+
+	statuses := service.ProviderStatus(context.Background())
+	require.Len(t, statuses, 2)
+
+	// No forecast was requested, the status comes from the health checks
+	assert.Equal(t, "open-meteo", statuses[0].Name)
+	assert.False(t, statuses[0].RequiresKey)
+	require.NotNil(t, statuses[0].Health)
+	assert.True(t, statuses[0].Health.Healthy)
+	assert.False(t, statuses[0].Health.CheckedAt.IsZero())
+	assert.Equal(t, models.CircuitNone, statuses[0].Circuit)
+	assert.Zero(t, statuses[0].Calls)
+
+	assert.Equal(t, "weatherapi", statuses[1].Name)
+	assert.True(t, statuses[1].RequiresKey)
+	require.NotNil(t, statuses[1].Health)
+	assert.False(t, statuses[1].Health.Healthy)
+	assert.Equal(t, models.ErrorCodeRateLimited, statuses[1].Health.ErrorCode)
+
+	// A recent health check is reused
+	service.ProviderStatus(context.Background())
+	assert.Equal(t, 1, healthy.callCount)
+	assert.Equal(t, 1, limited.callCount)
+}
+
+func TestProviderStatus_Metrics(t *testing.T) {
+	healthy := &MockRepository{name: "open-meteo", forecastData: models.Forecast{RepositoryName: "open-meteo"}}
+	limited := &MockRepository{name: "weatherapi", err: &repositories.HTTPStatusError{StatusCode: 429, Status: "429 Too Many Requests"}}
+	service := weather.NewWeatherService(
+		[]repositories.WeatherRepository{healthy, limited},
+		logger.NopLogger{},
+	)
+
+	for range 3 {
+		_, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+		require.NoError(t, err)
+	}
+
+	statuses := service.ProviderStatus(context.Background())
+	require.Len(t, statuses, 2)
+
+	assert.Equal(t, 3, statuses[0].Calls)
+	assert.Zero(t, statuses[0].ErrorRate)
+	assert.Empty(t, statuses[0].LastErrorCode)
+	assert.False(t, statuses[0].RateLimited)
+	assert.GreaterOrEqual(t, statuses[0].AvgLatencyMS, 0.0)
+
+	// The health checks aren't counted as calls
+	assert.Equal(t, 3, statuses[1].Calls)
+	assert.Equal(t, 1.0, statuses[1].ErrorRate)
+	assert.Equal(t, models.ErrorCodeRateLimited, statuses[1].LastErrorCode)
+	assert.True(t, statuses[1].RateLimited)
+}
+
+func TestProviderStatus_CanceledCallsIgnored(t *testing.T) {
+	slow := &MockRepository{name: "slow", shouldDelay: true}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{slow}, logger.NopLogger{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, _ = service.FetchForecasts(ctx, 52.52, 13.41, 1)
+
+	statuses := service.ProviderStatus(context.Background())
+	require.Len(t, statuses, 1)
+	assert.Zero(t, statuses[0].Calls)
+}
+
+func TestWeatherService_Ready(t *testing.T) {
+	ctx := context.Background()
+	assert.ErrorIs(t, weather.NewWeatherService(nil, logger.NopLogger{}).Ready(ctx), weather.ErrNotReady)
+
+	healthy := &MockRepository{name: "open-meteo", forecastData: models.Forecast{RepositoryName: "open-meteo"}}
+	failing := &MockRepository{name: "weatherapi", err: context.DeadlineExceeded}
+	repos := []repositories.WeatherRepository{healthy, failing}
+
+	// The providers not checked yet count as healthy
+	service := weather.NewWeatherService(repos, logger.NopLogger{}, weather.WithMinProviders(2))
+	require.NoError(t, service.Ready(ctx))
+	assert.Zero(t, healthy.callCount)
+
+	// A failed health check breaks the quorum of 2, not the default one
+	service.ProviderStatus(ctx)
+	assert.ErrorIs(t, service.Ready(ctx), weather.ErrNotReady)
+	service = weather.NewWeatherService(repos, logger.NopLogger{})
+	service.ProviderStatus(ctx)
+	require.NoError(t, service.Ready(ctx))
+
+	// Neither do the providers out of rotation
+	require.NoError(t, service.SetProviderEnabled("open-meteo", false))
+	assert.ErrorIs(t, service.Ready(ctx), weather.ErrNotReady)
+}
+
+func TestSetProviderEnabled(t *testing.T) {
+	openMeteo := &MockRepository{name: "open-meteo", forecastData: models.Forecast{RepositoryName: "open-meteo"}}
+	weatherAPI := &MockRepository{name: "weatherapi", forecastData: models.Forecast{RepositoryName: "weatherapi"}}
+	service := weather.NewWeatherService(
+		[]repositories.WeatherRepository{openMeteo, weatherAPI},
+		logger.NopLogger{},
+	)
+
+	require.NoError(t, service.SetProviderEnabled("weatherapi", false))
+	assert.False(t, service.ProviderEnabled("weatherapi"))
+
+	forecasts, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+	assert.Empty(t, forecasts["open-meteo"].ErrorCode)
+	assert.Equal(t, models.ErrorCodeDisabled, forecasts["weatherapi"].ErrorCode)
+	assert.Equal(t, "provider disabled", forecasts["weatherapi"].Error)
+	assert.Zero(t, weatherAPI.callCount, "a disabled provider was called")
+
+	first, err := service.FetchFirstForecast(context.Background(), 52.52, 13.41, 1, []string{"weatherapi"})
+	require.ErrorIs(t, err, weather.ErrNoForecasts)
+	assert.Empty(t, first.RepositoryName)
+
+	statuses := service.ProviderStatus(context.Background())
+	require.Len(t, statuses, 2)
+	assert.False(t, statuses[0].Disabled)
+	assert.True(t, statuses[1].Disabled)
+
+	require.NoError(t, service.SetProviderEnabled("weatherapi", true))
+	forecasts, err = service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+	assert.Empty(t, forecasts["weatherapi"].ErrorCode)
+
+	err = service.SetProviderEnabled("accuweather", false)
+	require.ErrorIs(t, err, weather.ErrUnknownProvider)
+	assert.EqualError(t, err, "unknown weather provider: accuweather")
+}
+
+func TestSetProviderEnabled_WhileFetching(t *testing.T) {
+	l := logger.NopLogger{}
+	service := weather.NewWeatherService(
+		[]repositories.WeatherRepository{repositories.NewMockWeatherRepository(time.Millisecond, 0, l)},
+		l,
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var toggles sync.WaitGroup
+	toggles.Add(1)
+	go func() {
+		defer toggles.Done()
+		for enabled := false; ctx.Err() == nil; enabled = !enabled {
+			assert.NoError(t, service.SetProviderEnabled("mock", enabled))
+		}
+	}()
+
+	var fetches sync.WaitGroup
+	for range 8 {
+		fetches.Add(1)
+		go func() {
+			defer fetches.Done()
+			for range 20 {
+				forecasts, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+				if !assert.NoError(t, err) {
+					return
+				}
+				// Every forecast is either served or skipped, never half of both
+				forecast := forecasts["mock"]
+				if forecast.ErrorCode == "" {
+					assert.Len(t, forecast.ForecastData, 1)
+				} else {
+					assert.Equal(t, models.ErrorCodeDisabled, forecast.ErrorCode)
+					assert.Empty(t, forecast.ForecastData)
+				}
+			}
+		}()
+	}
+
+	fetches.Wait()
+	cancel()
+	toggles.Wait()
+
+	require.NoError(t, service.SetProviderEnabled("mock", true))
+	forecasts, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+	assert.Empty(t, forecasts["mock"].ErrorCode)
+}
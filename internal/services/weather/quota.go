@@ -0,0 +1,274 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"time"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/pkg/logger"
+)
+
+// The reset periods of a quota, the periods start at midnight UTC
+const (
+	QuotaDaily   = "daily"
+	QuotaMonthly = "monthly"
+)
+
+const (
+	// quotaSaveInterval is how often the usage of the quotas is saved to the forecast store
+	quotaSaveInterval = time.Minute
+	quotaLoadTimeout  = 5 * time.Second
+)
+
+// ErrQuotaExhausted is returned without calling a provider whose enforced quota is used up
+var ErrQuotaExhausted = errors.New("provider quota exhausted")
+
+// Quota limits the calls to a provider over a period, Period is QuotaDaily or QuotaMonthly
+type Quota struct {
+	Limit  int64
+	Period string
+	// Enforce skips the provider once the limit is reached, the calls beyond it are only counted otherwise
+	Enforce bool
+}
+
+// QuotaOptions configures the quotas of the providers, see WithQuotas
+type QuotaOptions struct {
+	// Quotas are keyed by provider name, the providers without one are not counted
+	Quotas map[string]Quota
+	// Now is the clock of the periods, time.Now when nil
+	Now func() time.Time
+}
+
+// quotas counts the calls to the providers in the current period of their quota, the quotas themselves are kept
+// by the providerSet so they are replaced with the providers
+type quotas struct {
+	now func() time.Time
+
+	// mu guards the usage
+	mu    sync.Mutex
+	usage map[string]*quotaUsage
+}
+
+// quotaUsage is the number of calls in the period starting at start, dirty until saved to the store
+type quotaUsage struct {
+	start time.Time
+	used  int64
+	dirty bool
+}
+
+func newQuotas() *quotas {
+	return &quotas{
+		now:   time.Now,
+		usage: map[string]*quotaUsage{},
+	}
+}
+
+// WithQuotas counts the calls to the providers against their quota, every call including the health checks. The
+// usage is kept by the forecast store, when it keeps it, across restarts.
+func WithQuotas(opts QuotaOptions) Option {
+	return func(s *WeatherService) {
+		if opts.Now != nil {
+			s.quotas.now = opts.Now
+		}
+		s.current().quotas = maps.Clone(opts.Quotas)
+	}
+}
+
+// SetQuotas replaces the quotas of the current providers, the calls counted in the current period are kept. The
+// quotas replaced with the providers go through SetProviders.
+func (s *WeatherService) SetQuotas(limits map[string]Quota) {
+	s.setProviders.Lock()
+	defer s.setProviders.Unlock()
+
+	set := *s.current()
+	set.quotas = maps.Clone(limits)
+	s.providers.Store(&set)
+}
+
+// periodStart returns the start of the period of the quota containing now
+func periodStart(period string, now time.Time) time.Time {
+	now = now.UTC()
+	if period == QuotaMonthly {
+		return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// periodEnd returns the start of the period following the one starting at start
+func periodEnd(period string, start time.Time) time.Time {
+	if period == QuotaMonthly {
+		return start.AddDate(0, 1, 0)
+	}
+
+	return start.AddDate(0, 0, 1)
+}
+
+// current returns the usage of the provider in the current period, a new period starts from zero, q.mu is held
+func (q *quotas) current(provider string, limit Quota) *quotaUsage {
+	start := periodStart(limit.Period, q.now())
+	u, ok := q.usage[provider]
+	if !ok || !u.start.Equal(start) {
+		u = &quotaUsage{start: start, dirty: true}
+		q.usage[provider] = u
+	}
+
+	return u
+}
+
+// take counts a call to the provider, ErrQuotaExhausted refuses it when its enforced quota of limits is used up
+func (q *quotas) take(provider string, limits map[string]Quota) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	limit, ok := limits[provider]
+	if !ok {
+		return nil
+	}
+
+	u := q.current(provider, limit)
+	if limit.Enforce && u.used >= limit.Limit {
+		return ErrQuotaExhausted
+	}
+	u.used++
+	u.dirty = true
+
+	return nil
+}
+
+// status returns the usage of the quota of the provider in limits, nil when it has none
+func (q *quotas) status(provider string, limits map[string]Quota) *models.ProviderQuota {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	limit, ok := limits[provider]
+	if !ok {
+		return nil
+	}
+
+	u := q.current(provider, limit)
+	return &models.ProviderQuota{
+		Limit:     limit.Limit,
+		Used:      u.used,
+		Remaining: max(limit.Limit-u.used, 0),
+		Period:    limit.Period,
+		ResetsAt:  periodEnd(limit.Period, u.start),
+		Enforced:  limit.Enforce,
+	}
+}
+
+// QuotaUsage returns the usage of the quotas of the providers, keyed by provider name
+func (s *WeatherService) QuotaUsage() map[string]models.ProviderQuota {
+	limits := s.current().quotas
+	usage := make(map[string]models.ProviderQuota, len(limits))
+	for provider := range limits {
+		if status := s.quotas.status(provider, limits); status != nil {
+			usage[provider] = *status
+		}
+	}
+
+	return usage
+}
+
+// quotaStore returns the store of the quota usage, false when the forecast store doesn't keep it
+func (s *WeatherService) quotaStore() (repositories.QuotaStore, bool) {
+	if s.store == nil {
+		return nil, false
+	}
+	store, ok := s.store.store.(repositories.QuotaStore)
+
+	return store, ok
+}
+
+// loadQuotas restores the usage of the current periods saved by the previous process
+func (s *WeatherService) loadQuotas() {
+	store, ok := s.quotaStore()
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), quotaLoadTimeout)
+	defer cancel()
+
+	saved, err := store.QuotaUsage(ctx)
+	if err != nil {
+		s.l.Error(err, map[string]any{"job": "quotas"})
+		return
+	}
+
+	limits := s.current().quotas
+	q := s.quotas
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, usage := range saved {
+		limit, ok := limits[usage.Provider]
+		if !ok {
+			continue
+		}
+		if u := q.current(usage.Provider, limit); u.start.Equal(usage.PeriodStart) {
+			u.used += usage.Used
+		}
+	}
+}
+
+// saveQuotas saves the usage of the quotas counted since the last save to the forecast store
+func (s *WeatherService) saveQuotas(ctx context.Context) error {
+	store, ok := s.quotaStore()
+	if !ok {
+		return nil
+	}
+
+	q := s.quotas
+	q.mu.Lock()
+	var dirty []models.QuotaUsage
+	for provider, u := range q.usage {
+		if u.dirty {
+			dirty = append(dirty, models.QuotaUsage{Provider: provider, PeriodStart: u.start, Used: u.used})
+			u.dirty = false
+		}
+	}
+	q.mu.Unlock()
+	if len(dirty) == 0 {
+		return nil
+	}
+
+	if err := store.SaveQuotaUsage(ctx, dirty); err != nil {
+		// Saved again by the next save, unless a newer period replaced it
+		q.mu.Lock()
+		for _, usage := range dirty {
+			if u, ok := q.usage[usage.Provider]; ok && u.start.Equal(usage.PeriodStart) {
+				u.dirty = true
+			}
+		}
+		q.mu.Unlock()
+
+		return err
+	}
+
+	return nil
+}
+
+// saveQuotasEvery runs saveQuotas every interval until the shutdown, which saves them a last time
+func (s *WeatherService) saveQuotasEvery(interval time.Duration) {
+	ctx, end := s.begin(context.Background())
+	go func() {
+		defer end()
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.saveQuotas(ctx); err != nil && ctx.Err() == nil {
+					logger.FromContext(ctx, s.l).Error(err, map[string]any{"job": "quotas"})
+				}
+			}
+		}
+	}()
+}
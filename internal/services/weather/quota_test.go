@@ -0,0 +1,139 @@
+package weather_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+)
+
+func newQuotaService(repo *MockRepository, clock *fakeClock, quota weather.Quota, opts ...weather.Option) *weather.WeatherService {
+	opts = append(opts, weather.WithQuotas(weather.QuotaOptions{
+		Quotas: map[string]weather.Quota{repo.name: quota},
+		Now:    clock.Now,
+	}))
+
+	return newCachedService(repo, opts...)
+}
+
+func TestWeatherService_Quota_Daily(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 25, 23, 0, 0, 0, time.UTC)}
+	repo := cachedRepository()
+	service := newQuotaService(repo, clock, weather.Quota{Limit: 2, Period: weather.QuotaDaily})
+
+	for range 3 {
+		_, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+		require.NoError(t, err)
+	}
+
+	// The calls beyond the limit are counted, but not refused
+	usage := service.QuotaUsage()["open-meteo"]
+	assert.Equal(t, 3, repo.callCount)
+	assert.Equal(t, int64(3), usage.Used)
+	assert.Equal(t, int64(0), usage.Remaining)
+	assert.False(t, usage.Enforced)
+	assert.Equal(t, time.Date(2025, 7, 26, 0, 0, 0, 0, time.UTC), usage.ResetsAt)
+
+	// The quota resets at midnight UTC
+	clock.advance(time.Hour)
+	usage = service.QuotaUsage()["open-meteo"]
+	assert.Equal(t, int64(0), usage.Used)
+	assert.Equal(t, int64(2), usage.Remaining)
+	assert.Equal(t, time.Date(2025, 7, 27, 0, 0, 0, 0, time.UTC), usage.ResetsAt)
+}
+
+func TestWeatherService_Quota_Monthly(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 31, 12, 0, 0, 0, time.UTC)}
+	repo := cachedRepository()
+	service := newQuotaService(repo, clock, weather.Quota{Limit: 100, Period: weather.QuotaMonthly})
+
+	_, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+
+	// The next day is in the same month until the first of August
+	clock.advance(6 * time.Hour)
+	usage := service.QuotaUsage()["open-meteo"]
+	assert.Equal(t, int64(1), usage.Used)
+	assert.Equal(t, time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), usage.ResetsAt)
+
+	clock.advance(6 * time.Hour)
+	usage = service.QuotaUsage()["open-meteo"]
+	assert.Equal(t, int64(0), usage.Used)
+	assert.Equal(t, time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC), usage.ResetsAt)
+}
+
+func TestWeatherService_Quota_Enforced(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)}
+	repo := cachedRepository()
+	service := newQuotaService(repo, clock, weather.Quota{Limit: 1, Period: weather.QuotaDaily, Enforce: true})
+
+	_, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+
+	// The provider is skipped without being called until the reset
+	results, _ := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	assert.Equal(t, 1, repo.callCount)
+	assert.Equal(t, models.ErrorCodeQuotaExhausted, results["open-meteo"].ErrorCode)
+
+	clock.advance(12 * time.Hour)
+	_, err = service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+	assert.Equal(t, 2, repo.callCount)
+}
+
+func TestWeatherService_Quota_ProviderStatus(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)}
+	repo := cachedRepository()
+	service := newQuotaService(repo, clock, weather.Quota{Limit: 10, Period: weather.QuotaDaily, Enforce: true})
+
+	// The health check is a call to the provider as well
+	statuses := service.ProviderStatus(context.Background())
+	require.Len(t, statuses, 1)
+	require.NotNil(t, statuses[0].Quota)
+	assert.Equal(t, models.ProviderQuota{
+		Limit:     10,
+		Used:      1,
+		Remaining: 9,
+		Period:    weather.QuotaDaily,
+		ResetsAt:  time.Date(2025, 7, 26, 0, 0, 0, 0, time.UTC),
+		Enforced:  true,
+	}, *statuses[0].Quota)
+
+	// The providers without a quota report none
+	service.SetQuotas(nil)
+	statuses = service.ProviderStatus(context.Background())
+	assert.Nil(t, statuses[0].Quota)
+}
+
+func TestWeatherService_Quota_Persisted(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)}
+	quota := weather.Quota{Limit: 10, Period: weather.QuotaDaily}
+	store, err := repositories.OpenSQLiteForecastStore(filepath.Join(t.TempDir(), "forecasts.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	service := newQuotaService(cachedRepository(), clock, quota, weather.WithForecastStore(store, 10))
+	for range 3 {
+		_, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, service.Shutdown(context.Background()))
+
+	// The usage saved on shutdown is restored by the next process
+	restarted := newQuotaService(cachedRepository(), clock, quota, weather.WithForecastStore(store, 10))
+	defer restarted.Shutdown(context.Background())
+	assert.Equal(t, int64(3), restarted.QuotaUsage()["open-meteo"].Used)
+
+	// Unless its period is over
+	clock.advance(24 * time.Hour)
+	stale := newQuotaService(cachedRepository(), clock, quota, weather.WithForecastStore(store, 10))
+	defer stale.Shutdown(context.Background())
+	assert.Equal(t, int64(0), stale.QuotaUsage()["open-meteo"].Used)
+}
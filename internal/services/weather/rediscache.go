@@ -0,0 +1,211 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"weather-api/internal/models"
+	"weather-api/pkg/logger"
+)
+
+// redisTimeout bounds every call to Redis, an unreachable server delays a request by that much at most
+const redisTimeout = 250 * time.Millisecond
+
+// redisErrorLogInterval throttles the logs of a failing Redis, every request would log otherwise
+const redisErrorLogInterval = time.Minute
+
+// RedisCacheOptions configures a RedisCache
+type RedisCacheOptions struct {
+	Address  string
+	Password string
+	DB       int
+	// KeyPrefix namespaces the keys of the forecasts, e.g. by environment when the replicas of several share
+	// the server
+	KeyPrefix string
+	TTL       time.Duration
+}
+
+// RedisCache keeps the provider forecasts in Redis as JSON, shared by the replicas of the service. A failing
+// Redis is passed through: a read is a miss and a write is skipped, both logged and counted in Errors.
+type RedisCache struct {
+	client *redis.Client
+	prefix string
+	ttl    time.Duration
+	l      logger.Logger
+	// errorLog throttles the logs of the failed operations
+	errorLog *logger.Throttle
+
+	hits   atomic.Int64
+	misses atomic.Int64
+	errors atomic.Int64
+}
+
+var _ Cache = (*RedisCache)(nil)
+
+// NewRedisCache returns a cache in the Redis server of opts, the connection is established on the first use
+func NewRedisCache(opts RedisCacheOptions, l logger.Logger) *RedisCache {
+	return &RedisCache{
+		client: redis.NewClient(&redis.Options{
+			Addr:         opts.Address,
+			Password:     opts.Password,
+			DB:           opts.DB,
+			DialTimeout:  redisTimeout,
+			ReadTimeout:  redisTimeout,
+			WriteTimeout: redisTimeout,
+			// A failed call is retried by the next request rather than delaying this one
+			MaxRetries: -1,
+		}),
+		prefix:   opts.KeyPrefix + "forecast:",
+		ttl:      opts.TTL,
+		l:        l,
+		errorLog: logger.NewThrottle(0, redisErrorLogInterval),
+	}
+}
+
+// Get returns the cached forecast, a Redis failure is a miss
+func (c *RedisCache) Get(ctx context.Context, key CacheKey) (models.Forecast, bool) {
+	data, err := c.client.Get(ctx, c.key(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		c.misses.Add(1)
+		return models.Forecast{}, false
+	}
+	if err != nil {
+		c.fail(ctx, "get", err)
+		c.misses.Add(1)
+		return models.Forecast{}, false
+	}
+
+	var forecast models.Forecast
+	if err := json.Unmarshal(data, &forecast); err != nil {
+		c.fail(ctx, "decode", err)
+		c.misses.Add(1)
+		return models.Forecast{}, false
+	}
+	c.hits.Add(1)
+
+	return forecast, true
+}
+
+// Set caches the forecast for the TTL, a Redis failure skips it
+func (c *RedisCache) Set(ctx context.Context, key CacheKey, forecast models.Forecast) {
+	data, err := json.Marshal(forecast)
+	if err != nil {
+		c.fail(ctx, "encode", err)
+		return
+	}
+	if err := c.client.Set(ctx, c.key(key), data, c.ttl).Err(); err != nil {
+		c.fail(ctx, "set", err)
+	}
+}
+
+// Purge removes the forecasts of every replica matching the filter, nil removes them all
+func (c *RedisCache) Purge(ctx context.Context, match func(key CacheKey) bool) int {
+	var purged int
+	iter := c.client.Scan(ctx, 0, c.prefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		if match != nil {
+			key, ok := c.parseKey(iter.Val())
+			if !ok || !match(key) {
+				continue
+			}
+		}
+		n, err := c.client.Del(ctx, iter.Val()).Result()
+		if err != nil {
+			c.fail(ctx, "purge", err)
+			return purged
+		}
+		purged += int(n)
+	}
+	if err := iter.Err(); err != nil {
+		c.fail(ctx, "purge", err)
+	}
+
+	return purged
+}
+
+// Stats returns the counters of this replica and the number of forecasts cached by all of them
+func (c *RedisCache) Stats(ctx context.Context) CacheStats {
+	var entries int
+	iter := c.client.Scan(ctx, 0, c.prefix+"*", 0).Iterator()
+	for iter.Next(ctx) {
+		entries++
+	}
+	if err := iter.Err(); err != nil {
+		c.fail(ctx, "stats", err)
+	}
+
+	return CacheStats{
+		Enabled:    true,
+		Backend:    CacheBackendRedis,
+		Entries:    entries,
+		TTLSeconds: int(c.ttl.Seconds()),
+		Hits:       c.hits.Load(),
+		Misses:     c.misses.Load(),
+		Errors:     c.errors.Load(),
+	}
+}
+
+// Errors returns the number of failed operations
+func (c *RedisCache) Errors() int64 {
+	return c.errors.Load()
+}
+
+// Ready fails when Redis doesn't answer, for the readiness probe
+func (c *RedisCache) Ready(ctx context.Context) error {
+	if err := c.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("%w: redis cache unreachable: %v", ErrNotReady, err)
+	}
+
+	return nil
+}
+
+// Close closes the connections to Redis
+func (c *RedisCache) Close() error {
+	return c.client.Close()
+}
+
+// fail counts and logs a failed operation, the logs of a Redis failing repeatedly are throttled
+func (c *RedisCache) fail(ctx context.Context, op string, err error) {
+	c.errors.Add(1)
+	if ok, suppressed, _ := c.errorLog.Allow(op); ok {
+		logger.FromContext(ctx, c.l).Warning("redis cache unavailable, the forecasts are not cached", map[string]any{
+			"op":         op,
+			"err":        err.Error(),
+			"suppressed": suppressed,
+		})
+	}
+}
+
+// key is the Redis key of a forecast, prefix + provider:lat:lon:window
+func (c *RedisCache) key(key CacheKey) string {
+	return c.prefix + strings.Join([]string{
+		key.Provider,
+		strconv.FormatFloat(key.Lat, 'f', -1, 64),
+		strconv.FormatFloat(key.Lon, 'f', -1, 64),
+		strconv.Itoa(key.Window),
+	}, ":")
+}
+
+// parseKey is the reverse of key
+func (c *RedisCache) parseKey(s string) (CacheKey, bool) {
+	parts := strings.Split(strings.TrimPrefix(s, c.prefix), ":")
+	if len(parts) != 4 {
+		return CacheKey{}, false
+	}
+	lat, latErr := strconv.ParseFloat(parts[1], 64)
+	lon, lonErr := strconv.ParseFloat(parts[2], 64)
+	window, windowErr := strconv.Atoi(parts[3])
+	if latErr != nil || lonErr != nil || windowErr != nil {
+		return CacheKey{}, false
+	}
+
+	return CacheKey{Provider: parts[0], Lat: lat, Lon: lon, Window: window}, true
+}
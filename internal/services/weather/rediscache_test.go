@@ -0,0 +1,120 @@
+package weather_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+func newRedisCache(t *testing.T, prefix string) (*weather.RedisCache, *miniredis.Miniredis) {
+	t.Helper()
+	server := miniredis.RunT(t)
+	cache := weather.NewRedisCache(weather.RedisCacheOptions{
+		Address:   server.Addr(),
+		KeyPrefix: prefix,
+		TTL:       time.Minute,
+	}, logger.NopLogger{})
+	t.Cleanup(func() { _ = cache.Close() })
+
+	return cache, server
+}
+
+func TestRedisCache(t *testing.T) {
+	cache, server := newRedisCache(t, "test:")
+	ctx := context.Background()
+	date, err := models.ParseDate("2025-07-25")
+	require.NoError(t, err)
+	fetchedAt := time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC)
+
+	key := weather.CacheKey{Provider: "open-meteo", Lat: 52.52, Lon: 13.41, Window: 1}
+	forecast := models.Forecast{
+		RepositoryName: "open-meteo",
+		Lat:            52.52,
+		Lon:            13.41,
+		ForecastWindow: 1,
+		Timezone:       &models.Timezone{Name: "Europe/Berlin", UTCOffsetSeconds: 7200},
+		FetchMetadata:  models.FetchMetadata{FetchedAt: fetchedAt, FetchDurationMS: 120},
+		ForecastData:   []models.WeatherData{{Date: date, TempMax: 25.5, TempMin: 15.2, Condition: models.ConditionRain}},
+	}
+
+	_, ok := cache.Get(ctx, key)
+	assert.False(t, ok)
+
+	cache.Set(ctx, key, forecast)
+	assert.True(t, server.Exists("test:forecast:open-meteo:52.52:13.41:1"))
+	assert.Equal(t, time.Minute, server.TTL("test:forecast:open-meteo:52.52:13.41:1"))
+
+	cached, ok := cache.Get(ctx, key)
+	require.True(t, ok)
+	assert.Equal(t, forecast, cached)
+
+	// Another replica sees the forecast
+	replica := weather.NewRedisCache(weather.RedisCacheOptions{Address: server.Addr(), KeyPrefix: "test:", TTL: time.Minute}, logger.NopLogger{})
+	defer replica.Close()
+	_, ok = replica.Get(ctx, key)
+	assert.True(t, ok)
+
+	server.FastForward(time.Minute)
+	_, ok = cache.Get(ctx, key)
+	assert.False(t, ok, "expired")
+
+	stats := cache.Stats(ctx)
+	assert.Equal(t, weather.CacheStats{Enabled: true, Backend: "redis", TTLSeconds: 60, Hits: 1, Misses: 2}, stats)
+}
+
+func TestRedisCache_Purge(t *testing.T) {
+	cache, server := newRedisCache(t, "")
+	ctx := context.Background()
+	forecast := models.Forecast{RepositoryName: "open-meteo"}
+
+	cache.Set(ctx, weather.CacheKey{Provider: "open-meteo", Lat: 52.52, Lon: 13.41, Window: 1}, forecast)
+	cache.Set(ctx, weather.CacheKey{Provider: "weatherapi", Lat: 52.52, Lon: 13.41, Window: 3}, forecast)
+	cache.Set(ctx, weather.CacheKey{Provider: "open-meteo", Lat: -33.8688, Lon: 151.2093, Window: 1}, forecast)
+	// Not a forecast of the cache
+	require.NoError(t, server.Set("session:1", "x"))
+	assert.Equal(t, 3, cache.Stats(ctx).Entries)
+
+	purged := cache.Purge(ctx, func(key weather.CacheKey) bool { return key.Lat == 52.52 && key.Lon == 13.41 })
+	assert.Equal(t, 2, purged)
+	assert.Equal(t, 1, cache.Stats(ctx).Entries)
+	assert.True(t, server.Exists("forecast:open-meteo:-33.8688:151.2093:1"))
+
+	assert.Equal(t, 1, cache.Purge(ctx, nil))
+	assert.True(t, server.Exists("session:1"))
+}
+
+func TestRedisCache_Unreachable(t *testing.T) {
+	cache, server := newRedisCache(t, "")
+	repo := cachedRepository()
+	service := newCachedService(repo, weather.WithCache(cache))
+	ctx := context.Background()
+
+	require.NoError(t, cache.Ready(ctx))
+	_, err := service.FetchForecasts(ctx, 52.52, 13.41, 1)
+	require.NoError(t, err)
+	forecasts, err := service.FetchForecasts(ctx, 52.52, 13.41, 1)
+	require.NoError(t, err)
+	assert.True(t, forecasts["open-meteo"].Cached)
+	assert.Equal(t, 1, repo.callCount)
+
+	// The requests are passed through to the providers
+	server.Close()
+	forecasts, err = service.FetchForecasts(ctx, 52.52, 13.41, 1)
+	require.NoError(t, err)
+	assert.Empty(t, forecasts["open-meteo"].Error)
+	assert.False(t, forecasts["open-meteo"].Cached)
+	assert.Equal(t, 2, repo.callCount)
+	assert.Equal(t, int64(2), cache.Errors(), "the get and the set failed")
+
+	assert.ErrorIs(t, cache.Ready(ctx), weather.ErrNotReady)
+	assert.Zero(t, service.PurgeCache())
+	assert.Positive(t, service.CacheStats().Errors)
+}
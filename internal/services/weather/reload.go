@@ -0,0 +1,77 @@
+package weather
+
+import (
+	"maps"
+	"sync/atomic"
+	"time"
+
+	"weather-api/internal/repositories"
+)
+
+// providerSet are the forecast providers and their settings, replaced at once by SetProviders
+type providerSet struct {
+	repos []repositories.WeatherRepository
+	// disabled flags the providers pulled out of rotation at runtime, keyed by provider name
+	disabled map[string]*atomic.Bool
+	// timeouts bound every provider request, missing providers use defaultProviderTimeout
+	timeouts map[string]time.Duration
+	// weights of the providers in the weighted mean aggregation, missing providers weigh 1
+	weights map[string]float64
+	// slots bound the concurrent calls to the providers, missing providers are not limited
+	slots map[string]providerSlots
+	// quotas of the providers, missing providers are not counted
+	quotas map[string]Quota
+}
+
+// ProviderSettings are the settings of the forecast providers keyed by provider name, see SetProviders
+type ProviderSettings struct {
+	// Timeouts bound every provider request, missing providers use the default timeout
+	Timeouts map[string]time.Duration
+	// Weights of the providers in the weighted mean aggregation, missing providers weigh 1
+	Weights map[string]float64
+	// ConcurrencyLimits bound the concurrent calls to the providers, zero means no limit
+	ConcurrencyLimits map[string]int
+	// Quotas count the calls to the providers, missing providers are not counted
+	Quotas map[string]Quota
+}
+
+// newProviderSet returns the set of repos, the providers of previous keep their rotation state
+func newProviderSet(repos []repositories.WeatherRepository, previous *providerSet) *providerSet {
+	set := &providerSet{repos: repos, disabled: make(map[string]*atomic.Bool, len(repos))}
+	for _, repo := range repos {
+		disabled, ok := previous.disabled[repo.Name()]
+		if !ok {
+			disabled = &atomic.Bool{}
+		}
+		set.disabled[repo.Name()] = disabled
+	}
+
+	return set
+}
+
+// current returns the providers serving the requests, a request reads them once to see a consistent set
+func (s *WeatherService) current() *providerSet {
+	return s.providers.Load()
+}
+
+// SetProviders replaces the forecast providers with their settings at once, e.g. when the configuration is
+// reloaded, a request never sees the new providers with the old limits or quotas. The requests in flight finish
+// with the providers they started with. The providers kept by name keep their rotation state, metrics, cached
+// forecasts, the calls counted against their quota and their concurrency slots when their limit is unchanged.
+func (s *WeatherService) SetProviders(repos []repositories.WeatherRepository, settings ProviderSettings) {
+	s.setProviders.Lock()
+	defer s.setProviders.Unlock()
+
+	current := s.current()
+	set := newProviderSet(repos, current)
+	set.timeouts = settings.Timeouts
+	set.weights = settings.Weights
+	set.slots = newProviderSlots(settings.ConcurrencyLimits, current.slots)
+	set.quotas = maps.Clone(settings.Quotas)
+	s.providers.Store(set)
+}
+
+// Canaries returns the canary groups among the current providers
+func (s *WeatherService) Canaries() []*repositories.CanaryRepository {
+	return repositories.Canaries(s.current().repos)
+}
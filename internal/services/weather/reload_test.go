@@ -0,0 +1,91 @@
+package weather_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+func TestWeatherService_SetProviders(t *testing.T) {
+	repo := func(name string) *MockRepository {
+		return &MockRepository{name: name, forecastData: models.Forecast{RepositoryName: name}}
+	}
+	removed, kept := repo("removed-repo"), repo("kept-repo")
+	service := weather.NewWeatherService([]repositories.WeatherRepository{removed, kept}, logger.NopLogger{})
+	require.NoError(t, service.SetProviderEnabled("kept-repo", false))
+
+	added := repo("added-repo")
+	service.SetProviders([]repositories.WeatherRepository{kept, added}, weather.ProviderSettings{
+		Timeouts: map[string]time.Duration{"added-repo": time.Second},
+		Weights:  map[string]float64{"added-repo": 2},
+	})
+
+	assert.Equal(t, []string{"kept-repo", "added-repo"}, service.Providers())
+	assert.False(t, service.ProviderEnabled("kept-repo"), "the rotation state is kept across the reload")
+	assert.True(t, service.ProviderEnabled("added-repo"))
+	assert.ErrorIs(t, service.SetProviderEnabled("removed-repo", true), weather.ErrUnknownProvider)
+
+	forecasts, err := service.FetchForecasts(context.Background(), 40.7128, -74.0060, 1)
+	require.NoError(t, err)
+	assert.Len(t, forecasts, 2)
+	assert.Equal(t, models.ErrorCodeDisabled, forecasts["kept-repo"].ErrorCode)
+	assert.Empty(t, forecasts["added-repo"].Error)
+	assert.Equal(t, 1, added.callCount)
+	assert.Zero(t, removed.callCount)
+	assert.Zero(t, kept.callCount)
+}
+
+func TestWeatherService_SetProviders_ConcurrencyLimits(t *testing.T) {
+	probe := &concurrencyProbe{}
+	repos := slowRepositories(1, probe)
+	service := weather.NewWeatherService(repos, logger.NopLogger{})
+	service.SetProviders(repos, weather.ProviderSettings{ConcurrencyLimits: map[string]int{"slow-repo-1": 1}})
+
+	done := make(chan struct{})
+	for i := 0; i < 3; i++ {
+		go func() {
+			_, _ = service.FetchForecasts(context.Background(), 40.7128, -74.0060, 1)
+			done <- struct{}{}
+		}()
+	}
+	for i := 0; i < 3; i++ {
+		<-done
+	}
+
+	assert.Equal(t, int64(1), probe.peak.Load())
+	assert.Equal(t, int64(0), service.InFlight())
+}
+
+func TestWeatherService_SetProviders_Quotas(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)}
+	repo := cachedRepository()
+	service := newQuotaService(repo, clock, weather.Quota{Limit: 10, Period: weather.QuotaDaily})
+
+	_, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+
+	// The quota is replaced with the providers, the calls counted in the period are kept
+	service.SetProviders([]repositories.WeatherRepository{repo}, weather.ProviderSettings{
+		Quotas: map[string]weather.Quota{"open-meteo": {Limit: 1, Period: weather.QuotaDaily, Enforce: true}},
+	})
+	usage := service.QuotaUsage()["open-meteo"]
+	assert.Equal(t, int64(1), usage.Limit)
+	assert.Equal(t, int64(1), usage.Used)
+	assert.True(t, usage.Enforced)
+
+	results, _ := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	assert.Equal(t, models.ErrorCodeQuotaExhausted, results["open-meteo"].ErrorCode)
+	assert.Equal(t, 1, repo.callCount)
+
+	// Providers reloaded without a quota are no longer counted
+	service.SetProviders([]repositories.WeatherRepository{repo}, weather.ProviderSettings{})
+	assert.Empty(t, service.QuotaUsage())
+}
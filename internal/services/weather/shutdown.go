@@ -0,0 +1,69 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// operations tracks the operations of the service in flight, their context is canceled on shutdown
+type operations struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// mu orders the operations begun before the shutdown with its wait
+	mu      sync.RWMutex
+	closing bool
+	wg      sync.WaitGroup
+}
+
+func newOperations() *operations {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &operations{ctx: ctx, cancel: cancel}
+}
+
+// begin tracks an operation until end is called, its context is canceled on shutdown. The operations begun after
+// the shutdown are canceled right away.
+func (s *WeatherService) begin(ctx context.Context) (_ context.Context, end func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	stop := context.AfterFunc(s.ops.ctx, cancel)
+
+	s.ops.mu.RLock()
+	defer s.ops.mu.RUnlock()
+	if s.ops.closing {
+		return ctx, func() { stop(); cancel() }
+	}
+	s.ops.wg.Add(1)
+
+	return ctx, func() {
+		stop()
+		cancel()
+		s.ops.wg.Done()
+	}
+}
+
+// Shutdown cancels the operations in flight, the provider fetches and the health checks included, and waits until
+// they returned, and logged, or ctx is done. The operations started afterwards fail with a canceled context.
+// The forecasts waiting for the forecast store, and the usage of the quotas, are written last.
+func (s *WeatherService) Shutdown(ctx context.Context) error {
+	s.ops.mu.Lock()
+	s.ops.closing = true
+	s.ops.mu.Unlock()
+	s.ops.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		s.ops.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		return fmt.Errorf("weather operations still in flight: %w", ctx.Err())
+	}
+
+	return errors.Join(s.saveQuotas(ctx), s.store.close(ctx))
+}
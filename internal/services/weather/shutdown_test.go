@@ -0,0 +1,93 @@
+package weather_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// stuckRepository answers once its context is canceled, or after release when it ignores it
+type stuckRepository struct {
+	name         string
+	started      chan struct{}
+	ignoreCancel bool
+	release      time.Duration
+	calls        atomic.Int32
+}
+
+func (r *stuckRepository) Name() string {
+	return r.name
+}
+
+func (r *stuckRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	if r.calls.Add(1) == 1 {
+		close(r.started)
+	}
+	if r.ignoreCancel {
+		time.Sleep(r.release)
+		return models.Forecast{RepositoryName: r.name}, nil
+	}
+	<-ctx.Done()
+
+	return models.Forecast{}, ctx.Err()
+}
+
+func TestWeatherService_Shutdown(t *testing.T) {
+	l := logger.NewTestLogger()
+	slow := &stuckRepository{name: "slow-repo", started: make(chan struct{})}
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "ok-repo", forecastData: models.Forecast{RepositoryName: "ok-repo"}},
+		slow,
+	}
+	service := weather.NewWeatherService(repos, l, weather.WithProviderTimeouts(map[string]time.Duration{"slow-repo": time.Minute}))
+
+	type result struct {
+		forecasts map[string]models.Forecast
+		err       error
+	}
+	results := make(chan result, 1)
+	go func() {
+		forecasts, err := service.FetchForecasts(context.Background(), 40.7128, -74.0060, 1)
+		results <- result{forecasts, err}
+	}()
+	<-slow.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	require.NoError(t, service.Shutdown(ctx))
+
+	// The fetch in flight was canceled and logged its outcome before Shutdown returned
+	warnings := l.Filter(logger.LevelWarning)
+	require.NotEmpty(t, warnings)
+	assert.Equal(t, "forecast fetch aborted", warnings[len(warnings)-1].Message)
+	res := <-results
+	assert.ErrorIs(t, res.err, context.Canceled)
+
+	// The operations started afterwards are canceled right away
+	_, err := service.FetchForecasts(context.Background(), 40.7128, -74.0060, 1)
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestWeatherService_Shutdown_Timeout(t *testing.T) {
+	stuck := &stuckRepository{name: "stuck-repo", started: make(chan struct{}), ignoreCancel: true, release: 200 * time.Millisecond}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{stuck}, logger.NopLogger{})
+
+	go func() { _, _ = service.FetchForecasts(context.Background(), 40.7128, -74.0060, 1) }()
+	<-stuck.started
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, service.Shutdown(ctx), context.DeadlineExceeded)
+
+	// The stuck provider is still waited for by a later shutdown
+	require.NoError(t, service.Shutdown(context.Background()))
+}
@@ -0,0 +1,159 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/pkg/logger"
+)
+
+// ErrNoForecastStore is returned by StoredForecasts when no forecast store is configured
+var ErrNoForecastStore = errors.New("no forecast store configured")
+
+// storeWriteTimeout bounds the write of a forecast, a stalled database doesn't hold the queue forever
+const storeWriteTimeout = 5 * time.Second
+
+// StoreStats counts the provider forecasts handed to the forecast store
+type StoreStats struct {
+	Enabled bool  `json:"enabled" example:"true"`
+	Queued  int   `json:"queued" example:"3"`
+	Written int64 `json:"written" example:"1234"`
+	// Dropped forecasts found the queue full, Failed ones were rejected by the store
+	Dropped int64 `json:"dropped" example:"0"`
+	Failed  int64 `json:"failed" example:"0"`
+}
+
+// WithForecastStore records every successful provider forecast in store, in the background. Up to queueSize
+// forecasts wait to be written, the ones beyond are dropped and counted rather than delaying the requests.
+func WithForecastStore(store repositories.ForecastStore, queueSize int) Option {
+	return func(s *WeatherService) {
+		if store != nil {
+			s.store = newStoreWriter(store, max(queueSize, 1), s.l)
+		}
+	}
+}
+
+// storeWriter writes the forecasts of its queue to the store from a single goroutine. A nil writer is disabled,
+// it drops nothing and counts nothing.
+type storeWriter struct {
+	store repositories.ForecastStore
+	queue chan models.Forecast
+	done  chan struct{}
+	l     logger.Logger
+
+	// mu orders the enqueued forecasts with the close of the queue
+	mu     sync.RWMutex
+	closed bool
+
+	written atomic.Int64
+	dropped atomic.Int64
+	failed  atomic.Int64
+}
+
+func newStoreWriter(store repositories.ForecastStore, queueSize int, l logger.Logger) *storeWriter {
+	w := &storeWriter{
+		store: store,
+		queue: make(chan models.Forecast, queueSize),
+		done:  make(chan struct{}),
+		l:     l,
+	}
+	go w.run()
+
+	return w
+}
+
+// enqueue queues a copy of the forecast to be written, the rules may change the days of the forecast returned.
+// It is dropped when the queue is full or the writer closed.
+func (w *storeWriter) enqueue(forecast models.Forecast) {
+	if w == nil {
+		return
+	}
+
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.closed {
+		w.dropped.Add(1)
+		return
+	}
+
+	select {
+	case w.queue <- cloneForecast(forecast):
+	default:
+		w.dropped.Add(1)
+	}
+}
+
+func (w *storeWriter) run() {
+	defer close(w.done)
+
+	for forecast := range w.queue {
+		ctx, cancel := context.WithTimeout(context.Background(), storeWriteTimeout)
+		err := w.store.Save(ctx, forecast)
+		cancel()
+		if err != nil {
+			w.failed.Add(1)
+			w.l.Error(err, map[string]any{"repo": forecast.RepositoryName})
+			continue
+		}
+		w.written.Add(1)
+	}
+}
+
+// close stops accepting forecasts and waits until the queued ones are written or ctx is done
+func (w *storeWriter) close(ctx context.Context) error {
+	if w == nil {
+		return nil
+	}
+
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+
+	select {
+	case <-w.done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("%d forecasts still waiting to be stored: %w", len(w.queue), ctx.Err())
+	}
+}
+
+func (w *storeWriter) stats() StoreStats {
+	if w == nil {
+		return StoreStats{}
+	}
+
+	return StoreStats{
+		Enabled: true,
+		Queued:  len(w.queue),
+		Written: w.written.Load(),
+		Dropped: w.dropped.Load(),
+		Failed:  w.failed.Load(),
+	}
+}
+
+// StoreStats returns the counters of the forecast store
+func (s *WeatherService) StoreStats() StoreStats {
+	return s.store.stats()
+}
+
+// StoredForecasts returns the forecasts recorded for the date at the location, every provider and every fetch,
+// the oldest first
+func (s *WeatherService) StoredForecasts(ctx context.Context, lat, lon float64, date models.Date) ([]models.StoredForecast, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	if s.store == nil {
+		return nil, ErrNoForecastStore
+	}
+
+	return s.store.store.Stored(ctx, lat, lon, date)
+}
@@ -0,0 +1,89 @@
+package weather_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+)
+
+// blockingStore holds every Save until release is closed, started receives the forecasts being saved
+type blockingStore struct {
+	started chan models.Forecast
+	release chan struct{}
+}
+
+func (s *blockingStore) Save(ctx context.Context, forecast models.Forecast) error {
+	s.started <- forecast
+	<-s.release
+	return nil
+}
+
+func (s *blockingStore) Stored(ctx context.Context, lat, lon float64, date models.Date) ([]models.StoredForecast, error) {
+	return nil, nil
+}
+
+func (s *blockingStore) Close() error {
+	return nil
+}
+
+func TestWeatherService_ForecastStore(t *testing.T) {
+	store, err := repositories.OpenSQLiteForecastStore(filepath.Join(t.TempDir(), "forecasts.db"))
+	require.NoError(t, err)
+	defer store.Close()
+
+	repo := cachedRepository()
+	repo.forecastData.Lat, repo.forecastData.Lon = 52.52, 13.41
+	service := newCachedService(repo, weather.WithForecastCache(10), weather.WithForecastStore(store, 10))
+
+	// The forecast served from the cache was stored already
+	for range 2 {
+		_, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+		require.NoError(t, err)
+	}
+	require.NoError(t, service.Shutdown(context.Background()))
+
+	assert.Equal(t, weather.StoreStats{Enabled: true, Written: 1}, service.StoreStats())
+
+	date, err := models.ParseDate("2025-07-25")
+	require.NoError(t, err)
+	stored, err := store.Stored(context.Background(), 52.52, 13.41, date)
+	require.NoError(t, err)
+	require.Len(t, stored, 1)
+	assert.Equal(t, "open-meteo", stored[0].Provider)
+	assert.Equal(t, 25.5, stored[0].TempMax)
+	assert.Equal(t, 15.2, stored[0].TempMin)
+}
+
+func TestWeatherService_ForecastStore_QueueFull(t *testing.T) {
+	store := &blockingStore{started: make(chan models.Forecast, 3), release: make(chan struct{})}
+	service := newCachedService(cachedRepository(), weather.WithForecastStore(store, 1))
+
+	// The first forecast is being written, the second waits in the queue and the third is dropped
+	_, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 1)
+	require.NoError(t, err)
+	<-store.started
+	for _, lat := range []float64{48.85, 40.71} {
+		_, err := service.FetchForecasts(context.Background(), lat, 13.41, 1)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, weather.StoreStats{Enabled: true, Queued: 1, Dropped: 1}, service.StoreStats())
+
+	close(store.release)
+	require.NoError(t, service.Shutdown(context.Background()))
+	assert.Equal(t, weather.StoreStats{Enabled: true, Written: 2, Dropped: 1}, service.StoreStats())
+}
+
+func TestWeatherService_StoredForecasts_NoStore(t *testing.T) {
+	service := newCachedService(cachedRepository())
+
+	_, err := service.StoredForecasts(context.Background(), 52.52, 13.41, models.Date{})
+	assert.ErrorIs(t, err, weather.ErrNoForecastStore)
+	assert.Equal(t, weather.StoreStats{}, service.StoreStats())
+}
@@ -0,0 +1,58 @@
+package weather
+
+import (
+	"context"
+	"sync"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+// FetchForecastsStream fetches the forecasts of the given providers, an empty list selects every provider, and sends
+// each one on the returned channel as soon as it is fetched, failed providers included with their error. The channel
+// is closed once every provider answered, canceling ctx abandons the remaining fetches, which are then sent with
+// the canceled error code. Unlike FetchForecasts, the timezone of a forecast is resolved from its provider alone.
+func (s *WeatherService) FetchForecastsStream(ctx context.Context, lat, lon float64, forecastWindow int, providers []string) (<-chan models.Forecast, error) {
+	repos, err := s.selectRepositories(providers)
+	if err != nil {
+		return nil, err
+	}
+	// The operation ends with the last forecast sent
+	ctx, end := s.begin(ctx)
+
+	requestID := requestid.FromContext(ctx)
+	logger.FromContext(ctx, s.l).Info("starting forecast stream", map[string]any{
+		"request_id":     requestID,
+		"lat":            lat,
+		"lon":            lon,
+		"forecastWindow": forecastWindow,
+		"repositories":   len(repos),
+	})
+
+	// Buffered for every provider, so the fetches never block on a reader that went away
+	results := make(chan models.Forecast, len(repos))
+	var wg sync.WaitGroup
+	for _, repo := range repos {
+		wg.Add(1)
+		go func(repo repositories.WeatherRepository) {
+			defer wg.Done()
+
+			forecast := []models.Forecast{s.fetchForecast(ctx, repo, lat, lon, forecastWindow)}
+			if forecast[0].Error == "" {
+				s.resolveTimezone(ctx, lat, lon, forecast)
+				s.applyRules(ctx, forecast)
+			}
+			results <- forecast[0]
+		}(repo)
+	}
+
+	go func() {
+		wg.Wait()
+		close(results)
+		end()
+	}()
+
+	return results, nil
+}
@@ -0,0 +1,107 @@
+package weather_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/logger"
+)
+
+// collect reads the stream until it is closed
+func collect(t *testing.T, results <-chan models.Forecast) []models.Forecast {
+	t.Helper()
+
+	var forecasts []models.Forecast
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case forecast, ok := <-results:
+			if !ok {
+				return forecasts
+			}
+			forecasts = append(forecasts, forecast)
+		case <-timeout:
+			t.Fatal("the stream was not closed")
+		}
+	}
+}
+
+func TestWeatherService_FetchForecastsStream_ArrivalOrder(t *testing.T) {
+	l := logger.NopLogger{}
+	slow := &MockRepository{
+		name:         "slow",
+		delay:        50 * time.Millisecond,
+		forecastData: models.Forecast{RepositoryName: "slow", ForecastData: []models.WeatherData{}},
+	}
+	fast := &MockRepository{name: "fast", forecastData: models.Forecast{RepositoryName: "fast", ForecastData: []models.WeatherData{}}}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{slow, fast}, l)
+
+	results, err := service.FetchForecastsStream(context.Background(), 40.7128, -74.0060, 1, nil)
+	require.NoError(t, err)
+
+	forecasts := collect(t, results)
+	require.Len(t, forecasts, 2)
+	assert.Equal(t, "fast", forecasts[0].RepositoryName)
+	assert.Equal(t, "slow", forecasts[1].RepositoryName)
+	assert.Equal(t, estimatedTimezone, *forecasts[0].Timezone)
+}
+
+func TestWeatherService_FetchForecastsStream_AllFailures(t *testing.T) {
+	l := logger.NopLogger{}
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "failure-repo-1", shouldFail: true},
+		&MockRepository{name: "failure-repo-2", err: context.DeadlineExceeded},
+	}
+	service := weather.NewWeatherService(repos, l)
+
+	results, err := service.FetchForecastsStream(context.Background(), 40.7128, -74.0060, 1, nil)
+	require.NoError(t, err)
+
+	forecasts := collect(t, results)
+	require.Len(t, forecasts, 2)
+	codes := map[string]string{}
+	for _, forecast := range forecasts {
+		assert.NotEmpty(t, forecast.Error, forecast.RepositoryName)
+		codes[forecast.RepositoryName] = forecast.ErrorCode
+	}
+	assert.Equal(t, map[string]string{
+		"failure-repo-1": models.ErrorCodeUnknown,
+		"failure-repo-2": models.ErrorCodeTimeout,
+	}, codes)
+}
+
+func TestWeatherService_FetchForecastsStream_Cancel(t *testing.T) {
+	l := logger.NopLogger{}
+	blocking := &blockingRepository{canceled: make(chan struct{})}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{blocking}, l)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	results, err := service.FetchForecastsStream(ctx, 40.7128, -74.0060, 1, nil)
+	require.NoError(t, err)
+	cancel()
+
+	forecasts := collect(t, results)
+	require.Len(t, forecasts, 1)
+	assert.Equal(t, models.ErrorCodeCanceled, forecasts[0].ErrorCode)
+
+	select {
+	case <-blocking.canceled:
+	default:
+		t.Fatal("the provider was not canceled")
+	}
+}
+
+func TestWeatherService_FetchForecastsStream_UnknownProvider(t *testing.T) {
+	l := logger.NopLogger{}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{&MockRepository{name: "known"}}, l)
+
+	_, err := service.FetchForecastsStream(context.Background(), 40.7128, -74.0060, 1, []string{"unknown"})
+	assert.ErrorContains(t, err, "unknown weather provider: unknown")
+}
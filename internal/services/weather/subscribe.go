@@ -0,0 +1,66 @@
+package weather
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+
+	"weather-api/internal/models"
+)
+
+const (
+	defaultSubscriptionMinInterval = time.Minute
+	defaultSubscriptionMaxDuration = time.Hour
+)
+
+// subscriptions shares the aggregate fetches of the live subscribers, see SharedAggregateForecasts
+type subscriptions struct {
+	group       singleflight.Group
+	minInterval time.Duration
+	maxDuration time.Duration
+}
+
+// WithSubscriptionLimits sets the shortest update interval of a subscription and how long it may stay open,
+// zero keeps the defaults of a minute and an hour
+func WithSubscriptionLimits(minInterval, maxDuration time.Duration) Option {
+	return func(s *WeatherService) {
+		if minInterval > 0 {
+			s.subscriptions.minInterval = minInterval
+		}
+		if maxDuration > 0 {
+			s.subscriptions.maxDuration = maxDuration
+		}
+	}
+}
+
+// SubscriptionLimits returns the shortest update interval of a subscription and how long it may stay open
+func (s *WeatherService) SubscriptionLimits() (minInterval, maxDuration time.Duration) {
+	return s.subscriptions.minInterval, s.subscriptions.maxDuration
+}
+
+// SharedAggregateForecasts aggregates the forecasts like AggregateForecasts with the configured quorum,
+// concurrent calls for the same location, window and strategy share one upstream fetch. The fetch keeps the
+// values of the first caller's context but not its cancellation, it is bounded by the request budget so a
+// caller going away doesn't fail the others, ctx only stops the wait.
+func (s *WeatherService) SharedAggregateForecasts(ctx context.Context, lat, lon float64, forecastWindow int, strategy string) (models.AggregatedForecast, error) {
+	key := fmt.Sprintf("%.4f,%.4f,%d,%s", lat, lon, forecastWindow, strategy)
+	results := s.subscriptions.group.DoChan(key, func() (any, error) {
+		fetchCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), s.RequestBudget())
+		defer cancel()
+
+		return s.AggregateForecasts(fetchCtx, lat, lon, forecastWindow, strategy, 0)
+	})
+
+	select {
+	case <-ctx.Done():
+		return models.AggregatedForecast{}, ctx.Err()
+	case res := <-results:
+		if res.Err != nil {
+			return models.AggregatedForecast{}, res.Err
+		}
+
+		return res.Val.(models.AggregatedForecast), nil
+	}
+}
@@ -0,0 +1,79 @@
+package weather_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/services/weather"
+)
+
+func TestWeatherService_SharedAggregateForecasts(t *testing.T) {
+	repo := &MockRepository{
+		name:         "repo-1",
+		delay:        50 * time.Millisecond,
+		forecastData: models.Forecast{RepositoryName: "repo-1", ForecastData: []models.WeatherData{day(0, 30, 20)}},
+	}
+	service := newAggregateService(repo)
+
+	results := make([]models.AggregatedForecast, 5)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			aggregated, err := service.SharedAggregateForecasts(context.Background(), 40.7128, -74.0060, 1, weather.StrategyMean)
+			assert.NoError(t, err)
+			results[i] = aggregated
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 1, repo.callCount)
+	for _, aggregated := range results {
+		require.Len(t, aggregated.ForecastData, 1)
+		assert.Equal(t, 30.0, aggregated.ForecastData[0].TempMax)
+	}
+}
+
+func TestWeatherService_SharedAggregateForecasts_CallerCanceled(t *testing.T) {
+	repo := &MockRepository{
+		name:         "repo-1",
+		delay:        50 * time.Millisecond,
+		forecastData: models.Forecast{RepositoryName: "repo-1", ForecastData: []models.WeatherData{day(0, 30, 20)}},
+	}
+	service := newAggregateService(repo)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		_, err := service.SharedAggregateForecasts(canceled, 40.7128, -74.0060, 1, weather.StrategyMean)
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	// The fetch shared with the canceled caller goes on for the others
+	aggregated, err := service.SharedAggregateForecasts(context.Background(), 40.7128, -74.0060, 1, weather.StrategyMean)
+	require.NoError(t, err)
+	assert.Len(t, aggregated.ForecastData, 1)
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, 1, repo.callCount)
+}
+
+func TestWithSubscriptionLimits(t *testing.T) {
+	service := newAggregateService()
+	minInterval, maxDuration := service.SubscriptionLimits()
+	assert.Equal(t, time.Minute, minInterval)
+	assert.Equal(t, time.Hour, maxDuration)
+
+	service = weather.NewWeatherService(nil, nil, weather.WithSubscriptionLimits(5*time.Second, 0))
+	minInterval, maxDuration = service.SubscriptionLimits()
+	assert.Equal(t, 5*time.Second, minInterval)
+	assert.Equal(t, time.Hour, maxDuration)
+}
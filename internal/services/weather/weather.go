@@ -2,82 +2,952 @@ package weather
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"weather-api/internal/models"
 	"weather-api/internal/repositories"
+	"weather-api/internal/services/rules"
+	"weather-api/internal/services/timezone"
 	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
 )
 
 // WeatherService represents the weather service.
 type WeatherService struct {
-	repos []repositories.WeatherRepository
-	l     *logger.Logger
+	// providers are the forecast providers, replaced by SetProviders
+	providers    atomic.Pointer[providerSet]
+	setProviders sync.Mutex
+	tz           *timezone.Resolver
+	rules        *rules.Engine
+	// outlierMADs is the outlier rejection threshold of the aggregation
+	outlierMADs float64
+	// minProviders is the default quorum of the aggregation
+	minProviders int
+	// highConfidenceSpread and mediumConfidenceSpread are the largest spreads, in °C, of the aggregated days
+	// labeled high and medium confidence
+	highConfidenceSpread   float64
+	mediumConfidenceSpread float64
+	// compareThreshold is the disagreement beyond which CompareForecasts flags a day
+	compareThreshold float64
+	// requestTimeout caps RequestBudget, zero when not capped
+	requestTimeout time.Duration
+	limiter        *limiter
+	// historyMaxDays is the longest date range served by FetchHistory
+	historyMaxDays int
+	// maxForecastDays is the longest forecast window served, the providers with a shorter horizon are clamped
+	maxForecastDays int
+	// cacheTTL is how long a forecast is considered fresh
+	cacheTTL time.Duration
+	// cache keeps the provider forecasts for cacheTTL, in memory unless set by WithCache, nil when disabled
+	cache        Cache
+	cacheEntries int
+	// prewarm keeps the forecasts of popular locations in the cache, nil when none is configured
+	prewarm *prewarmer
+	// batchMaxItems and batchConcurrency bound the batch forecasts, see FetchBatchForecasts
+	batchMaxItems    int
+	batchConcurrency int
+	// airQuality serves FetchAirQuality, nil when not configured
+	airQuality repositories.AirQualityProvider
+	// marine serves FetchMarineForecast, nil when not configured
+	marine repositories.MarineProvider
+	// alertSources serve alerts on top of the providers exposing them
+	alertSources  []repositories.AlertSource
+	subscriptions *subscriptions
+	// store records the successful provider forecasts in the background, nil when not configured
+	store *storeWriter
+	// accuracyInterval schedules ScoreAccuracy, zero when not scheduled, over the last accuracyWindowDays days
+	accuracyInterval   time.Duration
+	accuracyWindowDays int
+	// webhooks notifies the subscriptions kept by the forecast store
+	webhooks *webhooks
+	// quotas counts the calls to the providers against their quota
+	quotas *quotas
+	// metrics records the recent calls and the health of the providers, see ProviderStatus
+	metrics *providerMetrics
+	// errorLog throttles the logs of the repeated failures of a provider, keyed by provider name
+	errorLog *logger.Throttle
+	// ops are canceled and waited for by Shutdown
+	ops *operations
+	l   logger.Logger
 }
 
-func NewWeatherService(repos []repositories.WeatherRepository, l *logger.Logger) *WeatherService {
-	return &WeatherService{
-		repos: repos,
-		l:     l,
+const (
+	defaultProviderTimeout = 5 * time.Second
+	// requestBudgetMargin is added to the slowest provider timeout for the whole request
+	requestBudgetMargin    = time.Second
+	defaultHistoryMaxDays  = 366
+	defaultMaxForecastDays = 16
+	defaultCacheTTL        = 5 * time.Minute
+)
+
+// ErrUnknownProvider is returned when a provider name matches no configured provider
+var ErrUnknownProvider = errors.New("unknown weather provider")
+
+// ErrNoHistoricalProviders is returned by FetchHistory when no configured provider has a weather archive
+var ErrNoHistoricalProviders = errors.New("no configured provider supports historical weather")
+
+// Option configures optional behavior of the WeatherService
+type Option func(*WeatherService)
+
+// WithRules applies the post-processing rules engine to every fetched forecast
+func WithRules(engine *rules.Engine) Option {
+	return func(s *WeatherService) {
+		s.rules = engine
 	}
 }
 
-// FetchForecasts fetches the weather forecasts from all available APIs for the given latitude and longitude
+// WithOutlierThreshold sets the distance from the median, in median absolute deviations, beyond which
+// aggregated provider values are rejected, zero keeps the default
+func WithOutlierThreshold(mads float64) Option {
+	return func(s *WeatherService) {
+		if mads > 0 {
+			s.outlierMADs = mads
+		}
+	}
+}
+
+// WithMinProviders sets the default number of providers an aggregate needs, zero keeps the default of 1
+func WithMinProviders(n int) Option {
+	return func(s *WeatherService) {
+		if n > 0 {
+			s.minProviders = n
+		}
+	}
+}
+
+// WithConfidenceThresholds sets the largest spreads, in °C, of the aggregated days of high and medium confidence,
+// zero keeps the defaults of 2 and 5
+func WithConfidenceThresholds(high, medium float64) Option {
+	return func(s *WeatherService) {
+		if high > 0 {
+			s.highConfidenceSpread = high
+		}
+		if medium > 0 {
+			s.mediumConfidenceSpread = medium
+		}
+	}
+}
+
+// WithProviderTimeouts sets the timeout of every provider request, keyed by provider name
+func WithProviderTimeouts(timeouts map[string]time.Duration) Option {
+	return func(s *WeatherService) {
+		s.current().timeouts = timeouts
+	}
+}
+
+// WithRequestTimeout caps the total time of a request, see RequestBudget, zero or negative doesn't cap it
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(s *WeatherService) {
+		s.requestTimeout = max(timeout, 0)
+	}
+}
+
+// WithConcurrencyLimits bounds the number of concurrent upstream calls across all requests, globally and
+// per provider name, zero means no limit
+func WithConcurrencyLimits(global int, perProvider map[string]int) Option {
+	return func(s *WeatherService) {
+		s.limiter = newLimiter(global)
+		s.current().slots = newProviderSlots(perProvider, nil)
+	}
+}
+
+// WithWeights sets the weights of the providers in the weighted mean aggregation, keyed by provider name
+func WithWeights(weights map[string]float64) Option {
+	return func(s *WeatherService) {
+		s.current().weights = weights
+	}
+}
+
+// WithHistoryMaxDays sets the longest date range of a history request, zero keeps the default of 366 days
+func WithHistoryMaxDays(days int) Option {
+	return func(s *WeatherService) {
+		if days > 0 {
+			s.historyMaxDays = days
+		}
+	}
+}
+
+// WithMaxForecastDays sets the longest forecast window of a request, zero keeps the default of 16 days
+func WithMaxForecastDays(days int) Option {
+	return func(s *WeatherService) {
+		if days > 0 {
+			s.maxForecastDays = days
+		}
+	}
+}
+
+// WithCacheTTL sets how long a forecast is considered fresh, zero keeps the default of 5 minutes
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(s *WeatherService) {
+		if ttl > 0 {
+			s.cacheTTL = ttl
+		}
+	}
+}
+
+// WithForecastCache caches up to maxEntries provider forecasts in memory for the cache TTL, zero disables the
+// cache unless WithCache sets another one
+func WithForecastCache(maxEntries int) Option {
+	return func(s *WeatherService) {
+		s.cacheEntries = maxEntries
+	}
+}
+
+// WithProviderErrorLog logs one in every failures of a provider, or at most one per interval, along with the
+// number of failures skipped since the last one logged. Zero logs every failure.
+func WithProviderErrorLog(every int, interval time.Duration) Option {
+	return func(s *WeatherService) {
+		s.errorLog = logger.NewThrottle(every, interval)
+	}
+}
+
+func NewWeatherService(repos []repositories.WeatherRepository, l logger.Logger, opts ...Option) *WeatherService {
+	s := &WeatherService{
+		tz:                     timezone.NewResolver(),
+		outlierMADs:            defaultOutlierMADs,
+		minProviders:           defaultMinProviders,
+		highConfidenceSpread:   defaultHighConfidenceSpread,
+		mediumConfidenceSpread: defaultMediumConfidenceSpread,
+		compareThreshold:       defaultCompareThreshold,
+		limiter:                newLimiter(0),
+		historyMaxDays:         defaultHistoryMaxDays,
+		maxForecastDays:        defaultMaxForecastDays,
+		cacheTTL:               defaultCacheTTL,
+		batchMaxItems:          defaultBatchMaxItems,
+		batchConcurrency:       defaultBatchConcurrency,
+		accuracyWindowDays:     defaultAccuracyWindowDays,
+		subscriptions: &subscriptions{
+			minInterval: defaultSubscriptionMinInterval,
+			maxDuration: defaultSubscriptionMaxDuration,
+		},
+		webhooks: newWebhooks(),
+		quotas:   newQuotas(),
+		metrics:  newProviderMetrics(),
+		errorLog: logger.NewThrottle(0, 0),
+		ops:      newOperations(),
+		l:        l,
+	}
+
+	s.providers.Store(newProviderSet(repos, &providerSet{}))
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.cache == nil && s.cacheEntries > 0 {
+		s.cache = newForecastCache(s.cacheTTL, s.cacheEntries, time.Now)
+	}
+
+	if s.prewarm != nil && s.cache != nil && !s.prewarm.manual {
+		s.warmCacheInBackground()
+	}
+	if _, ok := s.accuracyStore(); ok && s.accuracyInterval > 0 {
+		s.scoreAccuracyEvery(s.accuracyInterval)
+	}
+	if _, ok := s.subscriptionStore(); ok && s.webhooks.tick > 0 {
+		s.checkSubscriptionsEvery(s.webhooks.tick)
+	}
+	if _, ok := s.quotaStore(); ok {
+		s.loadQuotas()
+		s.saveQuotasEvery(quotaSaveInterval)
+	}
+
+	return s
+}
+
+// RequestBudget returns the total time a request fanning out to every provider should be given,
+// slightly above the slowest provider timeout, and at most the request timeout
+func (s *WeatherService) RequestBudget() time.Duration {
+	budget := defaultProviderTimeout
+	set := s.current()
+	for _, repo := range set.repos {
+		budget = max(budget, set.timeout(repo.Name()))
+	}
+	budget += requestBudgetMargin
+	if s.requestTimeout > 0 {
+		budget = min(budget, s.requestTimeout)
+	}
+
+	return budget
+}
+
+// HistoryMaxDays returns the longest date range, in days, a history request may span
+func (s *WeatherService) HistoryMaxDays() int {
+	return s.historyMaxDays
+}
+
+// MaxForecastDays returns the longest forecast window a request may ask for
+func (s *WeatherService) MaxForecastDays() int {
+	return s.maxForecastDays
+}
+
+// CacheTTL returns how long a forecast is considered fresh
+func (s *WeatherService) CacheTTL() time.Duration {
+	return s.cacheTTL
+}
+
+// timeout returns the request timeout of a provider of set
+func (set *providerSet) timeout(provider string) time.Duration {
+	if t, ok := set.timeouts[provider]; ok && t > 0 {
+		return t
+	}
+
+	return defaultProviderTimeout
+}
+
+// callProvider waits for a free upstream slot, bounded by the request context, and calls the provider
+// with the provider timeout, a stuck provider then ends with a timeout instead of holding the whole response.
+// The outcome of the call is recorded in the provider metrics unless the caller gave up on it.
+func (s *WeatherService) callProvider(ctx context.Context, provider string, call func(ctx context.Context) error) error {
+	// The quota, the limit and the timeout of the call are read from the same providers
+	set := s.current()
+	if err := s.quotas.take(provider, set.quotas); err != nil {
+		return err
+	}
+
+	release, err := s.limiter.acquire(ctx, set.slots[provider].sem)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	callCtx, cancel := context.WithTimeout(ctx, set.timeout(provider))
+	defer cancel()
+
+	start := time.Now()
+	err = call(callCtx)
+
+	var code string
+	if err != nil {
+		code, _ = classifyError(err)
+	}
+	if code != models.ErrorCodeCanceled && code != models.ErrorCodeUnsupported && ctx.Err() == nil {
+		s.metrics.record(provider, time.Since(start), code)
+	}
+
+	return err
+}
+
+// InFlight returns the number of upstream calls in progress
+func (s *WeatherService) InFlight() int64 {
+	return s.limiter.inFlight.Load()
+}
+
+// Providers returns the names of the available providers in configuration order
+func (s *WeatherService) Providers() []string {
+	repos := s.current().repos
+	names := make([]string, len(repos))
+	for i, repo := range repos {
+		names[i] = repo.Name()
+	}
+
+	return names
+}
+
+// FetchForecasts fetches the weather forecasts from all available APIs for the given latitude and longitude,
+// keyed by provider name. It is kept for the map-based /weather response, see FetchOrderedForecasts.
 func (s *WeatherService) FetchForecasts(ctx context.Context, lat, lon float64, forecastWindow int) (map[string]models.Forecast, error) {
-	s.l.Info("starting forecast fetch", map[string]any{
+	return s.FetchProviderForecasts(ctx, lat, lon, forecastWindow, nil)
+}
+
+// FetchProviderForecasts fetches the weather forecasts from the given providers only, keyed by provider name,
+// an empty list selects every provider. ErrBudgetExceeded comes with the forecasts, see FetchOrderedForecasts.
+func (s *WeatherService) FetchProviderForecasts(ctx context.Context, lat, lon float64, forecastWindow int, providers []string) (map[string]models.Forecast, error) {
+	repos, err := s.selectRepositories(providers)
+	if err != nil {
+		return nil, err
+	}
+
+	forecasts, err := s.fetchOrdered(ctx, repos, lat, lon, forecastWindow)
+	if err != nil && forecasts == nil {
+		return nil, err
+	}
+
+	results := make(map[string]models.Forecast, len(forecasts))
+	for _, forecast := range forecasts {
+		results[forecast.RepositoryName] = forecast
+	}
+
+	return results, err
+}
+
+// FetchOrderedForecasts fetches the weather forecasts from all available APIs for the given latitude and longitude,
+// the forecasts are returned in the configuration order of the providers. A canceled request context is returned
+// as the error, an expired one as ErrBudgetExceeded, with the forecasts when a provider answered in time.
+func (s *WeatherService) FetchOrderedForecasts(ctx context.Context, lat, lon float64, forecastWindow int) ([]models.Forecast, error) {
+	return s.fetchOrdered(ctx, s.current().repos, lat, lon, forecastWindow)
+}
+
+// FetchFirstForecast races the given providers, an empty list selects every provider, and returns the first
+// successful forecast, the other requests are canceled, disabled providers are left out. It only fails when
+// every provider fails or the request context ends.
+func (s *WeatherService) FetchFirstForecast(ctx context.Context, lat, lon float64, forecastWindow int, providers []string) (models.Forecast, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	l := logger.FromContext(ctx, s.l)
+	repos, err := s.selectRepositories(providers)
+	if err != nil {
+		return models.Forecast{}, err
+	}
+	repos = slices.DeleteFunc(slices.Clone(repos), func(repo repositories.WeatherRepository) bool {
+		return !s.ProviderEnabled(repo.Name())
+	})
+	if len(repos) == 0 {
+		return models.Forecast{}, ErrNoForecasts
+	}
+
+	requestID := requestid.FromContext(ctx)
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	type result struct {
+		forecast models.Forecast
+		err      error
+	}
+
+	// Buffered for every provider, so the losers can always deliver their result and exit
+	results := make(chan result, len(repos))
+	for _, repo := range repos {
+		go func(repo repositories.WeatherRepository) {
+			var forecast models.Forecast
+			err := s.callProvider(ctx, repo.Name(), func(ctx context.Context) (err error) {
+				forecast, err = repo.FetchForecast(s.withTimezone(ctx, lat, lon), lat, lon, forecastWindow)
+				return err
+			})
+			if err != nil {
+				err = fmt.Errorf("%s: %w", repo.Name(), err)
+			}
+			results <- result{forecast: forecast, err: err}
+		}(repo)
+	}
+
+	var errs []error
+	for range repos {
+		r := <-results
+		if r.err != nil {
+			errs = append(errs, r.err)
+			continue
+		}
+
+		l.Info("first forecast fetched", map[string]any{
+			"request_id": requestID,
+			"repo":       r.forecast.RepositoryName,
+			"failed":     len(errs),
+		})
+
+		forecasts := []models.Forecast{r.forecast}
+		s.resolveTimezone(ctx, lat, lon, forecasts)
+		s.applyRules(ctx, forecasts)
+
+		return forecasts[0], nil
+	}
+
+	if err := ctx.Err(); err != nil {
+		return models.Forecast{}, err
+	}
+
+	err = errors.Join(errs...)
+	l.Error(err, map[string]any{"request_id": requestID, "repositories": len(repos)})
+
+	return models.Forecast{}, fmt.Errorf("%w: %w", ErrNoForecasts, err)
+}
+
+// selectRepositories returns the repositories with the given names in configuration order
+func (s *WeatherService) selectRepositories(names []string) ([]repositories.WeatherRepository, error) {
+	all := s.current().repos
+	if len(names) == 0 {
+		return all, nil
+	}
+
+	selected := make(map[string]bool, len(names))
+	for _, name := range names {
+		selected[name] = true
+	}
+
+	var repos []repositories.WeatherRepository
+	for _, repo := range all {
+		if selected[repo.Name()] {
+			repos = append(repos, repo)
+			delete(selected, repo.Name())
+		}
+	}
+
+	for name := range selected {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownProvider, name)
+	}
+
+	return repos, nil
+}
+
+func (s *WeatherService) fetchOrdered(ctx context.Context, repos []repositories.WeatherRepository, lat, lon float64, forecastWindow int) ([]models.Forecast, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	l := logger.FromContext(ctx, s.l)
+	requestID := requestid.FromContext(ctx)
+	start := time.Now()
+
+	l.Info("starting forecast fetch", map[string]any{
+		"request_id":     requestID,
 		"lat":            lat,
 		"lon":            lon,
 		"forecastWindow": forecastWindow,
-		"repositories":   len(s.repos),
+		"repositories":   len(repos),
+	})
+
+	// Every provider writes to its own slot, so the order doesn't depend on the response times
+	results := make([]models.Forecast, len(repos))
+	durations := make([]time.Duration, len(repos))
+	var wg sync.WaitGroup
+
+	for i, repo := range repos {
+		wg.Add(1)
+		go func(i int, repo repositories.WeatherRepository) {
+			defer wg.Done()
+
+			callStart := time.Now()
+			results[i] = s.fetchForecast(ctx, repo, lat, lon, forecastWindow)
+			durations[i] = time.Since(callStart)
+		}(i, repo)
+	}
+
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		answered := slices.ContainsFunc(results, func(f models.Forecast) bool { return f.Error == "" })
+		if !errors.Is(err, context.DeadlineExceeded) || !answered {
+			l.Warning("forecast fetch aborted", map[string]any{"request_id": requestID, "err": err.Error()})
+			return nil, err
+		}
+
+		// The providers late are reported with the timeout error code, the ones that answered are kept
+		l.Warning("forecast fetch exceeded the request budget", map[string]any{
+			"request_id": requestID,
+			"providers":  summarize(results, durations),
+		})
+		s.resolveTimezone(ctx, lat, lon, results)
+		s.applyRules(ctx, results)
+
+		return results, ErrBudgetExceeded
+	}
+
+	s.resolveTimezone(ctx, lat, lon, results)
+	s.applyRules(ctx, results)
+
+	l.Info("completed forecast fetch", map[string]any{
+		"request_id": requestID,
+		"providers":  summarize(results, durations),
+		"elapsed_ms": time.Since(start).Milliseconds(),
 	})
 
-	results := make(map[string]models.Forecast)
-	resultsChan := make(chan models.Forecast)
+	return results, nil
+}
+
+// fetchForecast fetches the forecast of a provider, a failure is returned as a forecast carrying the error,
+// a disabled provider isn't called and is returned with the disabled error code. Successful forecasts are cached
+// and recorded in the forecast store.
+func (s *WeatherService) fetchForecast(ctx context.Context, repo repositories.WeatherRepository, lat, lon float64, forecastWindow int) models.Forecast {
+	l := logger.FromContext(ctx, s.l)
+	requestID := requestid.FromContext(ctx)
+	if !s.ProviderEnabled(repo.Name()) {
+		l.Debug("skipping disabled provider", map[string]any{"request_id": requestID, "repo": repo.Name()})
+
+		return models.Forecast{
+			RepositoryName: repo.Name(),
+			Lat:            lat,
+			Lon:            lon,
+			ForecastWindow: forecastWindow,
+			Error:          "provider disabled",
+			ErrorCode:      models.ErrorCodeDisabled,
+			ForecastData:   []models.WeatherData{},
+		}
+	}
+
+	// A provider with a shorter horizon serves the days it has rather than failing the request
+	requested := forecastWindow
+	if maxDays := repositories.MaxDays(repo); maxDays > 0 && forecastWindow > maxDays {
+		forecastWindow = maxDays
+	}
+
+	key := newCacheKey(repo.Name(), lat, lon, forecastWindow)
+	if s.cache != nil && !isCacheRefresh(ctx) {
+		if forecast, ok := s.cache.Get(ctx, key); ok {
+			l.Debug("forecast served from cache", map[string]any{"request_id": requestID, "repo": repo.Name()})
+			s.prewarm.hit(key)
+			forecast.Cached = true
+			return withClampNote(forecast, requested, forecastWindow)
+		}
+	}
+
+	l.Debug("fetching forecast", map[string]any{"request_id": requestID, "repo": repo.Name(), "lat": lat, "lon": lon})
+
+	var forecast models.Forecast
+	err := s.callProvider(ctx, repo.Name(), func(ctx context.Context) (err error) {
+		forecast, err = repo.FetchForecast(s.withTimezone(ctx, lat, lon), lat, lon, forecastWindow)
+		return err
+	})
+	if err != nil {
+		code, message := classifyError(err)
+		s.logProviderError(l, repo.Name(), err, map[string]any{"request_id": requestID, "err": err, "error_code": code})
+
+		return models.Forecast{
+			RepositoryName: repo.Name(),
+			Lat:            lat,
+			Lon:            lon,
+			ForecastWindow: forecastWindow,
+			Error:          message,
+			ErrorCode:      code,
+			ForecastData:   []models.WeatherData{},
+		}
+	}
+
+	l.Info("successfully fetched forecast", map[string]any{
+		"request_id": requestID,
+		"repo":       repo.Name(),
+	})
+	// The repositories sort the days, an unsorted forecast is a bug of the provider's repository
+	if !models.WeatherDataSorted(forecast.ForecastData) {
+		l.Warning("provider returned the forecast days out of order, sorted them", map[string]any{
+			"request_id": requestID,
+			"repo":       repo.Name(),
+		})
+		models.SortWeatherData(forecast.ForecastData)
+	}
+	if s.cache != nil {
+		s.cache.Set(ctx, key, forecast)
+	}
+	s.store.enqueue(forecast)
+
+	return withClampNote(forecast, requested, forecastWindow)
+}
+
+// withClampNote notes in the metadata of the forecast that the provider served fewer days than requested
+func withClampNote(forecast models.Forecast, requested, served int) models.Forecast {
+	if served < requested {
+		forecast.Note = fmt.Sprintf("forecast window clamped from %d to %d days, the provider horizon", requested, served)
+	}
+
+	return forecast
+}
+
+// providerSummary is the log line entry of a provider, the forecasts themselves are too large to log
+type providerSummary struct {
+	Provider   string `json:"provider"`
+	Status     string `json:"status"`
+	ErrorCode  string `json:"error_code,omitempty"`
+	Days       int    `json:"days"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+func summarize(results []models.Forecast, durations []time.Duration) []providerSummary {
+	summary := make([]providerSummary, len(results))
+	for i, forecast := range results {
+		summary[i] = providerSummary{
+			Provider:   forecast.RepositoryName,
+			Status:     "ok",
+			ErrorCode:  forecast.ErrorCode,
+			Days:       len(forecast.ForecastData),
+			DurationMS: durations[i].Milliseconds(),
+		}
+		if forecast.Error != "" {
+			summary[i].Status = "failed"
+		}
+	}
+
+	return summary
+}
+
+// FetchHourlyForecasts fetches the hourly forecasts from all available APIs for the given latitude and longitude,
+// providers without hourly support are reported in the forecast error
+func (s *WeatherService) FetchHourlyForecasts(ctx context.Context, lat, lon float64, hours int) (map[string]models.HourlyForecast, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	l := logger.FromContext(ctx, s.l)
+	requestID := requestid.FromContext(ctx)
+
+	repos := s.current().repos
+	l.Info("starting hourly forecast fetch", map[string]any{
+		"request_id":   requestID,
+		"lat":          lat,
+		"lon":          lon,
+		"hours":        hours,
+		"repositories": len(repos),
+	})
+
+	results := make(map[string]models.HourlyForecast)
+	resultsChan := make(chan models.HourlyForecast)
 	var wg sync.WaitGroup
 
-	for _, repo := range s.repos {
+	for _, repo := range repos {
 		wg.Add(1)
 		go func(repo repositories.WeatherRepository) {
 			defer wg.Done()
-			s.l.Debug("fetching forecast", map[string]any{"repo": repo.Name(), "lat": lat, "lon": lon})
 
-			forecast, err := repo.FetchForecast(ctx, lat, lon, forecastWindow)
+			var forecast models.HourlyForecast
+			err := s.callProvider(ctx, repo.Name(), func(ctx context.Context) (err error) {
+				forecast, err = repositories.FetchHourlyForecast(s.withTimezone(ctx, lat, lon), repo, lat, lon, hours)
+				return err
+			})
 			if err != nil {
-				s.l.Error(err, map[string]any{"repo": repo.Name(), "err": err})
+				code, message := classifyError(err)
+				s.logProviderError(l, repo.Name(), err, map[string]any{"request_id": requestID, "err": err, "error_code": code})
 
-				resultsChan <- models.Forecast{
+				resultsChan <- models.HourlyForecast{
 					RepositoryName: repo.Name(),
 					Lat:            lat,
 					Lon:            lon,
-					ForecastWindow: forecastWindow,
-					ForecastData:   []models.WeatherData{},
+					Hours:          hours,
+					Error:          message,
+					ErrorCode:      code,
+					HourlyData:     []models.HourlyWeatherData{},
 				}
 
 				return
 			}
 
-			s.l.Info("successfully fetched forecast", map[string]any{
-				"repo": repo.Name(),
-			})
-
 			resultsChan <- forecast
 		}(repo)
 	}
 
-	// Close channel when all goroutines complete
 	go func() {
 		wg.Wait()
 		close(resultsChan)
 	}()
 
-	// Read all results from channel
 	for forecast := range resultsChan {
 		results[forecast.RepositoryName] = forecast
 	}
 
-	s.l.Info("completed forecast fetch", map[string]any{
-		"results": results,
+	if err := ctx.Err(); err != nil {
+		l.Warning("hourly forecast fetch aborted", map[string]any{"request_id": requestID, "err": err.Error()})
+		return nil, err
+	}
+
+	l.Info("completed hourly forecast fetch", map[string]any{
+		"request_id": requestID,
+		"results":    len(results),
 	})
 
 	return results, nil
 }
+
+// FetchCurrentWeather fetches the current conditions from all available APIs for the given latitude and longitude,
+// providers without current conditions are reported in the error with the unsupported code
+func (s *WeatherService) FetchCurrentWeather(ctx context.Context, lat, lon float64) (map[string]models.CurrentWeather, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	l := logger.FromContext(ctx, s.l)
+	requestID := requestid.FromContext(ctx)
+
+	repos := s.current().repos
+	l.Info("starting current weather fetch", map[string]any{
+		"request_id":   requestID,
+		"lat":          lat,
+		"lon":          lon,
+		"repositories": len(repos),
+	})
+
+	results := make(map[string]models.CurrentWeather)
+	resultsChan := make(chan models.CurrentWeather)
+	var wg sync.WaitGroup
+
+	for _, repo := range repos {
+		wg.Add(1)
+		go func(repo repositories.WeatherRepository) {
+			defer wg.Done()
+
+			var current models.CurrentWeather
+			err := s.callProvider(ctx, repo.Name(), func(ctx context.Context) (err error) {
+				current, err = repositories.FetchCurrent(s.withTimezone(ctx, lat, lon), repo, lat, lon)
+				return err
+			})
+			if err != nil {
+				code, message := classifyError(err)
+				s.logProviderError(l, repo.Name(), err, map[string]any{"request_id": requestID, "err": err, "error_code": code})
+
+				resultsChan <- models.CurrentWeather{
+					RepositoryName: repo.Name(),
+					Lat:            lat,
+					Lon:            lon,
+					Error:          message,
+					ErrorCode:      code,
+				}
+
+				return
+			}
+
+			resultsChan <- current
+		}(repo)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+
+	for current := range resultsChan {
+		results[current.RepositoryName] = current
+	}
+
+	if err := ctx.Err(); err != nil {
+		l.Warning("current weather fetch aborted", map[string]any{"request_id": requestID, "err": err.Error()})
+		return nil, err
+	}
+
+	l.Info("completed current weather fetch", map[string]any{
+		"request_id": requestID,
+		"results":    len(results),
+	})
+
+	return results, nil
+}
+
+// FetchHistory fetches the observed weather between start and end, both included, from the providers
+// with a weather archive, the other providers are not queried
+func (s *WeatherService) FetchHistory(ctx context.Context, lat, lon float64, start, end models.Date) (map[string]models.HistoricalWeather, error) {
+	ctx, done := s.begin(ctx)
+	defer done()
+
+	l := logger.FromContext(ctx, s.l)
+	requestID := requestid.FromContext(ctx)
+
+	var repos []repositories.WeatherRepository
+	for _, repo := range s.current().repos {
+		if _, ok := repositories.As[repositories.HistoricalProvider](repo); ok {
+			repos = append(repos, repo)
+		}
+	}
+	if len(repos) == 0 {
+		return nil, ErrNoHistoricalProviders
+	}
+
+	l.Info("starting history fetch", map[string]any{
+		"request_id":   requestID,
+		"lat":          lat,
+		"lon":          lon,
+		"start":        start.String(),
+		"end":          end.String(),
+		"repositories": len(repos),
+	})
+
+	results := make(map[string]models.HistoricalWeather)
+	resultsChan := make(chan models.HistoricalWeather)
+	var wg sync.WaitGroup
+
+	for _, repo := range repos {
+		wg.Add(1)
+		go func(repo repositories.WeatherRepository) {
+			defer wg.Done()
+
+			var history models.HistoricalWeather
+			err := s.callProvider(ctx, repo.Name(), func(ctx context.Context) (err error) {
+				history, err = repositories.FetchHistory(ctx, repo, lat, lon, start, end)
+				return err
+			})
+			if err != nil {
+				code, message := classifyError(err)
+				s.logProviderError(l, repo.Name(), err, map[string]any{"request_id": requestID, "err": err, "error_code": code})
+
+				resultsChan <- models.HistoricalWeather{
+					RepositoryName: repo.Name(),
+					Lat:            lat,
+					Lon:            lon,
+					Start:          start,
+					End:            end,
+					Error:          message,
+					ErrorCode:      code,
+					WeatherData:    []models.WeatherData{},
+				}
+
+				return
+			}
+
+			resultsChan <- history
+		}(repo)
+	}
+
+	go func() {
+		wg.Wait()
+		close(resultsChan)
+	}()
+
+	for history := range resultsChan {
+		results[history.RepositoryName] = history
+	}
+
+	if err := ctx.Err(); err != nil {
+		l.Warning("history fetch aborted", map[string]any{"request_id": requestID, "err": err.Error()})
+		return nil, err
+	}
+
+	l.Info("completed history fetch", map[string]any{
+		"request_id": requestID,
+		"results":    len(results),
+	})
+
+	return results, nil
+}
+
+// withTimezone passes the timezone name resolved for the location by an earlier request to the providers, so that
+// they take the local days in it, see repositories.WithTimezone. Until a name is resolved they use their own offset.
+func (s *WeatherService) withTimezone(ctx context.Context, lat, lon float64) context.Context {
+	if loc, ok := s.tz.Cached(lat, lon); ok {
+		return repositories.WithTimezone(ctx, loc)
+	}
+
+	return ctx
+}
+
+// resolveTimezone resolves a single timezone for the location and assigns it to every forecast,
+// providers reporting a different offset are logged and overridden by the resolved value
+func (s *WeatherService) resolveTimezone(ctx context.Context, lat, lon float64, results []models.Forecast) {
+	var reported []models.Timezone
+	for _, forecast := range results {
+		if forecast.Timezone != nil {
+			reported = append(reported, *forecast.Timezone)
+		}
+	}
+
+	tz := s.tz.Resolve(lat, lon, reported)
+
+	for i := range results {
+		forecast := &results[i]
+		if forecast.Timezone != nil && forecast.Timezone.UTCOffsetSeconds != tz.UTCOffsetSeconds {
+			logger.FromContext(ctx, s.l).Warning("timezone disagreement between providers", map[string]any{
+				"repo":            forecast.RepositoryName,
+				"reported_offset": forecast.Timezone.UTCOffsetSeconds,
+				"resolved_offset": tz.UTCOffsetSeconds,
+				"resolved_name":   tz.Name,
+			})
+		}
+
+		resolved := tz
+		forecast.Timezone = &resolved
+	}
+}
+
+// RuleHits returns how many times the post-processing rules matched, see rules.Engine.Hits
+func (s *WeatherService) RuleHits() map[rules.HitKey]int64 {
+	return s.rules.Hits()
+}
+
+// applyRules runs the post-processing rules on every forecast
+func (s *WeatherService) applyRules(ctx context.Context, results []models.Forecast) {
+	if s.rules == nil {
+		return
+	}
+
+	for i := range results {
+		if !s.rules.Apply(&results[i]) {
+			logger.FromContext(ctx, s.l).Warning("rules evaluation budget exceeded", map[string]any{"repo": results[i].RepositoryName})
+		}
+	}
+}
@@ -1,16 +1,23 @@
 package weather_test
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"net/url"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"weather-api/config"
 	"weather-api/internal/models"
 	"weather-api/internal/repositories"
+	"weather-api/internal/services/rules"
 	"weather-api/internal/services/weather"
 	"weather-api/pkg/logger"
 )
@@ -19,7 +26,9 @@ import (
 type MockRepository struct {
 	name         string
 	shouldFail   bool
+	err          error
 	shouldDelay  bool
+	delay        time.Duration
 	forecastData models.Forecast
 	callCount    int
 }
@@ -39,6 +48,13 @@ func (m *MockRepository) FetchForecast(ctx context.Context, lat, lon float64, fo
 		}
 	}
 
+	if m.delay > 0 {
+		time.Sleep(m.delay)
+	}
+
+	if m.err != nil {
+		return models.Forecast{}, m.err
+	}
 	if m.shouldFail {
 		return models.Forecast{}, errors.New("mock repository error")
 	}
@@ -46,8 +62,18 @@ func (m *MockRepository) FetchForecast(ctx context.Context, lat, lon float64, fo
 	return m.forecastData, nil
 }
 
+// estimatedTimezone is the offline estimate for the New York coordinates used in these tests
+var estimatedTimezone = models.Timezone{Name: "Etc/GMT+5", UTCOffsetSeconds: -5 * 3600}
+
+// withTimezone returns the forecast as the service returns it, with the resolved timezone
+func withTimezone(f models.Forecast) models.Forecast {
+	tz := estimatedTimezone
+	f.Timezone = &tz
+	return f
+}
+
 func TestNewWeatherService(t *testing.T) {
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 	repos := []repositories.WeatherRepository{
 		&MockRepository{name: "test-repo-1"},
 		&MockRepository{name: "test-repo-2"},
@@ -59,7 +85,7 @@ func TestNewWeatherService(t *testing.T) {
 }
 
 func TestWeatherService_FetchForecasts_Success(t *testing.T) {
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 
 	date1 := time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)
 	date2 := time.Date(2025, 7, 26, 0, 0, 0, 0, time.UTC)
@@ -70,8 +96,8 @@ func TestWeatherService_FetchForecasts_Success(t *testing.T) {
 		Lon:            -74.0060,
 		ForecastWindow: 2,
 		ForecastData: []models.WeatherData{
-			{Date: &date1, TempMax: 25.0, TempMin: 15.0},
-			{Date: &date2, TempMax: 26.0, TempMin: 16.0},
+			{Date: models.NewDate(date1), TempMax: 25.0, TempMin: 15.0},
+			{Date: models.NewDate(date2), TempMax: 26.0, TempMin: 16.0},
 		},
 	}
 
@@ -81,8 +107,8 @@ func TestWeatherService_FetchForecasts_Success(t *testing.T) {
 		Lon:            -74.0060,
 		ForecastWindow: 2,
 		ForecastData: []models.WeatherData{
-			{Date: &date1, TempMax: 24.5, TempMin: 14.5},
-			{Date: &date2, TempMax: 25.5, TempMin: 15.5},
+			{Date: models.NewDate(date1), TempMax: 24.5, TempMin: 14.5},
+			{Date: models.NewDate(date2), TempMax: 25.5, TempMin: 15.5},
 		},
 	}
 
@@ -104,12 +130,12 @@ func TestWeatherService_FetchForecasts_Success(t *testing.T) {
 	assert.NotNil(t, results)
 	assert.Len(t, results, 2)
 
-	assert.Equal(t, mockForecast1, results["repo-1"])
-	assert.Equal(t, mockForecast2, results["repo-2"])
+	assert.Equal(t, withTimezone(mockForecast1), results["repo-1"])
+	assert.Equal(t, withTimezone(mockForecast2), results["repo-2"])
 }
 
 func TestWeatherService_FetchForecasts_PartialFailure(t *testing.T) {
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 
 	date1 := time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)
 	date2 := time.Date(2025, 7, 26, 0, 0, 0, 0, time.UTC)
@@ -120,8 +146,8 @@ func TestWeatherService_FetchForecasts_PartialFailure(t *testing.T) {
 		Lon:            -74.0060,
 		ForecastWindow: 2,
 		ForecastData: []models.WeatherData{
-			{Date: &date1, TempMax: 25.0, TempMin: 15.0},
-			{Date: &date2, TempMax: 26.0, TempMin: 16.0},
+			{Date: models.NewDate(date1), TempMax: 25.0, TempMin: 15.0},
+			{Date: models.NewDate(date2), TempMax: 26.0, TempMin: 16.0},
 		},
 	}
 
@@ -143,13 +169,14 @@ func TestWeatherService_FetchForecasts_PartialFailure(t *testing.T) {
 	assert.NotNil(t, results)
 	assert.Len(t, results, 2) // Both repos should be in results
 
-	assert.Equal(t, mockForecast, results["success-repo"])
+	assert.Equal(t, withTimezone(mockForecast), results["success-repo"])
 	assert.Equal(t, "failure-repo", results["failure-repo"].RepositoryName)
 	assert.Empty(t, results["failure-repo"].ForecastData)
+	assert.Equal(t, models.ErrorCodeUnknown, results["failure-repo"].ErrorCode)
 }
 
 func TestWeatherService_FetchForecasts_AllFailures(t *testing.T) {
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 
 	repos := []repositories.WeatherRepository{
 		&MockRepository{name: "failure-repo-1", shouldFail: true},
@@ -170,12 +197,14 @@ func TestWeatherService_FetchForecasts_AllFailures(t *testing.T) {
 	assert.Len(t, results, 2) // Both failed repos should be included with empty arrays
 	assert.Equal(t, "failure-repo-1", results["failure-repo-1"].RepositoryName)
 	assert.Empty(t, results["failure-repo-1"].ForecastData)
+	assert.Equal(t, models.ErrorCodeUnknown, results["failure-repo-1"].ErrorCode)
 	assert.Equal(t, "failure-repo-2", results["failure-repo-2"].RepositoryName)
 	assert.Empty(t, results["failure-repo-2"].ForecastData)
+	assert.Equal(t, models.ErrorCodeUnknown, results["failure-repo-2"].ErrorCode)
 }
 
 func TestWeatherService_FetchForecasts_EmptyRepositories(t *testing.T) {
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 
 	repos := []repositories.WeatherRepository{}
 
@@ -194,7 +223,7 @@ func TestWeatherService_FetchForecasts_EmptyRepositories(t *testing.T) {
 }
 
 func TestWeatherService_FetchForecasts_ContextCancellation(t *testing.T) {
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 
 	repos := []repositories.WeatherRepository{
 		&MockRepository{name: "delayed-repo", shouldDelay: true},
@@ -212,15 +241,12 @@ func TestWeatherService_FetchForecasts_ContextCancellation(t *testing.T) {
 
 	results, err := service.FetchForecasts(ctx, lat, lon, forecastWindow)
 
-	assert.NoError(t, err)
-	assert.NotNil(t, results)
-	assert.Len(t, results, 1) // Failed repo should be included with empty array
-	assert.Equal(t, "delayed-repo", results["delayed-repo"].RepositoryName)
-	assert.Empty(t, results["delayed-repo"].ForecastData)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Nil(t, results)
 }
 
 func TestWeatherService_FetchForecasts_ConcurrentExecution(t *testing.T) {
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 
 	date1 := time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)
 
@@ -233,7 +259,7 @@ func TestWeatherService_FetchForecasts_ConcurrentExecution(t *testing.T) {
 				Lat:            40.7128,
 				Lon:            -74.0060,
 				ForecastWindow: 1,
-				ForecastData:   []models.WeatherData{{Date: &date1, TempMax: 25.0, TempMin: 15.0}},
+				ForecastData:   []models.WeatherData{{Date: models.NewDate(date1), TempMax: 25.0, TempMin: 15.0}},
 			},
 		},
 		&MockRepository{
@@ -243,7 +269,7 @@ func TestWeatherService_FetchForecasts_ConcurrentExecution(t *testing.T) {
 				Lat:            40.7128,
 				Lon:            -74.0060,
 				ForecastWindow: 1,
-				ForecastData:   []models.WeatherData{{Date: &date1, TempMax: 24.0, TempMin: 14.0}},
+				ForecastData:   []models.WeatherData{{Date: models.NewDate(date1), TempMax: 24.0, TempMin: 14.0}},
 			},
 		},
 		&MockRepository{
@@ -253,7 +279,7 @@ func TestWeatherService_FetchForecasts_ConcurrentExecution(t *testing.T) {
 				Lat:            40.7128,
 				Lon:            -74.0060,
 				ForecastWindow: 1,
-				ForecastData:   []models.WeatherData{{Date: &date1, TempMax: 23.0, TempMin: 13.0}},
+				ForecastData:   []models.WeatherData{{Date: models.NewDate(date1), TempMax: 23.0, TempMin: 13.0}},
 			},
 		},
 	}
@@ -289,7 +315,7 @@ func TestWeatherService_FetchForecasts_ConcurrentExecution(t *testing.T) {
 }
 
 func TestWeatherService_FetchForecasts_DefaultForecastWindow(t *testing.T) {
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 
 	date1 := time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)
 
@@ -298,7 +324,7 @@ func TestWeatherService_FetchForecasts_DefaultForecastWindow(t *testing.T) {
 		Lat:            40.7128,
 		Lon:            -74.0060,
 		ForecastWindow: 0,
-		ForecastData:   []models.WeatherData{{Date: &date1, TempMax: 25.0, TempMin: 15.0}},
+		ForecastData:   []models.WeatherData{{Date: models.NewDate(date1), TempMax: 25.0, TempMin: 15.0}},
 	}
 
 	repos := []repositories.WeatherRepository{
@@ -317,11 +343,11 @@ func TestWeatherService_FetchForecasts_DefaultForecastWindow(t *testing.T) {
 	require.NoError(t, err)
 	assert.NotNil(t, results)
 	assert.Len(t, results, 1)
-	assert.Equal(t, mockForecast, results["test-repo"])
+	assert.Equal(t, withTimezone(mockForecast), results["test-repo"])
 }
 
 func TestWeatherService_FetchForecasts_InvalidCoordinates(t *testing.T) {
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 
 	repos := []repositories.WeatherRepository{
 		&MockRepository{name: "test-repo", shouldFail: true}, // Will fail with invalid coordinates
@@ -341,10 +367,11 @@ func TestWeatherService_FetchForecasts_InvalidCoordinates(t *testing.T) {
 	assert.Len(t, results, 1) // Failed repo should be included with empty array
 	assert.Equal(t, "test-repo", results["test-repo"].RepositoryName)
 	assert.Empty(t, results["test-repo"].ForecastData)
+	assert.Equal(t, models.ErrorCodeUnknown, results["test-repo"].ErrorCode)
 }
 
 func TestWeatherService_FetchForecasts_MixedSuccessAndFailure(t *testing.T) {
-	l := logger.NewZapLogger("test-app")
+	l := logger.NopLogger{}
 
 	date1 := time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)
 
@@ -353,7 +380,7 @@ func TestWeatherService_FetchForecasts_MixedSuccessAndFailure(t *testing.T) {
 		Lat:            40.7128,
 		Lon:            -74.0060,
 		ForecastWindow: 1,
-		ForecastData:   []models.WeatherData{{Date: &date1, TempMax: 25.0, TempMin: 15.0}},
+		ForecastData:   []models.WeatherData{{Date: models.NewDate(date1), TempMax: 25.0, TempMin: 15.0}},
 	}
 
 	mockForecast2 := models.Forecast{
@@ -361,7 +388,7 @@ func TestWeatherService_FetchForecasts_MixedSuccessAndFailure(t *testing.T) {
 		Lat:            40.7128,
 		Lon:            -74.0060,
 		ForecastWindow: 1,
-		ForecastData:   []models.WeatherData{{Date: &date1, TempMax: 24.0, TempMin: 14.0}},
+		ForecastData:   []models.WeatherData{{Date: models.NewDate(date1), TempMax: 24.0, TempMin: 14.0}},
 	}
 
 	repos := []repositories.WeatherRepository{
@@ -384,10 +411,643 @@ func TestWeatherService_FetchForecasts_MixedSuccessAndFailure(t *testing.T) {
 	assert.NotNil(t, results)
 	assert.Len(t, results, 4) // All repos should be in results
 
-	assert.Equal(t, mockForecast1, results["success-1"])
-	assert.Equal(t, mockForecast2, results["success-2"])
+	assert.Equal(t, withTimezone(mockForecast1), results["success-1"])
+	assert.Equal(t, withTimezone(mockForecast2), results["success-2"])
 	assert.Equal(t, "failure-1", results["failure-1"].RepositoryName)
 	assert.Empty(t, results["failure-1"].ForecastData)
+	assert.Equal(t, models.ErrorCodeUnknown, results["failure-1"].ErrorCode)
 	assert.Equal(t, "failure-2", results["failure-2"].RepositoryName)
 	assert.Empty(t, results["failure-2"].ForecastData)
+	assert.Equal(t, models.ErrorCodeUnknown, results["failure-2"].ErrorCode)
+}
+
+func TestWeatherService_FetchForecasts_FailureLog(t *testing.T) {
+	l := logger.NewTestLogger()
+
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "ok-repo", forecastData: models.Forecast{RepositoryName: "ok-repo"}},
+		&MockRepository{name: "failed-repo", err: context.DeadlineExceeded},
+	}
+
+	_, err := weather.NewWeatherService(repos, l).FetchForecasts(context.Background(), 40.7128, -74.0060, 2)
+	require.NoError(t, err)
+
+	errors := l.Filter(logger.LevelError)
+	require.Len(t, errors, 1)
+	assert.ErrorIs(t, errors[0].Err, context.DeadlineExceeded)
+	assert.Equal(t, "failed-repo", errors[0].Fields["repo"])
+	assert.Equal(t, models.ErrorCodeTimeout, errors[0].Fields["error_code"])
+}
+
+func TestWeatherService_FetchForecasts_ProviderErrorLog(t *testing.T) {
+	l := logger.NewTestLogger()
+
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "ok-repo", forecastData: models.Forecast{RepositoryName: "ok-repo"}},
+		&MockRepository{name: "failed-repo", err: context.DeadlineExceeded},
+	}
+	service := weather.NewWeatherService(repos, l, weather.WithProviderErrorLog(100, time.Hour))
+
+	for range 250 {
+		_, err := service.FetchForecasts(context.Background(), 40.7128, -74.0060, 2)
+		require.NoError(t, err)
+	}
+
+	// The first failure, then one in 100 along with the summary of the skipped ones
+	assert.Len(t, l.Filter(logger.LevelError), 3)
+	summaries := l.Filter(logger.LevelWarning)
+	require.Len(t, summaries, 2)
+	assert.Contains(t, summaries[0].Message, "failed-repo failed 100 times in the last")
+	assert.Equal(t, 99, summaries[0].Fields["suppressed"])
+}
+
+func TestWeatherService_FetchForecasts_TimezoneDisagreement(t *testing.T) {
+	l := logger.NewTestLogger()
+
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "named-repo", forecastData: models.Forecast{
+			RepositoryName: "named-repo",
+			Timezone:       &models.Timezone{Name: "Asia/Tokyo", UTCOffsetSeconds: 9 * 3600},
+		}},
+		&MockRepository{name: "offset-repo", forecastData: models.Forecast{
+			RepositoryName: "offset-repo",
+			Timezone:       &models.Timezone{UTCOffsetSeconds: 8 * 3600},
+		}},
+	}
+
+	service := weather.NewWeatherService(repos, l)
+
+	results, err := service.FetchForecasts(context.Background(), 35.68, 139.69, 1)
+
+	require.NoError(t, err)
+	expected := models.Timezone{Name: "Asia/Tokyo", UTCOffsetSeconds: 9 * 3600}
+	assert.Equal(t, &expected, results["named-repo"].Timezone)
+	assert.Equal(t, &expected, results["offset-repo"].Timezone)
+	warnings := l.Filter(logger.LevelWarning)
+	require.Len(t, warnings, 1)
+	assert.Equal(t, "timezone disagreement between providers", warnings[0].Message)
+	assert.Equal(t, "offset-repo", warnings[0].Fields["repo"])
+}
+
+// timezoneRepository records the timezone the service passes to the provider
+type timezoneRepository struct {
+	*MockRepository
+	received []*time.Location
+}
+
+func (r *timezoneRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	r.received = append(r.received, repositories.TimezoneFromContext(ctx))
+	return r.MockRepository.FetchForecast(ctx, lat, lon, forecastWindow)
+}
+
+func TestWeatherService_FetchForecasts_PassesResolvedTimezone(t *testing.T) {
+	named := &MockRepository{name: "named-repo", forecastData: models.Forecast{
+		RepositoryName: "named-repo",
+		Timezone:       &models.Timezone{Name: "Asia/Tokyo", UTCOffsetSeconds: 9 * 3600},
+	}}
+	offset := &timezoneRepository{MockRepository: &MockRepository{name: "offset-repo", forecastData: models.Forecast{
+		RepositoryName: "offset-repo",
+		Timezone:       &models.Timezone{UTCOffsetSeconds: 9 * 3600},
+	}}}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{named, offset}, logger.NopLogger{})
+
+	// The first request resolves the name, the providers take the local days in it from then on
+	for range 2 {
+		_, err := service.FetchForecasts(context.Background(), 35.68, 139.69, 1)
+		require.NoError(t, err)
+	}
+
+	require.Len(t, offset.received, 2)
+	assert.Nil(t, offset.received[0])
+	require.NotNil(t, offset.received[1])
+	assert.Equal(t, "Asia/Tokyo", offset.received[1].String())
+}
+
+func TestWeatherService_FetchForecasts_AppliesRules(t *testing.T) {
+	l := logger.NopLogger{}
+
+	date1 := time.Date(2025, 7, 25, 0, 0, 0, 0, time.UTC)
+	date2 := time.Date(2025, 7, 26, 0, 0, 0, 0, time.UTC)
+
+	engine, err := rules.NewEngine(config.RulesConfig{Rules: []config.RuleConfig{
+		{Field: "temp_max", Comparator: "gt", Value: 50, Action: rules.ActionSuspect},
+	}})
+	require.NoError(t, err)
+
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "repo-1", forecastData: models.Forecast{
+			RepositoryName: "repo-1",
+			ForecastData: []models.WeatherData{
+				{Date: models.NewDate(date1), TempMax: 25.0, TempMin: 15.0},
+				{Date: models.NewDate(date2), TempMax: 85.0, TempMin: 16.0},
+			},
+		}},
+	}
+
+	service := weather.NewWeatherService(repos, l, weather.WithRules(engine))
+
+	results, err := service.FetchForecasts(context.Background(), 40.7128, -74.0060, 2)
+
+	require.NoError(t, err)
+	require.Len(t, results["repo-1"].ForecastData, 2)
+	assert.False(t, results["repo-1"].ForecastData[0].Suspect)
+	assert.True(t, results["repo-1"].ForecastData[1].Suspect)
+}
+
+// MockHourlyRepository is a MockRepository that also serves hourly forecasts
+type MockHourlyRepository struct {
+	MockRepository
+	hourlyData models.HourlyForecast
+}
+
+func (m *MockHourlyRepository) FetchHourlyForecast(ctx context.Context, lat, lon float64, hours int) (models.HourlyForecast, error) {
+	m.callCount++
+
+	if m.shouldFail {
+		return models.HourlyForecast{}, errors.New("mock repository error")
+	}
+
+	return m.hourlyData, nil
+}
+
+func TestWeatherService_FetchHourlyForecasts(t *testing.T) {
+	hour := time.Date(2025, 7, 25, 14, 0, 0, 0, time.UTC)
+	probability := 40.0
+
+	hourlyRepo := &MockHourlyRepository{
+		MockRepository: MockRepository{name: "hourly"},
+		hourlyData: models.HourlyForecast{
+			RepositoryName: "hourly",
+			Lat:            40.7128,
+			Lon:            -74.0060,
+			Hours:          1,
+			HourlyData:     []models.HourlyWeatherData{{Time: &hour, Temp: 21.4, PrecipitationProbability: &probability}},
+		},
+	}
+	failingRepo := &MockHourlyRepository{MockRepository: MockRepository{name: "failing", shouldFail: true}}
+	dailyOnlyRepo := &MockRepository{name: "daily-only"}
+
+	l := logger.NopLogger{}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{hourlyRepo, failingRepo, dailyOnlyRepo}, l)
+
+	results, err := service.FetchHourlyForecasts(context.Background(), 40.7128, -74.0060, 1)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, hourlyRepo.hourlyData, results["hourly"])
+
+	assert.Equal(t, models.ErrorCodeUnknown, results["failing"].ErrorCode)
+	assert.Empty(t, results["failing"].HourlyData)
+
+	assert.Equal(t, models.ErrorCodeUnsupported, results["daily-only"].ErrorCode)
+	assert.Empty(t, results["daily-only"].HourlyData)
+	assert.Equal(t, 0, dailyOnlyRepo.callCount, "daily path must not be used for hourly forecasts")
+}
+
+// MockCurrentRepository is a MockRepository that also serves current conditions
+type MockCurrentRepository struct {
+	MockRepository
+	currentData models.CurrentWeather
+}
+
+func (m *MockCurrentRepository) FetchCurrent(ctx context.Context, lat, lon float64) (models.CurrentWeather, error) {
+	m.callCount++
+
+	if m.shouldFail {
+		return models.CurrentWeather{}, errors.New("mock repository error")
+	}
+
+	return m.currentData, nil
+}
+
+func TestWeatherService_FetchCurrentWeather(t *testing.T) {
+	currentRepo := &MockCurrentRepository{
+		MockRepository: MockRepository{name: "current"},
+		currentData: models.CurrentWeather{
+			RepositoryName: "current",
+			Lat:            40.7128,
+			Lon:            -74.0060,
+			Current: &models.CurrentConditions{
+				ObservedAt:  time.Date(2025, 7, 25, 14, 15, 0, 0, time.UTC),
+				Temperature: 21.4,
+			},
+		},
+	}
+	failingRepo := &MockCurrentRepository{MockRepository: MockRepository{name: "failing", shouldFail: true}}
+	forecastOnlyRepo := &MockRepository{name: "forecast-only"}
+
+	l := logger.NopLogger{}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{currentRepo, failingRepo, forecastOnlyRepo}, l)
+
+	results, err := service.FetchCurrentWeather(context.Background(), 40.7128, -74.0060)
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	assert.Equal(t, currentRepo.currentData, results["current"])
+
+	assert.Equal(t, models.ErrorCodeUnknown, results["failing"].ErrorCode)
+	assert.Nil(t, results["failing"].Current)
+
+	assert.Equal(t, models.ErrorCodeUnsupported, results["forecast-only"].ErrorCode)
+	assert.Nil(t, results["forecast-only"].Current)
+	assert.Equal(t, 0, forecastOnlyRepo.callCount, "forecast path must not be used for current conditions")
+}
+
+// MockHistoricalRepository is a MockRepository with a weather archive
+type MockHistoricalRepository struct {
+	MockRepository
+}
+
+func (m *MockHistoricalRepository) FetchHistory(ctx context.Context, lat, lon float64, start, end models.Date) (models.HistoricalWeather, error) {
+	m.callCount++
+
+	if m.shouldFail {
+		return models.HistoricalWeather{}, errors.New("mock repository error")
+	}
+
+	return models.HistoricalWeather{
+		RepositoryName: m.name,
+		Lat:            lat,
+		Lon:            lon,
+		Start:          start,
+		End:            end,
+		WeatherData:    []models.WeatherData{{Date: start, TempMax: 4.2, TempMin: -1.3}},
+	}, nil
+}
+
+func TestWeatherService_FetchHistory(t *testing.T) {
+	archiveRepo := &MockHistoricalRepository{MockRepository{name: "archive"}}
+	failingRepo := &MockHistoricalRepository{MockRepository{name: "failing", shouldFail: true}}
+	forecastOnlyRepo := &MockRepository{name: "forecast-only"}
+
+	l := logger.NopLogger{}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{archiveRepo, failingRepo, forecastOnlyRepo}, l)
+
+	start, err := models.ParseDate("2025-01-25")
+	require.NoError(t, err)
+
+	results, err := service.FetchHistory(context.Background(), 40.7128, -74.0060, start, start)
+	require.NoError(t, err)
+	require.Len(t, results, 2, "providers without an archive must not be queried")
+	assert.NotContains(t, results, "forecast-only")
+	assert.Equal(t, 0, forecastOnlyRepo.callCount)
+
+	assert.Len(t, results["archive"].WeatherData, 1)
+	assert.Equal(t, models.ErrorCodeUnknown, results["failing"].ErrorCode)
+	assert.Empty(t, results["failing"].WeatherData)
+}
+
+func TestWeatherService_FetchHistory_NoHistoricalProviders(t *testing.T) {
+	l := logger.NopLogger{}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{&MockRepository{name: "forecast-only"}}, l)
+
+	_, err := service.FetchHistory(context.Background(), 40.7128, -74.0060, models.NewDate(time.Now()), models.NewDate(time.Now()))
+	assert.ErrorIs(t, err, weather.ErrNoHistoricalProviders)
+}
+
+func TestWeatherService_FetchForecasts_ErrorClassification(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{"timeout", fmt.Errorf("failed to do request: %w", context.DeadlineExceeded), models.ErrorCodeTimeout, "provider timed out"},
+		{"rate limited", &repositories.HTTPStatusError{StatusCode: 429, Status: "429 Too Many Requests"}, models.ErrorCodeRateLimited, "provider rate limit exceeded"},
+		{"upstream http", &repositories.HTTPStatusError{StatusCode: 502, Status: "502 Bad Gateway"}, models.ErrorCodeUpstreamHTTP, "provider returned HTTP 502"},
+		{"parse failure", &repositories.ResponseError{Err: errors.New("failed to parse JSON response")}, models.ErrorCodeParse, "invalid provider response"},
+		{"no data", repositories.ErrNoData, models.ErrorCodeNoData, "provider returned no forecast data"},
+		{"network", &url.Error{Op: "Get", URL: "https://api.example.com/forecast?appid=secret", Err: errors.New("connection refused")}, models.ErrorCodeUnavailable, "provider unreachable"},
+		{"unknown", errors.New("something with appid=secret"), models.ErrorCodeUnknown, "provider error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repos := []repositories.WeatherRepository{&MockRepository{name: "failing", err: tt.err}}
+			service := weather.NewWeatherService(repos, logger.NopLogger{})
+
+			results, err := service.FetchForecasts(context.Background(), 40.7128, -74.0060, 1)
+			require.NoError(t, err)
+
+			forecast := results["failing"]
+			assert.Equal(t, tt.wantCode, forecast.ErrorCode)
+			assert.Equal(t, tt.wantMessage, forecast.Error)
+			assert.NotContains(t, forecast.Error, "secret")
+			assert.Empty(t, forecast.ForecastData)
+		})
+	}
+}
+
+func TestWeatherService_FetchOrderedForecasts_StableOrder(t *testing.T) {
+	l := logger.NopLogger{}
+
+	// The first configured providers are the slowest, so the completion order is the reverse of the configuration
+	names := []string{"repo-a", "repo-b", "repo-c", "repo-d"}
+	repos := make([]repositories.WeatherRepository, len(names))
+	for i, name := range names {
+		repos[i] = &MockRepository{
+			name:         name,
+			shouldFail:   i == 2,
+			delay:        time.Duration(len(names)-i) * 2 * time.Millisecond,
+			forecastData: models.Forecast{RepositoryName: name, ForecastData: []models.WeatherData{}},
+		}
+	}
+
+	service := weather.NewWeatherService(repos, l)
+
+	for run := 0; run < 20; run++ {
+		forecasts, err := service.FetchOrderedForecasts(context.Background(), 40.7128, -74.0060, 1)
+		require.NoError(t, err)
+		require.Len(t, forecasts, len(names))
+
+		for i, forecast := range forecasts {
+			assert.Equal(t, names[i], forecast.RepositoryName, "run %d", run)
+		}
+		assert.NotEmpty(t, forecasts[2].Error)
+	}
+}
+
+func TestWeatherService_FetchProviderForecasts(t *testing.T) {
+	l := logger.NopLogger{}
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "repo-1", forecastData: models.Forecast{RepositoryName: "repo-1", ForecastData: []models.WeatherData{}}},
+		&MockRepository{name: "repo-2", forecastData: models.Forecast{RepositoryName: "repo-2", ForecastData: []models.WeatherData{}}},
+	}
+	service := weather.NewWeatherService(repos, l)
+
+	assert.Equal(t, []string{"repo-1", "repo-2"}, service.Providers())
+
+	results, err := service.FetchProviderForecasts(context.Background(), 40.7128, -74.0060, 1, []string{"repo-2"})
+	require.NoError(t, err)
+	assert.Len(t, results, 1)
+	assert.Contains(t, results, "repo-2")
+	assert.Equal(t, 0, repos[0].(*MockRepository).callCount)
+
+	_, err = service.FetchProviderForecasts(context.Background(), 40.7128, -74.0060, 1, []string{"repo-3"})
+	assert.EqualError(t, err, "unknown weather provider: repo-3")
+}
+
+// blockingRepository blocks until its request is canceled
+type blockingRepository struct {
+	canceled chan struct{}
+}
+
+func (b *blockingRepository) Name() string {
+	return "blocking-repo"
+}
+
+func (b *blockingRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	<-ctx.Done()
+	close(b.canceled)
+	return models.Forecast{}, ctx.Err()
+}
+
+func TestWeatherService_FetchFirstForecast_SkipsFailures(t *testing.T) {
+	l := logger.NopLogger{}
+	fast := &MockRepository{name: "fast-failing", shouldFail: true}
+	slow := &MockRepository{
+		name:         "slow-succeeding",
+		delay:        20 * time.Millisecond,
+		forecastData: models.Forecast{RepositoryName: "slow-succeeding", ForecastData: []models.WeatherData{}},
+	}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{fast, slow}, l)
+
+	forecast, err := service.FetchFirstForecast(context.Background(), 40.7128, -74.0060, 1, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, "slow-succeeding", forecast.RepositoryName)
+	assert.Equal(t, estimatedTimezone, *forecast.Timezone)
+	assert.Equal(t, 1, fast.callCount)
+	assert.Equal(t, 1, slow.callCount)
+}
+
+func TestWeatherService_FetchFirstForecast_CancelsLosers(t *testing.T) {
+	l := logger.NopLogger{}
+	blocking := &blockingRepository{canceled: make(chan struct{})}
+	fast := &MockRepository{name: "fast", forecastData: models.Forecast{RepositoryName: "fast", ForecastData: []models.WeatherData{}}}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{blocking, fast}, l)
+
+	forecast, err := service.FetchFirstForecast(context.Background(), 40.7128, -74.0060, 1, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "fast", forecast.RepositoryName)
+
+	select {
+	case <-blocking.canceled:
+	case <-time.After(time.Second):
+		t.Fatal("the losing provider was not canceled")
+	}
+}
+
+func TestWeatherService_FetchFirstForecast_AllFailures(t *testing.T) {
+	l := logger.NopLogger{}
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "failure-repo-1", shouldFail: true},
+		&MockRepository{name: "failure-repo-2", err: context.DeadlineExceeded},
+	}
+	service := weather.NewWeatherService(repos, l)
+
+	_, err := service.FetchFirstForecast(context.Background(), 40.7128, -74.0060, 1, nil)
+	assert.ErrorIs(t, err, weather.ErrNoForecasts)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "failure-repo-1: mock repository error")
+}
+
+func TestWeatherService_FetchForecasts_ProviderTimeout(t *testing.T) {
+	l := logger.NopLogger{}
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "delayed-repo", shouldDelay: true},
+		&MockRepository{name: "fast-repo", forecastData: models.Forecast{RepositoryName: "fast-repo", ForecastData: []models.WeatherData{}}},
+	}
+	timeouts := map[string]time.Duration{"delayed-repo": 20 * time.Millisecond, "fast-repo": 20 * time.Millisecond}
+	service := weather.NewWeatherService(repos, l, weather.WithProviderTimeouts(timeouts))
+
+	// The slowest provider timeout is below the default one
+	assert.Equal(t, 6*time.Second, service.RequestBudget())
+
+	start := time.Now()
+	results, err := service.FetchForecasts(context.Background(), 40.7128, -74.0060, 1)
+	elapsed := time.Since(start)
+
+	require.NoError(t, err)
+	assert.Less(t, elapsed, 80*time.Millisecond)
+
+	assert.Equal(t, models.ErrorCodeTimeout, results["delayed-repo"].ErrorCode)
+	assert.Empty(t, results["delayed-repo"].ForecastData)
+	assert.Empty(t, results["fast-repo"].Error)
+}
+
+func TestWeatherService_RequestBudget(t *testing.T) {
+	l := logger.NopLogger{}
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "repo-1"},
+		&MockRepository{name: "repo-2"},
+	}
+
+	service := weather.NewWeatherService(repos, l)
+	assert.Equal(t, 6*time.Second, service.RequestBudget())
+
+	service = weather.NewWeatherService(repos, l, weather.WithProviderTimeouts(map[string]time.Duration{"repo-2": 10 * time.Second}))
+	assert.Equal(t, 11*time.Second, service.RequestBudget())
+
+	// The request timeout caps the budget
+	service = weather.NewWeatherService(repos, l,
+		weather.WithProviderTimeouts(map[string]time.Duration{"repo-2": 30 * time.Second}),
+		weather.WithRequestTimeout(10*time.Second))
+	assert.Equal(t, 10*time.Second, service.RequestBudget())
+}
+
+func TestWeatherService_FetchForecasts_BudgetExceeded(t *testing.T) {
+	l := logger.NopLogger{}
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "delayed-repo", shouldDelay: true},
+		&MockRepository{name: "fast-repo", forecastData: models.Forecast{RepositoryName: "fast-repo", ForecastData: []models.WeatherData{}}},
+	}
+	service := weather.NewWeatherService(repos, l)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	results, err := service.FetchForecasts(ctx, 40.7128, -74.0060, 1)
+
+	// The forecast of the provider that answered comes with the error
+	require.ErrorIs(t, err, weather.ErrBudgetExceeded)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Empty(t, results["fast-repo"].Error)
+	assert.Equal(t, models.ErrorCodeTimeout, results["delayed-repo"].ErrorCode)
+
+	// Without one, the error alone
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	results, err = service.FetchProviderForecasts(ctx, 40.7128, -74.0060, 1, []string{"delayed-repo"})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.NotErrorIs(t, err, weather.ErrBudgetExceeded)
+	assert.Nil(t, results)
+}
+
+func TestWeatherService_FetchForecasts_SummaryLog(t *testing.T) {
+	var logs bytes.Buffer
+	l := logger.NewZapLogger("test-app", &logs)
+
+	repos := []repositories.WeatherRepository{
+		&MockRepository{name: "ok-repo", forecastData: models.Forecast{
+			RepositoryName: "ok-repo",
+			ForecastData:   []models.WeatherData{{TempMax: 123.4, TempMin: 20}, {TempMax: 31, TempMin: 21}},
+		}},
+		&MockRepository{name: "failed-repo", err: context.DeadlineExceeded},
+	}
+
+	service := weather.NewWeatherService(repos, l)
+
+	_, err := service.FetchForecasts(context.Background(), 40.7128, -74.0060, 2)
+	require.NoError(t, err)
+
+	var summary struct {
+		Providers []struct {
+			Provider   string `json:"provider"`
+			Status     string `json:"status"`
+			ErrorCode  string `json:"error_code"`
+			Days       int    `json:"days"`
+			DurationMS *int64 `json:"duration_ms"`
+		} `json:"providers"`
+		ElapsedMS *int64 `json:"elapsed_ms"`
+	}
+	for _, line := range strings.Split(logs.String(), "\n") {
+		if strings.Contains(line, `"msg":"completed forecast fetch"`) {
+			require.NoError(t, json.Unmarshal([]byte(line), &summary))
+		}
+	}
+
+	require.Len(t, summary.Providers, 2)
+	assert.Equal(t, "ok-repo", summary.Providers[0].Provider)
+	assert.Equal(t, "ok", summary.Providers[0].Status)
+	assert.Equal(t, 2, summary.Providers[0].Days)
+	assert.NotNil(t, summary.Providers[0].DurationMS)
+	assert.Equal(t, "failed-repo", summary.Providers[1].Provider)
+	assert.Equal(t, "failed", summary.Providers[1].Status)
+	assert.Equal(t, models.ErrorCodeTimeout, summary.Providers[1].ErrorCode)
+	assert.Equal(t, 0, summary.Providers[1].Days)
+	assert.NotNil(t, summary.ElapsedMS)
+
+	// The forecast data stays out of the logs
+	assert.NotContains(t, logs.String(), "123.4")
+}
+
+// horizonRepository forecasts as many days as requested from today in UTC, up to maxDays
+type horizonRepository struct {
+	name      string
+	maxDays   int
+	requested int
+}
+
+func (h *horizonRepository) Name() string {
+	return h.name
+}
+
+func (h *horizonRepository) MaxDays() int {
+	return h.maxDays
+}
+
+func (h *horizonRepository) FetchForecast(ctx context.Context, lat, lon float64, forecastWindow int) (models.Forecast, error) {
+	h.requested = forecastWindow
+	if forecastWindow > h.maxDays {
+		return models.Forecast{}, fmt.Errorf("%s serves up to %d days", h.name, h.maxDays)
+	}
+
+	today := time.Now().UTC()
+	data := make([]models.WeatherData, forecastWindow)
+	for i := range data {
+		data[i] = models.WeatherData{Date: models.NewDate(today.AddDate(0, 0, i)), TempMax: 20, TempMin: 10}
+	}
+
+	return models.Forecast{
+		RepositoryName: h.name,
+		Lat:            lat,
+		Lon:            lon,
+		ForecastWindow: forecastWindow,
+		ForecastData:   data,
+	}, nil
+}
+
+func TestWeatherService_FetchForecasts_ClampedToProviderHorizon(t *testing.T) {
+	short := &horizonRepository{name: "short", maxDays: 5}
+	long := &horizonRepository{name: "long", maxDays: 16}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{short, long}, logger.NopLogger{})
+
+	forecasts, err := service.FetchForecasts(context.Background(), 40.7128, -74.0060, 10)
+	require.NoError(t, err)
+
+	assert.Equal(t, 5, short.requested)
+	assert.Empty(t, forecasts["short"].Error)
+	assert.Equal(t, 5, forecasts["short"].ForecastWindow)
+	assert.Len(t, forecasts["short"].ForecastData, 5)
+	assert.Equal(t, "forecast window clamped from 10 to 5 days, the provider horizon", forecasts["short"].Note)
+
+	assert.Equal(t, 10, long.requested)
+	assert.Len(t, forecasts["long"].ForecastData, 10)
+	assert.Empty(t, forecasts["long"].Note)
+}
+
+func TestWeatherService_MaxForecastDays(t *testing.T) {
+	l := logger.NopLogger{}
+
+	assert.Equal(t, 16, weather.NewWeatherService(nil, l).MaxForecastDays())
+	assert.Equal(t, 10, weather.NewWeatherService(nil, l, weather.WithMaxForecastDays(10)).MaxForecastDays())
+	assert.Equal(t, 16, weather.NewWeatherService(nil, l, weather.WithMaxForecastDays(0)).MaxForecastDays())
+}
+
+func TestWeatherService_SortsForecastDays(t *testing.T) {
+	var days []models.WeatherData
+	for _, s := range []string{"2025-07-27", "2025-07-25", "2025-07-26"} {
+		date, _ := models.ParseDate(s)
+		days = append(days, models.WeatherData{Date: date})
+	}
+	repo := &MockRepository{name: "open-meteo", forecastData: models.Forecast{RepositoryName: "open-meteo", ForecastData: days}}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{repo}, logger.NopLogger{})
+
+	forecasts, err := service.FetchForecasts(context.Background(), 52.52, 13.41, 3)
+	require.NoError(t, err)
+
+	data := forecasts["open-meteo"].ForecastData
+	require.Len(t, data, 3)
+	assert.True(t, models.WeatherDataSorted(data))
+	assert.Equal(t, "2025-07-25", data[0].Date.String())
 }
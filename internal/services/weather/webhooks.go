@@ -0,0 +1,484 @@
+package weather
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"syscall"
+	"time"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/pkg/logger"
+)
+
+const (
+	defaultWebhookTick        = 30 * time.Second
+	defaultWebhookMinInterval = 5 * time.Minute
+	defaultWebhookMaxFailures = 5
+	defaultWebhookTimeout     = 5 * time.Second
+	// webhookRetryDelay is the delay before the first retry of a failed delivery, doubled by every failure up to
+	// the check interval
+	webhookRetryDelay = 30 * time.Second
+	// defaultConditionDays is the number of forecast days checked unless set by the condition
+	defaultConditionDays = 3
+)
+
+// The headers of a webhook notification, the signature authenticates the timestamp and the body, see SignWebhook
+const (
+	WebhookSubscriptionHeader = "X-Webhook-Subscription"
+	WebhookTimestampHeader    = "X-Webhook-Timestamp"
+	WebhookSignatureHeader    = "X-Webhook-Signature"
+)
+
+// errWebhookRedirect refuses the redirects of a webhook, they could send the notification to another host
+var errWebhookRedirect = errors.New("webhook redirects are not followed")
+
+// nonPublicPrefixes are the special-purpose ranges of global unicast addresses a webhook may not point to, besides
+// the private ones
+var nonPublicPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+}
+
+// conditionFields are the aggregated values a subscription condition compares
+var conditionFields = map[string]func(d models.AggregatedWeatherData) float64{
+	"temp_max": func(d models.AggregatedWeatherData) float64 { return d.TempMax },
+	"temp_min": func(d models.AggregatedWeatherData) float64 { return d.TempMin },
+}
+
+// conditionOps are the comparisons of a subscription condition, of the field to the value
+var conditionOps = map[string]func(a, b float64) bool{
+	"lt":  func(a, b float64) bool { return a < b },
+	"lte": func(a, b float64) bool { return a <= b },
+	"gt":  func(a, b float64) bool { return a > b },
+	"gte": func(a, b float64) bool { return a >= b },
+}
+
+// WebhookOptions configures the delivery of the subscription notifications, the zero values keep the defaults
+type WebhookOptions struct {
+	// Client posts the notifications as configured, when nil a client with Timeout connecting only to the public
+	// addresses and following no redirect
+	Client  *http.Client
+	Timeout time.Duration
+	// AllowPrivateNetworks lets the webhooks point to the loopback, private and link-local addresses, for the
+	// receivers of an internal network
+	AllowPrivateNetworks bool
+	// MinInterval is the shortest check interval of a subscription, 5 minutes by default
+	MinInterval time.Duration
+	// MaxFailures is the number of consecutive failed deliveries disabling a subscription, 5 by default
+	MaxFailures int
+	// Tick is how often the due subscriptions are checked in the background, every 30 seconds by default,
+	// negative disables the background checks, see CheckSubscriptions
+	Tick time.Duration
+	// Now is the clock of the checks, time.Now when nil
+	Now func() time.Time
+}
+
+// webhooks delivers the notifications of the subscriptions kept by the forecast store
+type webhooks struct {
+	client      *http.Client
+	minInterval time.Duration
+	maxFailures int
+	tick        time.Duration
+	now         func() time.Time
+}
+
+func newWebhooks() *webhooks {
+	return &webhooks{
+		client:      newWebhookClient(defaultWebhookTimeout, false),
+		minInterval: defaultWebhookMinInterval,
+		maxFailures: defaultWebhookMaxFailures,
+		tick:        defaultWebhookTick,
+		now:         time.Now,
+	}
+}
+
+// WithWebhooks configures the notifications of the subscriptions, they need a forecast store keeping them
+func WithWebhooks(opts WebhookOptions) Option {
+	return func(s *WeatherService) {
+		switch {
+		case opts.Client != nil:
+			s.webhooks.client = opts.Client
+		case opts.Timeout > 0 || opts.AllowPrivateNetworks:
+			s.webhooks.client = newWebhookClient(cmp.Or(opts.Timeout, defaultWebhookTimeout), opts.AllowPrivateNetworks)
+		}
+		if opts.MinInterval > 0 {
+			s.webhooks.minInterval = opts.MinInterval
+		}
+		if opts.MaxFailures > 0 {
+			s.webhooks.maxFailures = opts.MaxFailures
+		}
+		if opts.Tick != 0 {
+			s.webhooks.tick = opts.Tick
+		}
+		if opts.Now != nil {
+			s.webhooks.now = opts.Now
+		}
+	}
+}
+
+// newWebhookClient returns the client posting the notifications, it follows no redirect. Unless allowPrivate,
+// it only connects to the public addresses: they are checked once the host of the webhook is resolved, so that a
+// name resolving to an internal address is refused too.
+func newWebhookClient(timeout time.Duration, allowPrivate bool) *http.Client {
+	dialer := &net.Dialer{Timeout: timeout}
+	if !allowPrivate {
+		dialer.Control = dialPublicOnly
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// The address of a proxy would be the only one checked
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return errWebhookRedirect
+		},
+	}
+}
+
+// dialPublicOnly refuses a connection to an address that isn't public, see publicAddress
+func dialPublicOnly(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !publicAddress(addr) {
+		return fmt.Errorf("webhook address %s is not public", addr)
+	}
+
+	return nil
+}
+
+// publicAddress reports whether addr is a public unicast address: not loopback, private, link-local, as the cloud
+// metadata endpoints, multicast, unspecified nor special-purpose
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	if !addr.IsGlobalUnicast() || addr.IsPrivate() {
+		return false
+	}
+	for _, prefix := range nonPublicPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// WebhookMinInterval returns the shortest check interval of a subscription
+func (s *WeatherService) WebhookMinInterval() time.Duration {
+	return s.webhooks.minInterval
+}
+
+// ParseCondition validates a subscription condition, zero days selects 3 days
+func ParseCondition(condition models.SubscriptionCondition, maxDays int) (models.SubscriptionCondition, error) {
+	if _, ok := conditionFields[condition.Field]; !ok {
+		return condition, fmt.Errorf("unsupported condition field: %q, expected temp_max or temp_min", condition.Field)
+	}
+	if _, ok := conditionOps[condition.Op]; !ok {
+		return condition, fmt.Errorf("unsupported condition op: %q, expected lt, lte, gt or gte", condition.Op)
+	}
+	if condition.Days == 0 {
+		condition.Days = min(defaultConditionDays, maxDays)
+	}
+	if condition.Days < 1 || condition.Days > maxDays {
+		return condition, fmt.Errorf("condition days must be between 1 and %d, got: %d", maxDays, condition.Days)
+	}
+
+	return condition, nil
+}
+
+// subscriptionStore returns the store of the subscriptions, false when the forecast store doesn't keep them
+func (s *WeatherService) subscriptionStore() (repositories.SubscriptionStore, bool) {
+	if s.store == nil {
+		return nil, false
+	}
+	store, ok := s.store.store.(repositories.SubscriptionStore)
+
+	return store, ok
+}
+
+// CreateSubscription records a subscription of its owner to the location, the condition, the webhook and the check
+// interval of sub, validated by the caller. It returns the subscription with its ID and the secret signing its notifications,
+// the first check is due right away.
+func (s *WeatherService) CreateSubscription(ctx context.Context, sub models.Subscription) (models.Subscription, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	store, ok := s.subscriptionStore()
+	if !ok {
+		return models.Subscription{}, ErrNoForecastStore
+	}
+
+	now := s.webhooks.now().UTC()
+	sub.ID = randomHex(8)
+	sub.Secret = randomHex(32)
+	sub.Lat, sub.Lon = roundCoordinate(sub.Lat), roundCoordinate(sub.Lon)
+	sub.Enabled = true
+	sub.Triggered, sub.Failures, sub.LastError, sub.LastNotifiedAt = false, 0, "", nil
+	sub.CreatedAt, sub.NextCheckAt = now, now
+	if err := store.CreateSubscription(ctx, sub); err != nil {
+		return models.Subscription{}, err
+	}
+
+	logger.FromContext(ctx, s.l).Info("subscription created", map[string]any{
+		"subscription": sub.ID,
+		"owner":        sub.Owner,
+		"lat":          sub.Lat,
+		"lon":          sub.Lon,
+	})
+
+	return sub, nil
+}
+
+// Subscription returns the subscription of the owner without its secret, repositories.ErrSubscriptionNotFound when
+// unknown
+func (s *WeatherService) Subscription(ctx context.Context, owner, id string) (models.Subscription, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	store, ok := s.subscriptionStore()
+	if !ok {
+		return models.Subscription{}, ErrNoForecastStore
+	}
+
+	sub, err := store.Subscription(ctx, owner, id)
+	sub.Secret = ""
+
+	return sub, err
+}
+
+// Subscriptions returns the subscriptions of the owner without their secret, the oldest first
+func (s *WeatherService) Subscriptions(ctx context.Context, owner string) ([]models.Subscription, error) {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	store, ok := s.subscriptionStore()
+	if !ok {
+		return nil, ErrNoForecastStore
+	}
+
+	subs, err := store.Subscriptions(ctx, owner)
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+
+	return subs, err
+}
+
+// DeleteSubscription removes the subscription of the owner, repositories.ErrSubscriptionNotFound when unknown
+func (s *WeatherService) DeleteSubscription(ctx context.Context, owner, id string) error {
+	ctx, end := s.begin(ctx)
+	defer end()
+
+	store, ok := s.subscriptionStore()
+	if !ok {
+		return ErrNoForecastStore
+	}
+
+	return store.DeleteSubscription(ctx, owner, id)
+}
+
+// checkSubscriptionsEvery runs CheckSubscriptions every tick until the shutdown
+func (s *WeatherService) checkSubscriptionsEvery(tick time.Duration) {
+	ctx, end := s.begin(context.Background())
+	go func() {
+		defer end()
+
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.CheckSubscriptions(ctx); err != nil && ctx.Err() == nil {
+					s.l.Error(err, map[string]any{"job": "subscriptions"})
+				}
+			}
+		}
+	}()
+}
+
+// CheckSubscriptions checks the subscriptions due and notifies the webhooks of the ones whose condition is met by
+// a day of the aggregated forecast. A subscription is notified once until its condition is unmet again. A failed
+// delivery is retried after 30 seconds, doubled by every failure up to the check interval, and the subscription is
+// disabled after the configured number of consecutive failures. It returns the number of notifications delivered.
+func (s *WeatherService) CheckSubscriptions(ctx context.Context) (int, error) {
+	store, ok := s.subscriptionStore()
+	if !ok {
+		return 0, ErrNoForecastStore
+	}
+
+	due, err := store.DueSubscriptions(ctx, s.webhooks.now())
+	if err != nil {
+		return 0, err
+	}
+
+	var delivered int
+	for _, sub := range due {
+		if ctx.Err() != nil {
+			return delivered, ctx.Err()
+		}
+		if s.checkSubscription(ctx, &sub) {
+			delivered++
+		}
+		if err := store.UpdateSubscription(ctx, sub); err != nil {
+			return delivered, err
+		}
+	}
+
+	return delivered, nil
+}
+
+// checkSubscription evaluates the condition of sub and notifies its webhook, it updates the state of sub and
+// reports whether a notification was delivered
+func (s *WeatherService) checkSubscription(ctx context.Context, sub *models.Subscription) bool {
+	l := logger.FromContext(ctx, s.l)
+	interval := time.Duration(sub.CheckIntervalSeconds) * time.Second
+
+	fetchCtx, cancel := context.WithTimeout(ctx, s.RequestBudget())
+	aggregated, err := s.SharedAggregateForecasts(fetchCtx, sub.Lat, sub.Lon, sub.Condition.Days, StrategyMean)
+	cancel()
+
+	now := s.webhooks.now().UTC()
+	sub.NextCheckAt = now.Add(interval)
+	if err != nil {
+		l.Warning("subscription not checked, the forecast is unavailable", map[string]any{
+			"subscription": sub.ID,
+			"err":          err.Error(),
+		})
+		return false
+	}
+
+	matches := matchCondition(sub.Condition, aggregated.ForecastData)
+	if len(matches) == 0 {
+		sub.Triggered = false
+		return false
+	}
+	if sub.Triggered {
+		return false
+	}
+
+	if err := s.notify(ctx, *sub, matches, now); err != nil {
+		sub.Failures++
+		sub.LastError = err.Error()
+		sub.NextCheckAt = now.Add(min(webhookRetryDelay<<min(sub.Failures-1, 16), interval))
+		if sub.Failures >= s.webhooks.maxFailures {
+			sub.Enabled = false
+		}
+		l.Warning("subscription notification failed", map[string]any{
+			"subscription": sub.ID,
+			"failures":     sub.Failures,
+			"enabled":      sub.Enabled,
+			"err":          err.Error(),
+		})
+		return false
+	}
+
+	sub.Triggered = true
+	sub.Failures, sub.LastError = 0, ""
+	sub.LastNotifiedAt = &now
+	l.Info("subscription notified", map[string]any{"subscription": sub.ID, "matches": len(matches)})
+
+	return true
+}
+
+// matchCondition returns the days meeting the condition
+func matchCondition(condition models.SubscriptionCondition, days []models.AggregatedWeatherData) []models.AggregatedWeatherData {
+	field, op := conditionFields[condition.Field], conditionOps[condition.Op]
+	if field == nil || op == nil {
+		return nil
+	}
+
+	var matches []models.AggregatedWeatherData
+	for _, day := range days {
+		if op(field(day), condition.Value) {
+			matches = append(matches, day)
+		}
+	}
+
+	return matches
+}
+
+// notify posts the signed notification of the matching days to the webhook of sub
+func (s *WeatherService) notify(ctx context.Context, sub models.Subscription, matches []models.AggregatedWeatherData, now time.Time) error {
+	body, err := json.Marshal(models.SubscriptionNotification{
+		SubscriptionID: sub.ID,
+		Lat:            sub.Lat,
+		Lon:            sub.Lon,
+		Condition:      sub.Condition,
+		Matches:        matches,
+		SentAt:         now,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid webhook: %w", err)
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(WebhookSubscriptionHeader, sub.ID)
+	req.Header.Set(WebhookTimestampHeader, timestamp)
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(sub.Secret, timestamp, body))
+
+	resp, err := s.webhooks.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+
+	return nil
+}
+
+// SignWebhook returns the signature of a notification, "sha256=" and the hex HMAC-SHA256 keyed by the secret of
+// the subscription of the timestamp header, a dot and the body. A receiver compares it to the signature header, and
+// rejects the old timestamps to prevent replays.
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// randomHex returns n random bytes in hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,311 @@
+package weather_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/internal/models"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+)
+
+// webhookReceiver records the notifications posted to it, and answers them with status
+type webhookReceiver struct {
+	mu       sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+	w.WriteHeader(r.status)
+}
+
+func (r *webhookReceiver) setStatus(status int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.status = status
+}
+
+func (r *webhookReceiver) received() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.requests)
+}
+
+// fakeClock is the clock of the subscription checks, moved forward by the tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) advance(d time.Duration) { c.now = c.now.Add(d) }
+
+// newWebhookService returns a service checking the subscriptions only when asked, with opts
+func newWebhookService(t *testing.T, repo *MockRepository, opts weather.WebhookOptions) *weather.WeatherService {
+	t.Helper()
+	opts.Tick = -1
+	store, err := repositories.OpenSQLiteForecastStore(filepath.Join(t.TempDir(), "forecasts.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = store.Close() })
+
+	service := newCachedService(repo,
+		weather.WithForecastStore(store, 10),
+		weather.WithWebhooks(opts))
+	t.Cleanup(func() { _ = service.Shutdown(context.Background()) })
+
+	return service
+}
+
+func TestWeatherService_CheckSubscriptions(t *testing.T) {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	repo := cachedRepository()
+	clock := &fakeClock{now: time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC)}
+	// The receivers of the tests listen on the loopback address
+	service := newWebhookService(t, repo, weather.WebhookOptions{Now: clock.Now, AllowPrivateNetworks: true})
+	ctx := context.Background()
+
+	created, err := service.CreateSubscription(ctx, models.Subscription{
+		Lat:                  52.52,
+		Lon:                  13.41,
+		Condition:            models.SubscriptionCondition{Field: "temp_max", Op: "gte", Value: 25, Days: 1},
+		WebhookURL:           server.URL,
+		CheckIntervalSeconds: 1800,
+	})
+	require.NoError(t, err)
+	require.NotEmpty(t, created.ID)
+	require.NotEmpty(t, created.Secret)
+	assert.True(t, created.Enabled)
+	assert.Equal(t, clock.now, created.NextCheckAt)
+
+	delivered, err := service.CheckSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	require.Equal(t, 1, receiver.received())
+
+	// The notification is signed with the secret of the subscription
+	req, body := receiver.requests[0], receiver.bodies[0]
+	assert.Equal(t, created.ID, req.Header.Get(weather.WebhookSubscriptionHeader))
+	assert.Equal(t, "1753358400", req.Header.Get(weather.WebhookTimestampHeader))
+	assert.Equal(t, weather.SignWebhook(created.Secret, "1753358400", body), req.Header.Get(weather.WebhookSignatureHeader))
+	var notification models.SubscriptionNotification
+	require.NoError(t, json.Unmarshal(body, &notification))
+	assert.Equal(t, created.ID, notification.SubscriptionID)
+	require.Len(t, notification.Matches, 1)
+	assert.Equal(t, 25.5, notification.Matches[0].TempMax)
+
+	// Not due before the check interval, and notified once while the condition is met
+	delivered, err = service.CheckSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	clock.advance(30 * time.Minute)
+	delivered, err = service.CheckSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	assert.Equal(t, 1, receiver.received())
+
+	// Unmet, then met again
+	repo.forecastData.ForecastData[0].TempMax = 20
+	clock.advance(30 * time.Minute)
+	_, err = service.CheckSubscriptions(ctx)
+	require.NoError(t, err)
+	sub, err := service.Subscription(ctx, "", created.ID)
+	require.NoError(t, err)
+	assert.False(t, sub.Triggered)
+	assert.Empty(t, sub.Secret, "the secret is only returned on creation")
+
+	repo.forecastData.ForecastData[0].TempMax = 26
+	receiver.setStatus(http.StatusServiceUnavailable)
+	clock.advance(30 * time.Minute)
+	delivered, err = service.CheckSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	sub, err = service.Subscription(ctx, "", created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sub.Failures)
+	assert.Contains(t, sub.LastError, "503")
+	assert.Equal(t, clock.now.Add(30*time.Second), sub.NextCheckAt, "retried after 30 seconds")
+
+	// The retry succeeds
+	receiver.setStatus(http.StatusNoContent)
+	clock.advance(30 * time.Second)
+	delivered, err = service.CheckSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 1, delivered)
+	sub, err = service.Subscription(ctx, "", created.ID)
+	require.NoError(t, err)
+	assert.Zero(t, sub.Failures)
+	assert.Empty(t, sub.LastError)
+	require.NotNil(t, sub.LastNotifiedAt)
+	assert.Equal(t, clock.now, *sub.LastNotifiedAt)
+
+	subs, err := service.Subscriptions(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, subs, 1)
+	assert.Empty(t, subs[0].Secret)
+
+	require.NoError(t, service.DeleteSubscription(ctx, "", created.ID))
+	assert.ErrorIs(t, service.DeleteSubscription(ctx, "", created.ID), repositories.ErrSubscriptionNotFound)
+	_, err = service.Subscription(ctx, "", created.ID)
+	assert.ErrorIs(t, err, repositories.ErrSubscriptionNotFound)
+}
+
+func TestWeatherService_CheckSubscriptions_Disabled(t *testing.T) {
+	receiver := &webhookReceiver{status: http.StatusInternalServerError}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	clock := &fakeClock{now: time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC)}
+	service := newWebhookService(t, cachedRepository(), weather.WebhookOptions{
+		MaxFailures:          3,
+		Now:                  clock.Now,
+		AllowPrivateNetworks: true,
+	})
+	ctx := context.Background()
+
+	created, err := service.CreateSubscription(ctx, models.Subscription{
+		Lat:                  52.52,
+		Lon:                  13.41,
+		Condition:            models.SubscriptionCondition{Field: "temp_min", Op: "lt", Value: 20, Days: 1},
+		WebhookURL:           server.URL,
+		CheckIntervalSeconds: 3600,
+	})
+	require.NoError(t, err)
+
+	// Retried after 30 seconds, then 1 minute, and disabled by the third failure
+	for _, delay := range []time.Duration{0, 30 * time.Second, time.Minute} {
+		clock.advance(delay)
+		_, err := service.CheckSubscriptions(ctx)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, 3, receiver.received())
+
+	sub, err := service.Subscription(ctx, "", created.ID)
+	require.NoError(t, err)
+	assert.False(t, sub.Enabled)
+	assert.Equal(t, 3, sub.Failures)
+
+	clock.advance(24 * time.Hour)
+	_, err = service.CheckSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, 3, receiver.received(), "a disabled subscription is not checked")
+}
+
+func TestWeatherService_CheckSubscriptions_PrivateAddress(t *testing.T) {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+	port := server.Listener.Addr().(*net.TCPAddr).Port
+
+	clock := &fakeClock{now: time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC)}
+	service := newWebhookService(t, cachedRepository(), weather.WebhookOptions{Now: clock.Now})
+	ctx := context.Background()
+
+	// localhost is refused once resolved, the literal addresses are refused before connecting
+	for _, webhook := range []string{
+		server.URL,
+		fmt.Sprintf("http://localhost:%d/hooks", port),
+		fmt.Sprintf("http://[::ffff:127.0.0.1]:%d/hooks", port),
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/hooks",
+		"http://100.64.0.1/hooks",
+	} {
+		created, err := service.CreateSubscription(ctx, models.Subscription{
+			Lat:                  52.52,
+			Lon:                  13.41,
+			Condition:            models.SubscriptionCondition{Field: "temp_max", Op: "gte", Value: 25, Days: 1},
+			WebhookURL:           webhook,
+			CheckIntervalSeconds: 1800,
+		})
+		require.NoError(t, err)
+
+		delivered, err := service.CheckSubscriptions(ctx)
+		require.NoError(t, err)
+		assert.Zero(t, delivered, webhook)
+
+		sub, err := service.Subscription(ctx, "", created.ID)
+		require.NoError(t, err)
+		assert.Equal(t, 1, sub.Failures, webhook)
+		assert.Contains(t, sub.LastError, "is not public", webhook)
+		require.NoError(t, service.DeleteSubscription(ctx, "", created.ID))
+	}
+	assert.Zero(t, receiver.received())
+}
+
+func TestWeatherService_CheckSubscriptions_Redirect(t *testing.T) {
+	receiver := &webhookReceiver{status: http.StatusOK}
+	target := httptest.NewServer(receiver)
+	defer target.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	clock := &fakeClock{now: time.Date(2025, 7, 24, 12, 0, 0, 0, time.UTC)}
+	service := newWebhookService(t, cachedRepository(), weather.WebhookOptions{Now: clock.Now, AllowPrivateNetworks: true})
+	ctx := context.Background()
+
+	created, err := service.CreateSubscription(ctx, models.Subscription{
+		Lat:                  52.52,
+		Lon:                  13.41,
+		Condition:            models.SubscriptionCondition{Field: "temp_max", Op: "gte", Value: 25, Days: 1},
+		WebhookURL:           redirect.URL,
+		CheckIntervalSeconds: 1800,
+	})
+	require.NoError(t, err)
+
+	delivered, err := service.CheckSubscriptions(ctx)
+	require.NoError(t, err)
+	assert.Zero(t, delivered)
+	assert.Zero(t, receiver.received(), "the redirect is not followed")
+
+	sub, err := service.Subscription(ctx, "", created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 1, sub.Failures)
+	assert.Contains(t, sub.LastError, "redirects are not followed")
+}
+
+func TestParseCondition(t *testing.T) {
+	condition, err := weather.ParseCondition(models.SubscriptionCondition{Field: "temp_max", Op: "gte", Value: 35}, 16)
+	require.NoError(t, err)
+	assert.Equal(t, 3, condition.Days)
+
+	for _, c := range []models.SubscriptionCondition{
+		{Field: "humidity", Op: "gte"},
+		{Field: "temp_max", Op: "eq"},
+		{Field: "temp_max", Op: "gte", Days: 17},
+		{Field: "temp_max", Op: "gte", Days: -1},
+	} {
+		_, err := weather.ParseCondition(c, 16)
+		assert.Error(t, err, c)
+	}
+}
+
+func TestWeatherService_Subscriptions_NoStore(t *testing.T) {
+	service := newCachedService(cachedRepository())
+
+	_, err := service.CreateSubscription(context.Background(), models.Subscription{})
+	assert.ErrorIs(t, err, weather.ErrNoForecastStore)
+	_, err = service.CheckSubscriptions(context.Background())
+	assert.ErrorIs(t, err, weather.ErrNoForecastStore)
+}
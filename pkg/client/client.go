@@ -0,0 +1,240 @@
+// Package client calls the weather API from other Go services. It only depends on the standard library and the
+// API models, not on the server.
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"weather-api/internal/models"
+)
+
+const (
+	defaultTimeout   = 10 * time.Second
+	defaultRetryWait = 200 * time.Millisecond
+	// maxErrorBody bounds the error responses read
+	maxErrorBody = 64 << 10
+
+	headerAPIKey = "X-API-Key"
+)
+
+// The models of the responses, aliased so that the services outside this module can name them
+type (
+	Forecast           = models.Forecast
+	AggregatedForecast = models.AggregatedForecast
+	ProviderStatus     = models.ProviderStatus
+)
+
+// Error is a response of the API with an error status, decoded from its RFC 7807 problem details
+type Error struct {
+	StatusCode int
+	Type       string `json:"type"`
+	Title      string `json:"title"`
+	Detail     string `json:"detail"`
+	RequestID  string `json:"request_id"`
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("weather API: %d %s", e.StatusCode, e.Title)
+	if e.Detail != "" {
+		msg += ": " + e.Detail
+	}
+	if e.RequestID != "" {
+		msg += " (request " + e.RequestID + ")"
+	}
+
+	return msg
+}
+
+// Client calls the /v1 routes of the weather API, it is safe for concurrent use
+type Client struct {
+	baseURL   string
+	http      *http.Client
+	apiKey    string
+	retries   int
+	retryWait time.Duration
+}
+
+// Option configures the optional behaviors of the client
+type Option func(*Client)
+
+// WithTimeout bounds every attempt of a call, 10 seconds by default, zero keeps the default
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		if timeout > 0 {
+			c.http.Timeout = timeout
+		}
+	}
+}
+
+// WithHTTPClient sends the requests with hc, its timeout bounds every attempt
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// WithAPIKey sends the key in the X-API-Key header of every request
+func WithAPIKey(key string) Option {
+	return func(c *Client) {
+		c.apiKey = key
+	}
+}
+
+// WithRetries retries the calls failing on the network, or answered 429, 502, 503 or 504, up to retries times.
+// The attempts are wait apart, doubling each time, unless the response asks for longer in Retry-After.
+func WithRetries(retries int, wait time.Duration) Option {
+	return func(c *Client) {
+		c.retries = max(retries, 0)
+		if wait > 0 {
+			c.retryWait = wait
+		}
+	}
+}
+
+// NewClient returns a client of the API served at baseURL, e.g. http://localhost:8080
+func NewClient(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:   strings.TrimSuffix(baseURL, "/"),
+		http:      &http.Client{Timeout: defaultTimeout},
+		retryWait: defaultRetryWait,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	return c
+}
+
+// GetForecast returns the forecast of every provider for the location, keyed by provider name. The providers
+// that failed are returned with their Error and ErrorCode set.
+func (c *Client) GetForecast(ctx context.Context, lat, lon float64, days int) (map[string]Forecast, error) {
+	var forecasts map[string]Forecast
+	err := c.get(ctx, "/v1/weather", locationQuery(lat, lon, days), &forecasts)
+
+	return forecasts, err
+}
+
+// GetAggregate returns the forecasts of the providers merged with the strategy, an empty strategy selects the mean
+func (c *Client) GetAggregate(ctx context.Context, lat, lon float64, days int, strategy string) (AggregatedForecast, error) {
+	query := locationQuery(lat, lon, days)
+	if strategy != "" {
+		query.Set("strategy", strategy)
+	}
+
+	var aggregated AggregatedForecast
+	err := c.get(ctx, "/v1/weather/aggregate", query, &aggregated)
+
+	return aggregated, err
+}
+
+// GetProviders returns the status of the forecast providers
+func (c *Client) GetProviders(ctx context.Context) ([]ProviderStatus, error) {
+	var statuses []ProviderStatus
+	err := c.get(ctx, "/v1/providers", nil, &statuses)
+
+	return statuses, err
+}
+
+// locationQuery builds the query of a forecast, zero days select the default window of the API
+func locationQuery(lat, lon float64, days int) url.Values {
+	query := url.Values{}
+	query.Set("lat", strconv.FormatFloat(lat, 'f', -1, 64))
+	query.Set("lon", strconv.FormatFloat(lon, 'f', -1, 64))
+	if days > 0 {
+		query.Set("days", strconv.Itoa(days))
+	}
+
+	return query
+}
+
+// get calls a route and decodes its JSON response into out, retrying the transient failures
+func (c *Client) get(ctx context.Context, path string, query url.Values, out any) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	wait := c.retryWait
+	for attempt := 0; ; attempt++ {
+		retryAfter, err := c.do(ctx, target, out)
+		if err == nil || attempt >= c.retries || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+
+		delay := max(wait, retryAfter)
+		wait *= 2
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+}
+
+// do sends one request, a failed one returns the delay asked for by its Retry-After header
+func (c *Client) do(ctx context.Context, target string, out any) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set(headerAPIKey, c.apiKey)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		apiErr := &Error{StatusCode: resp.StatusCode, Title: http.StatusText(resp.StatusCode)}
+		// A response without problem details keeps the status text
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		_ = json.Unmarshal(body, apiErr)
+
+		return retryAfter(resp.Header.Get("Retry-After")), apiErr
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return 0, fmt.Errorf("decode %s response: %w", req.URL.Path, err)
+	}
+
+	return 0, nil
+}
+
+// retryable reports whether a failed call may succeed when retried, an attempt timing out is retried
+func retryable(err error) bool {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		switch apiErr.StatusCode {
+		case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+
+	// The network errors
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
+
+// retryAfter parses the delay of a Retry-After header in seconds, zero when it is missing or a date
+func retryAfter(header string) time.Duration {
+	seconds, err := strconv.Atoi(header)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+
+	return time.Duration(seconds) * time.Second
+}
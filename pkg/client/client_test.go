@@ -0,0 +1,145 @@
+package client_test
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	v1 "weather-api/internal/controllers/http/v1"
+	"weather-api/internal/repositories"
+	"weather-api/internal/services/weather"
+	"weather-api/pkg/client"
+	"weather-api/pkg/httpserver"
+	"weather-api/pkg/logger"
+)
+
+// newTestServer serves the router of the API with the mock provider, which synthesizes the forecasts
+func newTestServer(t *testing.T, opts httpserver.Options) *httptest.Server {
+	t.Helper()
+
+	l := logger.NopLogger{}
+	service := weather.NewWeatherService([]repositories.WeatherRepository{repositories.NewMockWeatherRepository(0, 0, l)}, l)
+	app := httpserver.InitFiberServer("test-client", opts, l)
+	v1.NewRouter(app.Group("/v1"), service, nil, l)
+
+	server := httptest.NewServer(adaptor.FiberApp(app))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestClient_GetForecast(t *testing.T) {
+	server := newTestServer(t, httpserver.Options{})
+	c := client.NewClient(server.URL + "/")
+
+	forecasts, err := c.GetForecast(context.Background(), 52.52, 13.41, 3)
+	require.NoError(t, err)
+	require.Contains(t, forecasts, "mock")
+	forecast := forecasts["mock"]
+	assert.Equal(t, 52.52, forecast.Lat)
+	assert.Equal(t, 3, forecast.ForecastWindow)
+	assert.Len(t, forecast.ForecastData, 3)
+	assert.False(t, forecast.ForecastData[0].Date.IsZero())
+}
+
+func TestClient_GetAggregate(t *testing.T) {
+	server := newTestServer(t, httpserver.Options{})
+	c := client.NewClient(server.URL)
+
+	aggregated, err := c.GetAggregate(context.Background(), 52.52, 13.41, 2, weather.StrategyMedian)
+	require.NoError(t, err)
+	assert.Equal(t, weather.StrategyMedian, aggregated.Strategy)
+	assert.Equal(t, []string{"mock"}, aggregated.Providers)
+	assert.Len(t, aggregated.ForecastData, 2)
+}
+
+func TestClient_GetProviders(t *testing.T) {
+	server := newTestServer(t, httpserver.Options{})
+	c := client.NewClient(server.URL)
+
+	statuses, err := c.GetProviders(context.Background())
+	require.NoError(t, err)
+	require.Len(t, statuses, 1)
+	assert.Equal(t, "mock", statuses[0].Name)
+	assert.False(t, statuses[0].RequiresKey)
+}
+
+func TestClient_Error(t *testing.T) {
+	server := newTestServer(t, httpserver.Options{})
+	c := client.NewClient(server.URL, client.WithRetries(3, time.Millisecond))
+
+	_, err := c.GetForecast(context.Background(), 91, 13.41, 3)
+	var apiErr *client.Error
+	require.True(t, errors.As(err, &apiErr), err)
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Equal(t, "/problems/out-of-range", apiErr.Type)
+	assert.Contains(t, apiErr.Detail, "latitude")
+	assert.NotEmpty(t, apiErr.RequestID)
+}
+
+func TestClient_APIKey(t *testing.T) {
+	sum := sha256.Sum256([]byte("secret"))
+	server := newTestServer(t, httpserver.Options{
+		Auth: httpserver.APIKeyAuthConfig{Keys: map[string]string{hex.EncodeToString(sum[:]): "client"}},
+	})
+
+	_, err := client.NewClient(server.URL).GetProviders(context.Background())
+	var apiErr *client.Error
+	require.True(t, errors.As(err, &apiErr), err)
+	assert.Equal(t, http.StatusUnauthorized, apiErr.StatusCode)
+
+	_, err = client.NewClient(server.URL, client.WithAPIKey("secret")).GetProviders(context.Background())
+	assert.NoError(t, err)
+}
+
+func TestClient_Retries(t *testing.T) {
+	api := newTestServer(t, httpserver.Options{})
+
+	// The proxy fails the first calls before passing them to the API
+	var calls atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		http.Redirect(w, r, api.URL+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	}))
+	t.Cleanup(proxy.Close)
+
+	_, err := client.NewClient(proxy.URL, client.WithRetries(1, time.Millisecond)).GetProviders(context.Background())
+	var apiErr *client.Error
+	require.True(t, errors.As(err, &apiErr), err)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.Equal(t, int32(2), calls.Load())
+
+	calls.Store(0)
+	statuses, err := client.NewClient(proxy.URL, client.WithRetries(2, time.Millisecond)).GetProviders(context.Background())
+	require.NoError(t, err)
+	assert.Len(t, statuses, 1)
+	assert.Equal(t, int32(3), calls.Load())
+}
+
+func TestClient_Timeout(t *testing.T) {
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(time.Second):
+		}
+	}))
+	t.Cleanup(slow.Close)
+
+	start := time.Now()
+	_, err := client.NewClient(slow.URL, client.WithTimeout(20*time.Millisecond)).GetProviders(context.Background())
+	require.Error(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+}
@@ -0,0 +1,119 @@
+// Package geo checks coordinates against the service area of the API, a set of bounding boxes
+package geo
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// The ways coordinates fall outside the service area, see Area.Check
+var (
+	ErrNullIsland  = errors.New("lat=0 and lon=0 is Null Island, a location a client most likely failed to set")
+	ErrOutsideArea = errors.New("location outside the service area")
+	ErrDeniedArea  = errors.New("location in an excluded area")
+)
+
+// NormalizeLongitude brings a longitude back into [-180, 180], e.g. 200 is -160, the longitudes already in it are
+// returned as is
+func NormalizeLongitude(lon float64) float64 {
+	if lon >= -180 && lon <= 180 {
+		return lon
+	}
+
+	lon = math.Mod(lon+180, 360)
+	if lon < 0 {
+		lon += 360
+	}
+
+	return lon - 180
+}
+
+// Box is a bounding box of coordinates in degrees, its edges included. A box whose West is east of its East
+// crosses the antimeridian, e.g. West 170 and East -170 spans 20 degrees around it.
+type Box struct {
+	// Name describes the box in the errors, optional
+	Name  string
+	South float64
+	West  float64
+	North float64
+	East  float64
+}
+
+// Validate checks the latitudes and the longitudes of the edges of the box
+func (b Box) Validate() error {
+	for _, lat := range []float64{b.South, b.North} {
+		if lat < -90 || lat > 90 {
+			return fmt.Errorf("latitudes must be between -90 and 90, got: %g", lat)
+		}
+	}
+	for _, lon := range []float64{b.West, b.East} {
+		if lon < -180 || lon > 180 {
+			return fmt.Errorf("longitudes must be between -180 and 180, got: %g", lon)
+		}
+	}
+	if b.South > b.North {
+		return fmt.Errorf("south must not be north of north, got: %g > %g", b.South, b.North)
+	}
+
+	return nil
+}
+
+// Contains reports whether the coordinates are in the box, -180 and 180 are the same meridian
+func (b Box) Contains(lat, lon float64) bool {
+	if lat < b.South || lat > b.North {
+		return false
+	}
+	if b.West > b.East {
+		return lon >= b.West || lon <= b.East
+	}
+	if lon >= b.West && lon <= b.East {
+		return true
+	}
+
+	// The box reaches the antimeridian from the other side
+	return (lon == -180 && b.East == 180) || (lon == 180 && b.West == -180)
+}
+
+// String names the box, its edges when it has no name
+func (b Box) String() string {
+	if b.Name != "" {
+		return b.Name
+	}
+
+	return fmt.Sprintf("[%g,%g,%g,%g]", b.South, b.West, b.North, b.East)
+}
+
+// Area is the service area of the API: the coordinates in one of the Allow boxes, anywhere when there is none,
+// but in none of the Deny boxes. The zero Area serves every location.
+type Area struct {
+	Allow []Box
+	Deny  []Box
+	// RejectNullIsland rejects lat=0 and lon=0 exactly, the location sent by the clients missing theirs
+	RejectNullIsland bool
+}
+
+// Check returns nil when the coordinates are in the area, an error wrapping ErrNullIsland, ErrOutsideArea or
+// ErrDeniedArea describing why not otherwise
+func (a Area) Check(lat, lon float64) error {
+	if a.RejectNullIsland && lat == 0 && lon == 0 {
+		return ErrNullIsland
+	}
+
+	for _, box := range a.Deny {
+		if box.Contains(lat, lon) {
+			return fmt.Errorf("%w: lat=%g and lon=%g are in %s", ErrDeniedArea, lat, lon, box)
+		}
+	}
+
+	if len(a.Allow) == 0 {
+		return nil
+	}
+	for _, box := range a.Allow {
+		if box.Contains(lat, lon) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: lat=%g and lon=%g are in none of its %d areas", ErrOutsideArea, lat, lon, len(a.Allow))
+}
@@ -0,0 +1,120 @@
+package geo
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNormalizeLongitude(t *testing.T) {
+	tests := []struct {
+		lon, want float64
+	}{
+		{13.41, 13.41},
+		{180, 180},
+		{-180, -180},
+		{200, -160},
+		{-200, 160},
+		{360, 0},
+		{540, -180},
+		{-540, -180},
+		{719.5, -0.5},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeLongitude(tt.lon); got != tt.want {
+			t.Errorf("NormalizeLongitude(%g) = %g, want %g", tt.lon, got, tt.want)
+		}
+	}
+}
+
+func TestBoxContains(t *testing.T) {
+	europe := Box{South: 35, West: -10, North: 71, East: 40}
+	// Fiji and its neighbors straddle the antimeridian
+	pacific := Box{South: -25, West: 170, North: -10, East: -170}
+	eastEdge := Box{South: -10, West: 170, North: 10, East: 180}
+
+	tests := []struct {
+		name     string
+		box      Box
+		lat, lon float64
+		want     bool
+	}{
+		{"inside", europe, 52.52, 13.41, true},
+		{"edge", europe, 35, -10, true},
+		{"north of", europe, 72, 13.41, false},
+		{"west of", europe, 52.52, -11, false},
+		{"antimeridian west side", pacific, -18, 178.4, true},
+		{"antimeridian east side", pacific, -18, -178.4, true},
+		{"antimeridian itself", pacific, -18, 180, true},
+		{"antimeridian negative", pacific, -18, -180, true},
+		{"antimeridian edge", pacific, -18, -170, true},
+		{"antimeridian outside", pacific, -18, 0, false},
+		{"antimeridian between the edges", pacific, -18, 160, false},
+		{"antimeridian south of", pacific, -30, 178.4, false},
+		{"reaching the antimeridian", eastEdge, 0, -180, true},
+		{"reaching the antimeridian, other side", eastEdge, 0, -179, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.box.Contains(tt.lat, tt.lon); got != tt.want {
+			t.Errorf("%s: %v.Contains(%g, %g) = %v, want %v", tt.name, tt.box, tt.lat, tt.lon, got, tt.want)
+		}
+	}
+}
+
+func TestBoxValidate(t *testing.T) {
+	valid := []Box{
+		{South: 35, West: -10, North: 71, East: 40},
+		{South: -25, West: 170, North: -10, East: -170},
+		{South: -90, West: -180, North: 90, East: 180},
+	}
+	for _, box := range valid {
+		if err := box.Validate(); err != nil {
+			t.Errorf("%v.Validate() = %v, want nil", box, err)
+		}
+	}
+
+	invalid := []Box{
+		{South: 71, West: -10, North: 35, East: 40},
+		{South: -91, West: -10, North: 35, East: 40},
+		{South: 35, West: -181, North: 71, East: 40},
+	}
+	for _, box := range invalid {
+		if err := box.Validate(); err == nil {
+			t.Errorf("%v.Validate() = nil, want an error", box)
+		}
+	}
+}
+
+func TestAreaCheck(t *testing.T) {
+	area := Area{
+		Allow: []Box{
+			{Name: "europe", South: 35, West: -10, North: 71, East: 40},
+			{Name: "pacific", South: -25, West: 170, North: -10, East: -170},
+		},
+		Deny:             []Box{{Name: "alps", South: 45, West: 6, North: 48, East: 14}},
+		RejectNullIsland: true,
+	}
+
+	tests := []struct {
+		name     string
+		area     Area
+		lat, lon float64
+		want     error
+	}{
+		{"allowed", area, 52.52, 13.41, nil},
+		{"allowed across the antimeridian", area, -18, -178.4, nil},
+		{"outside", area, 40.71, -74.01, ErrOutsideArea},
+		{"denied", area, 46.5, 10, ErrDeniedArea},
+		{"null island", area, 0, 0, ErrNullIsland},
+		{"null island accepted", Area{}, 0, 0, nil},
+		{"anywhere", Area{RejectNullIsland: true}, 0, 0.5, nil},
+	}
+
+	for _, tt := range tests {
+		err := tt.area.Check(tt.lat, tt.lon)
+		if tt.want == nil && err != nil || !errors.Is(err, tt.want) {
+			t.Errorf("%s: Check(%g, %g) = %v, want %v", tt.name, tt.lat, tt.lon, err, tt.want)
+		}
+	}
+}
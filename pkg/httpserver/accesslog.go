@@ -0,0 +1,139 @@
+package httpserver
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+const defaultAccessSampleEvery = 100
+
+// RedactedQueryParams are the query parameters whose value is never logged
+var RedactedQueryParams = []string{"api_key", "appid", "key", "token"}
+
+// AccessLogConfig configures AccessLog
+type AccessLogConfig struct {
+	Enabled bool
+	// SampleAfter is the number of requests logged every second before sampling starts, zero logs every request
+	SampleAfter int
+	// SampleEvery is the share of the requests logged once sampling started, one in SampleEvery, zero selects 100
+	SampleEvery int
+	// TrustedProxy takes the client address from the X-Forwarded-For header, see ClientIP
+	TrustedProxy bool
+}
+
+// AccessLog logs every request once it is answered, with the status, the response size and the duration.
+// Under load, beyond SampleAfter requests in a second only one in SampleEvery is logged, failed requests are
+// always logged. The duration of a streamed response ends when the stream starts.
+func AccessLog(cfg AccessLogConfig, l logger.Logger) fiber.Handler {
+	if !cfg.Enabled {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	s := newSampler(cfg.SampleAfter, cfg.SampleEvery, time.Now)
+
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			// Answer the error now to log the status it is answered with
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		if status < fiber.StatusInternalServerError && !s.sample() {
+			return nil
+		}
+
+		fields := map[string]any{
+			"request_id":  requestid.FromContext(c.UserContext()),
+			"method":      c.Method(),
+			"path":        c.Path(),
+			"query":       sanitizedQuery(c),
+			"status":      status,
+			"ip":          ClientIP(c, cfg.TrustedProxy),
+			"duration_ms": time.Since(start).Milliseconds(),
+		}
+		if c.Response().IsBodyStream() {
+			fields["streamed"] = true
+		} else {
+			fields["bytes"] = len(c.Response().Body())
+		}
+		if name := APIKeyName(c); name != "" {
+			fields["api_key_name"] = name
+		}
+
+		if status >= fiber.StatusInternalServerError {
+			l.Warning("http request", fields)
+		} else {
+			l.Info("http request", fields)
+		}
+
+		return nil
+	}
+}
+
+// sanitizedQuery returns the query of the request with the values of RedactedQueryParams redacted
+func sanitizedQuery(c *fiber.Ctx) string {
+	var b strings.Builder
+	c.Request().URI().QueryArgs().VisitAll(func(key, value []byte) {
+		if b.Len() > 0 {
+			b.WriteByte('&')
+		}
+		b.Write(key)
+		b.WriteByte('=')
+		if slices.Contains(RedactedQueryParams, strings.ToLower(string(key))) {
+			b.WriteString("REDACTED")
+		} else {
+			b.Write(value)
+		}
+	})
+
+	return b.String()
+}
+
+// sampler lets the first requests of every second through, then one in every
+type sampler struct {
+	after int
+	every int
+	now   func() time.Time
+
+	mu     sync.Mutex
+	second int64
+	count  int
+}
+
+func newSampler(after, every int, now func() time.Time) *sampler {
+	if every <= 0 {
+		every = defaultAccessSampleEvery
+	}
+
+	return &sampler{after: after, every: every, now: now}
+}
+
+// sample reports whether a request is logged
+func (s *sampler) sample() bool {
+	if s.after <= 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if second := s.now().Unix(); second != s.second {
+		s.second = second
+		s.count = 0
+	}
+	s.count++
+
+	return s.count <= s.after || (s.count-s.after)%s.every == 0
+}
@@ -0,0 +1,146 @@
+package httpserver
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+func newAccessLogApp(cfg AccessLogConfig, logs *bytes.Buffer) *fiber.App {
+	app := fiber.New()
+	app.Use(RequestID())
+	app.Use(AccessLog(cfg, logger.NewZapLogger("test-app", logs)))
+	app.Get("/weather", func(c *fiber.Ctx) error {
+		return c.SendString("sunny")
+	})
+	app.Get("/teapot", func(c *fiber.Ctx) error {
+		return fiber.NewError(fiber.StatusTeapot, "short and stout")
+	})
+	app.Get("/broken", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusInternalServerError)
+	})
+
+	return app
+}
+
+// accessLogs returns the access log lines written to logs
+func accessLogs(t *testing.T, logs *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var lines []map[string]any
+	scanner := bufio.NewScanner(logs)
+	for scanner.Scan() {
+		var line map[string]any
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &line))
+		if line["msg"] == "http request" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+func TestAccessLog(t *testing.T) {
+	var logs bytes.Buffer
+	app := newAccessLogApp(AccessLogConfig{Enabled: true}, &logs)
+
+	req := httptest.NewRequest(http.MethodGet, "/weather?lat=52.52&appid=s3cret&API_KEY=k3y&lon=13.41", nil)
+	req.Header.Set(requestid.Header, "3f2b8c0e9a4d4f5e")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	assert.NotContains(t, logs.String(), "s3cret")
+	assert.NotContains(t, logs.String(), "k3y")
+
+	lines := accessLogs(t, &logs)
+	require.Len(t, lines, 1)
+	line := lines[0]
+	assert.Equal(t, "info", line["level"])
+	assert.Equal(t, "3f2b8c0e9a4d4f5e", line["request_id"])
+	assert.Equal(t, "GET", line["method"])
+	assert.Equal(t, "/weather", line["path"])
+	assert.Equal(t, "lat=52.52&appid=REDACTED&API_KEY=REDACTED&lon=13.41", line["query"])
+	assert.Equal(t, float64(fiber.StatusOK), line["status"])
+	assert.Equal(t, float64(len("sunny")), line["bytes"])
+	assert.Equal(t, "0.0.0.0", line["ip"])
+	assert.Contains(t, line, "duration_ms")
+}
+
+func TestAccessLog_Status(t *testing.T) {
+	var logs bytes.Buffer
+	app := newAccessLogApp(AccessLogConfig{Enabled: true}, &logs)
+
+	for _, path := range []string{"/teapot", "/broken"} {
+		_, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+	}
+
+	lines := accessLogs(t, &logs)
+	require.Len(t, lines, 2)
+	// The status of an error returned by the handler is the one it is answered with
+	assert.Equal(t, float64(fiber.StatusTeapot), lines[0]["status"])
+	assert.Equal(t, "info", lines[0]["level"])
+	assert.Equal(t, float64(fiber.StatusInternalServerError), lines[1]["status"])
+	assert.Equal(t, "warn", lines[1]["level"])
+}
+
+func TestAccessLog_Disabled(t *testing.T) {
+	var logs bytes.Buffer
+	app := newAccessLogApp(AccessLogConfig{}, &logs)
+
+	_, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather", nil))
+	require.NoError(t, err)
+	assert.Empty(t, accessLogs(t, &logs))
+}
+
+func TestAccessLog_SampledFailuresLogged(t *testing.T) {
+	var logs bytes.Buffer
+	app := newAccessLogApp(AccessLogConfig{Enabled: true, SampleAfter: 1, SampleEvery: 1000}, &logs)
+
+	for _, path := range []string{"/weather", "/weather", "/broken", "/weather", "/broken"} {
+		_, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+	}
+
+	// Unless the requests spanned two seconds, the first one and the failures are logged
+	lines := accessLogs(t, &logs)
+	var failures int
+	for _, line := range lines {
+		if line["status"] == float64(fiber.StatusInternalServerError) {
+			failures++
+		}
+	}
+	assert.Equal(t, 2, failures)
+	assert.LessOrEqual(t, len(lines), 4)
+}
+
+func TestSampler(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)}
+	s := newSampler(2, 3, clock.Now)
+
+	var got []bool
+	for range 8 {
+		got = append(got, s.sample())
+	}
+	assert.Equal(t, []bool{true, true, false, false, true, false, false, true}, got)
+
+	// Every second starts over
+	clock.now = clock.now.Add(time.Second)
+	assert.True(t, s.sample())
+	assert.True(t, s.sample())
+	assert.False(t, s.sample())
+
+	assert.True(t, newSampler(0, 0, clock.Now).sample())
+}
@@ -0,0 +1,107 @@
+package httpserver
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+const (
+	HeaderAPIKey = "X-API-Key"
+	// QueryAPIKey is the query parameter alternative to the X-API-Key header
+	QueryAPIKey = "api_key"
+
+	apiKeyNameLocal = "api_key_name"
+)
+
+// APIKeyAuthConfig configures APIKeyAuth
+type APIKeyAuthConfig struct {
+	// Keys maps the lowercase hex SHA-256 hash of every accepted key to its name, nil disables the authentication
+	Keys map[string]string
+	// OpenPaths are the path prefixes served without a key
+	OpenPaths []string
+}
+
+// APIKeyAuth requires an API key in the X-API-Key header or the api_key query parameter outside the open paths,
+// a missing key is answered 401 and an unknown one 403. The key is only compared by hash and removed from the
+// query of the request, so that it never reaches the logs, the accepted key is known by its name, see APIKeyName.
+func APIKeyAuth(cfg APIKeyAuthConfig, l logger.Logger) fiber.Handler {
+	if cfg.Keys == nil {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		if openPath(c.Path(), cfg.OpenPaths) {
+			return c.Next()
+		}
+
+		key := c.Get(HeaderAPIKey)
+		if key == "" {
+			key = c.Query(QueryAPIKey)
+		}
+		provided := key != ""
+		sum := sha256.Sum256([]byte(key))
+		name, ok := cfg.Keys[hex.EncodeToString(sum[:])]
+		// The key may point into the query, it isn't used past this point
+		removeQueryParam(c, QueryAPIKey)
+
+		fields := map[string]any{
+			"request_id": requestid.FromContext(c.UserContext()),
+			"path":       c.Path(),
+			"ip":         c.IP(),
+		}
+		if !provided {
+			l.Warning("request without API key", fields)
+			return sendProblem(c, fiber.StatusUnauthorized, "/problems/unauthorized", "Unauthorized",
+				"An API key is required in the X-API-Key header or the api_key query parameter")
+		}
+		if !ok {
+			l.Warning("request with an unknown API key", fields)
+			return sendProblem(c, fiber.StatusForbidden, "/problems/forbidden", "Forbidden", "Unknown API key")
+		}
+
+		fields["api_key_name"] = name
+		l.Debug("authenticated request", fields)
+		c.Locals(apiKeyNameLocal, name)
+
+		return c.Next()
+	}
+}
+
+// APIKeyName returns the name of the API key of the request, empty when it wasn't authenticated
+func APIKeyName(c *fiber.Ctx) string {
+	name, _ := c.Locals(apiKeyNameLocal).(string)
+	return name
+}
+
+// openPath reports whether the path is one of the prefixes or below one of them
+func openPath(path string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		prefix = strings.TrimSuffix(prefix, "/")
+		if path == prefix || strings.HasPrefix(path, prefix+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// removeQueryParam deletes a query parameter from the request, including its original URL
+func removeQueryParam(c *fiber.Ctx, name string) {
+	uri := c.Request().URI()
+	args := uri.QueryArgs()
+	if !args.Has(name) {
+		return
+	}
+
+	args.Del(name)
+	uri.SetQueryStringBytes(args.QueryString())
+	c.Request().Header.SetRequestURIBytes(uri.RequestURI())
+}
@@ -0,0 +1,129 @@
+package httpserver
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/pkg/logger"
+)
+
+const (
+	mobileKey = "k3y-of-the-m0bile-app"
+	webKey    = "k3y-of-the-web-app"
+)
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// newAuthApp serves the name of the key and the URL seen by the handlers
+func newAuthApp(l logger.Logger, rateLimit RateLimitConfig) *fiber.App {
+	app := fiber.New()
+	app.Use(RequestID())
+	app.Use(APIKeyAuth(APIKeyAuthConfig{
+		Keys:      map[string]string{hashKey(mobileKey): "mobile", hashKey(webKey): "web"},
+		OpenPaths: []string{"/manage", "/swagger/"},
+	}, l))
+	app.Use(RateLimit(rateLimit))
+
+	handler := func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"name": APIKeyName(c), "url": c.OriginalURL()})
+	}
+	app.Get("/weather", handler)
+	app.Get("/weather/aggregate", handler)
+	app.Get("/manage/health", handler)
+	app.Get("/swagger/index.html", handler)
+
+	return app
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	var logs bytes.Buffer
+	app := newAuthApp(logger.NewZapLogger("test-app", &logs), RateLimitConfig{})
+
+	tests := []struct {
+		name   string
+		target string
+		header string
+		status int
+		want   map[string]string
+	}{
+		{"header", "/weather?lat=1&lon=2", mobileKey, fiber.StatusOK, map[string]string{"name": "mobile", "url": "/weather?lat=1&lon=2"}},
+		{"query", "/weather?lat=1&api_key=" + webKey + "&lon=2", "", fiber.StatusOK, map[string]string{"name": "web", "url": "/weather?lat=1&lon=2"}},
+		{"below a protected path", "/weather/aggregate", mobileKey, fiber.StatusOK, map[string]string{"name": "mobile", "url": "/weather/aggregate"}},
+		{"open path", "/manage/health", "", fiber.StatusOK, map[string]string{"name": "", "url": "/manage/health"}},
+		{"open path with a trailing slash", "/swagger/index.html", "", fiber.StatusOK, map[string]string{"name": "", "url": "/swagger/index.html"}},
+		{"missing", "/weather?lat=1&lon=2", "", fiber.StatusUnauthorized, nil},
+		{"unknown", "/weather?lat=1&lon=2", "not-" + mobileKey, fiber.StatusForbidden, nil},
+		{"unknown in the query", "/weather?api_key=not-" + webKey, "", fiber.StatusForbidden, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.header != "" {
+				req.Header.Set(HeaderAPIKey, tt.header)
+			}
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			require.Equal(t, tt.status, resp.StatusCode)
+
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.NotContains(t, string(body), "k3y")
+			if tt.want == nil {
+				assert.Equal(t, "application/problem+json", resp.Header.Get(fiber.HeaderContentType))
+				return
+			}
+
+			var got map[string]string
+			require.NoError(t, json.Unmarshal(body, &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	assert.Contains(t, logs.String(), `"api_key_name":"mobile"`)
+	assert.Contains(t, logs.String(), "request with an unknown API key")
+	assert.NotContains(t, logs.String(), "k3y", "a key was logged")
+}
+
+func TestAPIKeyAuth_Disabled(t *testing.T) {
+	app := fiber.New()
+	app.Use(APIKeyAuth(APIKeyAuthConfig{OpenPaths: []string{"/manage"}}, logger.NopLogger{}))
+	app.Get("/weather", func(c *fiber.Ctx) error {
+		return c.SendString(APIKeyName(c))
+	})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/weather", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
+
+func TestAPIKeyAuth_RateLimitedByKey(t *testing.T) {
+	app := newAuthApp(logger.NopLogger{}, RateLimitConfig{RequestsPerMinute: 1, Burst: 1})
+
+	get := func(key string) int {
+		req := httptest.NewRequest(http.MethodGet, "/weather", nil)
+		req.Header.Set(HeaderAPIKey, key)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	// Both keys are used from the same address, each has its own bucket
+	assert.Equal(t, fiber.StatusOK, get(mobileKey))
+	assert.Equal(t, fiber.StatusOK, get(webKey))
+	assert.Equal(t, fiber.StatusTooManyRequests, get(mobileKey))
+	assert.Equal(t, fiber.StatusTooManyRequests, get(webKey))
+}
@@ -0,0 +1,25 @@
+package httpserver
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ClientIP returns the address of the caller. Behind a trusted proxy it is the last X-Forwarded-For entry,
+// the one appended by the proxy, the earlier entries are set by the client and can be spoofed.
+func ClientIP(c *fiber.Ctx, trustedProxy bool) string {
+	if !trustedProxy {
+		return c.IP()
+	}
+
+	forwarded := c.Get(fiber.HeaderXForwardedFor)
+	if i := strings.LastIndexByte(forwarded, ','); i >= 0 {
+		forwarded = forwarded[i+1:]
+	}
+	if forwarded = strings.TrimSpace(forwarded); forwarded == "" {
+		return c.IP()
+	}
+
+	return forwarded
+}
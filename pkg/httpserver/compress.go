@@ -0,0 +1,111 @@
+package httpserver
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/valyala/fasthttp"
+)
+
+// DefaultCompressionMinSize is the smallest body compressed unless CompressionConfig.MinSize is set, the
+// compressed smaller ones are often larger
+const DefaultCompressionMinSize = 1024
+
+// CompressionLevel trades the compression ratio for the CPU time
+type CompressionLevel int
+
+const (
+	CompressionOff CompressionLevel = iota
+	CompressionSpeed
+	CompressionDefault
+	CompressionBest
+)
+
+// compressionLevels are the brotli and gzip levels of every CompressionLevel
+var compressionLevels = map[CompressionLevel][2]int{
+	CompressionSpeed:   {fasthttp.CompressBrotliBestSpeed, fasthttp.CompressBestSpeed},
+	CompressionDefault: {fasthttp.CompressBrotliDefaultCompression, fasthttp.CompressDefaultCompression},
+	CompressionBest:    {fasthttp.CompressBrotliBestCompression, fasthttp.CompressBestCompression},
+}
+
+// CompressionConfig configures Compress
+type CompressionConfig struct {
+	// Level of the compression, zero disables it
+	Level CompressionLevel
+	// MinSize is the smallest body compressed in bytes, zero selects DefaultCompressionMinSize
+	MinSize int
+}
+
+// Compress compresses the response bodies with brotli, or gzip, when the Accept-Encoding header allows it.
+// The streamed responses, e.g. the server-sent events, are sent as they are written and never compressed.
+// The ETag of a handler is computed from the uncompressed body, it is weakened for the encoded representations
+// only so it still matches If-None-Match, see RFC 9110.
+func Compress(cfg CompressionConfig) fiber.Handler {
+	levels, ok := compressionLevels[cfg.Level]
+	if !ok {
+		return func(c *fiber.Ctx) error { return c.Next() }
+	}
+	minSize := cfg.MinSize
+	if minSize <= 0 {
+		minSize = DefaultCompressionMinSize
+	}
+
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		resp := c.Response()
+		if resp.IsBodyStream() || len(resp.Header.ContentEncoding()) > 0 || !compressible(resp) {
+			return nil
+		}
+		c.Vary(fiber.HeaderAcceptEncoding)
+
+		// brotli is preferred whatever the order of the header, its ratio is better
+		var encoding string
+		switch {
+		case c.Get(fiber.HeaderAcceptEncoding) == "":
+			return nil
+		case c.AcceptsEncodings("br") != "":
+			encoding = "br"
+		case c.AcceptsEncodings("gzip") != "":
+			encoding = "gzip"
+		default:
+			return nil
+		}
+
+		body := resp.Body()
+		if len(body) < minSize {
+			return nil
+		}
+		if etag := resp.Header.Peek(fiber.HeaderETag); len(etag) > 0 && !bytes.HasPrefix(etag, []byte("W/")) {
+			c.Set(fiber.HeaderETag, "W/"+string(etag))
+		}
+		var compressed []byte
+		if encoding == "br" {
+			compressed = fasthttp.AppendBrotliBytesLevel(nil, body, levels[0])
+		} else {
+			compressed = fasthttp.AppendGzipBytesLevel(nil, body, levels[1])
+		}
+		resp.SetBodyRaw(compressed)
+		c.Set(fiber.HeaderContentEncoding, encoding)
+
+		return nil
+	}
+}
+
+// compressible reports whether resp is text or structured data, the images and archives are compressed already
+func compressible(resp *fasthttp.Response) bool {
+	contentType := string(resp.Header.ContentType())
+	if strings.HasPrefix(contentType, "text/") {
+		return true
+	}
+	for _, t := range []string{"json", "xml", "csv", "msgpack", "javascript", "graphql"} {
+		if strings.Contains(contentType, t) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,117 @@
+package httpserver
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/valyala/fasthttp"
+
+	"weather-api/pkg/logger"
+)
+
+func newCompressApp(body string) *fiber.App {
+	app := InitFiberServer("test-app", Options{Compression: CompressionConfig{Level: CompressionDefault}}, logger.NopLogger{})
+	app.Get("/weather", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderETag, `"abc"`)
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.SendString(body)
+	})
+	app.Get("/stream", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "text/event-stream")
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			_, _ = w.WriteString(body)
+		})
+		return nil
+	})
+
+	return app
+}
+
+func getEncoded(t *testing.T, app *fiber.App, path, acceptEncoding string) (*http.Response, []byte) {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	if acceptEncoding != "" {
+		req.Header.Set(fiber.HeaderAcceptEncoding, acceptEncoding)
+	}
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	return resp, body
+}
+
+func TestCompress(t *testing.T) {
+	body := `{"forecast": [` + strings.Repeat(`{"temperature": 21.5, "humidity": 60},`, 100) + `{}]}`
+	app := newCompressApp(body)
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		encoding       string
+		decode         func(dst, src []byte) ([]byte, error)
+	}{
+		{"brotli preferred", "gzip, deflate, br", "br", fasthttp.AppendUnbrotliBytes},
+		{"gzip", "gzip", "gzip", fasthttp.AppendGunzipBytes},
+		{"brotli refused", "br;q=0, gzip", "gzip", fasthttp.AppendGunzipBytes},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, compressed := getEncoded(t, app, "/weather", tt.acceptEncoding)
+
+			assert.Equal(t, tt.encoding, resp.Header.Get(fiber.HeaderContentEncoding))
+			assert.Contains(t, resp.Header.Get(fiber.HeaderVary), fiber.HeaderAcceptEncoding)
+			assert.Equal(t, `W/"abc"`, resp.Header.Get(fiber.HeaderETag))
+			assert.Less(t, len(compressed), len(body))
+			decoded, err := tt.decode(nil, compressed)
+			require.NoError(t, err)
+			assert.Equal(t, body, string(decoded))
+		})
+	}
+
+	// The uncompressed representation keeps its strong ETag
+	resp, plain := getEncoded(t, app, "/weather", "")
+	assert.Empty(t, resp.Header.Get(fiber.HeaderContentEncoding))
+	assert.Contains(t, resp.Header.Get(fiber.HeaderVary), fiber.HeaderAcceptEncoding)
+	assert.Equal(t, `"abc"`, resp.Header.Get(fiber.HeaderETag))
+	assert.Equal(t, body, string(plain))
+
+	resp, plain = getEncoded(t, app, "/weather", "identity")
+	assert.Empty(t, resp.Header.Get(fiber.HeaderContentEncoding))
+	assert.Equal(t, body, string(plain))
+}
+
+func TestCompress_Skipped(t *testing.T) {
+	app := newCompressApp(strings.Repeat("data: sunny\n\n", 200))
+
+	// The events are flushed as they are written
+	resp, body := getEncoded(t, app, "/stream", "gzip, br")
+	assert.Empty(t, resp.Header.Get(fiber.HeaderContentEncoding))
+	assert.Equal(t, strings.Repeat("data: sunny\n\n", 200), string(body))
+
+	small := newCompressApp(`{"temperature": 21.5}`)
+	resp, body = getEncoded(t, small, "/weather", "gzip, br")
+	assert.Empty(t, resp.Header.Get(fiber.HeaderContentEncoding))
+	assert.Equal(t, `{"temperature": 21.5}`, string(body))
+	// The small bodies are sent as they are, they keep the strong ETag whatever the Accept-Encoding
+	assert.Equal(t, `"abc"`, resp.Header.Get(fiber.HeaderETag))
+}
+
+func TestCompress_Disabled(t *testing.T) {
+	app := InitFiberServer("test-app", Options{}, logger.NopLogger{})
+	app.Get("/weather", func(c *fiber.Ctx) error {
+		return c.SendString(strings.Repeat("sunny ", 1000))
+	})
+
+	resp, _ := getEncoded(t, app, "/weather", "gzip")
+	assert.Empty(t, resp.Header.Get(fiber.HeaderContentEncoding))
+	assert.Empty(t, resp.Header.Get(fiber.HeaderVary))
+}
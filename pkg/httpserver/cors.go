@@ -0,0 +1,34 @@
+package httpserver
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// CORSConfig configures the cross-origin requests of CORS
+type CORSConfig struct {
+	// AllowOrigins are the origins allowed to call the API from a browser, e.g. https://app.example.com,
+	// https://*.example.com for its subdomains or * for any, none disables CORS
+	AllowOrigins []string
+	// AllowMethods are the methods of the preflight responses, none selects the methods of the API
+	AllowMethods []string
+	// AllowHeaders are the request headers of the preflight responses, none allows the requested ones
+	AllowHeaders []string
+	// MaxAge is how long the browsers cache a preflight response in seconds, zero leaves it to them
+	MaxAge int
+	// AllowCredentials lets the browsers send the cookies and authorization, it needs explicit origins
+	AllowCredentials bool
+}
+
+// CORS answers the preflight requests and sets the CORS headers of the responses to the allowed origins
+func CORS(cfg CORSConfig) fiber.Handler {
+	return cors.New(cors.Config{
+		AllowOrigins:     strings.Join(cfg.AllowOrigins, ","),
+		AllowMethods:     strings.Join(cfg.AllowMethods, ","),
+		AllowHeaders:     strings.Join(cfg.AllowHeaders, ","),
+		MaxAge:           cfg.MaxAge,
+		AllowCredentials: cfg.AllowCredentials,
+	})
+}
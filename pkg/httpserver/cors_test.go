@@ -0,0 +1,90 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/pkg/logger"
+)
+
+func preflight(t *testing.T, app *fiber.App, origin string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodOptions, "/weather", nil)
+	req.Header.Set(fiber.HeaderOrigin, origin)
+	req.Header.Set(fiber.HeaderAccessControlRequestMethod, http.MethodGet)
+	req.Header.Set(fiber.HeaderAccessControlRequestHeaders, HeaderAPIKey)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	return resp
+}
+
+func TestCORS(t *testing.T) {
+	app := InitFiberServer("test-app", Options{CORS: CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.org"},
+		AllowMethods:     []string{http.MethodGet, http.MethodPost},
+		AllowHeaders:     []string{HeaderAPIKey, fiber.HeaderContentType},
+		MaxAge:           600,
+		AllowCredentials: true,
+	}}, logger.NopLogger{})
+	app.Get("/weather", func(c *fiber.Ctx) error { return c.SendString("sunny") })
+
+	tests := []struct {
+		name    string
+		origin  string
+		allowed bool
+	}{
+		{"listed origin", "https://app.example.com", true},
+		{"subdomain", "https://eu.example.org", true},
+		{"other origin", "https://evil.example.net", false},
+		{"other scheme", "http://app.example.com", false},
+		{"wildcard domain itself", "https://example.org", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := preflight(t, app, tt.origin)
+			assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+			if !tt.allowed {
+				assert.Empty(t, resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+				return
+			}
+			assert.Equal(t, tt.origin, resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+			assert.Equal(t, "GET,POST", resp.Header.Get(fiber.HeaderAccessControlAllowMethods))
+			assert.Equal(t, "X-API-Key,Content-Type", resp.Header.Get(fiber.HeaderAccessControlAllowHeaders))
+			assert.Equal(t, "600", resp.Header.Get(fiber.HeaderAccessControlMaxAge))
+			assert.Equal(t, "true", resp.Header.Get(fiber.HeaderAccessControlAllowCredentials))
+		})
+	}
+
+	// The simple requests of an allowed origin carry the header as well
+	req := httptest.NewRequest(http.MethodGet, "/weather", nil)
+	req.Header.Set(fiber.HeaderOrigin, "https://app.example.com")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "https://app.example.com", resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+}
+
+func TestCORS_AnyOrigin(t *testing.T) {
+	app := InitFiberServer("test-app", Options{CORS: CORSConfig{AllowOrigins: []string{"*"}}}, logger.NopLogger{})
+
+	resp := preflight(t, app, "https://anywhere.example.com")
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	assert.Equal(t, "*", resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+	assert.Equal(t, HeaderAPIKey, resp.Header.Get(fiber.HeaderAccessControlAllowHeaders))
+}
+
+func TestCORS_Disabled(t *testing.T) {
+	app := InitFiberServer("test-app", Options{}, logger.NopLogger{})
+	app.Get("/weather", func(c *fiber.Ctx) error { return c.SendString("sunny") })
+
+	resp := preflight(t, app, "https://app.example.com")
+	assert.Empty(t, resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+	assert.Empty(t, resp.Header.Get(fiber.HeaderAccessControlAllowMethods))
+}
@@ -0,0 +1,66 @@
+package httpserver
+
+import (
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// recentGCPauses is the number of GC pauses listed by /debug/stats
+const recentGCPauses = 10
+
+// RuntimeStats is the runtime state served by /debug/stats
+type RuntimeStats struct {
+	Goroutines     int     `json:"goroutines"`
+	HeapAllocBytes uint64  `json:"heap_alloc_bytes"`
+	HeapSysBytes   uint64  `json:"heap_sys_bytes"`
+	HeapObjects    uint64  `json:"heap_objects"`
+	NumGC          uint32  `json:"num_gc"`
+	GCPauseTotalMS float64 `json:"gc_pause_total_ms"`
+	// RecentGCPausesMS are the last GC pauses, the most recent first
+	RecentGCPausesMS []float64 `json:"recent_gc_pauses_ms"`
+}
+
+// mountDebug serves the net/http/pprof profiles under /debug/pprof and the runtime state on /debug/stats,
+// they expose the internals of the process and are only meant for development
+func mountDebug(app *fiber.App) {
+	debug := app.Group("/debug")
+	debug.Get("/pprof/cmdline", adaptor.HTTPHandlerFunc(pprof.Cmdline))
+	debug.Get("/pprof/profile", adaptor.HTTPHandlerFunc(pprof.Profile))
+	debug.Get("/pprof/symbol", adaptor.HTTPHandlerFunc(pprof.Symbol))
+	debug.Post("/pprof/symbol", adaptor.HTTPHandlerFunc(pprof.Symbol))
+	debug.Get("/pprof/trace", adaptor.HTTPHandlerFunc(pprof.Trace))
+	// Index serves the named profiles too, e.g. /debug/pprof/heap
+	debug.Get("/pprof/*", adaptor.HTTPHandlerFunc(pprof.Index))
+	debug.Get("/stats", func(c *fiber.Ctx) error {
+		return c.JSON(readRuntimeStats())
+	})
+}
+
+func readRuntimeStats() RuntimeStats {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+
+	stats := RuntimeStats{
+		Goroutines:       runtime.NumGoroutine(),
+		HeapAllocBytes:   m.HeapAlloc,
+		HeapSysBytes:     m.HeapSys,
+		HeapObjects:      m.HeapObjects,
+		NumGC:            m.NumGC,
+		GCPauseTotalMS:   durationMS(m.PauseTotalNs),
+		RecentGCPausesMS: []float64{},
+	}
+	// PauseNs is a circular buffer, the most recent pause is at (NumGC+255)%256
+	for i := range min(m.NumGC, recentGCPauses) {
+		stats.RecentGCPausesMS = append(stats.RecentGCPausesMS, durationMS(m.PauseNs[(m.NumGC-1-i)%uint32(len(m.PauseNs))]))
+	}
+
+	return stats
+}
+
+func durationMS(ns uint64) float64 {
+	return float64(time.Duration(ns)) / float64(time.Millisecond)
+}
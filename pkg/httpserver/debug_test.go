@@ -0,0 +1,48 @@
+package httpserver
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/pkg/logger"
+)
+
+func TestDebugEndpoints(t *testing.T) {
+	app := InitFiberServer("test-app", Options{Debug: true}, logger.NopLogger{})
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/cmdline", "/debug/pprof/heap?debug=1", "/debug/pprof/goroutine?debug=1"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, resp.StatusCode, path)
+	}
+
+	runtime.GC()
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/debug/stats", nil))
+	require.NoError(t, err)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+
+	var stats RuntimeStats
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&stats))
+	assert.Positive(t, stats.Goroutines)
+	assert.Positive(t, stats.HeapAllocBytes)
+	assert.Positive(t, stats.NumGC)
+	assert.NotEmpty(t, stats.RecentGCPausesMS)
+	assert.LessOrEqual(t, len(stats.RecentGCPausesMS), recentGCPauses)
+}
+
+func TestDebugEndpoints_Disabled(t *testing.T) {
+	app := InitFiberServer("test-app", Options{}, logger.NopLogger{})
+
+	for _, path := range []string{"/debug/pprof/", "/debug/pprof/heap", "/debug/stats"} {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusNotFound, resp.StatusCode, path)
+	}
+}
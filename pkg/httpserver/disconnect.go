@@ -0,0 +1,50 @@
+//go:build unix
+
+package httpserver
+
+import (
+	"crypto/tls"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// PeerClosed reports whether the client of conn closed the connection, it peeks at the socket so the bytes of a
+// pipelined request are left for the server. ok is false when conn can't be inspected, e.g. the in-memory
+// connections of the tests. A TLS client is only noticed once its close_notify was read.
+func PeerClosed(conn net.Conn) (closed, ok bool) {
+	if tlsConn, isTLS := conn.(*tls.Conn); isTLS {
+		conn = tlsConn.NetConn()
+	}
+	sc, isSyscall := conn.(syscall.Conn)
+	if !isSyscall {
+		return false, false
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return false, false
+	}
+
+	var n int
+	var peekErr error
+	buf := make([]byte, 1)
+	err = raw.Read(func(fd uintptr) bool {
+		n, _, peekErr = syscall.Recvfrom(int(fd), buf, syscall.MSG_PEEK|syscall.MSG_DONTWAIT)
+		// Never wait for the socket to be readable, the answer is needed now
+		return true
+	})
+	if err != nil {
+		return false, false
+	}
+
+	switch {
+	case errors.Is(peekErr, syscall.EAGAIN), errors.Is(peekErr, syscall.EINTR):
+		return false, true
+	case peekErr != nil:
+		// e.g. ECONNRESET
+		return true, true
+	}
+
+	// A read of 0 bytes is the end of the stream, pending bytes are the next request of the client
+	return n == 0, true
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package httpserver
+
+import "net"
+
+// PeerClosed reports whether the client of conn closed the connection, the connections are never inspected on
+// this platform so ok is always false
+func PeerClosed(conn net.Conn) (closed, ok bool) {
+	return false, false
+}
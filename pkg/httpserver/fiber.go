@@ -2,30 +2,99 @@ package httpserver
 
 import (
 	"encoding/json"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/healthcheck"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/prometheus/client_golang/prometheus"
+
+	"weather-api/pkg/logger"
 )
 
-func InitFiberServer(appName string) *fiber.App {
+// DefaultBodyLimit bounds the request bodies unless Options.BodyLimit is set, the largest ones are the batch and
+// GraphQL queries
+const DefaultBodyLimit = 1 << 20
+
+// Options configures the optional middlewares of the server, the zero value disables them
+type Options struct {
+	// ReadTimeout, WriteTimeout and IdleTimeout bound the connections, zero is unlimited
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// BodyLimit is the largest request body in bytes, zero selects DefaultBodyLimit
+	BodyLimit int
+	// TrustedProxy takes the client address of the request logger from X-Forwarded-For
+	TrustedProxy bool
+
+	AccessLog AccessLogConfig
+	Auth      APIKeyAuthConfig
+	RateLimit RateLimitConfig
+	// Compression compresses the response bodies, see Compress
+	Compression CompressionConfig
+	// CORS allows the cross-origin requests from its origins, without any the browsers only allow same-origin ones
+	CORS CORSConfig
+	// Debug serves the pprof profiles under /debug/pprof and the runtime state on /debug/stats
+	Debug bool
+	// Tracing records a span for every request, see Tracing
+	Tracing bool
+	// Metrics records the metrics of every request on the registry and serves it on MetricsPath, see Metrics
+	Metrics *prometheus.Registry
+	// Readiness answers /manage/ready, always ready when nil
+	Readiness *Readiness
+}
+
+// InitFiberServer creates the app with the middlewares shared by every route, the health probes and the metrics
+// are not logged, need no API key and are not rate limited
+func InitFiberServer(appName string, opts Options, l logger.Logger) *fiber.App {
+	bodyLimit := opts.BodyLimit
+	if bodyLimit <= 0 {
+		bodyLimit = DefaultBodyLimit
+	}
+
 	s := fiber.New(fiber.Config{
 		AppName:           appName,
 		JSONEncoder:       json.Marshal,
 		JSONDecoder:       json.Unmarshal,
-		BodyLimit:         500 * 1024 * 1024,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+		BodyLimit:         bodyLimit,
 		StreamRequestBody: true,
 	})
 
 	s.Use(recover.New(recover.Config{
 		EnableStackTrace: true,
 	}))
-	s.Use(cors.New())
+	s.Use(RequestID())
+	if opts.Tracing {
+		s.Use(Tracing())
+	}
+	if opts.Metrics != nil {
+		s.Use(Metrics(opts.Metrics))
+	}
+	s.Use(RequestLogger(l, opts.TrustedProxy))
+	if opts.Compression.Level != CompressionOff {
+		s.Use(Compress(opts.Compression))
+	}
+	if len(opts.CORS.AllowOrigins) > 0 {
+		s.Use(CORS(opts.CORS))
+	}
 	s.Use(healthcheck.New(healthcheck.Config{
 		LivenessEndpoint:  "/manage/health",
 		ReadinessEndpoint: "/manage/ready",
+		ReadinessProbe: func(c *fiber.Ctx) bool {
+			return opts.Readiness == nil || opts.Readiness.Check(c.UserContext()) == nil
+		},
 	}))
+	s.Use(AccessLog(opts.AccessLog, l))
+	// The rate limiter identifies the clients by the name of their key
+	s.Use(APIKeyAuth(opts.Auth, l))
+	s.Use(RateLimit(opts.RateLimit))
+
+	if opts.Debug {
+		mountDebug(s)
+	}
 
 	return s
 }
@@ -0,0 +1,83 @@
+package httpserver
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// MetricsPath serves the metrics of Options.Metrics in the Prometheus format
+const MetricsPath = "/metrics"
+
+// httpMetrics are the request rate, errors and duration of the API, labeled by route template rather than path
+// so the unknown paths and the path parameters don't multiply the series
+type httpMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	size     *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+}
+
+func newHTTPMetrics(reg prometheus.Registerer) *httpMetrics {
+	m := &httpMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests answered, by method, route and status.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "Time to answer the HTTP requests, by method, route and status.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route", "status"}),
+		size: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_response_size_bytes",
+			Help:    "Size of the HTTP response bodies, by method and route. Streamed responses are not observed.",
+			Buckets: prometheus.ExponentialBuckets(256, 4, 8),
+		}, []string{"method", "route"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "http_requests_in_flight",
+			Help: "HTTP requests being answered.",
+		}),
+	}
+	reg.MustRegister(m.requests, m.duration, m.size, m.inFlight)
+
+	return m
+}
+
+// Metrics records the RED metrics of every request on reg, and serves the metrics of reg on MetricsPath. The
+// metrics are readable without an API key, they carry no client data.
+func Metrics(reg *prometheus.Registry) fiber.Handler {
+	m := newHTTPMetrics(reg)
+	serve := adaptor.HTTPHandler(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+
+	return func(c *fiber.Ctx) error {
+		if c.Path() == MetricsPath && c.Method() == fiber.MethodGet {
+			return serve(c)
+		}
+
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			// Answer the error now to record the status it is answered with
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		method, route := c.Method(), c.Route().Path
+		status := strconv.Itoa(c.Response().StatusCode())
+		m.requests.WithLabelValues(method, route, status).Inc()
+		m.duration.WithLabelValues(method, route, status).Observe(time.Since(start).Seconds())
+		if !c.Response().IsBodyStream() {
+			m.size.WithLabelValues(method, route).Observe(float64(len(c.Response().Body())))
+		}
+
+		return nil
+	}
+}
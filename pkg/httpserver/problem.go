@@ -0,0 +1,19 @@
+package httpserver
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"weather-api/pkg/requestid"
+)
+
+// sendProblem answers with an RFC 7807 problem, in the shape of the problems of the routes
+func sendProblem(c *fiber.Ctx, status int, problemType, title, detail string) error {
+	return c.Status(status).JSON(fiber.Map{
+		"type":       problemType,
+		"title":      title,
+		"status":     status,
+		"detail":     detail,
+		"instance":   c.OriginalURL(),
+		"request_id": requestid.FromContext(c.UserContext()),
+	}, "application/problem+json")
+}
@@ -0,0 +1,178 @@
+package httpserver
+
+import (
+	"container/list"
+	"math"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// The rate limit headers set on every response, see RateLimit
+	HeaderRateLimitLimit     = "X-RateLimit-Limit"
+	HeaderRateLimitRemaining = "X-RateLimit-Remaining"
+	HeaderRateLimitReset     = "X-RateLimit-Reset"
+
+	defaultRateLimitClients = 10000
+)
+
+// RateLimitConfig configures the per-client limit of RateLimit
+type RateLimitConfig struct {
+	// RequestsPerMinute is the sustained rate of a client, zero disables the limit
+	RequestsPerMinute int
+	// Burst is the number of requests a client may send at once, zero selects RequestsPerMinute
+	Burst int
+	// MaxClients bounds the clients tracked at once, the least recently seen is forgotten first,
+	// zero selects 10000
+	MaxClients int
+	// TrustedProxy takes the client address from the X-Forwarded-For header, see ClientIP
+	TrustedProxy bool
+	// Key identifies the client of a request, nil selects the name of its API key, see APIKeyAuth,
+	// or its address
+	Key func(c *fiber.Ctx) string
+}
+
+// RateLimit limits the requests of every client with a token bucket of Burst tokens refilled at
+// RequestsPerMinute. Every response carries the X-RateLimit headers: the burst, the requests left and the
+// seconds until the bucket is full again. A client without tokens is answered 429 with Retry-After.
+func RateLimit(cfg RateLimitConfig) fiber.Handler {
+	if cfg.RequestsPerMinute <= 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return newRateLimiter(cfg, time.Now).handle
+}
+
+type rateLimiter struct {
+	// rate is the refill rate of the buckets, in tokens per second
+	rate  float64
+	burst float64
+	key   func(c *fiber.Ctx) string
+	now   func() time.Time
+
+	mu      sync.Mutex
+	buckets *buckets
+}
+
+func newRateLimiter(cfg RateLimitConfig, now func() time.Time) *rateLimiter {
+	l := &rateLimiter{
+		rate:    float64(cfg.RequestsPerMinute) / 60,
+		burst:   float64(cfg.Burst),
+		key:     cfg.Key,
+		now:     now,
+		buckets: newBuckets(cfg.MaxClients),
+	}
+	if cfg.Burst <= 0 {
+		l.burst = float64(cfg.RequestsPerMinute)
+	}
+	if l.key == nil {
+		l.key = func(c *fiber.Ctx) string {
+			if name := APIKeyName(c); name != "" {
+				return "key:" + name
+			}
+			return ClientIP(c, cfg.TrustedProxy)
+		}
+	}
+
+	return l
+}
+
+func (l *rateLimiter) handle(c *fiber.Ctx) error {
+	tokens, ok := l.take(l.key(c))
+
+	c.Set(HeaderRateLimitLimit, strconv.Itoa(int(l.burst)))
+	c.Set(HeaderRateLimitRemaining, strconv.Itoa(int(tokens)))
+	c.Set(HeaderRateLimitReset, strconv.Itoa(l.seconds(l.burst-tokens)))
+	if !ok {
+		c.Set(fiber.HeaderRetryAfter, strconv.Itoa(max(l.seconds(1-tokens), 1)))
+
+		return sendProblem(c, fiber.StatusTooManyRequests, "/problems/too-many-requests", "Too many requests",
+			"Rate limit exceeded, retry after the time given in Retry-After")
+	}
+
+	return c.Next()
+}
+
+// take refills the bucket of a client and takes a token from it, it returns the tokens left and whether
+// a token was available
+func (l *rateLimiter) take(key string) (float64, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	b, ok := l.buckets.get(key)
+	if !ok {
+		// The key may point into the request buffer, reused by the next request
+		b = &bucket{key: strings.Clone(key), tokens: l.burst, updated: now}
+		l.buckets.add(b)
+	}
+
+	b.tokens = min(b.tokens+now.Sub(b.updated).Seconds()*l.rate, l.burst)
+	b.updated = now
+	if b.tokens < 1 {
+		return b.tokens, false
+	}
+	b.tokens--
+
+	return b.tokens, true
+}
+
+// seconds returns the whole seconds needed to refill the given tokens
+func (l *rateLimiter) seconds(tokens float64) int {
+	if tokens <= 0 {
+		return 0
+	}
+
+	return int(math.Ceil(tokens / l.rate))
+}
+
+// bucket holds the tokens of a client
+type bucket struct {
+	key     string
+	tokens  float64
+	updated time.Time
+}
+
+// buckets keeps the most recently seen clients, the least recently seen is evicted beyond max
+type buckets struct {
+	max   int
+	order *list.List
+	items map[string]*list.Element
+}
+
+func newBuckets(maxClients int) *buckets {
+	if maxClients <= 0 {
+		maxClients = defaultRateLimitClients
+	}
+
+	return &buckets{max: maxClients, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+func (b *buckets) get(key string) (*bucket, bool) {
+	e, ok := b.items[key]
+	if !ok {
+		return nil, false
+	}
+	b.order.MoveToFront(e)
+
+	return e.Value.(*bucket), true
+}
+
+func (b *buckets) add(bk *bucket) {
+	b.items[bk.key] = b.order.PushFront(bk)
+	if b.order.Len() > b.max {
+		oldest := b.order.Back()
+		b.order.Remove(oldest)
+		delete(b.items, oldest.Value.(*bucket).key)
+	}
+}
+
+func (b *buckets) len() int {
+	return b.order.Len()
+}
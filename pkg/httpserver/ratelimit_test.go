@@ -0,0 +1,145 @@
+package httpserver
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock is moved forward by the tests
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func newRateLimitApp(cfg RateLimitConfig, clock *fakeClock) (*fiber.App, *rateLimiter) {
+	limiter := newRateLimiter(cfg, clock.Now)
+	app := fiber.New()
+	app.Use(limiter.handle)
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	return app, limiter
+}
+
+// get requests / as the client, it is identified by the X-Client header in these tests
+func get(t *testing.T, app *fiber.App, client string) *http.Response {
+	t.Helper()
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Client", client)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+
+	return resp
+}
+
+func clientHeader(c *fiber.Ctx) string {
+	return c.Get("X-Client")
+}
+
+func TestRateLimit_Enforced(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)}
+	app, _ := newRateLimitApp(RateLimitConfig{RequestsPerMinute: 6, Burst: 2, Key: clientHeader}, clock)
+
+	assert.Equal(t, fiber.StatusOK, get(t, app, "a").StatusCode)
+	assert.Equal(t, fiber.StatusOK, get(t, app, "a").StatusCode)
+
+	resp := get(t, app, "a")
+	assert.Equal(t, fiber.StatusTooManyRequests, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get(fiber.HeaderContentType))
+	// A token is refilled every 10 seconds
+	assert.Equal(t, "10", resp.Header.Get(fiber.HeaderRetryAfter))
+
+	// Other clients have their own bucket
+	assert.Equal(t, fiber.StatusOK, get(t, app, "b").StatusCode)
+
+	clock.now = clock.now.Add(10 * time.Second)
+	assert.Equal(t, fiber.StatusOK, get(t, app, "a").StatusCode)
+	assert.Equal(t, fiber.StatusTooManyRequests, get(t, app, "a").StatusCode)
+}
+
+func TestRateLimit_Headers(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)}
+	app, _ := newRateLimitApp(RateLimitConfig{RequestsPerMinute: 60, Burst: 3, Key: clientHeader}, clock)
+
+	tests := []struct {
+		advance   time.Duration
+		status    int
+		remaining string
+		reset     string
+	}{
+		{0, fiber.StatusOK, "2", "1"},
+		{0, fiber.StatusOK, "1", "2"},
+		{500 * time.Millisecond, fiber.StatusOK, "0", "3"},
+		{0, fiber.StatusTooManyRequests, "0", "3"},
+		{2 * time.Second, fiber.StatusOK, "1", "2"},
+		{time.Minute, fiber.StatusOK, "2", "1"},
+	}
+
+	for i, tt := range tests {
+		clock.now = clock.now.Add(tt.advance)
+		resp := get(t, app, "a")
+		assert.Equal(t, tt.status, resp.StatusCode, i)
+		assert.Equal(t, "3", resp.Header.Get(HeaderRateLimitLimit), i)
+		assert.Equal(t, tt.remaining, resp.Header.Get(HeaderRateLimitRemaining), i)
+		assert.Equal(t, tt.reset, resp.Header.Get(HeaderRateLimitReset), i)
+	}
+}
+
+func TestRateLimit_Eviction(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)}
+	app, limiter := newRateLimitApp(RateLimitConfig{RequestsPerMinute: 1, Burst: 1, MaxClients: 2, Key: clientHeader}, clock)
+
+	assert.Equal(t, fiber.StatusOK, get(t, app, "a").StatusCode)
+	assert.Equal(t, fiber.StatusOK, get(t, app, "b").StatusCode)
+	// a is seen again, b becomes the least recently seen
+	assert.Equal(t, fiber.StatusTooManyRequests, get(t, app, "a").StatusCode)
+	assert.Equal(t, fiber.StatusOK, get(t, app, "c").StatusCode)
+	assert.Equal(t, 2, limiter.buckets.len())
+
+	// b was forgotten and starts with a full bucket, a was kept
+	assert.Equal(t, fiber.StatusOK, get(t, app, "b").StatusCode)
+	assert.Equal(t, fiber.StatusTooManyRequests, get(t, app, "c").StatusCode)
+}
+
+func TestRateLimit_DefaultBurstAndKey(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2025, 7, 25, 12, 0, 0, 0, time.UTC)}
+	app, _ := newRateLimitApp(RateLimitConfig{RequestsPerMinute: 2, TrustedProxy: true}, clock)
+
+	forwardedFor := func(ip string) *http.Response {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set(fiber.HeaderXForwardedFor, "203.0.113.1, "+ip)
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	assert.Equal(t, "2", forwardedFor("198.51.100.7").Header.Get(HeaderRateLimitLimit))
+	assert.Equal(t, fiber.StatusOK, forwardedFor("198.51.100.7").StatusCode)
+	assert.Equal(t, fiber.StatusTooManyRequests, forwardedFor("198.51.100.7").StatusCode)
+	assert.Equal(t, fiber.StatusOK, forwardedFor("198.51.100.8").StatusCode)
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	app := fiber.New()
+	app.Use(RateLimit(RateLimitConfig{}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+
+	for range 100 {
+		resp := get(t, app, "a")
+		require.Equal(t, fiber.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get(HeaderRateLimitLimit))
+	}
+}
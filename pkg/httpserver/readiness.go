@@ -0,0 +1,44 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// ErrDraining is the readiness of a server shutting down
+var ErrDraining = errors.New("server is draining")
+
+// ReadinessCheck reports why the server can't serve requests, nil when it can
+type ReadinessCheck func(ctx context.Context) error
+
+// Readiness answers the readiness probe from its checks, Drain turns it down for good so the load balancers stop
+// routing requests before the server stops accepting them. The liveness probe is not affected.
+type Readiness struct {
+	checks   []ReadinessCheck
+	draining atomic.Bool
+}
+
+// NewReadiness returns a readiness passing when every check passes
+func NewReadiness(checks ...ReadinessCheck) *Readiness {
+	return &Readiness{checks: checks}
+}
+
+// Drain makes the server not ready whatever the checks
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// Check returns the error of the first failed check, ErrDraining once drained
+func (r *Readiness) Check(ctx context.Context) error {
+	if r.draining.Load() {
+		return ErrDraining
+	}
+	for _, check := range r.checks {
+		if err := check(ctx); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
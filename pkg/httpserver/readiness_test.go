@@ -0,0 +1,48 @@
+package httpserver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/pkg/logger"
+)
+
+func TestReadiness(t *testing.T) {
+	var checkErr error
+	readiness := NewReadiness(func(context.Context) error { return checkErr })
+	app := InitFiberServer("test-app", Options{Readiness: readiness}, logger.NopLogger{})
+
+	probe := func(path string) int {
+		resp, err := app.Test(httptest.NewRequest(http.MethodGet, path, nil))
+		require.NoError(t, err)
+		return resp.StatusCode
+	}
+
+	assert.Equal(t, fiber.StatusOK, probe("/manage/ready"))
+
+	checkErr = errors.New("no weather provider configured")
+	assert.Equal(t, fiber.StatusServiceUnavailable, probe("/manage/ready"))
+	assert.Equal(t, fiber.StatusOK, probe("/manage/health"))
+
+	// Once drained the server is not ready whatever the checks, it is still alive
+	checkErr = nil
+	readiness.Drain()
+	assert.ErrorIs(t, readiness.Check(context.Background()), ErrDraining)
+	assert.Equal(t, fiber.StatusServiceUnavailable, probe("/manage/ready"))
+	assert.Equal(t, fiber.StatusOK, probe("/manage/health"))
+}
+
+func TestReadiness_Default(t *testing.T) {
+	app := InitFiberServer("test-app", Options{}, logger.NopLogger{})
+
+	resp, err := app.Test(httptest.NewRequest(http.MethodGet, "/manage/ready", nil))
+	require.NoError(t, err)
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+}
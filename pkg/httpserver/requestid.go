@@ -0,0 +1,41 @@
+package httpserver
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+
+	"weather-api/pkg/logger"
+	"weather-api/pkg/requestid"
+)
+
+// RequestID accepts the X-Request-ID header of the client or generates a new one,
+// stores it in the user context and echoes it back in the response headers
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := utils.CopyString(c.Get(requestid.Header))
+		if !requestid.Valid(id) {
+			id = requestid.Generate()
+		}
+
+		c.Set(requestid.Header, id)
+		c.SetUserContext(requestid.NewContext(c.UserContext(), id))
+
+		return c.Next()
+	}
+}
+
+// RequestLogger stores a child of l in the user context, carrying the request ID, the method, the path and the
+// client address, the services log the request through it with logger.FromContext. It follows RequestID.
+func RequestLogger(l logger.Logger, trustedProxy bool) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		child := l.WithFields(map[string]any{
+			"request_id": requestid.FromContext(c.UserContext()),
+			"method":     c.Method(),
+			"path":       utils.CopyString(c.Path()),
+			"ip":         utils.CopyString(ClientIP(c, trustedProxy)),
+		})
+		c.SetUserContext(logger.NewContext(c.UserContext(), child))
+
+		return c.Next()
+	}
+}
@@ -0,0 +1,29 @@
+package httpserver
+
+import (
+	"net"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// StreamDeadline renews the write deadline of a streamed response. fasthttp sets the deadline of the WriteTimeout
+// of the server once per response, the body streams included, so a stream writing for longer would be cut.
+// Renewed before every write, a stream lives as long as it keeps writing while a stuck client is still dropped
+// after the write timeout.
+type StreamDeadline struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+// NewStreamDeadline is called by the handler, the connection is out of reach of the stream writer
+func NewStreamDeadline(c *fiber.Ctx) StreamDeadline {
+	return StreamDeadline{conn: c.Context().Conn(), timeout: c.App().Config().WriteTimeout}
+}
+
+// Renew gives the next write the full write timeout, it does nothing when the server has none
+func (d StreamDeadline) Renew() {
+	if d.timeout > 0 && d.conn != nil {
+		_ = d.conn.SetWriteDeadline(time.Now().Add(d.timeout))
+	}
+}
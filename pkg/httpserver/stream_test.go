@@ -0,0 +1,61 @@
+package httpserver
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/pkg/logger"
+)
+
+func TestStreamDeadline(t *testing.T) {
+	app := InitFiberServer("test-app", Options{WriteTimeout: 200 * time.Millisecond}, logger.NopLogger{})
+	stream := func(renew bool) fiber.Handler {
+		return func(c *fiber.Ctx) error {
+			deadline := NewStreamDeadline(c)
+			c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+				for i := range 6 {
+					if renew {
+						deadline.Renew()
+					}
+					_, _ = fmt.Fprintf(w, "line %d\n", i)
+					if err := w.Flush(); err != nil {
+						return
+					}
+					time.Sleep(100 * time.Millisecond)
+				}
+			})
+			return nil
+		}
+	}
+	app.Get("/renewed", stream(true))
+	app.Get("/fixed", stream(false))
+
+	// The write deadline is only set on a real connection
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(ln) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+
+	read := func(path string) string {
+		resp, err := http.Get("http://" + ln.Addr().String() + path)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	// The stream outlives the write timeout as long as it writes
+	assert.Equal(t, 6, strings.Count(read("/renewed"), "line"))
+	// Without renewing, the stream is cut by the write timeout
+	assert.Less(t, strings.Count(read("/fixed"), "line"), 6)
+}
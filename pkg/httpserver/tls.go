@@ -0,0 +1,97 @@
+package httpserver
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// TLSOptions selects the certificate of the HTTPS server, read from files or obtained from Let's Encrypt for the
+// AutocertHosts
+type TLSOptions struct {
+	CertFile string
+	KeyFile  string
+	// AutocertHosts are the host names certificates are requested for, they are kept in AutocertCacheDir
+	// across restarts
+	AutocertHosts    []string
+	AutocertCacheDir string
+}
+
+// TLS is the configuration of an HTTPS server
+type TLS struct {
+	Config *tls.Config
+	// manager obtains and renews the certificates in autocert mode, nil otherwise
+	manager *autocert.Manager
+}
+
+// NewTLS loads the certificate of the options, or prepares the certificates to be obtained on the first
+// handshakes in autocert mode. The certificate files are read once, a missing or invalid one fails here rather
+// than on the first connection.
+func NewTLS(opts TLSOptions) (*TLS, error) {
+	if len(opts.AutocertHosts) > 0 {
+		if opts.AutocertCacheDir == "" {
+			return nil, errors.New("autocert needs a cache directory for the certificates")
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(opts.AutocertCacheDir),
+			HostPolicy: autocert.HostWhitelist(opts.AutocertHosts...),
+		}
+		config := m.TLSConfig()
+		config.MinVersion = tls.VersionTLS12
+
+		return &TLS{Config: config, manager: m}, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(opts.CertFile, opts.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load the TLS certificate %s with the key %s: %w", opts.CertFile, opts.KeyFile, err)
+	}
+
+	return &TLS{Config: &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}}, nil
+}
+
+// Listener returns ln serving TLS
+func (t *TLS) Listener(ln net.Listener) net.Listener {
+	return tls.NewListener(ln, t.Config)
+}
+
+// RedirectHandler redirects the plain HTTP requests to the HTTPS server on port, and answers the challenges of
+// Let's Encrypt in autocert mode
+func (t *TLS) RedirectHandler(port string) http.Handler {
+	redirect := RedirectHTTPS(port)
+	if t.manager == nil {
+		return redirect
+	}
+
+	return t.manager.HTTPHandler(redirect)
+}
+
+// RedirectHTTPS redirects every request to the same URL on HTTPS and port, the port is left out when it is 443.
+// GET and HEAD are redirected with 301, the other methods with 308 so the clients resend their body.
+func RedirectHTTPS(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := (&url.URL{Host: r.Host}).Hostname()
+		switch {
+		case port != "443":
+			host = net.JoinHostPort(host, port)
+		case strings.Contains(host, ":"):
+			host = "[" + host + "]"
+		}
+
+		status := http.StatusPermanentRedirect
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			status = http.StatusMovedPermanently
+		}
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), status)
+	})
+}
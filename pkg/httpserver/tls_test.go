@@ -0,0 +1,129 @@
+package httpserver
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"weather-api/pkg/logger"
+)
+
+// writeSelfSignedCert writes a certificate of localhost and its key in dir, and returns their paths
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certFile, keyFile
+}
+
+func TestNewTLS(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+	server, err := NewTLS(TLSOptions{CertFile: certFile, KeyFile: keyFile})
+	require.NoError(t, err)
+
+	app := InitFiberServer("test-app", Options{}, logger.NopLogger{})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(server.Listener(ln)) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+
+	certPEM, err := os.ReadFile(certFile)
+	require.NoError(t, err)
+	roots := x509.NewCertPool()
+	require.True(t, roots.AppendCertsFromPEM(certPEM))
+	client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}}
+
+	resp, err := client.Get("https://" + ln.Addr().String() + "/manage/health")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.NotNil(t, resp.TLS)
+}
+
+func TestNewTLS_Errors(t *testing.T) {
+	dir := t.TempDir()
+	certFile, _ := writeSelfSignedCert(t, dir)
+
+	_, err := NewTLS(TLSOptions{CertFile: certFile, KeyFile: filepath.Join(dir, "missing.pem")})
+	assert.ErrorContains(t, err, "cannot load the TLS certificate "+certFile)
+
+	// The certificate is not a key
+	_, err = NewTLS(TLSOptions{CertFile: certFile, KeyFile: certFile})
+	assert.ErrorContains(t, err, "cannot load the TLS certificate")
+
+	_, err = NewTLS(TLSOptions{AutocertHosts: []string{"weather.example.com"}})
+	assert.EqualError(t, err, "autocert needs a cache directory for the certificates")
+}
+
+func TestNewTLS_Autocert(t *testing.T) {
+	server, err := NewTLS(TLSOptions{AutocertHosts: []string{"weather.example.com"}, AutocertCacheDir: t.TempDir()})
+	require.NoError(t, err)
+
+	// The challenges are answered on the redirection, the other requests are redirected
+	assert.Contains(t, server.Config.NextProtos, "acme-tls/1")
+	rec := httptest.NewRecorder()
+	server.RedirectHandler("443").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://weather.example.com/v1/weather", nil))
+	assert.Equal(t, http.StatusMovedPermanently, rec.Code)
+	assert.Equal(t, "https://weather.example.com/v1/weather", rec.Header().Get("Location"))
+}
+
+func TestRedirectHTTPS(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		target   string
+		port     string
+		status   int
+		location string
+	}{
+		{"default port", http.MethodGet, "http://example.com:8080/v1/weather?city=Berlin", "443", http.StatusMovedPermanently, "https://example.com/v1/weather?city=Berlin"},
+		{"other port", http.MethodHead, "http://example.com/manage/health", "8443", http.StatusMovedPermanently, "https://example.com:8443/manage/health"},
+		{"IPv6 host", http.MethodGet, "http://[::1]:8080/", "443", http.StatusMovedPermanently, "https://[::1]/"},
+		{"body kept", http.MethodPost, "http://example.com/v1/weather/batch", "443", http.StatusPermanentRedirect, "https://example.com/v1/weather/batch"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			RedirectHTTPS(tt.port).ServeHTTP(rec, httptest.NewRequest(tt.method, tt.target, nil))
+
+			assert.Equal(t, tt.status, rec.Code)
+			assert.Equal(t, tt.location, rec.Header().Get("Location"))
+		})
+	}
+}
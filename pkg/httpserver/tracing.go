@@ -0,0 +1,76 @@
+package httpserver
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"weather-api/pkg/requestid"
+)
+
+const tracerName = "weather-api/pkg/httpserver"
+
+// Tracing records a server span for every request with the global tracer provider, continuing the trace of the
+// client from its headers. The span is stored in the user context, the spans of the service and the providers
+// are its children. It follows RequestID.
+func Tracing() fiber.Handler {
+	tracer := otel.Tracer(tracerName)
+
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), headerCarrier{c})
+		ctx, span := tracer.Start(ctx, c.Method(), trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+			attribute.String("http.request.method", c.Method()),
+			attribute.String("url.path", c.Path()),
+			attribute.String("request_id", requestid.FromContext(c.UserContext())),
+		))
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		if err := c.Next(); err != nil {
+			span.RecordError(err)
+			// Answer the error now to record the status it is answered with
+			if err := c.App().ErrorHandler(c, err); err != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		// The route is known once matched, the span is named after it rather than the path
+		span.SetName(c.Method() + " " + c.Route().Path)
+		span.SetAttributes(attribute.String("http.route", c.Route().Path))
+
+		status := c.Response().StatusCode()
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		if status >= fiber.StatusInternalServerError {
+			span.SetStatus(codes.Error, "")
+		}
+		if !c.Response().IsBodyStream() {
+			span.SetAttributes(attribute.Int("http.response.body.size", len(c.Response().Body())))
+		}
+
+		return nil
+	}
+}
+
+// headerCarrier reads the trace context from the request headers
+type headerCarrier struct {
+	c *fiber.Ctx
+}
+
+func (h headerCarrier) Get(key string) string {
+	return h.c.Get(key)
+}
+
+func (h headerCarrier) Set(key, value string) {
+	h.c.Request().Header.Set(key, value)
+}
+
+func (h headerCarrier) Keys() []string {
+	var keys []string
+	h.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+
+	return keys
+}
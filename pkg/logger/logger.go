@@ -0,0 +1,158 @@
+package logger
+
+import (
+	"context"
+	"maps"
+	"os"
+	"sync"
+)
+
+// Logger is the logging the packages depend on, the fields of a line are in the first map
+type Logger interface {
+	Debug(msg string, fields ...map[string]any)
+	Info(msg string, fields ...map[string]any)
+	Warning(msg string, fields ...map[string]any)
+	Error(err error, fields ...map[string]any)
+	// Fatal logs then exits the process
+	Fatal(msg string, fields ...map[string]any)
+	// WithFields returns a logger adding the fields to every line
+	WithFields(fields map[string]any) Logger
+}
+
+type ctxKey struct{}
+
+// NewContext returns a copy of ctx carrying the logger of a request
+func NewContext(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext returns the logger stored in ctx, or fallback when there is none
+func FromContext(ctx context.Context, fallback Logger) Logger {
+	if ctx == nil {
+		return fallback
+	}
+	if l, ok := ctx.Value(ctxKey{}).(Logger); ok {
+		return l
+	}
+
+	return fallback
+}
+
+// mergeFields returns the fields of base overridden by the ones of fields, base is not modified
+func mergeFields(base, fields map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(fields))
+	maps.Copy(merged, base)
+	maps.Copy(merged, fields)
+
+	return merged
+}
+
+var (
+	_ Logger = (*ZapLogger)(nil)
+	_ Logger = NopLogger{}
+	_ Logger = (*TestLogger)(nil)
+)
+
+// NopLogger discards the logs, its Fatal still exits
+type NopLogger struct{}
+
+func (NopLogger) Debug(string, ...map[string]any)   {}
+func (NopLogger) Info(string, ...map[string]any)    {}
+func (NopLogger) Warning(string, ...map[string]any) {}
+func (NopLogger) Error(error, ...map[string]any)    {}
+func (NopLogger) Fatal(string, ...map[string]any)   { os.Exit(1) }
+
+func (l NopLogger) WithFields(map[string]any) Logger { return l }
+
+// The levels of the entries of a TestLogger
+const (
+	LevelDebug   = "debug"
+	LevelInfo    = "info"
+	LevelWarning = "warning"
+	LevelError   = "error"
+	LevelFatal   = "fatal"
+)
+
+// Entry is a line captured by a TestLogger, Message is the error message of the Error lines
+type Entry struct {
+	Level   string
+	Message string
+	Err     error
+	Fields  map[string]any
+}
+
+// TestLogger captures the logs for the assertions of the tests, it is safe for concurrent use. Its Fatal is
+// captured as well and doesn't exit.
+type TestLogger struct {
+	captured *captured
+	fields   map[string]any
+}
+
+// captured are the entries of a TestLogger, shared with the loggers derived from it
+type captured struct {
+	mu      sync.Mutex
+	entries []Entry
+}
+
+// NewTestLogger returns a logger capturing the logs
+func NewTestLogger() *TestLogger {
+	return &TestLogger{captured: &captured{}}
+}
+
+// WithFields returns a logger adding the fields to its entries, they are captured with the ones of l
+func (l *TestLogger) WithFields(fields map[string]any) Logger {
+	return &TestLogger{captured: l.captured, fields: mergeFields(l.fields, fields)}
+}
+
+func (l *TestLogger) Debug(msg string, fields ...map[string]any) {
+	l.add(Entry{Level: LevelDebug, Message: msg}, fields)
+}
+
+func (l *TestLogger) Info(msg string, fields ...map[string]any) {
+	l.add(Entry{Level: LevelInfo, Message: msg}, fields)
+}
+
+func (l *TestLogger) Warning(msg string, fields ...map[string]any) {
+	l.add(Entry{Level: LevelWarning, Message: msg}, fields)
+}
+
+func (l *TestLogger) Error(err error, fields ...map[string]any) {
+	l.add(Entry{Level: LevelError, Message: err.Error(), Err: err}, fields)
+}
+
+func (l *TestLogger) Fatal(msg string, fields ...map[string]any) {
+	l.add(Entry{Level: LevelFatal, Message: msg}, fields)
+}
+
+// Entries returns the captured lines in the order they were logged, including the ones of the derived loggers
+func (l *TestLogger) Entries() []Entry {
+	l.captured.mu.Lock()
+	defer l.captured.mu.Unlock()
+
+	return append([]Entry(nil), l.captured.entries...)
+}
+
+// Filter returns the captured lines of a level
+func (l *TestLogger) Filter(level string) []Entry {
+	var entries []Entry
+	for _, entry := range l.Entries() {
+		if entry.Level == level {
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+func (l *TestLogger) add(entry Entry, fields []map[string]any) {
+	switch {
+	case len(fields) > 0:
+		entry.Fields = mergeFields(l.fields, fields[0])
+	case len(l.fields) > 0:
+		entry.Fields = maps.Clone(l.fields)
+	}
+
+	l.captured.mu.Lock()
+	defer l.captured.mu.Unlock()
+	l.captured.entries = append(l.captured.entries, entry)
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Redacted replaces the secret values in the logs
+const Redacted = "REDACTED"
+
+// DefaultRedactedKeys are the field names whose values are never logged, matched case-insensitively
+var DefaultRedactedKeys = []string{"api_key", "apikey", "appid", "authorization", "token"}
+
+// secretParams matches the secret query parameters inside a string, e.g. the provider URL of an error
+var secretParams = regexp.MustCompile(`(?i)\b(api_?key|appid|key|token)=[^&\s"']+`)
+
+// redactor masks the values of the secret fields and the secret query parameters of the strings
+type redactor struct {
+	keys map[string]bool
+}
+
+func newRedactor(extra []string) *redactor {
+	keys := make(map[string]bool, len(DefaultRedactedKeys)+len(extra))
+	for _, key := range slices.Concat(DefaultRedactedKeys, extra) {
+		keys[strings.ToLower(key)] = true
+	}
+
+	return &redactor{keys: keys}
+}
+
+// value returns v with its secrets masked, the values without any are returned unchanged
+func (r *redactor) value(key string, v any) any {
+	if r.keys[strings.ToLower(key)] {
+		return Redacted
+	}
+
+	switch v := v.(type) {
+	case string:
+		return r.scrub(v)
+	case error:
+		// The errors of the HTTP client quote the URL of the request
+		if msg := v.Error(); strings.IndexByte(msg, '=') >= 0 {
+			return r.scrub(msg)
+		}
+	case map[string]any:
+		redacted := make(map[string]any, len(v))
+		for k, nested := range v {
+			redacted[k] = r.value(k, nested)
+		}
+		return redacted
+	case map[string]string:
+		redacted := make(map[string]string, len(v))
+		for k, nested := range v {
+			redacted[k] = r.value(k, nested).(string)
+		}
+		return redacted
+	case []string:
+		redacted := make([]string, len(v))
+		for i, s := range v {
+			redacted[i] = r.scrub(s)
+		}
+		return redacted
+	}
+
+	return v
+}
+
+// scrub masks the secret query parameters of s, the regexp only runs on the strings with a parameter
+func (r *redactor) scrub(s string) string {
+	if strings.IndexByte(s, '=') < 0 {
+		return s
+	}
+
+	return secretParams.ReplaceAllString(s, "${1}="+Redacted)
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRedactor_Value(t *testing.T) {
+	r := newRedactor([]string{"password"})
+
+	assert.Equal(t, Redacted, r.value("api_key", "secret"))
+	assert.Equal(t, Redacted, r.value("Authorization", "Bearer secret"))
+	assert.Equal(t, Redacted, r.value("password", "secret"))
+	assert.Equal(t, "mock", r.value("repo", "mock"))
+	assert.Equal(t, 42, r.value("status", 42))
+
+	assert.Equal(t,
+		"https://api.openweathermap.org/data?lat=52.5&appid=REDACTED&units=metric",
+		r.value("url", "https://api.openweathermap.org/data?lat=52.5&appid=secret&units=metric"))
+	assert.Equal(t,
+		`Get "https://api.weatherapi.com/v1/forecast.json?key=REDACTED&q=52.5,13.4": timeout`,
+		r.value("err", errors.New(`Get "https://api.weatherapi.com/v1/forecast.json?key=secret&q=52.5,13.4": timeout`)))
+
+	nested := map[string]any{
+		"provider": map[string]any{"name": "weatherapi", "token": "secret", "url": "/forecast?api_key=secret"},
+		"headers":  map[string]string{"Authorization": "Bearer secret", "Accept": "application/json"},
+		"urls":     []string{"/a?apikey=secret", "/b"},
+	}
+	assert.Equal(t, map[string]any{
+		"provider": map[string]any{"name": "weatherapi", "token": Redacted, "url": "/forecast?api_key=REDACTED"},
+		"headers":  map[string]string{"Authorization": Redacted, "Accept": "application/json"},
+		"urls":     []string{"/a?apikey=REDACTED", "/b"},
+	}, r.value("request", nested))
+	// The fields of the caller are not modified
+	assert.Equal(t, "secret", nested["provider"].(map[string]any)["token"])
+}
+
+func TestZapLogger_Redaction(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZapLoggerWithOptions("test-logger", Options{RedactKeys: []string{"password"}}, &buf).
+		WithFields(map[string]any{"token": "secret"})
+
+	l.Info("calling /forecast?key=secret", map[string]any{
+		"password": "secret",
+		"provider": map[string]any{"appid": "secret"},
+	})
+	l.Error(errors.New(`Get "/forecast?appid=secret": EOF`))
+	require.NoError(t, l.(*ZapLogger).Stop())
+
+	assert.NotContains(t, buf.String(), "secret")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	assert.Equal(t, "calling /forecast?key=REDACTED", line["msg"])
+	assert.Equal(t, Redacted, line["token"])
+	assert.Equal(t, map[string]any{"appid": Redacted}, line["provider"])
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+	assert.Equal(t, `Get "/forecast?appid=REDACTED": EOF`, line["error"])
+}
+
+func BenchmarkZapLogger_Fields(b *testing.B) {
+	var buf bytes.Buffer
+	l := NewZapLogger("bench-logger", &buf)
+	fields := map[string]any{"request_id": "abc", "repo": "open-meteo", "status": 200, "path": "/v1/weather"}
+
+	b.ReportAllocs()
+	for b.Loop() {
+		buf.Reset()
+		l.Info("fetched forecast", fields)
+	}
+}
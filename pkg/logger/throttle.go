@@ -0,0 +1,63 @@
+package logger
+
+import (
+	"sync"
+	"time"
+)
+
+// Throttle limits the lines logged for a repeated event, e.g. the failures of a provider which is down. The
+// first occurrence of an event is logged, then one in every occurrences or the first one after interval,
+// whichever comes first. Zero disables the limit. It is safe for concurrent use.
+type Throttle struct {
+	every    int
+	interval time.Duration
+	now      func() time.Time
+
+	mu     sync.Mutex
+	events map[string]*throttled
+}
+
+// throttled is the state of an event since it was last logged
+type throttled struct {
+	logged     time.Time
+	suppressed int
+}
+
+// NewThrottle returns a throttle logging one in every occurrences of an event, or at most once per interval
+func NewThrottle(every int, interval time.Duration) *Throttle {
+	return newThrottle(every, interval, time.Now)
+}
+
+func newThrottle(every int, interval time.Duration, now func() time.Time) *Throttle {
+	return &Throttle{every: every, interval: interval, now: now, events: make(map[string]*throttled)}
+}
+
+// Allow reports whether an occurrence of the event key is logged. When it is, suppressed is the number of
+// occurrences skipped since the event was last logged, elapsed ago.
+func (t *Throttle) Allow(key string) (ok bool, suppressed int, elapsed time.Duration) {
+	if t.every <= 0 && t.interval <= 0 {
+		return true, 0, 0
+	}
+
+	now := t.now()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	event, seen := t.events[key]
+	if !seen {
+		t.events[key] = &throttled{logged: now}
+		return true, 0, 0
+	}
+
+	elapsed = now.Sub(event.logged)
+	if (t.every > 0 && event.suppressed+1 >= t.every) || (t.interval > 0 && elapsed >= t.interval) {
+		suppressed = event.suppressed
+		event.logged, event.suppressed = now, 0
+		return true, suppressed, elapsed
+	}
+
+	event.suppressed++
+
+	return false, 0, 0
+}
@@ -0,0 +1,55 @@
+package logger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestThrottle_Every(t *testing.T) {
+	th := NewThrottle(10, 0)
+
+	var logged []int
+	for i := range 25 {
+		if ok, suppressed, _ := th.Allow("open-meteo"); ok {
+			logged = append(logged, i)
+			if i > 0 {
+				assert.Equal(t, 9, suppressed)
+			}
+		}
+	}
+	assert.Equal(t, []int{0, 10, 20}, logged)
+
+	// The events are throttled separately
+	ok, _, _ := th.Allow("weatherapi")
+	assert.True(t, ok)
+}
+
+func TestThrottle_Interval(t *testing.T) {
+	now := time.Date(2025, 6, 1, 12, 0, 0, 0, time.UTC)
+	th := newThrottle(0, time.Minute, func() time.Time { return now })
+
+	ok, _, _ := th.Allow("open-meteo")
+	assert.True(t, ok)
+	for range 411 {
+		now = now.Add(100 * time.Millisecond)
+		ok, _, _ = th.Allow("open-meteo")
+		assert.False(t, ok)
+	}
+
+	now = now.Add(time.Minute)
+	ok, suppressed, elapsed := th.Allow("open-meteo")
+	assert.True(t, ok)
+	assert.Equal(t, 411, suppressed)
+	assert.Equal(t, time.Minute+41100*time.Millisecond, elapsed)
+}
+
+func TestThrottle_Disabled(t *testing.T) {
+	th := NewThrottle(0, 0)
+	for range 5 {
+		ok, suppressed, _ := th.Allow("open-meteo")
+		assert.True(t, ok)
+		assert.Zero(t, suppressed)
+	}
+}
@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"errors"
 	"io"
 	"os"
 	"runtime"
@@ -8,19 +9,118 @@ import (
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
-type Logger struct {
+// ZapLogger writes the logs with zap
+type ZapLogger struct {
 	appEnv  string
 	appName string
 	l       *zap.Logger
+	// fields are added to every line, the fields of a line override them
+	fields map[string]any
+	redact *redactor
+	// file is the rotated file the lines are written to as well, nil without any
+	file io.Closer
 }
 
-func NewZapLogger(appName string, writers ...io.Writer) *Logger {
+const (
+	FormatJSON    = "json"
+	FormatConsole = "console"
+)
+
+// Options selects the lowest level logged, info when empty, and the format of the lines: json, the default, or
+// console, colored for reading in a terminal
+type Options struct {
+	Level  string
+	Format string
+	// Env is the environment of the application, logged as app_zone
+	Env string
+	// RedactKeys are the field names whose values are redacted in addition to DefaultRedactedKeys
+	RedactKeys []string
+	// SampleInitial is the number of identical lines, same level and message, logged every second before
+	// sampling starts, zero disables sampling. SampleThereafter is the share logged beyond it, one in
+	// SampleThereafter, zero drops them all.
+	SampleInitial    int
+	SampleThereafter int
+	// File writes the lines to a rotated file as well, when its path is set
+	File FileOptions
+}
+
+// FileOptions is the file the logs are written to, rotated beyond MaxSizeMB, 100 when zero. MaxBackups rotated
+// files are kept for MaxAgeDays days, zero keeps all of them, gzipped when Compress is set.
+type FileOptions struct {
+	Path       string
+	MaxSizeMB  int
+	MaxBackups int
+	MaxAgeDays int
+	Compress   bool
+}
+
+// NewZapLogger logs every level as JSON to the writers, stdout without any
+func NewZapLogger(appName string, writers ...io.Writer) *ZapLogger {
+	return newZapLogger(appName, zapcore.DebugLevel, Options{Format: FormatJSON}, writers)
+}
+
+// NewZapLoggerWithOptions logs from the level of opts in its format to the writers, stdout without any, and to the
+// file of opts. An invalid level falls back to info and an invalid format to JSON, with a warning.
+func NewZapLoggerWithOptions(appName string, opts Options, writers ...io.Writer) *ZapLogger {
+	var warnings []string
+
+	level := zapcore.InfoLevel
+	if opts.Level != "" {
+		parsed, err := zapcore.ParseLevel(opts.Level)
+		if err != nil {
+			warnings = append(warnings, "invalid log level "+opts.Level+", logging from info")
+		} else {
+			level = parsed
+		}
+	}
 
+	switch opts.Format {
+	case FormatJSON, FormatConsole:
+	case "":
+		opts.Format = FormatJSON
+	default:
+		warnings = append(warnings, "invalid log format "+opts.Format+", logging as json")
+		opts.Format = FormatJSON
+	}
+
+	var file *lumberjack.Logger
+	if opts.File.Path != "" {
+		file = &lumberjack.Logger{
+			Filename:   opts.File.Path,
+			MaxSize:    opts.File.MaxSizeMB,
+			MaxBackups: opts.File.MaxBackups,
+			MaxAge:     opts.File.MaxAgeDays,
+			Compress:   opts.File.Compress,
+		}
+		if len(writers) == 0 {
+			writers = append(writers, os.Stdout)
+		}
+		writers = append(writers, file)
+	}
+
+	l := newZapLogger(appName, level, opts, writers)
+	if file != nil {
+		l.file = file
+	}
+	for _, warning := range warnings {
+		l.Warning(warning)
+	}
+
+	return l
+}
+
+// newZapLogger builds the logger of a valid level and format
+func newZapLogger(appName string, level zapcore.Level, opts Options, writers []io.Writer) *ZapLogger {
 	var multiWriters []zapcore.WriteSyncer
 
 	cfg := zap.NewProductionEncoderConfig()
+	if opts.Format == FormatConsole {
+		cfg = zap.NewDevelopmentEncoderConfig()
+		cfg.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
 
 	cfg.EncodeTime = timeEncoder("2006-01-02T15-04-05.000", time.FixedZone("Europe/Rome", 3*3600))
 	cfg.TimeKey = "timestamp"
@@ -33,36 +133,47 @@ func NewZapLogger(appName string, writers ...io.Writer) *Logger {
 		}
 	}
 
+	encoder := zapcore.NewJSONEncoder(cfg)
+	if opts.Format == FormatConsole {
+		encoder = zapcore.NewConsoleEncoder(cfg)
+	}
+
 	core := zapcore.NewCore(
-		zapcore.NewJSONEncoder(cfg),
+		encoder,
 		zapcore.NewMultiWriteSyncer(multiWriters...),
-		zapcore.DebugLevel,
+		level,
 	)
+	if opts.SampleInitial > 0 {
+		core = zapcore.NewSamplerWithOptions(core, time.Second, opts.SampleInitial, opts.SampleThereafter)
+	}
 
-	return &Logger{
+	return &ZapLogger{
+		appEnv:  opts.Env,
 		appName: appName,
 		l:       zap.New(core),
+		redact:  newRedactor(opts.RedactKeys),
 	}
 }
 
-func (l *Logger) Stop() (err error) {
-	if err = l.l.Sync(); err != nil {
-		return
+// Stop flushes the buffered lines and closes the log file
+func (l *ZapLogger) Stop() error {
+	err := l.l.Sync()
+	if l.file != nil {
+		err = errors.Join(err, l.file.Close())
 	}
-	return
+
+	return err
 }
 
-func (l *Logger) Error(err error, fields ...map[string]any) {
+func (l *ZapLogger) Error(err error, fields ...map[string]any) {
 	file, line, funcName := getRuntimeParams()
-	zapFields := []zapcore.Field{}
-	if len(fields) > 0 {
-		zapFields = mapToZapFields(fields[0])
-	}
+	zapFields := l.zapFields(l.lineFields(fields))
+	msg := l.redact.scrub(err.Error())
 	l.l.WithOptions(zap.Fields(zapFields...)).Error(
-		err.Error(),
+		msg,
 		zap.String("app_zone", l.appEnv),
 		zap.String("app_name", l.appName),
-		zap.String("error", err.Error()),
+		zap.String("error", msg),
 		zap.String("caller_file", file),
 		zap.Int("caller_line", line),
 		zap.String("caller_func", funcName),
@@ -70,14 +181,11 @@ func (l *Logger) Error(err error, fields ...map[string]any) {
 	)
 }
 
-func (l *Logger) Info(msg string, fields ...map[string]any) {
+func (l *ZapLogger) Info(msg string, fields ...map[string]any) {
 	file, line, funcName := getRuntimeParams()
-	zapFields := []zapcore.Field{}
-	if len(fields) > 0 {
-		zapFields = mapToZapFields(fields[0])
-	}
+	zapFields := l.zapFields(l.lineFields(fields))
 	l.l.WithOptions(zap.Fields(zapFields...)).Info(
-		msg,
+		l.redact.scrub(msg),
 		zap.String("app_zone", l.appEnv),
 		zap.String("app_name", l.appName),
 		zap.Any("caller_file", file),
@@ -85,14 +193,11 @@ func (l *Logger) Info(msg string, fields ...map[string]any) {
 		zap.Any("caller_func", funcName))
 }
 
-func (l *Logger) Warning(msg string, fields ...map[string]any) {
+func (l *ZapLogger) Warning(msg string, fields ...map[string]any) {
 	file, line, funcName := getRuntimeParams()
-	zapFields := []zapcore.Field{}
-	if len(fields) > 0 {
-		zapFields = mapToZapFields(fields[0])
-	}
+	zapFields := l.zapFields(l.lineFields(fields))
 	l.l.WithOptions(zap.Fields(zapFields...)).Warn(
-		msg,
+		l.redact.scrub(msg),
 		zap.String("app_zone", l.appEnv),
 		zap.String("app_name", l.appName),
 		zap.Any("caller_file", file),
@@ -101,14 +206,11 @@ func (l *Logger) Warning(msg string, fields ...map[string]any) {
 
 }
 
-func (l *Logger) Debug(msg string, fields ...map[string]any) {
+func (l *ZapLogger) Debug(msg string, fields ...map[string]any) {
 	file, line, funcName := getRuntimeParams()
-	zapFields := []zapcore.Field{}
-	if len(fields) > 0 {
-		zapFields = mapToZapFields(fields[0])
-	}
+	zapFields := l.zapFields(l.lineFields(fields))
 	l.l.WithOptions(zap.Fields(zapFields...)).Debug(
-		msg,
+		l.redact.scrub(msg),
 		zap.String("app_zone", l.appEnv),
 		zap.String("app_name", l.appName),
 		zap.Any("caller_file", file),
@@ -116,14 +218,11 @@ func (l *Logger) Debug(msg string, fields ...map[string]any) {
 		zap.Any("caller_func", funcName))
 }
 
-func (l *Logger) Fatal(msg string, fields ...map[string]any) {
+func (l *ZapLogger) Fatal(msg string, fields ...map[string]any) {
 	file, line, funcName := getRuntimeParams()
-	zapFields := []zapcore.Field{}
-	if len(fields) > 0 {
-		zapFields = mapToZapFields(fields[0])
-	}
+	zapFields := l.zapFields(l.lineFields(fields))
 	l.l.WithOptions(zap.Fields(zapFields...)).Fatal(
-		msg,
+		l.redact.scrub(msg),
 		zap.String("app_zone", l.appEnv),
 		zap.String("app_name", l.appName),
 		zap.Any("caller_file", file),
@@ -131,13 +230,34 @@ func (l *Logger) Fatal(msg string, fields ...map[string]any) {
 		zap.Any("caller_func", funcName))
 }
 
-func (l *Logger) Log(keyvals ...any) error {
-	l.l.Info("", toZapFields(keyvals)...)
+// WithFields returns a logger adding the fields to every line, after the fields of l
+func (l *ZapLogger) WithFields(fields map[string]any) Logger {
+	child := *l
+	child.fields = mergeFields(l.fields, fields)
+
+	return &child
+}
+
+// lineFields returns the fields of a line, the ones of the logger overridden by the ones of the call
+func (l *ZapLogger) lineFields(fields []map[string]any) map[string]any {
+	if len(fields) == 0 {
+		return l.fields
+	}
+	if len(l.fields) == 0 {
+		return fields[0]
+	}
+
+	return mergeFields(l.fields, fields[0])
+}
+
+func (l *ZapLogger) Log(keyvals ...any) error {
+	l.l.Info("", l.keyvalsFields(keyvals)...)
 
 	return nil
 }
 
-func toZapFields(keyvals []any) []zap.Field {
+// keyvalsFields converts the alternating keys and values to redacted fields
+func (l *ZapLogger) keyvalsFields(keyvals []any) []zap.Field {
 	fields := make([]zap.Field, 0, len(keyvals)/2)
 
 	for i := 0; i < len(keyvals); i += 2 {
@@ -146,17 +266,18 @@ func toZapFields(keyvals []any) []zap.Field {
 			key = "invalid-key"
 		}
 
-		fields = append(fields, zap.Any(key, keyvals[i+1]))
+		fields = append(fields, zap.Any(key, l.redact.value(key, keyvals[i+1])))
 	}
 
 	return fields
 }
 
-func mapToZapFields(data map[string]any) []zap.Field {
+// zapFields converts the fields of a line, with the secret values redacted
+func (l *ZapLogger) zapFields(data map[string]any) []zap.Field {
 	zapFields := make([]zap.Field, 0, len(data))
 
 	for k, v := range data {
-		zapFields = append(zapFields, zap.Any(k, v))
+		zapFields = append(zapFields, zap.Any(k, l.redact.value(k, v)))
 	}
 
 	return zapFields
@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewZapLoggerWithOptions_Level(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZapLoggerWithOptions("test-logger", Options{Level: "info"}, &buf)
+
+	l.Debug("debug line")
+	l.Info("info line")
+	require.NoError(t, l.Stop())
+
+	assert.NotContains(t, buf.String(), "debug line")
+	assert.Contains(t, buf.String(), "info line")
+
+	buf.Reset()
+	l = NewZapLoggerWithOptions("test-logger", Options{Level: "warn"}, &buf)
+	l.Info("info line")
+	l.Warning("warning line")
+	require.NoError(t, l.Stop())
+
+	assert.NotContains(t, buf.String(), "info line")
+	assert.Contains(t, buf.String(), "warning line")
+}
+
+func TestNewZapLoggerWithOptions_InvalidLevel(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZapLoggerWithOptions("test-logger", Options{Level: "verbose"}, &buf)
+
+	l.Debug("debug line")
+	l.Info("info line")
+	require.NoError(t, l.Stop())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var warning map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &warning))
+	assert.Equal(t, "warn", warning["level"])
+	assert.Contains(t, warning["msg"], "invalid log level verbose")
+	assert.Contains(t, lines[1], "info line")
+}
+
+func TestNewZapLoggerWithOptions_Format(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZapLoggerWithOptions("test-logger", Options{Format: FormatConsole}, &buf)
+	l.Info("console line")
+	require.NoError(t, l.Stop())
+
+	assert.Contains(t, buf.String(), "console line")
+	assert.False(t, json.Valid(bytes.TrimSpace(buf.Bytes())))
+
+	buf.Reset()
+	l = NewZapLoggerWithOptions("test-logger", Options{Format: "xml"}, &buf)
+	l.Info("json line")
+	require.NoError(t, l.Stop())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[0], "invalid log format xml")
+	assert.True(t, json.Valid([]byte(lines[1])))
+}
+
+func TestNewZapLoggerWithOptions_Env(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZapLoggerWithOptions("test-logger", Options{Env: "production"}, &buf)
+	l.Info("env line")
+	l.WithFields(map[string]any{"request_id": "abc"}).Info("derived line")
+	require.NoError(t, l.Stop())
+
+	assert.Equal(t, 2, strings.Count(buf.String(), `"app_zone":"production"`))
+}
+
+func TestZapLogger_WithFields(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZapLogger("test-logger", &buf).WithFields(map[string]any{"request_id": "abc", "path": "/v1/weather"})
+
+	l.Info("fetched", map[string]any{"request_id": "abc", "repo": "mock"})
+	l.Info("no fields")
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	// A field of the logger repeated by the call is written once
+	assert.Equal(t, 1, strings.Count(lines[0], `"request_id"`))
+
+	var line map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &line))
+	assert.Equal(t, "/v1/weather", line["path"])
+	assert.Equal(t, "mock", line["repo"])
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &line))
+	assert.Equal(t, "abc", line["request_id"])
+}
+
+func TestNewZapLoggerWithOptions_Sampling(t *testing.T) {
+	var buf bytes.Buffer
+	l := NewZapLoggerWithOptions("test-logger", Options{SampleInitial: 5, SampleThereafter: 10}, &buf)
+
+	for range 100 {
+		l.Error(errors.New("open-meteo unreachable"))
+	}
+	l.Info("other line")
+	require.NoError(t, l.Stop())
+
+	// 5 lines, then one in 10 of the 95 remaining ones, and the other line
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	assert.Len(t, lines, 5+9+1)
+	assert.Contains(t, lines[len(lines)-1], "other line")
+}
+
+func TestNewZapLoggerWithOptions_File(t *testing.T) {
+	dir := t.TempDir()
+	var buf bytes.Buffer
+	l := NewZapLoggerWithOptions("test-logger", Options{
+		File: FileOptions{Path: filepath.Join(dir, "weather-api.log"), MaxSizeMB: 1, MaxBackups: 2},
+	}, &buf)
+
+	// Every line is about 1 KiB, 1500 of them rotate the file once
+	padding := strings.Repeat("x", 1000)
+	for i := range 1500 {
+		l.Info("forecast fetched", map[string]any{"n": i, "padding": padding})
+	}
+	require.NoError(t, l.Stop())
+
+	files, err := filepath.Glob(filepath.Join(dir, "weather-api-*.log"))
+	require.NoError(t, err)
+	assert.Len(t, files, 1)
+
+	current, err := os.ReadFile(filepath.Join(dir, "weather-api.log"))
+	require.NoError(t, err)
+	assert.Contains(t, string(current), `"n":1499`)
+	// The writers get every line as well
+	assert.Equal(t, 1500, strings.Count(buf.String(), "\n"))
+}
@@ -0,0 +1,71 @@
+// Package units converts the metric values reported by the providers to other unit systems
+package units
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	Metric   = "metric"
+	Imperial = "imperial"
+)
+
+// Parse validates a unit system name, an empty name selects the metric system
+func Parse(system string) (string, error) {
+	switch system {
+	case "", Metric:
+		return Metric, nil
+	case Imperial:
+		return Imperial, nil
+	}
+
+	return "", fmt.Errorf("unsupported units: %s, expected %s or %s", system, Metric, Imperial)
+}
+
+// Temperature converts a temperature in °C to the given system, rounded to one decimal
+func Temperature(celsius float64, system string) float64 {
+	if system != Imperial {
+		return celsius
+	}
+
+	return round(celsius*9/5+32, 1)
+}
+
+// TemperatureDifference converts a difference between two temperatures in °C to the given system, rounded to one decimal
+func TemperatureDifference(celsius float64, system string) float64 {
+	if system != Imperial {
+		return celsius
+	}
+
+	return round(celsius*9/5, 1)
+}
+
+// Speed converts a speed in m/s to the given system (mph), rounded to one decimal
+func Speed(ms float64, system string) float64 {
+	if system != Imperial {
+		return ms
+	}
+
+	return round(ms*2.236936, 1)
+}
+
+// Length converts a precipitation amount in mm to the given system (in), rounded to two decimals
+func Length(mm float64, system string) float64 {
+	if system != Imperial {
+		return mm
+	}
+
+	return round(mm/25.4, 2)
+}
+
+// round rounds half away from zero and never returns negative zero
+func round(v float64, decimals int) float64 {
+	p := math.Pow(10, float64(decimals))
+	r := math.Round(v*p) / p
+	if r == 0 {
+		return 0
+	}
+
+	return r
+}
@@ -0,0 +1,95 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{"", Metric, false},
+		{"metric", Metric, false},
+		{"imperial", Imperial, false},
+		{"kelvin", "", true},
+		{"Imperial", "", true},
+	}
+
+	for _, tt := range tests {
+		got, err := Parse(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Parse(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+		}
+		if got != tt.want {
+			t.Errorf("Parse(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestTemperature(t *testing.T) {
+	tests := []struct {
+		name    string
+		celsius float64
+		system  string
+		want    float64
+	}{
+		{"metric is unchanged", 21.37, Metric, 21.37},
+		{"freezing point", 0, Imperial, 32},
+		{"boiling point", 100, Imperial, 212},
+		{"scales cross", -40, Imperial, -40},
+		{"negative", -12.5, Imperial, 9.5},
+		{"rounds to one decimal", 22.52, Imperial, 72.5},
+		{"rounds half away from zero", -17.75, Imperial, 0.1},
+		{"no negative zero", -17.8, Imperial, 0},
+		{"below zero fahrenheit", -30.1, Imperial, -22.2},
+	}
+
+	for _, tt := range tests {
+		got := Temperature(tt.celsius, tt.system)
+		if got != tt.want || math.Signbit(got) != math.Signbit(tt.want) {
+			t.Errorf("%s: Temperature(%v, %s) = %v, want %v", tt.name, tt.celsius, tt.system, got, tt.want)
+		}
+	}
+}
+
+func TestTemperatureDifference(t *testing.T) {
+	tests := []struct {
+		celsius float64
+		system  string
+		want    float64
+	}{
+		{1.4, Metric, 1.4},
+		{0, Imperial, 0},
+		{1, Imperial, 1.8},
+		{2.5, Imperial, 4.5},
+	}
+
+	for _, tt := range tests {
+		if got := TemperatureDifference(tt.celsius, tt.system); got != tt.want {
+			t.Errorf("TemperatureDifference(%v, %s) = %v, want %v", tt.celsius, tt.system, got, tt.want)
+		}
+	}
+}
+
+func TestSpeedAndLength(t *testing.T) {
+	tests := []struct {
+		name string
+		got  float64
+		want float64
+	}{
+		{"speed metric", Speed(5.4, Metric), 5.4},
+		{"speed imperial", Speed(10, Imperial), 22.4},
+		{"length metric", Length(2.4, Metric), 2.4},
+		{"length imperial", Length(25.4, Imperial), 1},
+		{"length rounds to two decimals", Length(1.2, Imperial), 0.05},
+	}
+
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: got %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}